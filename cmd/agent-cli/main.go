@@ -14,6 +14,7 @@ import (
 	"github.com/tagus/agent-sdk-go/pkg/config"
 	"github.com/tagus/agent-sdk-go/pkg/interfaces"
 	"github.com/tagus/agent-sdk-go/pkg/llm/anthropic"
+	"github.com/tagus/agent-sdk-go/pkg/llm/gemini"
 	"github.com/tagus/agent-sdk-go/pkg/llm/ollama"
 	"github.com/tagus/agent-sdk-go/pkg/llm/openai"
 	"github.com/tagus/agent-sdk-go/pkg/llm/vllm"
@@ -508,7 +509,7 @@ func initConfig() {
 	reader := bufio.NewReader(os.Stdin)
 
 	// Provider selection
-	fmt.Print("Select LLM provider (openai/anthropic/vertex/ollama/vllm) [openai]: ")
+	fmt.Print("Select LLM provider (openai/anthropic/gemini/vertex/ollama/vllm) [openai]: ")
 	if input, _ := reader.ReadString('\n'); strings.TrimSpace(input) != "" {
 		config.Provider = strings.TrimSpace(input)
 	}
@@ -556,6 +557,8 @@ func initConfig() {
 		fmt.Println("   export OPENAI_API_KEY=your_api_key_here")
 	case "anthropic":
 		fmt.Println("   export ANTHROPIC_API_KEY=your_api_key_here")
+	case "gemini":
+		fmt.Println("   export GEMINI_API_KEY=your_api_key_here")
 	case "vertex":
 		fmt.Println("   export GOOGLE_APPLICATION_CREDENTIALS=path_to_service_account.json")
 	case "ollama":
@@ -1419,6 +1422,7 @@ func listProviders() {
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	fmt.Println("  openai     - OpenAI GPT models (requires OPENAI_API_KEY)")
 	fmt.Println("  anthropic  - Anthropic Claude models (requires ANTHROPIC_API_KEY)")
+	fmt.Println("  gemini     - Google Gemini models (requires GEMINI_API_KEY)")
 	fmt.Println("  ollama     - Local Ollama server (requires Ollama running on localhost:11434)")
 	fmt.Println("  vllm       - vLLM inference server (requires vLLM server running on localhost:8000)")
 }
@@ -1435,6 +1439,11 @@ func listModels() {
 	fmt.Println("  - claude-3-5-sonnet-20241022 (most capable)")
 	fmt.Println("  - claude-3-haiku-20240307 (fast)")
 	fmt.Println()
+	fmt.Println("Gemini:")
+	fmt.Println("  - gemini-2.5-pro (most capable)")
+	fmt.Println("  - gemini-2.5-flash (fast, cost-effective)")
+	fmt.Println("  - gemini-2.5-flash-lite (fastest)")
+	fmt.Println()
 	fmt.Println("Ollama (local):")
 	fmt.Println("  - llama3.2:latest")
 	fmt.Println("  - mistral:latest")
@@ -1589,6 +1598,8 @@ func getDefaultModel(provider string) string {
 		return "gpt-4o-mini"
 	case "anthropic":
 		return "claude-3-5-sonnet-20241022"
+	case "gemini":
+		return gemini.Gemini25Flash
 	case "ollama":
 		return "llama3.2:latest"
 	case "vllm":
@@ -1660,6 +1671,14 @@ func createLLM(config *CLIConfig) interfaces.LLM {
 		return anthropic.NewClient(apiKey,
 			anthropic.WithModel(config.Model))
 
+	case "gemini":
+		apiKey := os.Getenv("GEMINI_API_KEY")
+		if apiKey == "" {
+			log.Fatal("GEMINI_API_KEY environment variable is required for Gemini provider")
+		}
+		return gemini.NewClient(apiKey,
+			gemini.WithModel(config.Model))
+
 	case "ollama":
 		baseURL := os.Getenv("OLLAMA_BASE_URL")
 		if baseURL == "" {
@@ -24,7 +24,7 @@ func main() {
 
 	tokenLimit := guardrails.NewTokenLimit(
 		100,
-		nil, // Use simple token counter
+		nil, // Use the default token estimator
 		guardrails.RedactAction,
 		"end",
 	)
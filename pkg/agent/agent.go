@@ -9,6 +9,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/tagus/agent-sdk-go/pkg/approval"
+	"github.com/tagus/agent-sdk-go/pkg/cost"
 	"github.com/tagus/agent-sdk-go/pkg/executionplan"
 	"github.com/tagus/agent-sdk-go/pkg/grpc/client"
 	"github.com/tagus/agent-sdk-go/pkg/interfaces"
@@ -17,6 +19,8 @@ import (
 	"github.com/tagus/agent-sdk-go/pkg/mcp"
 	"github.com/tagus/agent-sdk-go/pkg/memory"
 	"github.com/tagus/agent-sdk-go/pkg/multitenancy"
+	"github.com/tagus/agent-sdk-go/pkg/retrieval"
+	"github.com/tagus/agent-sdk-go/pkg/tokens"
 	"github.com/tagus/agent-sdk-go/pkg/tools"
 )
 
@@ -52,6 +56,7 @@ type Agent struct {
 	memory               interfaces.Memory
 	datastore            interfaces.DataStore     // DataStore for persistent data storage (PostgreSQL, Supabase, etc.)
 	graphRAGStore        interfaces.GraphRAGStore // GraphRAG store for knowledge graph operations
+	retriever            *retrieval.Retriever     // Retriever for retrieval-augmented generation
 	tools                []interfaces.Tool
 	subAgents            []*Agent // Sub-agents that can be called as tools
 	orgID                string
@@ -72,11 +77,19 @@ type Agent struct {
 	lazyMCPConfigs       []LazyMCPConfig          // Lazy MCP server configurations
 	maxIterations        int                      // Maximum number of tool-calling iterations (default: 2)
 	streamConfig         *interfaces.StreamConfig // Streaming configuration for the agent
+	contextWindow        int                      // Context window of the model in tokens, looked up by model if zero
+	tokenCounter         tokens.Counter           // Counter used to fit the history into the context window
+	pricing              *cost.Pricing            // Prices of the models, the default prices if nil
+	budget               *cost.Budget             // Spend limits checked before each LLM call
+	downgradeLLM         interfaces.LLM           // LLM used once a downgrade budget is exceeded
+	validateToolArgs     bool                     // Whether tool arguments are validated against their schema
+	approver             *approval.Approver       // Approver of the tool calls that need approval
 
 	// Runtime configuration fields
-	memoryConfig   map[string]interface{} // Memory configuration from YAML
-	timeout        time.Duration          // Agent timeout from runtime config
-	metricsEnabled bool                   // Whether metrics are enabled
+	memoryConfig    map[string]interface{} // Memory configuration from YAML
+	retrievalConfig *RetrievalConfigYAML   // Retrieval configuration from YAML
+	timeout         time.Duration          // Agent timeout from runtime config
+	metricsEnabled  bool                   // Whether metrics are enabled
 
 	// Remote agent fields
 	isRemote      bool                      // Whether this is a remote agent
@@ -295,7 +308,7 @@ func WithAgentConfig(config AgentConfig, variables map[string]string) Option {
 				if toolConfig.Enabled != nil && !*toolConfig.Enabled {
 					continue // Skip disabled tools
 				}
-				tool, err := factory.CreateTool(toolConfig)
+				tools, err := factory.CreateTools(toolConfig)
 				if err != nil {
 					// Log warning but continue - don't fail agent creation for tool issues
 					if a.logger != nil {
@@ -307,7 +320,7 @@ func WithAgentConfig(config AgentConfig, variables map[string]string) Option {
 					}
 					continue
 				}
-				toolsToAdd = append(toolsToAdd, tool)
+				toolsToAdd = append(toolsToAdd, tools...)
 			}
 			// Deduplicate before adding to agent
 			a.tools = deduplicateTools(append(a.tools, toolsToAdd...))
@@ -318,6 +331,16 @@ func WithAgentConfig(config AgentConfig, variables map[string]string) Option {
 			a.memoryConfig = convertMemoryConfigYAMLToInterface(expandedConfig.Memory)
 		}
 
+		// Store retrieval config for later instantiation (after LLM is set)
+		if expandedConfig.Retrieval != nil && len(expandedConfig.Retrieval.Stores) > 0 {
+			a.retrievalConfig = expandedConfig.Retrieval
+		}
+
+		// Apply pricing and budget settings
+		if expandedConfig.Cost != nil {
+			applyCostConfig(a, expandedConfig.Cost)
+		}
+
 		// Apply runtime settings
 		if expandedConfig.Runtime != nil {
 			// TODO: Set log level if logger supports it when LogLevel is specified
@@ -491,6 +514,16 @@ func WithMaxIterations(maxIterations int) Option {
 	}
 }
 
+// WithToolArgumentValidation sets whether the arguments of tool calls are
+// validated against the schema of the tool before the tool is executed.
+// Invalid arguments are returned to the LLM with the expected schema so that
+// it can correct the call. Validation is enabled by default.
+func WithToolArgumentValidation(enabled bool) Option {
+	return func(a *Agent) {
+		a.validateToolArgs = enabled
+	}
+}
+
 // WithStreamConfig sets the streaming configuration for the agent
 func WithStreamConfig(config *interfaces.StreamConfig) Option {
 	return func(a *Agent) {
@@ -549,6 +582,7 @@ func NewAgent(options ...Option) (*Agent, error) {
 	agent := &Agent{
 		requirePlanApproval: true, // Default to requiring approval
 		maxIterations:       2,    // Default to 2 iterations (current behavior)
+		validateToolArgs:    true, // Default to validating tool arguments
 	}
 
 	for _, option := range options {
@@ -560,6 +594,11 @@ func NewAgent(options ...Option) (*Agent, error) {
 		agent.logger = logging.New()
 	}
 
+	// Tools that select their own calls that need approval need an approver
+	if agent.approver == nil && hasToolPolicy(agent.tools) {
+		agent.approver = approval.New()
+	}
+
 	// Create memory from config if specified and LLM is available
 	if agent.memoryConfig != nil && agent.llm != nil && agent.memory == nil {
 		memoryInstance, err := CreateMemoryFromConfig(agent.memoryConfig, agent.llm)
@@ -577,6 +616,18 @@ func NewAgent(options ...Option) (*Agent, error) {
 		}
 	}
 
+	// Create retriever from config if specified
+	if agent.retrievalConfig != nil && agent.retriever == nil {
+		retriever, err := createRetrieverFromConfig(agent.retrievalConfig, agent.llm)
+		if err != nil {
+			agent.logger.Warn(context.Background(), "Failed to create retriever from config", map[string]interface{}{
+				"error": err.Error(),
+			})
+		} else {
+			agent.retriever = retriever
+		}
+	}
+
 	// Different validation for local vs remote agents
 	if agent.isRemote {
 		return validateRemoteAgent(agent)
@@ -744,8 +795,11 @@ func (a *Agent) RunDetailed(ctx context.Context, input string) (*interfaces.Agen
 func (a *Agent) runInternal(ctx context.Context, input string, detailed bool) (*interfaces.AgentResponse, error) {
 	startTime := time.Now()
 
-	tracker := newUsageTracker(detailed)
+	// Budgets need the usage of every call, so tracking is always on with a budget
+	tracker := newUsageTracker(detailed || a.budget != nil)
 	ctx = withUsageTracker(ctx, tracker)
+	retrievalState := &retrievalState{}
+	ctx = withRetrievalState(ctx, retrievalState)
 
 	var response string
 	var err error
@@ -769,6 +823,7 @@ func (a *Agent) runInternal(ctx context.Context, input string, detailed bool) (*
 
 	tracker.setExecutionTime(time.Since(startTime).Milliseconds())
 	usage, execSummary, primaryModel := tracker.getResults()
+	runCost := tracker.getCost()
 
 	var execSum interfaces.ExecutionSummary
 	if execSummary != nil {
@@ -797,20 +852,27 @@ func (a *Agent) runInternal(ctx context.Context, input string, detailed bool) (*
 			executionDetails["total_tokens"] = usage.TotalTokens
 			executionDetails["reasoning_tokens"] = usage.ReasoningTokens
 		}
+		if runCost != nil {
+			executionDetails["cost"] = runCost.Total
+		}
 		log.Printf("[Agent SDK] Agent execution completed: %+v", executionDetails)
 	}
 
+	metadata := map[string]interface{}{
+		"agent_name":            a.name,
+		"execution_timestamp":   startTime.Unix(),
+		"execution_duration_ms": time.Since(startTime).Milliseconds(),
+	}
+	addRetrievalMetadata(metadata, retrievalState, response)
+
 	return &interfaces.AgentResponse{
 		Content:          response,
 		Usage:            usage,
+		Cost:             runCost,
 		AgentName:        a.name,
 		Model:            primaryModel,
 		ExecutionSummary: execSum,
-		Metadata: map[string]interface{}{
-			"agent_name":            a.name,
-			"execution_timestamp":   startTime.Unix(),
-			"execution_duration_ms": time.Since(startTime).Milliseconds(),
-		},
+		Metadata:         metadata,
 	}, nil
 }
 
@@ -818,11 +880,17 @@ func (a *Agent) runLocalWithTracking(ctx context.Context, input string) (string,
 	if a.orgID != "" {
 		ctx = multitenancy.WithOrgID(ctx, a.orgID)
 	}
+	ctx = approval.WithRun(ctx, a.name, input)
 
-	if a.memory != nil {
+	ctx, parts := takeContentParts(ctx)
+	ctx, resumed := takeResumedRun(ctx)
+
+	// The input of a resumed run is already in memory
+	if a.memory != nil && resumed == nil {
 		if err := a.memory.AddMessage(ctx, interfaces.Message{
 			Role:    interfaces.MessageRoleUser,
 			Content: input,
+			Parts:   parts,
 		}); err != nil {
 			return "", fmt.Errorf("failed to add user message to memory: %w", err)
 		}
@@ -878,7 +946,9 @@ func (a *Agent) runLocalWithTracking(ctx context.Context, input string) (string,
 		return a.runWithExecutionPlan(ctx, input)
 	}
 
-	return a.runWithoutExecutionPlanWithToolsTracked(ctx, input, allTools)
+	ctx, _ = a.retrieve(ctx, input)
+
+	return a.runWithoutExecutionPlanWithToolsTracked(ctx, input, allTools, parts, resumed)
 }
 
 func (a *Agent) RunWithAuth(ctx context.Context, input string, authToken string) (string, error) {
@@ -896,8 +966,11 @@ func (a *Agent) RunWithAuthDetailed(ctx context.Context, input string, authToken
 func (a *Agent) runWithAuthInternal(ctx context.Context, input string, authToken string, detailed bool) (*interfaces.AgentResponse, error) {
 	startTime := time.Now()
 
-	tracker := newUsageTracker(detailed)
+	// Budgets need the usage of every call, so tracking is always on with a budget
+	tracker := newUsageTracker(detailed || a.budget != nil)
 	ctx = withUsageTracker(ctx, tracker)
+	retrievalState := &retrievalState{}
+	ctx = withRetrievalState(ctx, retrievalState)
 
 	var response string
 	var err error
@@ -916,24 +989,29 @@ func (a *Agent) runWithAuthInternal(ctx context.Context, input string, authToken
 
 	tracker.setExecutionTime(time.Since(startTime).Milliseconds())
 	usage, execSummary, primaryModel := tracker.getResults()
+	runCost := tracker.getCost()
 
 	var execSum interfaces.ExecutionSummary
 	if execSummary != nil {
 		execSum = *execSummary
 	}
 
+	metadata := map[string]interface{}{
+		"agent_name":            a.name,
+		"execution_timestamp":   startTime.Unix(),
+		"execution_duration_ms": time.Since(startTime).Milliseconds(),
+		"auth_enabled":          true,
+	}
+	addRetrievalMetadata(metadata, retrievalState, response)
+
 	return &interfaces.AgentResponse{
 		Content:          response,
 		Usage:            usage,
+		Cost:             runCost,
 		AgentName:        a.name,
 		Model:            primaryModel,
 		ExecutionSummary: execSum,
-		Metadata: map[string]interface{}{
-			"agent_name":            a.name,
-			"execution_timestamp":   startTime.Unix(),
-			"execution_duration_ms": time.Since(startTime).Milliseconds(),
-			"auth_enabled":          true,
-		},
+		Metadata:         metadata,
 	}, nil
 }
 
@@ -1153,16 +1231,21 @@ func (a *Agent) createLazyMCPTools() []interfaces.Tool {
 	return lazyTools
 }
 
-func (a *Agent) runWithoutExecutionPlanWithToolsTracked(ctx context.Context, input string, tools []interfaces.Tool) (string, error) {
+func (a *Agent) runWithoutExecutionPlanWithToolsTracked(ctx context.Context, input string, tools []interfaces.Tool, parts []interfaces.ContentPart, resumed []interfaces.Message) (string, error) {
 	prompt := input
 
 	var response string
 	var err error
 
 	generateOptions := []interfaces.GenerateOption{}
-	if a.systemPrompt != "" {
-		fmt.Printf("[DEBUG] Using system prompt (length=%d):\n%s\n", len(a.systemPrompt), a.systemPrompt)
-		generateOptions = append(generateOptions, openai.WithSystemMessage(a.systemPrompt))
+	systemPrompt := a.systemPromptWithRetrieval(ctx)
+	if systemPrompt != "" {
+		// The retrieved documents are part of the prompt, so only its length is logged
+		a.logger.Debug(ctx, "Using system prompt", map[string]interface{}{
+			"agent":  a.name,
+			"length": len(systemPrompt),
+		})
+		generateOptions = append(generateOptions, openai.WithSystemMessage(systemPrompt))
 	} else {
 		fmt.Printf("[DEBUG] WARNING: No system prompt set for agent %s\n", a.name)
 	}
@@ -1179,48 +1262,70 @@ func (a *Agent) runWithoutExecutionPlanWithToolsTracked(ctx context.Context, inp
 
 	generateOptions = append(generateOptions, interfaces.WithMaxIterations(a.maxIterations))
 
-	if a.memory != nil {
-		generateOptions = append(generateOptions, interfaces.WithMemory(a.memory))
+	// Runs with tool approval keep their messages apart until they end
+	history := a.newRunHistory(input, parts, systemPrompt, tools, resumed)
+	if history != nil {
+		generateOptions = append(generateOptions, interfaces.WithMemory(history))
+	} else {
+		if a.memory != nil {
+			generateOptions = append(generateOptions, interfaces.WithMemory(a.historyMemory(systemPrompt, tools)))
+		}
+		generateOptions = append(generateOptions, a.contentPartsOption(parts)...)
 	}
 
 	tracker := getUsageTracker(ctx)
 
+	llm, err := a.llmForCall(ctx, tracker)
+	if err != nil {
+		return "", err
+	}
+	llm = a.metered(llm, tracker)
+
 	if len(tools) > 0 {
 		if tracker != nil {
 			for _, tool := range tools {
 				tracker.addToolCall(tool.Name())
 			}
 		}
+		tools = a.toolsForCall(tools)
 
 		if tracker != nil && tracker.detailed {
-			llmResp, err := a.llm.GenerateWithToolsDetailed(ctx, prompt, tools, generateOptions...)
+			llmResp, err := llm.GenerateWithToolsDetailed(ctx, prompt, tools, generateOptions...)
 			if err != nil {
-				return "", fmt.Errorf("failed to generate response: %w", err)
+				return "", a.generateError(ctx, err, history)
 			}
 			response = llmResp.Content
-			tracker.addLLMUsage(llmResp.Usage, llmResp.Model)
 		} else {
-			response, err = a.llm.GenerateWithTools(ctx, prompt, tools, generateOptions...)
+			response, err = llm.GenerateWithTools(ctx, prompt, tools, generateOptions...)
 			if err != nil {
-				return "", fmt.Errorf("failed to generate response: %w", err)
+				return "", a.generateError(ctx, err, history)
 			}
 		}
 	} else {
 		if tracker != nil && tracker.detailed {
-			llmResp, err := a.llm.GenerateDetailed(ctx, prompt, generateOptions...)
+			llmResp, err := llm.GenerateDetailed(ctx, prompt, generateOptions...)
 			if err != nil {
-				return "", fmt.Errorf("failed to generate response: %w", err)
+				return "", a.generateError(ctx, err, history)
 			}
 			response = llmResp.Content
-			tracker.addLLMUsage(llmResp.Usage, llmResp.Model)
 		} else {
-			response, err = a.llm.Generate(ctx, prompt, generateOptions...)
+			response, err = llm.Generate(ctx, prompt, generateOptions...)
 			if err != nil {
-				return "", fmt.Errorf("failed to generate response: %w", err)
+				return "", a.generateError(ctx, err, history)
 			}
 		}
 	}
 
+	// The tool-calling loop stops once a tool call of the run is suspended
+	if history != nil {
+		if err := a.suspendedRun(ctx, history.runMessages()); err != nil {
+			return "", err
+		}
+		if err := history.flush(ctx); err != nil {
+			return "", fmt.Errorf("failed to add run messages to memory: %w", err)
+		}
+	}
+
 	// Apply guardrails to output if available
 	if a.guardrails != nil {
 		guardedResponse, err := a.guardrails.ProcessOutput(ctx, response)
@@ -1243,6 +1348,20 @@ func (a *Agent) runWithoutExecutionPlanWithToolsTracked(ctx context.Context, inp
 	return response, nil
 }
 
+// generateError returns the error of a failed LLM call of a run, or the
+// SuspendedError of the run when the call stopped because a tool call was
+// suspended waiting for approval
+func (a *Agent) generateError(ctx context.Context, err error, history *runHistory) error {
+	if history != nil {
+		if suspended := a.suspendedRun(ctx, history.runMessages()); suspended != nil {
+			return suspended
+		}
+		// Keep the tool calls of the failed run, as without tool approval
+		_ = history.flush(ctx)
+	}
+	return fmt.Errorf("failed to generate response: %w", err)
+}
+
 // extractPlanAction attempts to extract a plan action from the user input
 // Returns taskID, action, and remaining input
 func (a *Agent) extractPlanAction(input string) (string, string, string) {
@@ -1707,6 +1826,19 @@ func (a *Agent) GetSystemPrompt() string {
 	return a.systemPrompt
 }
 
+// toolsForCall returns the tools passed to the LLM, wrapped to validate their
+// arguments unless validation is disabled, and to wait for approval when an
+// approver is set
+func (a *Agent) toolsForCall(toolList []interfaces.Tool) []interfaces.Tool {
+	if a.validateToolArgs {
+		toolList = tools.WithArgumentValidation(toolList)
+	}
+	if a.approver != nil {
+		toolList = a.approver.Wrap(toolList)
+	}
+	return toolList
+}
+
 // configureSubAgentTools configures sub-agent tools with logger and tracer from parent agent
 func (a *Agent) configureSubAgentTools() {
 	for _, tool := range a.tools {
@@ -0,0 +1,326 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/tagus/agent-sdk-go/pkg/approval"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/memory"
+	"github.com/tagus/agent-sdk-go/pkg/multitenancy"
+	"github.com/tagus/agent-sdk-go/pkg/tools"
+)
+
+// WithToolApproval pauses the tool calls selected by the policies of the
+// approver until they are approved. Streamed runs emit an
+// interfaces.AgentEventApprovalRequest event for each paused call. A run
+// that stops waiting is suspended and resumed by ResolveApproval, in this
+// process or in another one sharing the store of the approver.
+func WithToolApproval(approver *approval.Approver) Option {
+	return func(a *Agent) {
+		a.approver = approver
+	}
+}
+
+// hasToolPolicy reports whether a tool selects its own calls that need approval
+func hasToolPolicy(toolList []interfaces.Tool) bool {
+	for _, tool := range toolList {
+		if _, ok := approval.ToolPolicyOf(tool); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// GetApprover returns the tool approver of the agent, or nil if none is set
+func (a *Agent) GetApprover() *approval.Approver {
+	return a.approver
+}
+
+// ResolveApproval records the decision on a paused tool call. If a run is
+// waiting for the decision, that run applies it and ResolveApproval returns
+// with resumed false. Otherwise the suspended run is resumed here and its
+// output is returned with resumed true. Remote agents resolve the call on
+// the remote service.
+func (a *Agent) ResolveApproval(ctx context.Context, id string, decision approval.Decision) (output string, resumed bool, err error) {
+	if a.isRemote {
+		if a.remoteClient == nil {
+			return "", false, fmt.Errorf("remote client not initialized")
+		}
+		resp, err := a.remoteClient.ResolveToolApproval(ctx, id, string(decision.Action), decision.Arguments, decision.Reason)
+		if err != nil {
+			return "", false, fmt.Errorf("remote approval failed: %w", err)
+		}
+		if resp.Error != "" {
+			return "", false, fmt.Errorf("remote approval error: %s", resp.Error)
+		}
+		return resp.Output, resp.Resumed, nil
+	}
+	if a.approver == nil {
+		return "", false, fmt.Errorf("tool approval is not enabled for agent %s", a.name)
+	}
+
+	if err := a.approver.Resolve(ctx, id, decision); err != nil {
+		return "", false, err
+	}
+	req, err := a.approver.Get(ctx, id)
+	if err != nil {
+		return "", false, err
+	}
+	if req.Waiting {
+		return "", false, nil
+	}
+
+	output, err = a.ResumeApproval(ctx, id)
+	if errors.Is(err, approval.ErrAlreadyApplied) {
+		// The waiting run picked up the decision after all
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return output, true, nil
+}
+
+// ResumeApproval applies the decision on a tool call whose run was
+// suspended, and continues the tool-calling loop of the run with the result
+// of the call, executed with the approved or edited arguments
+func (a *Agent) ResumeApproval(ctx context.Context, id string) (string, error) {
+	if a.approver == nil {
+		return "", fmt.Errorf("tool approval is not enabled for agent %s", a.name)
+	}
+
+	req, err := a.approver.Get(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if req.Decision == nil {
+		return "", approval.ErrPending
+	}
+	if req.Waiting {
+		return "", fmt.Errorf("approval request %s is applied by a waiting run", id)
+	}
+
+	if req.OrgID != "" {
+		ctx = multitenancy.WithOrgID(ctx, req.OrgID)
+	}
+	if req.ConversationID != "" {
+		ctx = memory.WithConversationID(ctx, req.ConversationID)
+	}
+
+	tool := a.findTool(ctx, req.ToolName)
+	if tool == nil {
+		return "", fmt.Errorf("tool %s of approval request %s not found", req.ToolName, id)
+	}
+	if a.validateToolArgs {
+		tool = tools.WithArgumentValidation([]interfaces.Tool{tool})[0]
+	}
+
+	result, err := a.approver.Apply(ctx, id, tool)
+	if errors.Is(err, approval.ErrAlreadyApplied) {
+		return "", err
+	}
+
+	return a.Run(withResumedRun(ctx, a.resumedHistory(req, result, err)), req.Input)
+}
+
+// resumedHistory returns the message history that resumes the run of a
+// request. The result of the call replaces the error returned to the LLM when
+// the run was suspended, and the call carries the arguments it was executed
+// with. Calls missing from the history are added to it.
+func (a *Agent) resumedHistory(req *approval.Request, result string, err error) []interfaces.Message {
+	content := result
+	if err != nil {
+		content = fmt.Sprintf("Error: %v", err)
+	}
+	arguments := req.Arguments
+	if req.Decision.Action == approval.ActionEdit {
+		arguments = req.Decision.Arguments
+	}
+
+	history := append([]interfaces.Message(nil), req.History...)
+	for i := len(history) - 1; i >= 0; i-- {
+		message := history[i]
+		if message.Role != interfaces.MessageRoleTool || !strings.Contains(message.Content, req.ID) {
+			continue
+		}
+		history[i].Content = content
+		for j := range history[:i] {
+			for k, call := range history[j].ToolCalls {
+				if call.ID == message.ToolCallID {
+					history[j].ToolCalls[k].Arguments = arguments
+				}
+			}
+		}
+		return history
+	}
+
+	// Without memory the history starts with the input of the run
+	if len(history) == 0 && a.memory == nil {
+		history = append(history, interfaces.Message{Role: interfaces.MessageRoleUser, Content: req.Input})
+	}
+	callID := "call_" + strings.ReplaceAll(req.ID, "-", "")
+	return append(history,
+		interfaces.Message{
+			Role: interfaces.MessageRoleAssistant,
+			ToolCalls: []interfaces.ToolCall{{
+				ID:          callID,
+				Name:        req.ToolName,
+				DisplayName: req.DisplayName,
+				Arguments:   arguments,
+			}},
+		},
+		interfaces.Message{
+			Role:       interfaces.MessageRoleTool,
+			Content:    content,
+			ToolCallID: callID,
+			Metadata: map[string]interface{}{
+				"tool_name": req.ToolName,
+			},
+		},
+	)
+}
+
+const resumedRunKey contextKey = "resumedRun"
+
+// withResumedRun returns a context that continues a suspended run with the
+// message history of the run
+func withResumedRun(ctx context.Context, history []interfaces.Message) context.Context {
+	return context.WithValue(ctx, resumedRunKey, history)
+}
+
+// takeResumedRun returns the history of the run continued by the context and
+// a context without it, so that sub-agents do not continue it
+func takeResumedRun(ctx context.Context) (context.Context, []interfaces.Message) {
+	history, ok := ctx.Value(resumedRunKey).([]interfaces.Message)
+	if !ok || history == nil {
+		return ctx, nil
+	}
+	return context.WithValue(ctx, resumedRunKey, []interfaces.Message(nil)), history
+}
+
+// runHistory is the memory of the LLM calls of a run with tool approval. The
+// messages of the run are kept apart from the memory of the agent until the
+// run ends, so that a suspended run is saved with its tool calls and resumed
+// with the results of the approved calls.
+type runHistory struct {
+	memory   interfaces.Memory
+	mu       sync.Mutex
+	messages []interfaces.Message
+}
+
+// newRunHistory returns the memory of a run, or nil without tool approval.
+// Resumed runs continue with the messages of the suspended run.
+func (a *Agent) newRunHistory(input string, parts []interfaces.ContentPart, systemPrompt string, tools []interfaces.Tool, resumed []interfaces.Message) *runHistory {
+	if a.approver == nil {
+		return nil
+	}
+	history := &runHistory{memory: a.historyMemory(systemPrompt, tools)}
+	switch {
+	case resumed != nil:
+		history.messages = resumed
+	case a.memory == nil:
+		// Without memory the input is the first message of the run
+		history.messages = []interfaces.Message{{Role: interfaces.MessageRoleUser, Content: input, Parts: parts}}
+	}
+	return history
+}
+
+// AddMessage adds a message to the run
+func (h *runHistory) AddMessage(ctx context.Context, message interfaces.Message) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.messages = append(h.messages, message)
+	return nil
+}
+
+// GetMessages returns the messages of the memory of the agent followed by
+// the messages of the run
+func (h *runHistory) GetMessages(ctx context.Context, options ...interfaces.GetMessagesOption) ([]interfaces.Message, error) {
+	var messages []interfaces.Message
+	if h.memory != nil {
+		stored, err := h.memory.GetMessages(ctx, options...)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, stored...)
+	}
+	return append(messages, h.runMessages()...), nil
+}
+
+// Clear clears the messages of the run and the memory of the agent
+func (h *runHistory) Clear(ctx context.Context) error {
+	h.mu.Lock()
+	h.messages = nil
+	h.mu.Unlock()
+	if h.memory != nil {
+		return h.memory.Clear(ctx)
+	}
+	return nil
+}
+
+// runMessages returns the messages of the run
+func (h *runHistory) runMessages() []interfaces.Message {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]interfaces.Message(nil), h.messages...)
+}
+
+// flush adds the messages of the run to the memory of the agent
+func (h *runHistory) flush(ctx context.Context) error {
+	if h.memory == nil {
+		return nil
+	}
+	for _, message := range h.runMessages() {
+		if err := h.memory.AddMessage(ctx, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// suspendedRun returns the SuspendedError of a run whose tool call was
+// suspended, or nil. The messages of the run are saved with the approval
+// request, to resume the run with them.
+func (a *Agent) suspendedRun(ctx context.Context, history []interfaces.Message) error {
+	suspended := approval.Suspended(ctx)
+	if suspended == nil {
+		return nil
+	}
+	// The run may be suspended because its context is done
+	if err := a.approver.SaveHistory(context.WithoutCancel(ctx), suspended.ID, history); err != nil {
+		a.logger.Warn(ctx, "Failed to save the history of a suspended run", map[string]interface{}{
+			"agent":       a.name,
+			"approval_id": suspended.ID,
+			"error":       err.Error(),
+		})
+	}
+	return suspended
+}
+
+// findTool returns the tool of the agent with the given name, or nil
+func (a *Agent) findTool(ctx context.Context, name string) interfaces.Tool {
+	for _, tool := range a.tools {
+		if tool.Name() == name {
+			return tool
+		}
+	}
+	if len(a.mcpServers) > 0 {
+		if mcpTools, err := a.collectMCPTools(ctx); err == nil {
+			for _, tool := range mcpTools {
+				if tool.Name() == name {
+					return tool
+				}
+			}
+		}
+	}
+	for _, tool := range a.createLazyMCPTools() {
+		if tool.Name() == name {
+			return tool
+		}
+	}
+	return nil
+}
@@ -0,0 +1,338 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tagus/agent-sdk-go/pkg/approval"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/llm"
+	"github.com/tagus/agent-sdk-go/pkg/memory"
+	"github.com/tagus/agent-sdk-go/pkg/multitenancy"
+)
+
+// toolCallingLLM calls the first tool with fixed arguments and answers with
+// the result of the call. Like the LLM clients, it reads the conversation
+// from the memory of the call and adds the call and its result to it, and it
+// answers with the last tool result of a conversation that ends with one.
+type toolCallingLLM struct {
+	histories [][]interfaces.Message
+
+	// toolName and args select the call, the first tool is called with
+	// {"input":"rm -rf /tmp/cache"} by default
+	toolName string
+	args     string
+}
+
+func (m *toolCallingLLM) Generate(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (string, error) {
+	return "ok", nil
+}
+
+func (m *toolCallingLLM) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (string, error) {
+	params := &interfaces.GenerateOptions{}
+	for _, option := range options {
+		option(params)
+	}
+
+	if params.Memory != nil {
+		history, err := params.Memory.GetMessages(ctx)
+		if err != nil {
+			return "", err
+		}
+		m.histories = append(m.histories, history)
+		if len(history) > 0 && history[len(history)-1].Role == interfaces.MessageRoleTool {
+			return "tool result: " + history[len(history)-1].Content, nil
+		}
+	}
+
+	tool, args := tools[0], `{"input":"rm -rf /tmp/cache"}`
+	if m.args != "" {
+		args = m.args
+	}
+	for _, t := range tools {
+		if t.Name() == m.toolName {
+			tool = t
+		}
+	}
+	result, err := tool.Execute(ctx, args)
+	content := result
+	if err != nil {
+		content = "Error: " + err.Error()
+	}
+	if params.Memory != nil {
+		_ = params.Memory.AddMessage(ctx, interfaces.Message{
+			Role:      interfaces.MessageRoleAssistant,
+			ToolCalls: []interfaces.ToolCall{{ID: "call-1", Name: tool.Name(), Arguments: args}},
+		})
+		_ = params.Memory.AddMessage(ctx, interfaces.Message{
+			Role:       interfaces.MessageRoleTool,
+			Content:    content,
+			ToolCallID: "call-1",
+		})
+	}
+	if err != nil {
+		return "tool error: " + err.Error(), nil
+	}
+	return "tool result: " + result, nil
+}
+
+func (m *toolCallingLLM) GenerateDetailed(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (*interfaces.LLMResponse, error) {
+	content, err := m.Generate(ctx, prompt, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &interfaces.LLMResponse{Content: content}, nil
+}
+
+func (m *toolCallingLLM) GenerateWithToolsDetailed(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (*interfaces.LLMResponse, error) {
+	content, err := m.GenerateWithTools(ctx, prompt, tools, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &interfaces.LLMResponse{Content: content}, nil
+}
+
+func (m *toolCallingLLM) Name() string {
+	return "tool-calling-mock"
+}
+
+func (m *toolCallingLLM) SupportsStreaming() bool {
+	return false
+}
+
+func TestAgent_ToolApprovalSuspendAndResume(t *testing.T) {
+	for _, withMemory := range []bool{false, true} {
+		name := "WithoutMemory"
+		if withMemory {
+			name = "WithMemory"
+		}
+		t.Run(name, func(t *testing.T) {
+			var executed []string
+			tool := &mockTool{
+				name:        "shell",
+				description: "Runs a shell command",
+				runFunc: func(ctx context.Context, input string) (string, error) {
+					executed = append(executed, input)
+					return "done", nil
+				},
+			}
+
+			approver := approval.New(
+				approval.WithPolicy("shell", approval.WhenArgument("input", `rm -rf`)),
+				approval.WithTimeout(20*time.Millisecond),
+				approval.WithPollInterval(5*time.Millisecond),
+			)
+			llm := &toolCallingLLM{}
+			options := []Option{
+				WithName("ops"),
+				WithLLM(llm),
+				WithTools(tool),
+				WithToolApproval(approver),
+				WithRequirePlanApproval(false),
+			}
+			mem := memory.NewConversationBuffer()
+			if withMemory {
+				options = append(options, WithMemory(mem))
+			}
+			agent, err := NewAgent(options...)
+			require.NoError(t, err)
+
+			ctx := multitenancy.WithOrgID(context.Background(), "org-1")
+			ctx = memory.WithConversationID(ctx, "conv-1")
+			_, err = agent.Run(ctx, "clear the cache")
+			assert.ErrorIs(t, err, interfaces.ErrRunSuspended)
+			var suspended *approval.SuspendedError
+			require.ErrorAs(t, err, &suspended)
+			assert.Equal(t, "shell", suspended.Tool)
+			assert.Empty(t, executed)
+
+			id := suspended.ID
+			req, err := approver.Get(context.Background(), id)
+			require.NoError(t, err)
+			assert.Equal(t, "ops", req.AgentName)
+			assert.Equal(t, "clear the cache", req.Input)
+			assert.False(t, req.Waiting)
+			require.NotEmpty(t, req.History)
+			assert.Contains(t, req.History[len(req.History)-1].Content, id)
+
+			if withMemory {
+				// The suspended call is not added to the memory
+				stored, err := mem.GetMessages(ctx)
+				require.NoError(t, err)
+				require.Len(t, stored, 1)
+				assert.Equal(t, "clear the cache", stored[0].Content)
+			}
+
+			output, resumed, err := agent.ResolveApproval(context.Background(), id, approval.Decision{
+				Action:    approval.ActionEdit,
+				Arguments: `{"input":"rm -rf /tmp/cache/old"}`,
+			})
+			require.NoError(t, err)
+			assert.True(t, resumed)
+			assert.Equal(t, []string{`{"input":"rm -rf /tmp/cache/old"}`}, executed)
+			assert.Equal(t, "tool result: done", output)
+
+			// The run continues with the executed call and its result
+			history := llm.histories[len(llm.histories)-1]
+			require.GreaterOrEqual(t, len(history), 3)
+			assert.Equal(t, interfaces.MessageRoleUser, history[0].Role)
+			assert.Equal(t, "clear the cache", history[0].Content)
+			call, result := history[len(history)-2], history[len(history)-1]
+			require.Len(t, call.ToolCalls, 1)
+			assert.Equal(t, "call-1", call.ToolCalls[0].ID)
+			assert.Equal(t, `{"input":"rm -rf /tmp/cache/old"}`, call.ToolCalls[0].Arguments)
+			assert.Equal(t, "call-1", result.ToolCallID)
+			assert.Equal(t, "done", result.Content)
+
+			if withMemory {
+				stored, err := mem.GetMessages(ctx)
+				require.NoError(t, err)
+				require.Len(t, stored, 4)
+				assert.Equal(t, "clear the cache", stored[0].Content)
+				assert.Equal(t, "call-1", stored[1].ToolCalls[0].ID)
+				assert.Equal(t, "done", stored[2].Content)
+				assert.Equal(t, "tool result: done", stored[3].Content)
+			}
+
+			// The decision is applied only once
+			_, _, err = agent.ResolveApproval(context.Background(), id, approval.Decision{Action: approval.ActionApprove})
+			assert.ErrorIs(t, err, approval.ErrAlreadyDecided)
+		})
+	}
+}
+
+// approvalLoopLLM runs a tool-calling loop that calls the first tool in each
+// round-trip and reports the round-trips to the call hook, as the LLM clients do
+type approvalLoopLLM struct {
+	toolCallingLLM
+	requests int
+}
+
+func (m *approvalLoopLLM) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (string, error) {
+	params := &interfaces.GenerateOptions{}
+	for _, option := range options {
+		option(params)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := llm.BeforeCall(ctx, params); err != nil {
+			return "", err
+		}
+		m.requests++
+		// The LLM asks for the call again after each result
+		_, _ = tools[0].Execute(ctx, `{"input":"rm -rf /tmp/cache"}`)
+	}
+	return "done", nil
+}
+
+func (m *approvalLoopLLM) GenerateWithToolsDetailed(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (*interfaces.LLMResponse, error) {
+	content, err := m.GenerateWithTools(ctx, prompt, tools, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &interfaces.LLMResponse{Content: content}, nil
+}
+
+func TestAgent_ToolApprovalStopsToolLoop(t *testing.T) {
+	tool := &mockTool{name: "shell", description: "Runs a shell command"}
+	approver := approval.New(
+		approval.WithPolicy("shell", approval.Always()),
+		approval.WithTimeout(time.Millisecond),
+		approval.WithPollInterval(time.Millisecond),
+	)
+	mock := &approvalLoopLLM{}
+	agent, err := NewAgent(WithLLM(mock), WithTools(tool), WithToolApproval(approver), WithRequirePlanApproval(false))
+	require.NoError(t, err)
+
+	_, err = agent.Run(context.Background(), "clear the cache")
+	var suspended *approval.SuspendedError
+	require.ErrorAs(t, err, &suspended)
+	assert.Equal(t, 1, mock.requests, "no request is sent once the call is suspended")
+
+	req, err := approver.Get(context.Background(), suspended.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "shell", req.ToolName)
+}
+
+func TestAgent_ToolApprovalNotRequired(t *testing.T) {
+	tool := &mockTool{name: "shell", description: "Runs a shell command"}
+	approver := approval.New(approval.WithPolicy("shell", approval.WhenArgument("input", `^sudo`)))
+
+	agent, err := NewAgent(WithLLM(&toolCallingLLM{}), WithTools(tool), WithToolApproval(approver), WithRequirePlanApproval(false))
+	require.NoError(t, err)
+
+	output, err := agent.Run(context.Background(), "clear the cache")
+	require.NoError(t, err)
+	assert.Equal(t, `tool result: tool shell executed with: {"input":"rm -rf /tmp/cache"}`, output)
+}
+
+func TestAgent_FilesystemToolsFromConfig(t *testing.T) {
+	config := AgentConfig{
+		Role: "Maintainer",
+		Tools: []ToolConfigYAML{{
+			Type: "builtin",
+			Name: "filesystem",
+			Config: map[string]interface{}{
+				"root":             t.TempDir(),
+				"read_only":        false,
+				"require_approval": []interface{}{"delete"},
+			},
+		}},
+	}
+
+	agent, err := NewAgent(WithLLM(&toolCallingLLM{}), WithAgentConfig(config, nil), WithRequirePlanApproval(false))
+	require.NoError(t, err)
+
+	var names []string
+	for _, tool := range agent.GetTools() {
+		names = append(names, tool.Name())
+	}
+	assert.Equal(t, []string{"list_files", "read_file", "search_files", "write_file", "patch_file", "move_file", "delete_file"}, names)
+
+	// The delete tool requires approval, so the agent gets an approver
+	require.NotNil(t, agent.GetApprover())
+
+	_, err = NewToolFactory().CreateTool(config.Tools[0])
+	assert.Error(t, err)
+}
+
+func TestAgent_FilesystemApprovalWithArgumentValidation(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "notes.txt"), []byte("keep"), 0o600))
+	config := AgentConfig{
+		Role: "Maintainer",
+		Tools: []ToolConfigYAML{{
+			Type: "builtin",
+			Name: "filesystem",
+			Config: map[string]interface{}{
+				"root":             root,
+				"require_approval": []interface{}{"delete"},
+			},
+		}},
+	}
+
+	llm := &toolCallingLLM{toolName: "delete_file", args: `{"path":"notes.txt"}`}
+	agent, err := NewAgent(WithLLM(llm), WithAgentConfig(config, nil), WithRequirePlanApproval(false))
+	require.NoError(t, err)
+
+	// The approver of the agent waits until the context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = agent.Run(ctx, "delete the notes")
+	assert.ErrorIs(t, err, interfaces.ErrRunSuspended)
+	var suspended *approval.SuspendedError
+	require.ErrorAs(t, err, &suspended)
+	assert.Equal(t, "delete_file", suspended.Tool)
+
+	req, err := agent.GetApprover().Get(context.Background(), suspended.ID)
+	require.NoError(t, err)
+	assert.Nil(t, req.Decision)
+	assert.JSONEq(t, `{"path":"notes.txt"}`, req.Arguments)
+	assert.FileExists(t, filepath.Join(root, "notes.txt"))
+}
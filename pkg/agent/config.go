@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/tagus/agent-sdk-go/pkg/cost"
 	"github.com/tagus/agent-sdk-go/pkg/interfaces"
 	"gopkg.in/yaml.v3"
 )
@@ -55,6 +56,12 @@ type AgentConfig struct {
 	// NEW: Memory configuration (config only)
 	Memory *MemoryConfigYAML `yaml:"memory,omitempty"`
 
+	// Retrieval-augmented generation from knowledge vector stores
+	Retrieval *RetrievalConfigYAML `yaml:"retrieval,omitempty"`
+
+	// Model prices and spend limits
+	Cost *CostConfigYAML `yaml:"cost,omitempty"`
+
 	// NEW: Runtime settings
 	Runtime *RuntimeConfigYAML `yaml:"runtime,omitempty"`
 
@@ -119,6 +126,38 @@ type MemoryConfigYAML struct {
 	Config map[string]interface{} `yaml:"config,omitempty"`
 }
 
+// RetrievalConfigYAML represents retrieval-augmented generation settings in YAML.
+// Each store uses the same keys as vector memory config, plus optional "class"
+// and "tenant" keys to scope searches.
+type RetrievalConfigYAML struct {
+	Stores       []map[string]interface{} `yaml:"stores"`
+	TopK         *int                     `yaml:"top_k,omitempty"`
+	MaxDocuments *int                     `yaml:"max_documents,omitempty"`
+	MinScore     *float64                 `yaml:"min_score,omitempty"`
+	TokenBudget  *int                     `yaml:"token_budget,omitempty"`
+	RewriteQuery *bool                    `yaml:"rewrite_query,omitempty"`
+	Instructions string                   `yaml:"instructions,omitempty"`
+}
+
+// CostConfigYAML represents pricing and budget settings in YAML. Prices
+// override the default prices by model name prefix, optionally qualified by
+// the provider as "provider/model".
+type CostConfigYAML struct {
+	Pricing map[string]cost.Price `yaml:"pricing,omitempty"`
+	Budget  *BudgetConfigYAML     `yaml:"budget,omitempty"`
+}
+
+// BudgetConfigYAML represents spend limits in US dollars in YAML. The store
+// uses the keys of cost.NewStoreFromConfig.
+type BudgetConfigYAML struct {
+	PerRun          float64                `yaml:"per_run,omitempty"`
+	PerConversation float64                `yaml:"per_conversation,omitempty"`
+	PerOrgDaily     float64                `yaml:"per_org_daily,omitempty"`
+	Action          string                 `yaml:"action,omitempty"` // "abort", "downgrade"
+	DowngradeModel  *LLMProviderYAML       `yaml:"downgrade_model,omitempty"`
+	Store           map[string]interface{} `yaml:"store,omitempty"`
+}
+
 // RuntimeConfigYAML represents runtime behavior settings in YAML
 type RuntimeConfigYAML struct {
 	LogLevel        string `yaml:"log_level,omitempty"` // "debug", "info", "warn", "error"
@@ -543,6 +582,33 @@ func ExpandAgentConfig(config AgentConfig) AgentConfig {
 		}
 	}
 
+	// Expand retrieval configuration
+	if config.Retrieval != nil {
+		retrieval := *config.Retrieval
+		retrieval.Instructions = expandWithConfigVars(config.Retrieval.Instructions, configVars)
+		retrieval.Stores = make([]map[string]interface{}, len(config.Retrieval.Stores))
+		for i, store := range config.Retrieval.Stores {
+			retrieval.Stores[i] = expandConfigMap(store, configVars)
+		}
+		expanded.Retrieval = &retrieval
+	}
+
+	// Expand budget store configuration
+	if config.Cost != nil && config.Cost.Budget != nil {
+		budget := *config.Cost.Budget
+		budget.Store = expandConfigMap(config.Cost.Budget.Store, configVars)
+		if budget.DowngradeModel != nil {
+			budget.DowngradeModel = &LLMProviderYAML{
+				Provider: expandWithConfigVars(budget.DowngradeModel.Provider, configVars),
+				Model:    expandWithConfigVars(budget.DowngradeModel.Model, configVars),
+				Config:   expandConfigMap(budget.DowngradeModel.Config, configVars),
+			}
+		}
+		costConfig := *config.Cost
+		costConfig.Budget = &budget
+		expanded.Cost = &costConfig
+	}
+
 	// Expand tool configurations
 	if config.Tools != nil {
 		expandedTools := make([]ToolConfigYAML, len(config.Tools))
@@ -0,0 +1,41 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+const contentPartsKey contextKey = "contentParts"
+
+// WithContentParts attaches content parts such as images, documents or audio
+// to the input of a run. The parts are stored with the user message in memory
+// and sent to the LLM together with the input:
+//
+//	ctx = agent.WithContentParts(ctx, interfaces.ImagePart(data, "image/png"))
+//	response, err := a.Run(ctx, "What is in this image?")
+//
+// The parts apply to the run they are passed to only, sub-agents invoked
+// during the run do not receive them.
+func WithContentParts(ctx context.Context, parts ...interfaces.ContentPart) context.Context {
+	return context.WithValue(ctx, contentPartsKey, parts)
+}
+
+// takeContentParts returns the content parts of the context and a context
+// without them, so that they are not passed on to sub-agents
+func takeContentParts(ctx context.Context) (context.Context, []interfaces.ContentPart) {
+	parts, ok := ctx.Value(contentPartsKey).([]interfaces.ContentPart)
+	if !ok || len(parts) == 0 {
+		return ctx, nil
+	}
+	return context.WithValue(ctx, contentPartsKey, []interfaces.ContentPart(nil)), parts
+}
+
+// contentPartsOption returns the generate options that send the content parts
+// to the LLM. With memory the parts are sent as part of the stored user message.
+func (a *Agent) contentPartsOption(parts []interfaces.ContentPart) []interfaces.GenerateOption {
+	if a.memory != nil || len(parts) == 0 {
+		return nil
+	}
+	return []interfaces.GenerateOption{interfaces.WithContentParts(parts...)}
+}
@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/memory"
+	"github.com/tagus/agent-sdk-go/pkg/multitenancy"
+)
+
+// partsCaptureLLM records the content parts of the last request
+type partsCaptureLLM struct {
+	StreamingMockLLM
+	parts []interfaces.ContentPart
+}
+
+func (m *partsCaptureLLM) Generate(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (string, error) {
+	opts := &interfaces.GenerateOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+	m.parts = opts.ContentParts
+	return m.responseContent, nil
+}
+
+func TestAgent_WithContentParts(t *testing.T) {
+	image := interfaces.ImagePart([]byte("png"), "image/png")
+
+	t.Run("WithoutMemory", func(t *testing.T) {
+		llm := &partsCaptureLLM{StreamingMockLLM: StreamingMockLLM{llmName: "capture", responseContent: "A cat."}}
+		agent, err := NewAgent(WithLLM(llm))
+		require.NoError(t, err)
+
+		ctx := WithContentParts(context.Background(), image)
+		_, err = agent.Run(ctx, "What is in this image?")
+		require.NoError(t, err)
+		assert.Equal(t, []interfaces.ContentPart{image}, llm.parts)
+	})
+
+	t.Run("WithMemory", func(t *testing.T) {
+		llm := &partsCaptureLLM{StreamingMockLLM: StreamingMockLLM{llmName: "capture", responseContent: "A cat."}}
+		mem := memory.NewConversationBuffer()
+		agent, err := NewAgent(WithLLM(llm), WithMemory(mem))
+		require.NoError(t, err)
+
+		ctx := multitenancy.WithOrgID(context.Background(), "test-org")
+		ctx = memory.WithConversationID(ctx, "test-conversation")
+		_, err = agent.Run(WithContentParts(ctx, image), "What is in this image?")
+		require.NoError(t, err)
+		assert.Empty(t, llm.parts)
+
+		messages, err := mem.GetMessages(ctx)
+		require.NoError(t, err)
+		require.NotEmpty(t, messages)
+		assert.Equal(t, []interfaces.ContentPart{image}, messages[0].Parts)
+	})
+}
+
+func TestTakeContentParts(t *testing.T) {
+	ctx := WithContentParts(context.Background(), interfaces.TextPart("note"))
+
+	ctx, parts := takeContentParts(ctx)
+	assert.Len(t, parts, 1)
+
+	_, parts = takeContentParts(ctx)
+	assert.Empty(t, parts)
+}
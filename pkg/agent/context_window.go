@@ -0,0 +1,112 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/logging"
+	"github.com/tagus/agent-sdk-go/pkg/tokens"
+)
+
+// WithContextWindow sets the context window of the model in tokens. Before
+// each LLM call the conversation history is trimmed, oldest messages first,
+// so that the system prompt, tools, history and the response fit in it. By
+// default the window is looked up in the tokens model registry by the model
+// of the LLM, and the history is not trimmed for unknown models.
+func WithContextWindow(tokens int) Option {
+	return func(a *Agent) {
+		a.contextWindow = tokens
+	}
+}
+
+// WithTokenCounter sets the counter used to fit the history into the context
+// window. By default the counter of the model is used, see tokens.ForModel.
+func WithTokenCounter(counter tokens.Counter) Option {
+	return func(a *Agent) {
+		a.tokenCounter = counter
+	}
+}
+
+// historyMemory returns the memory passed to the LLM, which trims the
+// history to the token budget left by the system prompt, the tools and the
+// reserved response tokens
+func (a *Agent) historyMemory(systemPrompt string, tools []interfaces.Tool) interfaces.Memory {
+	if a.memory == nil {
+		return nil
+	}
+
+	model := modelName(a.llm)
+	info, known := tokens.LookupModel(model)
+
+	window := a.contextWindow
+	if window <= 0 {
+		if !known || info.ContextWindow == 0 {
+			return a.memory
+		}
+		window = info.ContextWindow
+	}
+
+	// Reserve the output limit of the model, at most a quarter of the window
+	reserve := window / 4
+	if info.MaxOutputTokens > 0 && info.MaxOutputTokens < reserve {
+		reserve = info.MaxOutputTokens
+	}
+
+	counter := a.tokenCounter
+	if counter == nil {
+		counter = tokens.ForModel(model)
+	}
+
+	budget := window - reserve - countPromptTokens(counter, systemPrompt, tools)
+	return &windowMemory{Memory: a.memory, counter: counter, budget: budget, logger: a.logger}
+}
+
+// countPromptTokens counts the tokens of the system prompt and tool
+// definitions. Counting errors are ignored, the history is then trimmed less.
+func countPromptTokens(counter tokens.Counter, systemPrompt string, tools []interfaces.Tool) int {
+	total, _ := counter.CountTokens(systemPrompt)
+	for _, tool := range tools {
+		definition, err := json.Marshal(map[string]interface{}{
+			"name":        tool.Name(),
+			"description": tool.Description(),
+			"parameters":  tool.Parameters(),
+		})
+		if err != nil {
+			continue
+		}
+		count, _ := counter.CountTokens(string(definition))
+		total += count
+	}
+	return total
+}
+
+// windowMemory returns the messages of a memory trimmed to a token budget.
+// Messages are still added to and cleared from the underlying memory.
+type windowMemory struct {
+	interfaces.Memory
+	counter tokens.Counter
+	budget  int
+	logger  logging.Logger
+}
+
+// GetMessages returns the newest messages that fit in the token budget
+func (w *windowMemory) GetMessages(ctx context.Context, options ...interfaces.GetMessagesOption) ([]interfaces.Message, error) {
+	messages, err := w.Memory.GetMessages(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	trimmed, err := tokens.TrimMessages(w.counter, messages, w.budget)
+	if err != nil {
+		return nil, err
+	}
+	if len(trimmed) < len(messages) && w.logger != nil {
+		w.logger.Debug(ctx, "Trimmed conversation history to fit the context window", map[string]interface{}{
+			"messages": len(messages),
+			"kept":     len(trimmed),
+			"budget":   w.budget,
+		})
+	}
+	return trimmed, nil
+}
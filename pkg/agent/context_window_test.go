@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/memory"
+	"github.com/tagus/agent-sdk-go/pkg/multitenancy"
+)
+
+// historyCaptureLLM records the history the LLM reads from memory
+type historyCaptureLLM struct {
+	StreamingMockLLM
+	model   string
+	history []interfaces.Message
+}
+
+func (m *historyCaptureLLM) GetModel() string {
+	return m.model
+}
+
+func (m *historyCaptureLLM) Generate(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (string, error) {
+	opts := &interfaces.GenerateOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+	history, err := opts.Memory.GetMessages(ctx)
+	if err != nil {
+		return "", err
+	}
+	m.history = history
+	return m.responseContent, nil
+}
+
+func TestAgent_ContextWindow(t *testing.T) {
+	ctx := multitenancy.WithOrgID(context.Background(), "test-org")
+	ctx = memory.WithConversationID(ctx, "test-conversation")
+
+	newHistory := func(t *testing.T) interfaces.Memory {
+		mem := memory.NewConversationBuffer()
+		for i := 0; i < 20; i++ {
+			require.NoError(t, mem.AddMessage(ctx, interfaces.Message{Role: interfaces.MessageRoleUser, Content: strings.Repeat("question ", 200)}))
+			require.NoError(t, mem.AddMessage(ctx, interfaces.Message{Role: interfaces.MessageRoleAssistant, Content: strings.Repeat("answer ", 200)}))
+		}
+		return mem
+	}
+
+	t.Run("ExplicitWindow", func(t *testing.T) {
+		llm := &historyCaptureLLM{StreamingMockLLM: StreamingMockLLM{llmName: "capture", responseContent: "ok"}}
+		mem := newHistory(t)
+		agent, err := NewAgent(WithLLM(llm), WithMemory(mem), WithContextWindow(2000))
+		require.NoError(t, err)
+
+		_, err = agent.Run(ctx, "latest question")
+		require.NoError(t, err)
+
+		require.NotEmpty(t, llm.history)
+		assert.Less(t, len(llm.history), 41)
+		assert.Equal(t, "latest question", llm.history[len(llm.history)-1].Content)
+
+		// The memory itself keeps the full history
+		stored, err := mem.GetMessages(ctx)
+		require.NoError(t, err)
+		assert.Len(t, stored, 42)
+	})
+
+	t.Run("ModelRegistry", func(t *testing.T) {
+		llm := &historyCaptureLLM{StreamingMockLLM: StreamingMockLLM{llmName: "capture", responseContent: "ok"}, model: "gpt-4-0613"}
+		agent, err := NewAgent(WithLLM(llm), WithMemory(newHistory(t)))
+		require.NoError(t, err)
+
+		_, err = agent.Run(ctx, "latest question")
+		require.NoError(t, err)
+		assert.Less(t, len(llm.history), 41)
+	})
+
+	t.Run("UnknownModel", func(t *testing.T) {
+		llm := &historyCaptureLLM{StreamingMockLLM: StreamingMockLLM{llmName: "capture", responseContent: "ok"}, model: "custom-model"}
+		agent, err := NewAgent(WithLLM(llm), WithMemory(newHistory(t)))
+		require.NoError(t, err)
+
+		_, err = agent.Run(ctx, "latest question")
+		require.NoError(t, err)
+		assert.Len(t, llm.history, 41)
+	})
+}
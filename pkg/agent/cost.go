@@ -0,0 +1,396 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tagus/agent-sdk-go/pkg/approval"
+	"github.com/tagus/agent-sdk-go/pkg/cost"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/tokens"
+)
+
+// defaultPricing prices the LLM calls of agents without WithPricing
+var defaultPricing = cost.DefaultPricing()
+
+// WithPricing sets the price table used to compute the cost of LLM calls.
+// The default prices of cost.DefaultPricing are used otherwise.
+func WithPricing(pricing *cost.Pricing) Option {
+	return func(a *Agent) {
+		a.pricing = pricing
+	}
+}
+
+// WithBudget records the cost of each LLM call in the budget and checks its
+// limits before each call. When a limit is reached the run fails with
+// cost.ErrBudgetExceeded. If the action of the budget is cost.ActionDowngrade
+// and a downgrade LLM is set, the call in progress finishes its tool-calling
+// loop and the following calls use the downgrade LLM instead.
+func WithBudget(budget *cost.Budget) Option {
+	return func(a *Agent) {
+		a.budget = budget
+	}
+}
+
+// WithDowngradeLLM sets the cheaper LLM used once a budget with
+// cost.ActionDowngrade is exceeded
+func WithDowngradeLLM(llm interfaces.LLM) Option {
+	return func(a *Agent) {
+		a.downgradeLLM = llm
+	}
+}
+
+// GetBudget returns the budget of the agent, or nil if no budget is set
+func (a *Agent) GetBudget() *cost.Budget {
+	return a.budget
+}
+
+// llmForCall checks the budget before an LLM call and returns the LLM to use
+func (a *Agent) llmForCall(ctx context.Context, tracker *usageTracker) (interfaces.LLM, error) {
+	if a.budget == nil {
+		return a.llm, nil
+	}
+
+	var spent float64
+	if tracker != nil {
+		spent = tracker.spent()
+	}
+
+	err := a.budget.Check(ctx, spent)
+	if err == nil {
+		return a.llm, nil
+	}
+
+	var exceeded *cost.BudgetExceededError
+	if !errors.As(err, &exceeded) {
+		return nil, fmt.Errorf("failed to check budget: %w", err)
+	}
+	if a.budget.Action() != cost.ActionDowngrade || a.downgradeLLM == nil {
+		return nil, err
+	}
+
+	model := modelName(a.downgradeLLM)
+	a.logger.Warn(ctx, "Budget exceeded, downgrading model", map[string]interface{}{
+		"agent": a.name,
+		"scope": string(exceeded.Scope),
+		"limit": exceeded.Limit,
+		"spent": exceeded.Spent,
+		"model": model,
+	})
+	if tracker != nil {
+		tracker.setDowngradedModel(model)
+	}
+	return a.downgradeLLM, nil
+}
+
+// recordCost prices the usage of an LLM call, adds it to the run and records
+// it in the budget
+func (a *Agent) recordCost(ctx context.Context, tracker *usageTracker, llm interfaces.LLM, resp *interfaces.LLMResponse) {
+	if resp == nil || resp.Usage == nil {
+		return
+	}
+
+	pricing := a.pricing
+	if pricing == nil {
+		pricing = defaultPricing
+	}
+
+	model := resp.Model
+	if model == "" {
+		model = modelName(llm)
+	}
+	callCost, ok := pricing.Cost(llm.Name(), model, resp.Usage)
+	if !ok {
+		return
+	}
+
+	if tracker != nil {
+		tracker.addCost(callCost)
+	}
+	if a.budget != nil {
+		if err := a.budget.Record(ctx, callCost.Total); err != nil {
+			a.logger.Warn(ctx, "Failed to record spend", map[string]interface{}{
+				"agent": a.name,
+				"error": err.Error(),
+			})
+		}
+	}
+}
+
+// meteredLLM checks the budget before every request that a call sends to the
+// provider and records the usage and cost of every response. LLM clients run
+// the tool-calling loop of a call internally and report each round-trip to
+// the call hook, so that a loop stops as soon as the budget is exceeded or a
+// tool call of the run is suspended waiting for approval.
+type meteredLLM struct {
+	interfaces.LLM
+	agent   *Agent
+	tracker *usageTracker
+	limited bool
+}
+
+// metered returns the LLM of a run wrapped in a meteredLLM. The usage is only
+// recorded with a detailed tracker, and the downgrade LLM is not limited by
+// the budget.
+func (a *Agent) metered(llm interfaces.LLM, tracker *usageTracker) interfaces.LLM {
+	if tracker != nil && !tracker.detailed {
+		tracker = nil
+	}
+	if tracker == nil && a.approver == nil {
+		return llm
+	}
+	return &meteredLLM{
+		LLM:     llm,
+		agent:   a,
+		tracker: tracker,
+		limited: tracker != nil && a.limits(llm),
+	}
+}
+
+// limits reports whether the budget stops the round-trips of a call of the
+// LLM. A budget that downgrades lets the call finish, since the following
+// calls are made with the downgrade LLM.
+func (a *Agent) limits(llm interfaces.LLM) bool {
+	if a.budget == nil || llm == a.downgradeLLM {
+		return false
+	}
+	return a.budget.Action() != cost.ActionDowngrade || a.downgradeLLM == nil
+}
+
+// streamMeter returns the call hook of a streamed call, or nil when the agent
+// has neither a budget nor an approver. The round-trips reported to the hook
+// are recorded as they complete, so that each one is checked against the
+// spend of the run including the previous ones.
+func (a *Agent) streamMeter(llm interfaces.LLM, tracker *usageTracker) *callMeter {
+	if a.budget == nil && a.approver == nil {
+		return nil
+	}
+	return &callMeter{llm: &meteredLLM{
+		LLM:     llm,
+		agent:   a,
+		tracker: tracker,
+		limited: tracker != nil && a.limits(llm),
+	}}
+}
+
+// Generate generates text and meters its requests
+func (m *meteredLLM) Generate(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (string, error) {
+	hook := &callMeter{llm: m}
+	return m.LLM.Generate(ctx, prompt, append(options, interfaces.WithCallHook(hook))...)
+}
+
+// GenerateWithTools generates text with tools and meters every round-trip of
+// the tool-calling loop
+func (m *meteredLLM) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (string, error) {
+	hook := &callMeter{llm: m}
+	return m.LLM.GenerateWithTools(ctx, prompt, tools, append(options, interfaces.WithCallHook(hook))...)
+}
+
+// GenerateDetailed generates text and meters its requests
+func (m *meteredLLM) GenerateDetailed(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (*interfaces.LLMResponse, error) {
+	hook := &callMeter{llm: m}
+	resp, err := m.LLM.GenerateDetailed(ctx, prompt, append(options, interfaces.WithCallHook(hook))...)
+	if err != nil {
+		return nil, err
+	}
+	hook.complete(ctx, resp)
+	return resp, nil
+}
+
+// GenerateWithToolsDetailed generates text with tools and meters every
+// round-trip of the tool-calling loop
+func (m *meteredLLM) GenerateWithToolsDetailed(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (*interfaces.LLMResponse, error) {
+	hook := &callMeter{llm: m}
+	resp, err := m.LLM.GenerateWithToolsDetailed(ctx, prompt, tools, append(options, interfaces.WithCallHook(hook))...)
+	if err != nil {
+		return nil, err
+	}
+	hook.complete(ctx, resp)
+	return resp, nil
+}
+
+// callMeter is the call hook of one call of a meteredLLM
+type callMeter struct {
+	llm       *meteredLLM
+	responses int
+	reported  int // responses with their usage
+}
+
+// BeforeCall stops the call when a tool call of the run is suspended, and
+// checks the budget with the spend of the run so far
+func (h *callMeter) BeforeCall(ctx context.Context) error {
+	if suspended := approval.Suspended(ctx); suspended != nil {
+		return suspended
+	}
+	if !h.llm.limited {
+		return nil
+	}
+
+	err := h.llm.agent.budget.Check(ctx, h.llm.tracker.spent())
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, cost.ErrBudgetExceeded) {
+		return fmt.Errorf("failed to check budget: %w", err)
+	}
+	return err
+}
+
+// AfterCall records the usage and cost of a response
+func (h *callMeter) AfterCall(ctx context.Context, model string, usage *interfaces.TokenUsage) {
+	h.responses++
+	if usage != nil {
+		h.reported++
+	}
+	h.record(ctx, &interfaces.LLMResponse{Model: model, Usage: usage})
+}
+
+// complete records the usage of the whole call when the LLM client did not
+// report its requests to the hook
+func (h *callMeter) complete(ctx context.Context, resp *interfaces.LLMResponse) {
+	if h.responses == 0 {
+		h.record(ctx, resp)
+	}
+}
+
+func (h *callMeter) record(ctx context.Context, resp *interfaces.LLMResponse) {
+	if h.llm.tracker == nil {
+		return
+	}
+	h.llm.tracker.addLLMUsage(resp.Usage, resp.Model)
+	h.llm.agent.recordCost(ctx, h.llm.tracker, h.llm.LLM, resp)
+}
+
+// recordStreamCost records the usage and cost of a streamed run. The usage
+// reported by the stream events is used when there is some, otherwise the
+// tokens of the prompt and the streamed output are counted with the tokenizer
+// of the model.
+func (a *Agent) recordStreamCost(ctx context.Context, llm interfaces.LLM, usage *interfaces.TokenUsage, systemPrompt, input string, tools []interfaces.Tool, output string) {
+	tracker := getUsageTracker(ctx)
+	if tracker == nil {
+		return
+	}
+
+	model := modelName(llm)
+	if usage == nil {
+		counter := tokens.ForModel(model)
+		inputTokens, _ := counter.CountTokens(input)
+		inputTokens += countPromptTokens(counter, systemPrompt, tools)
+		outputTokens, _ := counter.CountTokens(output)
+		usage = &interfaces.TokenUsage{
+			InputTokens:  inputTokens,
+			OutputTokens: outputTokens,
+			TotalTokens:  inputTokens + outputTokens,
+		}
+	}
+
+	tracker.addLLMUsage(usage, model)
+	a.recordCost(ctx, tracker, llm, &interfaces.LLMResponse{Model: model, Usage: usage})
+}
+
+// addStreamUsage adds the token usage of a stream event to a total. LLM
+// clients report it in the "usage" metadata of their final events, either as
+// a TokenUsage or as a map of OpenAI usage fields.
+func addStreamUsage(total *interfaces.TokenUsage, event interfaces.StreamEvent) *interfaces.TokenUsage {
+	var usage interfaces.TokenUsage
+	switch u := event.Metadata["usage"].(type) {
+	case *interfaces.TokenUsage:
+		if u == nil {
+			return total
+		}
+		usage = *u
+	case map[string]interface{}:
+		usage = interfaces.TokenUsage{
+			InputTokens:          usageField(u, "prompt_tokens"),
+			OutputTokens:         usageField(u, "completion_tokens"),
+			TotalTokens:          usageField(u, "total_tokens"),
+			ReasoningTokens:      usageField(u, "reasoning_tokens"),
+			CacheReadInputTokens: usageField(u, "cached_input_tokens"),
+		}
+	default:
+		return total
+	}
+
+	if total == nil {
+		total = &interfaces.TokenUsage{}
+	}
+	total.InputTokens += usage.InputTokens
+	total.OutputTokens += usage.OutputTokens
+	total.TotalTokens += usage.TotalTokens
+	total.ReasoningTokens += usage.ReasoningTokens
+	total.CacheCreationInputTokens += usage.CacheCreationInputTokens
+	total.CacheReadInputTokens += usage.CacheReadInputTokens
+	return total
+}
+
+// usageField returns an integer field of a usage map
+func usageField(usage map[string]interface{}, key string) int {
+	switch v := usage[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
+// modelName returns the model of an LLM that reports it
+func modelName(llm interfaces.LLM) string {
+	if m, ok := llm.(interface{ GetModel() string }); ok {
+		return m.GetModel()
+	}
+	return ""
+}
+
+// applyCostConfig sets the pricing and budget of the agent from the YAML cost
+// section. Settings provided programmatically are kept.
+func applyCostConfig(a *Agent, config *CostConfigYAML) {
+	if a.pricing == nil && len(config.Pricing) > 0 {
+		a.pricing = cost.NewPricing(config.Pricing)
+	}
+
+	if config.Budget == nil || a.budget != nil {
+		return
+	}
+
+	store, err := cost.NewStoreFromConfig(config.Budget.Store)
+	if err != nil {
+		// Log warning but continue - don't fail agent creation for budget issues
+		if a.logger != nil {
+			a.logger.Warn(context.Background(), "Failed to create spend store from config, budget disabled", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+		return
+	}
+
+	options := []cost.BudgetOption{
+		cost.WithStore(store),
+		cost.WithLimits(cost.Limits{
+			PerRun:          config.Budget.PerRun,
+			PerConversation: config.Budget.PerConversation,
+			PerOrgDaily:     config.Budget.PerOrgDaily,
+		}),
+	}
+	if config.Budget.Action != "" {
+		options = append(options, cost.WithAction(cost.Action(config.Budget.Action)))
+	}
+	a.budget = cost.NewBudget(options...)
+
+	if config.Budget.DowngradeModel != nil && a.downgradeLLM == nil {
+		llm, err := createLLMFromConfig(config.Budget.DowngradeModel)
+		if err != nil {
+			if a.logger != nil {
+				a.logger.Warn(context.Background(), "Failed to create downgrade LLM from config", map[string]interface{}{
+					"provider": config.Budget.DowngradeModel.Provider,
+					"error":    err.Error(),
+				})
+			}
+			return
+		}
+		a.downgradeLLM = llm
+	}
+}
@@ -0,0 +1,375 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/tagus/agent-sdk-go/pkg/cost"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/llm"
+	"github.com/tagus/agent-sdk-go/pkg/memory"
+	"github.com/tagus/agent-sdk-go/pkg/multitenancy"
+	"github.com/tagus/agent-sdk-go/pkg/tokens"
+)
+
+func TestAgent_Cost(t *testing.T) {
+	// The mock reports 100 input and 50 output tokens per call, which costs
+	// $0.00075 at the gpt-4o price
+	const callCost = 0.00075
+
+	ctx := multitenancy.WithOrgID(context.Background(), "test-org")
+	ctx = memory.WithConversationID(ctx, "test-conversation")
+
+	t.Run("Response", func(t *testing.T) {
+		agent, err := NewAgent(WithLLM(&StreamingMockLLM{llmName: "gpt-4o", responseContent: "ok"}))
+		require.NoError(t, err)
+
+		response, err := agent.RunDetailed(ctx, "hello")
+		require.NoError(t, err)
+		require.NotNil(t, response.Cost)
+		assert.InDelta(t, 0.00025, response.Cost.Input, 1e-12)
+		assert.InDelta(t, 0.0005, response.Cost.Output, 1e-12)
+		assert.InDelta(t, callCost, response.Cost.Total, 1e-12)
+		assert.InDelta(t, callCost, response.ExecutionSummary.TotalCost, 1e-12)
+	})
+
+	t.Run("UnknownModel", func(t *testing.T) {
+		agent, err := NewAgent(WithLLM(&StreamingMockLLM{llmName: "local-model", responseContent: "ok"}))
+		require.NoError(t, err)
+
+		response, err := agent.RunDetailed(ctx, "hello")
+		require.NoError(t, err)
+		assert.Nil(t, response.Cost)
+
+		agent, err = NewAgent(
+			WithLLM(&StreamingMockLLM{llmName: "local-model", responseContent: "ok"}),
+			WithPricing(cost.NewPricing(map[string]cost.Price{"local-model": {Input: 1, Output: 1}})),
+		)
+		require.NoError(t, err)
+
+		response, err = agent.RunDetailed(ctx, "hello")
+		require.NoError(t, err)
+		require.NotNil(t, response.Cost)
+		assert.InDelta(t, 0.00015, response.Cost.Total, 1e-12)
+	})
+
+	t.Run("Abort", func(t *testing.T) {
+		budget := cost.NewBudget(cost.WithLimits(cost.Limits{PerConversation: 1.5 * callCost}))
+		agent, err := NewAgent(
+			WithLLM(&StreamingMockLLM{llmName: "gpt-4o", responseContent: "ok"}),
+			WithBudget(budget),
+		)
+		require.NoError(t, err)
+
+		_, err = agent.Run(ctx, "first")
+		require.NoError(t, err)
+		_, err = agent.Run(ctx, "second")
+		require.NoError(t, err)
+
+		_, err = agent.Run(ctx, "third")
+		assert.ErrorIs(t, err, cost.ErrBudgetExceeded)
+
+		spent, err := budget.ConversationSpend(ctx, "test-org", "test-conversation")
+		require.NoError(t, err)
+		assert.InDelta(t, 2*callCost, spent, 1e-12)
+		total, err := budget.OrgTotal(ctx, "test-org")
+		require.NoError(t, err)
+		assert.InDelta(t, 2*callCost, total, 1e-12)
+	})
+
+	t.Run("Downgrade", func(t *testing.T) {
+		downgrade := &historyCaptureLLM{StreamingMockLLM: StreamingMockLLM{llmName: "gpt-4o-mini", responseContent: "cheap"}, model: "gpt-4o-mini"}
+		agent, err := NewAgent(
+			WithLLM(&StreamingMockLLM{llmName: "gpt-4o", responseContent: "ok"}),
+			WithBudget(cost.NewBudget(
+				cost.WithLimits(cost.Limits{PerOrgDaily: callCost}),
+				cost.WithAction(cost.ActionDowngrade),
+			)),
+			WithDowngradeLLM(downgrade),
+		)
+		require.NoError(t, err)
+
+		response, err := agent.RunDetailed(ctx, "first")
+		require.NoError(t, err)
+		assert.Equal(t, "ok", response.Content)
+		assert.Empty(t, response.ExecutionSummary.DowngradedModel)
+
+		response, err = agent.RunDetailed(ctx, "second")
+		require.NoError(t, err)
+		assert.Equal(t, "cheap", response.Content)
+		assert.Equal(t, "gpt-4o-mini", response.ExecutionSummary.DowngradedModel)
+		assert.InDelta(t, 0.000045, response.ExecutionSummary.TotalCost, 1e-12)
+	})
+}
+
+// toolLoopMockLLM runs a tool-calling loop of several round-trips and reports
+// each of them to the call hook, as the LLM clients do
+type toolLoopMockLLM struct {
+	StreamingMockLLM
+	roundTrips int
+	requests   int
+}
+
+func (m *toolLoopMockLLM) GenerateWithToolsDetailed(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (*interfaces.LLMResponse, error) {
+	params := &interfaces.GenerateOptions{}
+	for _, option := range options {
+		option(params)
+	}
+
+	usage := &interfaces.TokenUsage{}
+	for i := 0; i < m.roundTrips; i++ {
+		if err := llm.BeforeCall(ctx, params); err != nil {
+			return nil, err
+		}
+		m.requests++
+
+		callUsage := &interfaces.TokenUsage{InputTokens: 100, OutputTokens: 50, TotalTokens: 150}
+		llm.AfterCall(ctx, params, m.llmName, callUsage)
+		usage.InputTokens += callUsage.InputTokens
+		usage.OutputTokens += callUsage.OutputTokens
+		usage.TotalTokens += callUsage.TotalTokens
+	}
+	return &interfaces.LLMResponse{Content: m.responseContent, Usage: usage, Model: m.llmName}, nil
+}
+
+func (m *toolLoopMockLLM) GenerateWithToolsStream(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (<-chan interfaces.StreamEvent, error) {
+	params := &interfaces.GenerateOptions{}
+	for _, option := range options {
+		option(params)
+	}
+
+	events := make(chan interfaces.StreamEvent, 1)
+	go func() {
+		defer close(events)
+		for i := 0; i < m.roundTrips; i++ {
+			if err := llm.BeforeCall(ctx, params); err != nil {
+				events <- interfaces.StreamEvent{Type: interfaces.StreamEventError, Error: err, Timestamp: time.Now()}
+				return
+			}
+			m.requests++
+			llm.AfterCall(ctx, params, m.llmName, &interfaces.TokenUsage{InputTokens: 100, OutputTokens: 50, TotalTokens: 150})
+		}
+		events <- interfaces.StreamEvent{Type: interfaces.StreamEventContentDelta, Content: m.responseContent, Timestamp: time.Now()}
+	}()
+	return events, nil
+}
+
+func TestAgent_BudgetPerRoundTrip(t *testing.T) {
+	const callCost = 0.00075
+	tools := WithTools(&mockTool{name: "lookup", description: "Looks things up"})
+
+	t.Run("Exceeded", func(t *testing.T) {
+		mock := &toolLoopMockLLM{StreamingMockLLM: StreamingMockLLM{llmName: "gpt-4o", responseContent: "ok"}, roundTrips: 5}
+		budget := cost.NewBudget(cost.WithLimits(cost.Limits{PerRun: callCost}))
+		agent, err := NewAgent(WithLLM(mock), WithBudget(budget), tools, WithRequirePlanApproval(false))
+		require.NoError(t, err)
+
+		_, err = agent.Run(context.Background(), "hello")
+		assert.ErrorIs(t, err, cost.ErrBudgetExceeded)
+		var exceeded *cost.BudgetExceededError
+		require.ErrorAs(t, err, &exceeded)
+		assert.Equal(t, cost.ScopeRun, exceeded.Scope)
+
+		// The second round-trip is refused once the first one reached the limit
+		assert.Equal(t, 1, mock.requests)
+		total, err := budget.OrgTotal(context.Background(), "default")
+		require.NoError(t, err)
+		assert.InDelta(t, callCost, total, 1e-12)
+	})
+
+	t.Run("WithinBudget", func(t *testing.T) {
+		mock := &toolLoopMockLLM{StreamingMockLLM: StreamingMockLLM{llmName: "gpt-4o", responseContent: "ok"}, roundTrips: 3}
+		agent, err := NewAgent(
+			WithLLM(mock),
+			WithBudget(cost.NewBudget(cost.WithLimits(cost.Limits{PerRun: 10 * callCost}))),
+			tools,
+			WithRequirePlanApproval(false),
+		)
+		require.NoError(t, err)
+
+		response, err := agent.RunDetailed(context.Background(), "hello")
+		require.NoError(t, err)
+		assert.Equal(t, 3, mock.requests)
+		assert.Equal(t, 3, response.ExecutionSummary.LLMCalls)
+		assert.Equal(t, 450, response.Usage.TotalTokens)
+		assert.InDelta(t, 3*callCost, response.ExecutionSummary.TotalCost, 1e-12)
+	})
+
+	t.Run("Downgrade", func(t *testing.T) {
+		ctx := multitenancy.WithOrgID(context.Background(), "downgrade-org")
+		mock := &toolLoopMockLLM{StreamingMockLLM: StreamingMockLLM{llmName: "gpt-4o", responseContent: "ok"}, roundTrips: 3}
+		downgrade := &historyCaptureLLM{StreamingMockLLM: StreamingMockLLM{llmName: "gpt-4o-mini", responseContent: "cheap"}, model: "gpt-4o-mini"}
+		agent, err := NewAgent(
+			WithLLM(mock),
+			WithBudget(cost.NewBudget(
+				cost.WithLimits(cost.Limits{PerOrgDaily: callCost}),
+				cost.WithAction(cost.ActionDowngrade),
+			)),
+			WithDowngradeLLM(downgrade),
+			tools,
+			WithRequirePlanApproval(false),
+		)
+		require.NoError(t, err)
+
+		// The limit is crossed after the first round-trip, and the call
+		// finishes its loop instead of failing
+		response, err := agent.RunDetailed(ctx, "first")
+		require.NoError(t, err)
+		assert.Equal(t, "ok", response.Content)
+		assert.Equal(t, 3, mock.requests)
+		assert.Empty(t, response.ExecutionSummary.DowngradedModel)
+
+		response, err = agent.RunDetailed(ctx, "second")
+		require.NoError(t, err)
+		assert.Equal(t, "cheap", response.Content)
+		assert.Equal(t, 3, mock.requests)
+		assert.Equal(t, "gpt-4o-mini", response.ExecutionSummary.DowngradedModel)
+	})
+
+	t.Run("Streamed", func(t *testing.T) {
+		mock := &toolLoopMockLLM{StreamingMockLLM: StreamingMockLLM{llmName: "gpt-4o", responseContent: "ok"}, roundTrips: 5}
+		budget := cost.NewBudget(cost.WithLimits(cost.Limits{PerRun: callCost}))
+		agent, err := NewAgent(WithLLM(mock), WithBudget(budget), tools, WithRequirePlanApproval(false))
+		require.NoError(t, err)
+
+		events, err := agent.RunStream(context.Background(), "hello")
+		require.NoError(t, err)
+		var streamErr error
+		for event := range events {
+			if event.Error != nil {
+				streamErr = event.Error
+			}
+		}
+		assert.ErrorIs(t, streamErr, cost.ErrBudgetExceeded)
+
+		// The completed round-trip is recorded once, from the call hook
+		assert.Equal(t, 1, mock.requests)
+		total, err := budget.OrgTotal(context.Background(), "default")
+		require.NoError(t, err)
+		assert.InDelta(t, callCost, total, 1e-12)
+	})
+}
+
+// usageStreamMockLLM streams its response and reports the usage of the call
+// in the metadata of the final event, as the OpenAI client does
+type usageStreamMockLLM struct {
+	StreamingMockLLM
+}
+
+func (m *usageStreamMockLLM) GetModel() string {
+	return m.llmName
+}
+
+func (m *usageStreamMockLLM) GenerateStream(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (<-chan interfaces.StreamEvent, error) {
+	events := make(chan interfaces.StreamEvent, 2)
+	events <- interfaces.StreamEvent{Type: interfaces.StreamEventContentDelta, Content: m.responseContent, Timestamp: time.Now()}
+	events <- interfaces.StreamEvent{
+		Type: interfaces.StreamEventMessageStop,
+		Metadata: map[string]interface{}{
+			"usage": map[string]interface{}{
+				"prompt_tokens":     int64(100),
+				"completion_tokens": int64(50),
+				"total_tokens":      int64(150),
+			},
+		},
+		Timestamp: time.Now(),
+	}
+	close(events)
+	return events, nil
+}
+
+func TestAgent_StreamCost(t *testing.T) {
+	const callCost = 0.00075
+
+	ctx := multitenancy.WithOrgID(context.Background(), "test-org")
+	ctx = memory.WithConversationID(ctx, "stream-conversation")
+
+	runStream := func(t *testing.T, llm interfaces.LLM, budget *cost.Budget) float64 {
+		agent, err := NewAgent(WithLLM(llm), WithBudget(budget))
+		require.NoError(t, err)
+
+		events, err := agent.RunStream(ctx, "hello")
+		require.NoError(t, err)
+		for event := range events {
+			require.NoError(t, event.Error)
+		}
+
+		spent, err := budget.ConversationSpend(ctx, "test-org", "stream-conversation")
+		require.NoError(t, err)
+		return spent
+	}
+
+	t.Run("ReportedUsage", func(t *testing.T) {
+		mock := &usageStreamMockLLM{StreamingMockLLM{llmName: "gpt-4o", responseContent: "ok"}}
+		spent := runStream(t, mock, cost.NewBudget())
+		assert.InDelta(t, callCost, spent, 1e-12)
+	})
+
+	t.Run("EstimatedUsage", func(t *testing.T) {
+		mock := &historyCaptureLLM{StreamingMockLLM: StreamingMockLLM{llmName: "gpt-4o", responseContent: "ok"}, model: "gpt-4o"}
+		spent := runStream(t, mock, cost.NewBudget())
+
+		counter := tokens.ForModel("gpt-4o")
+		input, err := counter.CountTokens("hello")
+		require.NoError(t, err)
+		output, err := counter.CountTokens("ok ")
+		require.NoError(t, err)
+		assert.InDelta(t, float64(input)*2.5e-6+float64(output)*10e-6, spent, 1e-12)
+	})
+
+	t.Run("Abort", func(t *testing.T) {
+		budget := cost.NewBudget(cost.WithLimits(cost.Limits{PerConversation: 1.5 * callCost}))
+		mock := &usageStreamMockLLM{StreamingMockLLM{llmName: "gpt-4o", responseContent: "ok"}}
+		agent, err := NewAgent(WithLLM(mock), WithBudget(budget))
+		require.NoError(t, err)
+
+		var lastErr error
+		for i := 0; i < 3; i++ {
+			events, err := agent.RunStream(memory.WithConversationID(ctx, "stream-abort"), "hello")
+			require.NoError(t, err)
+			for event := range events {
+				if event.Error != nil {
+					lastErr = event.Error
+				}
+			}
+		}
+		assert.ErrorIs(t, lastErr, cost.ErrBudgetExceeded)
+	})
+}
+
+func TestAgent_CostFromConfig(t *testing.T) {
+	var config AgentConfig
+	require.NoError(t, yaml.Unmarshal([]byte(`
+role: Assistant
+cost:
+  pricing:
+    local-model:
+      input: 1
+      output: 2
+  budget:
+    per_run: 0.5
+    per_org_daily: 10
+    action: downgrade
+    store:
+      type: memory
+`), &config))
+
+	agent, err := NewAgent(
+		WithLLM(&StreamingMockLLM{llmName: "local-model", responseContent: "ok"}),
+		WithAgentConfig(config, nil),
+	)
+	require.NoError(t, err)
+
+	require.NotNil(t, agent.GetBudget())
+	assert.Equal(t, cost.Limits{PerRun: 0.5, PerOrgDaily: 10}, agent.GetBudget().Limits())
+	assert.Equal(t, cost.ActionDowngrade, agent.GetBudget().Action())
+
+	response, err := agent.RunDetailed(context.Background(), "hello")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.0002, response.ExecutionSummary.TotalCost, 1e-12)
+}
@@ -10,7 +10,9 @@ import (
 	"github.com/tagus/agent-sdk-go/pkg/interfaces"
 	"github.com/tagus/agent-sdk-go/pkg/llm/anthropic"
 	"github.com/tagus/agent-sdk-go/pkg/llm/azureopenai"
+	"github.com/tagus/agent-sdk-go/pkg/llm/bedrock"
 	"github.com/tagus/agent-sdk-go/pkg/llm/deepseek"
+	"github.com/tagus/agent-sdk-go/pkg/llm/gemini"
 	"github.com/tagus/agent-sdk-go/pkg/llm/ollama"
 	"github.com/tagus/agent-sdk-go/pkg/llm/openai"
 	"github.com/tagus/agent-sdk-go/pkg/llm/vllm"
@@ -31,14 +33,18 @@ func createLLMFromConfig(config *LLMProviderYAML) (interfaces.LLM, error) {
 		return createOpenAIClient(config)
 	case "azureopenai", "azure_openai":
 		return createAzureOpenAIClient(config)
+	case "bedrock", "aws_bedrock":
+		return createBedrockClient(config)
 	case "deepseek":
 		return createDeepSeekClient(config)
+	case "gemini", "google":
+		return createGeminiClient(config)
 	case "ollama":
 		return createOllamaClient(config)
 	case "vllm":
 		return createVllmClient(config)
 	default:
-		return nil, fmt.Errorf("unsupported LLM provider: %s (supported: anthropic, openai, azureopenai, deepseek, ollama, vllm)", provider)
+		return nil, fmt.Errorf("unsupported LLM provider: %s (supported: anthropic, openai, azureopenai, bedrock, deepseek, gemini, ollama, vllm)", provider)
 	}
 }
 
@@ -280,6 +286,112 @@ func createAzureOpenAIClient(config *LLMProviderYAML) (interfaces.LLM, error) {
 	return azureopenai.NewClient(apiKey, endpoint, deployment, options...), nil
 }
 
+// createGeminiClient creates a Google Gemini LLM client, using the Gemini API
+// with an API key or Vertex AI when a project is configured
+func createGeminiClient(config *LLMProviderYAML) (interfaces.LLM, error) {
+	var options []gemini.Option
+
+	apiKey := getConfigString(config.Config, "api_key")
+	if apiKey == "" {
+		apiKey = GetEnvValue("GEMINI_API_KEY")
+	}
+	if apiKey == "" {
+		apiKey = GetEnvValue("GOOGLE_API_KEY")
+	}
+
+	// Vertex AI is used when configured explicitly, or from the environment when no API key is set
+	vertexProject := getConfigString(config.Config, "vertex_ai_project")
+	if vertexProject == "" && apiKey == "" {
+		vertexProject = GetEnvValue("VERTEX_AI_PROJECT")
+	}
+
+	if vertexProject != "" {
+		location := getConfigString(config.Config, "vertex_ai_region")
+		if location == "" {
+			location = GetEnvValue("VERTEX_AI_REGION")
+		}
+		if location == "" {
+			location = "us-central1" // Default location
+		}
+
+		if creds := getConfigString(config.Config, "google_application_credentials"); creds != "" {
+			credContent, err := parseGoogleCredentials(creds)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse google_application_credentials for Vertex AI project %s: %w", vertexProject, err)
+			}
+			options = append(options, gemini.WithGoogleApplicationCredentials(location, vertexProject, credContent))
+		} else {
+			options = append(options, gemini.WithVertexAI(location, vertexProject))
+		}
+	} else if apiKey == "" {
+		return nil, fmt.Errorf("api_key is required for Gemini provider (set GEMINI_API_KEY or config.api_key) or configure Vertex AI (set VERTEX_AI_PROJECT and optionally VERTEX_AI_REGION)")
+	}
+
+	// Set model - use config model or fallback to GEMINI_MODEL env var
+	model := ExpandEnv(config.Model)
+	if model == "" {
+		model = getConfigString(config.Config, "model")
+	}
+	if model == "" {
+		model = GetEnvValue("GEMINI_MODEL")
+	}
+	if model != "" {
+		options = append(options, gemini.WithModel(model))
+	}
+
+	// Set base URL if provided (for custom endpoints)
+	if baseURL := getConfigString(config.Config, "base_url"); baseURL != "" {
+		options = append(options, gemini.WithBaseURL(baseURL))
+	}
+
+	return gemini.NewClient(apiKey, options...), nil
+}
+
+// createBedrockClient creates an AWS Bedrock LLM client. Credentials and region
+// default to the standard AWS environment variables.
+func createBedrockClient(config *LLMProviderYAML) (interfaces.LLM, error) {
+	var options []bedrock.Option
+
+	region := getConfigString(config.Config, "region")
+	if region == "" {
+		region = GetEnvValue("AWS_REGION")
+	}
+	if region != "" {
+		options = append(options, bedrock.WithRegion(region))
+	}
+
+	// Explicit credentials take precedence over the environment
+	if accessKeyID := getConfigString(config.Config, "access_key_id"); accessKeyID != "" {
+		secretAccessKey := getConfigString(config.Config, "secret_access_key")
+		if secretAccessKey == "" {
+			return nil, fmt.Errorf("secret_access_key is required for Bedrock provider when access_key_id is set")
+		}
+		options = append(options, bedrock.WithCredentials(accessKeyID, secretAccessKey, getConfigString(config.Config, "session_token")))
+	}
+	if apiKey := getConfigString(config.Config, "api_key"); apiKey != "" {
+		options = append(options, bedrock.WithAPIKey(apiKey))
+	}
+
+	// Set model - use config model or fallback to BEDROCK_MODEL env var
+	model := ExpandEnv(config.Model)
+	if model == "" {
+		model = getConfigString(config.Config, "model")
+	}
+	if model == "" {
+		model = GetEnvValue("BEDROCK_MODEL")
+	}
+	if model != "" {
+		options = append(options, bedrock.WithModel(model))
+	}
+
+	// Set endpoint if provided (for VPC endpoints)
+	if endpoint := getConfigString(config.Config, "endpoint"); endpoint != "" {
+		options = append(options, bedrock.WithEndpoint(endpoint))
+	}
+
+	return bedrock.NewClient(options...), nil
+}
+
 // createOllamaClient creates an Ollama LLM client
 func createOllamaClient(config *LLMProviderYAML) (interfaces.LLM, error) {
 	var options []ollama.Option
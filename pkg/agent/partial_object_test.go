@@ -0,0 +1,55 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/structuredoutput"
+)
+
+func TestAgent_RunStreamPartialObjects(t *testing.T) {
+	type summary struct {
+		Title string   `json:"title"`
+		Tags  []string `json:"tags"`
+	}
+
+	agent, err := NewAgent(
+		WithLLM(&StreamingMockLLM{
+			llmName:         "mock",
+			responseContent: `{"title": "Go", "tags": ["fast", "simple"]}`,
+		}),
+		WithResponseFormat(*structuredoutput.NewResponseFormat(summary{})),
+	)
+	require.NoError(t, err)
+
+	events, err := agent.RunStream(context.Background(), "Summarize Go")
+	require.NoError(t, err)
+
+	var partials []interfaces.AgentStreamEvent
+	timeout := time.After(5 * time.Second)
+	for done := false; !done; {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				done = true
+				break
+			}
+			if event.Type == interfaces.AgentEventPartialObject {
+				partials = append(partials, event)
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for stream events")
+		}
+	}
+
+	require.Greater(t, len(partials), 2)
+	assert.JSONEq(t, `{}`, partials[0].Content)
+	last := partials[len(partials)-1]
+	assert.JSONEq(t, `{"title": "Go", "tags": ["fast", "simple"]}`, last.Content)
+	assert.Equal(t, true, last.Metadata[structuredoutput.MetadataPartialComplete])
+}
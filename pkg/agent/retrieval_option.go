@@ -0,0 +1,140 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/memory"
+	"github.com/tagus/agent-sdk-go/pkg/retrieval"
+)
+
+const retrievalKey contextKey = "retrieval"
+
+// retrievalState carries the documents retrieved for a run so that the
+// response can report them
+type retrievalState struct {
+	result *retrieval.Result
+}
+
+// WithRetriever enables retrieval-augmented generation. Before each run the
+// retriever is queried with the user input and the retrieved documents are
+// added to the system prompt. Unlike a VectorStoreRetriever used as memory,
+// retrieved knowledge is not mixed with the conversation history.
+func WithRetriever(retriever *retrieval.Retriever) Option {
+	return func(a *Agent) {
+		a.retriever = retriever
+	}
+}
+
+// GetRetriever returns the retriever if configured.
+// Returns nil if retrieval is not enabled.
+func (a *Agent) GetRetriever() *retrieval.Retriever {
+	return a.retriever
+}
+
+// HasRetriever returns true if the agent has retrieval enabled.
+func (a *Agent) HasRetriever() bool {
+	return a.retriever != nil
+}
+
+func withRetrievalState(ctx context.Context, state *retrievalState) context.Context {
+	return context.WithValue(ctx, retrievalKey, state)
+}
+
+func getRetrievalState(ctx context.Context) *retrievalState {
+	if state, ok := ctx.Value(retrievalKey).(*retrievalState); ok {
+		return state
+	}
+	return nil
+}
+
+// retrieve queries the retriever with the input and records the result in the
+// context. Retrieval failures are logged and the run continues without documents.
+func (a *Agent) retrieve(ctx context.Context, input string) (context.Context, *retrieval.Result) {
+	if a.retriever == nil {
+		return ctx, nil
+	}
+
+	state := getRetrievalState(ctx)
+	if state == nil {
+		state = &retrievalState{}
+		ctx = withRetrievalState(ctx, state)
+	}
+
+	result, err := a.retriever.Retrieve(ctx, input)
+	if err != nil {
+		a.logger.Warn(ctx, "Retrieval failed, continuing without documents", map[string]interface{}{
+			"agent": a.name,
+			"error": err.Error(),
+		})
+		return ctx, nil
+	}
+
+	state.result = result
+	return ctx, result
+}
+
+// systemPromptWithRetrieval returns the system prompt followed by the
+// documents retrieved for the current run
+func (a *Agent) systemPromptWithRetrieval(ctx context.Context) string {
+	state := getRetrievalState(ctx)
+	if state == nil || state.result == nil || state.result.Context == "" {
+		return a.systemPrompt
+	}
+	if a.systemPrompt == "" {
+		return state.result.Context
+	}
+	return a.systemPrompt + "\n\n" + state.result.Context
+}
+
+// addRetrievalMetadata reports the retrieved and cited document IDs
+func addRetrievalMetadata(metadata map[string]interface{}, state *retrievalState, response string) {
+	if state == nil || state.result == nil {
+		return
+	}
+	metadata["retrieved_document_ids"] = state.result.DocumentIDs()
+	metadata["cited_document_ids"] = state.result.Citations(response)
+}
+
+// createRetrieverFromConfig builds a retriever from the agent YAML retrieval section
+func createRetrieverFromConfig(config *RetrievalConfigYAML, llm interfaces.LLM) (*retrieval.Retriever, error) {
+	options := []retrieval.Option{}
+	for _, storeConfig := range config.Stores {
+		store, err := memory.NewVectorStoreFromConfig(storeConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		var searchOptions []interfaces.SearchOption
+		if class, ok := storeConfig["class"].(string); ok && class != "" {
+			searchOptions = append(searchOptions, func(o *interfaces.SearchOptions) {
+				o.Class = class
+			})
+		}
+		if tenant, ok := storeConfig["tenant"].(string); ok && tenant != "" {
+			searchOptions = append(searchOptions, interfaces.WithTenantSearch(tenant))
+		}
+		options = append(options, retrieval.WithStore(store, searchOptions...))
+	}
+
+	if config.TopK != nil {
+		options = append(options, retrieval.WithTopK(*config.TopK))
+	}
+	if config.MaxDocuments != nil {
+		options = append(options, retrieval.WithMaxDocuments(*config.MaxDocuments))
+	}
+	if config.MinScore != nil {
+		options = append(options, retrieval.WithMinScore(float32(*config.MinScore)))
+	}
+	if config.TokenBudget != nil {
+		options = append(options, retrieval.WithTokenBudget(*config.TokenBudget))
+	}
+	if config.Instructions != "" {
+		options = append(options, retrieval.WithInstructions(config.Instructions))
+	}
+	if config.RewriteQuery != nil && *config.RewriteQuery {
+		options = append(options, retrieval.WithQueryRewriter(llm))
+	}
+
+	return retrieval.New(options...), nil
+}
@@ -0,0 +1,138 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/retrieval"
+	"github.com/tagus/agent-sdk-go/pkg/vectorstore/inmemory"
+)
+
+// systemCaptureLLM records the system message of the last request
+type systemCaptureLLM struct {
+	StreamingMockLLM
+	systemMessage string
+}
+
+func (m *systemCaptureLLM) capture(options []interfaces.GenerateOption) {
+	opts := &interfaces.GenerateOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+	m.systemMessage = opts.SystemMessage
+}
+
+func (m *systemCaptureLLM) Generate(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (string, error) {
+	m.capture(options)
+	return m.responseContent, nil
+}
+
+func (m *systemCaptureLLM) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (string, error) {
+	return m.Generate(ctx, prompt, options...)
+}
+
+func (m *systemCaptureLLM) GenerateDetailed(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (*interfaces.LLMResponse, error) {
+	m.capture(options)
+	return m.StreamingMockLLM.GenerateDetailed(ctx, prompt, options...)
+}
+
+func (m *systemCaptureLLM) GenerateStream(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (<-chan interfaces.StreamEvent, error) {
+	m.capture(options)
+	return m.StreamingMockLLM.GenerateStream(ctx, prompt, options...)
+}
+
+func newKnowledgeRetriever(t *testing.T) *retrieval.Retriever {
+	t.Helper()
+	store, err := inmemory.New(nil)
+	require.NoError(t, err)
+	require.NoError(t, store.Store(context.Background(), []interfaces.Document{
+		{ID: "refunds", Content: "Refunds are processed within five business days."},
+		{ID: "shipping", Content: "Orders ship from the Lisbon warehouse."},
+	}))
+	return retrieval.New(retrieval.WithStore(store), retrieval.WithMaxDocuments(1))
+}
+
+func TestAgent_WithRetriever(t *testing.T) {
+	llm := &systemCaptureLLM{StreamingMockLLM: StreamingMockLLM{
+		llmName:         "capture",
+		responseContent: "Refunds take five business days [refunds].",
+	}}
+	agent, err := NewAgent(
+		WithLLM(llm),
+		WithSystemPrompt("You are a support agent."),
+		WithRetriever(newKnowledgeRetriever(t)),
+	)
+	require.NoError(t, err)
+	assert.True(t, agent.HasRetriever())
+
+	response, err := agent.RunDetailed(context.Background(), "How long do refunds take?")
+	require.NoError(t, err)
+	assert.Contains(t, llm.systemMessage, "You are a support agent.\n\n"+retrieval.DefaultInstructions)
+	assert.Contains(t, llm.systemMessage, `<document id="refunds">`)
+	assert.NotContains(t, llm.systemMessage, "Lisbon")
+	assert.Equal(t, []string{"refunds"}, response.Metadata["retrieved_document_ids"])
+	assert.Equal(t, []string{"refunds"}, response.Metadata["cited_document_ids"])
+}
+
+func TestAgent_RunStreamWithRetriever(t *testing.T) {
+	llm := &systemCaptureLLM{StreamingMockLLM: StreamingMockLLM{
+		llmName:         "capture",
+		responseContent: "They ship from Lisbon [shipping].",
+	}}
+	agent, err := NewAgent(
+		WithLLM(llm),
+		WithRetriever(newKnowledgeRetriever(t)),
+	)
+	require.NoError(t, err)
+
+	events, err := agent.RunStream(context.Background(), "Where do orders ship from?")
+	require.NoError(t, err)
+
+	var retrievalEvent, completeEvent *interfaces.AgentStreamEvent
+	timeout := time.After(5 * time.Second)
+	for done := false; !done; {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				done = true
+				break
+			}
+			switch event.Type {
+			case interfaces.AgentEventRetrieval:
+				retrievalEvent = &event
+			case interfaces.AgentEventComplete:
+				completeEvent = &event
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for stream events")
+		}
+	}
+
+	require.NotNil(t, retrievalEvent)
+	assert.Equal(t, []string{"shipping"}, retrievalEvent.Metadata["document_ids"])
+	assert.Contains(t, llm.systemMessage, `<document id="shipping">`)
+	require.NotNil(t, completeEvent)
+	assert.Equal(t, []string{"shipping"}, completeEvent.Metadata["cited_document_ids"])
+}
+
+func TestAgent_RetrievalFromConfig(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	topK := 3
+	agent, err := NewAgent(
+		WithLLM(&StreamingMockLLM{llmName: "mock"}),
+		WithAgentConfig(AgentConfig{
+			Role: "Support",
+			Retrieval: &RetrievalConfigYAML{
+				Stores: []map[string]interface{}{{"vector_store": "inmemory", "tenant": "${KB_TENANT}"}},
+				TopK:   &topK,
+			},
+		}, nil),
+	)
+	require.NoError(t, err)
+	assert.True(t, agent.HasRetriever())
+}
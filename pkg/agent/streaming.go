@@ -6,8 +6,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/tagus/agent-sdk-go/pkg/approval"
 	"github.com/tagus/agent-sdk-go/pkg/interfaces"
 	"github.com/tagus/agent-sdk-go/pkg/multitenancy"
+	"github.com/tagus/agent-sdk-go/pkg/retrieval"
+	"github.com/tagus/agent-sdk-go/pkg/structuredoutput"
 )
 
 // RunStream executes the agent with streaming response
@@ -40,6 +43,9 @@ func (a *Agent) runLocalStream(ctx context.Context, input string) (<-chan interf
 	// Create agent event channel
 	eventChan := make(chan interfaces.AgentStreamEvent, bufferSize)
 
+	// Take the content parts of the input so that sub-agents do not receive them
+	ctx, parts := takeContentParts(ctx)
+
 	// Start streaming in a goroutine
 	go func() {
 		defer close(eventChan)
@@ -48,6 +54,7 @@ func (a *Agent) runLocalStream(ctx context.Context, input string) (<-chan interf
 		if a.orgID != "" {
 			ctx = multitenancy.WithOrgID(ctx, a.orgID)
 		}
+		ctx = approval.WithRun(ctx, a.name, input)
 
 		// Create usage tracker for detailed metrics collection
 		tracker := newUsageTracker(true)
@@ -58,6 +65,7 @@ func (a *Agent) runLocalStream(ctx context.Context, input string) (<-chan interf
 			if err := a.memory.AddMessage(ctx, interfaces.Message{
 				Role:    "user",
 				Content: input,
+				Parts:   parts,
 			}); err != nil {
 				eventChan <- interfaces.AgentStreamEvent{
 					Type:      interfaces.AgentEventError,
@@ -174,8 +182,46 @@ func (a *Agent) runLocalStream(ctx context.Context, input string) (<-chan interf
 			return
 		}
 
+		// Retrieve knowledge documents and report them before generation
+		var retrieved *retrieval.Result
+		ctx, retrieved = a.retrieve(ctx, processedInput)
+		if retrieved != nil {
+			eventChan <- interfaces.AgentStreamEvent{
+				Type:      interfaces.AgentEventRetrieval,
+				Timestamp: time.Now(),
+				Metadata: map[string]interface{}{
+					"query":           retrieved.Query,
+					"rewritten_query": retrieved.RewrittenQuery,
+					"document_ids":    retrieved.DocumentIDs(),
+				},
+			}
+		}
+
+		// Check the budget before the streamed call
+		callLLM, err := a.llmForCall(ctx, tracker)
+		if err != nil {
+			eventChan <- interfaces.AgentStreamEvent{
+				Type:      interfaces.AgentEventError,
+				Error:     err,
+				Timestamp: time.Now(),
+			}
+			return
+		}
+		if callLLM != a.llm {
+			downgradeLLM, ok := callLLM.(interfaces.StreamingLLM)
+			if !ok {
+				eventChan <- interfaces.AgentStreamEvent{
+					Type:      interfaces.AgentEventError,
+					Error:     fmt.Errorf("downgrade LLM '%s' does not support streaming", callLLM.Name()),
+					Timestamp: time.Now(),
+				}
+				return
+			}
+			streamingLLM = downgradeLLM
+		}
+
 		// Run with streaming
-		_, err := a.runStreamingGeneration(ctx, processedInput, allTools, streamingLLM, eventChan)
+		_, err = a.runStreamingGeneration(ctx, processedInput, parts, allTools, streamingLLM, eventChan)
 		if err != nil {
 			eventChan <- interfaces.AgentStreamEvent{
 				Type:      interfaces.AgentEventError,
@@ -192,6 +238,7 @@ func (a *Agent) runLocalStream(ctx context.Context, input string) (<-chan interf
 func (a *Agent) runStreamingGeneration(
 	ctx context.Context,
 	input string,
+	parts []interfaces.ContentPart,
 	allTools []interfaces.Tool,
 	streamingLLM interfaces.StreamingLLM,
 	eventChan chan<- interfaces.AgentStreamEvent,
@@ -200,9 +247,10 @@ func (a *Agent) runStreamingGeneration(
 	options := []interfaces.GenerateOption{}
 
 	// Add system prompt if available
-	if a.systemPrompt != "" {
+	systemPrompt := a.systemPromptWithRetrieval(ctx)
+	if systemPrompt != "" {
 		options = append(options, func(opts *interfaces.GenerateOptions) {
-			opts.SystemMessage = a.systemPrompt
+			opts.SystemMessage = systemPrompt
 		})
 	}
 
@@ -227,14 +275,24 @@ func (a *Agent) runStreamingGeneration(
 
 	// Add memory if available
 	if a.memory != nil {
-		options = append(options, interfaces.WithMemory(a.memory))
+		options = append(options, interfaces.WithMemory(a.historyMemory(systemPrompt, allTools)))
 	}
 
+	// Add content parts of the input when they are not stored in memory
+	options = append(options, a.contentPartsOption(parts)...)
+
 	// Add stream config if available
 	if a.streamConfig != nil {
 		options = append(options, interfaces.WithStreamConfig(*a.streamConfig))
 	}
 
+	// Check the budget before every round-trip of the tool-calling loop and
+	// stop it once a tool call is suspended
+	meter := a.streamMeter(streamingLLM, getUsageTracker(ctx))
+	if meter != nil {
+		options = append(options, interfaces.WithCallHook(meter))
+	}
+
 	// Inject stream forwarder into context so sub-agents can forward their events
 	// This allows nested sub-agent streaming to work properly
 	streamForwarder := func(event interfaces.AgentStreamEvent) {
@@ -255,7 +313,7 @@ func (a *Agent) runStreamingGeneration(
 	var err error
 
 	if len(allTools) > 0 {
-		llmEventChan, err = streamingLLM.GenerateWithToolsStream(ctxWithForwarder, input, allTools, options...)
+		llmEventChan, err = streamingLLM.GenerateWithToolsStream(ctxWithForwarder, input, a.toolsForCall(allTools), options...)
 	} else {
 		llmEventChan, err = streamingLLM.GenerateStream(ctxWithForwarder, input, options...)
 	}
@@ -269,9 +327,16 @@ func (a *Agent) runStreamingGeneration(
 	var toolCalls []interfaces.ToolCall
 	var toolResults map[string]string // map[toolCallID]result
 	var finalError error
+	var usage *interfaces.TokenUsage
 
 	toolResults = make(map[string]string)
 
+	// Structured responses are also streamed as partial objects
+	var partialParser *structuredoutput.PartialParser
+	if a.responseFormat != nil {
+		partialParser = structuredoutput.NewPartialParser()
+	}
+
 	// Forward LLM events as agent events
 	for llmEvent := range llmEventChan {
 		agentEvent := a.convertLLMEventToAgentEvent(llmEvent, allTools)
@@ -296,12 +361,48 @@ func (a *Agent) runStreamingGeneration(
 			finalError = llmEvent.Error
 		}
 
+		// Track the usage reported by the final events of the LLM calls
+		usage = addStreamUsage(usage, llmEvent)
+
 		// Send agent event
 		eventChan <- agentEvent
+
+		if partialParser != nil && llmEvent.Type == interfaces.StreamEventContentDelta {
+			if partial, changed := partialParser.Write(llmEvent.Content); changed {
+				eventChan <- interfaces.AgentStreamEvent{
+					Type:    interfaces.AgentEventPartialObject,
+					Content: partial,
+					Metadata: map[string]interface{}{
+						structuredoutput.MetadataPartialComplete: partialParser.Complete(),
+					},
+					Timestamp: time.Now(),
+				}
+			}
+		}
+	}
+
+	// The round-trips reported to the call hook are already recorded
+	if meter == nil || meter.reported == 0 {
+		a.recordStreamCost(ctx, streamingLLM, usage, systemPrompt, input, allTools, accumulatedContent.String())
+	}
+
+	// The run ends with the suspended tool call waiting for approval, and its
+	// messages are saved with the approval request instead of the memory
+	suspended := false
+	if a.approver != nil {
+		var history []interfaces.Message
+		if a.memory == nil {
+			history = append(history, interfaces.Message{Role: interfaces.MessageRoleUser, Content: input, Parts: parts})
+		}
+		history = append(history, streamedToolMessages(accumulatedContent.String(), toolCalls, toolResults)...)
+		if err := a.suspendedRun(ctx, history); err != nil {
+			finalError = err
+			suspended = true
+		}
 	}
 
 	// Add messages to memory if available (save even on error to preserve conversation history)
-	if a.memory != nil {
+	if a.memory != nil && !suspended {
 		// If we have tool calls, save them in the correct order
 		if len(toolCalls) > 0 {
 			// Add assistant message with tool calls
@@ -343,18 +444,48 @@ func (a *Agent) runStreamingGeneration(
 	}
 
 	// Send completion event
+	completeMetadata := map[string]interface{}{
+		"total_content_length": accumulatedContent.Len(),
+		"had_error":            finalError != nil,
+	}
+	if state := getRetrievalState(ctx); state != nil && state.result != nil {
+		completeMetadata["cited_document_ids"] = state.result.Citations(accumulatedContent.String())
+	}
 	eventChan <- interfaces.AgentStreamEvent{
 		Type:      interfaces.AgentEventComplete,
 		Timestamp: time.Now(),
-		Metadata: map[string]interface{}{
-			"total_content_length": accumulatedContent.Len(),
-			"had_error":            finalError != nil,
-		},
+		Metadata:  completeMetadata,
 	}
 
 	return int64(accumulatedContent.Len()), finalError
 }
 
+// streamedToolMessages returns the messages of the tool calls of a streamed
+// run and of their results
+func streamedToolMessages(content string, toolCalls []interfaces.ToolCall, toolResults map[string]string) []interfaces.Message {
+	if len(toolCalls) == 0 {
+		return nil
+	}
+	messages := []interfaces.Message{{
+		Role:      interfaces.MessageRoleAssistant,
+		Content:   content,
+		ToolCalls: toolCalls,
+	}}
+	for _, toolCall := range toolCalls {
+		if result, ok := toolResults[toolCall.ID]; ok {
+			messages = append(messages, interfaces.Message{
+				Role:       interfaces.MessageRoleTool,
+				Content:    result,
+				ToolCallID: toolCall.ID,
+				Metadata: map[string]interface{}{
+					"tool_name": toolCall.Name,
+				},
+			})
+		}
+	}
+	return messages
+}
+
 // getToolMetadata retrieves display name and internal flag for a tool
 func getToolMetadata(toolName string, tools []interfaces.Tool) (displayName string, internal bool) {
 	displayName = toolName
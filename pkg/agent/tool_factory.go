@@ -7,11 +7,14 @@ import (
 
 	"github.com/tagus/agent-sdk-go/pkg/interfaces"
 	"github.com/tagus/agent-sdk-go/pkg/tools/calculator"
+	"github.com/tagus/agent-sdk-go/pkg/tools/codeinterpreter"
+	"github.com/tagus/agent-sdk-go/pkg/tools/filesystem"
 )
 
 // ToolFactory creates tools from YAML configuration
 type ToolFactory struct {
 	builtinFactories map[string]func(map[string]interface{}) (interfaces.Tool, error)
+	builtinToolsets  map[string]func(map[string]interface{}) ([]interfaces.Tool, error)
 	customFactories  map[string]func(map[string]interface{}) (interfaces.Tool, error)
 }
 
@@ -19,6 +22,7 @@ type ToolFactory struct {
 func NewToolFactory() *ToolFactory {
 	tf := &ToolFactory{
 		builtinFactories: make(map[string]func(map[string]interface{}) (interfaces.Tool, error)),
+		builtinToolsets:  make(map[string]func(map[string]interface{}) ([]interfaces.Tool, error)),
 		customFactories:  make(map[string]func(map[string]interface{}) (interfaces.Tool, error)),
 	}
 
@@ -35,6 +39,12 @@ func (tf *ToolFactory) registerBuiltinTools() {
 		return calculator.New(), nil
 	}
 
+	// Code interpreter tool
+	tf.builtinFactories["code_interpreter"] = newCodeInterpreterTool
+
+	// Filesystem tools, confined to a workspace root
+	tf.builtinToolsets["filesystem"] = newFilesystemTools
+
 	// Add other builtin tools as they become available
 	// tf.builtinFactories["web_search"] = func(config map[string]interface{}) (interfaces.Tool, error) {
 	//     // Implementation depends on available web search tool
@@ -42,11 +52,141 @@ func (tf *ToolFactory) registerBuiltinTools() {
 	// }
 }
 
+// newCodeInterpreterTool creates the code interpreter from its YAML configuration
+func newCodeInterpreterTool(config map[string]interface{}) (interfaces.Tool, error) {
+	var options []codeinterpreter.Option
+
+	if dir := getConfigString(config, "work_dir"); dir != "" {
+		options = append(options, codeinterpreter.WithWorkDir(dir))
+	}
+	for key, option := range map[string]func(time.Duration) codeinterpreter.Option{
+		"timeout":  codeinterpreter.WithTimeout,
+		"cpu_time": codeinterpreter.WithCPUTime,
+	} {
+		if value := getConfigString(config, key); value != "" {
+			duration, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s for code_interpreter: %w", key, err)
+			}
+			options = append(options, option(duration))
+		}
+	}
+	if mb, ok := getConfigInt(config, "memory_mb"); ok {
+		options = append(options, codeinterpreter.WithMemoryLimit(int64(mb)<<20))
+	}
+	if bytes, ok := getConfigInt(config, "max_output_bytes"); ok {
+		options = append(options, codeinterpreter.WithMaxOutput(bytes))
+	}
+	if values, ok := config["languages"].([]interface{}); ok {
+		var languages []codeinterpreter.Language
+		for _, value := range values {
+			language, ok := value.(string)
+			if !ok || (language != string(codeinterpreter.Python) && language != string(codeinterpreter.Shell)) {
+				return nil, fmt.Errorf("unsupported code_interpreter language: %v", value)
+			}
+			languages = append(languages, codeinterpreter.Language(language))
+		}
+		options = append(options, codeinterpreter.WithLanguages(languages...))
+	}
+	if allowed, ok := config["allow_network"].(bool); ok {
+		options = append(options, codeinterpreter.WithNetwork(allowed))
+	}
+	if isolation := getConfigString(config, "isolation"); isolation != "" {
+		switch codeinterpreter.Isolation(isolation) {
+		case codeinterpreter.IsolationAuto, codeinterpreter.IsolationRequired, codeinterpreter.IsolationNone:
+			options = append(options, codeinterpreter.WithIsolation(codeinterpreter.Isolation(isolation)))
+		default:
+			return nil, fmt.Errorf("unsupported code_interpreter isolation: %s", isolation)
+		}
+	}
+	if python := getConfigString(config, "python"); python != "" {
+		options = append(options, codeinterpreter.WithPython(python))
+	}
+
+	return codeinterpreter.New(options...), nil
+}
+
+// newFilesystemTools creates the filesystem tools from their YAML configuration
+func newFilesystemTools(config map[string]interface{}) ([]interfaces.Tool, error) {
+	root := getConfigString(config, "root")
+	if root == "" {
+		return nil, fmt.Errorf("root is required for the filesystem tools")
+	}
+
+	var options []filesystem.Option
+	if readOnly, ok := config["read_only"].(bool); ok {
+		options = append(options, filesystem.WithReadOnly(readOnly))
+	}
+	if bytes, ok := getConfigInt(config, "max_file_size"); ok {
+		options = append(options, filesystem.WithMaxFileSize(int64(bytes)))
+	}
+	if results, ok := getConfigInt(config, "max_results"); ok {
+		options = append(options, filesystem.WithMaxResults(results))
+	}
+	if values, ok := config["require_approval"].([]interface{}); ok {
+		var operations []filesystem.Operation
+		for _, value := range values {
+			operation, err := filesystemOperation(value)
+			if err != nil {
+				return nil, err
+			}
+			operations = append(operations, operation)
+		}
+		options = append(options, filesystem.WithApproval(operations...))
+	}
+
+	workspace, err := filesystem.New(root, options...)
+	if err != nil {
+		return nil, err
+	}
+	return workspace.Tools(), nil
+}
+
+// filesystemOperation parses the name of a filesystem operation
+func filesystemOperation(value interface{}) (filesystem.Operation, error) {
+	name, _ := value.(string)
+	for _, operation := range filesystem.Operations() {
+		if string(operation) == name {
+			return operation, nil
+		}
+	}
+	return "", fmt.Errorf("unknown filesystem operation: %v", value)
+}
+
+// getConfigInt extracts an integer value from a config map
+func getConfigInt(config map[string]interface{}, key string) (int, bool) {
+	switch value := config[key].(type) {
+	case int:
+		return value, true
+	case int64:
+		return int(value), true
+	case float64:
+		return int(value), true
+	}
+	return 0, false
+}
+
 // CreateTool creates a tool from YAML configuration
 func (tf *ToolFactory) CreateTool(config ToolConfigYAML) (interfaces.Tool, error) {
 	return tf.CreateToolWithParentConfig(config, nil)
 }
 
+// CreateTools creates the tools of a YAML configuration. Builtin toolsets,
+// such as filesystem, create several tools; other configurations create one.
+func (tf *ToolFactory) CreateTools(config ToolConfigYAML) ([]interfaces.Tool, error) {
+	if config.Type == "builtin" {
+		if factory, exists := tf.builtinToolsets[config.Name]; exists {
+			return factory(config.Config)
+		}
+	}
+
+	tool, err := tf.CreateTool(config)
+	if err != nil {
+		return nil, err
+	}
+	return []interfaces.Tool{tool}, nil
+}
+
 // CreateToolWithParentConfig creates a tool from YAML configuration with access to parent agent config
 func (tf *ToolFactory) CreateToolWithParentConfig(config ToolConfigYAML, parentConfig *AgentConfig) (interfaces.Tool, error) {
 	switch config.Type {
@@ -67,6 +207,9 @@ func (tf *ToolFactory) CreateToolWithParentConfig(config ToolConfigYAML, parentC
 func (tf *ToolFactory) createBuiltinTool(config ToolConfigYAML) (interfaces.Tool, error) {
 	factory, exists := tf.builtinFactories[config.Name]
 	if !exists {
+		if _, isToolset := tf.builtinToolsets[config.Name]; isToolset {
+			return nil, fmt.Errorf("builtin %s creates several tools, use CreateTools", config.Name)
+		}
 		return nil, fmt.Errorf("unknown builtin tool: %s", config.Name)
 	}
 
@@ -13,6 +13,7 @@ const usageTrackerKey contextKey = "usageTracker"
 
 type usageTracker struct {
 	totalUsage   *interfaces.TokenUsage
+	totalCost    *interfaces.Cost
 	execSummary  *interfaces.ExecutionSummary
 	detailed     bool
 	primaryModel string
@@ -42,6 +43,8 @@ func (ut *usageTracker) addLLMUsage(usage *interfaces.TokenUsage, model string)
 	ut.totalUsage.OutputTokens += usage.OutputTokens
 	ut.totalUsage.TotalTokens += usage.TotalTokens
 	ut.totalUsage.ReasoningTokens += usage.ReasoningTokens
+	ut.totalUsage.CacheCreationInputTokens += usage.CacheCreationInputTokens
+	ut.totalUsage.CacheReadInputTokens += usage.CacheReadInputTokens
 	ut.execSummary.LLMCalls++
 
 	if ut.primaryModel == "" && model != "" {
@@ -49,6 +52,42 @@ func (ut *usageTracker) addLLMUsage(usage *interfaces.TokenUsage, model string)
 	}
 }
 
+// addCost adds the cost of an LLM call. The cost stays nil while no call
+// of the run has a known price.
+func (ut *usageTracker) addCost(cost interfaces.Cost) {
+	if !ut.detailed {
+		return
+	}
+
+	ut.mu.Lock()
+	defer ut.mu.Unlock()
+
+	if ut.totalCost == nil {
+		ut.totalCost = &interfaces.Cost{}
+	}
+	ut.totalCost.Add(cost)
+	ut.execSummary.TotalCost = ut.totalCost.Total
+}
+
+// spent returns the cost of the run so far
+func (ut *usageTracker) spent() float64 {
+	ut.mu.Lock()
+	defer ut.mu.Unlock()
+
+	return ut.execSummary.TotalCost
+}
+
+func (ut *usageTracker) setDowngradedModel(model string) {
+	if !ut.detailed {
+		return
+	}
+
+	ut.mu.Lock()
+	defer ut.mu.Unlock()
+
+	ut.execSummary.DowngradedModel = model
+}
+
 func (ut *usageTracker) addToolCall(toolName string) {
 	if !ut.detailed {
 		return
@@ -89,6 +128,17 @@ func (ut *usageTracker) getResults() (*interfaces.TokenUsage, *interfaces.Execut
 	return ut.totalUsage, ut.execSummary, ut.primaryModel
 }
 
+func (ut *usageTracker) getCost() *interfaces.Cost {
+	if !ut.detailed {
+		return nil
+	}
+
+	ut.mu.Lock()
+	defer ut.mu.Unlock()
+
+	return ut.totalCost
+}
+
 func withUsageTracker(ctx context.Context, tracker *usageTracker) context.Context {
 	return context.WithValue(ctx, usageTrackerKey, tracker)
 }
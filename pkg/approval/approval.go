@@ -0,0 +1,287 @@
+// Package approval pauses tool calls until a human approves them. Policies
+// decide which calls need approval. A pending call is persisted in a Store,
+// announced to the caller, and resumed once a decision to approve, deny or
+// edit its arguments arrives, in the same process or in another one sharing
+// the store.
+package approval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// Action is the decision taken on a tool call
+type Action string
+
+const (
+	// ActionApprove runs the tool call with its arguments
+	ActionApprove Action = "approve"
+
+	// ActionDeny does not run the tool call
+	ActionDeny Action = "deny"
+
+	// ActionEdit runs the tool call with the arguments of the decision
+	ActionEdit Action = "edit"
+)
+
+var (
+	// ErrNotFound is returned for unknown or expired approval requests
+	ErrNotFound = errors.New("approval request not found")
+
+	// ErrAlreadyDecided is returned when deciding a request twice
+	ErrAlreadyDecided = errors.New("approval request already decided")
+
+	// ErrPending is returned when resuming a request that is not decided
+	ErrPending = errors.New("approval request is pending")
+
+	// ErrAlreadyApplied is returned when the decision of a request was
+	// already applied by another run
+	ErrAlreadyApplied = errors.New("approval decision already applied")
+)
+
+// Decision is the decision taken on a pending tool call
+type Decision struct {
+	// Action is the decision taken
+	Action Action `json:"action"`
+
+	// Arguments replace the arguments of the call for ActionEdit
+	Arguments string `json:"arguments,omitempty"`
+
+	// Reason explains the decision. The reason of a denial is passed to the LLM.
+	Reason string `json:"reason,omitempty"`
+
+	// DecidedAt is the time of the decision
+	DecidedAt time.Time `json:"decided_at"`
+}
+
+// Validate checks that the decision can be applied
+func (d Decision) Validate() error {
+	switch d.Action {
+	case ActionApprove, ActionDeny:
+		return nil
+	case ActionEdit:
+		var args map[string]interface{}
+		if err := json.Unmarshal([]byte(d.Arguments), &args); err != nil {
+			return fmt.Errorf("edited arguments must be a JSON object: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown approval action %q", d.Action)
+	}
+}
+
+// Request is a tool call waiting for a decision
+type Request struct {
+	// ID identifies the request
+	ID string `json:"id"`
+
+	// ToolName is the name of the tool
+	ToolName string `json:"tool_name"`
+
+	// DisplayName is the display name of the tool
+	DisplayName string `json:"display_name,omitempty"`
+
+	// Arguments are the JSON arguments of the call
+	Arguments string `json:"arguments"`
+
+	// AgentName is the name of the agent that made the call
+	AgentName string `json:"agent_name,omitempty"`
+
+	// Input is the input of the run that made the call
+	Input string `json:"input,omitempty"`
+
+	// OrgID is the organization of the run
+	OrgID string `json:"org_id,omitempty"`
+
+	// ConversationID is the conversation of the run
+	ConversationID string `json:"conversation_id,omitempty"`
+
+	// CreatedAt is the time of the call
+	CreatedAt time.Time `json:"created_at"`
+
+	// History holds the messages of the run up to the suspended call, with
+	// which the run is resumed. It is set when the run is suspended.
+	History []interfaces.Message `json:"history,omitempty"`
+
+	// Decision is the decision taken on the call, nil while it is pending
+	Decision *Decision `json:"decision,omitempty"`
+
+	// Waiting reports whether a run is waiting for the decision
+	Waiting bool `json:"waiting"`
+
+	// Applied reports whether the decision was applied
+	Applied bool `json:"applied"`
+}
+
+// DeniedError is returned to the LLM for denied tool calls
+type DeniedError struct {
+	// Tool is the name of the tool
+	Tool string
+
+	// Reason is the reason of the denial
+	Reason string
+}
+
+func (e *DeniedError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("the call to tool %s was denied by the user", e.Tool)
+	}
+	return fmt.Sprintf("the call to tool %s was denied by the user: %s", e.Tool, e.Reason)
+}
+
+// SuspendedError is returned to the LLM when a run stops waiting for the
+// decision on a tool call. The tool-calling loop of the run then stops and the
+// run returns it. The call is applied when the run is resumed.
+type SuspendedError struct {
+	// Tool is the name of the tool
+	Tool string
+
+	// ID is the ID of the approval request
+	ID string
+}
+
+func (e *SuspendedError) Error() string {
+	return fmt.Sprintf("the call to tool %s is waiting for approval (approval ID %s); it will run once it is approved, tell the user that approval is required", e.Tool, e.ID)
+}
+
+// Is reports whether the target is interfaces.ErrRunSuspended
+func (e *SuspendedError) Is(target error) bool {
+	return target == interfaces.ErrRunSuspended
+}
+
+// Policy decides which tool calls need approval
+type Policy interface {
+	// RequiresApproval reports whether a call to a tool with JSON arguments needs approval
+	RequiresApproval(tool, arguments string) bool
+}
+
+// ToolPolicy is implemented by tools that select their own calls that need
+// approval. It applies to the tools without a policy set with WithPolicy.
+// Wrappers of tools forward it by implementing Unwrap() interfaces.Tool.
+type ToolPolicy interface {
+	RequiresApproval(arguments string) bool
+}
+
+// ToolPolicyOf returns the policy of a tool, or of the tool wrapped by it,
+// and whether it has one
+func ToolPolicyOf(tool interfaces.Tool) (ToolPolicy, bool) {
+	for tool != nil {
+		if policy, ok := tool.(ToolPolicy); ok {
+			return policy, true
+		}
+		wrapper, ok := tool.(interface{ Unwrap() interfaces.Tool })
+		if !ok {
+			break
+		}
+		tool = wrapper.Unwrap()
+	}
+	return nil, false
+}
+
+// PolicyFunc is a function that implements Policy
+type PolicyFunc func(tool, arguments string) bool
+
+// RequiresApproval calls f
+func (f PolicyFunc) RequiresApproval(tool, arguments string) bool {
+	return f(tool, arguments)
+}
+
+// Always requires approval for every call
+func Always() Policy {
+	return PolicyFunc(func(tool, arguments string) bool { return true })
+}
+
+// Never runs every call without approval
+func Never() Policy {
+	return PolicyFunc(func(tool, arguments string) bool { return false })
+}
+
+// WhenArguments requires approval for calls whose JSON arguments match a
+// regular expression. It panics if the expression does not compile.
+func WhenArguments(pattern string) Policy {
+	re := regexp.MustCompile(pattern)
+	return PolicyFunc(func(tool, arguments string) bool {
+		return re.MatchString(arguments)
+	})
+}
+
+// WhenArgument requires approval for calls with a top-level argument whose
+// value matches a regular expression. Values that are not strings are
+// matched as JSON. Calls with invalid arguments require approval. It panics
+// if the expression does not compile.
+func WhenArgument(name, pattern string) Policy {
+	re := regexp.MustCompile(pattern)
+	return PolicyFunc(func(tool, arguments string) bool {
+		var args map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+			return true
+		}
+		raw, ok := args[name]
+		if !ok {
+			return false
+		}
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			value = string(raw)
+		}
+		return re.MatchString(value)
+	})
+}
+
+type runKey struct{}
+
+type runInfo struct {
+	agentName string
+	input     string
+	state     *runState
+}
+
+// runState records the first tool call of a run that was suspended
+type runState struct {
+	mu        sync.Mutex
+	suspended *SuspendedError
+}
+
+// WithRun returns a context with the agent and input of the run, which are
+// stored with the approval requests of its tool calls so that the run can be
+// resumed by another process. The context also records whether the run was
+// suspended, see Suspended.
+func WithRun(ctx context.Context, agentName, input string) context.Context {
+	return context.WithValue(ctx, runKey{}, runInfo{agentName: agentName, input: input, state: &runState{}})
+}
+
+func runFromContext(ctx context.Context) runInfo {
+	info, _ := ctx.Value(runKey{}).(runInfo)
+	return info
+}
+
+// Suspended returns the error of the first tool call of the run of ctx that
+// was suspended, or nil while no call was suspended
+func Suspended(ctx context.Context) *SuspendedError {
+	state := runFromContext(ctx).state
+	if state == nil {
+		return nil
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	return state.suspended
+}
+
+// suspend records a suspended tool call of the run of ctx
+func (r runInfo) suspend(err *SuspendedError) {
+	if r.state == nil {
+		return
+	}
+	r.state.mu.Lock()
+	defer r.state.mu.Unlock()
+	if r.state.suspended == nil {
+		r.state.suspended = err
+	}
+}
@@ -0,0 +1,378 @@
+package approval
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/memory"
+	"github.com/tagus/agent-sdk-go/pkg/multitenancy"
+)
+
+// recordingTool records the arguments of its calls
+type recordingTool struct {
+	calls []string
+}
+
+func (t *recordingTool) Name() string        { return "delete_file" }
+func (t *recordingTool) Description() string { return "Deletes a file" }
+func (t *recordingTool) Parameters() map[string]interfaces.ParameterSpec {
+	return map[string]interfaces.ParameterSpec{
+		"path": {Type: "string", Required: true},
+	}
+}
+func (t *recordingTool) Run(ctx context.Context, input string) (string, error) {
+	return t.Execute(ctx, input)
+}
+func (t *recordingTool) Execute(ctx context.Context, args string) (string, error) {
+	t.calls = append(t.calls, args)
+	return "deleted " + args, nil
+}
+
+func TestPolicies(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		args   string
+		want   bool
+	}{
+		{"always", Always(), `{}`, true},
+		{"never", Never(), `{}`, false},
+		{"arguments match", WhenArguments(`/etc/`), `{"path":"/etc/passwd"}`, true},
+		{"arguments do not match", WhenArguments(`/etc/`), `{"path":"/tmp/x"}`, false},
+		{"argument matches", WhenArgument("path", `^/etc/`), `{"path":"/etc/passwd"}`, true},
+		{"argument does not match", WhenArgument("path", `^/etc/`), `{"path":"/tmp/etc/x"}`, false},
+		{"argument missing", WhenArgument("path", `.*`), `{"other":"x"}`, false},
+		{"non-string argument", WhenArgument("amount", `^[0-9]{4,}$`), `{"amount":25000}`, true},
+		{"invalid arguments", WhenArgument("path", `^/etc/`), `not json`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.policy.RequiresApproval("tool", tt.args))
+		})
+	}
+}
+
+func TestDecision_Validate(t *testing.T) {
+	assert.NoError(t, Decision{Action: ActionApprove}.Validate())
+	assert.NoError(t, Decision{Action: ActionDeny}.Validate())
+	assert.NoError(t, Decision{Action: ActionEdit, Arguments: `{"path":"/tmp/x"}`}.Validate())
+	assert.Error(t, Decision{Action: ActionEdit, Arguments: `[1]`}.Validate())
+	assert.Error(t, Decision{Action: "maybe"}.Validate())
+}
+
+func testStore(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	_, err := store.Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, store.Decide(ctx, "missing", Decision{Action: ActionApprove}), ErrNotFound)
+
+	req := &Request{ID: "req-1", ToolName: "delete_file", Arguments: `{"path":"/tmp/x"}`, OrgID: "org-1", CreatedAt: time.Now()}
+	require.NoError(t, store.Create(ctx, req, time.Hour))
+
+	got, err := store.Get(ctx, "req-1")
+	require.NoError(t, err)
+	assert.Equal(t, "delete_file", got.ToolName)
+	assert.Equal(t, "org-1", got.OrgID)
+	assert.Nil(t, got.Decision)
+	assert.False(t, got.Waiting)
+
+	assert.ErrorIs(t, store.SetHistory(ctx, "missing", nil), ErrNotFound)
+	history := []interfaces.Message{
+		{Role: interfaces.MessageRoleUser, Content: "clean up"},
+		{Role: interfaces.MessageRoleAssistant, ToolCalls: []interfaces.ToolCall{{ID: "call-1", Name: "delete_file", Arguments: `{"path":"/tmp/x"}`}}},
+	}
+	require.NoError(t, store.SetHistory(ctx, "req-1", history))
+	got, err = store.Get(ctx, "req-1")
+	require.NoError(t, err)
+	assert.Equal(t, history, got.History)
+	assert.Equal(t, "org-1", got.OrgID)
+
+	require.NoError(t, store.Wait(ctx, "req-1", time.Now().Add(time.Minute)))
+	got, err = store.Get(ctx, "req-1")
+	require.NoError(t, err)
+	assert.True(t, got.Waiting)
+
+	require.NoError(t, store.Wait(ctx, "req-1", time.Time{}))
+	got, err = store.Get(ctx, "req-1")
+	require.NoError(t, err)
+	assert.False(t, got.Waiting)
+
+	require.NoError(t, store.Decide(ctx, "req-1", Decision{Action: ActionDeny, Reason: "no"}))
+	assert.ErrorIs(t, store.Decide(ctx, "req-1", Decision{Action: ActionApprove}), ErrAlreadyDecided)
+
+	got, err = store.Get(ctx, "req-1")
+	require.NoError(t, err)
+	require.NotNil(t, got.Decision)
+	assert.Equal(t, ActionDeny, got.Decision.Action)
+	assert.Equal(t, "no", got.Decision.Reason)
+	assert.False(t, got.Applied)
+
+	claimed, err := store.Claim(ctx, "req-1")
+	require.NoError(t, err)
+	assert.True(t, claimed)
+	claimed, err = store.Claim(ctx, "req-1")
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	got, err = store.Get(ctx, "req-1")
+	require.NoError(t, err)
+	assert.True(t, got.Applied)
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestMemoryStore_Expiry(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	require.NoError(t, store.Create(context.Background(), &Request{ID: "req-1"}, time.Minute))
+	now = now.Add(2 * time.Minute)
+
+	_, err := store.Get(context.Background(), "req-1")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestRedisStore(t *testing.T) {
+	server, err := miniredis.Run()
+	require.NoError(t, err)
+	defer server.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	store := NewRedisStore(client, WithRedisPrefix("test:"))
+	testStore(t, store)
+
+	assert.True(t, server.Exists("test:request:req-1"))
+	assert.True(t, server.Exists("test:decision:req-1"))
+
+	// Every key expires with the request
+	server.FastForward(2 * time.Hour)
+	_, err = store.Get(context.Background(), "req-1")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.False(t, server.Exists("test:decision:req-1"))
+	assert.False(t, server.Exists("test:claim:req-1"))
+}
+
+// startCall executes a wrapped tool in the background and returns the
+// approval request it creates
+func startCall(t *testing.T, ctx context.Context, approver *Approver, tool interfaces.Tool, args string) (*Request, <-chan callResult) {
+	requests := make(chan *Request, 1)
+	approver.notifier = func(ctx context.Context, req *Request) { requests <- req }
+
+	results := make(chan callResult, 1)
+	wrapped := approver.Wrap([]interfaces.Tool{tool})[0]
+	go func() {
+		output, err := wrapped.Execute(ctx, args)
+		results <- callResult{output, err}
+	}()
+
+	select {
+	case req := <-requests:
+		return req, results
+	case <-time.After(5 * time.Second):
+		t.Fatal("no approval request")
+		return nil, nil
+	}
+}
+
+type callResult struct {
+	output string
+	err    error
+}
+
+func waitResult(t *testing.T, results <-chan callResult) callResult {
+	select {
+	case result := <-results:
+		return result
+	case <-time.After(5 * time.Second):
+		t.Fatal("the call did not return")
+		return callResult{}
+	}
+}
+
+// policyTool selects its own calls that need approval
+type policyTool struct {
+	recordingTool
+}
+
+func (t *policyTool) RequiresApproval(arguments string) bool { return true }
+
+// wrapperTool wraps a tool without forwarding its interfaces
+type wrapperTool struct {
+	interfaces.Tool
+}
+
+func (t *wrapperTool) Unwrap() interfaces.Tool { return t.Tool }
+
+func TestToolPolicyOf(t *testing.T) {
+	tool := &policyTool{}
+	_, ok := ToolPolicyOf(&recordingTool{})
+	assert.False(t, ok)
+
+	policy, ok := ToolPolicyOf(&wrapperTool{&wrapperTool{tool}})
+	require.True(t, ok)
+	assert.Same(t, tool, policy)
+
+	// The policy of a wrapped tool pauses its calls
+	approver := New(WithTimeout(time.Millisecond), WithPollInterval(time.Millisecond))
+	_, err := approver.Wrap([]interfaces.Tool{&wrapperTool{tool}})[0].Execute(context.Background(), `{"path":"/tmp/x"}`)
+	assert.ErrorIs(t, err, interfaces.ErrRunSuspended)
+	assert.Empty(t, tool.calls)
+}
+
+func TestApprover_PolicyNotMatched(t *testing.T) {
+	tool := &recordingTool{}
+	approver := New(WithPolicy("delete_file", WhenArgument("path", `^/etc/`)))
+
+	output, err := approver.Wrap([]interfaces.Tool{tool})[0].Execute(context.Background(), `{"path":"/tmp/x"}`)
+	require.NoError(t, err)
+	assert.Equal(t, `deleted {"path":"/tmp/x"}`, output)
+}
+
+func TestApprover_WaitingRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		decision Decision
+		output   string
+		calls    []string
+		denied   bool
+	}{
+		{
+			name:     "approve",
+			decision: Decision{Action: ActionApprove},
+			output:   `deleted {"path":"/etc/passwd"}`,
+			calls:    []string{`{"path":"/etc/passwd"}`},
+		},
+		{
+			name:     "edit",
+			decision: Decision{Action: ActionEdit, Arguments: `{"path":"/etc/motd"}`},
+			output:   `deleted {"path":"/etc/motd"}`,
+			calls:    []string{`{"path":"/etc/motd"}`},
+		},
+		{
+			name:     "deny",
+			decision: Decision{Action: ActionDeny, Reason: "too risky"},
+			denied:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := &recordingTool{}
+			approver := New(WithDefaultPolicy(Always()), WithPollInterval(10*time.Millisecond))
+
+			ctx := multitenancy.WithOrgID(context.Background(), "org-1")
+			ctx = memory.WithConversationID(ctx, "conv-1")
+			ctx = WithRun(ctx, "assistant", "clean up")
+
+			req, results := startCall(t, ctx, approver, tool, `{"path":"/etc/passwd"}`)
+			assert.Equal(t, "delete_file", req.ToolName)
+			assert.Equal(t, "org-1", req.OrgID)
+			assert.Equal(t, "conv-1", req.ConversationID)
+			assert.Equal(t, "assistant", req.AgentName)
+			assert.Equal(t, "clean up", req.Input)
+
+			require.NoError(t, approver.Resolve(context.Background(), req.ID, tt.decision))
+			result := waitResult(t, results)
+
+			if tt.denied {
+				var denied *DeniedError
+				require.True(t, errors.As(result.err, &denied))
+				assert.Contains(t, denied.Error(), "too risky")
+				assert.Empty(t, tool.calls)
+				return
+			}
+			require.NoError(t, result.err)
+			assert.Equal(t, tt.output, result.output)
+			assert.Equal(t, tt.calls, tool.calls)
+		})
+	}
+}
+
+func TestApprover_SuspendAndApply(t *testing.T) {
+	tool := &recordingTool{}
+	approver := New(
+		WithDefaultPolicy(Always()),
+		WithTimeout(30*time.Millisecond),
+		WithPollInterval(10*time.Millisecond),
+	)
+
+	req, results := startCall(t, context.Background(), approver, tool, `{"path":"/etc/passwd"}`)
+	result := waitResult(t, results)
+
+	var suspended *SuspendedError
+	require.True(t, errors.As(result.err, &suspended))
+	assert.Equal(t, req.ID, suspended.ID)
+
+	stored, err := approver.Get(context.Background(), req.ID)
+	require.NoError(t, err)
+	assert.False(t, stored.Waiting)
+
+	_, err = approver.Apply(context.Background(), req.ID, tool)
+	assert.ErrorIs(t, err, ErrPending)
+
+	require.NoError(t, approver.Resolve(context.Background(), req.ID, Decision{Action: ActionApprove}))
+	output, err := approver.Apply(context.Background(), req.ID, tool)
+	require.NoError(t, err)
+	assert.Equal(t, `deleted {"path":"/etc/passwd"}`, output)
+
+	_, err = approver.Apply(context.Background(), req.ID, tool)
+	assert.ErrorIs(t, err, ErrAlreadyApplied)
+	assert.Len(t, tool.calls, 1)
+}
+
+func TestApprover_SuspendedRun(t *testing.T) {
+	tool := &recordingTool{}
+	approver := New(WithDefaultPolicy(Always()), WithTimeout(time.Millisecond), WithPollInterval(time.Millisecond))
+	wrapped := approver.Wrap([]interfaces.Tool{tool})[0]
+
+	ctx := WithRun(context.Background(), "assistant", "clean up")
+	assert.Nil(t, Suspended(ctx))
+
+	_, err := wrapped.Execute(ctx, `{"path":"/etc/passwd"}`)
+	assert.ErrorIs(t, err, interfaces.ErrRunSuspended)
+	suspended := Suspended(ctx)
+	require.NotNil(t, suspended)
+
+	// Other calls of the suspended run are not paused
+	_, err = wrapped.Execute(ctx, `{"path":"/etc/hosts"}`)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, interfaces.ErrRunSuspended)
+	assert.Contains(t, err.Error(), suspended.ID)
+	assert.Same(t, suspended, Suspended(ctx))
+	assert.Empty(t, tool.calls)
+}
+
+func TestApprover_StreamsApprovalRequest(t *testing.T) {
+	approver := New(WithDefaultPolicy(Always()), WithPollInterval(10*time.Millisecond))
+
+	events := make(chan interfaces.AgentStreamEvent, 1)
+	ctx := context.WithValue(context.Background(), interfaces.StreamForwarderKey,
+		interfaces.StreamForwarder(func(event interfaces.AgentStreamEvent) { events <- event }))
+
+	req, results := startCall(t, ctx, approver, &recordingTool{}, `{"path":"/etc/passwd"}`)
+
+	event := <-events
+	assert.Equal(t, interfaces.AgentEventApprovalRequest, event.Type)
+	assert.Equal(t, req.ID, event.Metadata["approval_id"])
+	require.NotNil(t, event.ToolCall)
+	assert.Equal(t, "delete_file", event.ToolCall.Name)
+	assert.Equal(t, "pending_approval", event.ToolCall.Status)
+
+	require.NoError(t, approver.Resolve(context.Background(), req.ID, Decision{Action: ActionApprove}))
+	require.NoError(t, waitResult(t, results).err)
+}
@@ -0,0 +1,338 @@
+package approval
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/memory"
+	"github.com/tagus/agent-sdk-go/pkg/multitenancy"
+)
+
+// Approver pauses the tool calls that its policies select until a decision
+// on them is resolved
+type Approver struct {
+	store         Store
+	policies      map[string]Policy
+	defaultPolicy Policy
+	timeout       time.Duration
+	pollInterval  time.Duration
+	retention     time.Duration
+	notifier      func(ctx context.Context, req *Request)
+}
+
+// Option represents an option for configuring an Approver
+type Option func(*Approver)
+
+// WithStore sets the store of the approval requests. Use a store shared
+// between processes, such as RedisStore, to resume runs in another process.
+func WithStore(store Store) Option {
+	return func(a *Approver) {
+		a.store = store
+	}
+}
+
+// WithPolicy sets the policy of a tool
+func WithPolicy(toolName string, policy Policy) Option {
+	return func(a *Approver) {
+		a.policies[toolName] = policy
+	}
+}
+
+// WithDefaultPolicy sets the policy of the tools without a policy of their own
+func WithDefaultPolicy(policy Policy) Option {
+	return func(a *Approver) {
+		a.defaultPolicy = policy
+	}
+}
+
+// WithTimeout sets how long a run waits for a decision before it is
+// suspended. Zero waits until the context of the run is done.
+func WithTimeout(timeout time.Duration) Option {
+	return func(a *Approver) {
+		a.timeout = timeout
+	}
+}
+
+// WithPollInterval sets how often a waiting run checks the store for a decision
+func WithPollInterval(interval time.Duration) Option {
+	return func(a *Approver) {
+		a.pollInterval = interval
+	}
+}
+
+// WithRetention sets how long approval requests are kept in the store
+func WithRetention(retention time.Duration) Option {
+	return func(a *Approver) {
+		a.retention = retention
+	}
+}
+
+// WithNotifier sets a function called with every new approval request, for
+// example to notify reviewers of runs that are not streamed
+func WithNotifier(notifier func(ctx context.Context, req *Request)) Option {
+	return func(a *Approver) {
+		a.notifier = notifier
+	}
+}
+
+// New creates an Approver. By default, requests are kept in memory and no
+// tool requires approval.
+func New(options ...Option) *Approver {
+	a := &Approver{
+		store:         NewMemoryStore(),
+		policies:      make(map[string]Policy),
+		defaultPolicy: Never(),
+		pollInterval:  time.Second,
+		retention:     24 * time.Hour,
+	}
+	for _, option := range options {
+		option(a)
+	}
+	return a
+}
+
+// RequiresApproval reports whether a call to a tool needs approval
+func (a *Approver) RequiresApproval(tool, arguments string) bool {
+	if policy, ok := a.policies[tool]; ok {
+		return policy.RequiresApproval(tool, arguments)
+	}
+	return a.defaultPolicy.RequiresApproval(tool, arguments)
+}
+
+// requiresApproval reports whether a call to a tool needs approval, taking
+// the policy of the tool itself into account
+func (a *Approver) requiresApproval(tool interfaces.Tool, arguments string) bool {
+	if _, ok := a.policies[tool.Name()]; !ok {
+		if policy, ok := ToolPolicyOf(tool); ok && policy.RequiresApproval(arguments) {
+			return true
+		}
+	}
+	return a.RequiresApproval(tool.Name(), arguments)
+}
+
+// Wrap wraps tools so that their calls are paused until they are approved
+// when their policy requires it. The wrappers keep the display name,
+// internal flag and schema of the tools.
+func (a *Approver) Wrap(tools []interfaces.Tool) []interfaces.Tool {
+	wrapped := make([]interfaces.Tool, len(tools))
+	for i, tool := range tools {
+		if t, ok := tool.(*approvalTool); ok && t.approver == a {
+			wrapped[i] = tool
+			continue
+		}
+		wrapped[i] = &approvalTool{Tool: tool, approver: a}
+	}
+	return wrapped
+}
+
+// Get returns an approval request
+func (a *Approver) Get(ctx context.Context, id string) (*Request, error) {
+	return a.store.Get(ctx, id)
+}
+
+// Resolve records the decision on an approval request. A run waiting for it
+// applies the decision; otherwise the run is suspended and Apply resumes it.
+func (a *Approver) Resolve(ctx context.Context, id string, decision Decision) error {
+	if err := decision.Validate(); err != nil {
+		return err
+	}
+	if decision.DecidedAt.IsZero() {
+		decision.DecidedAt = time.Now()
+	}
+	return a.store.Decide(ctx, id, decision)
+}
+
+// Apply applies the decision on an approval request whose run was suspended,
+// and returns the result of the tool call. It returns ErrPending if the
+// request is not decided, and ErrAlreadyApplied if the decision was applied.
+func (a *Approver) Apply(ctx context.Context, id string, tool interfaces.Tool) (string, error) {
+	req, err := a.store.Get(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if req.Decision == nil {
+		return "", ErrPending
+	}
+	if tool.Name() != req.ToolName {
+		return "", fmt.Errorf("approval request %s is for tool %s, not %s", id, req.ToolName, tool.Name())
+	}
+	return a.apply(ctx, req, tool)
+}
+
+// SaveHistory stores the message history of the run of a request when the
+// run is suspended, so that the run can be resumed with it
+func (a *Approver) SaveHistory(ctx context.Context, id string, history []interfaces.Message) error {
+	if err := a.store.SetHistory(ctx, id, history); err != nil {
+		return fmt.Errorf("failed to save the history of approval request %s: %w", id, err)
+	}
+	return nil
+}
+
+// apply claims the decision on a request and executes or denies the call
+func (a *Approver) apply(ctx context.Context, req *Request, tool interfaces.Tool) (string, error) {
+	claimed, err := a.store.Claim(ctx, req.ID)
+	if err != nil {
+		return "", err
+	}
+	if !claimed {
+		return "", ErrAlreadyApplied
+	}
+
+	switch req.Decision.Action {
+	case ActionDeny:
+		return "", &DeniedError{Tool: req.ToolName, Reason: req.Decision.Reason}
+	case ActionEdit:
+		return tool.Execute(ctx, req.Decision.Arguments)
+	default:
+		return tool.Execute(ctx, req.Arguments)
+	}
+}
+
+// request creates and announces the approval request of a call
+func (a *Approver) request(ctx context.Context, tool interfaces.Tool, arguments string) (*Request, error) {
+	run := runFromContext(ctx)
+	req := &Request{
+		ID:        uuid.New().String(),
+		ToolName:  tool.Name(),
+		Arguments: arguments,
+		AgentName: run.agentName,
+		Input:     run.input,
+		CreatedAt: time.Now(),
+	}
+	if named, ok := tool.(interfaces.ToolWithDisplayName); ok {
+		req.DisplayName = named.DisplayName()
+	}
+	if orgID, err := multitenancy.GetOrgID(ctx); err == nil {
+		req.OrgID = orgID
+	}
+	if conversationID, ok := memory.GetConversationID(ctx); ok {
+		req.ConversationID = conversationID
+	}
+
+	if err := a.store.Create(ctx, req, a.retention); err != nil {
+		return nil, fmt.Errorf("failed to create approval request: %w", err)
+	}
+
+	if a.notifier != nil {
+		a.notifier(ctx, req)
+	}
+	if forwarder, ok := ctx.Value(interfaces.StreamForwarderKey).(interfaces.StreamForwarder); ok && forwarder != nil {
+		forwarder(interfaces.AgentStreamEvent{
+			Type: interfaces.AgentEventApprovalRequest,
+			ToolCall: &interfaces.ToolCallEvent{
+				Name:        req.ToolName,
+				DisplayName: req.DisplayName,
+				Arguments:   req.Arguments,
+				Status:      "pending_approval",
+			},
+			Metadata: map[string]interface{}{
+				"approval_id": req.ID,
+			},
+			Timestamp: req.CreatedAt,
+		})
+	}
+	return req, nil
+}
+
+// wait polls the store for the decision on a request and applies it, or
+// suspends the run when the timeout expires or the context is done
+func (a *Approver) wait(ctx context.Context, req *Request, tool interfaces.Tool) (string, error) {
+	var deadline <-chan time.Time
+	if a.timeout > 0 {
+		timer := time.NewTimer(a.timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	ticker := time.NewTicker(a.pollInterval)
+	defer ticker.Stop()
+
+	suspend := func() (string, error) {
+		// The context of the run may be done, so the lease is released
+		// without it
+		_ = a.store.Wait(context.Background(), req.ID, time.Time{})
+		err := &SuspendedError{Tool: req.ToolName, ID: req.ID}
+		runFromContext(ctx).suspend(err)
+		return "", err
+	}
+
+	for {
+		// The lease outlives a few polls, so that a missed poll does not
+		// make the run look gone
+		if err := a.store.Wait(ctx, req.ID, time.Now().Add(3*a.pollInterval)); err != nil {
+			return "", fmt.Errorf("failed to wait for approval: %w", err)
+		}
+		current, err := a.store.Get(ctx, req.ID)
+		if err != nil {
+			return "", fmt.Errorf("failed to wait for approval: %w", err)
+		}
+		if current.Decision != nil {
+			_ = a.store.Wait(ctx, req.ID, time.Time{})
+			return a.apply(ctx, current, tool)
+		}
+
+		select {
+		case <-ctx.Done():
+			return suspend()
+		case <-deadline:
+			return suspend()
+		case <-ticker.C:
+		}
+	}
+}
+
+// approvalTool pauses the calls of a tool until they are approved
+type approvalTool struct {
+	interfaces.Tool
+
+	approver *Approver
+}
+
+// Execute executes the tool once the call is approved
+func (t *approvalTool) Execute(ctx context.Context, args string) (string, error) {
+	if !t.approver.requiresApproval(t.Tool, args) {
+		return t.Tool.Execute(ctx, args)
+	}
+	// The run stops after the tool calls of this turn, so other calls are
+	// not paused until it is resumed
+	if suspended := Suspended(ctx); suspended != nil {
+		return "", fmt.Errorf("the call to tool %s was not run because the run is suspended waiting for approval (approval ID %s)", t.Name(), suspended.ID)
+	}
+	req, err := t.approver.request(ctx, t.Tool, args)
+	if err != nil {
+		return "", err
+	}
+	return t.approver.wait(ctx, req, t.Tool)
+}
+
+// Unwrap returns the wrapped tool
+func (t *approvalTool) Unwrap() interfaces.Tool {
+	return t.Tool
+}
+
+// DisplayName implements interfaces.ToolWithDisplayName.DisplayName
+func (t *approvalTool) DisplayName() string {
+	if tool, ok := t.Tool.(interfaces.ToolWithDisplayName); ok {
+		return tool.DisplayName()
+	}
+	return ""
+}
+
+// Internal implements interfaces.InternalTool.Internal
+func (t *approvalTool) Internal() bool {
+	if tool, ok := t.Tool.(interfaces.InternalTool); ok {
+		return tool.Internal()
+	}
+	return false
+}
+
+// JSONSchema implements interfaces.ToolWithJSONSchema.JSONSchema
+func (t *approvalTool) JSONSchema() interfaces.JSONSchema {
+	if tool, ok := t.Tool.(interfaces.ToolWithJSONSchema); ok {
+		return tool.JSONSchema()
+	}
+	return nil
+}
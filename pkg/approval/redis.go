@@ -0,0 +1,210 @@
+package approval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// DefaultRedisPrefix is prepended to every Redis key
+const DefaultRedisPrefix = "approval:"
+
+// RedisStore stores approval requests in Redis, so that a request paused in
+// one process can be decided on and resumed in another. The request, its
+// decision, the run waiting for it and the claim of the decision are kept in
+// separate keys, which expire with the request.
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// RedisOption represents an option for configuring the Redis store
+type RedisOption func(*RedisStore)
+
+// WithRedisPrefix sets the prefix of the Redis keys
+func WithRedisPrefix(prefix string) RedisOption {
+	return func(s *RedisStore) {
+		s.prefix = prefix
+	}
+}
+
+// NewRedisStore creates a Redis request store on an existing client, such as
+// the one used by memory.RedisMemory
+func NewRedisStore(client redis.UniversalClient, options ...RedisOption) *RedisStore {
+	s := &RedisStore{
+		client: client,
+		prefix: DefaultRedisPrefix,
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+func (s *RedisStore) requestKey(id string) string {
+	return s.prefix + "request:" + id
+}
+
+func (s *RedisStore) decisionKey(id string) string {
+	return s.prefix + "decision:" + id
+}
+
+func (s *RedisStore) waitKey(id string) string {
+	return s.prefix + "wait:" + id
+}
+
+func (s *RedisStore) claimKey(id string) string {
+	return s.prefix + "claim:" + id
+}
+
+// Create stores a pending request
+func (s *RedisStore) Create(ctx context.Context, req *Request, ttl time.Duration) error {
+	stored := *req
+	stored.Decision = nil
+	stored.Waiting = false
+	stored.Applied = false
+	data, err := json.Marshal(&stored)
+	if err != nil {
+		return fmt.Errorf("failed to encode approval request: %w", err)
+	}
+	if err := s.client.Set(ctx, s.requestKey(req.ID), data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to store approval request in redis: %w", err)
+	}
+	return nil
+}
+
+// Get returns a request
+func (s *RedisStore) Get(ctx context.Context, id string) (*Request, error) {
+	pipe := s.client.Pipeline()
+	requestCmd := pipe.Get(ctx, s.requestKey(id))
+	decisionCmd := pipe.Get(ctx, s.decisionKey(id))
+	waitCmd := pipe.Exists(ctx, s.waitKey(id))
+	claimCmd := pipe.Exists(ctx, s.claimKey(id))
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to read approval request from redis: %w", err)
+	}
+
+	data, err := requestCmd.Bytes()
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read approval request from redis: %w", err)
+	}
+	var req Request
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, fmt.Errorf("failed to decode approval request: %w", err)
+	}
+
+	if data, err := decisionCmd.Bytes(); err == nil {
+		var decision Decision
+		if err := json.Unmarshal(data, &decision); err != nil {
+			return nil, fmt.Errorf("failed to decode approval decision: %w", err)
+		}
+		req.Decision = &decision
+	}
+	req.Waiting = waitCmd.Val() > 0
+	req.Applied = claimCmd.Val() > 0
+	return &req, nil
+}
+
+// ttl returns the remaining TTL of a request, or ErrNotFound
+func (s *RedisStore) ttl(ctx context.Context, id string) (time.Duration, error) {
+	ttl, err := s.client.PTTL(ctx, s.requestKey(id)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read approval request from redis: %w", err)
+	}
+	// PTTL returns -2 for missing keys and -1 for keys without expiry
+	if ttl == -2 {
+		return 0, ErrNotFound
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+// Decide records the decision on a request
+func (s *RedisStore) Decide(ctx context.Context, id string, decision Decision) error {
+	ttl, err := s.ttl(ctx, id)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(decision)
+	if err != nil {
+		return fmt.Errorf("failed to encode approval decision: %w", err)
+	}
+	ok, err := s.client.SetNX(ctx, s.decisionKey(id), data, ttl).Result()
+	if err != nil {
+		return fmt.Errorf("failed to store approval decision in redis: %w", err)
+	}
+	if !ok {
+		return ErrAlreadyDecided
+	}
+	return nil
+}
+
+// Wait records that a run waits for the decision on a request
+func (s *RedisStore) Wait(ctx context.Context, id string, until time.Time) error {
+	if _, err := s.ttl(ctx, id); err != nil {
+		return err
+	}
+	lease := time.Until(until)
+	if lease <= 0 {
+		if err := s.client.Del(ctx, s.waitKey(id)).Err(); err != nil {
+			return fmt.Errorf("failed to update approval request in redis: %w", err)
+		}
+		return nil
+	}
+	if err := s.client.Set(ctx, s.waitKey(id), "1", lease).Err(); err != nil {
+		return fmt.Errorf("failed to update approval request in redis: %w", err)
+	}
+	return nil
+}
+
+// Claim marks the decision on a request as applied
+func (s *RedisStore) Claim(ctx context.Context, id string) (bool, error) {
+	ttl, err := s.ttl(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	ok, err := s.client.SetNX(ctx, s.claimKey(id), "1", ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim approval request in redis: %w", err)
+	}
+	return ok, nil
+}
+
+// SetHistory stores the message history of the suspended run of a request.
+// The request is rewritten with its remaining TTL.
+func (s *RedisStore) SetHistory(ctx context.Context, id string, history []interfaces.Message) error {
+	data, err := s.client.Get(ctx, s.requestKey(id)).Bytes()
+	if err == redis.Nil {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read approval request from redis: %w", err)
+	}
+	var req Request
+	if err := json.Unmarshal(data, &req); err != nil {
+		return fmt.Errorf("failed to decode approval request: %w", err)
+	}
+	req.History = history
+	data, err = json.Marshal(&req)
+	if err != nil {
+		return fmt.Errorf("failed to encode approval request: %w", err)
+	}
+	ok, err := s.client.SetXX(ctx, s.requestKey(id), data, redis.KeepTTL).Result()
+	if err != nil {
+		return fmt.Errorf("failed to update approval request in redis: %w", err)
+	}
+	if !ok {
+		return ErrNotFound
+	}
+	return nil
+}
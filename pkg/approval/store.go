@@ -0,0 +1,160 @@
+package approval
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// Store persists approval requests. Stores shared between processes, such as
+// RedisStore, let any process decide on and resume a request. Implementations
+// must be safe for concurrent use.
+type Store interface {
+	// Create stores a pending request, which expires after the TTL
+	Create(ctx context.Context, req *Request, ttl time.Duration) error
+
+	// Get returns a request with its decision and state, or ErrNotFound
+	Get(ctx context.Context, id string) (*Request, error)
+
+	// Decide records the decision on a request, or returns ErrAlreadyDecided
+	Decide(ctx context.Context, id string, decision Decision) error
+
+	// Wait records that a run waits for the decision on a request until the
+	// given time, after which the run is considered gone
+	Wait(ctx context.Context, id string, until time.Time) error
+
+	// Claim marks the decision on a request as applied, and reports whether
+	// the caller is the first to claim it
+	Claim(ctx context.Context, id string) (bool, error)
+
+	// SetHistory stores the message history of the suspended run of a request
+	SetHistory(ctx context.Context, id string, history []interfaces.Message) error
+}
+
+// MemoryStore keeps requests in memory. Requests are lost when the process
+// exits and are not shared between processes.
+type MemoryStore struct {
+	mu       sync.Mutex
+	requests map[string]*memoryRequest
+	now      func() time.Time
+}
+
+type memoryRequest struct {
+	request      Request
+	expires      time.Time
+	waitingUntil time.Time
+}
+
+// NewMemoryStore creates a new in-memory request store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		requests: make(map[string]*memoryRequest),
+		now:      time.Now,
+	}
+}
+
+// Create stores a pending request
+func (s *MemoryStore) Create(ctx context.Context, req *Request, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := &memoryRequest{request: *req}
+	if ttl > 0 {
+		entry.expires = s.now().Add(ttl)
+	}
+	s.requests[req.ID] = entry
+	return nil
+}
+
+// get returns the entry of a request, removing it once expired. The caller
+// must hold the lock.
+func (s *MemoryStore) get(id string) (*memoryRequest, error) {
+	entry, ok := s.requests[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if !entry.expires.IsZero() && s.now().After(entry.expires) {
+		delete(s.requests, id)
+		return nil, ErrNotFound
+	}
+	return entry, nil
+}
+
+// Get returns a request
+func (s *MemoryStore) Get(ctx context.Context, id string) (*Request, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, err := s.get(id)
+	if err != nil {
+		return nil, err
+	}
+	req := entry.request
+	if req.Decision != nil {
+		decision := *req.Decision
+		req.Decision = &decision
+	}
+	req.History = append([]interfaces.Message(nil), req.History...)
+	req.Waiting = s.now().Before(entry.waitingUntil)
+	return &req, nil
+}
+
+// Decide records the decision on a request
+func (s *MemoryStore) Decide(ctx context.Context, id string, decision Decision) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, err := s.get(id)
+	if err != nil {
+		return err
+	}
+	if entry.request.Decision != nil {
+		return ErrAlreadyDecided
+	}
+	entry.request.Decision = &decision
+	return nil
+}
+
+// Wait records that a run waits for the decision on a request
+func (s *MemoryStore) Wait(ctx context.Context, id string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, err := s.get(id)
+	if err != nil {
+		return err
+	}
+	entry.waitingUntil = until
+	return nil
+}
+
+// Claim marks the decision on a request as applied
+func (s *MemoryStore) Claim(ctx context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, err := s.get(id)
+	if err != nil {
+		return false, err
+	}
+	if entry.request.Applied {
+		return false, nil
+	}
+	entry.request.Applied = true
+	return true, nil
+}
+
+// SetHistory stores the message history of the suspended run of a request
+func (s *MemoryStore) SetHistory(ctx context.Context, id string, history []interfaces.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, err := s.get(id)
+	if err != nil {
+		return err
+	}
+	entry.request.History = append([]interfaces.Message(nil), history...)
+	return nil
+}
@@ -0,0 +1,191 @@
+package cost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tagus/agent-sdk-go/pkg/memory"
+	"github.com/tagus/agent-sdk-go/pkg/multitenancy"
+)
+
+// defaultOrgID is used for spend recorded without an organization in the context
+const defaultOrgID = "default"
+
+// Action is what an agent does when a budget is exceeded
+type Action string
+
+const (
+	// ActionAbort fails the run with an error wrapping ErrBudgetExceeded
+	ActionAbort Action = "abort"
+
+	// ActionDowngrade continues the run with the downgrade model of the agent
+	ActionDowngrade Action = "downgrade"
+)
+
+// Scope identifies the limit that was exceeded
+type Scope string
+
+const (
+	ScopeRun          Scope = "run"
+	ScopeConversation Scope = "conversation"
+	ScopeOrgDaily     Scope = "org_daily"
+)
+
+// ErrBudgetExceeded is matched by errors.Is for every BudgetExceededError
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// BudgetExceededError reports the limit that was exceeded
+type BudgetExceededError struct {
+	Scope Scope
+	Limit float64
+	Spent float64
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("%s budget exceeded: spent $%.4f of $%.4f", e.Scope, e.Spent, e.Limit)
+}
+
+// Is reports whether the target is ErrBudgetExceeded
+func (e *BudgetExceededError) Is(target error) bool {
+	return target == ErrBudgetExceeded
+}
+
+// Limits are spend limits in US dollars. A zero limit is not enforced.
+type Limits struct {
+	PerRun          float64 `yaml:"per_run,omitempty" json:"per_run,omitempty"`
+	PerConversation float64 `yaml:"per_conversation,omitempty" json:"per_conversation,omitempty"`
+	PerOrgDaily     float64 `yaml:"per_org_daily,omitempty" json:"per_org_daily,omitempty"`
+}
+
+// Budget records the spend of organizations and conversations and enforces
+// spend limits. Organizations are identified by the multitenancy org ID of the
+// context and conversations by the memory conversation ID.
+type Budget struct {
+	limits Limits
+	action Action
+	store  Store
+	now    func() time.Time
+}
+
+// BudgetOption represents an option for configuring a budget
+type BudgetOption func(*Budget)
+
+// WithLimits sets the spend limits
+func WithLimits(limits Limits) BudgetOption {
+	return func(b *Budget) {
+		b.limits = limits
+	}
+}
+
+// WithAction sets the action taken when a limit is exceeded, ActionAbort by default
+func WithAction(action Action) BudgetOption {
+	return func(b *Budget) {
+		b.action = action
+	}
+}
+
+// WithStore sets the spend store, an in-memory store by default
+func WithStore(store Store) BudgetOption {
+	return func(b *Budget) {
+		b.store = store
+	}
+}
+
+// NewBudget creates a new budget
+func NewBudget(options ...BudgetOption) *Budget {
+	b := &Budget{
+		action: ActionAbort,
+		store:  NewMemoryStore(),
+		now:    time.Now,
+	}
+	for _, option := range options {
+		option(b)
+	}
+	return b
+}
+
+// Limits returns the spend limits
+func (b *Budget) Limits() Limits {
+	return b.limits
+}
+
+// Action returns the action taken when a limit is exceeded
+func (b *Budget) Action() Action {
+	return b.action
+}
+
+// Check returns a BudgetExceededError when the run, the conversation of the
+// context or the organization of the context has reached its limit
+func (b *Budget) Check(ctx context.Context, runSpend float64) error {
+	if b.limits.PerRun > 0 && runSpend >= b.limits.PerRun {
+		return &BudgetExceededError{Scope: ScopeRun, Limit: b.limits.PerRun, Spent: runSpend}
+	}
+
+	orgID := orgIDFromContext(ctx)
+	if conversationID, ok := memory.GetConversationID(ctx); ok && b.limits.PerConversation > 0 {
+		spent, err := b.store.Get(ctx, ConversationKey(orgID, conversationID))
+		if err != nil {
+			return err
+		}
+		if spent >= b.limits.PerConversation {
+			return &BudgetExceededError{Scope: ScopeConversation, Limit: b.limits.PerConversation, Spent: spent}
+		}
+	}
+
+	if b.limits.PerOrgDaily > 0 {
+		spent, err := b.store.Get(ctx, OrgDayKey(orgID, b.now()))
+		if err != nil {
+			return err
+		}
+		if spent >= b.limits.PerOrgDaily {
+			return &BudgetExceededError{Scope: ScopeOrgDaily, Limit: b.limits.PerOrgDaily, Spent: spent}
+		}
+	}
+
+	return nil
+}
+
+// Record adds an amount to the daily and cumulative spend of the organization
+// of the context and to the spend of its conversation
+func (b *Budget) Record(ctx context.Context, amount float64) error {
+	if amount <= 0 {
+		return nil
+	}
+
+	orgID := orgIDFromContext(ctx)
+	keys := []string{OrgDayKey(orgID, b.now()), OrgTotalKey(orgID)}
+	if conversationID, ok := memory.GetConversationID(ctx); ok {
+		keys = append(keys, ConversationKey(orgID, conversationID))
+	}
+
+	for _, key := range keys {
+		if _, err := b.store.Add(ctx, key, amount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OrgSpend returns the spend of an organization on a day
+func (b *Budget) OrgSpend(ctx context.Context, orgID string, day time.Time) (float64, error) {
+	return b.store.Get(ctx, OrgDayKey(orgID, day))
+}
+
+// OrgTotal returns the cumulative spend of an organization
+func (b *Budget) OrgTotal(ctx context.Context, orgID string) (float64, error) {
+	return b.store.Get(ctx, OrgTotalKey(orgID))
+}
+
+// ConversationSpend returns the spend of a conversation
+func (b *Budget) ConversationSpend(ctx context.Context, orgID, conversationID string) (float64, error) {
+	return b.store.Get(ctx, ConversationKey(orgID, conversationID))
+}
+
+func orgIDFromContext(ctx context.Context) string {
+	if orgID, err := multitenancy.GetOrgID(ctx); err == nil && orgID != "" {
+		return orgID
+	}
+	return defaultOrgID
+}
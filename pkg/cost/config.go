@@ -0,0 +1,80 @@
+package cost
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	_ "github.com/lib/pq"
+)
+
+// NewStoreFromConfig creates a spend store from a configuration map. The
+// "type" key selects the store:
+//   - "memory" (default)
+//   - "redis" with "address", "password", "db", "key_prefix" and "ttl_hours"
+//   - "postgres" with "url" and "table"
+func NewStoreFromConfig(config map[string]interface{}) (Store, error) {
+	storeType, _ := config["type"].(string)
+
+	switch storeType {
+	case "", "memory":
+		return NewMemoryStore(), nil
+	case "redis":
+		address, _ := config["address"].(string)
+		if address == "" {
+			address = "localhost:6379"
+		}
+		password, _ := config["password"].(string)
+		client := redis.NewClient(&redis.Options{
+			Addr:     address,
+			Password: password,
+			DB:       intValue(config["db"]),
+		})
+		if err := client.Ping(context.Background()).Err(); err != nil {
+			return nil, fmt.Errorf("failed to connect to Redis at %s: %w", address, err)
+		}
+
+		var options []RedisOption
+		if prefix, ok := config["key_prefix"].(string); ok {
+			options = append(options, WithKeyPrefix(prefix))
+		}
+		if hours := intValue(config["ttl_hours"]); hours > 0 {
+			options = append(options, WithTTL(time.Duration(hours)*time.Hour))
+		}
+		return NewRedisStore(client, options...), nil
+	case "postgres":
+		url, _ := config["url"].(string)
+		if url == "" {
+			return nil, fmt.Errorf("postgres spend store requires a url")
+		}
+		db, err := sql.Open("postgres", url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to PostgreSQL: %w", err)
+		}
+
+		var options []PostgresOption
+		if table, ok := config["table"].(string); ok && table != "" {
+			options = append(options, WithTable(table))
+		}
+		store, err := NewPostgresStore(db, options...)
+		if err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unsupported spend store type: %s", storeType)
+	}
+}
+
+func intValue(value interface{}) int {
+	switch v := value.(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	}
+	return 0
+}
@@ -0,0 +1,8 @@
+// Package cost prices the token usage of LLM calls and enforces spend limits.
+//
+// A Pricing table maps models to their price per million tokens. A Budget
+// records the spend of organizations and conversations in a Store, in memory,
+// Redis or PostgreSQL, and reports when a per-run, per-conversation or
+// per-organization daily limit is reached. Agents use both through the
+// agent.WithPricing and agent.WithBudget options.
+package cost
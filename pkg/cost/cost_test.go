@@ -0,0 +1,188 @@
+package cost
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/memory"
+	"github.com/tagus/agent-sdk-go/pkg/multitenancy"
+)
+
+func TestPricing_Lookup(t *testing.T) {
+	pricing := NewPricing(map[string]Price{
+		"azure-openai/gpt-4o": {Input: 5, Output: 15},
+		"my-finetune":         {Input: 1, Output: 2},
+	})
+
+	tests := []struct {
+		provider string
+		model    string
+		want     Price
+	}{
+		{"openai", "gpt-4o-2024-08-06", defaultPrices["gpt-4o"]},
+		{"openai", "gpt-4o-mini", defaultPrices["gpt-4o-mini"]},
+		{"azure-openai", "gpt-4o", Price{Input: 5, Output: 15}},
+		{"azure-openai", "gpt-4o-mini", Price{Input: 5, Output: 15}},
+		{"bedrock", "us.anthropic.claude-3-7-sonnet-20250219-v1:0", defaultPrices["claude-3-7-sonnet"]},
+		{"gemini", "models/gemini-2.5-flash", defaultPrices["gemini-2.5-flash"]},
+		{"vllm", "My-Finetune-v2", Price{Input: 1, Output: 2}},
+	}
+	for _, tt := range tests {
+		price, ok := pricing.Lookup(tt.provider, tt.model)
+		if assert.True(t, ok, tt.model) {
+			assert.Equal(t, tt.want, price, tt.model)
+		}
+	}
+
+	_, ok := pricing.Lookup("ollama", "llama3.1:8b")
+	assert.False(t, ok)
+}
+
+func TestPrice_Cost(t *testing.T) {
+	price := Price{Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75}
+
+	t.Run("CachedTokensReportedSeparately", func(t *testing.T) {
+		cost := price.Cost("claude-3-5-sonnet", &interfaces.TokenUsage{
+			InputTokens:              1_000_000,
+			OutputTokens:             100_000,
+			CacheReadInputTokens:     2_000_000,
+			CacheCreationInputTokens: 1_000_000,
+		})
+		assert.InDelta(t, 3, cost.Input, 1e-9)
+		assert.InDelta(t, 1.5, cost.Output, 1e-9)
+		assert.InDelta(t, 0.6, cost.CacheRead, 1e-9)
+		assert.InDelta(t, 3.75, cost.CacheWrite, 1e-9)
+		assert.InDelta(t, 8.85, cost.Total, 1e-9)
+	})
+
+	t.Run("Gemini", func(t *testing.T) {
+		// Gemini input includes the cached tokens and output excludes reasoning
+		cost := price.Cost("gemini-2.5-pro", &interfaces.TokenUsage{
+			InputTokens:          1_000_000,
+			OutputTokens:         100_000,
+			ReasoningTokens:      100_000,
+			CacheReadInputTokens: 500_000,
+		})
+		assert.InDelta(t, 1.5, cost.Input, 1e-9)
+		assert.InDelta(t, 3, cost.Output, 1e-9)
+		assert.InDelta(t, 0.15, cost.CacheRead, 1e-9)
+	})
+
+	t.Run("CacheDefaultsToInputPrice", func(t *testing.T) {
+		cost := Price{Input: 2, Output: 6}.Cost("mistral-large", &interfaces.TokenUsage{CacheReadInputTokens: 1_000_000})
+		assert.InDelta(t, 2, cost.Total, 1e-9)
+	})
+}
+
+func TestBudget(t *testing.T) {
+	ctx := multitenancy.WithOrgID(context.Background(), "org-1")
+	ctx = memory.WithConversationID(ctx, "conversation-1")
+
+	budget := NewBudget(WithLimits(Limits{PerRun: 1, PerConversation: 2, PerOrgDaily: 3}))
+	day := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	budget.now = func() time.Time { return day }
+
+	require.NoError(t, budget.Check(ctx, 0.5))
+
+	err := budget.Check(ctx, 1)
+	var exceeded *BudgetExceededError
+	require.True(t, errors.As(err, &exceeded))
+	assert.Equal(t, ScopeRun, exceeded.Scope)
+	assert.ErrorIs(t, err, ErrBudgetExceeded)
+
+	require.NoError(t, budget.Record(ctx, 2))
+	err = budget.Check(ctx, 0)
+	require.True(t, errors.As(err, &exceeded))
+	assert.Equal(t, ScopeConversation, exceeded.Scope)
+	assert.Equal(t, 2.0, exceeded.Spent)
+
+	// Another conversation of the organization is limited by the daily limit
+	other := memory.WithConversationID(multitenancy.WithOrgID(context.Background(), "org-1"), "conversation-2")
+	require.NoError(t, budget.Check(other, 0))
+	require.NoError(t, budget.Record(other, 1))
+	err = budget.Check(other, 0)
+	require.True(t, errors.As(err, &exceeded))
+	assert.Equal(t, ScopeOrgDaily, exceeded.Scope)
+
+	// The daily limit resets the next day while the cumulative spend remains
+	budget.now = func() time.Time { return day.Add(24 * time.Hour) }
+	require.NoError(t, budget.Check(other, 0))
+
+	total, err := budget.OrgTotal(ctx, "org-1")
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, total)
+	spend, err := budget.OrgSpend(ctx, "org-1", day)
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, spend)
+
+	// Other organizations are not affected
+	require.NoError(t, budget.Check(multitenancy.WithOrgID(context.Background(), "org-2"), 0))
+}
+
+func TestRedisStore(t *testing.T) {
+	server, err := miniredis.Run()
+	require.NoError(t, err)
+	defer server.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	ctx := context.Background()
+	store := NewRedisStore(client, WithTTL(time.Hour))
+
+	spend, err := store.Get(ctx, "org:a:total")
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, spend)
+
+	_, err = store.Add(ctx, "org:a:total", 0.25)
+	require.NoError(t, err)
+	spend, err = store.Add(ctx, "org:a:total", 0.5)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.75, spend, 1e-9)
+
+	spend, err = store.Get(ctx, "org:a:total")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.75, spend, 1e-9)
+	assert.Equal(t, time.Hour, server.TTL("agent:cost:org:a:total"))
+}
+
+func TestPostgresStore(t *testing.T) {
+	connStr := os.Getenv("POSTGRES_URL")
+	if connStr == "" {
+		t.Skip("POSTGRES_URL not set, skipping PostgreSQL spend store tests")
+	}
+
+	db, err := sql.Open("postgres", connStr)
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	store, err := NewPostgresStore(db, WithTable("agent_spend_test"))
+	require.NoError(t, err)
+	defer func() {
+		_, _ = db.Exec("DROP TABLE IF EXISTS agent_spend_test")
+	}()
+
+	_, err = store.Add(ctx, "org:a:total", 0.25)
+	require.NoError(t, err)
+	spend, err := store.Add(ctx, "org:a:total", 0.5)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.75, spend, 1e-9)
+
+	spend, err = store.Get(ctx, "missing")
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, spend)
+
+	_, err = NewPostgresStore(db, WithTable("spend; DROP TABLE users"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,79 @@
+package cost
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+)
+
+// DefaultTable is the table used by the Postgres store when none is configured
+const DefaultTable = "agent_spend"
+
+var tablePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// PostgresStore keeps spend in a PostgreSQL table so that it is shared between
+// processes and survives restarts
+type PostgresStore struct {
+	db    *sql.DB
+	table string
+}
+
+// PostgresOption represents an option for configuring the Postgres store
+type PostgresOption func(*PostgresStore)
+
+// WithTable sets the spend table, optionally schema-qualified
+func WithTable(table string) PostgresOption {
+	return func(s *PostgresStore) {
+		s.table = table
+	}
+}
+
+// NewPostgresStore creates a spend store backed by PostgreSQL and its table if needed
+func NewPostgresStore(db *sql.DB, options ...PostgresOption) (*PostgresStore, error) {
+	s := &PostgresStore{db: db, table: DefaultTable}
+	for _, option := range options {
+		option(s)
+	}
+	if !tablePattern.MatchString(s.table) {
+		return nil, fmt.Errorf("invalid spend table name: %q", s.table)
+	}
+
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		key TEXT PRIMARY KEY,
+		amount DOUBLE PRECISION NOT NULL DEFAULT 0,
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`, s.table)
+	if _, err := db.ExecContext(context.Background(), query); err != nil {
+		return nil, fmt.Errorf("failed to create spend table: %w", err)
+	}
+	return s, nil
+}
+
+// Add adds an amount to the spend of a key and returns the new spend
+func (s *PostgresStore) Add(ctx context.Context, key string, amount float64) (float64, error) {
+	query := fmt.Sprintf(`INSERT INTO %[1]s (key, amount, updated_at) VALUES ($1, $2, now())
+		ON CONFLICT (key) DO UPDATE SET amount = %[1]s.amount + EXCLUDED.amount, updated_at = now()
+		RETURNING amount`, s.table)
+
+	var spend float64
+	if err := s.db.QueryRowContext(ctx, query, key, amount).Scan(&spend); err != nil {
+		return 0, fmt.Errorf("failed to add spend: %w", err)
+	}
+	return spend, nil
+}
+
+// Get returns the spend of a key
+func (s *PostgresStore) Get(ctx context.Context, key string) (float64, error) {
+	query := fmt.Sprintf(`SELECT amount FROM %s WHERE key = $1`, s.table)
+
+	var spend float64
+	err := s.db.QueryRowContext(ctx, query, key).Scan(&spend)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get spend: %w", err)
+	}
+	return spend, nil
+}
@@ -0,0 +1,177 @@
+package cost
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/tokens"
+)
+
+// Price is the price of a model in US dollars per million tokens. Cached
+// tokens are charged at the input price when their price is not set.
+type Price struct {
+	Input      float64 `yaml:"input" json:"input"`
+	Output     float64 `yaml:"output" json:"output"`
+	CacheRead  float64 `yaml:"cache_read,omitempty" json:"cache_read,omitempty"`
+	CacheWrite float64 `yaml:"cache_write,omitempty" json:"cache_write,omitempty"`
+}
+
+// defaultPrices are the list prices of the models supported by the SDK, keyed
+// by model name prefix
+var defaultPrices = map[string]Price{
+	// OpenAI
+	"gpt-5":         {Input: 1.25, Output: 10, CacheRead: 0.125},
+	"gpt-5-mini":    {Input: 0.25, Output: 2, CacheRead: 0.025},
+	"gpt-5-nano":    {Input: 0.05, Output: 0.4, CacheRead: 0.005},
+	"gpt-4.1":       {Input: 2, Output: 8, CacheRead: 0.5},
+	"gpt-4.1-mini":  {Input: 0.4, Output: 1.6, CacheRead: 0.1},
+	"gpt-4.1-nano":  {Input: 0.1, Output: 0.4, CacheRead: 0.025},
+	"gpt-4o":        {Input: 2.5, Output: 10, CacheRead: 1.25},
+	"gpt-4o-mini":   {Input: 0.15, Output: 0.6, CacheRead: 0.075},
+	"gpt-4-turbo":   {Input: 10, Output: 30},
+	"gpt-4":         {Input: 30, Output: 60},
+	"gpt-3.5-turbo": {Input: 0.5, Output: 1.5},
+	"o1":            {Input: 15, Output: 60, CacheRead: 7.5},
+	"o1-mini":       {Input: 1.1, Output: 4.4, CacheRead: 0.55},
+	"o3":            {Input: 2, Output: 8, CacheRead: 0.5},
+	"o3-mini":       {Input: 1.1, Output: 4.4, CacheRead: 0.55},
+	"o4-mini":       {Input: 1.1, Output: 4.4, CacheRead: 0.275},
+
+	// Anthropic
+	"claude-opus-4":     {Input: 15, Output: 75, CacheRead: 1.5, CacheWrite: 18.75},
+	"claude-sonnet-4":   {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+	"claude-haiku-4":    {Input: 1, Output: 5, CacheRead: 0.1, CacheWrite: 1.25},
+	"claude-3-7-sonnet": {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+	"claude-3-5-sonnet": {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+	"claude-3-5-haiku":  {Input: 0.8, Output: 4, CacheRead: 0.08, CacheWrite: 1},
+	"claude-3-opus":     {Input: 15, Output: 75, CacheRead: 1.5, CacheWrite: 18.75},
+	"claude-3-sonnet":   {Input: 3, Output: 15},
+	"claude-3-haiku":    {Input: 0.25, Output: 1.25, CacheRead: 0.03, CacheWrite: 0.3},
+
+	// Gemini
+	"gemini-2.5-pro":        {Input: 1.25, Output: 10, CacheRead: 0.31},
+	"gemini-2.5-flash":      {Input: 0.3, Output: 2.5, CacheRead: 0.075},
+	"gemini-2.5-flash-lite": {Input: 0.1, Output: 0.4, CacheRead: 0.025},
+	"gemini-2.0-flash":      {Input: 0.1, Output: 0.4, CacheRead: 0.025},
+	"gemini-2.0-flash-lite": {Input: 0.075, Output: 0.3},
+	"gemini-1.5-pro":        {Input: 1.25, Output: 5, CacheRead: 0.3125},
+	"gemini-1.5-flash":      {Input: 0.075, Output: 0.3, CacheRead: 0.01875},
+
+	// DeepSeek
+	"deepseek-chat":     {Input: 0.27, Output: 1.1, CacheRead: 0.07},
+	"deepseek-reasoner": {Input: 0.55, Output: 2.19, CacheRead: 0.14},
+
+	// Mistral
+	"mistral-large": {Input: 2, Output: 6},
+	"mistral-small": {Input: 0.2, Output: 0.6},
+}
+
+// Pricing is a table of model prices. Prices are keyed by model name prefix,
+// optionally qualified by the provider as "provider/model", for example
+// "azure-openai/gpt-4o". Qualified prices take precedence.
+type Pricing struct {
+	mu     sync.RWMutex
+	prices map[string]Price
+}
+
+// NewPricing creates a pricing table with the default prices, overridden by
+// the given prices
+func NewPricing(overrides map[string]Price) *Pricing {
+	p := &Pricing{prices: make(map[string]Price, len(defaultPrices)+len(overrides))}
+	for model, price := range defaultPrices {
+		p.prices[model] = price
+	}
+	for model, price := range overrides {
+		p.Set(model, price)
+	}
+	return p
+}
+
+// DefaultPricing returns a pricing table with the default prices
+func DefaultPricing() *Pricing {
+	return NewPricing(nil)
+}
+
+// Set sets the price of a model name prefix
+func (p *Pricing) Set(model string, price Price) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.prices[strings.ToLower(model)] = price
+}
+
+// Lookup returns the price of a model. The price of the longest matching
+// prefix qualified by the provider is used, then the longest unqualified prefix.
+func (p *Pricing) Lookup(provider, model string) (Price, bool) {
+	name := tokens.NormalizeModel(model)
+	provider = strings.ToLower(provider)
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if provider != "" {
+		if price, ok := p.longestPrefix(provider + "/" + name); ok {
+			return price, true
+		}
+	}
+	return p.longestPrefix(name)
+}
+
+func (p *Pricing) longestPrefix(name string) (Price, bool) {
+	var best Price
+	bestLen := -1
+	for prefix, price := range p.prices {
+		if strings.HasPrefix(name, prefix) && len(prefix) > bestLen {
+			best, bestLen = price, len(prefix)
+		}
+	}
+	return best, bestLen >= 0
+}
+
+// Cost returns the cost of the usage of a model. It returns false when the
+// model has no price.
+func (p *Pricing) Cost(provider, model string, usage *interfaces.TokenUsage) (interfaces.Cost, bool) {
+	price, ok := p.Lookup(provider, model)
+	if !ok || usage == nil {
+		return interfaces.Cost{}, ok
+	}
+	return price.Cost(model, usage), true
+}
+
+// Cost returns the cost of the usage of a model at this price. Providers
+// report usage differently: Gemini includes cached tokens in the input tokens
+// and reports reasoning tokens separately from the output tokens, while the
+// other providers report cached tokens separately and include reasoning tokens
+// in the output tokens.
+func (p Price) Cost(model string, usage *interfaces.TokenUsage) interfaces.Cost {
+	input := usage.InputTokens
+	output := usage.OutputTokens
+	if info, ok := tokens.LookupModel(model); ok && info.Family == tokens.FamilyGemini {
+		input -= usage.CacheReadInputTokens
+		output += usage.ReasoningTokens
+	}
+	if input < 0 {
+		input = 0
+	}
+
+	cacheRead, cacheWrite := p.CacheRead, p.CacheWrite
+	if cacheRead == 0 {
+		cacheRead = p.Input
+	}
+	if cacheWrite == 0 {
+		cacheWrite = p.Input
+	}
+
+	cost := interfaces.Cost{
+		Input:      perMillion(input, p.Input),
+		Output:     perMillion(output, p.Output),
+		CacheRead:  perMillion(usage.CacheReadInputTokens, cacheRead),
+		CacheWrite: perMillion(usage.CacheCreationInputTokens, cacheWrite),
+	}
+	cost.Total = cost.Input + cost.Output + cost.CacheRead + cost.CacheWrite
+	return cost
+}
+
+func perMillion(n int, price float64) float64 {
+	return float64(n) * price / 1_000_000
+}
@@ -0,0 +1,72 @@
+package cost
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisStore keeps spend in Redis so that it is shared between processes
+type RedisStore struct {
+	client    *redis.Client
+	keyPrefix string
+	ttl       time.Duration
+}
+
+// RedisOption represents an option for configuring the Redis store
+type RedisOption func(*RedisStore)
+
+// WithKeyPrefix sets the prefix of the Redis keys, "agent:cost:" by default
+func WithKeyPrefix(prefix string) RedisOption {
+	return func(s *RedisStore) {
+		s.keyPrefix = prefix
+	}
+}
+
+// WithTTL expires keys after they have not been updated for the given duration
+func WithTTL(ttl time.Duration) RedisOption {
+	return func(s *RedisStore) {
+		s.ttl = ttl
+	}
+}
+
+// NewRedisStore creates a new spend store backed by Redis
+func NewRedisStore(client *redis.Client, options ...RedisOption) *RedisStore {
+	s := &RedisStore{
+		client:    client,
+		keyPrefix: "agent:cost:",
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// Add adds an amount to the spend of a key and returns the new spend
+func (s *RedisStore) Add(ctx context.Context, key string, amount float64) (float64, error) {
+	key = s.keyPrefix + key
+
+	pipe := s.client.TxPipeline()
+	incr := pipe.IncrByFloat(ctx, key, amount)
+	if s.ttl > 0 {
+		pipe.Expire(ctx, key, s.ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to add spend: %w", err)
+	}
+	return incr.Val(), nil
+}
+
+// Get returns the spend of a key
+func (s *RedisStore) Get(ctx context.Context, key string) (float64, error) {
+	spend, err := s.client.Get(ctx, s.keyPrefix+key).Float64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get spend: %w", err)
+	}
+	return spend, nil
+}
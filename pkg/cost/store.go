@@ -0,0 +1,58 @@
+package cost
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Store keeps the cumulative spend in US dollars by key
+type Store interface {
+	// Add adds an amount to the spend of a key and returns the new spend
+	Add(ctx context.Context, key string, amount float64) (float64, error)
+
+	// Get returns the spend of a key, or zero if nothing was spent
+	Get(ctx context.Context, key string) (float64, error)
+}
+
+// OrgDayKey returns the key of the spend of an organization on a day (UTC)
+func OrgDayKey(orgID string, day time.Time) string {
+	return "org:" + orgID + ":day:" + day.UTC().Format("2006-01-02")
+}
+
+// OrgTotalKey returns the key of the cumulative spend of an organization
+func OrgTotalKey(orgID string) string {
+	return "org:" + orgID + ":total"
+}
+
+// ConversationKey returns the key of the spend of a conversation
+func ConversationKey(orgID, conversationID string) string {
+	return "org:" + orgID + ":conversation:" + conversationID
+}
+
+// MemoryStore keeps spend in memory. Spend is lost when the process exits
+// and is not shared between processes.
+type MemoryStore struct {
+	mu    sync.Mutex
+	spend map[string]float64
+}
+
+// NewMemoryStore creates a new in-memory spend store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{spend: make(map[string]float64)}
+}
+
+// Add adds an amount to the spend of a key and returns the new spend
+func (s *MemoryStore) Add(ctx context.Context, key string, amount float64) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.spend[key] += amount
+	return s.spend[key], nil
+}
+
+// Get returns the spend of a key
+func (s *MemoryStore) Get(ctx context.Context, key string) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.spend[key], nil
+}
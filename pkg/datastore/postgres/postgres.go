@@ -97,6 +97,12 @@ func (c *Client) Transaction(ctx context.Context, fn func(tx interfaces.Transact
 	return nil
 }
 
+// DB returns the underlying database connection so that other components,
+// such as the pgvector store, can share the same connection pool
+func (c *Client) DB() *sql.DB {
+	return c.db
+}
+
 // Close closes the database connection
 func (c *Client) Close() error {
 	return c.db.Close()
@@ -0,0 +1,270 @@
+package embedding
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultLRUCacheSize is the default number of embeddings kept by an LRUCache
+const DefaultLRUCacheSize = 10000
+
+// Cache stores embeddings by key. Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the cached embedding for each key, with nil for misses
+	Get(ctx context.Context, keys []string) ([][]float32, error)
+
+	// Set stores embeddings under the given keys
+	Set(ctx context.Context, keys []string, vectors [][]float32) error
+}
+
+// CacheStats reports the effectiveness of a CachedClient
+type CacheStats struct {
+	// Hits is the number of texts served from the cache
+	Hits int64
+
+	// Misses is the number of texts sent to the embedding provider
+	Misses int64
+
+	// Errors is the number of failed cache reads and writes. A failed read is
+	// treated as a miss and a failed write is ignored.
+	Errors int64
+}
+
+// CachedClient is an embedding Client that serves repeated texts from a Cache.
+// Entries are keyed by model, dimensions, normalization and text, so clients
+// with different configurations can share a cache.
+type CachedClient struct {
+	client Client
+	cache  Cache
+	config EmbeddingConfig
+
+	hits   atomic.Int64
+	misses atomic.Int64
+	errors atomic.Int64
+}
+
+// NewCachedClient wraps an embedding client with a cache
+func NewCachedClient(client Client, cache Cache) *CachedClient {
+	c := &CachedClient{client: client, cache: cache}
+	if configured, ok := client.(interface{ GetConfig() EmbeddingConfig }); ok {
+		c.config = configured.GetConfig()
+	}
+	return c
+}
+
+// CacheKey returns the cache key of a text embedded with the given configuration
+func CacheKey(config EmbeddingConfig, text string) string {
+	h := sha256.New()
+	h.Write([]byte(config.Model))
+	h.Write([]byte{0})
+	h.Write([]byte(strconv.Itoa(config.Dimensions)))
+	h.Write([]byte{0})
+	h.Write([]byte(strconv.FormatBool(config.Normalize)))
+	h.Write([]byte{0})
+	h.Write([]byte(text))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Embed generates an embedding, using the cache when possible
+func (c *CachedClient) Embed(ctx context.Context, text string) ([]float32, error) {
+	vectors, err := c.EmbedBatch(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+// EmbedWithConfig generates an embedding with custom configuration, using the cache when possible
+func (c *CachedClient) EmbedWithConfig(ctx context.Context, text string, config EmbeddingConfig) ([]float32, error) {
+	vectors, err := c.EmbedBatchWithConfig(ctx, []string{text}, config)
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+// EmbedBatch generates embeddings for multiple texts, sending only cache misses to the client
+func (c *CachedClient) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return c.embedBatch(ctx, texts, c.config, c.client.EmbedBatch)
+}
+
+// EmbedBatchWithConfig generates embeddings for multiple texts with custom
+// configuration, sending only cache misses to the client
+func (c *CachedClient) EmbedBatchWithConfig(ctx context.Context, texts []string, config EmbeddingConfig) ([][]float32, error) {
+	return c.embedBatch(ctx, texts, config, func(ctx context.Context, texts []string) ([][]float32, error) {
+		return c.client.EmbedBatchWithConfig(ctx, texts, config)
+	})
+}
+
+func (c *CachedClient) embedBatch(ctx context.Context, texts []string, config EmbeddingConfig, embed func(ctx context.Context, texts []string) ([][]float32, error)) ([][]float32, error) {
+	if len(texts) == 0 {
+		return [][]float32{}, nil
+	}
+
+	keys := make([]string, len(texts))
+	for i, text := range texts {
+		keys[i] = CacheKey(config, text)
+	}
+
+	vectors, err := c.cache.Get(ctx, keys)
+	if err != nil || len(vectors) != len(keys) {
+		c.errors.Add(1)
+		vectors = make([][]float32, len(keys))
+	}
+
+	// Collect misses, embedding each distinct text once
+	var missTexts, missKeys []string
+	missIndexes := map[string][]int{}
+	for i, vec := range vectors {
+		if vec != nil {
+			c.hits.Add(1)
+			continue
+		}
+		c.misses.Add(1)
+		if _, seen := missIndexes[keys[i]]; !seen {
+			missTexts = append(missTexts, texts[i])
+			missKeys = append(missKeys, keys[i])
+		}
+		missIndexes[keys[i]] = append(missIndexes[keys[i]], i)
+	}
+	if len(missTexts) == 0 {
+		return vectors, nil
+	}
+
+	embedded, err := embed(ctx, missTexts)
+	if err != nil {
+		return nil, err
+	}
+	if len(embedded) != len(missTexts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(missTexts), len(embedded))
+	}
+
+	for i, key := range missKeys {
+		for _, index := range missIndexes[key] {
+			vectors[index] = embedded[i]
+		}
+	}
+	if err := c.cache.Set(ctx, missKeys, embedded); err != nil {
+		c.errors.Add(1)
+	}
+	return vectors, nil
+}
+
+// CalculateSimilarity calculates the similarity between two embeddings
+func (c *CachedClient) CalculateSimilarity(vec1, vec2 []float32, metric string) (float32, error) {
+	return c.client.CalculateSimilarity(vec1, vec2, metric)
+}
+
+// GetConfig returns the configuration of the wrapped client
+func (c *CachedClient) GetConfig() EmbeddingConfig {
+	return c.config
+}
+
+// Stats returns the cache hit, miss and error counts
+func (c *CachedClient) Stats() CacheStats {
+	return CacheStats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+		Errors: c.errors.Load(),
+	}
+}
+
+// LRUCache is an in-memory Cache that evicts the least recently used embeddings
+type LRUCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+type lruEntry struct {
+	key    string
+	vector []float32
+}
+
+// NewLRUCache creates an in-memory cache holding up to capacity embeddings.
+// Zero uses DefaultLRUCacheSize.
+func NewLRUCache(capacity int) *LRUCache {
+	if capacity <= 0 {
+		capacity = DefaultLRUCacheSize
+	}
+	return &LRUCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get returns the cached embedding for each key, with nil for misses
+func (c *LRUCache) Get(ctx context.Context, keys []string) ([][]float32, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	vectors := make([][]float32, len(keys))
+	for i, key := range keys {
+		if element, ok := c.entries[key]; ok {
+			c.order.MoveToFront(element)
+			vectors[i] = append([]float32(nil), element.Value.(*lruEntry).vector...)
+		}
+	}
+	return vectors, nil
+}
+
+// Set stores embeddings under the given keys
+func (c *LRUCache) Set(ctx context.Context, keys []string, vectors [][]float32) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, key := range keys {
+		// Copy so that callers modifying their embeddings do not corrupt the cache
+		vector := append([]float32(nil), vectors[i]...)
+		if element, ok := c.entries[key]; ok {
+			element.Value.(*lruEntry).vector = vector
+			c.order.MoveToFront(element)
+			continue
+		}
+		c.entries[key] = c.order.PushFront(&lruEntry{key: key, vector: vector})
+		if c.order.Len() > c.capacity {
+			oldest := c.order.Back()
+			c.order.Remove(oldest)
+			delete(c.entries, oldest.Value.(*lruEntry).key)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of cached embeddings
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// EncodeVector encodes an embedding as little-endian float32 bytes for cache backends
+func EncodeVector(vec []float32) []byte {
+	data := make([]byte, 4*len(vec))
+	for i, v := range vec {
+		binary.LittleEndian.PutUint32(data[4*i:], math.Float32bits(v))
+	}
+	return data
+}
+
+// DecodeVector decodes an embedding encoded with EncodeVector
+func DecodeVector(data []byte) ([]float32, error) {
+	if len(data)%4 != 0 {
+		return nil, fmt.Errorf("invalid encoded vector length: %d", len(data))
+	}
+	vec := make([]float32, len(data)/4)
+	for i := range vec {
+		vec[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+	}
+	return vec, nil
+}
@@ -0,0 +1,115 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingEmbedder records the texts sent to the provider
+type countingEmbedder struct {
+	*HashEmbedder
+	batches [][]string
+}
+
+func (e *countingEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	e.batches = append(e.batches, texts)
+	return e.HashEmbedder.EmbedBatch(ctx, texts)
+}
+
+func (e *countingEmbedder) EmbedBatchWithConfig(ctx context.Context, texts []string, config EmbeddingConfig) ([][]float32, error) {
+	e.batches = append(e.batches, texts)
+	return e.HashEmbedder.EmbedBatchWithConfig(ctx, texts, config)
+}
+
+type failingCache struct{}
+
+func (failingCache) Get(ctx context.Context, keys []string) ([][]float32, error) {
+	return nil, errors.New("unavailable")
+}
+
+func (failingCache) Set(ctx context.Context, keys []string, vectors [][]float32) error {
+	return errors.New("unavailable")
+}
+
+func TestCachedClient(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingEmbedder{HashEmbedder: NewHashEmbedder(32)}
+	client := NewCachedClient(inner, NewLRUCache(0))
+
+	first, err := client.EmbedBatch(ctx, []string{"alpha", "beta", "alpha"})
+	require.NoError(t, err)
+	require.Len(t, first, 3)
+	assert.Equal(t, first[0], first[2])
+	assert.Equal(t, [][]string{{"alpha", "beta"}}, inner.batches)
+
+	second, err := client.EmbedBatch(ctx, []string{"beta", "gamma", "alpha"})
+	require.NoError(t, err)
+	assert.Equal(t, first[1], second[0])
+	assert.Equal(t, first[0], second[2])
+	assert.Equal(t, []string{"gamma"}, inner.batches[1])
+
+	single, err := client.Embed(ctx, "gamma")
+	require.NoError(t, err)
+	assert.Equal(t, second[1], single)
+	assert.Len(t, inner.batches, 2)
+
+	assert.Equal(t, CacheStats{Hits: 3, Misses: 4}, client.Stats())
+	assert.Equal(t, inner.GetConfig(), client.GetConfig())
+}
+
+func TestCachedClientConfigIsPartOfKey(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingEmbedder{HashEmbedder: NewHashEmbedder(32)}
+	client := NewCachedClient(inner, NewLRUCache(0))
+
+	_, err := client.Embed(ctx, "alpha")
+	require.NoError(t, err)
+
+	config := inner.GetConfig()
+	config.Dimensions = 16
+	vec, err := client.EmbedWithConfig(ctx, "alpha", config)
+	require.NoError(t, err)
+	assert.Len(t, vec, 16)
+	assert.Len(t, inner.batches, 2)
+
+	assert.NotEqual(t, CacheKey(inner.GetConfig(), "alpha"), CacheKey(config, "alpha"))
+}
+
+func TestCachedClientCacheFailure(t *testing.T) {
+	inner := &countingEmbedder{HashEmbedder: NewHashEmbedder(32)}
+	client := NewCachedClient(inner, failingCache{})
+
+	vectors, err := client.EmbedBatch(context.Background(), []string{"alpha", "beta"})
+	require.NoError(t, err)
+	assert.Len(t, vectors, 2)
+	assert.Equal(t, CacheStats{Misses: 2, Errors: 2}, client.Stats())
+}
+
+func TestLRUCacheEviction(t *testing.T) {
+	ctx := context.Background()
+	cache := NewLRUCache(2)
+
+	require.NoError(t, cache.Set(ctx, []string{"a", "b"}, [][]float32{{1}, {2}}))
+	_, err := cache.Get(ctx, []string{"a"})
+	require.NoError(t, err)
+	require.NoError(t, cache.Set(ctx, []string{"c"}, [][]float32{{3}}))
+
+	vectors, err := cache.Get(ctx, []string{"a", "b", "c"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1}, nil, {3}}, vectors)
+	assert.Equal(t, 2, cache.Len())
+}
+
+func TestEncodeDecodeVector(t *testing.T) {
+	vec := []float32{0, 1.5, -2.25, 3e-8}
+	decoded, err := DecodeVector(EncodeVector(vec))
+	require.NoError(t, err)
+	assert.Equal(t, vec, decoded)
+
+	_, err = DecodeVector([]byte{1, 2, 3})
+	assert.Error(t, err)
+}
@@ -0,0 +1,140 @@
+package embedding
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Embedding provider names accepted by NewClient
+const (
+	ProviderOpenAI           = "openai"
+	ProviderAzureOpenAI      = "azure_openai"
+	ProviderOpenAICompatible = "openai_compatible"
+	ProviderOllama           = "ollama"
+	ProviderHash             = "hash"
+)
+
+// ProviderConfig selects and configures an embedding provider by name
+type ProviderConfig struct {
+	// Provider is one of "openai", "azure_openai", "openai_compatible" (alias "vllm"),
+	// "ollama" or "hash"
+	Provider string
+
+	// APIKey authenticates with the provider. OpenAI and Azure fall back to the
+	// OPENAI_API_KEY and AZURE_OPENAI_API_KEY environment variables.
+	APIKey string
+
+	// BaseURL is the server URL for OpenAI-compatible and Ollama providers, or the
+	// resource endpoint for Azure (falls back to AZURE_OPENAI_ENDPOINT)
+	BaseURL string
+
+	// Deployment is the Azure deployment name; defaults to Config.Model
+	Deployment string
+
+	// APIVersion is the Azure API version
+	APIVersion string
+
+	// Config holds the model and embedding options
+	Config EmbeddingConfig
+}
+
+// NewClient creates an embedding client for the configured provider
+func NewClient(pc ProviderConfig) (Client, error) {
+	config := pc.Config
+	if config.SimilarityMetric == "" {
+		config.SimilarityMetric = "cosine"
+	}
+
+	switch strings.ToLower(pc.Provider) {
+	case ProviderOpenAI, "":
+		apiKey := pc.APIKey
+		if apiKey == "" {
+			apiKey = os.Getenv("OPENAI_API_KEY")
+		}
+		if apiKey == "" {
+			return nil, fmt.Errorf("api key is required for the openai embedding provider")
+		}
+		if pc.BaseURL != "" {
+			if config.Model == "" {
+				config.Model = DefaultOpenAIEmbeddingModel
+			}
+			return NewOpenAICompatibleEmbedder(pc.BaseURL, apiKey, config), nil
+		}
+		return NewOpenAIEmbedderWithConfig(apiKey, config), nil
+	case ProviderAzureOpenAI, "azureopenai", "azure":
+		apiKey := pc.APIKey
+		if apiKey == "" {
+			apiKey = os.Getenv("AZURE_OPENAI_API_KEY")
+		}
+		endpoint := pc.BaseURL
+		if endpoint == "" {
+			endpoint = os.Getenv("AZURE_OPENAI_ENDPOINT")
+		}
+		deployment := pc.Deployment
+		if deployment == "" {
+			deployment = config.Model
+		}
+		if apiKey == "" || endpoint == "" || deployment == "" {
+			return nil, fmt.Errorf("api key, endpoint and deployment are required for the azure_openai embedding provider")
+		}
+		return NewAzureOpenAIEmbedder(endpoint, apiKey, deployment, pc.APIVersion, config), nil
+	case ProviderOpenAICompatible, "vllm":
+		if pc.BaseURL == "" {
+			return nil, fmt.Errorf("base url is required for the %s embedding provider", pc.Provider)
+		}
+		if config.Model == "" {
+			return nil, fmt.Errorf("model is required for the %s embedding provider", pc.Provider)
+		}
+		return NewOpenAICompatibleEmbedder(pc.BaseURL, pc.APIKey, config), nil
+	case ProviderOllama:
+		return NewOllamaEmbedderWithConfig(pc.BaseURL, config), nil
+	case ProviderHash:
+		return NewHashEmbedderWithConfig(config), nil
+	default:
+		return nil, fmt.Errorf("unsupported embedding provider: %s (supported: openai, azure_openai, openai_compatible, ollama, hash)", pc.Provider)
+	}
+}
+
+// NewClientFromMap creates an embedding client from a configuration map as found
+// in YAML files. Recognized keys are provider, model, api_key, base_url,
+// deployment, api_version, dimensions, batch_size, normalize and
+// similarity_metric.
+func NewClientFromMap(config map[string]interface{}) (Client, error) {
+	pc := ProviderConfig{
+		Provider:   mapString(config, "provider"),
+		APIKey:     mapString(config, "api_key"),
+		BaseURL:    mapString(config, "base_url"),
+		Deployment: mapString(config, "deployment"),
+		APIVersion: mapString(config, "api_version"),
+		Config: EmbeddingConfig{
+			Model:            mapString(config, "model"),
+			Dimensions:       mapInt(config, "dimensions"),
+			BatchSize:        mapInt(config, "batch_size"),
+			SimilarityMetric: mapString(config, "similarity_metric"),
+		},
+	}
+	if normalize, ok := config["normalize"].(bool); ok {
+		pc.Config.Normalize = normalize
+	} else if pc.Provider == ProviderHash {
+		pc.Config.Normalize = true
+	}
+	return NewClient(pc)
+}
+
+func mapString(config map[string]interface{}, key string) string {
+	value, _ := config[key].(string)
+	return value
+}
+
+func mapInt(config map[string]interface{}, key string) int {
+	switch v := config[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return 0
+}
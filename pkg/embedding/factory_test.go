@@ -0,0 +1,93 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClient(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("AZURE_OPENAI_API_KEY", "")
+	t.Setenv("AZURE_OPENAI_ENDPOINT", "")
+
+	client, err := NewClient(ProviderConfig{Provider: "openai", APIKey: "key"})
+	require.NoError(t, err)
+	assert.IsType(t, &OpenAIEmbedder{}, client)
+
+	client, err = NewClient(ProviderConfig{
+		Provider:   "azure_openai",
+		APIKey:     "key",
+		BaseURL:    "https://example.openai.azure.com",
+		Deployment: "embeddings",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "embeddings", client.(*OpenAIEmbedder).GetModel())
+
+	client, err = NewClient(ProviderConfig{Provider: "ollama", Config: EmbeddingConfig{Model: "all-minilm"}})
+	require.NoError(t, err)
+	assert.Equal(t, "all-minilm", client.(*OllamaEmbedder).GetModel())
+
+	client, err = NewClientFromMap(map[string]interface{}{"provider": "hash", "dimensions": 32.0})
+	require.NoError(t, err)
+	vector, err := client.Embed(context.Background(), "hello world")
+	require.NoError(t, err)
+	assert.Len(t, vector, 32)
+	assert.True(t, client.(*HashEmbedder).GetConfig().Normalize)
+
+	_, err = NewClient(ProviderConfig{Provider: "openai"})
+	assert.ErrorContains(t, err, "api key")
+	_, err = NewClient(ProviderConfig{Provider: "vllm", Config: EmbeddingConfig{Model: "bge"}})
+	assert.ErrorContains(t, err, "base url")
+	_, err = NewClient(ProviderConfig{Provider: "azure_openai", APIKey: "key"})
+	assert.ErrorContains(t, err, "endpoint")
+	_, err = NewClient(ProviderConfig{Provider: "unknown"})
+	assert.ErrorContains(t, err, "unsupported embedding provider")
+}
+
+func TestOpenAICompatibleEmbedder(t *testing.T) {
+	var batches [][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/embeddings", r.URL.Path)
+		assert.Empty(t, r.Header.Get("Authorization"))
+
+		var req struct {
+			Input []string `json:"input"`
+			Model string   `json:"model"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "BAAI/bge-small-en", req.Model)
+		batches = append(batches, req.Input)
+
+		data := make([]map[string]interface{}, len(req.Input))
+		// Return the results out of order to check they are sorted by index
+		for i := range req.Input {
+			index := len(req.Input) - 1 - i
+			data[i] = map[string]interface{}{"object": "embedding", "index": index, "embedding": []float64{float64(index + 1), 0}}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+			"object": "list", "model": req.Model, "data": data,
+			"usage": map[string]int{"prompt_tokens": 1, "total_tokens": 1},
+		}))
+	}))
+	defer server.Close()
+
+	client, err := NewClientFromMap(map[string]interface{}{
+		"provider":   "vllm",
+		"base_url":   server.URL + "/v1",
+		"model":      "BAAI/bge-small-en",
+		"batch_size": 2,
+	})
+	require.NoError(t, err)
+
+	vectors, err := client.EmbedBatch(context.Background(), []string{"a", "b", "c"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"a", "b"}, {"c"}}, batches)
+	assert.Equal(t, [][]float32{{1, 0}, {2, 0}, {1, 0}}, vectors)
+}
@@ -0,0 +1,117 @@
+package embedding
+
+import (
+	"context"
+	"hash/fnv"
+	"strings"
+	"unicode"
+)
+
+// DefaultHashDimensions is the default dimensionality of the hashing embedder
+const DefaultHashDimensions = 256
+
+// HashEmbedder generates deterministic embeddings by hashing words and word
+// pairs into a fixed number of dimensions. It needs no model or network and is
+// intended for tests and offline development: texts sharing words get similar
+// vectors, but there is no semantic understanding.
+type HashEmbedder struct {
+	config EmbeddingConfig
+}
+
+// NewHashEmbedder creates a hashing embedder with normalized vectors of the
+// given dimensionality. Zero uses DefaultHashDimensions.
+func NewHashEmbedder(dimensions int) *HashEmbedder {
+	config := DefaultEmbeddingConfig("hash")
+	config.Dimensions = dimensions
+	config.Normalize = true
+	return NewHashEmbedderWithConfig(config)
+}
+
+// NewHashEmbedderWithConfig creates a hashing embedder with custom configuration
+func NewHashEmbedderWithConfig(config EmbeddingConfig) *HashEmbedder {
+	if config.Model == "" {
+		config.Model = "hash"
+	}
+	if config.Dimensions <= 0 {
+		config.Dimensions = DefaultHashDimensions
+	}
+	return &HashEmbedder{config: config}
+}
+
+// Embed generates an embedding with default configuration
+func (e *HashEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	return e.EmbedWithConfig(ctx, text, e.config)
+}
+
+// EmbedWithConfig generates an embedding with custom configuration
+func (e *HashEmbedder) EmbedWithConfig(ctx context.Context, text string, config EmbeddingConfig) ([]float32, error) {
+	dimensions := config.Dimensions
+	if dimensions <= 0 {
+		dimensions = e.config.Dimensions
+	}
+
+	vec := make([]float32, dimensions)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for i, word := range words {
+		addFeature(vec, word, 1)
+		if i > 0 {
+			addFeature(vec, words[i-1]+" "+word, 0.5)
+		}
+	}
+
+	if config.Normalize {
+		vec = NormalizeVector(vec)
+	}
+	return vec, nil
+}
+
+// addFeature adds a signed weight to the dimension selected by the feature hash
+func addFeature(vec []float32, feature string, weight float32) {
+	h := fnv.New64a()
+	h.Write([]byte(feature))
+	sum := h.Sum64()
+
+	index := sum % uint64(len(vec))
+	if sum&(1<<63) != 0 {
+		weight = -weight
+	}
+	vec[index] += weight
+}
+
+// EmbedBatch generates embeddings for multiple texts using default configuration
+func (e *HashEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return e.EmbedBatchWithConfig(ctx, texts, e.config)
+}
+
+// EmbedBatchWithConfig generates embeddings for multiple texts with custom configuration
+func (e *HashEmbedder) EmbedBatchWithConfig(ctx context.Context, texts []string, config EmbeddingConfig) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		vec, err := e.EmbedWithConfig(ctx, text, config)
+		if err != nil {
+			return nil, err
+		}
+		embeddings[i] = vec
+	}
+	return embeddings, nil
+}
+
+// CalculateSimilarity calculates the similarity between two embeddings
+func (e *HashEmbedder) CalculateSimilarity(vec1, vec2 []float32, metric string) (float32, error) {
+	if metric == "" {
+		metric = e.config.SimilarityMetric
+	}
+	return CalculateSimilarity(vec1, vec2, metric)
+}
+
+// GetConfig returns the current configuration
+func (e *HashEmbedder) GetConfig() EmbeddingConfig {
+	return e.config
+}
+
+// GetModel returns the model name being used
+func (e *HashEmbedder) GetModel() string {
+	return e.config.Model
+}
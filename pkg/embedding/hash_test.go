@@ -0,0 +1,45 @@
+package embedding
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashEmbedder(t *testing.T) {
+	ctx := context.Background()
+	embedder := NewHashEmbedder(64)
+
+	first, err := embedder.Embed(ctx, "The quick brown fox")
+	require.NoError(t, err)
+	assert.Len(t, first, 64)
+
+	again, err := NewHashEmbedder(64).Embed(ctx, "the QUICK brown fox!")
+	require.NoError(t, err)
+	assert.Equal(t, first, again, "embeddings are deterministic and ignore case and punctuation")
+
+	related, err := embedder.Embed(ctx, "a quick brown dog")
+	require.NoError(t, err)
+	unrelated, err := embedder.Embed(ctx, "invoices are sent monthly")
+	require.NoError(t, err)
+
+	relatedScore, err := embedder.CalculateSimilarity(first, related, "")
+	require.NoError(t, err)
+	unrelatedScore, err := embedder.CalculateSimilarity(first, unrelated, "")
+	require.NoError(t, err)
+	assert.Greater(t, relatedScore, unrelatedScore)
+
+	norm, err := CalculateSimilarity(first, first, "dot_product")
+	require.NoError(t, err)
+	assert.InDelta(t, 1.0, norm, 1e-5)
+
+	vectors, err := embedder.EmbedBatch(ctx, []string{"one", ""})
+	require.NoError(t, err)
+	require.Len(t, vectors, 2)
+	assert.Equal(t, make([]float32, 64), vectors[1])
+
+	assert.Equal(t, "hash", NewHashEmbedder(0).GetModel())
+	assert.Equal(t, DefaultHashDimensions, NewHashEmbedder(0).GetConfig().Dimensions)
+}
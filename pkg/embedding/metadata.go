@@ -446,3 +446,90 @@ func operatorToMapKey(operator string) string {
 		return "equals"
 	}
 }
+
+// FilterFromMap converts a filter map back into a MetadataFilterGroup.
+// It accepts the format produced by FilterToMap as well as plain equality maps
+// such as {"category": "science"}. Multiple top-level keys are combined with AND.
+func FilterFromMap(filters map[string]interface{}) MetadataFilterGroup {
+	group := MetadataFilterGroup{Operator: "and"}
+
+	for key, value := range filters {
+		switch strings.ToLower(key) {
+		case "and", "or":
+			conditions, ok := toConditionList(value)
+			if !ok {
+				group.AddFilter(NewMetadataFilter(key, "=", value))
+				continue
+			}
+			subGroup := MetadataFilterGroup{Operator: strings.ToLower(key)}
+			for _, condition := range conditions {
+				parsed := FilterFromMap(condition)
+				// Flatten single-filter conditions into the sub-group
+				if len(parsed.SubGroups) == 0 && len(parsed.Filters) == 1 {
+					subGroup.AddFilter(parsed.Filters[0])
+				} else {
+					subGroup.AddSubGroup(parsed)
+				}
+			}
+			group.AddSubGroup(subGroup)
+		default:
+			if condition, ok := value.(map[string]interface{}); ok {
+				if operator, hasOperator := condition["operator"].(string); hasOperator {
+					group.AddFilter(NewMetadataFilter(key, mapKeyToOperator(operator), condition["value"]))
+					continue
+				}
+			}
+			group.AddFilter(NewMetadataFilter(key, "=", value))
+		}
+	}
+
+	return group
+}
+
+// toConditionList converts a list of conditions in any of the shapes produced by
+// JSON/YAML decoding into a slice of maps
+func toConditionList(value interface{}) ([]map[string]interface{}, bool) {
+	switch v := value.(type) {
+	case []map[string]interface{}:
+		return v, true
+	case []interface{}:
+		conditions := make([]map[string]interface{}, 0, len(v))
+		for _, item := range v {
+			condition, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			conditions = append(conditions, condition)
+		}
+		return conditions, true
+	default:
+		return nil, false
+	}
+}
+
+// mapKeyToOperator converts a map key produced by operatorToMapKey back to a filter operator
+func mapKeyToOperator(key string) string {
+	switch key {
+	case "equals":
+		return "="
+	case "notEquals":
+		return "!="
+	case "greaterThan":
+		return ">"
+	case "greaterThanEqual":
+		return ">="
+	case "lessThan":
+		return "<"
+	case "lessThanEqual":
+		return "<="
+	case "contains":
+		return "contains"
+	case "in":
+		return "in"
+	case "notIn":
+		return "not_in"
+	default:
+		// Allow raw operators such as ">" to be passed through
+		return key
+	}
+}
@@ -0,0 +1,50 @@
+package embedding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+func TestFilterFromMap(t *testing.T) {
+	docs := []interfaces.Document{
+		{ID: "1", Metadata: map[string]interface{}{"category": "science", "words": 50}},
+		{ID: "2", Metadata: map[string]interface{}{"category": "science", "words": 5}},
+		{ID: "3", Metadata: map[string]interface{}{"category": "news", "words": 500}},
+	}
+
+	t.Run("round trip from FilterToMap", func(t *testing.T) {
+		group := NewMetadataFilterGroup("and",
+			NewMetadataFilter("category", "=", "science"),
+			NewMetadataFilter("words", ">", 10),
+		)
+
+		filtered := ApplyFilters(docs, FilterFromMap(FilterToMap(group)))
+		assert.Len(t, filtered, 1)
+		assert.Equal(t, "1", filtered[0].ID)
+	})
+
+	t.Run("single filter", func(t *testing.T) {
+		group := NewMetadataFilterGroup("and", NewMetadataFilter("category", "not_in", []string{"science"}))
+
+		filtered := ApplyFilters(docs, FilterFromMap(FilterToMap(group)))
+		assert.Len(t, filtered, 1)
+		assert.Equal(t, "3", filtered[0].ID)
+	})
+
+	t.Run("nested or group", func(t *testing.T) {
+		group := NewMetadataFilterGroup("or", NewMetadataFilter("words", "<", 10))
+		group.AddSubGroup(NewMetadataFilterGroup("and", NewMetadataFilter("category", "=", "news")))
+
+		filtered := ApplyFilters(docs, FilterFromMap(FilterToMap(group)))
+		assert.Len(t, filtered, 2)
+	})
+
+	t.Run("plain equality map", func(t *testing.T) {
+		filtered := ApplyFilters(docs, FilterFromMap(map[string]interface{}{"category": "news"}))
+		assert.Len(t, filtered, 1)
+		assert.Equal(t, "3", filtered[0].ID)
+	})
+}
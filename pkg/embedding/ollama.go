@@ -0,0 +1,166 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultOllamaBaseURL is the default address of a local Ollama server
+	DefaultOllamaBaseURL = "http://localhost:11434"
+
+	// DefaultOllamaEmbeddingModel is the default Ollama embedding model
+	DefaultOllamaEmbeddingModel = "nomic-embed-text"
+)
+
+// OllamaEmbedder implements embedding generation using Ollama's /api/embed endpoint
+type OllamaEmbedder struct {
+	baseURL    string
+	httpClient *http.Client
+	model      string
+	config     EmbeddingConfig
+}
+
+// NewOllamaEmbedder creates a new OllamaEmbedder with default configuration.
+// An empty base URL uses the local Ollama server.
+func NewOllamaEmbedder(baseURL, model string) *OllamaEmbedder {
+	if model == "" {
+		model = DefaultOllamaEmbeddingModel
+	}
+	return NewOllamaEmbedderWithConfig(baseURL, DefaultEmbeddingConfig(model))
+}
+
+// NewOllamaEmbedderWithConfig creates a new OllamaEmbedder with custom configuration
+func NewOllamaEmbedderWithConfig(baseURL string, config EmbeddingConfig) *OllamaEmbedder {
+	if baseURL == "" {
+		baseURL = DefaultOllamaBaseURL
+	}
+	if config.Model == "" {
+		config.Model = DefaultOllamaEmbeddingModel
+	}
+
+	return &OllamaEmbedder{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+		model:      config.Model,
+		config:     config,
+	}
+}
+
+type ollamaEmbedRequest struct {
+	Model      string   `json:"model"`
+	Input      []string `json:"input"`
+	Truncate   *bool    `json:"truncate,omitempty"`
+	Dimensions int      `json:"dimensions,omitempty"`
+}
+
+type ollamaEmbedResponse struct {
+	Model      string      `json:"model"`
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+// Embed generates an embedding using Ollama with default configuration
+func (e *OllamaEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	return e.EmbedWithConfig(ctx, text, e.config)
+}
+
+// EmbedWithConfig generates an embedding using Ollama with custom configuration
+func (e *OllamaEmbedder) EmbedWithConfig(ctx context.Context, text string, config EmbeddingConfig) ([]float32, error) {
+	embeddings, err := e.EmbedBatchWithConfig(ctx, []string{text}, config)
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// EmbedBatch generates embeddings for multiple texts using default configuration
+func (e *OllamaEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return e.EmbedBatchWithConfig(ctx, texts, e.config)
+}
+
+// EmbedBatchWithConfig generates embeddings for multiple texts with custom configuration
+func (e *OllamaEmbedder) EmbedBatchWithConfig(ctx context.Context, texts []string, config EmbeddingConfig) ([][]float32, error) {
+	if len(texts) == 0 {
+		return [][]float32{}, nil
+	}
+	if config.Model == "" {
+		config.Model = e.model
+	}
+
+	return embedInBatches(ctx, texts, config, func(ctx context.Context, texts []string) ([][]float32, error) {
+		return e.embedBatch(ctx, texts, config)
+	})
+}
+
+// embedBatch embeds texts in a single request
+func (e *OllamaEmbedder) embedBatch(ctx context.Context, texts []string, config EmbeddingConfig) ([][]float32, error) {
+	req := ollamaEmbedRequest{
+		Model:      config.Model,
+		Input:      texts,
+		Dimensions: config.Dimensions,
+	}
+	if config.Truncation == "none" {
+		truncate := false
+		req.Truncate = &truncate
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+"/api/embed", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ollama API error (status %d): %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var embedResp ollamaEmbedResponse
+	if err := json.Unmarshal(respBody, &embedResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if len(embedResp.Embeddings) == 0 {
+		return nil, errors.New("no embedding data returned from API")
+	}
+
+	return embedResp.Embeddings, nil
+}
+
+// CalculateSimilarity calculates the similarity between two embeddings
+func (e *OllamaEmbedder) CalculateSimilarity(vec1, vec2 []float32, metric string) (float32, error) {
+	if metric == "" {
+		metric = e.config.SimilarityMetric
+	}
+	return CalculateSimilarity(vec1, vec2, metric)
+}
+
+// GetConfig returns the current configuration
+func (e *OllamaEmbedder) GetConfig() EmbeddingConfig {
+	return e.config
+}
+
+// GetModel returns the model name being used
+func (e *OllamaEmbedder) GetModel() string {
+	return e.model
+}
@@ -0,0 +1,65 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOllamaEmbedder(t *testing.T) {
+	var requests []ollamaEmbedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/embed", r.URL.Path)
+		var req ollamaEmbedRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		requests = append(requests, req)
+
+		resp := ollamaEmbedResponse{Model: req.Model}
+		for range req.Input {
+			resp.Embeddings = append(resp.Embeddings, []float32{3, 4, 12})
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	defer server.Close()
+
+	config := DefaultEmbeddingConfig("mxbai-embed-large")
+	config.BatchSize = 2
+	config.Dimensions = 2
+	config.Normalize = true
+	embedder := NewOllamaEmbedderWithConfig(server.URL+"/", config)
+
+	vectors, err := embedder.EmbedBatch(context.Background(), []string{"a", "b", "c"})
+	require.NoError(t, err)
+	require.Len(t, vectors, 3)
+	// Truncated to two dimensions, then normalized
+	assert.InDeltaSlice(t, []float32{0.6, 0.8}, vectors[2], 1e-6)
+
+	require.Len(t, requests, 2)
+	assert.Equal(t, []string{"a", "b"}, requests[0].Input)
+	assert.Equal(t, []string{"c"}, requests[1].Input)
+	assert.Equal(t, "mxbai-embed-large", requests[0].Model)
+	assert.Equal(t, 2, requests[0].Dimensions)
+
+	vector, err := embedder.Embed(context.Background(), "single")
+	require.NoError(t, err)
+	assert.Len(t, vector, 2)
+}
+
+func TestOllamaEmbedder_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"model not found"}`, http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	embedder := NewOllamaEmbedder(server.URL, "")
+	assert.Equal(t, DefaultOllamaEmbeddingModel, embedder.GetModel())
+
+	_, err := embedder.Embed(context.Background(), "text")
+	assert.ErrorContains(t, err, "status 404")
+	assert.ErrorContains(t, err, "model not found")
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
@@ -22,6 +23,9 @@ const (
 
 	// DefaultOpenAIEmbeddingModel is the default OpenAI embedding model
 	DefaultOpenAIEmbeddingModel = ModelTextEmbedding3Small
+
+	// DefaultAzureAPIVersion is the default Azure OpenAI API version
+	DefaultAzureAPIVersion = "2024-02-01"
 )
 
 // OpenAIEmbedder implements embedding generation using OpenAI API
@@ -56,6 +60,64 @@ func NewOpenAIEmbedderWithConfig(apiKey string, config EmbeddingConfig) *OpenAIE
 	}
 }
 
+// NewOpenAICompatibleEmbedder creates an embedder for a server implementing the
+// OpenAI /v1/embeddings API, such as vLLM, LocalAI or LM Studio. The base URL
+// includes the version prefix, e.g. "http://localhost:8000/v1". The API key may
+// be empty for servers without authentication.
+func NewOpenAICompatibleEmbedder(baseURL, apiKey string, config EmbeddingConfig) *OpenAIEmbedder {
+	options := isolatedClientOptions(baseURL)
+	if apiKey != "" {
+		options = append(options, option.WithAPIKey(apiKey))
+	}
+
+	// Most compatible servers only return floats
+	if config.EncodingFormat == "" {
+		config.EncodingFormat = "float"
+	}
+
+	return &OpenAIEmbedder{
+		client: openai.NewClient(options...),
+		model:  config.Model,
+		config: config,
+	}
+}
+
+// NewAzureOpenAIEmbedder creates an embedder for an Azure OpenAI embedding
+// deployment. The endpoint is the resource URL, e.g.
+// "https://my-resource.openai.azure.com", and the deployment name is used as
+// the model.
+func NewAzureOpenAIEmbedder(endpoint, apiKey, deployment, apiVersion string, config EmbeddingConfig) *OpenAIEmbedder {
+	if apiVersion == "" {
+		apiVersion = DefaultAzureAPIVersion
+	}
+	config.Model = deployment
+
+	baseURL := fmt.Sprintf("%s/openai/deployments/%s", strings.TrimSuffix(endpoint, "/"), deployment)
+	return &OpenAIEmbedder{
+		client: openai.NewClient(isolatedClientOptions(baseURL,
+			option.WithHeader("api-key", apiKey),
+			option.WithQuery("api-version", apiVersion),
+		)...),
+		model:  deployment,
+		config: config,
+	}
+}
+
+// isolatedClientOptions returns the client options for a server other than
+// the OpenAI API. openai.NewClient first applies defaults read from the
+// OPENAI_API_KEY, OPENAI_BASE_URL, OPENAI_ORG_ID and OPENAI_PROJECT_ID
+// environment variables, so the base URL is pinned and the OpenAI credentials,
+// organization and project headers are removed to keep them from being sent
+// to that server.
+func isolatedClientOptions(baseURL string, options ...option.RequestOption) []option.RequestOption {
+	return append([]option.RequestOption{
+		option.WithBaseURL(baseURL),
+		option.WithHeaderDel("Authorization"),
+		option.WithHeaderDel("OpenAI-Organization"),
+		option.WithHeaderDel("OpenAI-Project"),
+	}, options...)
+}
+
 // Embed generates an embedding using OpenAI API with default configuration
 func (e *OpenAIEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
 	return e.EmbedWithConfig(ctx, text, e.config)
@@ -96,7 +158,7 @@ func (e *OpenAIEmbedder) EmbedWithConfig(ctx context.Context, text string, confi
 		embedding[i] = float32(v)
 	}
 
-	return embedding, nil
+	return applyConfig(embedding, config), nil
 }
 
 // EmbedBatch generates embeddings for multiple texts using default configuration
@@ -110,6 +172,13 @@ func (e *OpenAIEmbedder) EmbedBatchWithConfig(ctx context.Context, texts []strin
 		return [][]float32{}, nil
 	}
 
+	return embedInBatches(ctx, texts, config, func(ctx context.Context, texts []string) ([][]float32, error) {
+		return e.embedBatch(ctx, texts, config)
+	})
+}
+
+// embedBatch embeds texts in a single request
+func (e *OpenAIEmbedder) embedBatch(ctx context.Context, texts []string, config EmbeddingConfig) ([][]float32, error) {
 	req := openai.EmbeddingNewParams{
 		Input: openai.EmbeddingNewParamsInputUnion{OfArrayOfStrings: texts},
 		Model: openai.EmbeddingModel(config.Model),
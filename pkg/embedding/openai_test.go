@@ -1,9 +1,13 @@
 package embedding
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestOpenAIModelConstants(t *testing.T) {
@@ -85,3 +89,51 @@ func TestOpenAIEmbedder_CalculateSimilarity(t *testing.T) {
 		assert.InDelta(t, 1.0, similarity, 0.01)
 	})
 }
+
+func TestOpenAICompatibleEmbedders_IgnoreOpenAIEnvironment(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "sk-environment")
+	t.Setenv("OPENAI_BASE_URL", "http://127.0.0.1:1/v1")
+	t.Setenv("OPENAI_ORG_ID", "org-environment")
+	t.Setenv("OPENAI_PROJECT_ID", "proj-environment")
+
+	var headers []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Clone())
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"object":"list","data":[{"object":"embedding","index":0,"embedding":[1,0]}],"model":"m"}`))
+	}))
+	defer server.Close()
+
+	embedders := map[string]*OpenAIEmbedder{
+		"compatible without key": NewOpenAICompatibleEmbedder(server.URL+"/v1", "", DefaultEmbeddingConfig("m")),
+		"compatible with key":    NewOpenAICompatibleEmbedder(server.URL+"/v1", "local-key", DefaultEmbeddingConfig("m")),
+		"azure":                  NewAzureOpenAIEmbedder(server.URL, "azure-key", "m", "", DefaultEmbeddingConfig("m")),
+	}
+	expected := map[string]string{
+		"compatible without key": "",
+		"compatible with key":    "Bearer local-key",
+		"azure":                  "",
+	}
+
+	for name, embedder := range embedders {
+		t.Run(name, func(t *testing.T) {
+			headers = nil
+			_, err := embedder.Embed(context.Background(), "text")
+			require.NoError(t, err)
+			require.Len(t, headers, 1)
+
+			header := headers[0]
+			assert.Equal(t, expected[name], header.Get("Authorization"))
+			assert.Empty(t, header.Get("OpenAI-Organization"))
+			assert.Empty(t, header.Get("OpenAI-Project"))
+			for _, values := range header {
+				for _, value := range values {
+					assert.NotContains(t, value, "environment")
+				}
+			}
+			if name == "azure" {
+				assert.Equal(t, "azure-key", header.Get("api-key"))
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+// Package pgcache provides a PostgreSQL backend for embedding.CachedClient
+package pgcache
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"github.com/tagus/agent-sdk-go/pkg/datastore/postgres"
+	"github.com/tagus/agent-sdk-go/pkg/embedding"
+)
+
+// DefaultTable is the table used when none is configured
+const DefaultTable = "embedding_cache"
+
+var tablePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// Cache stores embeddings in a PostgreSQL table
+type Cache struct {
+	db    *sql.DB
+	table string
+}
+
+// Option represents an option for configuring the cache
+type Option func(*Cache)
+
+// WithTable sets the cache table, optionally schema-qualified
+func WithTable(table string) Option {
+	return func(c *Cache) {
+		c.table = table
+	}
+}
+
+// New creates a PostgreSQL embedding cache that shares the connection of an existing client
+func New(client *postgres.Client, options ...Option) (*Cache, error) {
+	if client == nil {
+		return nil, errors.New("postgres client cannot be nil")
+	}
+	return NewWithDB(client.DB(), options...)
+}
+
+// NewWithDB creates a PostgreSQL embedding cache and its table if needed
+func NewWithDB(db *sql.DB, options ...Option) (*Cache, error) {
+	c := &Cache{db: db, table: DefaultTable}
+	for _, option := range options {
+		option(c)
+	}
+	if !tablePattern.MatchString(c.table) {
+		return nil, fmt.Errorf("invalid cache table name: %q", c.table)
+	}
+
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		key TEXT PRIMARY KEY,
+		vector BYTEA NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`, c.table)
+	if _, err := db.ExecContext(context.Background(), query); err != nil {
+		return nil, fmt.Errorf("failed to create embedding cache table: %w", err)
+	}
+	return c, nil
+}
+
+// Get returns the cached embedding for each key, with nil for misses
+func (c *Cache) Get(ctx context.Context, keys []string) ([][]float32, error) {
+	vectors := make([][]float32, len(keys))
+	if len(keys) == 0 {
+		return vectors, nil
+	}
+
+	rows, err := c.db.QueryContext(ctx, fmt.Sprintf("SELECT key, vector FROM %s WHERE key = ANY($1)", c.table), pq.Array(keys))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embeddings: %w", err)
+	}
+	defer rows.Close()
+
+	found := make(map[string][]float32, len(keys))
+	for rows.Next() {
+		var key string
+		var data []byte
+		if err := rows.Scan(&key, &data); err != nil {
+			return nil, fmt.Errorf("failed to scan embedding: %w", err)
+		}
+		vec, err := embedding.DecodeVector(data)
+		if err != nil {
+			continue
+		}
+		found[key] = vec
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read embeddings: %w", err)
+	}
+
+	for i, key := range keys {
+		vectors[i] = found[key]
+	}
+	return vectors, nil
+}
+
+// Set stores embeddings under the given keys
+func (c *Cache) Set(ctx context.Context, keys []string, vectors [][]float32) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(keys))
+	args := make([]interface{}, 0, 2*len(keys))
+	for i, key := range keys {
+		placeholders[i] = fmt.Sprintf("($%d, $%d)", 2*i+1, 2*i+2)
+		args = append(args, key, embedding.EncodeVector(vectors[i]))
+	}
+
+	query := fmt.Sprintf(`INSERT INTO %s (key, vector) VALUES %s
+		ON CONFLICT (key) DO UPDATE SET vector = EXCLUDED.vector, created_at = now()`,
+		c.table, strings.Join(placeholders, ", "))
+	if _, err := c.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to write embeddings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package pgcache
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvalidTableName(t *testing.T) {
+	_, err := NewWithDB(nil, WithTable("cache; DROP TABLE users"))
+	assert.Error(t, err)
+}
+
+func TestCache(t *testing.T) {
+	connStr := os.Getenv("POSTGRES_URL")
+	if connStr == "" {
+		t.Skip("POSTGRES_URL not set, skipping PostgreSQL embedding cache tests")
+	}
+
+	db, err := sql.Open("postgres", connStr)
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	cache, err := NewWithDB(db, WithTable("embedding_cache_test"))
+	require.NoError(t, err)
+	defer func() {
+		_, _ = db.ExecContext(ctx, "DROP TABLE IF EXISTS embedding_cache_test")
+	}()
+
+	require.NoError(t, cache.Set(ctx, []string{"a", "b"}, [][]float32{{1, 2}, {3, 4}}))
+	require.NoError(t, cache.Set(ctx, []string{"a"}, [][]float32{{5, 6}}))
+
+	vectors, err := cache.Get(ctx, []string{"b", "missing", "a"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{3, 4}, nil, {5, 6}}, vectors)
+}
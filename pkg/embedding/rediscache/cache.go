@@ -0,0 +1,102 @@
+// Package rediscache provides a Redis backend for embedding.CachedClient
+package rediscache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/tagus/agent-sdk-go/pkg/embedding"
+)
+
+// DefaultPrefix is prepended to every cache key
+const DefaultPrefix = "embedding:"
+
+// Cache stores embeddings in Redis as binary float32 values
+type Cache struct {
+	client redis.UniversalClient
+	prefix string
+	ttl    time.Duration
+}
+
+// Option represents an option for configuring the cache
+type Option func(*Cache)
+
+// WithPrefix sets the prefix of the Redis keys
+func WithPrefix(prefix string) Option {
+	return func(c *Cache) {
+		c.prefix = prefix
+	}
+}
+
+// WithTTL sets the expiration of cached embeddings. Zero keeps them until evicted by Redis.
+func WithTTL(ttl time.Duration) Option {
+	return func(c *Cache) {
+		c.ttl = ttl
+	}
+}
+
+// New creates a Redis embedding cache on an existing client, such as the one
+// used by memory.RedisMemory
+func New(client redis.UniversalClient, options ...Option) *Cache {
+	c := &Cache{
+		client: client,
+		prefix: DefaultPrefix,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// Get returns the cached embedding for each key, with nil for misses
+func (c *Cache) Get(ctx context.Context, keys []string) ([][]float32, error) {
+	if len(keys) == 0 {
+		return [][]float32{}, nil
+	}
+
+	redisKeys := make([]string, len(keys))
+	for i, key := range keys {
+		redisKeys[i] = c.prefix + key
+	}
+
+	values, err := c.client.MGet(ctx, redisKeys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embeddings from redis: %w", err)
+	}
+
+	vectors := make([][]float32, len(keys))
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		vec, err := embedding.DecodeVector([]byte(data))
+		if err != nil {
+			// Treat corrupt entries as misses; they are overwritten on the next Set
+			continue
+		}
+		vectors[i] = vec
+	}
+	return vectors, nil
+}
+
+// Set stores embeddings under the given keys
+func (c *Cache) Set(ctx context.Context, keys []string, vectors [][]float32) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			pipe.Set(ctx, c.prefix+key, embedding.EncodeVector(vectors[i]), c.ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write embeddings to redis: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package rediscache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tagus/agent-sdk-go/pkg/embedding"
+)
+
+func TestCache(t *testing.T) {
+	server, err := miniredis.Run()
+	require.NoError(t, err)
+	defer server.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	ctx := context.Background()
+	cache := New(client, WithPrefix("test:"), WithTTL(time.Minute))
+
+	require.NoError(t, cache.Set(ctx, []string{"a", "b"}, [][]float32{{1, 2}, {3, 4}}))
+	assert.True(t, server.Exists("test:a"))
+	assert.Equal(t, time.Minute, server.TTL("test:a"))
+
+	vectors, err := cache.Get(ctx, []string{"b", "missing", "a"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{3, 4}, nil, {1, 2}}, vectors)
+}
+
+func TestCachedClientWithRedis(t *testing.T) {
+	server, err := miniredis.Run()
+	require.NoError(t, err)
+	defer server.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	ctx := context.Background()
+	cached := embedding.NewCachedClient(embedding.NewHashEmbedder(16), New(client))
+
+	first, err := cached.EmbedBatch(ctx, []string{"hello world", "goodbye"})
+	require.NoError(t, err)
+
+	second, err := cached.EmbedBatch(ctx, []string{"goodbye", "hello world"})
+	require.NoError(t, err)
+	assert.Equal(t, first[1], second[0])
+	assert.Equal(t, first[0], second[1])
+	assert.Equal(t, embedding.CacheStats{Hits: 2, Misses: 2}, cached.Stats())
+}
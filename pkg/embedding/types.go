@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 )
 
 // EmbeddingConfig contains configuration options for embedding generation
@@ -32,6 +33,13 @@ type EmbeddingConfig struct {
 
 	// UserID is an optional identifier for tracking embedding usage
 	UserID string
+
+	// BatchSize is the maximum number of texts sent in one request
+	// Zero sends all texts of a batch call in a single request
+	BatchSize int
+
+	// Normalize scales every embedding to unit length
+	Normalize bool
 }
 
 // DefaultEmbeddingConfig returns a default configuration for embedding generation
@@ -97,10 +105,10 @@ func cosineSimilarity(vec1, vec2 []float32) float32 {
 		mag2 += vec2[i] * vec2[i]
 	}
 
-	mag1 = float32(float64(mag1) + 1e-9) // Avoid division by zero
-	mag2 = float32(float64(mag2) + 1e-9) // Avoid division by zero
+	// Avoid division by zero
+	denominator := math.Sqrt(float64(mag1))*math.Sqrt(float64(mag2)) + 1e-9
 
-	return dotProd / (float32(float64(mag1) * float64(mag2)))
+	return float32(float64(dotProd) / denominator)
 }
 
 // euclideanDistance calculates the euclidean distance between two vectors
@@ -129,3 +137,59 @@ func dotProduct(vec1, vec2 []float32) float32 {
 
 	return sum
 }
+
+// NormalizeVector scales a vector to unit length in place and returns it.
+// Zero vectors are returned unchanged.
+func NormalizeVector(vec []float32) []float32 {
+	var sum float64
+	for _, v := range vec {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return vec
+	}
+	norm := math.Sqrt(sum)
+	for i, v := range vec {
+		vec[i] = float32(float64(v) / norm)
+	}
+	return vec
+}
+
+// applyConfig truncates an embedding to the configured dimensions and
+// normalizes it when requested
+func applyConfig(vec []float32, config EmbeddingConfig) []float32 {
+	if config.Dimensions > 0 && len(vec) > config.Dimensions {
+		vec = vec[:config.Dimensions]
+	}
+	if config.Normalize {
+		vec = NormalizeVector(vec)
+	}
+	return vec
+}
+
+// embedInBatches splits texts into requests of at most config.BatchSize texts
+func embedInBatches(ctx context.Context, texts []string, config EmbeddingConfig, embed func(ctx context.Context, batch []string) ([][]float32, error)) ([][]float32, error) {
+	size := config.BatchSize
+	if size <= 0 {
+		size = len(texts)
+	}
+
+	embeddings := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += size {
+		end := start + size
+		if end > len(texts) {
+			end = len(texts)
+		}
+		batch, err := embed(ctx, texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) != end-start {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", end-start, len(batch))
+		}
+		for _, vec := range batch {
+			embeddings = append(embeddings, applyConfig(vec, config))
+		}
+	}
+	return embeddings, nil
+}
@@ -1,6 +1,7 @@
 package embedding
 
 import (
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -116,6 +117,23 @@ func TestCosineSimilarity(t *testing.T) {
 		similarity := cosineSimilarity(vec1, vec2)
 		assert.InDelta(t, -1.0, similarity, 0.01)
 	})
+
+	t.Run("known vectors", func(t *testing.T) {
+		// The magnitudes are square roots of the sums of squares
+		assert.InDelta(t, 0.96, cosineSimilarity([]float32{3, 4}, []float32{4, 3}), 1e-6)
+		assert.InDelta(t, 32/math.Sqrt(14*77), cosineSimilarity([]float32{1, 2, 3}, []float32{4, 5, 6}), 1e-6)
+		assert.InDelta(t, 0.0, cosineSimilarity([]float32{2, 0}, []float32{0, 5}), 1e-6)
+	})
+
+	t.Run("scale invariant", func(t *testing.T) {
+		similarity := cosineSimilarity([]float32{1, 2, 3}, []float32{10, 20, 30})
+		assert.InDelta(t, 1.0, similarity, 1e-6)
+	})
+
+	t.Run("zero vector", func(t *testing.T) {
+		similarity := cosineSimilarity([]float32{0, 0}, []float32{1, 1})
+		assert.Equal(t, float32(0), similarity)
+	})
 }
 
 func TestEuclideanDistance(t *testing.T) {
@@ -0,0 +1,770 @@
+package graphrag
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+
+	"github.com/tagus/agent-sdk-go/pkg/embedding"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/logging"
+	"github.com/tagus/agent-sdk-go/pkg/structuredoutput"
+)
+
+const (
+	// DefaultChunkSize is the maximum number of characters sent to the LLM per request
+	DefaultChunkSize = 4000
+
+	// DefaultChunkOverlap is the number of characters shared by consecutive chunks
+	DefaultChunkOverlap = 200
+
+	// DefaultDedupThreshold is the embedding similarity above which entities of the same type are merged
+	DefaultDedupThreshold = 0.92
+
+	// ConfidenceProperty is the property key holding the confidence of extracted entities and relationships
+	ConfidenceProperty = "confidence"
+)
+
+// Extractor turns unstructured text into entities and relationships using an LLM.
+//
+// Long text is split into overlapping chunks that are extracted independently with
+// structured output. Entities found in several chunks are merged by normalized name
+// and, when an embedder is configured, by embedding similarity; relationships are
+// resolved against the merged entities. Every backend can use it to implement
+// GraphRAGStore.ExtractFromText.
+type Extractor struct {
+	llm          interfaces.LLM
+	embedder     embedding.Client
+	schema       *GraphSchema
+	logger       logging.Logger
+	chunkSize    int
+	chunkOverlap int
+	concurrency  int
+}
+
+// ExtractorOption represents an option for configuring an Extractor
+type ExtractorOption func(*Extractor)
+
+// WithExtractorEmbedder sets the embedder used for similarity-based deduplication.
+// Merged entities are returned with their embedding set.
+func WithExtractorEmbedder(embedder embedding.Client) ExtractorOption {
+	return func(e *Extractor) {
+		e.embedder = embedder
+	}
+}
+
+// WithExtractorSchema sets the schema used for schema-guided extraction
+func WithExtractorSchema(schema *GraphSchema) ExtractorOption {
+	return func(e *Extractor) {
+		e.schema = schema
+	}
+}
+
+// WithExtractorLogger sets the logger for the extractor
+func WithExtractorLogger(logger logging.Logger) ExtractorOption {
+	return func(e *Extractor) {
+		e.logger = logger
+	}
+}
+
+// WithChunking sets the chunk size and overlap in characters
+func WithChunking(size, overlap int) ExtractorOption {
+	return func(e *Extractor) {
+		if size > 0 {
+			e.chunkSize = size
+		}
+		if overlap >= 0 && overlap < e.chunkSize {
+			e.chunkOverlap = overlap
+		}
+	}
+}
+
+// WithExtractorConcurrency sets how many chunks are extracted in parallel
+func WithExtractorConcurrency(concurrency int) ExtractorOption {
+	return func(e *Extractor) {
+		if concurrency > 0 {
+			e.concurrency = concurrency
+		}
+	}
+}
+
+// NewExtractor creates a new extractor
+func NewExtractor(llm interfaces.LLM, options ...ExtractorOption) *Extractor {
+	e := &Extractor{
+		llm:          llm,
+		logger:       logging.New(),
+		chunkSize:    DefaultChunkSize,
+		chunkOverlap: DefaultChunkOverlap,
+		concurrency:  1,
+	}
+
+	for _, option := range options {
+		option(e)
+	}
+
+	return e
+}
+
+// extractionResponse is the structured output requested from the LLM for each chunk
+type extractionResponse struct {
+	Entities      []extractedEntity       `json:"entities" description:"Entities mentioned in the text"`
+	Relationships []extractedRelationship `json:"relationships" description:"Relationships between the extracted entities"`
+}
+
+type extractedEntity struct {
+	Name        string            `json:"name" description:"Canonical name of the entity"`
+	Type        string            `json:"type" description:"Entity type"`
+	Description string            `json:"description" description:"Short description based only on the text"`
+	Properties  map[string]string `json:"properties,omitempty" description:"Additional attributes stated in the text"`
+	Confidence  float64           `json:"confidence" description:"Confidence between 0 and 1 that the entity is correct"`
+}
+
+type extractedRelationship struct {
+	Source      string  `json:"source" description:"Name of the source entity"`
+	Target      string  `json:"target" description:"Name of the target entity"`
+	Type        string  `json:"type" description:"Relationship type in UPPER_SNAKE_CASE"`
+	Description string  `json:"description" description:"Short description of the relationship"`
+	Strength    float64 `json:"strength" description:"Strength of the relationship between 0 and 1"`
+	Confidence  float64 `json:"confidence" description:"Confidence between 0 and 1 that the relationship is correct"`
+}
+
+// candidate is an entity mention being merged across chunks
+type candidate struct {
+	entity      Entity
+	key         string
+	confidences []float64
+	firstSeen   int
+}
+
+// Extract extracts entities and relationships from text. The result is ready to pass
+// to StoreEntities and StoreRelationships; per-item confidence is recorded under the
+// ConfidenceProperty property.
+func (e *Extractor) Extract(ctx context.Context, text string, options ...ExtractionOption) (*ExtractionResult, error) {
+	if e.llm == nil {
+		return nil, ErrNoLLM
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("%w: text is empty", ErrExtractionFailed)
+	}
+
+	opts := &ExtractionOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+	if opts.DedupThreshold <= 0 {
+		opts.DedupThreshold = DefaultDedupThreshold
+	}
+
+	entityTypes, relTypes := e.allowedTypes(opts)
+	chunks := ChunkText(text, e.chunkSize, e.chunkOverlap)
+	responses, err := e.extractChunks(ctx, chunks, opts, entityTypes, relTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, aliases := mergeByName(responses, e.schema, opts.SchemaGuided)
+	if e.embedder != nil {
+		candidates, err = e.mergeBySimilarity(ctx, candidates, aliases, opts.DedupThreshold)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	result := &ExtractionResult{
+		Entities:      []Entity{},
+		Relationships: []Relationship{},
+		SourceText:    text,
+	}
+	kept := map[string]string{}
+	for _, c := range selectEntities(candidates, entityTypes, opts) {
+		c.entity.ID = uuid.New().String()
+		c.entity.CreatedAt = now
+		c.entity.UpdatedAt = now
+		kept[c.key] = c.entity.ID
+		result.Entities = append(result.Entities, c.entity)
+	}
+	result.Relationships = mergeRelationships(responses, aliases, kept, relTypes, opts.MinConfidence, now)
+	result.Confidence = overallConfidence(result)
+
+	e.logger.Debug(ctx, "Extracted knowledge graph", map[string]interface{}{
+		"chunks":        len(chunks),
+		"entities":      len(result.Entities),
+		"relationships": len(result.Relationships),
+	})
+
+	return result, nil
+}
+
+// allowedTypes returns the entity and relationship types extraction is limited to.
+// Explicit options win; schema-guided extraction falls back to the schema's types.
+func (e *Extractor) allowedTypes(opts *ExtractionOptions) ([]string, []string) {
+	entityTypes := opts.EntityTypes
+	relTypes := opts.RelationshipTypes
+	if opts.SchemaGuided && e.schema != nil {
+		if len(entityTypes) == 0 {
+			for _, t := range e.schema.EntityTypes {
+				entityTypes = append(entityTypes, t.Name)
+			}
+		}
+		if len(relTypes) == 0 {
+			for _, t := range e.schema.RelationshipTypes {
+				relTypes = append(relTypes, t.Name)
+			}
+		}
+	}
+	return entityTypes, relTypes
+}
+
+// extractChunks runs extraction on every chunk. Chunks whose response cannot be parsed
+// are skipped; extraction fails only if no chunk succeeds.
+func (e *Extractor) extractChunks(ctx context.Context, chunks []string, opts *ExtractionOptions, entityTypes, relTypes []string) ([]*extractionResponse, error) {
+	responses := make([]*extractionResponse, len(chunks))
+	errs := make([]error, len(chunks))
+	format := structuredoutput.NewResponseFormat(extractionResponse{})
+	system := "You extract knowledge graphs from text. Only extract facts stated in the text and respond with JSON matching the requested schema."
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, e.concurrency)
+	for i, chunk := range chunks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, chunk string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			prompt := e.buildPrompt(chunk, i, len(chunks), opts, entityTypes, relTypes)
+			response, err := e.llm.Generate(ctx, prompt,
+				interfaces.WithSystemMessage(system),
+				interfaces.WithResponseFormat(*format))
+			if err != nil {
+				errs[i] = err
+				return
+			}
+
+			var parsed extractionResponse
+			if err := json.Unmarshal([]byte(stripCodeFence(response)), &parsed); err != nil {
+				errs[i] = fmt.Errorf("invalid LLM response: %w", err)
+				return
+			}
+			responses[i] = &parsed
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for i, err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("%w: %v", ErrExtractionFailed, ctx.Err())
+		}
+		e.logger.Warn(ctx, "Failed to extract chunk", map[string]interface{}{
+			"chunk": i,
+			"error": err.Error(),
+		})
+	}
+	if succeeded == 0 {
+		return nil, fmt.Errorf("%w: %v", ErrExtractionFailed, errs[0])
+	}
+
+	return responses, nil
+}
+
+// buildPrompt describes the extraction task for one chunk
+func (e *Extractor) buildPrompt(chunk string, index, total int, opts *ExtractionOptions, entityTypes, relTypes []string) string {
+	var b strings.Builder
+	b.WriteString("Extract the entities and the relationships between them from the text below.\n")
+
+	if opts.SchemaGuided && e.schema != nil {
+		b.WriteString("\nUse this schema:\n")
+		for _, t := range e.schema.EntityTypes {
+			if len(entityTypes) > 0 && !containsString(entityTypes, t.Name) {
+				continue
+			}
+			fmt.Fprintf(&b, "- Entity type %s", t.Name)
+			if t.Description != "" {
+				fmt.Fprintf(&b, ": %s", t.Description)
+			}
+			b.WriteString("\n")
+			writePropertySchemas(&b, t.Properties)
+		}
+		for _, t := range e.schema.RelationshipTypes {
+			if len(relTypes) > 0 && !containsString(relTypes, t.Name) {
+				continue
+			}
+			fmt.Fprintf(&b, "- Relationship type %s", t.Name)
+			if len(t.SourceTypes) > 0 || len(t.TargetTypes) > 0 {
+				fmt.Fprintf(&b, " (%s -> %s)", strings.Join(t.SourceTypes, "|"), strings.Join(t.TargetTypes, "|"))
+			}
+			if t.Description != "" {
+				fmt.Fprintf(&b, ": %s", t.Description)
+			}
+			b.WriteString("\n")
+			writePropertySchemas(&b, t.Properties)
+		}
+	}
+	if len(entityTypes) > 0 {
+		fmt.Fprintf(&b, "\nOnly extract entities of these types: %s.\n", strings.Join(entityTypes, ", "))
+	}
+	if len(relTypes) > 0 {
+		fmt.Fprintf(&b, "Only extract relationships of these types: %s.\n", strings.Join(relTypes, ", "))
+	}
+	if opts.MaxEntities > 0 {
+		fmt.Fprintf(&b, "Extract at most %d entities, preferring the most important ones.\n", opts.MaxEntities)
+	}
+
+	b.WriteString("\nUse the same name for an entity every time it is mentioned. ")
+	b.WriteString("Relationship source and target must be names of extracted entities. ")
+	b.WriteString("Confidence and strength are numbers between 0 and 1.\n")
+	if total > 1 {
+		fmt.Fprintf(&b, "\nThis is part %d of %d of a longer document.\n", index+1, total)
+	}
+	b.WriteString("\nText:\n")
+	b.WriteString(chunk)
+	return b.String()
+}
+
+func writePropertySchemas(b *strings.Builder, properties []PropertySchema) {
+	for _, p := range properties {
+		fmt.Fprintf(b, "  - property %s (%s)", p.Name, p.Type)
+		if p.Required {
+			b.WriteString(", required")
+		}
+		if p.Description != "" {
+			fmt.Fprintf(b, ": %s", p.Description)
+		}
+		b.WriteString("\n")
+	}
+}
+
+// mergeByName merges entity mentions with the same normalized name. It returns the
+// candidates in order of first mention and a map from normalized mention names to
+// candidate keys, used to resolve relationship endpoints.
+func mergeByName(responses []*extractionResponse, schema *GraphSchema, schemaGuided bool) ([]*candidate, map[string]string) {
+	byKey := map[string]*candidate{}
+	var candidates []*candidate
+	aliases := map[string]string{}
+	mention := 0
+
+	for _, response := range responses {
+		if response == nil {
+			continue
+		}
+		for _, extracted := range response.Entities {
+			key := NormalizeEntityName(extracted.Name)
+			if key == "" || strings.TrimSpace(extracted.Type) == "" {
+				continue
+			}
+			mention++
+			conf := clampConfidence(extracted.Confidence)
+			properties := convertProperties(extracted.Properties, propertySchemasFor(schema, schemaGuided, extracted.Type))
+
+			c, ok := byKey[key]
+			if !ok {
+				c = &candidate{
+					key:       key,
+					firstSeen: mention,
+					entity: Entity{
+						Name:        strings.TrimSpace(extracted.Name),
+						Type:        strings.TrimSpace(extracted.Type),
+						Description: strings.TrimSpace(extracted.Description),
+						Properties:  properties,
+					},
+				}
+				byKey[key] = c
+				aliases[key] = key
+				candidates = append(candidates, c)
+			} else {
+				// The most confident mention decides the name and type
+				if conf > maxFloat(c.confidences) {
+					c.entity.Name = strings.TrimSpace(extracted.Name)
+					c.entity.Type = strings.TrimSpace(extracted.Type)
+				}
+				c.entity.Description = mergeDescriptions(c.entity.Description, extracted.Description)
+				c.entity.Properties = mergeProperties(c.entity.Properties, properties)
+			}
+			c.confidences = append(c.confidences, conf)
+		}
+	}
+
+	return candidates, aliases
+}
+
+// mergeBySimilarity merges candidates of the same type whose embeddings are at least
+// threshold similar, and sets each remaining candidate's embedding
+func (e *Extractor) mergeBySimilarity(ctx context.Context, candidates []*candidate, aliases map[string]string, threshold float32) ([]*candidate, error) {
+	if len(candidates) == 0 {
+		return candidates, nil
+	}
+
+	texts := make([]string, len(candidates))
+	for i, c := range candidates {
+		texts[i] = EntityEmbeddingText(c.entity)
+	}
+	vectors, err := e.embedder.EmbedBatch(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed extracted entities: %w", err)
+	}
+	if len(vectors) != len(candidates) {
+		return nil, fmt.Errorf("failed to embed extracted entities: expected %d embeddings, got %d", len(candidates), len(vectors))
+	}
+
+	merged := make([]bool, len(candidates))
+	var result []*candidate
+	for i, c := range candidates {
+		if merged[i] {
+			continue
+		}
+		c.entity.Embedding = vectors[i]
+		for j := i + 1; j < len(candidates); j++ {
+			other := candidates[j]
+			if merged[j] || !strings.EqualFold(other.entity.Type, c.entity.Type) {
+				continue
+			}
+			similarity, err := e.embedder.CalculateSimilarity(vectors[i], vectors[j], "cosine")
+			if err != nil || similarity < threshold {
+				continue
+			}
+			merged[j] = true
+			if maxFloat(other.confidences) > maxFloat(c.confidences) {
+				c.entity.Name = other.entity.Name
+			}
+			c.entity.Description = mergeDescriptions(c.entity.Description, other.entity.Description)
+			c.entity.Properties = mergeProperties(c.entity.Properties, other.entity.Properties)
+			c.confidences = append(c.confidences, other.confidences...)
+			for alias, key := range aliases {
+				if key == other.key {
+					aliases[alias] = c.key
+				}
+			}
+		}
+		result = append(result, c)
+	}
+
+	return result, nil
+}
+
+// selectEntities applies type, confidence and count limits. The most confident
+// entities are kept when MaxEntities is set; results keep first-mention order.
+func selectEntities(candidates []*candidate, entityTypes []string, opts *ExtractionOptions) []*candidate {
+	var selected []*candidate
+	for _, c := range candidates {
+		if len(entityTypes) > 0 && !containsString(entityTypes, c.entity.Type) {
+			continue
+		}
+		conf := combineConfidence(c.confidences)
+		if conf < opts.MinConfidence {
+			continue
+		}
+		properties := make(map[string]interface{}, len(c.entity.Properties)+1)
+		for k, v := range c.entity.Properties {
+			properties[k] = v
+		}
+		properties[ConfidenceProperty] = roundConfidence(conf)
+		c.entity.Properties = properties
+		selected = append(selected, c)
+	}
+
+	if opts.MaxEntities > 0 && len(selected) > opts.MaxEntities {
+		sort.SliceStable(selected, func(i, j int) bool {
+			return combineConfidence(selected[i].confidences) > combineConfidence(selected[j].confidences)
+		})
+		selected = selected[:opts.MaxEntities]
+		sort.SliceStable(selected, func(i, j int) bool {
+			return selected[i].firstSeen < selected[j].firstSeen
+		})
+	}
+	return selected
+}
+
+// mergeRelationships resolves relationship endpoints to kept entities and merges
+// duplicates with the same source, target and type
+func mergeRelationships(responses []*extractionResponse, aliases, kept map[string]string, relTypes []string, minConfidence float32, now time.Time) []Relationship {
+	type merged struct {
+		rel         Relationship
+		confidences []float64
+	}
+	byKey := map[string]*merged{}
+	var order []string
+
+	for _, response := range responses {
+		if response == nil {
+			continue
+		}
+		for _, extracted := range response.Relationships {
+			relType := strings.TrimSpace(extracted.Type)
+			if relType == "" || (len(relTypes) > 0 && !containsString(relTypes, relType)) {
+				continue
+			}
+			sourceID := kept[aliases[NormalizeEntityName(extracted.Source)]]
+			targetID := kept[aliases[NormalizeEntityName(extracted.Target)]]
+			if sourceID == "" || targetID == "" || sourceID == targetID {
+				continue
+			}
+
+			strength := float32(extracted.Strength)
+			if strength <= 0 || strength > 1 {
+				strength = 1.0
+			}
+
+			key := sourceID + "|" + targetID + "|" + relType
+			m, ok := byKey[key]
+			if !ok {
+				m = &merged{rel: Relationship{
+					SourceID:    sourceID,
+					TargetID:    targetID,
+					Type:        relType,
+					Description: strings.TrimSpace(extracted.Description),
+					Strength:    strength,
+					CreatedAt:   now,
+				}}
+				byKey[key] = m
+				order = append(order, key)
+			} else {
+				m.rel.Description = mergeDescriptions(m.rel.Description, extracted.Description)
+				if strength > m.rel.Strength {
+					m.rel.Strength = strength
+				}
+			}
+			m.confidences = append(m.confidences, clampConfidence(extracted.Confidence))
+		}
+	}
+
+	relationships := []Relationship{}
+	for _, key := range order {
+		m := byKey[key]
+		conf := combineConfidence(m.confidences)
+		if conf < minConfidence {
+			continue
+		}
+		m.rel.ID = uuid.New().String()
+		m.rel.Properties = map[string]interface{}{ConfidenceProperty: roundConfidence(conf)}
+		relationships = append(relationships, m.rel)
+	}
+	return relationships
+}
+
+// NormalizeEntityName returns the key used to match entity names: lowercased,
+// without punctuation or a leading article, with whitespace collapsed
+func NormalizeEntityName(name string) string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(fields) > 1 && (fields[0] == "the" || fields[0] == "a" || fields[0] == "an") {
+		fields = fields[1:]
+	}
+	return strings.Join(fields, " ")
+}
+
+// EntityEmbeddingText is the text stores and the extractor embed for an entity
+func EntityEmbeddingText(entity Entity) string {
+	parts := []string{entity.Name, entity.Type}
+	if entity.Description != "" {
+		parts = append(parts, entity.Description)
+	}
+	return strings.Join(parts, " ")
+}
+
+// ChunkText splits text into chunks of at most size characters, with consecutive
+// chunks sharing about overlap characters. Chunks end at paragraph, sentence or word
+// boundaries where possible.
+func ChunkText(text string, size, overlap int) []string {
+	runes := []rune(strings.TrimSpace(text))
+	if size <= 0 || len(runes) <= size {
+		return []string{string(runes)}
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+
+	var chunks []string
+	for start := 0; start < len(runes); {
+		end := start + size
+		if end >= len(runes) {
+			chunks = append(chunks, strings.TrimSpace(string(runes[start:])))
+			break
+		}
+		end = start + boundary(runes[start:end])
+
+		chunks = append(chunks, strings.TrimSpace(string(runes[start:end])))
+
+		// Start the next chunk at a word boundary inside the overlap window
+		next := end - overlap
+		if next <= start {
+			next = end
+		}
+		for next < end && !unicode.IsSpace(runes[next-1]) {
+			next++
+		}
+		start = next
+	}
+	return chunks
+}
+
+// boundary returns the length of the window prefix ending at the last paragraph,
+// sentence or word break in its second half, or the whole window if there is none
+func boundary(window []rune) int {
+	text := string(window)
+	half := len(text) / 2
+	for _, sep := range []string{"\n\n", ". ", "! ", "? ", "\n", " "} {
+		if i := strings.LastIndex(text, sep); i >= half {
+			return len([]rune(text[:i+len(sep)]))
+		}
+	}
+	return len(window)
+}
+
+// convertProperties converts string property values to the types declared in the schema
+func convertProperties(values map[string]string, schema []PropertySchema) map[string]interface{} {
+	if len(values) == 0 {
+		return nil
+	}
+	types := map[string]string{}
+	for _, p := range schema {
+		types[p.Name] = p.Type
+	}
+
+	properties := make(map[string]interface{}, len(values))
+	for name, value := range values {
+		value = strings.TrimSpace(value)
+		switch types[name] {
+		case "number":
+			if n, err := strconv.ParseFloat(value, 64); err == nil {
+				properties[name] = n
+				continue
+			}
+		case "boolean":
+			if b, err := strconv.ParseBool(value); err == nil {
+				properties[name] = b
+				continue
+			}
+		}
+		properties[name] = value
+	}
+	return properties
+}
+
+func propertySchemasFor(schema *GraphSchema, schemaGuided bool, entityType string) []PropertySchema {
+	if schema == nil || !schemaGuided {
+		return nil
+	}
+	for _, t := range schema.EntityTypes {
+		if t.Name == entityType {
+			return t.Properties
+		}
+	}
+	return nil
+}
+
+// mergeDescriptions appends a description unless it is already contained
+func mergeDescriptions(existing, addition string) string {
+	addition = strings.TrimSpace(addition)
+	switch {
+	case addition == "" || strings.Contains(strings.ToLower(existing), strings.ToLower(addition)):
+		return existing
+	case existing == "" || strings.Contains(strings.ToLower(addition), strings.ToLower(existing)):
+		return addition
+	}
+	return existing + " " + addition
+}
+
+// mergeProperties returns a new map with addition's values added where existing has none
+func mergeProperties(existing, addition map[string]interface{}) map[string]interface{} {
+	if len(addition) == 0 {
+		return existing
+	}
+	merged := make(map[string]interface{}, len(existing)+len(addition))
+	for k, v := range addition {
+		merged[k] = v
+	}
+	for k, v := range existing {
+		merged[k] = v
+	}
+	return merged
+}
+
+// clampConfidence treats a missing confidence as certain and clamps it to 0-1
+func clampConfidence(value float64) float64 {
+	if value <= 0 || value > 1 {
+		return 1
+	}
+	return value
+}
+
+// combineConfidence combines independent mentions: the item is wrong only if every mention is
+func combineConfidence(confidences []float64) float32 {
+	wrong := 1.0
+	for _, c := range confidences {
+		wrong *= 1 - c
+	}
+	return float32(1 - wrong)
+}
+
+func overallConfidence(result *ExtractionResult) float32 {
+	var sum float64
+	count := 0
+	for _, entity := range result.Entities {
+		if c, ok := entity.Properties[ConfidenceProperty].(float64); ok {
+			sum += c
+			count++
+		}
+	}
+	for _, rel := range result.Relationships {
+		if c, ok := rel.Properties[ConfidenceProperty].(float64); ok {
+			sum += c
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return float32(sum / float64(count))
+}
+
+func roundConfidence(value float32) float64 {
+	return math.Round(float64(value)*1000) / 1000
+}
+
+func maxFloat(values []float64) float64 {
+	var max float64
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+	return max
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// stripCodeFence removes a surrounding markdown code fence from an LLM response
+func stripCodeFence(response string) string {
+	response = strings.TrimSpace(response)
+	if !strings.HasPrefix(response, "```") {
+		return response
+	}
+	response = strings.TrimPrefix(response, "```")
+	if newline := strings.Index(response, "\n"); newline >= 0 {
+		response = response[newline+1:]
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(response), "```"))
+}
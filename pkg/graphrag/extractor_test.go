@@ -0,0 +1,238 @@
+package graphrag
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tagus/agent-sdk-go/pkg/embedding"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// scriptedLLM answers each prompt with the response whose key appears in the prompt
+type scriptedLLM struct {
+	mu        sync.Mutex
+	responses map[string]string
+	prompts   []string
+	formats   []*interfaces.ResponseFormat
+}
+
+func (m *scriptedLLM) Generate(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (string, error) {
+	opts := &interfaces.GenerateOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prompts = append(m.prompts, prompt)
+	m.formats = append(m.formats, opts.ResponseFormat)
+	for key, response := range m.responses {
+		if strings.Contains(prompt, key) {
+			return response, nil
+		}
+	}
+	return "", errors.New("no scripted response")
+}
+
+func (m *scriptedLLM) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (string, error) {
+	return m.Generate(ctx, prompt, options...)
+}
+
+func (m *scriptedLLM) GenerateDetailed(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (*interfaces.LLMResponse, error) {
+	content, err := m.Generate(ctx, prompt, options...)
+	return &interfaces.LLMResponse{Content: content}, err
+}
+
+func (m *scriptedLLM) GenerateWithToolsDetailed(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (*interfaces.LLMResponse, error) {
+	return m.GenerateDetailed(ctx, prompt, options...)
+}
+
+func (m *scriptedLLM) Name() string { return "scripted" }
+
+func (m *scriptedLLM) SupportsStreaming() bool { return false }
+
+// aliasEmbedder maps texts containing a keyword to the same vector
+type aliasEmbedder struct {
+	groups []string
+}
+
+func (e *aliasEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	vector := make([]float32, len(e.groups)+1)
+	vector[len(e.groups)] = 1
+	for i, keyword := range e.groups {
+		if strings.Contains(strings.ToLower(text), keyword) {
+			vector[len(e.groups)] = 0
+			vector[i] = 1
+		}
+	}
+	return vector, nil
+}
+
+func (e *aliasEmbedder) EmbedWithConfig(ctx context.Context, text string, config embedding.EmbeddingConfig) ([]float32, error) {
+	return e.Embed(ctx, text)
+}
+
+func (e *aliasEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i], _ = e.Embed(ctx, text)
+	}
+	return vectors, nil
+}
+
+func (e *aliasEmbedder) EmbedBatchWithConfig(ctx context.Context, texts []string, config embedding.EmbeddingConfig) ([][]float32, error) {
+	return e.EmbedBatch(ctx, texts)
+}
+
+func (e *aliasEmbedder) CalculateSimilarity(vec1, vec2 []float32, metric string) (float32, error) {
+	return embedding.CalculateSimilarity(vec1, vec2, metric)
+}
+
+func TestChunkText(t *testing.T) {
+	assert.Equal(t, []string{"short text"}, ChunkText("  short text ", 100, 10))
+
+	text := strings.Repeat("Alpha beta gamma. ", 20)
+	chunks := ChunkText(text, 100, 20)
+	require.Greater(t, len(chunks), 3)
+	for i, chunk := range chunks {
+		assert.LessOrEqual(t, len(chunk), 100)
+		if i < len(chunks)-1 {
+			assert.True(t, strings.HasSuffix(chunk, "."), "chunk %d should end at a sentence: %q", i, chunk)
+		}
+		assert.False(t, strings.HasPrefix(chunk, "eta"), "chunk %d should start at a word", i)
+	}
+	// Consecutive chunks overlap
+	assert.True(t, strings.HasSuffix(chunks[0], chunks[1][:10]) || strings.Contains(chunks[0], chunks[1][:10]))
+}
+
+func TestNormalizeEntityName(t *testing.T) {
+	assert.Equal(t, "acme corp", NormalizeEntityName("The ACME Corp."))
+	assert.Equal(t, "o brien", NormalizeEntityName("O'Brien"))
+	assert.Equal(t, "the", NormalizeEntityName("The"))
+}
+
+func TestExtractor_MergesAcrossChunks(t *testing.T) {
+	ctx := context.Background()
+	llm := &scriptedLLM{responses: map[string]string{
+		"part 1 of 2": `{"entities": [
+				{"name": "Alice Smith", "type": "Person", "description": "Engineer", "confidence": 0.6},
+				{"name": "Acme", "type": "Organization", "confidence": 0.9}
+			], "relationships": [
+				{"source": "Alice Smith", "target": "Acme", "type": "WORKS_AT", "strength": 0.5, "confidence": 0.5}
+			]}`,
+		"part 2 of 2": "```json\n" + `{"entities": [
+				{"name": "alice smith", "type": "Person", "description": "Leads the platform team", "confidence": 0.5},
+				{"name": "The Acme Corporation", "type": "Organization", "confidence": 0.4}
+			], "relationships": [
+				{"source": "alice smith", "target": "The Acme Corporation", "type": "WORKS_AT", "strength": 0.9, "confidence": 0.5},
+				{"source": "alice smith", "target": "Nobody", "type": "KNOWS"}
+			]}` + "\n```",
+	}}
+
+	text := "Alice Smith is an engineer at Acme. " + strings.Repeat("Filler sentence here. ", 5) + "Alice leads the platform team at the Acme Corporation."
+	// The second chunk starts at the last sentence
+	extractor := NewExtractor(llm,
+		WithChunking(len(text)-20, 0),
+		WithExtractorConcurrency(2),
+		WithExtractorEmbedder(&aliasEmbedder{groups: []string{"acme"}}))
+
+	result, err := extractor.Extract(ctx, text)
+	require.NoError(t, err)
+	require.Len(t, llm.prompts, 2)
+	require.NotNil(t, llm.formats[0])
+	assert.Equal(t, "extractionResponse", llm.formats[0].Name)
+
+	require.Len(t, result.Entities, 2)
+	alice, acme := result.Entities[0], result.Entities[1]
+	assert.Equal(t, "Alice Smith", alice.Name)
+	assert.Equal(t, "Engineer Leads the platform team", alice.Description)
+	assert.InDelta(t, 0.8, alice.Properties[ConfidenceProperty], 0.001)
+	assert.NotEmpty(t, alice.ID)
+	assert.NotEmpty(t, alice.Embedding)
+
+	// "Acme" and "The Acme Corporation" are merged by embedding similarity
+	assert.Equal(t, "Acme", acme.Name)
+	assert.InDelta(t, 0.94, acme.Properties[ConfidenceProperty], 0.001)
+
+	require.Len(t, result.Relationships, 1)
+	rel := result.Relationships[0]
+	assert.Equal(t, alice.ID, rel.SourceID)
+	assert.Equal(t, acme.ID, rel.TargetID)
+	assert.Equal(t, float32(0.9), rel.Strength)
+	assert.InDelta(t, 0.75, rel.Properties[ConfidenceProperty], 0.001)
+	assert.InDelta(t, (0.8+0.94+0.75)/3, result.Confidence, 0.001)
+}
+
+func TestExtractor_SchemaGuided(t *testing.T) {
+	ctx := context.Background()
+	llm := &scriptedLLM{responses: map[string]string{
+		"Text:": `{"entities": [
+				{"name": "Alice", "type": "Person", "properties": {"age": "42", "active": "true", "team": "core"}, "confidence": 0.9},
+				{"name": "Paris", "type": "City", "confidence": 0.9},
+				{"name": "Bob", "type": "Person", "confidence": 0.3}
+			], "relationships": [
+				{"source": "Alice", "target": "Bob", "type": "KNOWS"},
+				{"source": "Alice", "target": "Paris", "type": "LIVES_IN"}
+			]}`,
+	}}
+	schema := &GraphSchema{
+		EntityTypes: []EntityTypeSchema{
+			{Name: "Person", Description: "A human", Properties: []PropertySchema{
+				{Name: "age", Type: "number"},
+				{Name: "active", Type: "boolean"},
+			}},
+		},
+		RelationshipTypes: []RelationshipTypeSchema{{Name: "KNOWS", SourceTypes: []string{"Person"}, TargetTypes: []string{"Person"}}},
+	}
+
+	result, err := NewExtractor(llm, WithExtractorSchema(schema)).Extract(ctx, "Alice knows Bob and lives in Paris.",
+		WithSchemaGuided(true), WithMinConfidence(0.5))
+	require.NoError(t, err)
+
+	assert.Contains(t, llm.prompts[0], "Entity type Person: A human")
+	assert.Contains(t, llm.prompts[0], "property age (number)")
+	assert.Contains(t, llm.prompts[0], "Relationship type KNOWS (Person -> Person)")
+
+	require.Len(t, result.Entities, 1)
+	assert.Equal(t, 42.0, result.Entities[0].Properties["age"])
+	assert.Equal(t, true, result.Entities[0].Properties["active"])
+	assert.Equal(t, "core", result.Entities[0].Properties["team"])
+	// Bob is below the confidence threshold, so the relationship to him is dropped
+	assert.Empty(t, result.Relationships)
+}
+
+func TestExtractor_MaxEntities(t *testing.T) {
+	llm := &scriptedLLM{responses: map[string]string{
+		"Text:": `{"entities": [
+				{"name": "A", "type": "Thing", "confidence": 0.5},
+				{"name": "B", "type": "Thing", "confidence": 0.9},
+				{"name": "C", "type": "Thing", "confidence": 0.7}
+			], "relationships": []}`,
+	}}
+
+	result, err := NewExtractor(llm).Extract(context.Background(), "A, B and C.", WithMaxEntities(2))
+	require.NoError(t, err)
+	require.Len(t, result.Entities, 2)
+	assert.Equal(t, "B", result.Entities[0].Name)
+	assert.Equal(t, "C", result.Entities[1].Name)
+	assert.Contains(t, llm.prompts[0], "at most 2 entities")
+}
+
+func TestExtractor_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := NewExtractor(nil).Extract(ctx, "text")
+	assert.ErrorIs(t, err, ErrNoLLM)
+
+	_, err = NewExtractor(&scriptedLLM{}).Extract(ctx, "   ")
+	assert.ErrorIs(t, err, ErrExtractionFailed)
+
+	_, err = NewExtractor(&scriptedLLM{responses: map[string]string{"Text:": "not json"}}).Extract(ctx, "text")
+	assert.ErrorIs(t, err, ErrExtractionFailed)
+}
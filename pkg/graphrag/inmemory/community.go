@@ -0,0 +1,394 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tagus/agent-sdk-go/pkg/graphrag"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// maxMoveRounds bounds the Louvain local moving phase
+const maxMoveRounds = 20
+
+// community is a group of densely connected entities
+type community struct {
+	id      string
+	members []string
+}
+
+// Community describes a detected community of entities
+type Community struct {
+	// ID identifies the community within its level, e.g. "1-0"
+	ID string `json:"id"`
+
+	// Level is the hierarchy level; level 0 is the finest partition
+	Level int `json:"level"`
+
+	// EntityIDs are the member entities, sorted
+	EntityIDs []string `json:"entity_ids"`
+}
+
+// Communities returns the communities detected at the given level for the current tenant.
+//
+// Communities are detected with the Louvain method, weighting edges by relationship
+// strength. Level 0 is the partition of the entity graph; each higher level partitions
+// the graph of the previous level's communities, so communities only grow as the level
+// increases. Levels past the point where modularity stops improving repeat the last partition.
+func (s *Store) Communities(ctx context.Context, level int, options ...interfaces.GraphSearchOption) ([]Community, error) {
+	if level < 0 {
+		return nil, fmt.Errorf("invalid community level: %d", level)
+	}
+
+	opts := applySearchOptions(options)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	g := s.graphFor(s.resolveTenant(ctx, opts.Tenant), false)
+	if g == nil {
+		return []Community{}, nil
+	}
+
+	detected := g.detectCommunities(level)
+	result := make([]Community, 0, len(detected))
+	for _, c := range detected {
+		result = append(result, Community{ID: c.id, Level: level, EntityIDs: c.members})
+	}
+	return result, nil
+}
+
+// GlobalSearch answers broad queries by ranking whole communities instead of single entities.
+// Each community is scored against the query using the centroid of its members' embeddings
+// and BM25 over its members' text; the result for a community carries its most connected
+// member as Entity, the remaining members as Context and the community ID.
+func (s *Store) GlobalSearch(ctx context.Context, query string, communityLevel int, options ...interfaces.GraphSearchOption) ([]interfaces.GraphSearchResult, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, graphrag.ErrEmptyQuery
+	}
+	if communityLevel < 0 {
+		return nil, fmt.Errorf("invalid community level: %d", communityLevel)
+	}
+
+	opts := applySearchOptions(options)
+	mode := opts.SearchMode
+	if mode == "" {
+		mode = interfaces.SearchModeHybrid
+	}
+	if mode == interfaces.SearchModeVector && s.embedder == nil {
+		return nil, graphrag.ErrNoEmbedder
+	}
+
+	var queryVector []float32
+	if s.embedder != nil && mode != interfaces.SearchModeKeyword {
+		var err error
+		queryVector, err = s.embedder.Embed(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed query: %w", err)
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	g := s.graphFor(s.resolveTenant(ctx, opts.Tenant), false)
+	if g == nil {
+		return []interfaces.GraphSearchResult{}, nil
+	}
+
+	communities := g.detectCommunities(communityLevel)
+
+	var keywordScores map[string]float32
+	if mode != interfaces.SearchModeVector {
+		texts := make(map[string]string, len(communities))
+		for _, c := range communities {
+			texts[c.id] = g.communityText(c)
+		}
+		keywordScores = bm25(query, texts)
+	}
+
+	entityTypes := toSet(opts.EntityTypes)
+	relTypes := toSet(opts.RelationshipTypes)
+	var results []interfaces.GraphSearchResult
+	for _, c := range communities {
+		var score float32
+		switch {
+		case queryVector == nil:
+			score = keywordScores[c.id]
+		case mode == interfaces.SearchModeVector:
+			score = vectorScore(queryVector, g.centroid(c))
+		default:
+			score = s.hybridAlpha*vectorScore(queryVector, g.centroid(c)) + (1-s.hybridAlpha)*keywordScores[c.id]
+		}
+		if score <= 0 || score < opts.MinScore {
+			continue
+		}
+
+		members := g.rankMembers(c)
+		var matching []interfaces.Entity
+		for _, id := range members {
+			if entity := g.entities[id]; matchesType(entityTypes, entity.Type) {
+				matching = append(matching, entity)
+			}
+		}
+		if len(matching) == 0 {
+			continue
+		}
+
+		result := interfaces.GraphSearchResult{
+			Entity:      matching[0],
+			Score:       score,
+			Context:     matching[1:],
+			CommunityID: c.id,
+		}
+		if opts.IncludeRelationships {
+			result.Path = g.internalRelationships(c, relTypes)
+		}
+		results = append(results, result)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if results == nil {
+		results = []interfaces.GraphSearchResult{}
+	}
+
+	return results, nil
+}
+
+// detectCommunities returns the communities at a level, computing and caching every level up to it.
+// Callers must hold at least the read lock; the cache is guarded separately.
+func (g *graph) detectCommunities(level int) []community {
+	g.communityMu.Lock()
+	defer g.communityMu.Unlock()
+
+	if g.communities == nil {
+		g.communities = map[int][]community{}
+	}
+	if cached, ok := g.communities[level]; ok {
+		return cached
+	}
+
+	// Level 0 nodes are entities connected by relationship strength
+	nodes := sortedKeys(g.entities)
+	weights := map[string]map[string]float64{}
+	for _, rel := range g.relationships {
+		addWeight(weights, rel.SourceID, rel.TargetID, float64(rel.Strength))
+	}
+	groups := groupByLabel(nodes, moveNodes(nodes, weights))
+
+	for current := 0; ; current++ {
+		if _, ok := g.communities[current]; !ok {
+			g.communities[current] = newCommunities(current, groups)
+		}
+		if current == level {
+			return g.communities[level]
+		}
+
+		// Collapse each community into a node; internal edges become self-loops
+		memberOf := map[string]string{}
+		communityNodes := make([]string, len(groups))
+		for i, members := range groups {
+			communityNodes[i] = members[0]
+			for _, id := range members {
+				memberOf[id] = members[0]
+			}
+		}
+		communityWeights := map[string]map[string]float64{}
+		for source, targets := range weights {
+			for target, weight := range targets {
+				// weights is symmetric, so halve to count each edge once
+				addWeight(communityWeights, memberOf[source], memberOf[target], weight/2)
+			}
+		}
+
+		labels := moveNodes(communityNodes, communityWeights)
+		merged := map[string][]string{}
+		for i, members := range groups {
+			label := labels[communityNodes[i]]
+			merged[label] = append(merged[label], members...)
+		}
+		groups = groups[:0:0]
+		for _, label := range sortedKeys(merged) {
+			members := merged[label]
+			sort.Strings(members)
+			groups = append(groups, members)
+		}
+		sort.Slice(groups, func(i, j int) bool { return groups[i][0] < groups[j][0] })
+	}
+}
+
+// moveNodes runs the local moving phase of the Louvain method: each node joins the
+// neighboring community with the largest modularity gain until no move improves modularity.
+// Nodes are visited in sorted order and ties keep the current community, then prefer the
+// smallest label, so results are deterministic. Self-loops count toward a node's degree.
+func moveNodes(nodes []string, weights map[string]map[string]float64) map[string]string {
+	labels := make(map[string]string, len(nodes))
+	degree := make(map[string]float64, len(nodes))
+	total := make(map[string]float64, len(nodes))
+	var twiceM float64
+	for _, node := range nodes {
+		labels[node] = node
+		for _, weight := range weights[node] {
+			degree[node] += weight
+		}
+		total[node] = degree[node]
+		twiceM += degree[node]
+	}
+	if twiceM == 0 {
+		return labels
+	}
+
+	for round := 0; round < maxMoveRounds; round++ {
+		changed := false
+		for _, node := range nodes {
+			k := degree[node]
+			if k == 0 {
+				continue
+			}
+
+			current := labels[node]
+			total[current] -= k
+			links := map[string]float64{}
+			for neighbor, weight := range weights[node] {
+				if neighbor != node {
+					links[labels[neighbor]] += weight
+				}
+			}
+
+			best := current
+			bestGain := links[current] - total[current]*k/twiceM
+			for _, label := range sortedKeys(links) {
+				if gain := links[label] - total[label]*k/twiceM; gain > bestGain+1e-12 {
+					best, bestGain = label, gain
+				}
+			}
+
+			total[best] += k
+			if best != current {
+				labels[node] = best
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	return labels
+}
+
+// groupByLabel groups nodes by label; groups and their members are sorted
+func groupByLabel(nodes []string, labels map[string]string) [][]string {
+	byLabel := map[string][]string{}
+	for _, node := range nodes {
+		byLabel[labels[node]] = append(byLabel[labels[node]], node)
+	}
+	groups := make([][]string, 0, len(byLabel))
+	for _, members := range byLabel {
+		sort.Strings(members)
+		groups = append(groups, members)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i][0] < groups[j][0] })
+	return groups
+}
+
+func newCommunities(level int, groups [][]string) []community {
+	communities := make([]community, len(groups))
+	for i, members := range groups {
+		communities[i] = community{
+			id:      fmt.Sprintf("%d-%d", level, i),
+			members: members,
+		}
+	}
+	return communities
+}
+
+func addWeight(weights map[string]map[string]float64, a, b string, weight float64) {
+	if weights[a] == nil {
+		weights[a] = map[string]float64{}
+	}
+	if weights[b] == nil {
+		weights[b] = map[string]float64{}
+	}
+	weights[a][b] += weight
+	weights[b][a] += weight
+}
+
+// rankMembers orders community members by weighted degree inside the community, then by ID
+func (g *graph) rankMembers(c community) []string {
+	inCommunity := toSet(c.members)
+	degree := make(map[string]float64, len(c.members))
+	for _, rel := range g.relationships {
+		_, sourceIn := inCommunity[rel.SourceID]
+		_, targetIn := inCommunity[rel.TargetID]
+		if sourceIn && targetIn {
+			degree[rel.SourceID] += float64(rel.Strength)
+			degree[rel.TargetID] += float64(rel.Strength)
+		}
+	}
+
+	members := append([]string(nil), c.members...)
+	sort.SliceStable(members, func(i, j int) bool {
+		return degree[members[i]] > degree[members[j]]
+	})
+	return members
+}
+
+// internalRelationships returns relationships with both endpoints in the community, sorted by ID
+func (g *graph) internalRelationships(c community, relTypes map[string]struct{}) []interfaces.Relationship {
+	inCommunity := toSet(c.members)
+	var rels []interfaces.Relationship
+	for _, id := range sortedKeys(g.relationships) {
+		rel := g.relationships[id]
+		_, sourceIn := inCommunity[rel.SourceID]
+		_, targetIn := inCommunity[rel.TargetID]
+		if sourceIn && targetIn && matchesType(relTypes, rel.Type) {
+			rels = append(rels, rel)
+		}
+	}
+	return rels
+}
+
+// communityText concatenates member and relationship descriptions for keyword scoring
+func (g *graph) communityText(c community) string {
+	var parts []string
+	for _, id := range c.members {
+		parts = append(parts, graphrag.EntityEmbeddingText(g.entities[id]))
+	}
+	for _, rel := range g.internalRelationships(c, nil) {
+		if rel.Description != "" {
+			parts = append(parts, rel.Description)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// centroid averages the embeddings of community members that have one
+func (g *graph) centroid(c community) []float32 {
+	var sum []float32
+	count := 0
+	for _, id := range c.members {
+		embedding := g.entities[id].Embedding
+		if len(embedding) == 0 {
+			continue
+		}
+		if sum == nil {
+			sum = make([]float32, len(embedding))
+		}
+		if len(embedding) != len(sum) {
+			continue
+		}
+		for i, v := range embedding {
+			sum[i] += v
+		}
+		count++
+	}
+	for i := range sum {
+		sum[i] /= float32(count)
+	}
+	return sum
+}
@@ -0,0 +1,198 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tagus/agent-sdk-go/pkg/graphrag"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// StoreEntities stores entities in the graph, replacing entities with the same ID.
+// Embeddings are generated for entities without one when an embedder is configured,
+// or for every entity when WithGenerateEmbeddings(true) is passed.
+func (s *Store) StoreEntities(ctx context.Context, entities []interfaces.Entity, options ...interfaces.GraphStoreOption) error {
+	if len(entities) == 0 {
+		return nil
+	}
+
+	opts := applyStoreOptions(options)
+	// Work on a copy so generated embeddings and defaults don't leak into the caller's slice
+	entities = append([]interfaces.Entity(nil), entities...)
+	for _, entity := range entities {
+		if err := validateEntity(entity); err != nil {
+			return err
+		}
+	}
+
+	if err := s.embedEntities(ctx, entities, opts.GenerateEmbeddings); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.schema != nil {
+		for i := range entities {
+			if err := validateEntityAgainstSchema(s.schema, &entities[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	tenant := s.resolveTenant(ctx, opts.Tenant)
+	g := s.graphFor(tenant, true)
+	now := time.Now()
+	for _, entity := range entities {
+		entity.OrgID = tenant
+		if existing, ok := g.entities[entity.ID]; ok && entity.CreatedAt.IsZero() {
+			entity.CreatedAt = existing.CreatedAt
+		}
+		if entity.CreatedAt.IsZero() {
+			entity.CreatedAt = now
+		}
+		entity.UpdatedAt = now
+		g.entities[entity.ID] = entity
+	}
+	g.communities = nil
+
+	s.logger.Debug(ctx, "Stored entities", map[string]interface{}{
+		"count":  len(entities),
+		"tenant": tenant,
+	})
+
+	return s.persistLocked()
+}
+
+// GetEntity retrieves an entity by ID
+func (s *Store) GetEntity(ctx context.Context, id string, options ...interfaces.GraphStoreOption) (*interfaces.Entity, error) {
+	if id == "" {
+		return nil, graphrag.ErrInvalidEntityID
+	}
+
+	opts := applyStoreOptions(options)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	g := s.graphFor(s.resolveTenant(ctx, opts.Tenant), false)
+	if g == nil {
+		return nil, fmt.Errorf("%w: %s", graphrag.ErrEntityNotFound, id)
+	}
+	entity, ok := g.entities[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", graphrag.ErrEntityNotFound, id)
+	}
+	return &entity, nil
+}
+
+// UpdateEntity replaces an existing entity. The embedding is regenerated when
+// the name, type or description changed and no new embedding was supplied.
+func (s *Store) UpdateEntity(ctx context.Context, entity interfaces.Entity, options ...interfaces.GraphStoreOption) error {
+	if err := validateEntity(entity); err != nil {
+		return err
+	}
+
+	opts := applyStoreOptions(options)
+
+	s.mu.RLock()
+	g := s.graphFor(s.resolveTenant(ctx, opts.Tenant), false)
+	var existing interfaces.Entity
+	var ok bool
+	if g != nil {
+		existing, ok = g.entities[entity.ID]
+	}
+	s.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", graphrag.ErrEntityNotFound, entity.ID)
+	}
+
+	if len(entity.Embedding) == 0 {
+		if graphrag.EntityEmbeddingText(existing) == graphrag.EntityEmbeddingText(entity) {
+			entity.Embedding = existing.Embedding
+		}
+	}
+	if entity.CreatedAt.IsZero() {
+		entity.CreatedAt = existing.CreatedAt
+	}
+
+	return s.StoreEntities(ctx, []interfaces.Entity{entity}, options...)
+}
+
+// DeleteEntity removes an entity and every relationship attached to it
+func (s *Store) DeleteEntity(ctx context.Context, id string, options ...interfaces.GraphStoreOption) error {
+	if id == "" {
+		return graphrag.ErrInvalidEntityID
+	}
+
+	opts := applyStoreOptions(options)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g := s.graphFor(s.resolveTenant(ctx, opts.Tenant), false)
+	if g == nil {
+		return fmt.Errorf("%w: %s", graphrag.ErrEntityNotFound, id)
+	}
+	if _, ok := g.entities[id]; !ok {
+		return fmt.Errorf("%w: %s", graphrag.ErrEntityNotFound, id)
+	}
+
+	for _, rel := range g.relationshipsOf(id, interfaces.DirectionBoth) {
+		g.removeRelationship(rel.ID)
+	}
+	delete(g.entities, id)
+	delete(g.outgoing, id)
+	delete(g.incoming, id)
+	g.communities = nil
+
+	return s.persistLocked()
+}
+
+// embedEntities fills in embeddings for entities that need one
+func (s *Store) embedEntities(ctx context.Context, entities []interfaces.Entity, force bool) error {
+	if s.embedder == nil {
+		if force {
+			return graphrag.ErrNoEmbedder
+		}
+		return nil
+	}
+
+	var texts []string
+	var indexes []int
+	for i, entity := range entities {
+		if force || len(entity.Embedding) == 0 {
+			texts = append(texts, graphrag.EntityEmbeddingText(entity))
+			indexes = append(indexes, i)
+		}
+	}
+	if len(texts) == 0 {
+		return nil
+	}
+
+	embeddings, err := s.embedder.EmbedBatch(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("failed to generate entity embeddings: %w", err)
+	}
+	if len(embeddings) != len(texts) {
+		return fmt.Errorf("failed to generate entity embeddings: expected %d embeddings, got %d", len(texts), len(embeddings))
+	}
+	for i, index := range indexes {
+		entities[index].Embedding = embeddings[i]
+	}
+	return nil
+}
+
+func validateEntity(entity interfaces.Entity) error {
+	if entity.ID == "" {
+		return graphrag.ErrInvalidEntityID
+	}
+	if entity.Name == "" {
+		return fmt.Errorf("%w: %s", graphrag.ErrMissingEntityName, entity.ID)
+	}
+	if entity.Type == "" {
+		return fmt.Errorf("%w: %s", graphrag.ErrMissingEntityType, entity.ID)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package inmemory
+
+import (
+	"context"
+
+	"github.com/tagus/agent-sdk-go/pkg/graphrag"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// ExtractFromText extracts entities and relationships from text with the shared
+// graphrag.Extractor, guided by the applied schema when WithSchemaGuided is set.
+// The result is not stored; pass it to StoreEntities and StoreRelationships.
+func (s *Store) ExtractFromText(ctx context.Context, text string, llm interfaces.LLM, options ...interfaces.ExtractionOption) (*interfaces.ExtractionResult, error) {
+	s.mu.RLock()
+	schema := s.schema
+	s.mu.RUnlock()
+
+	extractor := graphrag.NewExtractor(llm,
+		graphrag.WithExtractorEmbedder(s.embedder),
+		graphrag.WithExtractorSchema(schema),
+		graphrag.WithExtractorLogger(s.logger),
+	)
+	return extractor.Extract(ctx, text, options...)
+}
@@ -0,0 +1,144 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tagus/agent-sdk-go/pkg/graphrag"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// StoreRelationships stores relationships, replacing relationships with the same ID.
+// Both endpoints must already exist in the tenant's graph. A zero strength defaults to 1.0.
+func (s *Store) StoreRelationships(ctx context.Context, relationships []interfaces.Relationship, options ...interfaces.GraphStoreOption) error {
+	if len(relationships) == 0 {
+		return nil
+	}
+
+	opts := applyStoreOptions(options)
+	for _, rel := range relationships {
+		if err := validateRelationship(rel); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tenant := s.resolveTenant(ctx, opts.Tenant)
+	g := s.graphFor(tenant, true)
+
+	// Validate the whole batch before applying any of it
+	relationships = append([]interfaces.Relationship(nil), relationships...)
+	for i := range relationships {
+		rel := &relationships[i]
+		source, ok := g.entities[rel.SourceID]
+		if !ok {
+			return fmt.Errorf("%w: %s", graphrag.ErrSourceEntityNotFound, rel.SourceID)
+		}
+		target, ok := g.entities[rel.TargetID]
+		if !ok {
+			return fmt.Errorf("%w: %s", graphrag.ErrTargetEntityNotFound, rel.TargetID)
+		}
+		if s.schema != nil {
+			if err := validateRelationshipAgainstSchema(s.schema, rel, source, target); err != nil {
+				return err
+			}
+		}
+	}
+
+	now := time.Now()
+	for _, rel := range relationships {
+		if rel.Strength == 0 {
+			rel.Strength = 1.0
+		}
+		if rel.CreatedAt.IsZero() {
+			if existing, ok := g.relationships[rel.ID]; ok {
+				rel.CreatedAt = existing.CreatedAt
+			} else {
+				rel.CreatedAt = now
+			}
+		}
+		rel.OrgID = tenant
+		g.addRelationship(rel)
+	}
+
+	s.logger.Debug(ctx, "Stored relationships", map[string]interface{}{
+		"count":  len(relationships),
+		"tenant": tenant,
+	})
+
+	return s.persistLocked()
+}
+
+// GetRelationships returns the relationships attached to an entity in the given direction
+func (s *Store) GetRelationships(ctx context.Context, entityID string, direction interfaces.RelationshipDirection, options ...interfaces.GraphSearchOption) ([]interfaces.Relationship, error) {
+	if entityID == "" {
+		return nil, graphrag.ErrInvalidEntityID
+	}
+
+	opts := applySearchOptions(options)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	g := s.graphFor(s.resolveTenant(ctx, opts.Tenant), false)
+	if g == nil {
+		return nil, fmt.Errorf("%w: %s", graphrag.ErrEntityNotFound, entityID)
+	}
+	if _, ok := g.entities[entityID]; !ok {
+		return nil, fmt.Errorf("%w: %s", graphrag.ErrEntityNotFound, entityID)
+	}
+
+	types := toSet(opts.RelationshipTypes)
+	var rels []interfaces.Relationship
+	for _, rel := range g.relationshipsOf(entityID, direction) {
+		if matchesType(types, rel.Type) {
+			rels = append(rels, rel)
+		}
+	}
+	return rels, nil
+}
+
+// DeleteRelationship removes a relationship by ID
+func (s *Store) DeleteRelationship(ctx context.Context, id string, options ...interfaces.GraphStoreOption) error {
+	if id == "" {
+		return graphrag.ErrInvalidRelationshipID
+	}
+
+	opts := applyStoreOptions(options)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g := s.graphFor(s.resolveTenant(ctx, opts.Tenant), false)
+	if g == nil {
+		return fmt.Errorf("%w: %s", graphrag.ErrRelationshipNotFound, id)
+	}
+	if _, ok := g.relationships[id]; !ok {
+		return fmt.Errorf("%w: %s", graphrag.ErrRelationshipNotFound, id)
+	}
+	g.removeRelationship(id)
+
+	return s.persistLocked()
+}
+
+func validateRelationship(rel interfaces.Relationship) error {
+	if rel.ID == "" {
+		return graphrag.ErrInvalidRelationshipID
+	}
+	if rel.SourceID == "" {
+		return fmt.Errorf("%w: %s", graphrag.ErrMissingSourceID, rel.ID)
+	}
+	if rel.TargetID == "" {
+		return fmt.Errorf("%w: %s", graphrag.ErrMissingTargetID, rel.ID)
+	}
+	if rel.Type == "" {
+		return fmt.Errorf("%w: %s", graphrag.ErrMissingRelationshipType, rel.ID)
+	}
+	if rel.Strength < 0 || rel.Strength > 1 {
+		return fmt.Errorf("%w: %s has %v", graphrag.ErrInvalidStrength, rel.ID, rel.Strength)
+	}
+	return nil
+}
@@ -0,0 +1,339 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/tagus/agent-sdk-go/pkg/graphrag"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// ApplySchema validates the schema and enforces it on subsequent writes.
+// The schema is rejected if existing data in the current tenant does not conform to it.
+func (s *Store) ApplySchema(ctx context.Context, schema interfaces.GraphSchema) error {
+	if err := validateSchema(schema); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if g := s.graphFor(s.resolveTenant(ctx, ""), false); g != nil {
+		for _, id := range sortedKeys(g.entities) {
+			entity := g.entities[id]
+			if err := validateEntityAgainstSchema(&schema, &entity); err != nil {
+				return err
+			}
+		}
+		for _, id := range sortedKeys(g.relationships) {
+			rel := g.relationships[id]
+			if err := validateRelationshipAgainstSchema(&schema, &rel, g.entities[rel.SourceID], g.entities[rel.TargetID]); err != nil {
+				return err
+			}
+		}
+	}
+
+	s.schema = &schema
+
+	s.logger.Info(ctx, "Applied graph schema", map[string]interface{}{
+		"entity_types":       len(schema.EntityTypes),
+		"relationship_types": len(schema.RelationshipTypes),
+	})
+
+	return s.persistLocked()
+}
+
+// DiscoverSchema infers a schema from the entities and relationships stored for the current tenant.
+// A property is marked required when every entity of the type has it.
+func (s *Store) DiscoverSchema(ctx context.Context) (*interfaces.GraphSchema, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	schema := &interfaces.GraphSchema{
+		EntityTypes:       []interfaces.EntityTypeSchema{},
+		RelationshipTypes: []interfaces.RelationshipTypeSchema{},
+	}
+
+	g := s.graphFor(s.resolveTenant(ctx, ""), false)
+	if g == nil {
+		return schema, nil
+	}
+
+	entitiesByType := map[string][]interfaces.Entity{}
+	for _, id := range sortedKeys(g.entities) {
+		entity := g.entities[id]
+		entitiesByType[entity.Type] = append(entitiesByType[entity.Type], entity)
+	}
+	for _, typeName := range sortedKeys(entitiesByType) {
+		entities := entitiesByType[typeName]
+		properties := make([]map[string]interface{}, len(entities))
+		for i, entity := range entities {
+			properties[i] = entity.Properties
+		}
+		schema.EntityTypes = append(schema.EntityTypes, interfaces.EntityTypeSchema{
+			Name:        typeName,
+			Description: fmt.Sprintf("Discovered from %d entities", len(entities)),
+			Properties:  discoverProperties(properties),
+		})
+	}
+
+	relsByType := map[string][]interfaces.Relationship{}
+	for _, id := range sortedKeys(g.relationships) {
+		rel := g.relationships[id]
+		relsByType[rel.Type] = append(relsByType[rel.Type], rel)
+	}
+	for _, typeName := range sortedKeys(relsByType) {
+		rels := relsByType[typeName]
+		sources := map[string]struct{}{}
+		targets := map[string]struct{}{}
+		properties := make([]map[string]interface{}, len(rels))
+		for i, rel := range rels {
+			sources[g.entities[rel.SourceID].Type] = struct{}{}
+			targets[g.entities[rel.TargetID].Type] = struct{}{}
+			properties[i] = rel.Properties
+		}
+		schema.RelationshipTypes = append(schema.RelationshipTypes, interfaces.RelationshipTypeSchema{
+			Name:        typeName,
+			Description: fmt.Sprintf("Discovered from %d relationships", len(rels)),
+			SourceTypes: sortedKeys(sources),
+			TargetTypes: sortedKeys(targets),
+			Properties:  discoverProperties(properties),
+		})
+	}
+
+	return schema, nil
+}
+
+// validateSchema checks that a schema is internally consistent
+func validateSchema(schema interfaces.GraphSchema) error {
+	entityTypes := map[string]struct{}{}
+	for _, entityType := range schema.EntityTypes {
+		if entityType.Name == "" {
+			return fmt.Errorf("%w: entity type name is required", graphrag.ErrSchemaValidation)
+		}
+		if _, ok := entityTypes[entityType.Name]; ok {
+			return fmt.Errorf("%w: duplicate entity type %q", graphrag.ErrSchemaValidation, entityType.Name)
+		}
+		entityTypes[entityType.Name] = struct{}{}
+		if err := validatePropertySchemas(entityType.Name, entityType.Properties); err != nil {
+			return err
+		}
+	}
+
+	relTypes := map[string]struct{}{}
+	for _, relType := range schema.RelationshipTypes {
+		if relType.Name == "" {
+			return fmt.Errorf("%w: relationship type name is required", graphrag.ErrSchemaValidation)
+		}
+		if _, ok := relTypes[relType.Name]; ok {
+			return fmt.Errorf("%w: duplicate relationship type %q", graphrag.ErrSchemaValidation, relType.Name)
+		}
+		relTypes[relType.Name] = struct{}{}
+		for _, typeName := range append(append([]string{}, relType.SourceTypes...), relType.TargetTypes...) {
+			if _, ok := entityTypes[typeName]; !ok && len(entityTypes) > 0 {
+				return fmt.Errorf("%w: relationship type %q references unknown entity type %q", graphrag.ErrSchemaValidation, relType.Name, typeName)
+			}
+		}
+		if err := validatePropertySchemas(relType.Name, relType.Properties); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validatePropertySchemas(owner string, properties []interfaces.PropertySchema) error {
+	for _, prop := range properties {
+		if prop.Name == "" {
+			return fmt.Errorf("%w: property name is required on %q", graphrag.ErrSchemaValidation, owner)
+		}
+		switch prop.Type {
+		case "", "string", "number", "boolean", "datetime":
+		default:
+			return fmt.Errorf("%w: property %q on %q has unsupported type %q", graphrag.ErrSchemaValidation, prop.Name, owner, prop.Type)
+		}
+		if prop.Default != nil && !matchesPropertyType(prop.Type, prop.Default) {
+			return fmt.Errorf("%w: default for property %q on %q is not a %s", graphrag.ErrSchemaValidation, prop.Name, owner, prop.Type)
+		}
+	}
+	return nil
+}
+
+// validateEntityAgainstSchema applies property defaults to an entity and checks it against the schema.
+// Schemas without entity types accept any entity type.
+func validateEntityAgainstSchema(schema *interfaces.GraphSchema, entity *interfaces.Entity) error {
+	if len(schema.EntityTypes) == 0 {
+		return nil
+	}
+	entityType := findEntityType(schema, entity.Type)
+	if entityType == nil {
+		return fmt.Errorf("%w: entity %s has unknown type %q", graphrag.ErrSchemaValidation, entity.ID, entity.Type)
+	}
+	applyPropertyDefaults(entityType.Properties, &entity.Properties)
+	return validateProperties("entity "+entity.ID, entityType.Properties, entity.Properties)
+}
+
+// validateRelationshipAgainstSchema applies property defaults to a relationship and checks
+// its type and endpoint types against the schema.
+// Schemas without relationship types accept any relationship type.
+func validateRelationshipAgainstSchema(schema *interfaces.GraphSchema, rel *interfaces.Relationship, source, target interfaces.Entity) error {
+	if len(schema.RelationshipTypes) == 0 {
+		return nil
+	}
+	relType := findRelationshipType(schema, rel.Type)
+	if relType == nil {
+		return fmt.Errorf("%w: relationship %s has unknown type %q", graphrag.ErrSchemaValidation, rel.ID, rel.Type)
+	}
+	if len(relType.SourceTypes) > 0 && !contains(relType.SourceTypes, source.Type) {
+		return fmt.Errorf("%w: relationship %s of type %q cannot start at entity type %q", graphrag.ErrSchemaValidation, rel.ID, rel.Type, source.Type)
+	}
+	if len(relType.TargetTypes) > 0 && !contains(relType.TargetTypes, target.Type) {
+		return fmt.Errorf("%w: relationship %s of type %q cannot end at entity type %q", graphrag.ErrSchemaValidation, rel.ID, rel.Type, target.Type)
+	}
+	applyPropertyDefaults(relType.Properties, &rel.Properties)
+	return validateProperties("relationship "+rel.ID, relType.Properties, rel.Properties)
+}
+
+// validateProperties checks required properties and value types. Undeclared properties are allowed.
+func validateProperties(owner string, schema []interfaces.PropertySchema, values map[string]interface{}) error {
+	for _, prop := range schema {
+		value, ok := values[prop.Name]
+		if !ok || value == nil {
+			if prop.Required {
+				return fmt.Errorf("%w: %s is missing required property %q", graphrag.ErrSchemaValidation, owner, prop.Name)
+			}
+			continue
+		}
+		if !matchesPropertyType(prop.Type, value) {
+			return fmt.Errorf("%w: property %q of %s must be a %s", graphrag.ErrSchemaValidation, prop.Name, owner, prop.Type)
+		}
+	}
+	return nil
+}
+
+// applyPropertyDefaults fills in missing properties that declare a default.
+// The map is copied before modification so callers' maps are never mutated.
+func applyPropertyDefaults(schema []interfaces.PropertySchema, values *map[string]interface{}) {
+	copied := false
+	for _, prop := range schema {
+		if prop.Default == nil {
+			continue
+		}
+		if _, ok := (*values)[prop.Name]; ok {
+			continue
+		}
+		if !copied {
+			updated := make(map[string]interface{}, len(*values)+1)
+			for k, v := range *values {
+				updated[k] = v
+			}
+			*values = updated
+			copied = true
+		}
+		(*values)[prop.Name] = prop.Default
+	}
+}
+
+func matchesPropertyType(propType string, value interface{}) bool {
+	switch propType {
+	case "":
+		return true
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		switch value.(type) {
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			return true
+		}
+		return false
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "datetime":
+		switch v := value.(type) {
+		case time.Time:
+			return true
+		case string:
+			_, err := time.Parse(time.RFC3339, v)
+			return err == nil
+		}
+		return false
+	}
+	return false
+}
+
+// inferPropertyType returns the schema type name for a property value
+func inferPropertyType(value interface{}) string {
+	for _, propType := range []string{"boolean", "number", "datetime", "string"} {
+		if matchesPropertyType(propType, value) {
+			return propType
+		}
+	}
+	return "string"
+}
+
+// discoverProperties infers property schemas from a set of property maps
+func discoverProperties(values []map[string]interface{}) []interfaces.PropertySchema {
+	counts := map[string]int{}
+	types := map[string]string{}
+	for _, props := range values {
+		for name, value := range props {
+			counts[name]++
+			propType := inferPropertyType(value)
+			if existing, ok := types[name]; ok && existing != propType {
+				// Mixed value types fall back to an untyped string description
+				propType = "string"
+			}
+			types[name] = propType
+		}
+	}
+
+	var properties []interfaces.PropertySchema
+	for _, name := range sortedKeys(counts) {
+		properties = append(properties, interfaces.PropertySchema{
+			Name:     name,
+			Type:     types[name],
+			Required: counts[name] == len(values),
+		})
+	}
+	return properties
+}
+
+func findEntityType(schema *interfaces.GraphSchema, name string) *interfaces.EntityTypeSchema {
+	for i := range schema.EntityTypes {
+		if schema.EntityTypes[i].Name == name {
+			return &schema.EntityTypes[i]
+		}
+	}
+	return nil
+}
+
+func findRelationshipType(schema *interfaces.GraphSchema, name string) *interfaces.RelationshipTypeSchema {
+	for i := range schema.RelationshipTypes {
+		if schema.RelationshipTypes[i].Name == name {
+			return &schema.RelationshipTypes[i]
+		}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
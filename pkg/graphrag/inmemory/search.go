@@ -0,0 +1,344 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/tagus/agent-sdk-go/pkg/graphrag"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+const (
+	// BM25 parameters
+	bm25K1 = 1.2
+	bm25B  = 0.75
+
+	// localSearchSeeds is the number of entry points used by LocalSearch when no entity is given
+	localSearchSeeds = 5
+
+	// localSearchDecay scales the score of entities by hop distance from the seed
+	localSearchDecay = 0.5
+)
+
+// Search finds entities relevant to a query. The default hybrid mode blends cosine
+// similarity of entity embeddings with normalized BM25 over name, type and description;
+// without an embedder it degrades to keyword search.
+func (s *Store) Search(ctx context.Context, query string, limit int, options ...interfaces.GraphSearchOption) ([]interfaces.GraphSearchResult, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, graphrag.ErrEmptyQuery
+	}
+
+	opts := applySearchOptions(options)
+	mode := opts.SearchMode
+	if mode == "" {
+		mode = interfaces.SearchModeHybrid
+	}
+	if mode == interfaces.SearchModeVector && s.embedder == nil {
+		return nil, graphrag.ErrNoEmbedder
+	}
+
+	var queryVector []float32
+	if s.embedder != nil && mode != interfaces.SearchModeKeyword {
+		var err error
+		queryVector, err = s.embedder.Embed(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed query: %w", err)
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	g := s.graphFor(s.resolveTenant(ctx, opts.Tenant), false)
+	if g == nil {
+		return []interfaces.GraphSearchResult{}, nil
+	}
+
+	scores := s.scoreEntities(g, query, queryVector, mode, opts)
+	results := make([]interfaces.GraphSearchResult, 0, len(scores))
+	for _, id := range rankScores(scores, limit) {
+		result := interfaces.GraphSearchResult{Entity: g.entities[id], Score: scores[id]}
+		if opts.IncludeRelationships {
+			result.Context, result.Path = g.neighborhood(id, toSet(opts.RelationshipTypes))
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// LocalSearch searches the neighborhood of an entity. When entityID is empty the
+// best matches for the query are used as starting points. Entities reached through
+// traversal are scored by their seed's score, decayed by hop distance, and carry
+// the relationship path from the seed.
+func (s *Store) LocalSearch(ctx context.Context, query string, entityID string, depth int, options ...interfaces.GraphSearchOption) ([]interfaces.GraphSearchResult, error) {
+	opts := applySearchOptions(options)
+	if depth < 0 || depth > s.maxDepth {
+		return nil, fmt.Errorf("%w: %d", graphrag.ErrInvalidDepth, depth)
+	}
+	if opts.MaxDepth > 0 && depth > opts.MaxDepth {
+		depth = opts.MaxDepth
+	}
+
+	type seed struct {
+		id    string
+		score float32
+	}
+	var seeds []seed
+	if entityID != "" {
+		seeds = append(seeds, seed{id: entityID, score: 1})
+	} else {
+		if strings.TrimSpace(query) == "" {
+			return nil, graphrag.ErrEmptyQuery
+		}
+		// Seeds are not restricted by entity type so traversal can reach matching types
+		seedOptions := append(append([]interfaces.GraphSearchOption{}, options...), interfaces.WithEntityTypes(), interfaces.WithMinGraphScore(0))
+		matches, err := s.Search(ctx, query, localSearchSeeds, seedOptions...)
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			seeds = append(seeds, seed{id: match.Entity.ID, score: match.Score})
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	g := s.graphFor(s.resolveTenant(ctx, opts.Tenant), false)
+	if entityID != "" {
+		if g == nil {
+			return nil, fmt.Errorf("%w: %s", graphrag.ErrEntityNotFound, entityID)
+		}
+		if _, ok := g.entities[entityID]; !ok {
+			return nil, fmt.Errorf("%w: %s", graphrag.ErrEntityNotFound, entityID)
+		}
+	}
+	if g == nil {
+		return []interfaces.GraphSearchResult{}, nil
+	}
+
+	relTypes := toSet(opts.RelationshipTypes)
+	entityTypes := toSet(opts.EntityTypes)
+	best := map[string]interfaces.GraphSearchResult{}
+	for _, sd := range seeds {
+		visit := g.bfs(sd.id, depth, relTypes)
+		for _, id := range visit.order {
+			hops := visit.depth[id]
+			score := sd.score * float32(math.Pow(localSearchDecay, float64(hops)))
+			if existing, ok := best[id]; ok && existing.Score >= score {
+				continue
+			}
+			result := interfaces.GraphSearchResult{
+				Entity: g.entities[id],
+				Score:  score,
+				Path:   visit.pathTo(id, g),
+			}
+			if hops == 0 {
+				for _, otherID := range visit.order[1:] {
+					result.Context = append(result.Context, g.entities[otherID])
+				}
+			}
+			best[id] = result
+		}
+	}
+
+	results := make([]interfaces.GraphSearchResult, 0, len(best))
+	for _, result := range best {
+		if !matchesType(entityTypes, result.Entity.Type) || result.Score < opts.MinScore {
+			continue
+		}
+		results = append(results, result)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Entity.ID < results[j].Entity.ID
+	})
+
+	return results, nil
+}
+
+// scoreEntities scores every entity matching the filters. Entities without a positive score are omitted.
+// Callers must hold the lock.
+func (s *Store) scoreEntities(g *graph, query string, queryVector []float32, mode interfaces.GraphSearchMode, opts *interfaces.GraphSearchOptions) map[string]float32 {
+	entityTypes := toSet(opts.EntityTypes)
+	ids := make([]string, 0, len(g.entities))
+	for _, id := range sortedKeys(g.entities) {
+		if matchesType(entityTypes, g.entities[id].Type) {
+			ids = append(ids, id)
+		}
+	}
+
+	var keywordScores map[string]float32
+	if mode != interfaces.SearchModeVector {
+		texts := make(map[string]string, len(ids))
+		for _, id := range ids {
+			texts[id] = graphrag.EntityEmbeddingText(g.entities[id])
+		}
+		keywordScores = bm25(query, texts)
+	}
+
+	scores := make(map[string]float32, len(ids))
+	for _, id := range ids {
+		var score float32
+		switch {
+		case queryVector == nil:
+			score = keywordScores[id]
+		case mode == interfaces.SearchModeVector:
+			score = vectorScore(queryVector, g.entities[id].Embedding)
+		default:
+			score = s.hybridAlpha*vectorScore(queryVector, g.entities[id].Embedding) + (1-s.hybridAlpha)*keywordScores[id]
+		}
+		if score > 0 && score >= opts.MinScore {
+			scores[id] = score
+		}
+	}
+	return scores
+}
+
+// neighborhood returns the direct neighbors of an entity and the relationships connecting them
+func (g *graph) neighborhood(id string, relTypes map[string]struct{}) ([]interfaces.Entity, []interfaces.Relationship) {
+	var entities []interfaces.Entity
+	var rels []interfaces.Relationship
+	seen := map[string]struct{}{id: {}}
+	for _, rel := range g.relationshipsOf(id, interfaces.DirectionBoth) {
+		if !matchesType(relTypes, rel.Type) {
+			continue
+		}
+		rels = append(rels, rel)
+		other := rel.TargetID
+		if other == id {
+			other = rel.SourceID
+		}
+		if _, ok := seen[other]; !ok {
+			seen[other] = struct{}{}
+			entities = append(entities, g.entities[other])
+		}
+	}
+	return entities, rels
+}
+
+// vectorScore returns cosine similarity, clamped at zero so unrelated entities don't match
+func vectorScore(a, b []float32) float32 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, magA, magB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		magA += float64(a[i]) * float64(a[i])
+		magB += float64(b[i]) * float64(b[i])
+	}
+	if magA == 0 || magB == 0 {
+		return 0
+	}
+	return float32(math.Max(0, dot/(math.Sqrt(magA)*math.Sqrt(magB))))
+}
+
+// bm25 scores documents against a query, normalized so the best match scores 1
+func bm25(query string, docs map[string]string) map[string]float32 {
+	queryTerms := tokenize(query)
+	scores := make(map[string]float32, len(docs))
+	if len(queryTerms) == 0 || len(docs) == 0 {
+		return scores
+	}
+
+	termFreqs := make(map[string]map[string]int, len(docs))
+	docFreq := map[string]int{}
+	totalLength := 0
+	for id, text := range docs {
+		terms := tokenize(text)
+		totalLength += len(terms)
+		freqs := map[string]int{}
+		for _, term := range terms {
+			freqs[term]++
+		}
+		for term := range freqs {
+			docFreq[term]++
+		}
+		termFreqs[id] = freqs
+	}
+	avgLength := float64(totalLength) / float64(len(docs))
+	if avgLength == 0 {
+		return scores
+	}
+
+	var maxScore float64
+	raw := make(map[string]float64, len(docs))
+	for id, freqs := range termFreqs {
+		length := 0
+		for _, n := range freqs {
+			length += n
+		}
+		var score float64
+		for _, term := range queryTerms {
+			tf := float64(freqs[term])
+			if tf == 0 {
+				continue
+			}
+			n := float64(docFreq[term])
+			idf := math.Log(1 + (float64(len(docs))-n+0.5)/(n+0.5))
+			score += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*float64(length)/avgLength))
+		}
+		if score > 0 {
+			raw[id] = score
+			maxScore = math.Max(maxScore, score)
+		}
+	}
+
+	for id, score := range raw {
+		scores[id] = float32(score / maxScore)
+	}
+	return scores
+}
+
+// tokenize lowercases text and splits it on non-alphanumeric characters
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// rankScores returns IDs ordered by descending score, ties broken by ID, truncated to limit
+func rankScores(scores map[string]float32, limit int) []string {
+	ids := make([]string, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	if limit > 0 && len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids
+}
+
+func toSet(values []string) map[string]struct{} {
+	if len(values) == 0 {
+		return nil
+	}
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
+}
+
+// matchesType reports whether value is in set; an empty set matches everything
+func matchesType(set map[string]struct{}, value string) bool {
+	if len(set) == 0 {
+		return true
+	}
+	_, ok := set[value]
+	return ok
+}
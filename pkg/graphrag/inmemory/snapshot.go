@@ -0,0 +1,137 @@
+package inmemory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// snapshotVersion is incremented when the snapshot format changes incompatibly
+const snapshotVersion = 1
+
+// snapshot is the on-disk representation of the store
+type snapshot struct {
+	Version int                       `json:"version"`
+	Schema  *interfaces.GraphSchema   `json:"schema,omitempty"`
+	Tenants map[string]snapshotTenant `json:"tenants"`
+}
+
+// snapshotTenant is the on-disk representation of a tenant's graph
+type snapshotTenant struct {
+	Entities      []interfaces.Entity       `json:"entities"`
+	Relationships []interfaces.Relationship `json:"relationships"`
+}
+
+// SaveSnapshot writes the contents of the store to a file.
+// The file is written atomically by renaming a temporary file into place.
+func (s *Store) SaveSnapshot(path string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.writeSnapshotLocked(path)
+}
+
+// LoadSnapshot replaces the contents of the store with a snapshot file.
+// A missing file is not an error and leaves the store empty.
+// Property values are restored through JSON, so numbers are returned as float64.
+func (s *Store) LoadSnapshot(path string) error {
+	data, err := os.ReadFile(path) // #nosec G304 - Path is provided by the store owner
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version: %d", snap.Version)
+	}
+
+	graphs := make(map[string]*graph, len(snap.Tenants))
+	entityCount, relCount := 0, 0
+	for tenant, data := range snap.Tenants {
+		g := newGraph()
+		for _, entity := range data.Entities {
+			g.entities[entity.ID] = entity
+		}
+		for _, rel := range data.Relationships {
+			g.addRelationship(rel)
+		}
+		graphs[tenant] = g
+		entityCount += len(data.Entities)
+		relCount += len(data.Relationships)
+	}
+
+	s.mu.Lock()
+	s.graphs = graphs
+	s.schema = snap.Schema
+	s.mu.Unlock()
+
+	s.logger.Debug(context.Background(), "Loaded graph store snapshot", map[string]interface{}{
+		"path":          path,
+		"tenants":       len(graphs),
+		"entities":      entityCount,
+		"relationships": relCount,
+	})
+
+	return nil
+}
+
+// writeSnapshotLocked serializes the store to path. Callers must hold the lock.
+func (s *Store) writeSnapshotLocked(path string) error {
+	snap := snapshot{
+		Version: snapshotVersion,
+		Schema:  s.schema,
+		Tenants: make(map[string]snapshotTenant, len(s.graphs)),
+	}
+	for tenant, g := range s.graphs {
+		data := snapshotTenant{
+			Entities:      make([]interfaces.Entity, 0, len(g.entities)),
+			Relationships: make([]interfaces.Relationship, 0, len(g.relationships)),
+		}
+		for _, id := range sortedKeys(g.entities) {
+			data.Entities = append(data.Entities, g.entities[id])
+		}
+		for _, id := range sortedKeys(g.relationships) {
+			data.Relationships = append(data.Relationships, g.relationships[id])
+		}
+		snap.Tenants[tenant] = data
+	}
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("failed to serialize snapshot: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, ".graphstore-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,262 @@
+// Package inmemory provides an embedded GraphRAG store that keeps the knowledge
+// graph in process memory, with optional snapshots to disk.
+//
+// It implements every operation of interfaces.GraphRAGStore, including BFS
+// traversal, shortest paths, vector/keyword/hybrid search, local search and
+// community-based global search, and is suitable for tests, local development
+// and small deployments.
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/tagus/agent-sdk-go/pkg/embedding"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/logging"
+	"github.com/tagus/agent-sdk-go/pkg/multitenancy"
+)
+
+const (
+	// defaultMaxDepth bounds traversal when no depth limit is configured
+	defaultMaxDepth = 10
+
+	// defaultHybridAlpha weights vector and keyword scores equally in hybrid search
+	defaultHybridAlpha = 0.5
+)
+
+// Store is an in-memory implementation of interfaces.GraphRAGStore
+type Store struct {
+	mu           sync.RWMutex
+	embedder     embedding.Client
+	logger       logging.Logger
+	tenant       string
+	snapshotPath string
+	schema       *interfaces.GraphSchema
+	maxDepth     int
+	hybridAlpha  float32
+
+	// graphs holds one graph per tenant; the empty tenant is the default graph
+	graphs map[string]*graph
+}
+
+// graph holds the entities and relationships of a single tenant
+type graph struct {
+	entities      map[string]interfaces.Entity
+	relationships map[string]interfaces.Relationship
+
+	// outgoing and incoming index relationship IDs by entity ID
+	outgoing map[string]map[string]struct{}
+	incoming map[string]map[string]struct{}
+
+	// communities caches detected communities per level; reset on mutation.
+	// communityMu guards the cache, which is filled while holding only the store's read lock.
+	communityMu sync.Mutex
+	communities map[int][]community
+}
+
+// Option represents an option for configuring the store
+type Option func(*Store)
+
+// WithEmbedder sets the embedding client used for entity embeddings and vector search
+func WithEmbedder(embedder embedding.Client) Option {
+	return func(s *Store) {
+		s.embedder = embedder
+	}
+}
+
+// WithLogger sets the logger for the store
+func WithLogger(logger logging.Logger) Option {
+	return func(s *Store) {
+		s.logger = logger
+	}
+}
+
+// WithSnapshotPath enables persistence to the given file. The snapshot is loaded
+// when the store is created and rewritten after every mutation.
+func WithSnapshotPath(path string) Option {
+	return func(s *Store) {
+		s.snapshotPath = path
+	}
+}
+
+// WithStoreTenant sets the default tenant, equivalent to calling SetTenant
+func WithStoreTenant(tenant string) Option {
+	return func(s *Store) {
+		s.tenant = tenant
+	}
+}
+
+// WithMaxTraversalDepth sets the maximum depth allowed for traversal and path finding
+func WithMaxTraversalDepth(depth int) Option {
+	return func(s *Store) {
+		if depth > 0 {
+			s.maxDepth = depth
+		}
+	}
+}
+
+// WithHybridAlpha sets the weight of the vector score in hybrid search (0-1)
+func WithHybridAlpha(alpha float32) Option {
+	return func(s *Store) {
+		if alpha >= 0 && alpha <= 1 {
+			s.hybridAlpha = alpha
+		}
+	}
+}
+
+// New creates a new in-memory GraphRAG store
+func New(options ...Option) (*Store, error) {
+	s := &Store{
+		logger:      logging.New(),
+		maxDepth:    defaultMaxDepth,
+		hybridAlpha: defaultHybridAlpha,
+		graphs:      map[string]*graph{},
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	if s.snapshotPath != "" {
+		if err := s.LoadSnapshot(s.snapshotPath); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// SetTenant sets the default tenant for operations without an explicit tenant
+func (s *Store) SetTenant(tenant string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tenant = tenant
+}
+
+// GetTenant returns the default tenant
+func (s *Store) GetTenant() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tenant
+}
+
+// Close flushes the snapshot if persistence is enabled
+func (s *Store) Close() error {
+	if s.snapshotPath == "" {
+		return nil
+	}
+	return s.SaveSnapshot(s.snapshotPath)
+}
+
+// resolveTenant determines the tenant for an operation. An explicit tenant wins,
+// then the organization ID from the context, then the store's default tenant.
+// Callers must hold the lock.
+func (s *Store) resolveTenant(ctx context.Context, tenant string) string {
+	if tenant != "" {
+		return tenant
+	}
+	if orgID, err := multitenancy.GetOrgID(ctx); err == nil {
+		return orgID
+	}
+	return s.tenant
+}
+
+// graphFor returns the graph for a tenant, creating it if requested. Callers must hold the lock.
+func (s *Store) graphFor(tenant string, create bool) *graph {
+	g, ok := s.graphs[tenant]
+	if !ok && create {
+		g = newGraph()
+		s.graphs[tenant] = g
+	}
+	return g
+}
+
+// persistLocked writes a snapshot if persistence is enabled. Callers must hold the write lock.
+func (s *Store) persistLocked() error {
+	if s.snapshotPath == "" {
+		return nil
+	}
+	return s.writeSnapshotLocked(s.snapshotPath)
+}
+
+func newGraph() *graph {
+	return &graph{
+		entities:      map[string]interfaces.Entity{},
+		relationships: map[string]interfaces.Relationship{},
+		outgoing:      map[string]map[string]struct{}{},
+		incoming:      map[string]map[string]struct{}{},
+	}
+}
+
+// addRelationship indexes a relationship, replacing any previous version
+func (g *graph) addRelationship(rel interfaces.Relationship) {
+	if old, ok := g.relationships[rel.ID]; ok {
+		g.removeRelationship(old.ID)
+	}
+	g.relationships[rel.ID] = rel
+	if g.outgoing[rel.SourceID] == nil {
+		g.outgoing[rel.SourceID] = map[string]struct{}{}
+	}
+	if g.incoming[rel.TargetID] == nil {
+		g.incoming[rel.TargetID] = map[string]struct{}{}
+	}
+	g.outgoing[rel.SourceID][rel.ID] = struct{}{}
+	g.incoming[rel.TargetID][rel.ID] = struct{}{}
+	g.communities = nil
+}
+
+// removeRelationship removes a relationship and its index entries
+func (g *graph) removeRelationship(id string) {
+	rel, ok := g.relationships[id]
+	if !ok {
+		return
+	}
+	delete(g.relationships, id)
+	delete(g.outgoing[rel.SourceID], id)
+	delete(g.incoming[rel.TargetID], id)
+	g.communities = nil
+}
+
+// relationshipsOf returns the relationships attached to an entity in the given direction
+func (g *graph) relationshipsOf(entityID string, direction interfaces.RelationshipDirection) []interfaces.Relationship {
+	var ids []string
+	if direction == interfaces.DirectionOutgoing || direction == interfaces.DirectionBoth || direction == "" {
+		for id := range g.outgoing[entityID] {
+			ids = append(ids, id)
+		}
+	}
+	if direction == interfaces.DirectionIncoming || direction == interfaces.DirectionBoth || direction == "" {
+		for id := range g.incoming[entityID] {
+			// Self-loops are already included as outgoing
+			if _, seen := g.outgoing[entityID][id]; seen && direction != interfaces.DirectionIncoming {
+				continue
+			}
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Strings(ids)
+	rels := make([]interfaces.Relationship, 0, len(ids))
+	for _, id := range ids {
+		rels = append(rels, g.relationships[id])
+	}
+	return rels
+}
+
+func applyStoreOptions(options []interfaces.GraphStoreOption) *interfaces.GraphStoreOptions {
+	opts := &interfaces.GraphStoreOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+	return opts
+}
+
+func applySearchOptions(options []interfaces.GraphSearchOption) *interfaces.GraphSearchOptions {
+	opts := &interfaces.GraphSearchOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+	return opts
+}
@@ -0,0 +1,452 @@
+package inmemory
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tagus/agent-sdk-go/pkg/embedding"
+	"github.com/tagus/agent-sdk-go/pkg/graphrag"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/multitenancy"
+)
+
+// keywordEmbedder produces one dimension per known keyword so similarity is predictable
+type keywordEmbedder struct {
+	keywords []string
+}
+
+func (e *keywordEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	vector := make([]float32, len(e.keywords))
+	lower := strings.ToLower(text)
+	for i, keyword := range e.keywords {
+		if strings.Contains(lower, keyword) {
+			vector[i] = 1
+		}
+	}
+	return vector, nil
+}
+
+func (e *keywordEmbedder) EmbedWithConfig(ctx context.Context, text string, config embedding.EmbeddingConfig) ([]float32, error) {
+	return e.Embed(ctx, text)
+}
+
+func (e *keywordEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i], _ = e.Embed(ctx, text)
+	}
+	return vectors, nil
+}
+
+func (e *keywordEmbedder) EmbedBatchWithConfig(ctx context.Context, texts []string, config embedding.EmbeddingConfig) ([][]float32, error) {
+	return e.EmbedBatch(ctx, texts)
+}
+
+func (e *keywordEmbedder) CalculateSimilarity(vec1, vec2 []float32, metric string) (float32, error) {
+	return embedding.CalculateSimilarity(vec1, vec2, metric)
+}
+
+// mockLLM returns a canned response
+type mockLLM struct {
+	response string
+	prompt   string
+}
+
+func (m *mockLLM) Generate(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (string, error) {
+	m.prompt = prompt
+	return m.response, nil
+}
+
+func (m *mockLLM) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (string, error) {
+	return m.Generate(ctx, prompt, options...)
+}
+
+func (m *mockLLM) GenerateDetailed(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (*interfaces.LLMResponse, error) {
+	content, err := m.Generate(ctx, prompt, options...)
+	return &interfaces.LLMResponse{Content: content}, err
+}
+
+func (m *mockLLM) GenerateWithToolsDetailed(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (*interfaces.LLMResponse, error) {
+	return m.GenerateDetailed(ctx, prompt, options...)
+}
+
+func (m *mockLLM) Name() string { return "mock" }
+
+func (m *mockLLM) SupportsStreaming() bool { return false }
+
+// testGraph has two clusters, a Go team and a tea shop, joined by one weak relationship
+func testGraph() ([]interfaces.Entity, []interfaces.Relationship) {
+	entities := []interfaces.Entity{
+		{ID: "alice", Name: "Alice", Type: "Person", Description: "Backend engineer writing Go services"},
+		{ID: "bob", Name: "Bob", Type: "Person", Description: "Engineer maintaining the Go compiler"},
+		{ID: "gopher", Name: "Gopher Project", Type: "Project", Description: "Open source Go tooling"},
+		{ID: "carol", Name: "Carol", Type: "Person", Description: "Tea sommelier"},
+		{ID: "dave", Name: "Dave", Type: "Person", Description: "Brews green tea"},
+		{ID: "teashop", Name: "Leaf House", Type: "Organization", Description: "Tea shop selling green and black tea"},
+	}
+	relationships := []interfaces.Relationship{
+		{ID: "r1", SourceID: "alice", TargetID: "gopher", Type: "WORKS_ON"},
+		{ID: "r2", SourceID: "bob", TargetID: "gopher", Type: "WORKS_ON"},
+		{ID: "r3", SourceID: "alice", TargetID: "bob", Type: "KNOWS"},
+		{ID: "r4", SourceID: "carol", TargetID: "teashop", Type: "WORKS_AT"},
+		{ID: "r5", SourceID: "dave", TargetID: "teashop", Type: "WORKS_AT"},
+		{ID: "r6", SourceID: "carol", TargetID: "dave", Type: "KNOWS"},
+		{ID: "r7", SourceID: "bob", TargetID: "carol", Type: "KNOWS", Strength: 0.2},
+	}
+	return entities, relationships
+}
+
+func newTestStore(t *testing.T, options ...Option) *Store {
+	t.Helper()
+	store, err := New(options...)
+	require.NoError(t, err)
+
+	entities, relationships := testGraph()
+	ctx := context.Background()
+	require.NoError(t, store.StoreEntities(ctx, entities))
+	require.NoError(t, store.StoreRelationships(ctx, relationships))
+	return store
+}
+
+func TestStore_ImplementsGraphRAGStore(t *testing.T) {
+	var store interfaces.GraphRAGStore = newTestStore(t)
+	assert.NotNil(t, store)
+}
+
+func TestStore_EntityCRUD(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	entity, err := store.GetEntity(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", entity.Name)
+	assert.False(t, entity.CreatedAt.IsZero())
+
+	entity.Description = "Staff engineer"
+	require.NoError(t, store.UpdateEntity(ctx, *entity))
+	updated, err := store.GetEntity(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "Staff engineer", updated.Description)
+	assert.Equal(t, entity.CreatedAt, updated.CreatedAt)
+
+	err = store.UpdateEntity(ctx, interfaces.Entity{ID: "missing", Name: "X", Type: "Person"})
+	assert.ErrorIs(t, err, graphrag.ErrEntityNotFound)
+
+	require.NoError(t, store.DeleteEntity(ctx, "alice"))
+	_, err = store.GetEntity(ctx, "alice")
+	assert.True(t, graphrag.IsNotFoundError(err))
+
+	// Relationships attached to a deleted entity are removed with it
+	rels, err := store.GetRelationships(ctx, "gopher", interfaces.DirectionBoth)
+	require.NoError(t, err)
+	require.Len(t, rels, 1)
+	assert.Equal(t, "r2", rels[0].ID)
+
+	assert.ErrorIs(t, store.StoreEntities(ctx, []interfaces.Entity{{ID: "x", Type: "Person"}}), graphrag.ErrMissingEntityName)
+}
+
+func TestStore_Relationships(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	outgoing, err := store.GetRelationships(ctx, "bob", interfaces.DirectionOutgoing)
+	require.NoError(t, err)
+	assert.Len(t, outgoing, 2)
+
+	incoming, err := store.GetRelationships(ctx, "bob", interfaces.DirectionIncoming)
+	require.NoError(t, err)
+	require.Len(t, incoming, 1)
+	assert.Equal(t, "r3", incoming[0].ID)
+
+	knows, err := store.GetRelationships(ctx, "bob", interfaces.DirectionBoth, interfaces.WithRelationshipTypes("KNOWS"))
+	require.NoError(t, err)
+	assert.Len(t, knows, 2)
+	assert.Equal(t, float32(1.0), knows[0].Strength)
+
+	err = store.StoreRelationships(ctx, []interfaces.Relationship{{ID: "bad", SourceID: "alice", TargetID: "nobody", Type: "KNOWS"}})
+	assert.ErrorIs(t, err, graphrag.ErrTargetEntityNotFound)
+
+	err = store.StoreRelationships(ctx, []interfaces.Relationship{{ID: "bad", SourceID: "alice", TargetID: "bob", Type: "KNOWS", Strength: 2}})
+	assert.ErrorIs(t, err, graphrag.ErrInvalidStrength)
+
+	require.NoError(t, store.DeleteRelationship(ctx, "r3"))
+	assert.ErrorIs(t, store.DeleteRelationship(ctx, "r3"), graphrag.ErrRelationshipNotFound)
+}
+
+func TestStore_TraverseFrom(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	result, err := store.TraverseFrom(ctx, "alice", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", result.CentralEntity.ID)
+	assert.Equal(t, 1, result.Depth)
+	assert.ElementsMatch(t, []string{"alice", "bob", "gopher"}, entityIDs(result.Entities))
+	assert.Len(t, result.Relationships, 3)
+
+	result, err = store.TraverseFrom(ctx, "alice", 2, interfaces.WithRelationshipTypes("KNOWS"))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"alice", "bob", "carol"}, entityIDs(result.Entities))
+
+	_, err = store.TraverseFrom(ctx, "alice", -1)
+	assert.ErrorIs(t, err, graphrag.ErrInvalidDepth)
+	_, err = store.TraverseFrom(ctx, "nobody", 1)
+	assert.ErrorIs(t, err, graphrag.ErrEntityNotFound)
+}
+
+func TestStore_ShortestPath(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	path, err := store.ShortestPath(ctx, "alice", "teashop")
+	require.NoError(t, err)
+	assert.Equal(t, 3, path.Length)
+	assert.Equal(t, []string{"bob", "carol"}, entityIDs(path.Entities))
+	assert.Equal(t, "r3", path.Relationships[0].ID)
+	assert.Equal(t, "r4", path.Relationships[2].ID)
+
+	_, err = store.ShortestPath(ctx, "alice", "teashop", interfaces.WithRelationshipTypes("WORKS_ON", "WORKS_AT"))
+	assert.ErrorIs(t, err, graphrag.ErrPathNotFound)
+
+	_, err = store.ShortestPath(ctx, "alice", "teashop", interfaces.WithMaxDepth(2))
+	assert.ErrorIs(t, err, graphrag.ErrPathNotFound)
+}
+
+func TestStore_Search(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("keyword without embedder", func(t *testing.T) {
+		store := newTestStore(t)
+		results, err := store.Search(ctx, "green tea", 10)
+		require.NoError(t, err)
+		require.NotEmpty(t, results)
+		assert.Contains(t, []string{"dave", "teashop"}, results[0].Entity.ID)
+		for _, result := range results {
+			assert.NotContains(t, []string{"alice", "bob", "gopher"}, result.Entity.ID)
+		}
+
+		_, err = store.Search(ctx, "tea", 10, interfaces.WithSearchMode(interfaces.SearchModeVector))
+		assert.ErrorIs(t, err, graphrag.ErrNoEmbedder)
+		_, err = store.Search(ctx, " ", 10)
+		assert.ErrorIs(t, err, graphrag.ErrEmptyQuery)
+	})
+
+	t.Run("hybrid with embedder", func(t *testing.T) {
+		store := newTestStore(t, WithEmbedder(&keywordEmbedder{keywords: []string{"go", "tea", "engineer"}}))
+		results, err := store.Search(ctx, "golang engineer", 2,
+			interfaces.WithEntityTypes("Person"), interfaces.WithIncludeRelationships(true))
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		assert.ElementsMatch(t, []string{"alice", "bob"}, entityIDs([]interfaces.Entity{results[0].Entity, results[1].Entity}))
+		assert.NotEmpty(t, results[0].Context)
+		assert.NotEmpty(t, results[0].Path)
+
+		results, err = store.Search(ctx, "tea", 10, interfaces.WithSearchMode(interfaces.SearchModeVector))
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"carol", "dave", "teashop"}, searchIDs(results))
+	})
+}
+
+func TestStore_LocalSearch(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	results, err := store.LocalSearch(ctx, "sommelier", "", 1)
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, "carol", results[0].Entity.ID)
+	assert.Len(t, results[0].Context, 3)
+	assert.ElementsMatch(t, []string{"carol", "dave", "teashop", "bob"}, searchIDs(results))
+	for _, result := range results[1:] {
+		assert.Len(t, result.Path, 1)
+		assert.Less(t, result.Score, results[0].Score)
+	}
+
+	results, err = store.LocalSearch(ctx, "", "gopher", 1, interfaces.WithEntityTypes("Person"))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"alice", "bob"}, searchIDs(results))
+
+	_, err = store.LocalSearch(ctx, "x", "nobody", 1)
+	assert.ErrorIs(t, err, graphrag.ErrEntityNotFound)
+}
+
+func TestStore_Communities(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	communities, err := store.Communities(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, communities, 2)
+	assert.Equal(t, []string{"alice", "bob", "gopher"}, communities[0].EntityIDs)
+	assert.Equal(t, []string{"carol", "dave", "teashop"}, communities[1].EntityIDs)
+
+	// Merging the two clusters would lower modularity, so higher levels keep the partition
+	communities, err = store.Communities(ctx, 3)
+	require.NoError(t, err)
+	assert.Len(t, communities, 2)
+
+	results, err := store.GlobalSearch(ctx, "tea", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "1-1", results[0].CommunityID)
+	assert.Equal(t, "carol", results[0].Entity.ID)
+	assert.Len(t, results[0].Context, 2)
+
+	results, err = store.GlobalSearch(ctx, "tea", 0, interfaces.WithIncludeRelationships(true), interfaces.WithRelationshipTypes("KNOWS"))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Len(t, results[0].Path, 1)
+	assert.Equal(t, "r6", results[0].Path[0].ID)
+
+	// Mutations invalidate the cached communities
+	require.NoError(t, store.DeleteEntity(ctx, "gopher"))
+	require.NoError(t, store.DeleteEntity(ctx, "alice"))
+	require.NoError(t, store.DeleteEntity(ctx, "bob"))
+	communities, err = store.Communities(ctx, 0)
+	require.NoError(t, err)
+	assert.Len(t, communities, 1)
+}
+
+func TestStore_Schema(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	discovered, err := store.DiscoverSchema(ctx)
+	require.NoError(t, err)
+	require.Len(t, discovered.EntityTypes, 3)
+	assert.Equal(t, "Organization", discovered.EntityTypes[0].Name)
+	require.Len(t, discovered.RelationshipTypes, 3)
+	assert.Equal(t, []string{"Person"}, discovered.RelationshipTypes[0].SourceTypes)
+	assert.Equal(t, []string{"Person"}, discovered.RelationshipTypes[0].TargetTypes)
+
+	// The discovered schema always accepts the data it was discovered from
+	require.NoError(t, store.ApplySchema(ctx, *discovered))
+
+	err = store.StoreEntities(ctx, []interfaces.Entity{{ID: "x", Name: "X", Type: "Planet"}})
+	assert.ErrorIs(t, err, graphrag.ErrSchemaValidation)
+	err = store.StoreRelationships(ctx, []interfaces.Relationship{{ID: "x", SourceID: "gopher", TargetID: "alice", Type: "WORKS_ON"}})
+	assert.ErrorIs(t, err, graphrag.ErrSchemaValidation)
+
+	strict := interfaces.GraphSchema{
+		EntityTypes: []interfaces.EntityTypeSchema{
+			{Name: "Person", Properties: []interfaces.PropertySchema{{Name: "email", Type: "string", Required: true}}},
+		},
+	}
+	err = store.ApplySchema(ctx, strict)
+	assert.ErrorIs(t, err, graphrag.ErrSchemaValidation)
+
+	fresh, err := New()
+	require.NoError(t, err)
+	strict.EntityTypes[0].Properties = append(strict.EntityTypes[0].Properties,
+		interfaces.PropertySchema{Name: "active", Type: "boolean", Default: true})
+	require.NoError(t, fresh.ApplySchema(ctx, strict))
+	err = fresh.StoreEntities(ctx, []interfaces.Entity{{ID: "p", Name: "P", Type: "Person", Properties: map[string]interface{}{"email": 42}}})
+	assert.ErrorIs(t, err, graphrag.ErrSchemaValidation)
+	require.NoError(t, fresh.StoreEntities(ctx, []interfaces.Entity{{ID: "p", Name: "P", Type: "Person", Properties: map[string]interface{}{"email": "p@example.com"}}}))
+	entity, err := fresh.GetEntity(ctx, "p")
+	require.NoError(t, err)
+	assert.Equal(t, true, entity.Properties["active"])
+
+	err = fresh.ApplySchema(ctx, interfaces.GraphSchema{
+		EntityTypes:       []interfaces.EntityTypeSchema{{Name: "Person"}},
+		RelationshipTypes: []interfaces.RelationshipTypeSchema{{Name: "KNOWS", SourceTypes: []string{"Robot"}}},
+	})
+	assert.ErrorIs(t, err, graphrag.ErrSchemaValidation)
+}
+
+func TestStore_TenantIsolation(t *testing.T) {
+	ctx := context.Background()
+	store, err := New()
+	require.NoError(t, err)
+
+	store.SetTenant("acme")
+	assert.Equal(t, "acme", store.GetTenant())
+	require.NoError(t, store.StoreEntities(ctx, []interfaces.Entity{{ID: "a", Name: "Acme Widget", Type: "Product"}}))
+
+	entity, err := store.GetEntity(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "acme", entity.OrgID)
+
+	// The context organization overrides the store tenant
+	globexCtx := multitenancy.WithOrgID(ctx, "globex")
+	_, err = store.GetEntity(globexCtx, "a")
+	assert.ErrorIs(t, err, graphrag.ErrEntityNotFound)
+	results, err := store.Search(globexCtx, "widget", 10)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	// An explicit tenant overrides both
+	_, err = store.GetEntity(globexCtx, "a", interfaces.WithGraphTenant("acme"))
+	assert.NoError(t, err)
+}
+
+func TestStore_Snapshot(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "graph.json")
+
+	store := newTestStore(t, WithSnapshotPath(path))
+	require.NoError(t, store.StoreEntities(ctx, []interfaces.Entity{{ID: "t", Name: "Tenant Thing", Type: "Thing"}}, interfaces.WithGraphTenant("other")))
+	require.NoError(t, store.Close())
+
+	reloaded, err := New(WithSnapshotPath(path))
+	require.NoError(t, err)
+
+	path2, err := reloaded.ShortestPath(ctx, "alice", "teashop")
+	require.NoError(t, err)
+	assert.Equal(t, 3, path2.Length)
+
+	_, err = reloaded.GetEntity(ctx, "t", interfaces.WithGraphTenant("other"))
+	assert.NoError(t, err)
+}
+
+func TestStore_ExtractFromText(t *testing.T) {
+	ctx := context.Background()
+	store, err := New()
+	require.NoError(t, err)
+
+	llm := &mockLLM{response: "```json\n" + `{
+		"entities": [
+			{"name": "Alice", "type": "Person", "description": "Engineer", "confidence": 0.9},
+			{"name": "alice", "type": "Person", "confidence": 0.8},
+			{"name": "Gopher", "type": "Project", "confidence": 0.7},
+			{"name": "Acme", "type": "Organization", "confidence": 0.2}
+		],
+		"relationships": [
+			{"source": "Alice", "target": "Gopher", "type": "WORKS_ON", "strength": 0.8},
+			{"source": "Alice", "target": "Acme", "type": "WORKS_AT"}
+		]
+	}` + "\n```"}
+
+	result, err := store.ExtractFromText(ctx, "Alice works on Gopher.", llm,
+		graphrag.WithMinConfidence(0.5), graphrag.WithExtractionEntityTypes("Person", "Project"))
+	require.NoError(t, err)
+	require.Len(t, result.Entities, 2)
+	require.Len(t, result.Relationships, 1)
+	assert.Equal(t, result.Entities[0].ID, result.Relationships[0].SourceID)
+	assert.InDelta(t, 0.98, result.Entities[0].Properties[graphrag.ConfidenceProperty], 0.001)
+	assert.Contains(t, llm.prompt, "Person, Project")
+
+	_, err = store.ExtractFromText(ctx, "text", nil)
+	assert.ErrorIs(t, err, graphrag.ErrNoLLM)
+}
+
+func entityIDs(entities []interfaces.Entity) []string {
+	ids := make([]string, len(entities))
+	for i, entity := range entities {
+		ids[i] = entity.ID
+	}
+	return ids
+}
+
+func searchIDs(results []interfaces.GraphSearchResult) []string {
+	ids := make([]string, len(results))
+	for i, result := range results {
+		ids[i] = result.Entity.ID
+	}
+	return ids
+}
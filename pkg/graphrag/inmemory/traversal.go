@@ -0,0 +1,203 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tagus/agent-sdk-go/pkg/graphrag"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// visit records the result of a breadth-first traversal
+type visit struct {
+	// order lists reached entity IDs in BFS order, starting with the origin
+	order []string
+
+	// depth is the hop distance of each reached entity from the origin
+	depth map[string]int
+
+	// via is the relationship used to first reach each entity
+	via map[string]string
+}
+
+// bfs walks the graph from an entity in both directions up to maxDepth hops,
+// following only relationships whose type is in relTypes (all types if empty)
+func (g *graph) bfs(origin string, maxDepth int, relTypes map[string]struct{}) *visit {
+	v := &visit{
+		order: []string{origin},
+		depth: map[string]int{origin: 0},
+		via:   map[string]string{},
+	}
+
+	frontier := []string{origin}
+	for level := 0; level < maxDepth && len(frontier) > 0; level++ {
+		var next []string
+		for _, id := range frontier {
+			for _, rel := range g.relationshipsOf(id, interfaces.DirectionBoth) {
+				if !matchesType(relTypes, rel.Type) {
+					continue
+				}
+				other := rel.TargetID
+				if other == id {
+					other = rel.SourceID
+				}
+				if _, ok := v.depth[other]; ok {
+					continue
+				}
+				if _, ok := g.entities[other]; !ok {
+					continue
+				}
+				v.depth[other] = level + 1
+				v.via[other] = rel.ID
+				v.order = append(v.order, other)
+				next = append(next, other)
+			}
+		}
+		frontier = next
+	}
+
+	return v
+}
+
+// pathTo returns the relationships leading from the origin to an entity, in order
+func (v *visit) pathTo(id string, g *graph) []interfaces.Relationship {
+	var path []interfaces.Relationship
+	for current := id; ; {
+		relID, ok := v.via[current]
+		if !ok {
+			break
+		}
+		rel := g.relationships[relID]
+		path = append([]interfaces.Relationship{rel}, path...)
+		if rel.TargetID == current {
+			current = rel.SourceID
+		} else {
+			current = rel.TargetID
+		}
+	}
+	return path
+}
+
+// TraverseFrom collects the entities and relationships within depth hops of an entity.
+// Relationships are followed in both directions and can be filtered with WithRelationshipTypes.
+func (s *Store) TraverseFrom(ctx context.Context, entityID string, depth int, options ...interfaces.GraphSearchOption) (*interfaces.GraphContext, error) {
+	if entityID == "" {
+		return nil, graphrag.ErrInvalidEntityID
+	}
+
+	opts := applySearchOptions(options)
+	if depth < 0 || depth > s.maxDepth {
+		return nil, fmt.Errorf("%w: %d", graphrag.ErrInvalidDepth, depth)
+	}
+	if opts.MaxDepth > 0 && depth > opts.MaxDepth {
+		depth = opts.MaxDepth
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	g := s.graphFor(s.resolveTenant(ctx, opts.Tenant), false)
+	if g == nil {
+		return nil, fmt.Errorf("%w: %s", graphrag.ErrEntityNotFound, entityID)
+	}
+	central, ok := g.entities[entityID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", graphrag.ErrEntityNotFound, entityID)
+	}
+
+	relTypes := toSet(opts.RelationshipTypes)
+	v := g.bfs(entityID, depth, relTypes)
+	result := &interfaces.GraphContext{
+		CentralEntity: central,
+		Entities:      make([]interfaces.Entity, 0, len(v.order)),
+		Relationships: []interfaces.Relationship{},
+	}
+
+	entityTypes := toSet(opts.EntityTypes)
+	for _, id := range v.order {
+		entity := g.entities[id]
+		if id != entityID && !matchesType(entityTypes, entity.Type) {
+			continue
+		}
+		result.Entities = append(result.Entities, entity)
+		if v.depth[id] > result.Depth {
+			result.Depth = v.depth[id]
+		}
+	}
+	// Report every matching relationship between reached entities, including those
+	// between entities at the depth limit
+	seen := map[string]struct{}{}
+	for _, id := range v.order {
+		for _, rel := range g.relationshipsOf(id, interfaces.DirectionBoth) {
+			if _, ok := seen[rel.ID]; ok || !matchesType(relTypes, rel.Type) {
+				continue
+			}
+			_, sourceReached := v.depth[rel.SourceID]
+			_, targetReached := v.depth[rel.TargetID]
+			if sourceReached && targetReached {
+				seen[rel.ID] = struct{}{}
+				result.Relationships = append(result.Relationships, rel)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// ShortestPath finds the path with the fewest hops between two entities,
+// following relationships in either direction
+func (s *Store) ShortestPath(ctx context.Context, sourceID, targetID string, options ...interfaces.GraphSearchOption) (*interfaces.GraphPath, error) {
+	if sourceID == "" || targetID == "" {
+		return nil, graphrag.ErrInvalidEntityID
+	}
+
+	opts := applySearchOptions(options)
+	maxDepth := s.maxDepth
+	if opts.MaxDepth > 0 && opts.MaxDepth < maxDepth {
+		maxDepth = opts.MaxDepth
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	g := s.graphFor(s.resolveTenant(ctx, opts.Tenant), false)
+	if g == nil {
+		return nil, fmt.Errorf("%w: %s", graphrag.ErrSourceEntityNotFound, sourceID)
+	}
+	source, ok := g.entities[sourceID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", graphrag.ErrSourceEntityNotFound, sourceID)
+	}
+	target, ok := g.entities[targetID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", graphrag.ErrTargetEntityNotFound, targetID)
+	}
+
+	v := g.bfs(sourceID, maxDepth, toSet(opts.RelationshipTypes))
+	if _, ok := v.depth[targetID]; !ok {
+		return nil, fmt.Errorf("%w: %s -> %s", graphrag.ErrPathNotFound, sourceID, targetID)
+	}
+
+	path := &interfaces.GraphPath{
+		Source:        source,
+		Target:        target,
+		Entities:      []interfaces.Entity{},
+		Relationships: v.pathTo(targetID, g),
+	}
+	path.Length = len(path.Relationships)
+
+	// Walk the path to collect intermediate entities in order
+	current := sourceID
+	for i, rel := range path.Relationships {
+		if rel.SourceID == current {
+			current = rel.TargetID
+		} else {
+			current = rel.SourceID
+		}
+		if i < len(path.Relationships)-1 {
+			path.Entities = append(path.Entities, g.entities[current])
+		}
+	}
+
+	return path, nil
+}
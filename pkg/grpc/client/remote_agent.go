@@ -338,6 +338,30 @@ func (r *RemoteAgentClient) ApproveExecutionPlan(ctx context.Context, planID str
 	return r.client.ApproveExecutionPlan(ctx, req)
 }
 
+// ResolveToolApproval resolves a tool call waiting for approval via the remote agent
+func (r *RemoteAgentClient) ResolveToolApproval(ctx context.Context, approvalID, action, arguments, reason string) (*pb.ToolApprovalResponse, error) {
+	if err := r.ensureConnected(); err != nil {
+		return nil, err
+	}
+
+	req := &pb.ToolApprovalRequest{
+		ApprovalId: approvalID,
+		Action:     action,
+		Arguments:  arguments,
+		Reason:     reason,
+	}
+
+	// Add org_id from context if available
+	if orgID, _ := multitenancy.GetOrgID(ctx); orgID != "" {
+		req.OrgId = orgID
+	}
+
+	ctx, cancel := r.withTimeoutIfSet(ctx)
+	defer cancel()
+
+	return r.client.ResolveToolApproval(ctx, req)
+}
+
 // ensureConnected ensures that the client is connected to the remote service
 func (r *RemoteAgentClient) ensureConnected() error {
 	if r.conn == nil || r.client == nil {
@@ -732,6 +756,18 @@ func convertPbToStreamEvent(resp *pb.RunStreamResponse) interfaces.AgentStreamEv
 				Status:      resp.ToolCall.Status,
 			}
 		}
+	case pb.EventType_EVENT_TYPE_APPROVAL_REQUEST:
+		event.Type = interfaces.AgentEventApprovalRequest
+		if resp.ToolCall != nil {
+			event.ToolCall = &interfaces.ToolCallEvent{
+				ID:          resp.ToolCall.Id,
+				Name:        resp.ToolCall.Name,
+				DisplayName: resp.ToolCall.DisplayName,
+				Internal:    resp.ToolCall.Internal,
+				Arguments:   resp.ToolCall.Arguments,
+				Status:      resp.ToolCall.Status,
+			}
+		}
 	case pb.EventType_EVENT_TYPE_ERROR:
 		event.Type = interfaces.AgentEventError
 	case pb.EventType_EVENT_TYPE_COMPLETE:
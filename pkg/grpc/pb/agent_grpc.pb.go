@@ -27,6 +27,7 @@ const (
 	AgentService_Ready_FullMethodName                 = "/agent.AgentService/Ready"
 	AgentService_GenerateExecutionPlan_FullMethodName = "/agent.AgentService/GenerateExecutionPlan"
 	AgentService_ApproveExecutionPlan_FullMethodName  = "/agent.AgentService/ApproveExecutionPlan"
+	AgentService_ResolveToolApproval_FullMethodName   = "/agent.AgentService/ResolveToolApproval"
 )
 
 // AgentServiceClient is the client API for AgentService service.
@@ -51,6 +52,8 @@ type AgentServiceClient interface {
 	GenerateExecutionPlan(ctx context.Context, in *PlanRequest, opts ...grpc.CallOption) (*PlanResponse, error)
 	// Approve execution plan (if supported)
 	ApproveExecutionPlan(ctx context.Context, in *ApprovalRequest, opts ...grpc.CallOption) (*ApprovalResponse, error)
+	// Resolve a tool call waiting for approval
+	ResolveToolApproval(ctx context.Context, in *ToolApprovalRequest, opts ...grpc.CallOption) (*ToolApprovalResponse, error)
 }
 
 type agentServiceClient struct {
@@ -150,6 +153,16 @@ func (c *agentServiceClient) ApproveExecutionPlan(ctx context.Context, in *Appro
 	return out, nil
 }
 
+func (c *agentServiceClient) ResolveToolApproval(ctx context.Context, in *ToolApprovalRequest, opts ...grpc.CallOption) (*ToolApprovalResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ToolApprovalResponse)
+	err := c.cc.Invoke(ctx, AgentService_ResolveToolApproval_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // AgentServiceServer is the server API for AgentService service.
 // All implementations must embed UnimplementedAgentServiceServer
 // for forward compatibility.
@@ -172,6 +185,8 @@ type AgentServiceServer interface {
 	GenerateExecutionPlan(context.Context, *PlanRequest) (*PlanResponse, error)
 	// Approve execution plan (if supported)
 	ApproveExecutionPlan(context.Context, *ApprovalRequest) (*ApprovalResponse, error)
+	// Resolve a tool call waiting for approval
+	ResolveToolApproval(context.Context, *ToolApprovalRequest) (*ToolApprovalResponse, error)
 	mustEmbedUnimplementedAgentServiceServer()
 }
 
@@ -206,6 +221,9 @@ func (UnimplementedAgentServiceServer) GenerateExecutionPlan(context.Context, *P
 func (UnimplementedAgentServiceServer) ApproveExecutionPlan(context.Context, *ApprovalRequest) (*ApprovalResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method ApproveExecutionPlan not implemented")
 }
+func (UnimplementedAgentServiceServer) ResolveToolApproval(context.Context, *ToolApprovalRequest) (*ToolApprovalResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ResolveToolApproval not implemented")
+}
 func (UnimplementedAgentServiceServer) mustEmbedUnimplementedAgentServiceServer() {}
 func (UnimplementedAgentServiceServer) testEmbeddedByValue()                      {}
 
@@ -364,6 +382,24 @@ func _AgentService_ApproveExecutionPlan_Handler(srv interface{}, ctx context.Con
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AgentService_ResolveToolApproval_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ToolApprovalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).ResolveToolApproval(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentService_ResolveToolApproval_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).ResolveToolApproval(ctx, req.(*ToolApprovalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // AgentService_ServiceDesc is the grpc.ServiceDesc for AgentService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -399,6 +435,10 @@ var AgentService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ApproveExecutionPlan",
 			Handler:    _AgentService_ApproveExecutionPlan_Handler,
 		},
+		{
+			MethodName: "ResolveToolApproval",
+			Handler:    _AgentService_ResolveToolApproval_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
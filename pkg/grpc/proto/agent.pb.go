@@ -25,15 +25,16 @@ const (
 type EventType int32
 
 const (
-	EventType_EVENT_TYPE_UNSPECIFIED   EventType = 0
-	EventType_EVENT_TYPE_MESSAGE_START EventType = 1
-	EventType_EVENT_TYPE_CONTENT       EventType = 2
-	EventType_EVENT_TYPE_THINKING      EventType = 3
-	EventType_EVENT_TYPE_TOOL_CALL     EventType = 4
-	EventType_EVENT_TYPE_TOOL_RESULT   EventType = 5
-	EventType_EVENT_TYPE_ERROR         EventType = 6
-	EventType_EVENT_TYPE_COMPLETE      EventType = 7
-	EventType_EVENT_TYPE_MESSAGE_STOP  EventType = 8
+	EventType_EVENT_TYPE_UNSPECIFIED      EventType = 0
+	EventType_EVENT_TYPE_MESSAGE_START    EventType = 1
+	EventType_EVENT_TYPE_CONTENT          EventType = 2
+	EventType_EVENT_TYPE_THINKING         EventType = 3
+	EventType_EVENT_TYPE_TOOL_CALL        EventType = 4
+	EventType_EVENT_TYPE_TOOL_RESULT      EventType = 5
+	EventType_EVENT_TYPE_ERROR            EventType = 6
+	EventType_EVENT_TYPE_COMPLETE         EventType = 7
+	EventType_EVENT_TYPE_MESSAGE_STOP     EventType = 8
+	EventType_EVENT_TYPE_APPROVAL_REQUEST EventType = 9
 )
 
 // Enum value maps for EventType.
@@ -48,17 +49,19 @@ var (
 		6: "EVENT_TYPE_ERROR",
 		7: "EVENT_TYPE_COMPLETE",
 		8: "EVENT_TYPE_MESSAGE_STOP",
+		9: "EVENT_TYPE_APPROVAL_REQUEST",
 	}
 	EventType_value = map[string]int32{
-		"EVENT_TYPE_UNSPECIFIED":   0,
-		"EVENT_TYPE_MESSAGE_START": 1,
-		"EVENT_TYPE_CONTENT":       2,
-		"EVENT_TYPE_THINKING":      3,
-		"EVENT_TYPE_TOOL_CALL":     4,
-		"EVENT_TYPE_TOOL_RESULT":   5,
-		"EVENT_TYPE_ERROR":         6,
-		"EVENT_TYPE_COMPLETE":      7,
-		"EVENT_TYPE_MESSAGE_STOP":  8,
+		"EVENT_TYPE_UNSPECIFIED":      0,
+		"EVENT_TYPE_MESSAGE_START":    1,
+		"EVENT_TYPE_CONTENT":          2,
+		"EVENT_TYPE_THINKING":         3,
+		"EVENT_TYPE_TOOL_CALL":        4,
+		"EVENT_TYPE_TOOL_RESULT":      5,
+		"EVENT_TYPE_ERROR":            6,
+		"EVENT_TYPE_COMPLETE":         7,
+		"EVENT_TYPE_MESSAGE_STOP":     8,
+		"EVENT_TYPE_APPROVAL_REQUEST": 9,
 	}
 )
 
@@ -1203,6 +1206,144 @@ func (x *ApprovalResponse) GetError() string {
 	return ""
 }
 
+// ToolApprovalRequest contains the decision on a tool call waiting for approval
+type ToolApprovalRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ApprovalId    string                 `protobuf:"bytes,1,opt,name=approval_id,json=approvalId,proto3" json:"approval_id,omitempty"`
+	Action        string                 `protobuf:"bytes,2,opt,name=action,proto3" json:"action,omitempty"`       // "approve", "deny" or "edit"
+	Arguments     string                 `protobuf:"bytes,3,opt,name=arguments,proto3" json:"arguments,omitempty"` // JSON arguments replacing those of the call for "edit"
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	OrgId         string                 `protobuf:"bytes,5,opt,name=org_id,json=orgId,proto3" json:"org_id,omitempty"` // organization of the caller, which must own the request
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolApprovalRequest) Reset() {
+	*x = ToolApprovalRequest{}
+	mi := &file_pkg_grpc_proto_agent_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolApprovalRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolApprovalRequest) ProtoMessage() {}
+
+func (x *ToolApprovalRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_proto_agent_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolApprovalRequest.ProtoReflect.Descriptor instead.
+func (*ToolApprovalRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_proto_agent_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ToolApprovalRequest) GetApprovalId() string {
+	if x != nil {
+		return x.ApprovalId
+	}
+	return ""
+}
+
+func (x *ToolApprovalRequest) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *ToolApprovalRequest) GetArguments() string {
+	if x != nil {
+		return x.Arguments
+	}
+	return ""
+}
+
+func (x *ToolApprovalRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *ToolApprovalRequest) GetOrgId() string {
+	if x != nil {
+		return x.OrgId
+	}
+	return ""
+}
+
+// ToolApprovalResponse contains the output of the run resumed by the decision
+type ToolApprovalResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Resumed       bool                   `protobuf:"varint,1,opt,name=resumed,proto3" json:"resumed,omitempty"` // false when a waiting run applies the decision
+	Output        string                 `protobuf:"bytes,2,opt,name=output,proto3" json:"output,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolApprovalResponse) Reset() {
+	*x = ToolApprovalResponse{}
+	mi := &file_pkg_grpc_proto_agent_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolApprovalResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolApprovalResponse) ProtoMessage() {}
+
+func (x *ToolApprovalResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_proto_agent_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolApprovalResponse.ProtoReflect.Descriptor instead.
+func (*ToolApprovalResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_proto_agent_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ToolApprovalResponse) GetResumed() bool {
+	if x != nil {
+		return x.Resumed
+	}
+	return false
+}
+
+func (x *ToolApprovalResponse) GetOutput() string {
+	if x != nil {
+		return x.Output
+	}
+	return ""
+}
+
+func (x *ToolApprovalResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
 var File_pkg_grpc_proto_agent_proto protoreflect.FileDescriptor
 
 const file_pkg_grpc_proto_agent_proto_rawDesc = "" +
@@ -1308,7 +1449,18 @@ const file_pkg_grpc_proto_agent_proto_rawDesc = "" +
 	"\rmodifications\x18\x03 \x01(\tR\rmodifications\"@\n" +
 	"\x10ApprovalResponse\x12\x16\n" +
 	"\x06result\x18\x01 \x01(\tR\x06result\x12\x14\n" +
-	"\x05error\x18\x02 \x01(\tR\x05error*\xf8\x01\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"\x9b\x01\n" +
+	"\x13ToolApprovalRequest\x12\x1f\n" +
+	"\vapproval_id\x18\x01 \x01(\tR\n" +
+	"approvalId\x12\x16\n" +
+	"\x06action\x18\x02 \x01(\tR\x06action\x12\x1c\n" +
+	"\targuments\x18\x03 \x01(\tR\targuments\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\x12\x15\n" +
+	"\x06org_id\x18\x05 \x01(\tR\x05orgId\"^\n" +
+	"\x14ToolApprovalResponse\x12\x18\n" +
+	"\aresumed\x18\x01 \x01(\bR\aresumed\x12\x16\n" +
+	"\x06output\x18\x02 \x01(\tR\x06output\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error*\x99\x02\n" +
 	"\tEventType\x12\x1a\n" +
 	"\x16EVENT_TYPE_UNSPECIFIED\x10\x00\x12\x1c\n" +
 	"\x18EVENT_TYPE_MESSAGE_START\x10\x01\x12\x16\n" +
@@ -1318,7 +1470,8 @@ const file_pkg_grpc_proto_agent_proto_rawDesc = "" +
 	"\x16EVENT_TYPE_TOOL_RESULT\x10\x05\x12\x14\n" +
 	"\x10EVENT_TYPE_ERROR\x10\x06\x12\x17\n" +
 	"\x13EVENT_TYPE_COMPLETE\x10\a\x12\x1b\n" +
-	"\x17EVENT_TYPE_MESSAGE_STOP\x10\b2\x82\x04\n" +
+	"\x17EVENT_TYPE_MESSAGE_STOP\x10\b\x12\x1f\n" +
+	"\x1bEVENT_TYPE_APPROVAL_REQUEST\x10\t2\xd2\x04\n" +
 	"\fAgentService\x12,\n" +
 	"\x03Run\x12\x11.agent.RunRequest\x1a\x12.agent.RunResponse\x12:\n" +
 	"\tRunStream\x12\x11.agent.RunRequest\x1a\x18.agent.RunStreamResponse0\x01\x12>\n" +
@@ -1327,7 +1480,8 @@ const file_pkg_grpc_proto_agent_proto_rawDesc = "" +
 	"\x06Health\x12\x14.agent.HealthRequest\x1a\x15.agent.HealthResponse\x12:\n" +
 	"\x05Ready\x12\x17.agent.ReadinessRequest\x1a\x18.agent.ReadinessResponse\x12@\n" +
 	"\x15GenerateExecutionPlan\x12\x12.agent.PlanRequest\x1a\x13.agent.PlanResponse\x12G\n" +
-	"\x14ApproveExecutionPlan\x12\x16.agent.ApprovalRequest\x1a\x17.agent.ApprovalResponseB+Z)github.com/tagus/agent-sdk-go/pkg/grpc/pbb\x06proto3"
+	"\x14ApproveExecutionPlan\x12\x16.agent.ApprovalRequest\x1a\x17.agent.ApprovalResponse\x12N\n" +
+	"\x13ResolveToolApproval\x12\x1a.agent.ToolApprovalRequest\x1a\x1b.agent.ToolApprovalResponseB+Z)github.com/tagus/agent-sdk-go/pkg/grpc/pbb\x06proto3"
 
 var (
 	file_pkg_grpc_proto_agent_proto_rawDescOnce sync.Once
@@ -1342,7 +1496,7 @@ func file_pkg_grpc_proto_agent_proto_rawDescGZIP() []byte {
 }
 
 var file_pkg_grpc_proto_agent_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_pkg_grpc_proto_agent_proto_msgTypes = make([]protoimpl.MessageInfo, 23)
+var file_pkg_grpc_proto_agent_proto_msgTypes = make([]protoimpl.MessageInfo, 25)
 var file_pkg_grpc_proto_agent_proto_goTypes = []any{
 	(EventType)(0),               // 0: agent.EventType
 	(HealthResponse_Status)(0),   // 1: agent.HealthResponse.Status
@@ -1363,24 +1517,26 @@ var file_pkg_grpc_proto_agent_proto_goTypes = []any{
 	(*PlanStep)(nil),             // 16: agent.PlanStep
 	(*ApprovalRequest)(nil),      // 17: agent.ApprovalRequest
 	(*ApprovalResponse)(nil),     // 18: agent.ApprovalResponse
-	nil,                          // 19: agent.RunRequest.ContextEntry
-	nil,                          // 20: agent.RunResponse.MetadataEntry
-	nil,                          // 21: agent.RunStreamResponse.MetadataEntry
-	nil,                          // 22: agent.MetadataResponse.PropertiesEntry
-	nil,                          // 23: agent.PlanRequest.ContextEntry
-	nil,                          // 24: agent.PlanStep.ParametersEntry
+	(*ToolApprovalRequest)(nil),  // 19: agent.ToolApprovalRequest
+	(*ToolApprovalResponse)(nil), // 20: agent.ToolApprovalResponse
+	nil,                          // 21: agent.RunRequest.ContextEntry
+	nil,                          // 22: agent.RunResponse.MetadataEntry
+	nil,                          // 23: agent.RunStreamResponse.MetadataEntry
+	nil,                          // 24: agent.MetadataResponse.PropertiesEntry
+	nil,                          // 25: agent.PlanRequest.ContextEntry
+	nil,                          // 26: agent.PlanStep.ParametersEntry
 }
 var file_pkg_grpc_proto_agent_proto_depIdxs = []int32{
-	19, // 0: agent.RunRequest.context:type_name -> agent.RunRequest.ContextEntry
-	20, // 1: agent.RunResponse.metadata:type_name -> agent.RunResponse.MetadataEntry
+	21, // 0: agent.RunRequest.context:type_name -> agent.RunRequest.ContextEntry
+	22, // 1: agent.RunResponse.metadata:type_name -> agent.RunResponse.MetadataEntry
 	0,  // 2: agent.RunStreamResponse.event_type:type_name -> agent.EventType
 	5,  // 3: agent.RunStreamResponse.tool_call:type_name -> agent.ToolCall
-	21, // 4: agent.RunStreamResponse.metadata:type_name -> agent.RunStreamResponse.MetadataEntry
-	22, // 5: agent.MetadataResponse.properties:type_name -> agent.MetadataResponse.PropertiesEntry
+	23, // 4: agent.RunStreamResponse.metadata:type_name -> agent.RunStreamResponse.MetadataEntry
+	24, // 5: agent.MetadataResponse.properties:type_name -> agent.MetadataResponse.PropertiesEntry
 	1,  // 6: agent.HealthResponse.status:type_name -> agent.HealthResponse.Status
-	23, // 7: agent.PlanRequest.context:type_name -> agent.PlanRequest.ContextEntry
+	25, // 7: agent.PlanRequest.context:type_name -> agent.PlanRequest.ContextEntry
 	16, // 8: agent.PlanResponse.steps:type_name -> agent.PlanStep
-	24, // 9: agent.PlanStep.parameters:type_name -> agent.PlanStep.ParametersEntry
+	26, // 9: agent.PlanStep.parameters:type_name -> agent.PlanStep.ParametersEntry
 	2,  // 10: agent.AgentService.Run:input_type -> agent.RunRequest
 	2,  // 11: agent.AgentService.RunStream:input_type -> agent.RunRequest
 	6,  // 12: agent.AgentService.GetMetadata:input_type -> agent.MetadataRequest
@@ -1389,16 +1545,18 @@ var file_pkg_grpc_proto_agent_proto_depIdxs = []int32{
 	12, // 15: agent.AgentService.Ready:input_type -> agent.ReadinessRequest
 	14, // 16: agent.AgentService.GenerateExecutionPlan:input_type -> agent.PlanRequest
 	17, // 17: agent.AgentService.ApproveExecutionPlan:input_type -> agent.ApprovalRequest
-	3,  // 18: agent.AgentService.Run:output_type -> agent.RunResponse
-	4,  // 19: agent.AgentService.RunStream:output_type -> agent.RunStreamResponse
-	7,  // 20: agent.AgentService.GetMetadata:output_type -> agent.MetadataResponse
-	9,  // 21: agent.AgentService.GetCapabilities:output_type -> agent.CapabilitiesResponse
-	11, // 22: agent.AgentService.Health:output_type -> agent.HealthResponse
-	13, // 23: agent.AgentService.Ready:output_type -> agent.ReadinessResponse
-	15, // 24: agent.AgentService.GenerateExecutionPlan:output_type -> agent.PlanResponse
-	18, // 25: agent.AgentService.ApproveExecutionPlan:output_type -> agent.ApprovalResponse
-	18, // [18:26] is the sub-list for method output_type
-	10, // [10:18] is the sub-list for method input_type
+	19, // 18: agent.AgentService.ResolveToolApproval:input_type -> agent.ToolApprovalRequest
+	3,  // 19: agent.AgentService.Run:output_type -> agent.RunResponse
+	4,  // 20: agent.AgentService.RunStream:output_type -> agent.RunStreamResponse
+	7,  // 21: agent.AgentService.GetMetadata:output_type -> agent.MetadataResponse
+	9,  // 22: agent.AgentService.GetCapabilities:output_type -> agent.CapabilitiesResponse
+	11, // 23: agent.AgentService.Health:output_type -> agent.HealthResponse
+	13, // 24: agent.AgentService.Ready:output_type -> agent.ReadinessResponse
+	15, // 25: agent.AgentService.GenerateExecutionPlan:output_type -> agent.PlanResponse
+	18, // 26: agent.AgentService.ApproveExecutionPlan:output_type -> agent.ApprovalResponse
+	20, // 27: agent.AgentService.ResolveToolApproval:output_type -> agent.ToolApprovalResponse
+	19, // [19:28] is the sub-list for method output_type
+	10, // [10:19] is the sub-list for method input_type
 	10, // [10:10] is the sub-list for extension type_name
 	10, // [10:10] is the sub-list for extension extendee
 	0,  // [0:10] is the sub-list for field type_name
@@ -1415,7 +1573,7 @@ func file_pkg_grpc_proto_agent_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_grpc_proto_agent_proto_rawDesc), len(file_pkg_grpc_proto_agent_proto_rawDesc)),
 			NumEnums:      2,
-			NumMessages:   23,
+			NumMessages:   25,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -15,6 +15,7 @@ import (
 	"google.golang.org/grpc/status"
 
 	"github.com/tagus/agent-sdk-go/pkg/agent"
+	"github.com/tagus/agent-sdk-go/pkg/approval"
 	"github.com/tagus/agent-sdk-go/pkg/grpc/pb"
 	"github.com/tagus/agent-sdk-go/pkg/interfaces"
 	"github.com/tagus/agent-sdk-go/pkg/memory"
@@ -161,6 +162,12 @@ func (s *AgentServer) RunStream(req *pb.RunRequest, stream pb.AgentService_RunSt
 
 	// Stream events to client
 	for event := range eventChan {
+		// Partial objects have no protobuf event type; remote clients see the
+		// same JSON in the content events
+		if event.Type == interfaces.AgentEventPartialObject {
+			continue
+		}
+
 		response := &pb.RunStreamResponse{
 			Chunk:     event.Content,
 			EventType: s.convertEventType(event.Type),
@@ -242,6 +249,8 @@ func (s *AgentServer) convertEventType(eventType interfaces.AgentEventType) pb.E
 		return pb.EventType_EVENT_TYPE_ERROR
 	case interfaces.AgentEventComplete:
 		return pb.EventType_EVENT_TYPE_COMPLETE
+	case interfaces.AgentEventApprovalRequest:
+		return pb.EventType_EVENT_TYPE_APPROVAL_REQUEST
 	default:
 		return pb.EventType_EVENT_TYPE_CONTENT
 	}
@@ -444,6 +453,72 @@ func (s *AgentServer) ApproveExecutionPlan(ctx context.Context, req *pb.Approval
 	}, nil
 }
 
+// ResolveToolApproval records the decision on a tool call waiting for
+// approval, and resumes its run if no run is waiting for the decision
+func (s *AgentServer) ResolveToolApproval(ctx context.Context, req *pb.ToolApprovalRequest) (*pb.ToolApprovalResponse, error) {
+	if req.ApprovalId == "" {
+		return nil, status.Error(codes.InvalidArgument, "approval_id cannot be empty")
+	}
+
+	// Extract JWT token from gRPC metadata so that the resumed tool call is authorized
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if auths := md.Get("authorization"); len(auths) > 0 {
+			auth := auths[0]
+			if strings.HasPrefix(auth, "Bearer ") {
+				jwtToken := strings.TrimPrefix(auth, "Bearer ")
+				ctx = context.WithValue(ctx, JWTTokenKey, jwtToken)
+			}
+		}
+	}
+
+	// Add org_id to context if provided
+	if req.OrgId != "" {
+		ctx = multitenancy.WithOrgID(ctx, req.OrgId)
+	}
+
+	// The requests of other organizations are reported as not found, so
+	// that a caller cannot decide on the tool calls of another organization
+	if err := s.checkApprovalOrg(ctx, req.ApprovalId); err != nil {
+		return &pb.ToolApprovalResponse{
+			Error: fmt.Sprintf("Failed to resolve approval: %v", err),
+		}, nil
+	}
+
+	output, resumed, err := s.agent.ResolveApproval(ctx, req.ApprovalId, approval.Decision{
+		Action:    approval.Action(req.Action),
+		Arguments: req.Arguments,
+		Reason:    req.Reason,
+	})
+	if err != nil {
+		return &pb.ToolApprovalResponse{
+			Error: fmt.Sprintf("Failed to resolve approval: %v", err),
+		}, nil
+	}
+
+	return &pb.ToolApprovalResponse{
+		Resumed: resumed,
+		Output:  output,
+	}, nil
+}
+
+// checkApprovalOrg returns approval.ErrNotFound if an approval request does
+// not belong to the organization of the caller, set in the context
+func (s *AgentServer) checkApprovalOrg(ctx context.Context, id string) error {
+	approver := s.agent.GetApprover()
+	if approver == nil {
+		return nil
+	}
+	approvalReq, err := approver.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	orgID, _ := multitenancy.GetOrgID(ctx)
+	if approvalReq.OrgID != orgID {
+		return approval.ErrNotFound
+	}
+	return nil
+}
+
 // Start starts the gRPC server on the specified port
 func (s *AgentServer) Start(port int) error {
 	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
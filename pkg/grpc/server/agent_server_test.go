@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tagus/agent-sdk-go/pkg/agent"
+	"github.com/tagus/agent-sdk-go/pkg/approval"
+	"github.com/tagus/agent-sdk-go/pkg/grpc/pb"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// mockLLM answers every prompt with the same response
+type mockLLM struct{}
+
+func (m *mockLLM) Generate(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (string, error) {
+	return "ok", nil
+}
+
+func (m *mockLLM) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (string, error) {
+	return "ok", nil
+}
+
+func (m *mockLLM) GenerateDetailed(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (*interfaces.LLMResponse, error) {
+	return &interfaces.LLMResponse{Content: "ok"}, nil
+}
+
+func (m *mockLLM) GenerateWithToolsDetailed(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (*interfaces.LLMResponse, error) {
+	return &interfaces.LLMResponse{Content: "ok"}, nil
+}
+
+func (m *mockLLM) Name() string {
+	return "mock-llm"
+}
+
+func (m *mockLLM) SupportsStreaming() bool {
+	return false
+}
+
+func TestAgentServer_ResolveToolApprovalOtherOrg(t *testing.T) {
+	store := approval.NewMemoryStore()
+	if err := store.Create(context.Background(), &approval.Request{ID: "req-1", ToolName: "delete", OrgID: "org-a"}, time.Hour); err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	agentInstance, err := agent.NewAgent(
+		agent.WithLLM(&mockLLM{}),
+		agent.WithName("TestAgent"),
+		agent.WithToolApproval(approval.New(approval.WithStore(store))),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+	server := NewAgentServer(agentInstance)
+
+	for _, orgID := range []string{"org-b", ""} {
+		resp, err := server.ResolveToolApproval(context.Background(), &pb.ToolApprovalRequest{
+			ApprovalId: "req-1",
+			Action:     string(approval.ActionApprove),
+			OrgId:      orgID,
+		})
+		if err != nil {
+			t.Fatalf("ResolveToolApproval failed: %v", err)
+		}
+		if !strings.Contains(resp.Error, approval.ErrNotFound.Error()) || resp.Resumed {
+			t.Errorf("Expected the request to be reported as not found for org %q, got %+v", orgID, resp)
+		}
+	}
+
+	stored, err := store.Get(context.Background(), "req-1")
+	if err != nil {
+		t.Fatalf("Failed to get request: %v", err)
+	}
+	if stored.Decision != nil {
+		t.Errorf("Expected the request of org-a to stay pending, got %+v", stored.Decision)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"strings"
+
+	"github.com/tagus/agent-sdk-go/pkg/tokens"
 )
 
 // TokenCounter is an interface for counting tokens in text
@@ -11,7 +13,9 @@ type TokenCounter interface {
 	CountTokens(text string) (int, error)
 }
 
-// SimpleTokenCounter implements a simple token counter
+// SimpleTokenCounter implements a simple token counter that counts words.
+// It underestimates the tokens of most texts, see the tokens package for
+// tokenizer-based counters.
 type SimpleTokenCounter struct{}
 
 // CountTokens counts tokens in text (simple approximation)
@@ -28,10 +32,12 @@ type TokenLimit struct {
 	truncateMode string // "start", "end", or "middle"
 }
 
-// NewTokenLimit creates a new token limit guardrail
+// NewTokenLimit creates a new token limit guardrail. Without a counter,
+// tokens are estimated with tokens.Default; use tokens.ForModel for the
+// tokenizer of a specific model.
 func NewTokenLimit(maxTokens int, counter TokenCounter, action Action, truncateMode string) *TokenLimit {
 	if counter == nil {
-		counter = &SimpleTokenCounter{}
+		counter = tokens.Default()
 	}
 
 	if truncateMode == "" {
@@ -92,23 +98,49 @@ func (t *TokenLimit) Action() Action {
 	return t.action
 }
 
-// truncate truncates text to the maximum token limit
+// truncate truncates text to the maximum token limit, keeping whole words
 func (t *TokenLimit) truncate(text string) (string, error) {
 	words := strings.Fields(text)
 
-	if len(words) <= t.maxTokens {
+	// kept returns the words kept when n words fit
+	kept := func(n int) (head, tail []string) {
+		switch t.truncateMode {
+		case "start":
+			return nil, words[len(words)-n:]
+		case "middle":
+			half := n / 2
+			return words[:n-half], words[len(words)-half:]
+		default:
+			return words[:n], nil
+		}
+	}
+
+	// Find the largest number of words that fit
+	low, high := 0, len(words)
+	for low < high {
+		mid := (low + high + 1) / 2
+		head, tail := kept(mid)
+		count, err := t.counter.CountTokens(strings.Join(append(append([]string{}, head...), tail...), " "))
+		if err != nil {
+			return text, fmt.Errorf("failed to count tokens: %w", err)
+		}
+		if count <= t.maxTokens {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+	if low == len(words) {
 		return text, nil
 	}
 
+	head, tail := kept(low)
 	switch t.truncateMode {
 	case "start":
-		return strings.Join(words[len(words)-t.maxTokens:], " "), nil
+		return strings.Join(tail, " "), nil
 	case "middle":
-		half := t.maxTokens / 2
-		return strings.Join(words[:half], " ") + " ... " + strings.Join(words[len(words)-half:], " "), nil
-	case "end":
-		fallthrough
+		return strings.Join(head, " ") + " ... " + strings.Join(tail, " "), nil
 	default:
-		return strings.Join(words[:t.maxTokens], " ") + " ...", nil
+		return strings.Join(head, " ") + " ...", nil
 	}
 }
@@ -35,6 +35,14 @@ func (m *ToolMiddleware) Parameters() map[string]interfaces.ParameterSpec {
 	return m.tool.Parameters()
 }
 
+// JSONSchema returns the JSON schema of the wrapped tool, if it has one
+func (m *ToolMiddleware) JSONSchema() interfaces.JSONSchema {
+	if tool, ok := m.tool.(interfaces.ToolWithJSONSchema); ok {
+		return tool.JSONSchema()
+	}
+	return nil
+}
+
 // Run executes the tool with the given input
 func (m *ToolMiddleware) Run(ctx context.Context, input string) (string, error) {
 	// Process request through guardrails
@@ -0,0 +1,365 @@
+package ingest
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/tagus/agent-sdk-go/pkg/tokens"
+)
+
+const (
+	// DefaultChunkSize is the default maximum chunk length in characters
+	DefaultChunkSize = 1000
+
+	// DefaultChunkOverlap is the default overlap between consecutive chunks in characters
+	DefaultChunkOverlap = 100
+)
+
+// Chunker splits text into chunks
+type Chunker interface {
+	// Chunk splits text into chunks with byte offsets into text
+	Chunk(text string) []Chunk
+}
+
+// Tokenizer returns the byte spans of the tokens in text
+type Tokenizer func(text string) [][2]int
+
+// WordTokenizer treats every run of non-space characters as a token
+func WordTokenizer(text string) [][2]int {
+	var spans [][2]int
+	start := -1
+	for i, r := range text {
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				spans = append(spans, [2]int{start, i})
+				start = -1
+			}
+		} else if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(text)})
+	}
+	return spans
+}
+
+// BPETokenizer returns the tokens of a BPE encoding of the tokens package
+func BPETokenizer(encoding *tokens.BPE) Tokenizer {
+	return encoding.Spans
+}
+
+// ModelTokenizer returns the tokens of the encoding of a model. Models
+// without a supported encoding, such as Claude and Gemini models, use
+// cl100k_base, which approximates their token counts.
+func ModelTokenizer(model string) Tokenizer {
+	if bpe, ok := tokens.ForModel(model).(*tokens.BPE); ok {
+		return BPETokenizer(bpe)
+	}
+	return defaultTokenizer
+}
+
+// defaultTokenizer counts the tokens of cl100k_base
+func defaultTokenizer(text string) [][2]int {
+	bpe, ok := tokens.GetEncoding(tokens.CL100KBase)
+	if !ok {
+		return WordTokenizer(text)
+	}
+	return bpe.Spans(text)
+}
+
+// TokenChunker splits text into fixed windows of tokens with overlap
+type TokenChunker struct {
+	// Size is the number of tokens per chunk
+	Size int
+
+	// Overlap is the number of tokens shared by consecutive chunks
+	Overlap int
+
+	// Tokenizer splits text into tokens; it defaults to the cl100k_base
+	// encoding. Use ModelTokenizer to count the tokens of the embedding
+	// model, or WordTokenizer for windows of words.
+	Tokenizer Tokenizer
+}
+
+// NewTokenChunker creates a TokenChunker counting cl100k_base tokens
+func NewTokenChunker(size, overlap int) *TokenChunker {
+	return &TokenChunker{Size: size, Overlap: overlap, Tokenizer: defaultTokenizer}
+}
+
+// Chunk implements Chunker. Chunks end on character boundaries, so a chunk
+// ending inside a multi-byte character stops before the token that splits
+// it, and whitespace around chunks is trimmed.
+func (c *TokenChunker) Chunk(text string) []Chunk {
+	tokenize := c.Tokenizer
+	if tokenize == nil {
+		tokenize = defaultTokenizer
+	}
+	size, overlap := normalizeSizes(c.Size, c.Overlap)
+
+	spans := tokenize(text)
+	var chunks []Chunk
+	for i := 0; i < len(spans); {
+		end := i + size
+		if end > len(spans) {
+			end = len(spans)
+		}
+		// Back off to a character boundary, or extend to the next one when
+		// the first token alone splits a character
+		for end > i+1 && !runeBoundary(text, spans[end-1][1]) {
+			end--
+		}
+		for end < len(spans) && !runeBoundary(text, spans[end-1][1]) {
+			end++
+		}
+		if chunk, ok := trimmedChunk(text, spans[i][0], spans[end-1][1]); ok {
+			chunks = append(chunks, chunk)
+		}
+		if end == len(spans) {
+			break
+		}
+
+		next := end - overlap
+		if next <= i {
+			next = i + 1
+		}
+		for next < end && !runeBoundary(text, spans[next][0]) {
+			next++
+		}
+		i = next
+	}
+	return chunks
+}
+
+// runeBoundary reports whether offset is at the start of a character of text
+func runeBoundary(text string, offset int) bool {
+	return offset >= len(text) || utf8.RuneStart(text[offset])
+}
+
+// DefaultSeparators are the separators RecursiveChunker tries in order
+var DefaultSeparators = []string{"\n\n", "\n", ". ", " ", ""}
+
+// RecursiveChunker splits text at the coarsest separator that yields pieces no
+// longer than Size, recursing into oversized pieces with finer separators, and
+// then merges adjacent pieces into chunks of up to Size characters.
+type RecursiveChunker struct {
+	// Size is the maximum chunk length in characters
+	Size int
+
+	// Overlap is the maximum number of characters repeated from the end of the
+	// previous chunk
+	Overlap int
+
+	// Separators are tried in order; the empty string splits between characters
+	Separators []string
+}
+
+// NewRecursiveChunker creates a RecursiveChunker using DefaultSeparators
+func NewRecursiveChunker(size, overlap int) *RecursiveChunker {
+	return &RecursiveChunker{Size: size, Overlap: overlap, Separators: DefaultSeparators}
+}
+
+// Chunk implements Chunker
+func (c *RecursiveChunker) Chunk(text string) []Chunk {
+	return c.chunkRange(text, 0, len(text))
+}
+
+type piece struct {
+	start, end, length int
+}
+
+// chunkRange chunks text[start:end], keeping offsets relative to text
+func (c *RecursiveChunker) chunkRange(text string, start, end int) []Chunk {
+	size, overlap := normalizeSizes(c.Size, c.Overlap)
+	separators := c.Separators
+	if len(separators) == 0 {
+		separators = DefaultSeparators
+	}
+
+	pieces := splitRecursive(text, start, end, size, separators)
+
+	var chunks []Chunk
+	for i := 0; i < len(pieces); {
+		j, length := i, 0
+		for j < len(pieces) && (j == i || length+pieces[j].length <= size) {
+			length += pieces[j].length
+			j++
+		}
+		if chunk, ok := trimmedChunk(text, pieces[i].start, pieces[j-1].end); ok {
+			chunks = append(chunks, chunk)
+		}
+		if j == len(pieces) {
+			break
+		}
+
+		// Start the next chunk with trailing pieces of this one, up to the overlap
+		k, shared := j, 0
+		for k-1 > i && shared+pieces[k-1].length <= overlap {
+			k--
+			shared += pieces[k].length
+		}
+		i = k
+	}
+	return chunks
+}
+
+// splitRecursive splits text[start:end] into pieces of at most size characters.
+// Separators stay attached to the preceding piece so the pieces cover the range.
+func splitRecursive(text string, start, end, size int, separators []string) []piece {
+	length := utf8.RuneCountInString(text[start:end])
+	if length <= size {
+		return []piece{{start: start, end: end, length: length}}
+	}
+
+	for i, separator := range separators {
+		if separator == "" {
+			break
+		}
+		if !strings.Contains(text[start:end], separator) {
+			continue
+		}
+
+		var pieces []piece
+		for pos := start; pos < end; {
+			cut := end
+			if idx := strings.Index(text[pos:end], separator); idx >= 0 {
+				cut = pos + idx + len(separator)
+			}
+			pieces = append(pieces, splitRecursive(text, pos, cut, size, separators[i+1:])...)
+			pos = cut
+		}
+		return pieces
+	}
+
+	// No separator applies, so cut between characters
+	var pieces []piece
+	pos, count := start, 0
+	for i := range text[start:end] {
+		if count == size {
+			pieces = append(pieces, piece{start: pos, end: start + i, length: count})
+			pos, count = start+i, 0
+		}
+		count++
+	}
+	return append(pieces, piece{start: pos, end: end, length: count})
+}
+
+// trimmedChunk returns text[start:end] without surrounding whitespace
+func trimmedChunk(text string, start, end int) (Chunk, bool) {
+	content := text[start:end]
+	trimmedLeft := strings.TrimLeftFunc(content, unicode.IsSpace)
+	start += len(content) - len(trimmedLeft)
+	content = strings.TrimRightFunc(trimmedLeft, unicode.IsSpace)
+	if content == "" {
+		return Chunk{}, false
+	}
+	return Chunk{Content: content, Start: start, End: start + len(content)}, true
+}
+
+// normalizeSizes applies defaults and keeps the overlap smaller than the size
+func normalizeSizes(size, overlap int) (int, int) {
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+	if overlap < 0 {
+		overlap = 0
+	}
+	if overlap >= size {
+		overlap = size / 2
+	}
+	return size, overlap
+}
+
+// MarkdownChunker splits Markdown into one chunk per heading section, recording
+// the heading path in the chunk metadata. Sections longer than MaxSize are
+// split further with a RecursiveChunker. Headings inside fenced code blocks are
+// ignored.
+type MarkdownChunker struct {
+	// MaxSize is the maximum chunk length in characters
+	MaxSize int
+
+	// Overlap is used when splitting oversized sections
+	Overlap int
+}
+
+// NewMarkdownChunker creates a MarkdownChunker
+func NewMarkdownChunker(maxSize, overlap int) *MarkdownChunker {
+	return &MarkdownChunker{MaxSize: maxSize, Overlap: overlap}
+}
+
+var (
+	headingPattern = regexp.MustCompile(`^ {0,3}(#{1,6})[ \t]+(.*?)(?:[ \t]+#+)?[ \t]*$`)
+	fencePattern   = regexp.MustCompile("^ {0,3}(```|~~~)")
+)
+
+// HeadingPathSeparator joins the headings of a heading path
+const HeadingPathSeparator = " > "
+
+// Chunk implements Chunker
+func (c *MarkdownChunker) Chunk(text string) []Chunk {
+	splitter := &RecursiveChunker{Size: c.MaxSize, Overlap: c.Overlap, Separators: DefaultSeparators}
+
+	var (
+		chunks   []Chunk
+		headings []string
+		path     string
+		start    int
+		hasBody  bool
+		fence    string
+	)
+	flush := func(end int) {
+		if !hasBody {
+			return
+		}
+		for _, chunk := range splitter.chunkRange(text, start, end) {
+			if path != "" {
+				chunk.Metadata = map[string]interface{}{MetadataHeadingPath: path}
+			}
+			chunks = append(chunks, chunk)
+		}
+	}
+
+	for pos := 0; pos < len(text); {
+		lineEnd := strings.IndexByte(text[pos:], '\n')
+		next := len(text)
+		if lineEnd >= 0 {
+			next = pos + lineEnd + 1
+		}
+		line := strings.TrimRight(text[pos:next], "\r\n")
+
+		if match := fencePattern.FindStringSubmatch(line); match != nil {
+			if fence == "" {
+				fence = match[1]
+			} else if fence == match[1] {
+				fence = ""
+			}
+			hasBody = true
+		} else if match := headingPattern.FindStringSubmatch(line); match != nil && fence == "" {
+			flush(pos)
+			level := len(match[1])
+			for len(headings) < level {
+				headings = append(headings, "")
+			}
+			headings = append(headings[:level-1], strings.TrimSpace(match[2]))
+			path = joinHeadings(headings)
+			start, hasBody = pos, false
+		} else if strings.TrimSpace(line) != "" {
+			hasBody = true
+		}
+		pos = next
+	}
+	flush(len(text))
+	return chunks
+}
+
+func joinHeadings(headings []string) string {
+	parts := make([]string, 0, len(headings))
+	for _, heading := range headings {
+		if heading != "" {
+			parts = append(parts, heading)
+		}
+	}
+	return strings.Join(parts, HeadingPathSeparator)
+}
@@ -0,0 +1,145 @@
+package ingest
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tagus/agent-sdk-go/pkg/tokens"
+)
+
+// assertOffsets checks that every chunk is the text at its offsets
+func assertOffsets(t *testing.T, text string, chunks []Chunk) {
+	t.Helper()
+	for i, chunk := range chunks {
+		assert.Equal(t, text[chunk.Start:chunk.End], chunk.Content, "chunk %d offsets", i)
+	}
+}
+
+func chunkContents(chunks []Chunk) []string {
+	contents := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		contents[i] = chunk.Content
+	}
+	return contents
+}
+
+func TestTokenChunker(t *testing.T) {
+	// cl100k_base encodes every word with its leading space as one token
+	text := "one two three\nfour five six seven"
+	chunks := NewTokenChunker(3, 1).Chunk(text)
+	assertOffsets(t, text, chunks)
+	assert.Equal(t, []string{"one two three", "three\nfour", "four five six", "six seven"}, chunkContents(chunks))
+
+	assert.Empty(t, NewTokenChunker(3, 1).Chunk("   "))
+}
+
+func TestTokenChunker_CountsModelTokens(t *testing.T) {
+	bpe, ok := tokens.GetEncoding(tokens.CL100KBase)
+	require.True(t, ok)
+
+	text := strings.Repeat("Tokenization splits unfamiliar words into several tokens. ", 20)
+	chunks := NewTokenChunker(16, 4).Chunk(text)
+	require.Greater(t, len(chunks), 1)
+	assertOffsets(t, text, chunks)
+	spans := bpe.Spans(text)
+	for i, chunk := range chunks {
+		count := 0
+		for _, span := range spans {
+			if span[1] > chunk.Start && span[0] < chunk.End {
+				count++
+			}
+		}
+		if i < len(chunks)-1 {
+			assert.Equal(t, 16, count, "chunk %d", i)
+		} else {
+			assert.LessOrEqual(t, count, 16, "chunk %d", i)
+		}
+	}
+	assert.Equal(t, len(strings.TrimSpace(text)), chunks[len(chunks)-1].End)
+}
+
+func TestTokenChunker_CharacterBoundaries(t *testing.T) {
+	// Emoji are encoded as several tokens that split their bytes
+	text := strings.Repeat("🙂", 10)
+	chunks := NewTokenChunker(1, 0).Chunk(text)
+	require.Len(t, chunks, 10)
+	assertOffsets(t, text, chunks)
+	for _, chunk := range chunks {
+		assert.True(t, utf8.ValidString(chunk.Content))
+	}
+}
+
+func TestTokenChunker_Tokenizers(t *testing.T) {
+	text := "one two  three\nfour five six seven"
+	chunks := (&TokenChunker{Size: 3, Overlap: 1, Tokenizer: WordTokenizer}).Chunk(text)
+	assertOffsets(t, text, chunks)
+	assert.Equal(t, []string{"one two  three", "three\nfour five", "five six seven"}, chunkContents(chunks))
+
+	// gpt-4o uses o200k_base, and unsupported models fall back to cl100k_base
+	o200k, ok := tokens.GetEncoding(tokens.O200KBase)
+	require.True(t, ok)
+	text = "안녕하세요 세계, tokenization"
+	assert.Equal(t, o200k.Spans(text), ModelTokenizer("gpt-4o")(text))
+	assert.Equal(t, defaultTokenizer(text), ModelTokenizer("claude-3-5-sonnet")(text))
+}
+
+func TestRecursiveChunker(t *testing.T) {
+	paragraph := strings.Repeat("The quick brown fox jumps. ", 6)
+	text := paragraph + "\n\n" + paragraph + "\n\n" + strings.Repeat("x", 130)
+
+	chunks := NewRecursiveChunker(100, 30).Chunk(text)
+	require.Greater(t, len(chunks), 4)
+	assertOffsets(t, text, chunks)
+	for i, chunk := range chunks {
+		assert.LessOrEqual(t, utf8.RuneCountInString(chunk.Content), 100, "chunk %d", i)
+		if i > 0 {
+			assert.Less(t, chunks[i-1].Start, chunk.Start)
+		}
+	}
+
+	// Sentences are preferred over arbitrary cut points
+	assert.True(t, strings.HasSuffix(chunks[0].Content, "jumps."))
+	// Consecutive chunks share up to the overlap
+	assert.Less(t, chunks[1].Start, chunks[0].End)
+	// Text without separators is cut between characters
+	last := chunks[len(chunks)-1]
+	assert.Equal(t, strings.Repeat("x", 30), last.Content)
+
+	assert.Equal(t, []Chunk{{Content: "short", Start: 1, End: 6}}, NewRecursiveChunker(100, 10).Chunk(" short "))
+}
+
+func TestMarkdownChunker(t *testing.T) {
+	text := "Preamble.\n\n" +
+		"# Guide\n\nIntro.\n\n" +
+		"## Install\n\n```sh\n# not a heading\ngo get example.com/x\n```\n\n" +
+		"## Configure ##\n\n" +
+		"### Options\n\n" + strings.Repeat("Set the option value. ", 6) + "\n\n" +
+		"# Reference\n\nAPI.\n"
+
+	chunks := NewMarkdownChunker(100, 0).Chunk(text)
+	assertOffsets(t, text, chunks)
+
+	paths := make([]interface{}, len(chunks))
+	for i, chunk := range chunks {
+		paths[i] = chunk.Metadata[MetadataHeadingPath]
+	}
+	assert.Equal(t, []interface{}{
+		nil,
+		"Guide",
+		"Guide > Install",
+		"Guide > Configure > Options",
+		"Guide > Configure > Options",
+		"Reference",
+	}, paths)
+
+	assert.Equal(t, "Preamble.", chunks[0].Content)
+	assert.Contains(t, chunks[2].Content, "# not a heading")
+	assert.True(t, strings.HasPrefix(chunks[3].Content, "### Options"))
+	for _, chunk := range chunks {
+		assert.LessOrEqual(t, utf8.RuneCountInString(chunk.Content), 100)
+	}
+}
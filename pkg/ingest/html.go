@@ -0,0 +1,204 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// HTMLLoader extracts the readable text of an HTML document. Headings become
+// Markdown ATX headings and list items become "- " lines, so the result can be
+// split with MarkdownChunker. Scripts, styles and other non-content elements are
+// dropped.
+type HTMLLoader struct{}
+
+var blankLinesPattern = regexp.MustCompile(`\n{3,}`)
+
+// Load implements Loader
+func (l *HTMLLoader) Load(ctx context.Context, r io.Reader, path string) ([]Source, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	w := &htmlTextWriter{}
+	w.walk(doc)
+
+	content := blankLinesPattern.ReplaceAllString(string(w.text), "\n\n")
+	source := newSource(path, path, "html", strings.TrimSpace(content))
+	if title := strings.TrimSpace(w.title); title != "" {
+		source.Metadata[MetadataTitle] = collapseSpaces(title)
+	}
+	if w.description != "" {
+		source.Metadata["description"] = w.description
+	}
+	return []Source{source}, nil
+}
+
+// htmlTextWriter accumulates the text of an HTML tree
+type htmlTextWriter struct {
+	text        []byte
+	title       string
+	description string
+	pre         int
+}
+
+func (w *htmlTextWriter) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.writeText(n.Data)
+		return
+	case html.ElementNode:
+		switch n.DataAtom {
+		case atom.Script, atom.Style, atom.Noscript, atom.Template, atom.Svg, atom.Iframe, atom.Head:
+			if n.DataAtom == atom.Head {
+				w.readHead(n)
+			}
+			return
+		case atom.Br:
+			w.text = append(w.text, '\n')
+			return
+		case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+			level := int(n.Data[1] - '0')
+			heading := collapseSpaces(nodeText(n))
+			if heading != "" {
+				w.newBlock()
+				w.text = append(w.text, strings.Repeat("#", level)+" "+heading...)
+				w.newBlock()
+			}
+			return
+		case atom.Li:
+			w.newLine()
+			w.text = append(w.text, "- "...)
+		case atom.Td, atom.Th:
+			defer w.space()
+		case atom.Pre:
+			w.pre++
+			defer func() { w.pre-- }()
+			w.newBlock()
+		default:
+			if isBlockElement(n.DataAtom) {
+				w.newBlock()
+				defer w.newBlock()
+			}
+		}
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.walk(c)
+	}
+}
+
+// readHead collects the title and meta description
+func (w *htmlTextWriter) readHead(head *html.Node) {
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Title:
+				w.title = nodeText(n)
+			case atom.Meta:
+				if strings.EqualFold(attr(n, "name"), "description") {
+					w.description = strings.TrimSpace(attr(n, "content"))
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(head)
+}
+
+func (w *htmlTextWriter) writeText(data string) {
+	if w.pre > 0 {
+		w.text = append(w.text, data...)
+		return
+	}
+	text := collapseSpaces(data)
+	if text == "" {
+		if data != "" {
+			w.space()
+		}
+		return
+	}
+	if isSpace(data[0]) {
+		w.space()
+	}
+	w.text = append(w.text, text...)
+	if isSpace(data[len(data)-1]) {
+		w.space()
+	}
+}
+
+// space writes a single space unless the text already ends in whitespace
+func (w *htmlTextWriter) space() {
+	if n := len(w.text); n > 0 && w.text[n-1] != ' ' && w.text[n-1] != '\n' {
+		w.text = append(w.text, ' ')
+	}
+}
+
+func (w *htmlTextWriter) newLine() {
+	for len(w.text) > 0 && w.text[len(w.text)-1] == ' ' {
+		w.text = w.text[:len(w.text)-1]
+	}
+	if n := len(w.text); n > 0 && w.text[n-1] != '\n' {
+		w.text = append(w.text, '\n')
+	}
+}
+
+func (w *htmlTextWriter) newBlock() {
+	w.newLine()
+	if n := len(w.text); n > 0 && (n < 2 || w.text[n-2] != '\n') {
+		w.text = append(w.text, '\n')
+	}
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\n' || b == '\t' || b == '\r'
+}
+
+func isBlockElement(a atom.Atom) bool {
+	switch a {
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Header, atom.Footer,
+		atom.Nav, atom.Aside, atom.Blockquote, atom.Ul, atom.Ol, atom.Dl, atom.Dt, atom.Dd,
+		atom.Table, atom.Tr, atom.Figure, atom.Figcaption, atom.Hr, atom.Form, atom.Fieldset,
+		atom.Address, atom.Details, atom.Summary:
+		return true
+	}
+	return false
+}
+
+// nodeText returns the concatenated text of n and its descendants
+func nodeText(n *html.Node) string {
+	var b strings.Builder
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(n)
+	return b.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,202 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// IndexEntry records what was stored for a source
+type IndexEntry struct {
+	// SourceID identifies the source
+	SourceID string `json:"source_id"`
+
+	// Path is the file the source was loaded from
+	Path string `json:"path"`
+
+	// SourceHash is the SHA-256 of the source content when it was indexed
+	SourceHash string `json:"source_hash"`
+
+	// Chunks are the documents stored for the source
+	Chunks []IndexedChunk `json:"chunks"`
+
+	// UpdatedAt is when the source was last indexed
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IndexedChunk records a stored chunk and its position in the source
+type IndexedChunk struct {
+	ID    string `json:"id"`
+	Index int    `json:"index"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// Index tracks indexed sources so unchanged sources can be skipped and stale
+// chunks removed when a source changes
+type Index interface {
+	// Get returns the entry for a source, or nil if the source is not indexed
+	Get(ctx context.Context, sourceID string) (*IndexEntry, error)
+
+	// Put stores the entry for a source
+	Put(ctx context.Context, entry *IndexEntry) error
+
+	// Delete removes the entry for a source
+	Delete(ctx context.Context, sourceID string) error
+
+	// List returns the entries for all sources loaded from path
+	List(ctx context.Context, path string) ([]*IndexEntry, error)
+}
+
+// MemoryIndex is an Index held in memory
+type MemoryIndex struct {
+	mu      sync.RWMutex
+	entries map[string]*IndexEntry
+}
+
+// NewMemoryIndex creates an empty in-memory index
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{entries: map[string]*IndexEntry{}}
+}
+
+// Get implements Index
+func (i *MemoryIndex) Get(ctx context.Context, sourceID string) (*IndexEntry, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	entry, ok := i.entries[sourceID]
+	if !ok {
+		return nil, nil
+	}
+	return copyEntry(entry), nil
+}
+
+// Put implements Index
+func (i *MemoryIndex) Put(ctx context.Context, entry *IndexEntry) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.entries[entry.SourceID] = copyEntry(entry)
+	return nil
+}
+
+// Delete implements Index
+func (i *MemoryIndex) Delete(ctx context.Context, sourceID string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	delete(i.entries, sourceID)
+	return nil
+}
+
+// List implements Index
+func (i *MemoryIndex) List(ctx context.Context, path string) ([]*IndexEntry, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	var entries []*IndexEntry
+	for _, entry := range i.entries {
+		if entry.Path == path {
+			entries = append(entries, copyEntry(entry))
+		}
+	}
+	sort.Slice(entries, func(a, b int) bool { return entries[a].SourceID < entries[b].SourceID })
+	return entries, nil
+}
+
+func copyEntry(entry *IndexEntry) *IndexEntry {
+	copied := *entry
+	copied.Chunks = append([]IndexedChunk(nil), entry.Chunks...)
+	return &copied
+}
+
+// FileIndex is a MemoryIndex persisted to a JSON file after every change, so
+// incremental re-indexing survives restarts
+type FileIndex struct {
+	*MemoryIndex
+	path   string
+	saveMu sync.Mutex
+}
+
+// NewFileIndex loads the index stored at path, starting empty if the file does not exist
+func NewFileIndex(path string) (*FileIndex, error) {
+	index := &FileIndex{MemoryIndex: NewMemoryIndex(), path: path}
+
+	data, err := os.ReadFile(path) // #nosec G304 - Path is provided by the caller
+	if errors.Is(err, os.ErrNotExist) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+
+	var entries []*IndexEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse index: %w", err)
+	}
+	for _, entry := range entries {
+		index.entries[entry.SourceID] = entry
+	}
+	return index, nil
+}
+
+// Put implements Index
+func (i *FileIndex) Put(ctx context.Context, entry *IndexEntry) error {
+	i.saveMu.Lock()
+	defer i.saveMu.Unlock()
+	if err := i.MemoryIndex.Put(ctx, entry); err != nil {
+		return err
+	}
+	return i.save()
+}
+
+// Delete implements Index
+func (i *FileIndex) Delete(ctx context.Context, sourceID string) error {
+	i.saveMu.Lock()
+	defer i.saveMu.Unlock()
+	if err := i.MemoryIndex.Delete(ctx, sourceID); err != nil {
+		return err
+	}
+	return i.save()
+}
+
+// save writes the index atomically via a temporary file
+func (i *FileIndex) save() error {
+	i.mu.RLock()
+	entries := make([]*IndexEntry, 0, len(i.entries))
+	for _, entry := range i.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(a, b int) bool { return entries[a].SourceID < entries[b].SourceID })
+	data, err := json.MarshalIndent(entries, "", "  ")
+	i.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode index: %w", err)
+	}
+
+	if dir := filepath.Dir(i.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return fmt.Errorf("failed to create index directory: %w", err)
+		}
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(i.path), filepath.Base(i.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), i.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return nil
+}
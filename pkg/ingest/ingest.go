@@ -0,0 +1,100 @@
+// Package ingest turns files into indexed chunks in a VectorStore.
+//
+// Ingestion runs in three stages:
+//
+//   - Loaders read files (plain text, Markdown, HTML, JSON/JSONL, CSV and the PDF
+//     text layer) into Sources with metadata.
+//   - Chunkers split each Source into Chunks, recording byte offsets and, for
+//     Markdown, the heading path.
+//   - A Pipeline embeds new or changed chunks in concurrent, rate-limited batches
+//     and stores them, using content hashes to skip unchanged sources and remove
+//     stale chunks on re-indexing.
+package ingest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Metadata keys set by loaders, chunkers and the pipeline
+const (
+	// MetadataSource is the path or URI the source was loaded from
+	MetadataSource = "source"
+
+	// MetadataSourceID identifies the source for incremental re-indexing
+	MetadataSourceID = "source_id"
+
+	// MetadataFormat is the loader format, e.g. "markdown" or "pdf"
+	MetadataFormat = "format"
+
+	// MetadataTitle is the document title when the format provides one
+	MetadataTitle = "title"
+
+	// MetadataRow is the 1-based record number for JSON, JSONL and CSV sources
+	MetadataRow = "row"
+
+	// MetadataPages is the number of pages of a PDF source
+	MetadataPages = "pages"
+
+	// MetadataChunkIndex is the position of the chunk within its source
+	MetadataChunkIndex = "chunk_index"
+
+	// MetadataStartOffset is the byte offset where the chunk starts in the source content
+	MetadataStartOffset = "start_offset"
+
+	// MetadataEndOffset is the byte offset where the chunk ends in the source content
+	MetadataEndOffset = "end_offset"
+
+	// MetadataHeadingPath is the Markdown heading path of the chunk, e.g. "Guide > Setup"
+	MetadataHeadingPath = "heading_path"
+
+	// MetadataContentHash is the SHA-256 of the chunk content
+	MetadataContentHash = "content_hash"
+
+	// MetadataSourceHash is the SHA-256 of the source content
+	MetadataSourceHash = "source_hash"
+)
+
+// Source is a loaded document before chunking
+type Source struct {
+	// ID identifies the source for incremental re-indexing. Loaders set it to the
+	// path, or to path#record for formats with several records per file.
+	ID string
+
+	// Path is the file path or URI the source was loaded from
+	Path string
+
+	// Content is the extracted text
+	Content string
+
+	// Metadata is propagated to every chunk of the source
+	Metadata map[string]interface{}
+}
+
+// Chunk is a piece of a source's content
+type Chunk struct {
+	// Content is the chunk text
+	Content string
+
+	// Start and End are byte offsets of the chunk in the source content
+	Start int
+	End   int
+
+	// Metadata holds chunker-specific metadata such as the heading path
+	Metadata map[string]interface{}
+}
+
+// hashContent returns the hex-encoded SHA-256 of content
+func hashContent(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// copyMetadata returns a shallow copy of metadata with room for extra keys
+func copyMetadata(metadata map[string]interface{}, extra int) map[string]interface{} {
+	copied := make(map[string]interface{}, len(metadata)+extra)
+	for k, v := range metadata {
+		copied[k] = v
+	}
+	return copied
+}
@@ -0,0 +1,170 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrUnsupportedFormat is returned when no loader is registered for a file extension
+var ErrUnsupportedFormat = errors.New("unsupported document format")
+
+// Loader reads a document into one or more sources
+type Loader interface {
+	// Load reads r, which was opened from path, into sources
+	Load(ctx context.Context, r io.Reader, path string) ([]Source, error)
+}
+
+// LoaderFunc adapts a function to the Loader interface
+type LoaderFunc func(ctx context.Context, r io.Reader, path string) ([]Source, error)
+
+// Load calls f(ctx, r, path)
+func (f LoaderFunc) Load(ctx context.Context, r io.Reader, path string) ([]Source, error) {
+	return f(ctx, r, path)
+}
+
+// Registry maps file extensions to loaders
+type Registry struct {
+	mu      sync.RWMutex
+	loaders map[string]Loader
+}
+
+// NewRegistry creates a registry with the built-in loaders registered for
+// .txt, .text, .md, .markdown, .html, .htm, .json, .jsonl, .ndjson, .csv and .pdf
+func NewRegistry() *Registry {
+	r := &Registry{loaders: map[string]Loader{}}
+	r.Register(&TextLoader{}, ".txt", ".text")
+	r.Register(&MarkdownLoader{}, ".md", ".markdown")
+	r.Register(&HTMLLoader{}, ".html", ".htm")
+	r.Register(&JSONLoader{}, ".json")
+	r.Register(&JSONLLoader{}, ".jsonl", ".ndjson")
+	r.Register(&CSVLoader{}, ".csv")
+	r.Register(&PDFLoader{}, ".pdf")
+	return r
+}
+
+// Register sets the loader for the given extensions, replacing existing registrations
+func (r *Registry) Register(loader Loader, extensions ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, ext := range extensions {
+		r.loaders[normalizeExtension(ext)] = loader
+	}
+}
+
+// LoaderFor returns the loader registered for the extension of path
+func (r *Registry) LoaderFor(path string) (Loader, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	loader, ok := r.loaders[normalizeExtension(filepath.Ext(path))]
+	return loader, ok
+}
+
+// Supports reports whether a loader is registered for the extension of path
+func (r *Registry) Supports(path string) bool {
+	_, ok := r.LoaderFor(path)
+	return ok
+}
+
+// LoadFile opens and loads a file with the loader registered for its extension
+func (r *Registry) LoadFile(ctx context.Context, path string) ([]Source, error) {
+	loader, ok := r.LoaderFor(path)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, path)
+	}
+
+	file, err := os.Open(path) // #nosec G304 - Path is provided by the caller
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	sources, err := loader.Load(ctx, file, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	return sources, nil
+}
+
+func normalizeExtension(ext string) string {
+	ext = strings.ToLower(ext)
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// newSource creates a source with the standard metadata
+func newSource(id, path, format, content string) Source {
+	return Source{
+		ID:      id,
+		Path:    path,
+		Content: content,
+		Metadata: map[string]interface{}{
+			MetadataSource:   path,
+			MetadataSourceID: id,
+			MetadataFormat:   format,
+		},
+	}
+}
+
+// TextLoader loads plain text files as a single source
+type TextLoader struct{}
+
+// Load implements Loader
+func (l *TextLoader) Load(ctx context.Context, r io.Reader, path string) ([]Source, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return []Source{newSource(path, path, "text", string(bytes.TrimPrefix(data, []byte("\ufeff"))))}, nil
+}
+
+// MarkdownLoader loads Markdown files as a single source. YAML front matter is
+// removed from the content and merged into the metadata; the title comes from
+// the front matter or the first level-one heading.
+type MarkdownLoader struct{}
+
+var (
+	frontMatterPattern = regexp.MustCompile(`(?s)\A---\r?\n(.*?)\r?\n---\r?\n?`)
+	titlePattern       = regexp.MustCompile(`(?m)^#\s+(.+?)\s*#*\s*$`)
+)
+
+// Load implements Loader
+func (l *MarkdownLoader) Load(ctx context.Context, r io.Reader, path string) ([]Source, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	content := string(bytes.TrimPrefix(data, []byte("\ufeff")))
+
+	var frontMatter map[string]interface{}
+	if match := frontMatterPattern.FindStringSubmatchIndex(content); match != nil {
+		if err := yaml.Unmarshal([]byte(content[match[2]:match[3]]), &frontMatter); err != nil {
+			return nil, fmt.Errorf("invalid front matter: %w", err)
+		}
+		content = content[match[1]:]
+	}
+
+	source := newSource(path, path, "markdown", content)
+	for k, v := range frontMatter {
+		if _, reserved := source.Metadata[k]; !reserved {
+			source.Metadata[k] = v
+		}
+	}
+	if _, ok := source.Metadata[MetadataTitle]; !ok {
+		if match := titlePattern.FindStringSubmatch(content); match != nil {
+			source.Metadata[MetadataTitle] = match[1]
+		}
+	}
+	return []Source{source}, nil
+}
@@ -0,0 +1,155 @@
+package ingest
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func load(t *testing.T, loader Loader, path, content string) []Source {
+	t.Helper()
+	sources, err := loader.Load(context.Background(), strings.NewReader(content), path)
+	require.NoError(t, err)
+	return sources
+}
+
+func TestMarkdownLoader(t *testing.T) {
+	sources := load(t, &MarkdownLoader{}, "docs/guide.md", "---\ntags: [setup]\nsource: ignored\n---\n# User Guide\n\nIntro text.\n")
+	require.Len(t, sources, 1)
+
+	source := sources[0]
+	assert.Equal(t, "docs/guide.md", source.ID)
+	assert.Equal(t, "# User Guide\n\nIntro text.\n", source.Content)
+	assert.Equal(t, "User Guide", source.Metadata[MetadataTitle])
+	assert.Equal(t, []interface{}{"setup"}, source.Metadata["tags"])
+	assert.Equal(t, "docs/guide.md", source.Metadata[MetadataSource])
+	assert.Equal(t, "markdown", source.Metadata[MetadataFormat])
+}
+
+func TestHTMLLoader(t *testing.T) {
+	page := `<html><head><title>Release  Notes</title><meta name="description" content="What changed"></head>
+<body><script>var x = 1;</script>
+<h1>Version 2</h1><p>New <b>features</b>:</p>
+<ul><li>Streaming</li><li>Retries</li></ul>
+<h2>Fixes</h2><p>Line one<br>Line two</p>
+<table><tr><td>a</td><td>b</td></tr></table>
+</body></html>`
+
+	sources := load(t, &HTMLLoader{}, "notes.html", page)
+	require.Len(t, sources, 1)
+	assert.Equal(t, "# Version 2\n\nNew features:\n\n- Streaming\n- Retries\n\n## Fixes\n\nLine one\nLine two\n\na b", sources[0].Content)
+	assert.Equal(t, "Release Notes", sources[0].Metadata[MetadataTitle])
+	assert.Equal(t, "What changed", sources[0].Metadata["description"])
+	assert.NotContains(t, sources[0].Content, "var x")
+}
+
+func TestJSONLoaders(t *testing.T) {
+	loader := &JSONLoader{ContentFields: []string{"title", "body"}, MetadataFields: []string{"author"}, IDField: "id"}
+	sources := load(t, loader, "posts.json", `[
+		{"id": "a1", "title": "First", "body": "Hello", "author": "kim"},
+		{"title": "Second", "body": "World"}
+	]`)
+	require.Len(t, sources, 2)
+	assert.Equal(t, "posts.json#a1", sources[0].ID)
+	assert.Equal(t, "First\n\nHello", sources[0].Content)
+	assert.Equal(t, "kim", sources[0].Metadata["author"])
+	assert.Equal(t, "posts.json#2", sources[1].ID)
+	assert.Equal(t, 2, sources[1].Metadata[MetadataRow])
+
+	sources = load(t, &JSONLoader{}, "config.json", `{"name": "agent", "tags": ["a", "b"], "size": 3}`)
+	require.Len(t, sources, 1)
+	assert.Equal(t, "config.json", sources[0].ID)
+	assert.Equal(t, "name: agent\nsize: 3\ntags: [\"a\",\"b\"]", sources[0].Content)
+
+	sources = load(t, &JSONLLoader{ContentFields: []string{"text"}}, "log.jsonl", "{\"text\": \"one\"}\n\n{\"text\": \"two\"}")
+	require.Len(t, sources, 2)
+	assert.Equal(t, "two", sources[1].Content)
+	assert.Equal(t, "log.jsonl#3", sources[1].ID)
+
+	_, err := (&JSONLLoader{}).Load(context.Background(), strings.NewReader("{}\n{bad"), "bad.jsonl")
+	assert.ErrorContains(t, err, "line 2")
+}
+
+func TestCSVLoader(t *testing.T) {
+	loader := &CSVLoader{ContentColumns: []string{"name", "notes"}, MetadataColumns: []string{"team"}, IDColumn: "sku"}
+	sources := load(t, loader, "items.csv", "sku,name,notes,team\nx1,Widget,\"Small, blue\",core\nx2,Gadget,,infra\n")
+	require.Len(t, sources, 2)
+	assert.Equal(t, "items.csv#x1", sources[0].ID)
+	assert.Equal(t, "name: Widget\nnotes: Small, blue", sources[0].Content)
+	assert.Equal(t, "core", sources[0].Metadata["team"])
+	assert.Equal(t, "name: Gadget", sources[1].Content)
+	assert.Equal(t, 2, sources[1].Metadata[MetadataRow])
+
+	_, err := (&CSVLoader{IDColumn: "missing"}).Load(context.Background(), strings.NewReader("a,b\n1,2\n"), "x.csv")
+	assert.ErrorContains(t, err, `"missing"`)
+}
+
+// buildPDF returns a minimal PDF whose single page draws the given content stream
+func buildPDF(content string, compress bool) []byte {
+	stream, filter := []byte(content), ""
+	if compress {
+		var buf bytes.Buffer
+		w := zlib.NewWriter(&buf)
+		w.Write(stream)
+		w.Close()
+		stream, filter = buf.Bytes(), " /Filter /FlateDecode"
+	}
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+	pdf.WriteString("1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n")
+	pdf.WriteString("2 0 obj << /Type /Pages /Kids [3 0 R] /Count 1 >> endobj\n")
+	pdf.WriteString("3 0 obj << /Type /Page /Parent 2 0 R /Contents 4 0 R >> endobj\n")
+	fmt.Fprintf(&pdf, "4 0 obj << /Length %d%s >>\nstream\n", len(stream), filter)
+	pdf.Write(stream)
+	pdf.WriteString("\nendstream\nendobj\n")
+	pdf.WriteString("5 0 obj << /Title <FEFF005200650070006F00720074> >> endobj\n")
+	pdf.WriteString("trailer << /Root 1 0 R /Info 5 0 R >>\n%%EOF\n")
+	return pdf.Bytes()
+}
+
+func TestPDFLoader(t *testing.T) {
+	content := "BT /F1 12 Tf 72 720 Td (Hello \\(PDF\\) World) Tj 0 -14 Td [(Sec) 10 (ond) -300 (line)] TJ ET\n" +
+		"BT 1 0 0 1 72 600 Tm <0054006800690072> Tj T* (Third) Tj ET"
+
+	for _, compress := range []bool{false, true} {
+		sources := load(t, &PDFLoader{}, "report.pdf", string(buildPDF(content, compress)))
+		require.Len(t, sources, 1)
+		assert.Equal(t, "Hello (PDF) World\nSecond line\nThir\nThird", sources[0].Content)
+		assert.Equal(t, "Report", sources[0].Metadata[MetadataTitle])
+		assert.Equal(t, 1, sources[0].Metadata[MetadataPages])
+	}
+
+	_, err := (&PDFLoader{}).Load(context.Background(), strings.NewReader("plain text"), "fake.pdf")
+	assert.Error(t, err)
+}
+
+func TestRegistry(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "NOTES.TXT")
+	require.NoError(t, os.WriteFile(path, []byte("\ufeffplain notes"), 0o600))
+
+	registry := NewRegistry()
+	sources, err := registry.LoadFile(context.Background(), path)
+	require.NoError(t, err)
+	require.Len(t, sources, 1)
+	assert.Equal(t, "plain notes", sources[0].Content)
+	assert.Equal(t, "text", sources[0].Metadata[MetadataFormat])
+
+	_, err = registry.LoadFile(context.Background(), filepath.Join(dir, "image.png"))
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	registry.Register(LoaderFunc(func(ctx context.Context, r io.Reader, path string) ([]Source, error) {
+		return []Source{{ID: path, Path: path, Content: "custom"}}, nil
+	}), "log")
+	assert.True(t, registry.Supports("server.log"))
+}
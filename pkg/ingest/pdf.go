@@ -0,0 +1,469 @@
+package ingest
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// PDFLoader extracts the text layer of a PDF document. It reads uncompressed
+// and FlateDecode content streams and decodes text drawn with standard or
+// UTF-16 encoded strings. Scanned pages and text in fonts with custom CID
+// encodings cannot be recovered without OCR and are skipped.
+type PDFLoader struct{}
+
+var (
+	streamPattern   = regexp.MustCompile(`stream\r?\n`)
+	pagePattern     = regexp.MustCompile(`/Type\s*/Page\b`)
+	pdfTitlePattern = regexp.MustCompile(`/Title\s*([(<])`)
+)
+
+// Load implements Loader
+func (l *PDFLoader) Load(ctx context.Context, r io.Reader, path string) ([]Source, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(bytes.TrimLeft(data, "\x00\t\n\r "), []byte("%PDF-")) {
+		return nil, errors.New("not a PDF document")
+	}
+
+	var pages []string
+	for _, stream := range pdfStreams(data) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !bytes.Contains(stream, []byte("BT")) {
+			continue
+		}
+		if text := strings.TrimSpace(extractPDFText(stream)); text != "" {
+			pages = append(pages, text)
+		}
+	}
+
+	source := newSource(path, path, "pdf", strings.Join(pages, "\n\n"))
+	source.Metadata[MetadataPages] = len(pagePattern.FindAll(data, -1))
+	if match := pdfTitlePattern.FindSubmatchIndex(data); match != nil {
+		lexer := &pdfLexer{data: data, pos: match[2]}
+		if token, ok := lexer.next(); ok && token.kind == pdfString {
+			if title := strings.TrimSpace(decodePDFString(token.value, token.hex)); title != "" {
+				source.Metadata[MetadataTitle] = title
+			}
+		}
+	}
+	return []Source{source}, nil
+}
+
+// pdfStreams returns the decoded data of every stream that may hold page content
+func pdfStreams(data []byte) [][]byte {
+	var streams [][]byte
+	for _, match := range streamPattern.FindAllIndex(data, -1) {
+		if match[0] >= 3 && string(data[match[0]-3:match[0]]) == "end" {
+			continue
+		}
+		end := bytes.Index(data[match[1]:], []byte("endstream"))
+		if end < 0 {
+			continue
+		}
+
+		dictStart := bytes.LastIndex(data[:match[0]], []byte("obj"))
+		if dictStart < 0 {
+			continue
+		}
+		dict := string(data[dictStart:match[0]])
+		if strings.Contains(dict, "/Subtype/Image") || strings.Contains(dict, "/Subtype /Image") ||
+			strings.Contains(dict, "/Length1") || strings.Contains(dict, "/XRef") || strings.Contains(dict, "/ObjStm") {
+			continue
+		}
+
+		raw := bytes.TrimRight(data[match[1]:match[1]+end], "\r\n")
+		switch {
+		case !strings.Contains(dict, "/Filter"):
+			streams = append(streams, raw)
+		case strings.Contains(dict, "/FlateDecode") && strings.Count(dict, "Decode") == 1:
+			if decoded := inflate(raw); len(decoded) > 0 {
+				streams = append(streams, decoded)
+			}
+		}
+	}
+	return streams
+}
+
+// inflate decompresses zlib data, returning whatever could be decoded when the
+// stream is truncated or corrupt
+func inflate(data []byte) []byte {
+	reader, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	defer reader.Close()
+	decoded, _ := io.ReadAll(reader)
+	return decoded
+}
+
+// extractPDFText interprets the text operators of a content stream
+func extractPDFText(stream []byte) string {
+	var (
+		b        strings.Builder
+		operands []pdfToken
+		lastY    float64
+		haveY    bool
+	)
+	newLine := func() {
+		if s := b.String(); s != "" && !strings.HasSuffix(s, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	space := func() {
+		if s := b.String(); s != "" && !strings.HasSuffix(s, " ") && !strings.HasSuffix(s, "\n") {
+			b.WriteString(" ")
+		}
+	}
+	writeString := func(token pdfToken) {
+		if token.kind == pdfString {
+			b.WriteString(decodePDFString(token.value, token.hex))
+		}
+	}
+	number := func(i int) float64 {
+		if i < 0 || i >= len(operands) || operands[i].kind != pdfNumber {
+			return 0
+		}
+		return operands[i].number
+	}
+
+	lexer := &pdfLexer{data: stream}
+	for {
+		token, ok := lexer.next()
+		if !ok {
+			break
+		}
+		if token.kind != pdfOperator {
+			operands = append(operands, token)
+			continue
+		}
+
+		n := len(operands)
+		switch string(token.value) {
+		case "Tj":
+			if n > 0 {
+				writeString(operands[n-1])
+			}
+		case "TJ":
+			if n > 0 {
+				for _, element := range operands[n-1].elements {
+					if element.kind == pdfNumber && element.number < -200 {
+						space()
+					}
+					writeString(element)
+				}
+			}
+		case "'":
+			newLine()
+			if n > 0 {
+				writeString(operands[n-1])
+			}
+		case "\"":
+			newLine()
+			if n > 0 {
+				writeString(operands[n-1])
+			}
+		case "Td", "TD":
+			if number(n-1) != 0 {
+				newLine()
+			} else {
+				space()
+			}
+		case "Tm":
+			y := number(n - 1)
+			if haveY && y != lastY {
+				newLine()
+			} else {
+				space()
+			}
+			lastY, haveY = y, true
+		case "T*", "ET":
+			newLine()
+		case "ID":
+			lexer.skipInlineImage()
+		}
+		operands = operands[:0]
+	}
+	return b.String()
+}
+
+// decodePDFString converts PDF string bytes to UTF-8. Strings with a UTF-16
+// byte order mark, and hex strings that look like UTF-16, are decoded as
+// UTF-16BE; everything else is treated as a single-byte encoding.
+func decodePDFString(value []byte, hex bool) string {
+	utf16BE := bytes.HasPrefix(value, []byte{0xFE, 0xFF})
+	if utf16BE {
+		value = value[2:]
+	} else if hex && len(value) >= 2 && len(value)%2 == 0 {
+		utf16BE = true
+		for i := 0; i < len(value); i += 2 {
+			if value[i] != 0 {
+				utf16BE = false
+				break
+			}
+		}
+	}
+
+	if utf16BE {
+		units := make([]uint16, 0, len(value)/2)
+		for i := 0; i+1 < len(value); i += 2 {
+			units = append(units, uint16(value[i])<<8|uint16(value[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+
+	runes := make([]rune, 0, len(value))
+	for _, c := range value {
+		if c >= 0x20 || c == '\n' || c == '\t' {
+			runes = append(runes, rune(c))
+		}
+	}
+	return string(runes)
+}
+
+type pdfTokenKind int
+
+const (
+	pdfNumber pdfTokenKind = iota
+	pdfString
+	pdfName
+	pdfArray
+	pdfOperator
+	pdfOther
+)
+
+type pdfToken struct {
+	kind     pdfTokenKind
+	value    []byte
+	hex      bool
+	number   float64
+	elements []pdfToken
+}
+
+// pdfLexer tokenizes PDF content stream syntax
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+func (l *pdfLexer) next() (pdfToken, bool) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return pdfToken{}, false
+	}
+
+	c := l.data[l.pos]
+	switch {
+	case c == '(':
+		return pdfToken{kind: pdfString, value: l.literalString()}, true
+	case c == '<' && l.peek(1) == '<':
+		l.skipDict()
+		return pdfToken{kind: pdfOther}, true
+	case c == '<':
+		return pdfToken{kind: pdfString, value: l.hexString(), hex: true}, true
+	case c == '[':
+		l.pos++
+		array := pdfToken{kind: pdfArray}
+		for {
+			l.skipSpace()
+			if l.pos >= len(l.data) {
+				return array, true
+			}
+			if l.data[l.pos] == ']' {
+				l.pos++
+				return array, true
+			}
+			element, ok := l.next()
+			if !ok {
+				return array, true
+			}
+			array.elements = append(array.elements, element)
+		}
+	case c == ']' || c == '>' || c == ')' || c == '{' || c == '}':
+		l.pos++
+		return pdfToken{kind: pdfOther}, true
+	case c == '/':
+		l.pos++
+		return pdfToken{kind: pdfName, value: l.regular()}, true
+	case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		word := l.regular()
+		number, err := strconv.ParseFloat(string(word), 64)
+		if err != nil {
+			return pdfToken{kind: pdfOther, value: word}, true
+		}
+		return pdfToken{kind: pdfNumber, number: number}, true
+	default:
+		word := l.regular()
+		if len(word) == 0 {
+			l.pos++
+			return pdfToken{kind: pdfOther}, true
+		}
+		return pdfToken{kind: pdfOperator, value: word}, true
+	}
+}
+
+func (l *pdfLexer) peek(offset int) byte {
+	if l.pos+offset < len(l.data) {
+		return l.data[l.pos+offset]
+	}
+	return 0
+}
+
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		switch c := l.data[l.pos]; {
+		case isPDFSpace(c):
+			l.pos++
+		case c == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// regular reads a run of regular characters
+func (l *pdfLexer) regular() []byte {
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	return l.data[start:l.pos]
+}
+
+func (l *pdfLexer) literalString() []byte {
+	var out []byte
+	depth := 0
+	l.pos++ // opening parenthesis
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return out
+			}
+			depth--
+		case '\\':
+			if l.pos >= len(l.data) {
+				return out
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				if l.peek(0) == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					value := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						value = value*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					c = byte(value)
+				} else {
+					c = e
+				}
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+func (l *pdfLexer) hexString() []byte {
+	l.pos++ // opening angle bracket
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		if c := l.data[l.pos]; isHexDigit(c) {
+			digits = append(digits, c)
+		}
+		l.pos++
+	}
+	l.pos++ // closing angle bracket
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	for i := range out {
+		value, _ := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+		out[i] = byte(value)
+	}
+	return out
+}
+
+// skipDict skips a dictionary, including nested dictionaries
+func (l *pdfLexer) skipDict() {
+	depth := 0
+	for l.pos < len(l.data) {
+		switch {
+		case l.data[l.pos] == '<' && l.peek(1) == '<':
+			depth++
+			l.pos += 2
+		case l.data[l.pos] == '>' && l.peek(1) == '>':
+			depth--
+			l.pos += 2
+			if depth == 0 {
+				return
+			}
+		case l.data[l.pos] == '(':
+			l.literalString()
+		default:
+			l.pos++
+		}
+	}
+}
+
+// skipInlineImage skips inline image data up to the EI operator
+func (l *pdfLexer) skipInlineImage() {
+	for l.pos+2 < len(l.data) {
+		if isPDFSpace(l.data[l.pos]) && l.data[l.pos+1] == 'E' && l.data[l.pos+2] == 'I' &&
+			(l.pos+3 >= len(l.data) || isPDFSpace(l.data[l.pos+3])) {
+			l.pos += 3
+			return
+		}
+		l.pos++
+	}
+	l.pos = len(l.data)
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
@@ -0,0 +1,652 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/tagus/agent-sdk-go/pkg/embedding"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/logging"
+	"github.com/tagus/agent-sdk-go/pkg/retry"
+)
+
+const (
+	// DefaultBatchSize is the default number of chunks embedded per request
+	DefaultBatchSize = 64
+
+	// DefaultConcurrency is the default number of concurrent embedding requests
+	DefaultConcurrency = 4
+)
+
+// Result summarizes an ingestion run
+type Result struct {
+	// Sources is the number of new or changed sources that were indexed
+	Sources int
+
+	// Skipped is the number of sources skipped because their content is unchanged
+	Skipped int
+
+	// Chunks is the number of chunks of the indexed sources
+	Chunks int
+
+	// Embedded is the number of chunks embedded and stored
+	Embedded int
+
+	// Updated is the number of unchanged chunks re-stored with new positions
+	Updated int
+
+	// Deleted is the number of stale chunks removed from the store
+	Deleted int
+}
+
+func (r *Result) add(other *Result) {
+	r.Sources += other.Sources
+	r.Skipped += other.Skipped
+	r.Chunks += other.Chunks
+	r.Embedded += other.Embedded
+	r.Updated += other.Updated
+	r.Deleted += other.Deleted
+}
+
+// Pipeline loads, chunks, embeds and stores documents in a VectorStore.
+// Sources are tracked in an Index by content hash: unchanged sources are
+// skipped, only new chunks of changed sources are embedded, and chunks that no
+// longer exist are deleted.
+type Pipeline struct {
+	store        interfaces.VectorStore
+	embedder     embedding.Client
+	registry     *Registry
+	chunker      Chunker
+	chunkSize    int
+	chunkOverlap int
+	index        Index
+	batchSize    int
+	concurrency  int
+	limiter      *rateLimiter
+	retryPolicy  *retry.Policy
+	class        string
+	tenant       string
+	logger       logging.Logger
+}
+
+// Option configures a Pipeline
+type Option func(*Pipeline)
+
+// WithChunker sets the chunker used for every source. By default Markdown and
+// HTML sources use a MarkdownChunker and other sources a RecursiveChunker.
+func WithChunker(chunker Chunker) Option {
+	return func(p *Pipeline) {
+		p.chunker = chunker
+	}
+}
+
+// WithChunkSize sets the size and overlap, in characters, of the default chunkers
+func WithChunkSize(size, overlap int) Option {
+	return func(p *Pipeline) {
+		p.chunkSize = size
+		p.chunkOverlap = overlap
+	}
+}
+
+// WithRegistry sets the loader registry
+func WithRegistry(registry *Registry) Option {
+	return func(p *Pipeline) {
+		p.registry = registry
+	}
+}
+
+// WithIndex sets the index used for incremental re-indexing. It defaults to a
+// MemoryIndex; use a FileIndex to keep incremental state across runs.
+func WithIndex(index Index) Option {
+	return func(p *Pipeline) {
+		p.index = index
+	}
+}
+
+// WithBatchSize sets the number of chunks embedded per request
+func WithBatchSize(size int) Option {
+	return func(p *Pipeline) {
+		if size > 0 {
+			p.batchSize = size
+		}
+	}
+}
+
+// WithConcurrency sets the number of concurrent embedding requests
+func WithConcurrency(concurrency int) Option {
+	return func(p *Pipeline) {
+		if concurrency > 0 {
+			p.concurrency = concurrency
+		}
+	}
+}
+
+// WithRateLimit limits embedding requests to requestsPerSecond across all workers
+func WithRateLimit(requestsPerSecond float64) Option {
+	return func(p *Pipeline) {
+		if requestsPerSecond > 0 {
+			p.limiter = &rateLimiter{interval: time.Duration(float64(time.Second) / requestsPerSecond)}
+		}
+	}
+}
+
+// WithRetryPolicy retries failed embedding and store requests
+func WithRetryPolicy(policy *retry.Policy) Option {
+	return func(p *Pipeline) {
+		p.retryPolicy = policy
+	}
+}
+
+// WithClass sets the VectorStore class documents are stored in
+func WithClass(class string) Option {
+	return func(p *Pipeline) {
+		p.class = class
+	}
+}
+
+// WithTenant sets the VectorStore tenant documents are stored in
+func WithTenant(tenant string) Option {
+	return func(p *Pipeline) {
+		p.tenant = tenant
+	}
+}
+
+// WithLogger sets the logger
+func WithLogger(logger logging.Logger) Option {
+	return func(p *Pipeline) {
+		p.logger = logger
+	}
+}
+
+// NewPipeline creates an ingestion pipeline. When embedder is nil, documents
+// are stored without vectors and the store generates them.
+func NewPipeline(store interfaces.VectorStore, embedder embedding.Client, options ...Option) *Pipeline {
+	p := &Pipeline{
+		store:        store,
+		embedder:     embedder,
+		chunkSize:    DefaultChunkSize,
+		chunkOverlap: DefaultChunkOverlap,
+		batchSize:    DefaultBatchSize,
+		concurrency:  DefaultConcurrency,
+		logger:       logging.New(),
+	}
+	for _, option := range options {
+		option(p)
+	}
+	if p.registry == nil {
+		p.registry = NewRegistry()
+	}
+	if p.index == nil {
+		p.index = NewMemoryIndex()
+	}
+	return p
+}
+
+// IngestFile loads and indexes a file. Sources previously loaded from the file
+// that no longer exist, such as deleted CSV rows, are removed.
+func (p *Pipeline) IngestFile(ctx context.Context, path string) (*Result, error) {
+	sources, err := p.registry.LoadFile(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return p.ingestPath(ctx, path, sources)
+}
+
+// IngestReader loads r with the loader registered for the extension of path
+// and indexes the result like IngestFile
+func (p *Pipeline) IngestReader(ctx context.Context, r io.Reader, path string) (*Result, error) {
+	loader, ok := p.registry.LoaderFor(path)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, path)
+	}
+	sources, err := loader.Load(ctx, r, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	return p.ingestPath(ctx, path, sources)
+}
+
+// IngestDir indexes every supported file under dir, skipping hidden files and
+// directories. Files that fail are logged and reported in the returned error
+// without stopping the run.
+func (p *Pipeline) IngestDir(ctx context.Context, dir string) (*Result, error) {
+	total := &Result{}
+	var errs []error
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() || !p.registry.Supports(path) {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		result, err := p.IngestFile(ctx, path)
+		if err != nil {
+			p.logger.Warn(ctx, "Failed to ingest file", map[string]interface{}{
+				"path":  path,
+				"error": err.Error(),
+			})
+			errs = append(errs, err)
+			return nil
+		}
+		total.add(result)
+		return nil
+	})
+	if err != nil {
+		return total, fmt.Errorf("failed to walk %s: %w", dir, err)
+	}
+	return total, errors.Join(errs...)
+}
+
+// IngestSources indexes sources that were loaded or built by the caller
+func (p *Pipeline) IngestSources(ctx context.Context, sources ...Source) (*Result, error) {
+	total := &Result{}
+	for _, source := range sources {
+		result, err := p.ingestSource(ctx, source)
+		if err != nil {
+			return total, err
+		}
+		total.add(result)
+	}
+	return total, nil
+}
+
+// RemoveFile deletes the chunks of every source loaded from path
+func (p *Pipeline) RemoveFile(ctx context.Context, path string) (*Result, error) {
+	entries, err := p.index.List(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list index entries: %w", err)
+	}
+	result := &Result{}
+	for _, entry := range entries {
+		if err := p.removeEntry(ctx, entry, result); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// RemoveSource deletes the chunks of a single source
+func (p *Pipeline) RemoveSource(ctx context.Context, sourceID string) (*Result, error) {
+	entry, err := p.index.Get(ctx, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index entry: %w", err)
+	}
+	result := &Result{}
+	if entry == nil {
+		return result, nil
+	}
+	return result, p.removeEntry(ctx, entry, result)
+}
+
+func (p *Pipeline) ingestPath(ctx context.Context, path string, sources []Source) (*Result, error) {
+	total, err := p.IngestSources(ctx, sources...)
+	if err != nil {
+		return total, err
+	}
+
+	entries, err := p.index.List(ctx, path)
+	if err != nil {
+		return total, fmt.Errorf("failed to list index entries: %w", err)
+	}
+	current := make(map[string]bool, len(sources))
+	for _, source := range sources {
+		current[source.ID] = true
+	}
+	for _, entry := range entries {
+		if !current[entry.SourceID] {
+			if err := p.removeEntry(ctx, entry, total); err != nil {
+				return total, err
+			}
+		}
+	}
+	return total, nil
+}
+
+func (p *Pipeline) removeEntry(ctx context.Context, entry *IndexEntry, result *Result) error {
+	ids := make([]string, len(entry.Chunks))
+	for i, chunk := range entry.Chunks {
+		ids[i] = chunk.ID
+	}
+	if err := p.delete(ctx, ids); err != nil {
+		return err
+	}
+	result.Deleted += len(ids)
+	if err := p.index.Delete(ctx, entry.SourceID); err != nil {
+		return fmt.Errorf("failed to update index: %w", err)
+	}
+	return nil
+}
+
+// ingestSource chunks a source and brings the store in line with its content
+func (p *Pipeline) ingestSource(ctx context.Context, source Source) (*Result, error) {
+	if source.ID == "" {
+		source.ID = source.Path
+	}
+	if source.ID == "" {
+		return nil, errors.New("source has no ID or path")
+	}
+
+	result := &Result{}
+	sourceHash := hashContent(source.Content)
+	previous, err := p.index.Get(ctx, source.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index entry: %w", err)
+	}
+	if previous != nil && previous.SourceHash == sourceHash {
+		result.Skipped = 1
+		return result, nil
+	}
+
+	previousChunks := map[string]IndexedChunk{}
+	if previous != nil {
+		for _, chunk := range previous.Chunks {
+			previousChunks[chunk.ID] = chunk
+		}
+	}
+
+	chunks := p.chunkerFor(source).Chunk(source.Content)
+	entry := &IndexEntry{
+		SourceID:   source.ID,
+		Path:       source.Path,
+		SourceHash: sourceHash,
+		Chunks:     make([]IndexedChunk, 0, len(chunks)),
+		UpdatedAt:  time.Now(),
+	}
+
+	var embed, moved []interfaces.Document
+	occurrences := map[string]int{}
+	for i, chunk := range chunks {
+		contentHash := hashContent(chunk.Content)
+		occurrences[contentHash]++
+		id := chunkID(source.ID, contentHash, occurrences[contentHash])
+		indexed := IndexedChunk{ID: id, Index: i, Start: chunk.Start, End: chunk.End}
+		entry.Chunks = append(entry.Chunks, indexed)
+
+		old, exists := previousChunks[id]
+		delete(previousChunks, id)
+		if exists && old == indexed {
+			continue
+		}
+
+		metadata := copyMetadata(source.Metadata, len(chunk.Metadata)+8)
+		for k, v := range chunk.Metadata {
+			metadata[k] = v
+		}
+		metadata[MetadataSource] = source.Path
+		metadata[MetadataSourceID] = source.ID
+		metadata[MetadataSourceHash] = sourceHash
+		metadata[MetadataContentHash] = contentHash
+		metadata[MetadataChunkIndex] = i
+		metadata[MetadataStartOffset] = chunk.Start
+		metadata[MetadataEndOffset] = chunk.End
+
+		doc := interfaces.Document{ID: id, Content: chunk.Content, Metadata: metadata}
+		if exists {
+			moved = append(moved, doc)
+		} else {
+			embed = append(embed, doc)
+		}
+	}
+
+	// Unchanged chunks at new positions keep their vectors when the store returns them
+	var restore []interfaces.Document
+	for _, doc := range moved {
+		stored, err := p.store.Get(ctx, doc.ID, p.storeOptions()...)
+		if err != nil || stored == nil || len(stored.Vector) == 0 {
+			embed = append(embed, doc)
+			continue
+		}
+		doc.Vector = stored.Vector
+		restore = append(restore, doc)
+	}
+	if len(restore) > 0 {
+		if err := p.storeDocuments(ctx, restore); err != nil {
+			return result, err
+		}
+	}
+
+	if err := p.embedAndStore(ctx, embed); err != nil {
+		return result, err
+	}
+
+	stale := make([]string, 0, len(previousChunks))
+	for id := range previousChunks {
+		stale = append(stale, id)
+	}
+	if err := p.delete(ctx, stale); err != nil {
+		return result, err
+	}
+
+	if err := p.index.Put(ctx, entry); err != nil {
+		return result, fmt.Errorf("failed to update index: %w", err)
+	}
+
+	result.Sources = 1
+	result.Chunks = len(chunks)
+	result.Embedded = len(embed)
+	result.Updated = len(restore)
+	result.Deleted = len(stale)
+	p.logger.Debug(ctx, "Indexed source", map[string]interface{}{
+		"source":   source.ID,
+		"chunks":   result.Chunks,
+		"embedded": result.Embedded,
+		"updated":  result.Updated,
+		"deleted":  result.Deleted,
+	})
+	return result, nil
+}
+
+func (p *Pipeline) chunkerFor(source Source) Chunker {
+	if p.chunker != nil {
+		return p.chunker
+	}
+	switch source.Metadata[MetadataFormat] {
+	case "markdown", "html":
+		return NewMarkdownChunker(p.chunkSize, p.chunkOverlap)
+	default:
+		return NewRecursiveChunker(p.chunkSize, p.chunkOverlap)
+	}
+}
+
+// chunkID derives a stable document ID from the source and chunk content, so
+// unchanged chunks keep their IDs when a source is re-indexed
+func chunkID(sourceID, contentHash string, occurrence int) string {
+	name := sourceID + "#" + contentHash
+	if occurrence > 1 {
+		name += "#" + strconv.Itoa(occurrence)
+	}
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(name)).String()
+}
+
+// embedAndStore embeds documents in concurrent batches and stores each batch
+func (p *Pipeline) embedAndStore(ctx context.Context, docs []interfaces.Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	batches := make(chan []interfaces.Document)
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	for i := 0; i < p.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				if err := p.embedBatch(ctx, batch); err != nil {
+					errOnce.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+	for start := 0; start < len(docs); start += p.batchSize {
+		end := start + p.batchSize
+		if end > len(docs) {
+			end = len(docs)
+		}
+		select {
+		case batches <- docs[start:end]:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(batches)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+func (p *Pipeline) embedBatch(ctx context.Context, batch []interfaces.Document) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if p.embedder != nil {
+		texts := make([]string, len(batch))
+		for i, doc := range batch {
+			texts[i] = doc.Content
+		}
+
+		var vectors [][]float32
+		err := p.withRetry(ctx, func() error {
+			if err := p.limiter.wait(ctx); err != nil {
+				return err
+			}
+			var err error
+			vectors, err = p.embedder.EmbedBatch(ctx, texts)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to embed chunks: %w", err)
+		}
+		if len(vectors) != len(batch) {
+			return fmt.Errorf("failed to embed chunks: got %d embeddings for %d chunks", len(vectors), len(batch))
+		}
+		for i := range batch {
+			batch[i].Vector = vectors[i]
+		}
+	}
+
+	return p.storeDocuments(ctx, batch)
+}
+
+func (p *Pipeline) storeDocuments(ctx context.Context, docs []interfaces.Document) error {
+	options := append(p.storeOptions(), interfaces.WithBatchSize(p.batchSize))
+	err := p.withRetry(ctx, func() error {
+		return p.store.Store(ctx, docs, options...)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store chunks: %w", err)
+	}
+	return nil
+}
+
+func (p *Pipeline) delete(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	var options []interfaces.DeleteOption
+	if p.class != "" {
+		options = append(options, func(o *interfaces.DeleteOptions) {
+			o.Class = p.class
+		})
+	}
+	if p.tenant != "" {
+		options = append(options, interfaces.WithTenantDelete(p.tenant))
+	}
+	err := p.withRetry(ctx, func() error {
+		return p.store.Delete(ctx, ids, options...)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete stale chunks: %w", err)
+	}
+	return nil
+}
+
+func (p *Pipeline) storeOptions() []interfaces.StoreOption {
+	var options []interfaces.StoreOption
+	if p.class != "" {
+		options = append(options, interfaces.WithClass(p.class))
+	}
+	if p.tenant != "" {
+		options = append(options, interfaces.WithTenant(p.tenant))
+	}
+	return options
+}
+
+func (p *Pipeline) withRetry(ctx context.Context, operation func() error) error {
+	if p.retryPolicy == nil {
+		return operation()
+	}
+	return retry.NewExecutor(p.retryPolicy).Execute(ctx, operation)
+}
+
+// rateLimiter spaces requests evenly at a fixed interval
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// wait blocks until the next request may start. A nil limiter never blocks.
+func (r *rateLimiter) wait(ctx context.Context) error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	now := time.Now()
+	at := r.next
+	if at.Before(now) {
+		at = now
+	}
+	r.next = at.Add(r.interval)
+	r.mu.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,202 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tagus/agent-sdk-go/pkg/embedding"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/retry"
+	"github.com/tagus/agent-sdk-go/pkg/vectorstore/inmemory"
+)
+
+// countingEmbedder embeds text by length and records every batch
+type countingEmbedder struct {
+	mu      sync.Mutex
+	batches [][]string
+	fail    int
+}
+
+func (e *countingEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	return []float32{float32(len(text)), 1}, nil
+}
+
+func (e *countingEmbedder) EmbedWithConfig(ctx context.Context, text string, config embedding.EmbeddingConfig) ([]float32, error) {
+	return e.Embed(ctx, text)
+}
+
+func (e *countingEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.fail > 0 {
+		e.fail--
+		return nil, errors.New("rate limited")
+	}
+	e.batches = append(e.batches, texts)
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i], _ = e.Embed(ctx, text)
+	}
+	return vectors, nil
+}
+
+func (e *countingEmbedder) EmbedBatchWithConfig(ctx context.Context, texts []string, config embedding.EmbeddingConfig) ([][]float32, error) {
+	return e.EmbedBatch(ctx, texts)
+}
+
+func (e *countingEmbedder) CalculateSimilarity(vec1, vec2 []float32, metric string) (float32, error) {
+	return embedding.CalculateSimilarity(vec1, vec2, metric)
+}
+
+// embedded returns the texts embedded so far, sorted, and resets the record
+func (e *countingEmbedder) embedded() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var texts []string
+	for _, batch := range e.batches {
+		texts = append(texts, batch...)
+	}
+	e.batches = nil
+	sort.Strings(texts)
+	return texts
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func TestPipeline_IncrementalReindexing(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	guide := filepath.Join(dir, "guide.md")
+	items := filepath.Join(dir, "items.csv")
+	writeFile(t, guide, "# Guide\n\nIntro.\n\n## Install\n\nRun the installer.\n")
+	writeFile(t, items, "name,notes\nWidget,Small\nGadget,Large\n")
+	writeFile(t, filepath.Join(dir, "image.png"), "binary")
+	require.NoError(t, os.Mkdir(filepath.Join(dir, ".git"), 0o750))
+	writeFile(t, filepath.Join(dir, ".git", "notes.txt"), "hidden")
+
+	store, err := inmemory.New(nil)
+	require.NoError(t, err)
+	embedder := &countingEmbedder{}
+	index, err := NewFileIndex(filepath.Join(dir, ".index", "index.json"))
+	require.NoError(t, err)
+	pipeline := NewPipeline(store, embedder, WithIndex(index), WithBatchSize(2), WithConcurrency(2))
+
+	result, err := pipeline.IngestDir(ctx, dir)
+	require.NoError(t, err)
+	assert.Equal(t, &Result{Sources: 3, Chunks: 4, Embedded: 4}, result)
+	assert.Equal(t, 4, store.Count("", ""))
+	assert.Equal(t, []string{"# Guide\n\nIntro.", "## Install\n\nRun the installer.", "name: Gadget\nnotes: Large", "name: Widget\nnotes: Small"}, embedder.embedded())
+
+	entry, err := index.Get(ctx, guide)
+	require.NoError(t, err)
+	require.Len(t, entry.Chunks, 2)
+	doc, err := store.Get(ctx, entry.Chunks[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Guide > Install", doc.Metadata[MetadataHeadingPath])
+	assert.Equal(t, guide, doc.Metadata[MetadataSource])
+	assert.EqualValues(t, 17, doc.Metadata[MetadataStartOffset])
+	assert.Equal(t, []float32{30, 1}, doc.Vector)
+
+	// Unchanged files are skipped, even by a new pipeline sharing the index file
+	reloaded, err := NewFileIndex(filepath.Join(dir, ".index", "index.json"))
+	require.NoError(t, err)
+	result, err = NewPipeline(store, embedder, WithIndex(reloaded)).IngestDir(ctx, dir)
+	require.NoError(t, err)
+	assert.Equal(t, &Result{Skipped: 3}, result)
+	assert.Empty(t, embedder.embedded())
+
+	// Editing the guide embeds only the new section; the moved section keeps its vector
+	writeFile(t, guide, "# Guide\n\nIntro.\n\n## Upgrade\n\nRun the upgrade.\n\n## Install\n\nRun the installer.\n")
+	result, err = pipeline.IngestFile(ctx, guide)
+	require.NoError(t, err)
+	assert.Equal(t, &Result{Sources: 1, Chunks: 3, Embedded: 1, Updated: 1}, result)
+	assert.Equal(t, []string{"## Upgrade\n\nRun the upgrade."}, embedder.embedded())
+	doc, err = store.Get(ctx, entry.Chunks[1].ID)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, doc.Metadata[MetadataChunkIndex])
+	assert.Equal(t, []float32{30, 1}, doc.Vector)
+
+	// Removing a CSV row deletes its chunk
+	writeFile(t, items, "name,notes\nWidget,Small\n")
+	result, err = pipeline.IngestFile(ctx, items)
+	require.NoError(t, err)
+	assert.Equal(t, &Result{Skipped: 1, Deleted: 1}, result)
+	assert.Equal(t, 4, store.Count("", ""))
+
+	result, err = pipeline.RemoveFile(ctx, guide)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Deleted)
+	assert.Equal(t, 1, store.Count("", ""))
+}
+
+func TestPipeline_IngestSources(t *testing.T) {
+	ctx := context.Background()
+	store, err := inmemory.New(nil)
+	require.NoError(t, err)
+	embedder := &countingEmbedder{}
+	pipeline := NewPipeline(store, embedder,
+		WithChunker(NewTokenChunker(2, 0)),
+		WithTenant("acme"),
+		WithClass("Docs"))
+
+	result, err := pipeline.IngestSources(ctx, Source{
+		ID:       "faq",
+		Content:  "one two one two three",
+		Metadata: map[string]interface{}{"lang": "en"},
+	})
+	require.NoError(t, err)
+	// The repeated "one two" chunk gets a distinct ID
+	assert.Equal(t, &Result{Sources: 1, Chunks: 3, Embedded: 3}, result)
+	assert.Equal(t, 3, store.Count("acme", "Docs"))
+
+	doc, err := store.Get(ctx, chunkID("faq", hashContent("one two"), 2), interfaces.WithTenant("acme"), interfaces.WithClass("Docs"))
+	require.NoError(t, err)
+	assert.Equal(t, "one two", doc.Content)
+	assert.Equal(t, "en", doc.Metadata["lang"])
+	assert.Equal(t, "faq", doc.Metadata[MetadataSourceID])
+	assert.EqualValues(t, 1, doc.Metadata[MetadataChunkIndex])
+}
+
+func TestPipeline_RetriesAndRateLimit(t *testing.T) {
+	ctx := context.Background()
+	store, err := inmemory.New(nil)
+	require.NoError(t, err)
+	embedder := &countingEmbedder{fail: 2}
+	pipeline := NewPipeline(store, embedder,
+		WithChunker(NewTokenChunker(1, 0)),
+		WithBatchSize(1),
+		WithConcurrency(3),
+		WithRateLimit(200),
+		WithRetryPolicy(retry.NewPolicy(retry.WithMaxAttempts(3), retry.WithInitialInterval(time.Millisecond))))
+
+	start := time.Now()
+	result, err := pipeline.IngestSources(ctx, Source{ID: "words", Content: "a b c d e"})
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.Embedded)
+	assert.Len(t, embedder.embedded(), 5)
+	// Seven requests at 200 per second take at least 30ms
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+
+	index := NewMemoryIndex()
+	failing := NewPipeline(store, &countingEmbedder{fail: 10}, WithIndex(index))
+	_, err = failing.IngestSources(ctx, Source{ID: "other", Content: "x y"})
+	assert.ErrorContains(t, err, "rate limited")
+
+	// A failed source is not recorded, so it is retried on the next run
+	result, err = NewPipeline(store, embedder, WithIndex(index)).IngestSources(ctx, Source{ID: "other", Content: "x y"})
+	require.NoError(t, err)
+	assert.Equal(t, &Result{Sources: 1, Chunks: 1, Embedded: 1}, result)
+	assert.Equal(t, []string{"x y"}, embedder.embedded())
+}
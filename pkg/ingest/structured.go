@@ -0,0 +1,237 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// JSONLoader loads a JSON document. A top-level array yields one source per
+// element; any other value yields a single source.
+type JSONLoader struct {
+	// ContentFields are the object fields joined to form the content. When empty,
+	// every field is rendered as a "key: value" line.
+	ContentFields []string
+
+	// MetadataFields are the object fields copied into the metadata
+	MetadataFields []string
+
+	// IDField is the object field used to build the source ID. Records without
+	// it are identified by their position.
+	IDField string
+}
+
+// Load implements Loader
+func (l *JSONLoader) Load(ctx context.Context, r io.Reader, path string) ([]Source, error) {
+	var value interface{}
+	if err := json.NewDecoder(r).Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	records, isArray := value.([]interface{})
+	if !isArray {
+		source := recordSource(path, "json", 0, value, l.ContentFields, l.MetadataFields, l.IDField)
+		source.ID = path
+		source.Metadata[MetadataSourceID] = path
+		delete(source.Metadata, MetadataRow)
+		return []Source{source}, nil
+	}
+
+	sources := make([]Source, 0, len(records))
+	for i, record := range records {
+		sources = append(sources, recordSource(path, "json", i+1, record, l.ContentFields, l.MetadataFields, l.IDField))
+	}
+	return sources, nil
+}
+
+// JSONLLoader loads newline-delimited JSON with one source per line. Blank
+// lines are skipped.
+type JSONLLoader struct {
+	// ContentFields are the object fields joined to form the content. When empty,
+	// every field is rendered as a "key: value" line.
+	ContentFields []string
+
+	// MetadataFields are the object fields copied into the metadata
+	MetadataFields []string
+
+	// IDField is the object field used to build the source ID
+	IDField string
+}
+
+// Load implements Loader
+func (l *JSONLLoader) Load(ctx context.Context, r io.Reader, path string) ([]Source, error) {
+	var sources []Source
+	reader := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 {
+			var record interface{}
+			if jsonErr := json.Unmarshal(trimmed, &record); jsonErr != nil {
+				return nil, fmt.Errorf("failed to parse JSON on line %d: %w", line, jsonErr)
+			}
+			sources = append(sources, recordSource(path, "jsonl", line, record, l.ContentFields, l.MetadataFields, l.IDField))
+		}
+		if err != nil {
+			return sources, nil
+		}
+	}
+}
+
+// recordSource converts a decoded JSON value into a source
+func recordSource(path, format string, row int, record interface{}, contentFields, metadataFields []string, idField string) Source {
+	id := fmt.Sprintf("%s#%d", path, row)
+	object, isObject := record.(map[string]interface{})
+	if !isObject {
+		source := newSource(id, path, format, formatValue(record))
+		source.Metadata[MetadataRow] = row
+		return source
+	}
+
+	if value, ok := object[idField]; ok && idField != "" {
+		id = fmt.Sprintf("%s#%s", path, formatValue(value))
+	}
+
+	var content string
+	if len(contentFields) > 0 {
+		parts := make([]string, 0, len(contentFields))
+		for _, field := range contentFields {
+			if value, ok := object[field]; ok && value != nil {
+				parts = append(parts, formatValue(value))
+			}
+		}
+		content = strings.Join(parts, "\n\n")
+	} else {
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		lines := make([]string, 0, len(keys))
+		for _, key := range keys {
+			lines = append(lines, key+": "+formatValue(object[key]))
+		}
+		content = strings.Join(lines, "\n")
+	}
+
+	source := newSource(id, path, format, content)
+	source.Metadata[MetadataRow] = row
+	for _, field := range metadataFields {
+		if value, ok := object[field]; ok {
+			source.Metadata[field] = value
+		}
+	}
+	return source
+}
+
+// formatValue renders strings as-is and other JSON values in compact JSON
+func formatValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// CSVLoader loads a CSV file with a header row as one source per record
+type CSVLoader struct {
+	// ContentColumns are the columns rendered as "column: value" lines to form
+	// the content. When empty, every column is used.
+	ContentColumns []string
+
+	// MetadataColumns are the columns copied into the metadata
+	MetadataColumns []string
+
+	// IDColumn is the column used to build the source ID. Records are identified
+	// by their row number when it is empty.
+	IDColumn string
+
+	// Comma is the field delimiter; it defaults to ','
+	Comma rune
+}
+
+// Load implements Loader
+func (l *CSVLoader) Load(ctx context.Context, r io.Reader, path string) ([]Source, error) {
+	reader := csv.NewReader(r)
+	if l.Comma != 0 {
+		reader.Comma = l.Comma
+	}
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[name] = i
+	}
+
+	contentColumns := l.ContentColumns
+	if len(contentColumns) == 0 {
+		contentColumns = header
+	}
+	for _, names := range [][]string{contentColumns, l.MetadataColumns} {
+		for _, name := range names {
+			if _, ok := columns[name]; !ok {
+				return nil, fmt.Errorf("CSV column %q not found", name)
+			}
+		}
+	}
+	if _, ok := columns[l.IDColumn]; l.IDColumn != "" && !ok {
+		return nil, fmt.Errorf("CSV column %q not found", l.IDColumn)
+	}
+
+	var sources []Source
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return sources, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV row %d: %w", row, err)
+		}
+		field := func(name string) string {
+			if i := columns[name]; i < len(record) {
+				return record[i]
+			}
+			return ""
+		}
+
+		lines := make([]string, 0, len(contentColumns))
+		for _, name := range contentColumns {
+			if value := field(name); value != "" {
+				lines = append(lines, name+": "+value)
+			}
+		}
+
+		id := fmt.Sprintf("%s#%d", path, row)
+		if l.IDColumn != "" && field(l.IDColumn) != "" {
+			id = fmt.Sprintf("%s#%s", path, field(l.IDColumn))
+		}
+		source := newSource(id, path, "csv", strings.Join(lines, "\n"))
+		source.Metadata[MetadataRow] = row
+		for _, name := range l.MetadataColumns {
+			source.Metadata[name] = field(name)
+		}
+		sources = append(sources, source)
+	}
+}
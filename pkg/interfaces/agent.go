@@ -3,6 +3,7 @@ package interfaces
 type AgentResponse struct {
 	Content          string
 	Usage            *TokenUsage
+	Cost             *Cost
 	AgentName        string
 	Model            string
 	ExecutionSummary ExecutionSummary
@@ -16,4 +17,28 @@ type ExecutionSummary struct {
 	ExecutionTimeMs int64
 	UsedTools       []string
 	UsedSubAgents   []string
+
+	// TotalCost is the cost of the LLM calls in US dollars
+	TotalCost float64
+
+	// DowngradedModel is the model used after a budget was exceeded, if any
+	DowngradedModel string
+}
+
+// Cost is the price of the tokens used by LLM calls in US dollars
+type Cost struct {
+	Input      float64
+	Output     float64
+	CacheRead  float64
+	CacheWrite float64
+	Total      float64
+}
+
+// Add adds another cost to the cost
+func (c *Cost) Add(other Cost) {
+	c.Input += other.Input
+	c.Output += other.Output
+	c.CacheRead += other.CacheRead
+	c.CacheWrite += other.CacheWrite
+	c.Total += other.Total
 }
@@ -0,0 +1,150 @@
+package interfaces
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// ContentPartType is the kind of content carried by a ContentPart
+type ContentPartType string
+
+const (
+	// ContentPartText is a text part
+	ContentPartText ContentPartType = "text"
+	// ContentPartImage is an image, such as a screenshot or a chart
+	ContentPartImage ContentPartType = "image"
+	// ContentPartDocument is a document, such as a PDF
+	ContentPartDocument ContentPartType = "document"
+	// ContentPartAudio is an audio clip
+	ContentPartAudio ContentPartType = "audio"
+)
+
+// ContentPart is a piece of message content besides the text of the message.
+// Non-text parts reference their content either by URL or inline with Data.
+type ContentPart struct {
+	// Type is the kind of content
+	Type ContentPartType `json:"type"`
+
+	// Text is the text of a text part
+	Text string `json:"text,omitempty"`
+
+	// URL references the content, it can be an http(s) URL or a data URL
+	URL string `json:"url,omitempty"`
+
+	// Data holds the raw bytes of inline content
+	Data []byte `json:"data,omitempty"`
+
+	// MediaType is the MIME type of the content, such as image/png or application/pdf
+	MediaType string `json:"media_type,omitempty"`
+
+	// Name is an optional file name, used for documents
+	Name string `json:"name,omitempty"`
+
+	// Detail is an optional image detail level (low, high, auto) for providers that support it
+	Detail string `json:"detail,omitempty"`
+}
+
+// TextPart creates a text content part
+func TextPart(text string) ContentPart {
+	return ContentPart{Type: ContentPartText, Text: text}
+}
+
+// ImageURLPart creates an image content part referenced by URL
+func ImageURLPart(url string) ContentPart {
+	return ContentPart{Type: ContentPartImage, URL: url}
+}
+
+// ImagePart creates an inline image content part
+func ImagePart(data []byte, mediaType string) ContentPart {
+	return ContentPart{Type: ContentPartImage, Data: data, MediaType: mediaType}
+}
+
+// DocumentURLPart creates a document content part referenced by URL
+func DocumentURLPart(url string) ContentPart {
+	return ContentPart{Type: ContentPartDocument, URL: url, MediaType: "application/pdf"}
+}
+
+// DocumentPart creates an inline document content part. The name is
+// optional, some providers require a file name for documents.
+func DocumentPart(data []byte, mediaType string, name string) ContentPart {
+	return ContentPart{Type: ContentPartDocument, Data: data, MediaType: mediaType, Name: name}
+}
+
+// AudioPart creates an inline audio content part
+func AudioPart(data []byte, mediaType string) ContentPart {
+	return ContentPart{Type: ContentPartAudio, Data: data, MediaType: mediaType}
+}
+
+// Base64 returns the inline data of the part encoded as standard base64
+func (p ContentPart) Base64() string {
+	return base64.StdEncoding.EncodeToString(p.Data)
+}
+
+// DataURL returns the part as a data URL. Parts referenced by URL return the URL.
+func (p ContentPart) DataURL() string {
+	if len(p.Data) == 0 {
+		return p.URL
+	}
+	return fmt.Sprintf("data:%s;base64,%s", p.MediaType, p.Base64())
+}
+
+// Validate checks that the part has content of its type
+func (p ContentPart) Validate() error {
+	switch p.Type {
+	case ContentPartText:
+		return nil
+	case ContentPartImage, ContentPartDocument, ContentPartAudio:
+		if p.URL == "" && len(p.Data) == 0 {
+			return fmt.Errorf("%s content part has neither a URL nor data", p.Type)
+		}
+		if len(p.Data) > 0 && p.MediaType == "" {
+			return fmt.Errorf("%s content part with inline data has no media type", p.Type)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown content part type %q", p.Type)
+	}
+}
+
+// ErrUnsupportedContent is matched by errors returned when a provider or
+// model cannot accept a content part
+var ErrUnsupportedContent = errors.New("unsupported content")
+
+// UnsupportedContentError is returned when a provider or model cannot accept a content part
+type UnsupportedContentError struct {
+	// Provider is the name of the LLM provider
+	Provider string
+
+	// Model is the model the request was made for
+	Model string
+
+	// Type is the type of the rejected content part
+	Type ContentPartType
+
+	// Reason optionally explains the limitation, such as a source the provider cannot read
+	Reason string
+}
+
+// Error implements the error interface
+func (e *UnsupportedContentError) Error() string {
+	msg := fmt.Sprintf("%s model %q does not support %s content", e.Provider, e.Model, e.Type)
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+// Unwrap allows errors.Is(err, ErrUnsupportedContent)
+func (e *UnsupportedContentError) Unwrap() error {
+	return ErrUnsupportedContent
+}
+
+// WithContentParts creates a GenerateOption that attaches content parts, such
+// as images or documents, to the current user turn. Without memory the parts
+// are sent with the prompt, with memory they are added to the last user message.
+func WithContentParts(parts ...ContentPart) GenerateOption {
+	return func(options *GenerateOptions) {
+		options.ContentParts = append(options.ContentParts, parts...)
+	}
+}
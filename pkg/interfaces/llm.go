@@ -35,10 +35,25 @@ type GenerateOptions struct {
 	MaxIterations  int             // Maximum number of tool-calling iterations (0 = use default)
 	Memory         Memory          // Optional memory for storing tool calls and results
 	StreamConfig   *StreamConfig   // Optional streaming configuration
-	CacheConfig    *CacheConfig    // Optional prompt caching configuration (Anthropic only)
+	CacheConfig    *CacheConfig    // Optional prompt caching configuration (Anthropic and Bedrock)
+	ContentParts   []ContentPart   // Optional content parts (images, documents, audio) for the current user turn
+	CallHook       CallHook        // Optional hook called around every request to the provider
 }
 
-// CacheConfig contains configuration for prompt caching (Anthropic only)
+// CallHook observes the requests that a generation sends to the provider,
+// including every round-trip of a tool-calling loop
+type CallHook interface {
+	// BeforeCall is called before each request. An error stops the
+	// generation, which returns it.
+	BeforeCall(ctx context.Context) error
+
+	// AfterCall is called after each response with its model and usage. The
+	// usage is nil when the provider does not report it. Streamed responses
+	// are reported once their stream ends.
+	AfterCall(ctx context.Context, model string, usage *TokenUsage)
+}
+
+// CacheConfig contains configuration for prompt caching (Anthropic and Bedrock)
 // Cache breakpoints cache everything UP TO AND INCLUDING the marked block.
 // Order: tools → system → messages
 type CacheConfig struct {
@@ -85,6 +100,13 @@ func WithStreamConfig(config StreamConfig) GenerateOption {
 	}
 }
 
+// WithCallHook creates a GenerateOption to observe every request to the provider
+func WithCallHook(hook CallHook) GenerateOption {
+	return func(options *GenerateOptions) {
+		options.CallHook = hook
+	}
+}
+
 // WithReasoning creates a GenerateOption to enable native reasoning tokens
 func WithReasoning(enabled bool, budget ...int) GenerateOption {
 	return func(options *GenerateOptions) {
@@ -127,10 +149,10 @@ type TokenUsage struct {
 	// ReasoningTokens is the number of tokens used for reasoning (optional, for models that support it)
 	ReasoningTokens int
 
-	// CacheCreationInputTokens is the number of tokens written to cache (Anthropic only)
+	// CacheCreationInputTokens is the number of tokens written to cache (Anthropic and Bedrock)
 	CacheCreationInputTokens int
 
-	// CacheReadInputTokens is the number of tokens read from cache (Anthropic only)
+	// CacheReadInputTokens is the number of tokens read from cache
 	CacheReadInputTokens int
 }
 
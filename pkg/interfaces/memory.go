@@ -26,6 +26,9 @@ type Message struct {
 	// Content is the content of the message
 	Content string
 
+	// Parts contains additional content of the message, such as images or documents
+	Parts []ContentPart `json:",omitempty"`
+
 	// Metadata contains additional information about the message
 	Metadata map[string]interface{}
 
@@ -0,0 +1,11 @@
+package interfaces
+
+import "context"
+
+// Reranker reorders search results in a second ranking stage, after the
+// candidates have been retrieved from a vector store
+type Reranker interface {
+	// Rerank returns the results in their new order, most relevant first.
+	// Implementations may update the scores and must not add results.
+	Rerank(ctx context.Context, query string, results []SearchResult) ([]SearchResult, error)
+}
@@ -22,6 +22,9 @@ const (
 
 	// Thinking/reasoning events
 	StreamEventThinking StreamEventType = "thinking"
+
+	// Structured output events, carrying the completed part of a JSON response
+	StreamEventPartialObject StreamEventType = "partial_object"
 )
 
 // StreamEvent represents a single event in a stream
@@ -72,6 +75,15 @@ const (
 	AgentEventToolResult AgentEventType = "tool_result"
 	AgentEventError      AgentEventType = "error"
 	AgentEventComplete   AgentEventType = "complete"
+	AgentEventRetrieval  AgentEventType = "retrieval"
+
+	// AgentEventPartialObject carries the completed part of a structured
+	// response as JSON while it streams
+	AgentEventPartialObject AgentEventType = "partial_object"
+
+	// AgentEventApprovalRequest announces a tool call that waits for approval.
+	// The approval ID is in the "approval_id" metadata.
+	AgentEventApprovalRequest AgentEventType = "approval_request"
 )
 
 // ToolCallEvent represents a tool call in streaming context
@@ -82,7 +94,7 @@ type ToolCallEvent struct {
 	Internal    bool   `json:"internal,omitempty"`
 	Arguments   string `json:"arguments,omitempty"`
 	Result      string `json:"result,omitempty"`
-	Status      string `json:"status"` // "starting", "executing", "completed", "error"
+	Status      string `json:"status"` // "starting", "executing", "completed", "error", "pending_approval"
 }
 
 // StreamConfig contains configuration for streaming behavior
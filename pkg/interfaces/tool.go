@@ -1,6 +1,13 @@
 package interfaces
 
-import "context"
+import (
+	"context"
+	"errors"
+)
+
+// ErrRunSuspended is matched by errors.Is for the error of a run that stopped
+// because a tool call waits for approval, see approval.SuspendedError
+var ErrRunSuspended = errors.New("run suspended waiting for tool approval")
 
 // Tool represents a tool that can be used by an agent
 type Tool interface {
@@ -35,6 +42,16 @@ type InternalTool interface {
 	Internal() bool
 }
 
+// ToolWithJSONSchema is an optional interface that tools can implement to
+// describe their arguments with a full JSON schema, which can express nested
+// objects, oneOf, numeric ranges, string patterns and formats. LLM clients
+// send it instead of the schema built from Parameters, and agents validate
+// the arguments of tool calls against it.
+type ToolWithJSONSchema interface {
+	// JSONSchema returns the object schema of the arguments of the tool
+	JSONSchema() JSONSchema
+}
+
 // ParameterSpec defines the specification for a tool parameter
 type ParameterSpec struct {
 	// Type is the data type of the parameter (string, number, boolean, etc.)
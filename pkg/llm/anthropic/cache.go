@@ -13,6 +13,8 @@ type CacheControl struct {
 type CacheableContent struct {
 	Type         string        `json:"type"`                    // "text", "image", "tool_use", etc.
 	Text         string        `json:"text,omitempty"`          // For text content
+	Source       *MediaSource  `json:"source,omitempty"`        // For image and document content
+	Title        string        `json:"title,omitempty"`         // Optional document title
 	CacheControl *CacheControl `json:"cache_control,omitempty"` // Optional cache control
 }
 
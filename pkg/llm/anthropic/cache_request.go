@@ -69,17 +69,16 @@ func (b *cacheRequestBuilder) BuildMessages(messages []Message) (json.RawMessage
 		result[i] = messages[i]
 	}
 
-	// Last message gets cache_control
+	// Last block of the last message gets cache_control
 	lastMsg := messages[len(messages)-1]
+	content := []CacheableContent{{Type: "text", Text: lastMsg.Content}}
+	if len(lastMsg.Media) > 0 {
+		content = lastMsg.contentBlocks()
+	}
+	content[len(content)-1].CacheControl = b.getCacheControl()
 	result[len(messages)-1] = CacheableMessage{
-		Role: lastMsg.Role,
-		Content: []CacheableContent{
-			{
-				Type:         "text",
-				Text:         lastMsg.Content,
-				CacheControl: b.getCacheControl(),
-			},
-		},
+		Role:    lastMsg.Role,
+		Content: content,
 	}
 
 	return json.Marshal(result)
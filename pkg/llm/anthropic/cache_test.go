@@ -175,6 +175,23 @@ func TestCacheRequestBuilder_BuildMessages(t *testing.T) {
 	}
 }
 
+func TestCacheRequestBuilder_BuildMessagesWithMedia(t *testing.T) {
+	messages := []Message{
+		{Role: "user", Content: "Describe", Media: []MediaBlock{
+			{Type: "image", Source: MediaSource{Type: "url", URL: "https://example.com/a.png"}},
+		}},
+	}
+
+	builder := newCacheRequestBuilder(&interfaces.CacheConfig{CacheConversation: true})
+	got, err := builder.BuildMessages(messages)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `[{"role":"user","content":[
+		{"type":"text","text":"Describe"},
+		{"type":"image","source":{"type":"url","url":"https://example.com/a.png"},"cache_control":{"type":"ephemeral"}}
+	]}]`, string(got))
+}
+
 func TestCacheRequestBuilder_BuildTools(t *testing.T) {
 	tools := []Tool{
 		{Name: "calculator", Description: "Does math", InputSchema: map[string]interface{}{"type": "object"}},
@@ -51,7 +51,7 @@ func WithLogger(logger logging.Logger) Option {
 func WithRetry(opts ...retry.Option) Option {
 	return func(c *AnthropicClient) {
 		ctx := context.Background()
-		policy := retry.NewPolicy(opts...)
+		policy := llm.NewRetryPolicy(opts...)
 
 		c.logger.Debug(ctx, "Configuring retry", map[string]interface{}{
 			"vertex_config_enabled": c.VertexConfig != nil && c.VertexConfig.Enabled,
@@ -70,6 +70,7 @@ func WithRetry(opts ...retry.Option) Option {
 				BackoffCoefficient: policy.BackoffCoefficient,
 				MaximumInterval:    policy.MaximumInterval,
 				MaximumAttempts:    policy.MaximumAttempts,
+				Retryable:          policy.Retryable,
 			}
 			c.vertexRetryExecutor = NewVertexRetryExecutor(c.VertexConfig, vertexPolicy)
 			c.logger.Info(ctx, "Created vertex retry executor with multi-region support", map[string]interface{}{
@@ -135,6 +136,7 @@ func WithVertexAI(region, projectID string) Option {
 				BackoffCoefficient: 2.0,
 				MaximumInterval:    time.Second * 30,
 				MaximumAttempts:    3,
+				Retryable:          llm.IsRetryable,
 			}
 			c.vertexRetryExecutor = NewVertexRetryExecutor(c.VertexConfig, policy)
 			c.logger.Info(ctx, "Created vertex retry executor with multi-region support", map[string]interface{}{
@@ -282,8 +284,9 @@ func SupportsThinking(model string) bool {
 
 // Message represents a message for Anthropic API
 type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role    string       `json:"role"`
+	Content string       `json:"content"`
+	Media   []MediaBlock `json:"-"` // Images and documents sent after the text content
 }
 
 // ToolUse represents a tool call for Anthropic API
@@ -364,6 +367,17 @@ type Usage struct {
 	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
 }
 
+// tokenUsage converts the usage to the token usage of a response
+func (u Usage) tokenUsage() *interfaces.TokenUsage {
+	return &interfaces.TokenUsage{
+		InputTokens:              u.InputTokens,
+		OutputTokens:             u.OutputTokens,
+		TotalTokens:              u.InputTokens + u.OutputTokens,
+		CacheCreationInputTokens: u.CacheCreationInputTokens,
+		CacheReadInputTokens:     u.CacheReadInputTokens,
+	}
+}
+
 // WithReasoning creates a GenerateOption to set the reasoning mode
 // Note: Reasoning parameter is not supported in the current Anthropic API version.
 // This option is kept for compatibility but will have no effect.
@@ -462,10 +476,18 @@ func (c *AnthropicClient) generateInternal(ctx context.Context, prompt string, o
 	}
 
 	// Build messages with memory and current prompt
-	messages := c.buildMessagesWithMemory(ctx, prompt, params)
+	messages, err := c.buildMessagesWithMemory(ctx, prompt, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Structured output is forced through a tool whose input is the response,
+	// except with extended thinking, which does not allow forcing a tool
+	reasoning := params.LLMConfig != nil && params.LLMConfig.EnableReasoning && SupportsThinking(c.Model)
+	toolOutput := params.ResponseFormat != nil && !reasoning
 
 	// Handle structured output if requested
-	if params.ResponseFormat != nil {
+	if params.ResponseFormat != nil && !toolOutput {
 		// Convert the schema to a string representation for the prompt
 		schemaJSON, err := json.MarshalIndent(params.ResponseFormat.Schema, "", "  ")
 		if err != nil {
@@ -511,7 +533,7 @@ Return only the JSON object, with no additional text or markdown formatting.`, p
 	}
 
 	// Handle reasoning/thinking if supported
-	if params.LLMConfig != nil && params.LLMConfig.EnableReasoning && SupportsThinking(c.Model) {
+	if reasoning {
 		req.Thinking = &ReasoningSpec{
 			Type: "enabled",
 		}
@@ -538,8 +560,16 @@ Return only the JSON object, with no additional text or markdown formatting.`, p
 		req.System = params.SystemMessage
 	}
 
+	if toolOutput {
+		tool := structuredOutputTool(params.ResponseFormat)
+		req.Tools = []Tool{tool}
+		req.ToolChoice = map[string]string{
+			"type": "tool",
+			"name": tool.Name,
+		}
+	}
+
 	var resp CompletionResponse
-	var err error
 
 	operation := func() error {
 		var apiType string
@@ -597,7 +627,7 @@ Return only the JSON object, with no additional text or markdown formatting.`, p
 		// Perform the request
 		httpResp, err := c.HTTPClient.Do(httpReq)
 		if err != nil {
-			return fmt.Errorf("failed to send request to %s: %w", apiType, err)
+			return fmt.Errorf("failed to send request to %s: %w", apiType, llm.WrapError(apiType, err))
 		}
 		defer func() {
 			if err := httpResp.Body.Close(); err != nil {
@@ -614,7 +644,7 @@ Return only the JSON object, with no additional text or markdown formatting.`, p
 
 		// Check for HTTP errors
 		if httpResp.StatusCode != http.StatusOK {
-			return fmt.Errorf("%s API error (status %d): %s", apiType, httpResp.StatusCode, string(body))
+			return llm.NewAPIError(apiType, httpResp.StatusCode, httpResp.Header, body)
 		}
 
 		// Parse response
@@ -638,23 +668,32 @@ Return only the JSON object, with no additional text or markdown formatting.`, p
 		return nil, err
 	}
 
-	// Extract text from content blocks
-	var contentText []string
-	for _, block := range resp.Content {
-		if block.Type == "text" {
-			contentText = append(contentText, block.Text)
+	var content string
+	if toolOutput {
+		// The response is the input of the forced tool call
+		content, err = structuredOutputContent(resp.Content)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// Extract text from content blocks
+		var contentText []string
+		for _, block := range resp.Content {
+			if block.Type == "text" {
+				contentText = append(contentText, block.Text)
+			}
 		}
-	}
 
-	if len(contentText) == 0 {
-		return nil, fmt.Errorf("no text content in response")
-	}
+		if len(contentText) == 0 {
+			return nil, fmt.Errorf("no text content in response")
+		}
 
-	content := strings.Join(contentText, "\n")
+		content = strings.Join(contentText, "\n")
 
-	// For structured output, prepend the opening brace that was used as prefill
-	if params.ResponseFormat != nil && !strings.HasPrefix(strings.TrimSpace(content), "{") {
-		content = "{" + content
+		// For structured output, prepend the opening brace that was used as prefill
+		if params.ResponseFormat != nil && !strings.HasPrefix(strings.TrimSpace(content), "{") {
+			content = "{" + content
+		}
 	}
 
 	// Create detailed response
@@ -662,13 +701,7 @@ Return only the JSON object, with no additional text or markdown formatting.`, p
 		Content:    content,
 		Model:      resp.Model,
 		StopReason: resp.StopReason,
-		Usage: &interfaces.TokenUsage{
-			InputTokens:              resp.Usage.InputTokens,
-			OutputTokens:             resp.Usage.OutputTokens,
-			TotalTokens:              resp.Usage.InputTokens + resp.Usage.OutputTokens,
-			CacheCreationInputTokens: resp.Usage.CacheCreationInputTokens,
-			CacheReadInputTokens:     resp.Usage.CacheReadInputTokens,
-		},
+		Usage:      resp.Usage.tokenUsage(),
 		Metadata: map[string]interface{}{
 			"provider": "anthropic",
 		},
@@ -718,12 +751,20 @@ func (c *AnthropicClient) Chat(ctx context.Context, messages []llm.Message, para
 			Role:    role,
 			Content: msg.Content,
 		}
+		if role == "user" && len(msg.Parts) > 0 {
+			text, media, err := convertUserContent(c.Model, msg.Content, msg.Parts)
+			if err != nil {
+				return "", err
+			}
+			anthropicMessages[i].Content = text
+			anthropicMessages[i].Media = media
+		}
 	}
 
 	// Filter out any nil messages (from system messages being skipped) and messages with empty content
 	var filteredMessages []Message
 	for _, msg := range anthropicMessages {
-		if msg.Role != "" && strings.TrimSpace(msg.Content) != "" {
+		if msg.Role != "" && (strings.TrimSpace(msg.Content) != "" || len(msg.Media) > 0) {
 			filteredMessages = append(filteredMessages, msg)
 		}
 	}
@@ -807,7 +848,7 @@ func (c *AnthropicClient) Chat(ctx context.Context, messages []llm.Message, para
 				"error": err.Error(),
 				"model": c.Model,
 			})
-			return fmt.Errorf("failed to send request: %w", err)
+			return fmt.Errorf("failed to send request: %w", llm.WrapError(c.providerName(), err))
 		}
 		defer func() {
 			if closeErr := httpResp.Body.Close(); closeErr != nil {
@@ -830,7 +871,7 @@ func (c *AnthropicClient) Chat(ctx context.Context, messages []llm.Message, para
 				"response":    string(respBody),
 				"model":       c.Model,
 			})
-			return fmt.Errorf("error from Anthropic API: %s", string(respBody))
+			return llm.NewAPIError(c.providerName(), httpResp.StatusCode, httpResp.Header, respBody)
 		}
 
 		// Log raw response before unmarshaling for debugging
@@ -958,45 +999,10 @@ func (c *AnthropicClient) GenerateWithTools(ctx context.Context, prompt string,
 	// Convert tools to Anthropic format
 	anthropicTools := make([]Tool, len(tools))
 	for i, tool := range tools {
-		// Convert ParameterSpec to JSON Schema
-		properties := make(map[string]interface{})
-		required := []string{}
-
-		for name, param := range tool.Parameters() {
-			properties[name] = map[string]interface{}{
-				"type":        param.Type,
-				"description": param.Description,
-			}
-			if param.Default != nil {
-				properties[name].(map[string]interface{})["default"] = param.Default
-			}
-			if param.Required {
-				required = append(required, name)
-			}
-			if param.Items != nil {
-				properties[name].(map[string]interface{})["items"] = map[string]interface{}{
-					"type": param.Items.Type,
-				}
-				if param.Items.Enum != nil {
-					properties[name].(map[string]interface{})["items"].(map[string]interface{})["enum"] = param.Items.Enum
-				}
-			}
-			if param.Enum != nil {
-				properties[name].(map[string]interface{})["enum"] = param.Enum
-			}
-		}
-
-		// Create the input schema for this tool
-		inputSchema := map[string]interface{}{
-			"type":       "object",
-			"properties": properties,
-			"required":   required,
-		}
-
 		anthropicTools[i] = Tool{
 			Name:        tool.Name(),
 			Description: tool.Description(),
-			InputSchema: inputSchema,
+			InputSchema: llm.ToolSchema(tool),
 		}
 	}
 
@@ -1004,7 +1010,10 @@ func (c *AnthropicClient) GenerateWithTools(ctx context.Context, prompt string,
 	toolCallHistory := make(map[string]int)
 
 	// Build messages with memory and current prompt
-	messages := c.buildMessagesWithMemory(ctx, prompt, params)
+	messages, err := c.buildMessagesWithMemory(ctx, prompt, params)
+	if err != nil {
+		return "", err
+	}
 
 	// Calculate maxTokens - must be greater than budget_tokens when reasoning is enabled
 	maxTokens := 2048 // default
@@ -1061,6 +1070,10 @@ func (c *AnthropicClient) GenerateWithTools(ctx context.Context, prompt string,
 			"maxIterations": maxIterations,
 		})
 
+		if err := llm.BeforeCall(ctx, params); err != nil {
+			return "", err
+		}
+
 		var resp CompletionResponse
 		var err error
 
@@ -1080,7 +1093,7 @@ func (c *AnthropicClient) GenerateWithTools(ctx context.Context, prompt string,
 					"model":     c.Model,
 					"iteration": iteration + 1,
 				})
-				return fmt.Errorf("failed to send request (iteration %d): %w", iteration+1, err)
+				return fmt.Errorf("failed to send request (iteration %d): %w", iteration+1, llm.WrapError(c.providerName(), err))
 			}
 			defer func() {
 				if closeErr := httpResp.Body.Close(); closeErr != nil {
@@ -1104,7 +1117,7 @@ func (c *AnthropicClient) GenerateWithTools(ctx context.Context, prompt string,
 					"model":       c.Model,
 					"iteration":   iteration + 1,
 				})
-				return fmt.Errorf("error from Anthropic API (iteration %d): %s", iteration+1, string(respBody))
+				return fmt.Errorf("iteration %d: %w", iteration+1, llm.NewAPIError(c.providerName(), httpResp.StatusCode, httpResp.Header, respBody))
 			}
 
 			// Log raw response before unmarshaling for debugging
@@ -1160,6 +1173,7 @@ func (c *AnthropicClient) GenerateWithTools(ctx context.Context, prompt string,
 		if err != nil {
 			return "", err
 		}
+		llm.AfterCall(ctx, params, resp.Model, resp.Usage.tokenUsage())
 
 		// Make sure content is not nil
 		if resp.Content == nil {
@@ -1371,6 +1385,10 @@ CRITICAL INSTRUCTIONS:
 		"messages": len(finalReq.Messages),
 	})
 
+	if err := llm.BeforeCall(ctx, params); err != nil {
+		return "", err
+	}
+
 	// Create final HTTP request (supports both Vertex AI and standard Anthropic API)
 	finalHTTPReq, err := c.createHTTPRequest(ctx, &finalReq, "/v1/messages")
 	if err != nil {
@@ -1381,7 +1399,7 @@ CRITICAL INSTRUCTIONS:
 	finalHTTPResp, err := c.HTTPClient.Do(finalHTTPReq)
 	if err != nil {
 		c.logger.Error(ctx, "Error in final call without tools", map[string]interface{}{"error": err.Error()})
-		return "", fmt.Errorf("failed to send final request: %w", err)
+		return "", fmt.Errorf("failed to send final request: %w", llm.WrapError(c.providerName(), err))
 	}
 	defer func() {
 		if closeErr := finalHTTPResp.Body.Close(); closeErr != nil {
@@ -1403,7 +1421,7 @@ CRITICAL INSTRUCTIONS:
 			"status_code": finalHTTPResp.StatusCode,
 			"response":    string(finalRespBody),
 		})
-		return "", fmt.Errorf("error from Anthropic API in final call: %s", string(finalRespBody))
+		return "", fmt.Errorf("final call: %w", llm.NewAPIError(c.providerName(), finalHTTPResp.StatusCode, finalHTTPResp.Header, finalRespBody))
 	}
 
 	// Log raw final response before unmarshaling for debugging
@@ -1439,6 +1457,7 @@ CRITICAL INSTRUCTIONS:
 		})
 		return "", fmt.Errorf("failed to unmarshal final response: %w", err)
 	}
+	llm.AfterCall(ctx, params, finalResp.Model, finalResp.Usage.tokenUsage())
 
 	// Extract text content from final response
 	if finalResp.Content == nil {
@@ -1630,6 +1649,14 @@ func (c *AnthropicClient) SupportsStreaming() bool {
 	return true
 }
 
+// providerName returns the provider name used in errors
+func (c *AnthropicClient) providerName() string {
+	if c.VertexConfig != nil && c.VertexConfig.Enabled {
+		return "vertex"
+	}
+	return "anthropic"
+}
+
 // GetModel returns the model name being used
 func (c *AnthropicClient) GetModel() string {
 	return c.Model
@@ -1708,7 +1735,11 @@ func getExampleValue(prop map[string]interface{}) interface{} {
 	switch propType {
 	case "string":
 		if description != "" {
-			return "example_" + strings.ToLower(strings.ReplaceAll(description, " ", "_"))[:20]
+			example := strings.ToLower(strings.ReplaceAll(description, " ", "_"))
+			if len(example) > 20 {
+				example = example[:20]
+			}
+			return "example_" + example
 		}
 		return "example_string"
 	case "number":
@@ -2079,7 +2110,7 @@ func (c *AnthropicClient) executeToolsParallel(
 }
 
 // buildMessagesWithMemory builds Anthropic messages from memory and current prompt
-func (c *AnthropicClient) buildMessagesWithMemory(ctx context.Context, prompt string, params *interfaces.GenerateOptions) []Message {
-	builder := newMessageHistoryBuilder(c.logger)
+func (c *AnthropicClient) buildMessagesWithMemory(ctx context.Context, prompt string, params *interfaces.GenerateOptions) ([]Message, error) {
+	builder := newMessageHistoryBuilder(c.logger, c.Model)
 	return builder.buildMessages(ctx, prompt, params)
 }
@@ -0,0 +1,107 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// MediaSource is the source of an image or document block
+type MediaSource struct {
+	Type      string `json:"type"`                 // "base64", "url" or "text"
+	MediaType string `json:"media_type,omitempty"` // MIME type for base64 and text sources
+	Data      string `json:"data,omitempty"`       // Base64 data, or plain text for text sources
+	URL       string `json:"url,omitempty"`        // URL for url sources
+}
+
+// MediaBlock is an image or document content block of a user message
+type MediaBlock struct {
+	Type   string      `json:"type"` // "image" or "document"
+	Source MediaSource `json:"source"`
+	Title  string      `json:"title,omitempty"` // Optional document title
+}
+
+// MarshalJSON sends messages with media as an array of content blocks and
+// other messages with plain string content
+func (m Message) MarshalJSON() ([]byte, error) {
+	if len(m.Media) == 0 {
+		return json.Marshal(struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		}{m.Role, m.Content})
+	}
+
+	return json.Marshal(struct {
+		Role    string             `json:"role"`
+		Content []CacheableContent `json:"content"`
+	}{m.Role, m.contentBlocks()})
+}
+
+// contentBlocks returns the text of the message followed by its media blocks
+func (m Message) contentBlocks() []CacheableContent {
+	blocks := make([]CacheableContent, 0, len(m.Media)+1)
+	if m.Content != "" {
+		blocks = append(blocks, CacheableContent{Type: "text", Text: m.Content})
+	}
+	for _, media := range m.Media {
+		source := media.Source
+		blocks = append(blocks, CacheableContent{Type: media.Type, Source: &source, Title: media.Title})
+	}
+	return blocks
+}
+
+// convertUserContent converts the text and content parts of a user message.
+// Text parts are appended to the text, other parts become media blocks.
+func convertUserContent(model, text string, parts []interfaces.ContentPart) (string, []MediaBlock, error) {
+	var media []MediaBlock
+	for _, part := range parts {
+		if err := part.Validate(); err != nil {
+			return "", nil, err
+		}
+		if part.Type == interfaces.ContentPartText {
+			if text != "" {
+				text += "\n"
+			}
+			text += part.Text
+			continue
+		}
+
+		block, err := convertContentPart(model, part)
+		if err != nil {
+			return "", nil, err
+		}
+		media = append(media, block)
+	}
+	return text, media, nil
+}
+
+// convertContentPart converts an image or document part to a media block
+func convertContentPart(model string, part interfaces.ContentPart) (MediaBlock, error) {
+	if part.Type == interfaces.ContentPartAudio || !modelSupportsMedia(model) {
+		return MediaBlock{}, &interfaces.UnsupportedContentError{Provider: "anthropic", Model: model, Type: part.Type}
+	}
+
+	block := MediaBlock{Type: string(part.Type)}
+	if part.Type == interfaces.ContentPartDocument {
+		block.Title = part.Name
+	}
+
+	switch {
+	case len(part.Data) == 0:
+		block.Source = MediaSource{Type: "url", URL: part.URL}
+	case part.Type == interfaces.ContentPartDocument && strings.HasPrefix(part.MediaType, "text/"):
+		block.Source = MediaSource{Type: "text", MediaType: "text/plain", Data: string(part.Data)}
+	default:
+		block.Source = MediaSource{Type: "base64", MediaType: part.MediaType, Data: part.Base64()}
+	}
+
+	return block, nil
+}
+
+// modelSupportsMedia reports whether a model accepts images and documents,
+// which all models since Claude 3 do
+func modelSupportsMedia(model string) bool {
+	model = strings.ToLower(model)
+	return !strings.Contains(model, "claude-2") && !strings.Contains(model, "claude-instant")
+}
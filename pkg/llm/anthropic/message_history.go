@@ -6,24 +6,28 @@ import (
 	"fmt"
 
 	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/llm"
 	"github.com/tagus/agent-sdk-go/pkg/logging"
 )
 
 // messageHistoryBuilder builds Anthropic-compatible message history from memory and current prompt
 type messageHistoryBuilder struct {
 	logger logging.Logger
+	model  string
 }
 
 // newMessageHistoryBuilder creates a new message history builder
-func newMessageHistoryBuilder(logger logging.Logger) *messageHistoryBuilder {
+func newMessageHistoryBuilder(logger logging.Logger, model string) *messageHistoryBuilder {
 	return &messageHistoryBuilder{
 		logger: logger,
+		model:  model,
 	}
 }
 
 // buildMessages constructs Anthropic messages from memory and current prompt
-// Returns messages ready for Anthropic API calls, preserving chronological order
-func (b *messageHistoryBuilder) buildMessages(ctx context.Context, prompt string, params *interfaces.GenerateOptions) []Message {
+// Returns messages ready for Anthropic API calls, preserving chronological order.
+// Content parts are attached to the current user turn.
+func (b *messageHistoryBuilder) buildMessages(ctx context.Context, prompt string, params *interfaces.GenerateOptions) ([]Message, error) {
 	messages := []Message{}
 
 	// Add memory messages
@@ -35,8 +39,11 @@ func (b *messageHistoryBuilder) buildMessages(ctx context.Context, prompt string
 			})
 		} else {
 			// Convert memory messages to Anthropic format, preserving chronological order
-			for _, msg := range memoryMessages {
-				anthropicMsg := b.convertMemoryMessage(msg)
+			for _, msg := range llm.AttachContentParts(memoryMessages, prompt, params.ContentParts) {
+				anthropicMsg, err := b.convertMemoryMessage(msg)
+				if err != nil {
+					return nil, err
+				}
 				if anthropicMsg != nil {
 					messages = append(messages, *anthropicMsg)
 				}
@@ -44,23 +51,33 @@ func (b *messageHistoryBuilder) buildMessages(ctx context.Context, prompt string
 		}
 	} else {
 		// Only append current user message when memory is nil
+		content, media, err := convertUserContent(b.model, prompt, params.ContentParts)
+		if err != nil {
+			return nil, err
+		}
 		messages = append(messages, Message{
 			Role:    "user",
-			Content: prompt,
+			Content: content,
+			Media:   media,
 		})
 	}
 
-	return messages
+	return messages, nil
 }
 
 // convertMemoryMessage converts a memory message to Anthropic format
-func (b *messageHistoryBuilder) convertMemoryMessage(msg interfaces.Message) *Message {
+func (b *messageHistoryBuilder) convertMemoryMessage(msg interfaces.Message) (*Message, error) {
 	switch msg.Role {
 	case interfaces.MessageRoleUser:
+		content, media, err := convertUserContent(b.model, msg.Content, msg.Parts)
+		if err != nil {
+			return nil, err
+		}
 		return &Message{
 			Role:    "user",
-			Content: msg.Content,
-		}
+			Content: content,
+			Media:   media,
+		}, nil
 
 	case interfaces.MessageRoleAssistant:
 		if len(msg.ToolCalls) > 0 {
@@ -110,13 +127,13 @@ func (b *messageHistoryBuilder) convertMemoryMessage(msg interfaces.Message) *Me
 			return &Message{
 				Role:    "assistant",
 				Content: content,
-			}
+			}, nil
 		} else if msg.Content != "" {
 			// Regular assistant message
 			return &Message{
 				Role:    "assistant",
 				Content: msg.Content,
-			}
+			}, nil
 		}
 
 	case interfaces.MessageRoleTool:
@@ -125,15 +142,15 @@ func (b *messageHistoryBuilder) convertMemoryMessage(msg interfaces.Message) *Me
 			return &Message{
 				Role:    "user",
 				Content: fmt.Sprintf("Tool result for %s: %s", msg.ToolCallID, msg.Content),
-			}
+			}, nil
 		}
 
 	case interfaces.MessageRoleSystem:
 		return &Message{
 			Role:    "user", // System instruction is handled separately, other system (like summarized) are passed as user messages
 			Content: fmt.Sprintf("System: %s", msg.Content),
-		}
+		}, nil
 	}
 
-	return nil
+	return nil, nil
 }
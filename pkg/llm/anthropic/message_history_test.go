@@ -2,6 +2,8 @@ package anthropic
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"testing"
 
 	"github.com/tagus/agent-sdk-go/pkg/interfaces"
@@ -10,7 +12,7 @@ import (
 
 func TestMessageHistoryBuilder_BuildMessages(t *testing.T) {
 	logger := logging.New()
-	builder := newMessageHistoryBuilder(logger)
+	builder := newMessageHistoryBuilder(logger, "claude-sonnet-4-20250514")
 
 	tests := []struct {
 		name     string
@@ -82,7 +84,10 @@ func TestMessageHistoryBuilder_BuildMessages(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			messages := builder.buildMessages(context.Background(), tt.prompt, tt.params)
+			messages, err := builder.buildMessages(context.Background(), tt.prompt, tt.params)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
 			if len(messages) != tt.expected {
 				t.Errorf("Expected %d messages, got %d", tt.expected, len(messages))
 			}
@@ -90,6 +95,44 @@ func TestMessageHistoryBuilder_BuildMessages(t *testing.T) {
 	}
 }
 
+func TestMessageHistoryBuilder_ContentParts(t *testing.T) {
+	builder := newMessageHistoryBuilder(logging.New(), "claude-sonnet-4-20250514")
+
+	messages, err := builder.buildMessages(context.Background(), "Summarize", &interfaces.GenerateOptions{
+		ContentParts: []interfaces.ContentPart{
+			interfaces.ImagePart([]byte("png"), "image/png"),
+			interfaces.DocumentURLPart("https://example.com/report.pdf"),
+			interfaces.DocumentPart([]byte("notes"), "text/plain", "Notes"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	data, _ := json.Marshal(messages[0])
+	want := `{"role":"user","content":[` +
+		`{"type":"text","text":"Summarize"},` +
+		`{"type":"image","source":{"type":"base64","media_type":"image/png","data":"cG5n"}},` +
+		`{"type":"document","source":{"type":"url","url":"https://example.com/report.pdf"}},` +
+		`{"type":"document","source":{"type":"text","media_type":"text/plain","data":"notes"},"title":"Notes"}]}`
+	if string(data) != want {
+		t.Errorf("Expected %s, got %s", want, data)
+	}
+
+	// Messages without media keep string content
+	data, _ = json.Marshal(Message{Role: "user", Content: "Hi"})
+	if string(data) != `{"role":"user","content":"Hi"}` {
+		t.Errorf("Unexpected plain message %s", data)
+	}
+
+	_, err = builder.buildMessages(context.Background(), "Listen", &interfaces.GenerateOptions{
+		ContentParts: []interfaces.ContentPart{interfaces.AudioPart([]byte("wav"), "audio/wav")},
+	})
+	if !errors.Is(err, interfaces.ErrUnsupportedContent) {
+		t.Errorf("Expected unsupported content error, got %v", err)
+	}
+}
+
 // mockMemory is a simple mock implementation for testing
 type mockMemory struct {
 	messages []interfaces.Message
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/llm"
 )
 
 // AnthropicSSEEvent represents the structure of Anthropic's SSE events
@@ -266,7 +267,7 @@ func (c *AnthropicClient) convertAnthropicEventToStreamEvent(event *AnthropicSSE
 		}
 
 		streamEvent.Type = interfaces.StreamEventError
-		streamEvent.Error = fmt.Errorf("anthropic api error: %v", errorData)
+		streamEvent.Error = llm.NewAPIError("anthropic", 0, nil, event.Data)
 		streamEvent.Metadata["error_data"] = errorData
 
 	case "input_json_delta":
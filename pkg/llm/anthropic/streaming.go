@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/llm"
 	"github.com/tagus/agent-sdk-go/pkg/multitenancy"
 )
 
@@ -53,8 +54,11 @@ func (c *AnthropicClient) GenerateStream(
 	}
 
 	// Build messages using unified builder
-	builder := newMessageHistoryBuilder(c.logger)
-	messages := builder.buildMessages(ctx, prompt, params)
+	builder := newMessageHistoryBuilder(c.logger, c.Model)
+	messages, err := builder.buildMessages(ctx, prompt, params)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create request with streaming enabled
 	// Note: MaxTokens must be greater than reasoning budget_tokens
@@ -199,7 +203,7 @@ func (c *AnthropicClient) executeStreamingRequestWithMemory(
 				"error": err.Error(),
 				"model": c.Model,
 			})
-			return fmt.Errorf("failed to send request: %w", err)
+			return fmt.Errorf("failed to send request: %w", llm.WrapError(c.providerName(), err))
 		}
 		defer func() {
 			if closeErr := httpResp.Body.Close(); closeErr != nil {
@@ -225,10 +229,7 @@ func (c *AnthropicClient) executeStreamingRequestWithMemory(
 				"content_type": httpResp.Header.Get("Content-Type"),
 			})
 
-			if len(errorBody) > 0 {
-				return fmt.Errorf("error from Anthropic API: HTTP %d - %s", httpResp.StatusCode, string(errorBody))
-			}
-			return fmt.Errorf("error from Anthropic API: HTTP %d", httpResp.StatusCode)
+			return llm.NewAPIError(c.providerName(), httpResp.StatusCode, httpResp.Header, errorBody)
 		}
 
 		// Verify content type
@@ -330,45 +331,10 @@ func (c *AnthropicClient) GenerateWithToolsStream(
 	// Convert tools to Anthropic format
 	anthropicTools := make([]Tool, len(tools))
 	for i, tool := range tools {
-		// Convert ParameterSpec to JSON Schema
-		properties := make(map[string]interface{})
-		required := []string{}
-
-		for name, param := range tool.Parameters() {
-			properties[name] = map[string]interface{}{
-				"type":        param.Type,
-				"description": param.Description,
-			}
-			if param.Default != nil {
-				properties[name].(map[string]interface{})["default"] = param.Default
-			}
-			if param.Required {
-				required = append(required, name)
-			}
-			if param.Items != nil {
-				properties[name].(map[string]interface{})["items"] = map[string]interface{}{
-					"type": param.Items.Type,
-				}
-				if param.Items.Enum != nil {
-					properties[name].(map[string]interface{})["items"].(map[string]interface{})["enum"] = param.Items.Enum
-				}
-			}
-			if param.Enum != nil {
-				properties[name].(map[string]interface{})["enum"] = param.Enum
-			}
-		}
-
-		// Create the input schema for this tool
-		inputSchema := map[string]interface{}{
-			"type":       "object",
-			"properties": properties,
-			"required":   required,
-		}
-
 		anthropicTools[i] = Tool{
 			Name:        tool.Name(),
 			Description: tool.Description(),
-			InputSchema: inputSchema,
+			InputSchema: llm.ToolSchema(tool),
 		}
 	}
 
@@ -421,8 +387,11 @@ func (c *AnthropicClient) executeStreamingWithTools(
 	eventChan chan<- interfaces.StreamEvent,
 ) error {
 	// Build messages using unified builder
-	builder := newMessageHistoryBuilder(c.logger)
-	messages := builder.buildMessages(ctx, prompt, params)
+	builder := newMessageHistoryBuilder(c.logger, c.Model)
+	messages, err := builder.buildMessages(ctx, prompt, params)
+	if err != nil {
+		return err
+	}
 
 	// Get maxIterations from params
 	maxIterations := 2 // Default to match non-streaming behavior
@@ -497,7 +466,12 @@ func (c *AnthropicClient) executeStreamingWithTools(
 		if params.StreamConfig != nil && params.StreamConfig.IncludeIntermediateMessages {
 			filterContentDeltas = false
 		}
-		toolCalls, hasContent, capturedContentEvents, err := c.executeStreamingRequestWithToolCapture(ctx, req, eventChan, filterContentDeltas)
+		if err := llm.BeforeCall(ctx, params); err != nil {
+			return err
+		}
+
+		round := streamRound{model: c.Model}
+		toolCalls, hasContent, capturedContentEvents, err := c.executeStreamingRequestWithToolCapture(ctx, req, eventChan, filterContentDeltas, &round)
 		if err != nil {
 			c.logger.Error(ctx, "[LLM RESPONSE DEBUG] LLM call failed", map[string]interface{}{
 				"iteration": iteration + 1,
@@ -505,6 +479,7 @@ func (c *AnthropicClient) executeStreamingWithTools(
 			})
 			return err
 		}
+		llm.AfterCall(ctx, params, round.model, round.tokenUsage())
 		c.logger.Info(ctx, "[LLM RESPONSE DEBUG] LLM response received", map[string]interface{}{
 			"iteration":      iteration + 1,
 			"toolCallsCount": len(toolCalls),
@@ -814,7 +789,11 @@ CRITICAL INSTRUCTIONS:
 		"finalCallNumber": finalIterationCount + 1,
 		"messageCount":    len(finalMessages),
 	})
-	err := c.executeStreamingRequestWithMemory(ctx, finalReq, eventChan, "", params)
+	if err := llm.BeforeCall(ctx, params); err != nil {
+		return err
+	}
+
+	err = c.executeStreamingRound(ctx, finalReq, eventChan, params)
 	if err != nil {
 		c.logger.Error(ctx, "[LLM RESPONSE DEBUG] Final synthesis call failed", map[string]interface{}{
 			"error": err.Error(),
@@ -833,12 +812,15 @@ func (c *AnthropicClient) createFilteredEventForwarder(
 	tempEventChan <-chan interfaces.StreamEvent,
 	eventChan chan<- interfaces.StreamEvent,
 	filterContentDeltas bool,
+	round *streamRound,
 ) ([]interfaces.ToolCall, bool, []interfaces.StreamEvent, error) {
 	var toolCalls []interfaces.ToolCall
 	var hasContent bool
 	var capturedContentEvents []interfaces.StreamEvent
 
 	for event := range tempEventChan {
+		round.add(event)
+
 		// Always capture content events for conversation history (not just when filtering)
 		if event.Type == interfaces.StreamEventContentDelta && event.Content != "" {
 			hasContent = true
@@ -877,6 +859,7 @@ func (c *AnthropicClient) executeStreamingRequestWithToolCapture(
 	req CompletionRequest,
 	eventChan chan<- interfaces.StreamEvent,
 	filterContentDeltas bool,
+	round *streamRound,
 ) ([]interfaces.ToolCall, bool, []interfaces.StreamEvent, error) {
 
 	// Create temporary channel to capture events
@@ -909,6 +892,77 @@ func (c *AnthropicClient) executeStreamingRequestWithToolCapture(
 	}()
 
 	// Process events with optional filtering
-	return c.createFilteredEventForwarder(ctx, tempEventChan, eventChan, filterContentDeltas)
+	return c.createFilteredEventForwarder(ctx, tempEventChan, eventChan, filterContentDeltas, round)
+}
+
+// executeStreamingRound executes a streaming request, forwards its events and
+// reports the request to the call hook once its stream ends
+func (c *AnthropicClient) executeStreamingRound(
+	ctx context.Context,
+	req CompletionRequest,
+	eventChan chan<- interfaces.StreamEvent,
+	params *interfaces.GenerateOptions,
+) error {
+	roundEventChan := make(chan interfaces.StreamEvent, 100)
+	errChan := make(chan error, 1)
+	go func() {
+		defer close(roundEventChan)
+		errChan <- c.executeStreamingRequestWithMemory(ctx, req, roundEventChan, "", params)
+	}()
+
+	round := streamRound{model: c.Model}
+	for event := range roundEventChan {
+		round.add(event)
+		eventChan <- event
+	}
+	if err := <-errChan; err != nil {
+		return err
+	}
+
+	llm.AfterCall(ctx, params, round.model, round.tokenUsage())
+	return nil
+}
+
+// streamRound collects the model and usage of a streamed request from its
+// message_start and message_delta events
+type streamRound struct {
+	model string
+	usage *Usage
+}
+
+// add records the model and usage carried by a stream event. The output
+// tokens of message_delta events are cumulative.
+func (r *streamRound) add(event interfaces.StreamEvent) {
+	if model, ok := event.Metadata["model"].(string); ok && model != "" {
+		r.model = model
+	}
+	usage, ok := event.Metadata["usage"].(Usage)
+	if !ok {
+		return
+	}
+	if r.usage == nil {
+		r.usage = &Usage{}
+	}
+	if usage.InputTokens > 0 {
+		r.usage.InputTokens = usage.InputTokens
+	}
+	if usage.OutputTokens > 0 {
+		r.usage.OutputTokens = usage.OutputTokens
+	}
+	if usage.CacheCreationInputTokens > 0 {
+		r.usage.CacheCreationInputTokens = usage.CacheCreationInputTokens
+	}
+	if usage.CacheReadInputTokens > 0 {
+		r.usage.CacheReadInputTokens = usage.CacheReadInputTokens
+	}
+}
+
+// tokenUsage returns the usage of the request, or nil if the stream did not
+// report it
+func (r *streamRound) tokenUsage() *interfaces.TokenUsage {
+	if r.usage == nil {
+		return nil
+	}
+	return r.usage.tokenUsage()
 }
 
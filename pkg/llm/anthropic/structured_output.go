@@ -0,0 +1,58 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// defaultStructuredOutputTool is the name of the structured output tool when
+// the response format name is not a valid tool name
+const defaultStructuredOutputTool = "structured_output"
+
+var toolNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// structuredOutputTool returns the tool that Claude is forced to call to
+// produce a response in the given format
+func structuredOutputTool(format *interfaces.ResponseFormat) Tool {
+	name := format.Name
+	if !toolNamePattern.MatchString(name) {
+		name = defaultStructuredOutputTool
+	}
+
+	schema := map[string]interface{}(format.Schema)
+	if schema == nil {
+		schema = map[string]interface{}{"type": "object"}
+	}
+
+	return Tool{
+		Name:        name,
+		Description: "Respond to the user with a JSON object that follows the input schema of this tool.",
+		InputSchema: schema,
+	}
+}
+
+// structuredOutputContent returns the input of the structured output tool
+// call of a response as JSON
+func structuredOutputContent(blocks []ContentBlock) (string, error) {
+	for _, block := range blocks {
+		if block.Type != "tool_use" {
+			continue
+		}
+		input := block.Input
+		if input == nil && block.ToolUse != nil {
+			input = block.ToolUse.Input
+		}
+		if input == nil {
+			input = map[string]interface{}{}
+		}
+		data, err := json.Marshal(input)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode structured output: %w", err)
+		}
+		return string(data), nil
+	}
+	return "", fmt.Errorf("no structured output in response")
+}
@@ -0,0 +1,62 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+func TestGenerateStructuredOutput(t *testing.T) {
+	var request map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+			"content": []map[string]interface{}{
+				{"type": "tool_use", "id": "toolu_1", "name": "Weather", "input": map[string]interface{}{"city": "Paris", "temperature": 21}},
+			},
+			"model":       "claude-sonnet-4-20250514",
+			"stop_reason": "tool_use",
+		}))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithModel("claude-sonnet-4-20250514"))
+	format := interfaces.ResponseFormat{
+		Type: interfaces.ResponseFormatJSON,
+		Name: "Weather",
+		Schema: interfaces.JSONSchema{
+			"type":       "object",
+			"properties": map[string]interface{}{"city": map[string]interface{}{"type": "string"}},
+		},
+	}
+
+	response, err := client.Generate(context.Background(), "What is the weather in Paris?", interfaces.WithResponseFormat(format))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"city": "Paris", "temperature": 21}`, response)
+
+	// The prompt is sent unchanged and the tool is forced
+	messages := request["messages"].([]interface{})
+	assert.Equal(t, "What is the weather in Paris?", messages[len(messages)-1].(map[string]interface{})["content"])
+	assert.Equal(t, map[string]interface{}{"type": "tool", "name": "Weather"}, request["tool_choice"])
+	tools := request["tools"].([]interface{})
+	require.Len(t, tools, 1)
+	assert.Equal(t, "object", tools[0].(map[string]interface{})["input_schema"].(map[string]interface{})["type"])
+}
+
+func TestStructuredOutputTool(t *testing.T) {
+	tool := structuredOutputTool(&interfaces.ResponseFormat{Name: "my format"})
+	assert.Equal(t, defaultStructuredOutputTool, tool.Name)
+	assert.Equal(t, "object", tool.InputSchema["type"])
+
+	_, err := structuredOutputContent([]ContentBlock{{Type: "text", Text: "no tool call"}})
+	assert.Error(t, err)
+}
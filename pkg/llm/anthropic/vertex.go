@@ -419,6 +419,7 @@ type Policy struct {
 	BackoffCoefficient float64
 	MaximumInterval    time.Duration
 	MaximumAttempts    int32
+	Retryable          func(error) bool // Optional predicate, nil retries all errors
 }
 
 // VertexRetryExecutor wraps retry execution with region rotation for Vertex AI
@@ -475,6 +476,15 @@ func (e *VertexRetryExecutor) Execute(ctx context.Context, operation func() erro
 			lastErr = err
 			attempt++
 
+			if e.policy.Retryable != nil && !e.policy.Retryable(err) {
+				e.logger.Debug(ctx, "Operation failed with non-retryable error", map[string]interface{}{
+					"attempt": attempt,
+					"error":   err.Error(),
+					"region":  currentRegion,
+				})
+				return err
+			}
+
 			if attempt >= e.policy.MaximumAttempts {
 				e.logger.Debug(ctx, "Maximum attempts reached", map[string]interface{}{
 					"attempt": attempt,
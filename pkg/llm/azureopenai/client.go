@@ -120,7 +120,7 @@ func WithLogger(logger logging.Logger) Option {
 // WithRetry configures retry policy for the client
 func WithRetry(opts ...retry.Option) Option {
 	return func(c *AzureOpenAIClient) {
-		c.retryExecutor = retry.NewExecutor(retry.NewPolicy(opts...))
+		c.retryExecutor = retry.NewExecutor(llm.NewRetryPolicy(opts...))
 	}
 }
 
@@ -254,8 +254,12 @@ func (c *AzureOpenAIClient) generateInternal(ctx context.Context, prompt string,
 	}
 
 	// Add memory messages and current prompt
-	builder := newMessageHistoryBuilder(c.logger)
-	messages = append(messages, builder.buildMessages(ctx, prompt, params.Memory)...)
+	builder := newMessageHistoryBuilder(c.logger, c.Model)
+	historyMessages, err := builder.buildMessages(ctx, prompt, params.Memory, params.ContentParts...)
+	if err != nil {
+		return nil, err
+	}
+	messages = append(messages, historyMessages...)
 
 	// Create request - use deployment name as model for Azure OpenAI
 	req := openai.ChatCompletionNewParams{
@@ -304,7 +308,6 @@ func (c *AzureOpenAIClient) generateInternal(ctx context.Context, prompt string,
 	}
 
 	var resp *openai.ChatCompletion
-	var err error
 
 	operation := func() error {
 		var reasoningEffort string
@@ -334,7 +337,7 @@ func (c *AzureOpenAIClient) generateInternal(ctx context.Context, prompt string,
 				"model":      c.Model,
 				"deployment": c.deployment,
 			})
-			return fmt.Errorf("failed to generate text: %w", err)
+			return fmt.Errorf("failed to generate text: %w", convertError(err))
 		}
 		return nil
 	}
@@ -372,18 +375,7 @@ func (c *AzureOpenAIClient) generateInternal(ctx context.Context, prompt string,
 		}
 
 		// Extract token usage if available
-		usage := &interfaces.TokenUsage{
-			InputTokens:  int(resp.Usage.PromptTokens),
-			OutputTokens: int(resp.Usage.CompletionTokens),
-			TotalTokens:  int(resp.Usage.TotalTokens),
-		}
-
-		// Add reasoning tokens if available (for o1 models)
-		if resp.Usage.CompletionTokensDetails.ReasoningTokens > 0 {
-			usage.ReasoningTokens = int(resp.Usage.CompletionTokensDetails.ReasoningTokens)
-		}
-
-		response.Usage = usage
+		response.Usage = completionUsage(resp)
 
 		return response, nil
 	}
@@ -391,6 +383,21 @@ func (c *AzureOpenAIClient) generateInternal(ctx context.Context, prompt string,
 	return nil, fmt.Errorf("no response from Azure OpenAI API")
 }
 
+// completionUsage returns the token usage of a chat completion
+func completionUsage(resp *openai.ChatCompletion) *interfaces.TokenUsage {
+	usage := &interfaces.TokenUsage{
+		InputTokens:  int(resp.Usage.PromptTokens),
+		OutputTokens: int(resp.Usage.CompletionTokens),
+		TotalTokens:  int(resp.Usage.TotalTokens),
+	}
+
+	// Add reasoning tokens if available (for o1 models)
+	if resp.Usage.CompletionTokensDetails.ReasoningTokens > 0 {
+		usage.ReasoningTokens = int(resp.Usage.CompletionTokensDetails.ReasoningTokens)
+	}
+	return usage
+}
+
 // Chat uses the ChatCompletion API to have a conversation (messages) with a model
 func (c *AzureOpenAIClient) Chat(ctx context.Context, messages []llm.Message, params *llm.GenerateParams) (string, error) {
 	if params == nil {
@@ -404,7 +411,11 @@ func (c *AzureOpenAIClient) Chat(ctx context.Context, messages []llm.Message, pa
 		case "system":
 			chatMessages[i] = openai.SystemMessage(msg.Content)
 		case "user":
-			chatMessages[i] = openai.UserMessage(msg.Content)
+			userMsg, err := convertUserMessage(c.Model, msg.Content, msg.Parts)
+			if err != nil {
+				return "", err
+			}
+			chatMessages[i] = userMsg
 		case "assistant":
 			chatMessages[i] = openai.AssistantMessage(msg.Content)
 		case "tool":
@@ -464,7 +475,7 @@ func (c *AzureOpenAIClient) Chat(ctx context.Context, messages []llm.Message, pa
 				"model":      c.Model,
 				"deployment": c.deployment,
 			})
-			return fmt.Errorf("failed to create chat completion: %w", err)
+			return fmt.Errorf("failed to create chat completion: %w", convertError(err))
 		}
 		return nil
 	}
@@ -531,42 +542,10 @@ func (c *AzureOpenAIClient) GenerateWithTools(ctx context.Context, prompt string
 	// Convert tools to OpenAI format
 	openaiTools := make([]openai.ChatCompletionToolUnionParam, len(tools))
 	for i, tool := range tools {
-		// Convert ParameterSpec to JSON Schema
-		properties := make(map[string]interface{})
-		required := []string{}
-
-		for name, param := range tool.Parameters() {
-			properties[name] = map[string]interface{}{
-				"type":        param.Type,
-				"description": param.Description,
-			}
-			if param.Default != nil {
-				properties[name].(map[string]interface{})["default"] = param.Default
-			}
-			if param.Required {
-				required = append(required, name)
-			}
-			if param.Items != nil {
-				properties[name].(map[string]interface{})["items"] = map[string]interface{}{
-					"type": param.Items.Type,
-				}
-				if param.Items.Enum != nil {
-					properties[name].(map[string]interface{})["items"].(map[string]interface{})["enum"] = param.Items.Enum
-				}
-			}
-			if param.Enum != nil {
-				properties[name].(map[string]interface{})["enum"] = param.Enum
-			}
-		}
-
 		openaiTools[i] = openai.ChatCompletionFunctionTool(shared.FunctionDefinitionParam{
 			Name:        tool.Name(),
 			Description: openai.String(tool.Description()),
-			Parameters: map[string]interface{}{
-				"type":       "object",
-				"properties": properties,
-				"required":   required,
-			},
+			Parameters:  llm.ToolSchema(tool),
 		})
 	}
 
@@ -584,8 +563,12 @@ func (c *AzureOpenAIClient) GenerateWithTools(ctx context.Context, prompt string
 	}
 
 	// Add memory messages and current prompt
-	builder := newMessageHistoryBuilder(c.logger)
-	messages = append(messages, builder.buildMessages(ctx, prompt, params.Memory)...)
+	builder := newMessageHistoryBuilder(c.logger, c.Model)
+	historyMessages, err := builder.buildMessages(ctx, prompt, params.Memory, params.ContentParts...)
+	if err != nil {
+		return "", err
+	}
+	messages = append(messages, historyMessages...)
 
 	// Create request - use deployment name as model for Azure OpenAI
 	req := openai.ChatCompletionNewParams{
@@ -663,14 +646,18 @@ func (c *AzureOpenAIClient) GenerateWithTools(ctx context.Context, prompt string
 			"iteration":         iteration + 1,
 			"maxIterations":     maxIterations,
 		})
+		if err := llm.BeforeCall(ctx, params); err != nil {
+			return "", err
+		}
 		resp, err := c.ChatService.Completions.New(ctx, req)
 		if err != nil {
 			c.logger.Error(ctx, "Error from Azure OpenAI API", map[string]interface{}{
 				"error":      err.Error(),
 				"deployment": c.deployment,
 			})
-			return "", fmt.Errorf("failed to create chat completion: %w", err)
+			return "", fmt.Errorf("failed to create chat completion: %w", convertError(err))
 		}
+		llm.AfterCall(ctx, params, string(resp.Model), completionUsage(resp))
 
 		if len(resp.Choices) == 0 {
 			return "", fmt.Errorf("no completions returned")
@@ -1033,11 +1020,15 @@ func (c *AzureOpenAIClient) GenerateWithTools(ctx context.Context, prompt string
 		"messages": len(finalReq.Messages),
 	})
 
+	if err := llm.BeforeCall(ctx, params); err != nil {
+		return "", err
+	}
 	finalResp, err := c.ChatService.Completions.New(ctx, finalReq)
 	if err != nil {
 		c.logger.Error(ctx, "Error in final call without tools", map[string]interface{}{"error": err.Error()})
-		return "", fmt.Errorf("failed to create final chat completion: %w", err)
+		return "", fmt.Errorf("failed to create final chat completion: %w", convertError(err))
 	}
+	llm.AfterCall(ctx, params, string(finalResp.Model), completionUsage(finalResp))
 
 	if len(finalResp.Choices) == 0 {
 		return "", fmt.Errorf("no completions returned in final call")
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/llm"
 	"github.com/tagus/agent-sdk-go/pkg/logging"
 	"github.com/openai/openai-go/v2"
 )
@@ -11,18 +12,21 @@ import (
 // messageHistoryBuilder builds Azure OpenAI-compatible message history from memory and current prompt
 type messageHistoryBuilder struct {
 	logger logging.Logger
+	model  string
 }
 
 // newMessageHistoryBuilder creates a new message history builder
-func newMessageHistoryBuilder(logger logging.Logger) *messageHistoryBuilder {
+func newMessageHistoryBuilder(logger logging.Logger, model string) *messageHistoryBuilder {
 	return &messageHistoryBuilder{
 		logger: logger,
+		model:  model,
 	}
 }
 
 // buildMessages constructs Azure OpenAI messages from memory and current prompt
-// Returns messages ready for Azure OpenAI API calls, preserving chronological order
-func (b *messageHistoryBuilder) buildMessages(ctx context.Context, prompt string, memory interfaces.Memory) []openai.ChatCompletionMessageParamUnion {
+// Returns messages ready for Azure OpenAI API calls, preserving chronological order.
+// Content parts are attached to the current user turn.
+func (b *messageHistoryBuilder) buildMessages(ctx context.Context, prompt string, memory interfaces.Memory, parts ...interfaces.ContentPart) ([]openai.ChatCompletionMessageParamUnion, error) {
 	messages := []openai.ChatCompletionMessageParamUnion{}
 
 	// Add memory messages
@@ -34,8 +38,11 @@ func (b *messageHistoryBuilder) buildMessages(ctx context.Context, prompt string
 			})
 		} else {
 			// Convert memory messages to Azure OpenAI format, preserving chronological order
-			for _, msg := range memoryMessages {
-				openaiMsg := b.convertMemoryMessage(msg)
+			for _, msg := range llm.AttachContentParts(memoryMessages, prompt, parts) {
+				openaiMsg, err := b.convertMemoryMessage(msg)
+				if err != nil {
+					return nil, err
+				}
 				if openaiMsg != nil {
 					messages = append(messages, *openaiMsg)
 				}
@@ -43,18 +50,25 @@ func (b *messageHistoryBuilder) buildMessages(ctx context.Context, prompt string
 		}
 	} else {
 		// Only append current user message when memory is nil
-		messages = append(messages, openai.UserMessage(prompt))
+		userMsg, err := convertUserMessage(b.model, prompt, parts)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, userMsg)
 	}
 
-	return messages
+	return messages, nil
 }
 
 // convertMemoryMessage converts a memory message to Azure OpenAI format
-func (b *messageHistoryBuilder) convertMemoryMessage(msg interfaces.Message) *openai.ChatCompletionMessageParamUnion {
+func (b *messageHistoryBuilder) convertMemoryMessage(msg interfaces.Message) (*openai.ChatCompletionMessageParamUnion, error) {
 	switch msg.Role {
 	case interfaces.MessageRoleUser:
-		userMsg := openai.UserMessage(msg.Content)
-		return &userMsg
+		userMsg, err := convertUserMessage(b.model, msg.Content, msg.Parts)
+		if err != nil {
+			return nil, err
+		}
+		return &userMsg, nil
 
 	case interfaces.MessageRoleAssistant:
 		if len(msg.ToolCalls) > 0 {
@@ -79,24 +93,24 @@ func (b *messageHistoryBuilder) convertMemoryMessage(msg interfaces.Message) *op
 				ToolCalls: toolCalls,
 			}
 			param := assistantMsg.ToParam()
-			return &param
+			return &param, nil
 		} else if msg.Content != "" {
 			// Regular assistant message
 			assistantMsg := openai.AssistantMessage(msg.Content)
-			return &assistantMsg
+			return &assistantMsg, nil
 		}
 
 	case interfaces.MessageRoleTool:
 		if msg.ToolCallID != "" {
 			toolMsg := openai.ToolMessage(msg.Content, msg.ToolCallID)
-			return &toolMsg
+			return &toolMsg, nil
 		}
 
 	case interfaces.MessageRoleSystem:
 		// Convert system messages from memory to Azure OpenAI system messages
 		systemMsg := openai.SystemMessage(msg.Content)
-		return &systemMsg
+		return &systemMsg, nil
 	}
 
-	return nil
+	return nil, nil
 }
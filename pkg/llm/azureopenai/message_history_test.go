@@ -10,7 +10,7 @@ import (
 
 func TestMessageHistoryBuilder_BuildMessages(t *testing.T) {
 	logger := logging.New()
-	builder := newMessageHistoryBuilder(logger)
+	builder := newMessageHistoryBuilder(logger, "gpt-4o")
 
 	tests := []struct {
 		name     string
@@ -65,7 +65,10 @@ func TestMessageHistoryBuilder_BuildMessages(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			messages := builder.buildMessages(context.Background(), tt.prompt, tt.memory)
+			messages, err := builder.buildMessages(context.Background(), tt.prompt, tt.memory)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
 			if len(messages) != tt.expected {
 				t.Errorf("Expected %d messages, got %d", tt.expected, len(messages))
 			}
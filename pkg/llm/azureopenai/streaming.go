@@ -9,6 +9,7 @@ import (
 	"github.com/openai/openai-go/v2"
 	"github.com/openai/openai-go/v2/shared"
 	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/llm"
 	"github.com/tagus/agent-sdk-go/pkg/multitenancy"
 )
 
@@ -43,6 +44,13 @@ func (c *AzureOpenAIClient) GenerateStream(
 		bufferSize = params.StreamConfig.BufferSize
 	}
 
+	// Build messages from memory and the current prompt
+	builder := newMessageHistoryBuilder(c.logger, c.Model)
+	historyMessages, err := builder.buildMessages(ctx, prompt, params.Memory, params.ContentParts...)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create event channel
 	eventChan := make(chan interfaces.StreamEvent, bufferSize)
 
@@ -57,9 +65,8 @@ func (c *AzureOpenAIClient) GenerateStream(
 			c.logger.Debug(ctx, "Using system message", map[string]interface{}{"system_message": params.SystemMessage})
 		}
 
-		// Build messages using unified builder
-		builder := newMessageHistoryBuilder(c.logger)
-		messages = append(messages, builder.buildMessages(ctx, prompt, params.Memory)...)
+		// Add memory messages and the current prompt
+		messages = append(messages, historyMessages...)
 
 		// Create stream request - use deployment name as model for Azure OpenAI
 		streamParams := openai.ChatCompletionNewParams{
@@ -238,7 +245,7 @@ func (c *AzureOpenAIClient) GenerateStream(
 			})
 			eventChan <- interfaces.StreamEvent{
 				Type:      interfaces.StreamEventError,
-				Error:     fmt.Errorf("azure openai streaming error: %w", err),
+				Error:     fmt.Errorf("azure openai streaming error: %w", convertError(err)),
 				Timestamp: time.Now(),
 			}
 			return
@@ -297,6 +304,13 @@ func (c *AzureOpenAIClient) GenerateWithToolsStream(
 		bufferSize = params.StreamConfig.BufferSize
 	}
 
+	// Build messages from memory and the current prompt
+	builder := newMessageHistoryBuilder(c.logger, c.Model)
+	historyMessages, err := builder.buildMessages(ctx, prompt, params.Memory, params.ContentParts...)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create event channel
 	eventChan := make(chan interfaces.StreamEvent, bufferSize)
 
@@ -307,7 +321,7 @@ func (c *AzureOpenAIClient) GenerateWithToolsStream(
 		// Convert tools to OpenAI format
 		openaiTools := make([]openai.ChatCompletionToolUnionParam, len(tools))
 		for i, tool := range tools {
-			schema := c.convertToOpenAISchema(tool.Parameters())
+			schema := llm.ToolSchema(tool)
 
 			openaiTools[i] = openai.ChatCompletionFunctionTool(shared.FunctionDefinitionParam{
 				Name:        tool.Name(),
@@ -323,9 +337,8 @@ func (c *AzureOpenAIClient) GenerateWithToolsStream(
 			c.logger.Debug(ctx, "Using system message", map[string]interface{}{"system_message": params.SystemMessage})
 		}
 
-		// Build messages using unified builder
-		builder := newMessageHistoryBuilder(c.logger)
-		messages = append(messages, builder.buildMessages(ctx, prompt, params.Memory)...)
+		// Add memory messages and the current prompt
+		messages = append(messages, historyMessages...)
 
 		// Send initial message start event
 		eventChan <- interfaces.StreamEvent{
@@ -428,6 +441,22 @@ func (c *AzureOpenAIClient) GenerateWithToolsStream(
 				}
 			}
 
+			// Report the usage of every round-trip to the call hook
+			if params.CallHook != nil {
+				streamParams.StreamOptions = openai.ChatCompletionStreamOptionsParam{
+					IncludeUsage: openai.Bool(true),
+				}
+			}
+
+			if err := llm.BeforeCall(ctx, params); err != nil {
+				eventChan <- interfaces.StreamEvent{
+					Type:      interfaces.StreamEventError,
+					Error:     err,
+					Timestamp: time.Now(),
+				}
+				return
+			}
+
 			// Create stream
 			stream := c.ChatService.Completions.NewStreaming(ctx, streamParams)
 			if stream.Err() != nil {
@@ -436,7 +465,7 @@ func (c *AzureOpenAIClient) GenerateWithToolsStream(
 				})
 				eventChan <- interfaces.StreamEvent{
 					Type:      interfaces.StreamEventError,
-					Error:     fmt.Errorf("azure openai streaming error: %w", stream.Err()),
+					Error:     fmt.Errorf("azure openai streaming error: %w", convertError(stream.Err())),
 					Timestamp: time.Now(),
 				}
 				return
@@ -449,8 +478,10 @@ func (c *AzureOpenAIClient) GenerateWithToolsStream(
 			var hasContent bool
 
 			// Process stream chunks
+			round := streamRound{model: c.Model}
 			for stream.Next() {
 				chunk := stream.Current()
+				round.add(chunk)
 
 				for _, choice := range chunk.Choices {
 					// Handle content
@@ -563,12 +594,14 @@ func (c *AzureOpenAIClient) GenerateWithToolsStream(
 				})
 				eventChan <- interfaces.StreamEvent{
 					Type:      interfaces.StreamEventError,
-					Error:     fmt.Errorf("azure openai streaming error: %w", err),
+					Error:     fmt.Errorf("azure openai streaming error: %w", convertError(err)),
 					Timestamp: time.Now(),
 				}
 				return
 			}
 
+			llm.AfterCall(ctx, params, round.model, round.usage)
+
 			// Check if the model wants to use tools
 			if len(assistantResponse.ToolCalls) == 0 {
 				// No tool calls, we're done
@@ -828,6 +861,22 @@ func (c *AzureOpenAIClient) GenerateWithToolsStream(
 			"deployment": c.deployment,
 		})
 
+		// Report the usage of the final call to the call hook
+		if params.CallHook != nil {
+			finalStreamParams.StreamOptions = openai.ChatCompletionStreamOptionsParam{
+				IncludeUsage: openai.Bool(true),
+			}
+		}
+
+		if err := llm.BeforeCall(ctx, params); err != nil {
+			eventChan <- interfaces.StreamEvent{
+				Type:      interfaces.StreamEventError,
+				Error:     err,
+				Timestamp: time.Now(),
+			}
+			return
+		}
+
 		// Create final stream
 		finalStream := c.ChatService.Completions.NewStreaming(ctx, finalStreamParams)
 		if finalStream.Err() != nil {
@@ -836,7 +885,7 @@ func (c *AzureOpenAIClient) GenerateWithToolsStream(
 			})
 			eventChan <- interfaces.StreamEvent{
 				Type:      interfaces.StreamEventError,
-				Error:     fmt.Errorf("azure openai final streaming error: %w", finalStream.Err()),
+				Error:     fmt.Errorf("azure openai final streaming error: %w", convertError(finalStream.Err())),
 				Timestamp: time.Now(),
 			}
 			return
@@ -846,8 +895,10 @@ func (c *AzureOpenAIClient) GenerateWithToolsStream(
 		var finalContent strings.Builder
 
 		// Process final stream
+		finalRound := streamRound{model: c.Model}
 		for finalStream.Next() {
 			chunk := finalStream.Current()
+			finalRound.add(chunk)
 
 			for _, choice := range chunk.Choices {
 				// Handle final content
@@ -888,12 +939,14 @@ func (c *AzureOpenAIClient) GenerateWithToolsStream(
 			})
 			eventChan <- interfaces.StreamEvent{
 				Type:      interfaces.StreamEventError,
-				Error:     fmt.Errorf("azure openai final streaming error: %w", err),
+				Error:     fmt.Errorf("azure openai final streaming error: %w", convertError(err)),
 				Timestamp: time.Now(),
 			}
 			return
 		}
 
+		llm.AfterCall(ctx, params, finalRound.model, finalRound.usage)
+
 		// Send final message stop event
 		eventChan <- interfaces.StreamEvent{
 			Type:      interfaces.StreamEventMessageStop,
@@ -911,42 +964,27 @@ func (c *AzureOpenAIClient) GenerateWithToolsStream(
 
 // convertToOpenAISchema converts tool parameters to OpenAI function schema
 func (c *AzureOpenAIClient) convertToOpenAISchema(params map[string]interfaces.ParameterSpec) map[string]interface{} {
-	properties := make(map[string]interface{})
-	required := []string{}
-
-	for name, param := range params {
-		property := map[string]interface{}{
-			"type":        param.Type,
-			"description": param.Description,
-		}
-
-		if param.Default != nil {
-			property["default"] = param.Default
-		}
-
-		if param.Items != nil {
-			property["items"] = map[string]interface{}{
-				"type": param.Items.Type,
-			}
-			if param.Items.Enum != nil {
-				property["items"].(map[string]interface{})["enum"] = param.Items.Enum
-			}
-		}
-
-		if param.Enum != nil {
-			property["enum"] = param.Enum
-		}
+	return llm.ParametersSchema(params)
+}
 
-		properties[name] = property
+// streamRound collects the model and usage of a streamed request, reported
+// to the call hook once its stream ends. The usage is only sent when
+// requested with the stream options.
+type streamRound struct {
+	model string
+	usage *interfaces.TokenUsage
+}
 
-		if param.Required {
-			required = append(required, name)
-		}
+func (r *streamRound) add(chunk openai.ChatCompletionChunk) {
+	if chunk.Model != "" {
+		r.model = chunk.Model
 	}
-
-	return map[string]interface{}{
-		"type":       "object",
-		"properties": properties,
-		"required":   required,
+	if chunk.Usage.TotalTokens > 0 {
+		r.usage = &interfaces.TokenUsage{
+			InputTokens:     int(chunk.Usage.PromptTokens),
+			OutputTokens:    int(chunk.Usage.CompletionTokens),
+			TotalTokens:     int(chunk.Usage.TotalTokens),
+			ReasoningTokens: int(chunk.Usage.CompletionTokensDetails.ReasoningTokens),
+		}
 	}
 }
@@ -0,0 +1,852 @@
+package bedrock
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/llm"
+	"github.com/tagus/agent-sdk-go/pkg/logging"
+	"github.com/tagus/agent-sdk-go/pkg/multitenancy"
+	"github.com/tagus/agent-sdk-go/pkg/retry"
+)
+
+// Model ID constants for common Bedrock models. Newer models may require a
+// cross-region inference profile ID, such as "us." + ClaudeSonnet4.
+const (
+	ClaudeSonnet4  = "anthropic.claude-sonnet-4-20250514-v1:0"
+	Claude37Sonnet = "anthropic.claude-3-7-sonnet-20250219-v1:0"
+	Claude35Haiku  = "anthropic.claude-3-5-haiku-20241022-v1:0"
+	NovaPro        = "amazon.nova-pro-v1:0"
+	NovaLite       = "amazon.nova-lite-v1:0"
+	Llama33_70B    = "meta.llama3-3-70b-instruct-v1:0"
+)
+
+const (
+	// DefaultRegion is used when no region is configured or found in the environment
+	DefaultRegion = "us-east-1"
+
+	defaultMaxTokens = 4096
+
+	jsonSystemInstruction = "You must respond with valid JSON that matches the specified schema. Return ONLY the raw JSON object without any markdown formatting, code blocks, or wrapper text."
+)
+
+// BedrockClient implements the LLM interface for models on AWS Bedrock using
+// the Converse and ConverseStream APIs
+type BedrockClient struct {
+	Model         string
+	Region        string
+	Endpoint      string
+	HTTPClient    *http.Client
+	credentials   Credentials
+	apiKey        string
+	logger        logging.Logger
+	retryExecutor *retry.Executor
+	now           func() time.Time
+}
+
+// Option represents an option for configuring the Bedrock client
+type Option func(*BedrockClient)
+
+// WithModel sets the model ID or inference profile ID
+func WithModel(model string) Option {
+	return func(c *BedrockClient) {
+		c.Model = model
+	}
+}
+
+// WithRegion sets the AWS region
+func WithRegion(region string) Option {
+	return func(c *BedrockClient) {
+		c.Region = region
+	}
+}
+
+// WithCredentials sets the AWS credentials used to sign requests
+func WithCredentials(accessKeyID, secretAccessKey, sessionToken string) Option {
+	return func(c *BedrockClient) {
+		c.credentials = Credentials{
+			AccessKeyID:     accessKeyID,
+			SecretAccessKey: secretAccessKey,
+			SessionToken:    sessionToken,
+		}
+	}
+}
+
+// WithAPIKey authenticates with a Bedrock API key instead of signing requests
+func WithAPIKey(apiKey string) Option {
+	return func(c *BedrockClient) {
+		c.apiKey = apiKey
+	}
+}
+
+// WithEndpoint overrides the runtime endpoint, for VPC endpoints or local stand-ins
+func WithEndpoint(endpoint string) Option {
+	return func(c *BedrockClient) {
+		c.Endpoint = strings.TrimSuffix(endpoint, "/")
+	}
+}
+
+// WithHTTPClient sets the HTTP client for the Bedrock client
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *BedrockClient) {
+		c.HTTPClient = httpClient
+	}
+}
+
+// WithLogger sets the logger for the Bedrock client
+func WithLogger(logger logging.Logger) Option {
+	return func(c *BedrockClient) {
+		c.logger = logger
+	}
+}
+
+// WithRetry configures retry policy for the client
+func WithRetry(opts ...retry.Option) Option {
+	return func(c *BedrockClient) {
+		c.retryExecutor = retry.NewExecutor(llm.NewRetryPolicy(opts...))
+	}
+}
+
+// NewClient creates a new Bedrock client. The region, credentials and API key
+// default to the standard AWS environment variables (AWS_REGION,
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN and
+// AWS_BEARER_TOKEN_BEDROCK).
+func NewClient(options ...Option) *BedrockClient {
+	client := &BedrockClient{
+		Model:      ClaudeSonnet4,
+		Region:     firstNonEmpty(os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), DefaultRegion),
+		HTTPClient: &http.Client{Timeout: 10 * time.Minute},
+		credentials: Credentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		},
+		apiKey: os.Getenv("AWS_BEARER_TOKEN_BEDROCK"),
+		logger: logging.New(),
+		now:    time.Now,
+	}
+
+	for _, option := range options {
+		option(client)
+	}
+
+	if client.Endpoint == "" {
+		client.Endpoint = fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com", client.Region)
+	}
+
+	return client
+}
+
+// Message is a conversation turn
+type Message struct {
+	Role    string         `json:"role"`
+	Content []ContentBlock `json:"content"`
+}
+
+// ContentBlock is one block of a message or of the system prompt. Exactly one field is set.
+type ContentBlock struct {
+	Text             string            `json:"text,omitempty"`
+	ToolUse          *ToolUseBlock     `json:"toolUse,omitempty"`
+	ToolResult       *ToolResultBlock  `json:"toolResult,omitempty"`
+	ReasoningContent *ReasoningContent `json:"reasoningContent,omitempty"`
+	CachePoint       *CachePoint       `json:"cachePoint,omitempty"`
+	Image            *ImageBlock       `json:"image,omitempty"`
+	Document         *DocumentBlock    `json:"document,omitempty"`
+}
+
+// ToolUseBlock is a tool call requested by the model
+type ToolUseBlock struct {
+	ToolUseID string          `json:"toolUseId"`
+	Name      string          `json:"name"`
+	Input     json.RawMessage `json:"input"`
+}
+
+// ToolResultBlock is the result of a tool call
+type ToolResultBlock struct {
+	ToolUseID string              `json:"toolUseId"`
+	Content   []ToolResultContent `json:"content"`
+	Status    string              `json:"status,omitempty"`
+}
+
+// ToolResultContent is the content of a tool result
+type ToolResultContent struct {
+	Text string `json:"text"`
+}
+
+// ReasoningContent holds the model's reasoning, which must be sent back unchanged during tool use
+type ReasoningContent struct {
+	ReasoningText   *ReasoningText `json:"reasoningText,omitempty"`
+	RedactedContent []byte         `json:"redactedContent,omitempty"`
+}
+
+// ReasoningText is reasoning text with its signature
+type ReasoningText struct {
+	Text      string `json:"text"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// CachePoint marks the end of a cacheable prompt prefix
+type CachePoint struct {
+	Type string `json:"type"`
+}
+
+// InferenceConfig contains the sampling options
+type InferenceConfig struct {
+	MaxTokens     int      `json:"maxTokens,omitempty"`
+	Temperature   *float64 `json:"temperature,omitempty"`
+	TopP          *float64 `json:"topP,omitempty"`
+	StopSequences []string `json:"stopSequences,omitempty"`
+}
+
+// ToolConfig declares the tools the model may use
+type ToolConfig struct {
+	Tools      []ToolEntry `json:"tools"`
+	ToolChoice *ToolChoice `json:"toolChoice,omitempty"`
+}
+
+// ToolEntry is a tool specification or a cache point
+type ToolEntry struct {
+	ToolSpec   *ToolSpec   `json:"toolSpec,omitempty"`
+	CachePoint *CachePoint `json:"cachePoint,omitempty"`
+}
+
+// ToolSpec describes a tool
+type ToolSpec struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	InputSchema InputSchema `json:"inputSchema"`
+}
+
+// InputSchema wraps the JSON schema of a tool's input
+type InputSchema struct {
+	JSON map[string]interface{} `json:"json"`
+}
+
+// ToolChoice controls whether the model must use a tool
+type ToolChoice struct {
+	Auto *struct{} `json:"auto,omitempty"`
+}
+
+// ConverseRequest is the request body of Converse and ConverseStream
+type ConverseRequest struct {
+	Messages                     []Message              `json:"messages"`
+	System                       []ContentBlock         `json:"system,omitempty"`
+	InferenceConfig              *InferenceConfig       `json:"inferenceConfig,omitempty"`
+	ToolConfig                   *ToolConfig            `json:"toolConfig,omitempty"`
+	AdditionalModelRequestFields map[string]interface{} `json:"additionalModelRequestFields,omitempty"`
+}
+
+// ConverseResponse is the response of Converse
+type ConverseResponse struct {
+	Output struct {
+		Message *Message `json:"message"`
+	} `json:"output"`
+	StopReason string `json:"stopReason"`
+	Usage      *Usage `json:"usage"`
+}
+
+// Usage reports token usage
+type Usage struct {
+	InputTokens           int `json:"inputTokens"`
+	OutputTokens          int `json:"outputTokens"`
+	TotalTokens           int `json:"totalTokens"`
+	CacheReadInputTokens  int `json:"cacheReadInputTokens"`
+	CacheWriteInputTokens int `json:"cacheWriteInputTokens"`
+}
+
+// Generate generates text based on the provided prompt
+func (c *BedrockClient) Generate(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (string, error) {
+	response, err := c.GenerateDetailed(ctx, prompt, options...)
+	if err != nil {
+		return "", err
+	}
+	return response.Content, nil
+}
+
+// GenerateDetailed generates text and returns detailed response information including token usage
+func (c *BedrockClient) GenerateDetailed(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (*interfaces.LLMResponse, error) {
+	if c.Model == "" {
+		return nil, fmt.Errorf("model not specified: use WithModel option when creating the client")
+	}
+
+	params := c.applyOptions(options)
+	ctx = ensureOrgID(ctx)
+
+	messages, err := c.buildMessages(ctx, prompt, params)
+	if err != nil {
+		return nil, err
+	}
+
+	req := c.newConverseRequest(messages, params, nil)
+	resp, err := c.converse(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	text, thinking := splitText(resp.Output.Message)
+	if text == "" {
+		return nil, fmt.Errorf("no text content in response (stop reason: %s)", resp.StopReason)
+	}
+	if params.ResponseFormat != nil {
+		text = extractJSON(text)
+	}
+
+	usage := &interfaces.TokenUsage{}
+	addUsage(usage, resp.Usage)
+	return c.newResponse(text, thinking, resp.StopReason, usage, false), nil
+}
+
+// GenerateWithTools generates text and can use tools
+func (c *BedrockClient) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (string, error) {
+	response, err := c.GenerateWithToolsDetailed(ctx, prompt, tools, options...)
+	if err != nil {
+		return "", err
+	}
+	return response.Content, nil
+}
+
+// GenerateWithToolsDetailed generates text with tools and returns detailed response
+// information, with token usage summed over all tool iterations
+func (c *BedrockClient) GenerateWithToolsDetailed(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (*interfaces.LLMResponse, error) {
+	if c.Model == "" {
+		return nil, fmt.Errorf("model not specified: use WithModel option when creating the client")
+	}
+
+	params := c.applyOptions(options)
+	ctx = ensureOrgID(ctx)
+
+	maxIterations := params.MaxIterations
+	if maxIterations == 0 {
+		maxIterations = 2
+	}
+
+	messages, err := c.buildMessages(ctx, prompt, params)
+	if err != nil {
+		return nil, err
+	}
+	toolConfig := c.toolConfig(tools, params.CacheConfig)
+	toolCallHistory := make(map[string]int)
+	usage := &interfaces.TokenUsage{}
+
+	for iteration := 0; iteration < maxIterations; iteration++ {
+		c.logger.Debug(ctx, "Sending request with tools to Bedrock", map[string]interface{}{
+			"model":         c.Model,
+			"messages":      len(messages),
+			"tools":         len(tools),
+			"iteration":     iteration + 1,
+			"maxIterations": maxIterations,
+		})
+
+		if err := llm.BeforeCall(ctx, params); err != nil {
+			return nil, err
+		}
+		resp, err := c.converse(ctx, c.newConverseRequest(messages, params, toolConfig))
+		if err != nil {
+			return nil, fmt.Errorf("iteration %d: %w", iteration+1, err)
+		}
+		c.afterCall(ctx, params, resp.Usage)
+		addUsage(usage, resp.Usage)
+
+		toolUses := toolUseBlocks(resp.Output.Message)
+		if len(toolUses) == 0 {
+			text, thinking := splitText(resp.Output.Message)
+			if text == "" {
+				return nil, fmt.Errorf("no text content in response (iteration %d, stop reason: %s)", iteration+1, resp.StopReason)
+			}
+			if params.ResponseFormat != nil {
+				text = extractJSON(text)
+			}
+			return c.newResponse(text, thinking, resp.StopReason, usage, true), nil
+		}
+
+		c.logger.Info(ctx, "Processing tool calls", map[string]interface{}{
+			"count":     len(toolUses),
+			"iteration": iteration + 1,
+		})
+
+		// The assistant turn is sent back unchanged so that reasoning signatures are preserved
+		messages = append(messages, *resp.Output.Message)
+		messages = append(messages, Message{
+			Role:    "user",
+			Content: c.executeToolsParallel(ctx, toolUses, tools, params, toolCallHistory, iteration),
+		})
+	}
+
+	// The model still wants tools after the last iteration; ask for a final answer without them.
+	// Bedrock requires the tool configuration when the history contains tool use, so it is kept
+	// and the model is told not to call tools.
+	c.logger.Info(ctx, "Maximum iterations reached, making final call without tools", map[string]interface{}{
+		"maxIterations": maxIterations,
+	})
+
+	last := &messages[len(messages)-1]
+	last.Content = append(last.Content, ContentBlock{
+		Text: "Please provide your final response based on the information available. Do not request any additional tools.",
+	})
+
+	if err := llm.BeforeCall(ctx, params); err != nil {
+		return nil, err
+	}
+	resp, err := c.converse(ctx, c.newConverseRequest(messages, params, toolConfig))
+	if err != nil {
+		return nil, fmt.Errorf("final call: %w", err)
+	}
+	c.afterCall(ctx, params, resp.Usage)
+	addUsage(usage, resp.Usage)
+
+	text, thinking := splitText(resp.Output.Message)
+	if text == "" {
+		return nil, fmt.Errorf("no text content in final response (stop reason: %s)", resp.StopReason)
+	}
+	if params.ResponseFormat != nil {
+		text = extractJSON(text)
+	}
+	return c.newResponse(text, thinking, resp.StopReason, usage, true), nil
+}
+
+// Name returns the name of the LLM provider
+func (c *BedrockClient) Name() string {
+	return "bedrock"
+}
+
+// SupportsStreaming returns true as Bedrock supports streaming
+func (c *BedrockClient) SupportsStreaming() bool {
+	return true
+}
+
+// GetModel returns the model name being used
+func (c *BedrockClient) GetModel() string {
+	return c.Model
+}
+
+// applyOptions applies generate options over the client defaults
+func (c *BedrockClient) applyOptions(options []interfaces.GenerateOption) *interfaces.GenerateOptions {
+	params := &interfaces.GenerateOptions{
+		LLMConfig: &interfaces.LLMConfig{
+			Temperature: 0.7, // Default temperature
+		},
+	}
+	for _, option := range options {
+		if option != nil {
+			option(params)
+		}
+	}
+	if params.LLMConfig == nil {
+		params.LLMConfig = &interfaces.LLMConfig{}
+	}
+	return params
+}
+
+// newConverseRequest builds a Converse request, adding cache points and
+// reasoning configuration as requested
+func (c *BedrockClient) newConverseRequest(messages []Message, params *interfaces.GenerateOptions, toolConfig *ToolConfig) *ConverseRequest {
+	cfg := params.LLMConfig
+	inference := &InferenceConfig{
+		MaxTokens:     defaultMaxTokens,
+		Temperature:   &cfg.Temperature,
+		StopSequences: cfg.StopSequences,
+	}
+	if cfg.TopP > 0 {
+		inference.TopP = &cfg.TopP
+	}
+
+	req := &ConverseRequest{
+		Messages:        messages,
+		InferenceConfig: inference,
+		ToolConfig:      toolConfig,
+	}
+
+	system := params.SystemMessage
+	if params.ResponseFormat != nil {
+		schema, _ := json.Marshal(params.ResponseFormat.Schema)
+		instruction := jsonSystemInstruction + "\nSchema: " + string(schema)
+		if system == "" {
+			system = instruction
+		} else {
+			system += "\n\n" + instruction
+		}
+	}
+	if system != "" {
+		req.System = []ContentBlock{{Text: system}}
+		if params.CacheConfig != nil && params.CacheConfig.CacheSystemMessage {
+			req.System = append(req.System, ContentBlock{CachePoint: defaultCachePoint()})
+		}
+	}
+
+	// Extended thinking is only available for Anthropic models
+	if cfg.EnableReasoning && isAnthropicModel(c.Model) {
+		budget := cfg.ReasoningBudget
+		if budget < 1024 {
+			budget = 1024 // Minimum budget accepted by Anthropic
+		}
+		req.AdditionalModelRequestFields = map[string]interface{}{
+			"thinking": map[string]interface{}{
+				"type":          "enabled",
+				"budget_tokens": budget,
+			},
+		}
+		// Thinking requires temperature 1 and a token limit above the budget
+		one := 1.0
+		inference.Temperature = &one
+		inference.TopP = nil
+		inference.MaxTokens = budget + defaultMaxTokens
+	}
+
+	return req
+}
+
+// toolConfig converts tools to Bedrock tool specifications
+func (c *BedrockClient) toolConfig(tools []interfaces.Tool, cacheConfig *interfaces.CacheConfig) *ToolConfig {
+	if len(tools) == 0 {
+		return nil
+	}
+
+	entries := make([]ToolEntry, 0, len(tools)+1)
+	for _, tool := range tools {
+		entries = append(entries, ToolEntry{ToolSpec: &ToolSpec{
+			Name:        tool.Name(),
+			Description: tool.Description(),
+			InputSchema: InputSchema{JSON: llm.ToolSchema(tool)},
+		}})
+	}
+	if cacheConfig != nil && cacheConfig.CacheTools {
+		entries = append(entries, ToolEntry{CachePoint: defaultCachePoint()})
+	}
+	return &ToolConfig{Tools: entries, ToolChoice: &ToolChoice{Auto: &struct{}{}}}
+}
+
+// converse sends a Converse request, with retries if configured
+func (c *BedrockClient) converse(ctx context.Context, req *ConverseRequest) (*ConverseResponse, error) {
+	var resp ConverseResponse
+	operation := func() error {
+		httpReq, err := c.newHTTPRequest(ctx, "converse", req)
+		if err != nil {
+			return err
+		}
+
+		httpResp, err := c.HTTPClient.Do(httpReq)
+		if err != nil {
+			return fmt.Errorf("failed to send request to bedrock: %w", err)
+		}
+		defer func() {
+			_ = httpResp.Body.Close()
+		}()
+
+		body, err := io.ReadAll(httpResp.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read bedrock response: %w", err)
+		}
+		if httpResp.StatusCode != http.StatusOK {
+			return apiError(httpResp, body)
+		}
+
+		resp = ConverseResponse{}
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return fmt.Errorf("failed to parse bedrock response: %w", err)
+		}
+		if resp.Output.Message == nil {
+			return fmt.Errorf("no message in bedrock response (stop reason: %s)", resp.StopReason)
+		}
+		return nil
+	}
+
+	var err error
+	if c.retryExecutor != nil {
+		err = c.retryExecutor.Execute(ctx, operation)
+	} else {
+		err = operation()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// newHTTPRequest creates an authenticated request for a model operation
+// ("converse" or "converse-stream")
+func (c *BedrockClient) newHTTPRequest(ctx context.Context, operation string, req *ConverseRequest) (*http.Request, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	// Model IDs contain characters such as ':' that must be escaped in the path
+	rawPath := "/model/" + uriEncode(c.Model) + "/" + operation
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint+rawPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if operation == "converse-stream" {
+		httpReq.Header.Set("Accept", "application/vnd.amazon.eventstream")
+	}
+
+	switch {
+	case c.apiKey != "":
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	case c.credentials.AccessKeyID != "" && c.credentials.SecretAccessKey != "":
+		signRequest(httpReq, body, c.credentials, c.Region, signingService, c.now())
+	default:
+		return nil, fmt.Errorf("no AWS credentials configured: set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY or use WithCredentials")
+	}
+	return httpReq, nil
+}
+
+// apiError converts an error response to an error
+func apiError(resp *http.Response, body []byte) error {
+	return llm.NewAPIError("bedrock", resp.StatusCode, resp.Header, body)
+}
+
+// newResponse builds the detailed response returned to callers
+func (c *BedrockClient) newResponse(text, thinking, stopReason string, usage *interfaces.TokenUsage, toolsUsed bool) *interfaces.LLMResponse {
+	metadata := map[string]interface{}{
+		"provider": "bedrock",
+	}
+	if toolsUsed {
+		metadata["tools_used"] = true
+	}
+	if thinking != "" {
+		metadata["thinking"] = thinking
+	}
+	return &interfaces.LLMResponse{
+		Content:    text,
+		Model:      c.Model,
+		StopReason: stopReason,
+		Usage:      usage,
+		Metadata:   metadata,
+	}
+}
+
+type toolExecResult struct {
+	index int
+	block ContentBlock
+}
+
+// executeToolsParallel executes the tool calls concurrently and returns their
+// results in call order
+func (c *BedrockClient) executeToolsParallel(
+	ctx context.Context,
+	toolUses []ToolUseBlock,
+	tools []interfaces.Tool,
+	params *interfaces.GenerateOptions,
+	toolCallHistory map[string]int,
+	iteration int,
+) []ContentBlock {
+	c.logger.Info(ctx, "Executing tools in parallel", map[string]interface{}{
+		"count":     len(toolUses),
+		"iteration": iteration + 1,
+	})
+
+	resultChan := make(chan toolExecResult, len(toolUses))
+	var wg sync.WaitGroup
+	var historyMu sync.Mutex
+
+	for i, toolUse := range toolUses {
+		wg.Add(1)
+		go func(idx int, toolUse ToolUseBlock) {
+			defer wg.Done()
+
+			args := string(toolUse.Input)
+			if args == "" || args == "null" {
+				args = "{}"
+			}
+
+			var selectedTool interfaces.Tool
+			for _, tool := range tools {
+				if tool.Name() == toolUse.Name {
+					selectedTool = tool
+					break
+				}
+			}
+
+			var result string
+			var execErr error
+			if selectedTool == nil {
+				execErr = fmt.Errorf("tool not found: %s", toolUse.Name)
+				c.logger.Error(ctx, "Tool not found", map[string]interface{}{
+					"toolName":  toolUse.Name,
+					"iteration": iteration + 1,
+				})
+			} else {
+				c.logger.Info(ctx, "Executing tool (parallel)", map[string]interface{}{
+					"toolName":  toolUse.Name,
+					"iteration": iteration + 1,
+				})
+				result, execErr = selectedTool.Execute(ctx, args)
+			}
+
+			// Check for repetitive calls
+			historyMu.Lock()
+			cacheKey := toolUse.Name + ":" + args
+			toolCallHistory[cacheKey]++
+			callCount := toolCallHistory[cacheKey]
+			historyMu.Unlock()
+			if callCount > 2 && execErr == nil {
+				result += fmt.Sprintf("\n\n[WARNING: This is call #%d to %s with identical parameters. You may be in a loop.]", callCount, toolUse.Name)
+				c.logger.Warn(ctx, "Repetitive tool call detected", map[string]interface{}{
+					"toolName":  toolUse.Name,
+					"callCount": callCount,
+					"iteration": iteration + 1,
+				})
+			}
+
+			content := result
+			status := ""
+			if execErr != nil {
+				content = fmt.Sprintf("Error: %v", execErr)
+				status = "error"
+				if selectedTool != nil {
+					c.logger.Error(ctx, "Error executing tool", map[string]interface{}{
+						"toolName":  toolUse.Name,
+						"error":     execErr.Error(),
+						"iteration": iteration + 1,
+					})
+				}
+			}
+			if content == "" {
+				content = "(no output)" // Bedrock rejects empty text blocks
+			}
+
+			if params.Memory != nil {
+				_ = params.Memory.AddMessage(ctx, interfaces.Message{
+					Role: interfaces.MessageRoleAssistant,
+					ToolCalls: []interfaces.ToolCall{{
+						ID:        toolUse.ToolUseID,
+						Name:      toolUse.Name,
+						Arguments: args,
+					}},
+				})
+				_ = params.Memory.AddMessage(ctx, interfaces.Message{
+					Role:       interfaces.MessageRoleTool,
+					Content:    content,
+					ToolCallID: toolUse.ToolUseID,
+					Metadata:   map[string]interface{}{"tool_name": toolUse.Name},
+				})
+			}
+
+			resultChan <- toolExecResult{
+				index: idx,
+				block: ContentBlock{ToolResult: &ToolResultBlock{
+					ToolUseID: toolUse.ToolUseID,
+					Content:   []ToolResultContent{{Text: content}},
+					Status:    status,
+				}},
+			}
+		}(i, toolUse)
+	}
+
+	wg.Wait()
+	close(resultChan)
+
+	blocks := make([]ContentBlock, len(toolUses))
+	for result := range resultChan {
+		blocks[result.index] = result.block
+	}
+
+	c.logger.Info(ctx, "Parallel tool execution completed", map[string]interface{}{
+		"count":     len(blocks),
+		"iteration": iteration + 1,
+	})
+	return blocks
+}
+
+// ensureOrgID adds the default organization ID to the context if missing, so that tools can rely on it
+func ensureOrgID(ctx context.Context) context.Context {
+	if _, err := multitenancy.GetOrgID(ctx); err == nil {
+		return ctx
+	}
+	return multitenancy.WithOrgID(ctx, "default")
+}
+
+func defaultCachePoint() *CachePoint {
+	return &CachePoint{Type: "default"}
+}
+
+// isAnthropicModel reports whether the model ID or inference profile is an Anthropic model
+func isAnthropicModel(model string) bool {
+	return strings.Contains(model, "anthropic.")
+}
+
+// splitText returns the answer text and the reasoning text of a message
+func splitText(message *Message) (string, string) {
+	if message == nil {
+		return "", ""
+	}
+	var text, thinking strings.Builder
+	for _, block := range message.Content {
+		text.WriteString(block.Text)
+		if block.ReasoningContent != nil && block.ReasoningContent.ReasoningText != nil {
+			thinking.WriteString(block.ReasoningContent.ReasoningText.Text)
+		}
+	}
+	return text.String(), thinking.String()
+}
+
+// toolUseBlocks returns the tool calls of a message
+func toolUseBlocks(message *Message) []ToolUseBlock {
+	if message == nil {
+		return nil
+	}
+	var toolUses []ToolUseBlock
+	for _, block := range message.Content {
+		if block.ToolUse != nil {
+			toolUses = append(toolUses, *block.ToolUse)
+		}
+	}
+	return toolUses
+}
+
+// afterCall reports the usage of one response to the call hook
+func (c *BedrockClient) afterCall(ctx context.Context, params *interfaces.GenerateOptions, u *Usage) {
+	usage := &interfaces.TokenUsage{}
+	addUsage(usage, u)
+	llm.AfterCall(ctx, params, c.Model, usage)
+}
+
+// addUsage adds the usage of one response to the running total
+func addUsage(usage *interfaces.TokenUsage, u *Usage) {
+	if u == nil {
+		return
+	}
+	usage.InputTokens += u.InputTokens
+	usage.OutputTokens += u.OutputTokens
+	usage.TotalTokens += u.TotalTokens
+	usage.CacheReadInputTokens += u.CacheReadInputTokens
+	usage.CacheCreationInputTokens += u.CacheWriteInputTokens
+}
+
+// extractJSON strips a markdown code fence or surrounding text from a JSON response
+func extractJSON(response string) string {
+	response = strings.TrimSpace(response)
+	if strings.HasPrefix(response, "```") {
+		response = strings.TrimPrefix(response, "```")
+		if newline := strings.Index(response, "\n"); newline >= 0 {
+			response = response[newline+1:]
+		}
+		response = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(response), "```"))
+	}
+	start := strings.IndexAny(response, "{[")
+	end := strings.LastIndexAny(response, "}]")
+	if start >= 0 && end > start {
+		return response[start : end+1]
+	}
+	return response
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,271 @@
+package bedrock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// fakeRuntime is a local stand-in for the Bedrock runtime that answers
+// requests with canned responses in order
+type fakeRuntime struct {
+	t         *testing.T
+	responses []func(w http.ResponseWriter)
+	mu        sync.Mutex
+	requests  []ConverseRequest
+	paths     []string
+	headers   []http.Header
+}
+
+func (f *fakeRuntime) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var req ConverseRequest
+	require.NoError(f.t, json.NewDecoder(r.Body).Decode(&req))
+	f.requests = append(f.requests, req)
+	f.paths = append(f.paths, r.URL.EscapedPath())
+	f.headers = append(f.headers, r.Header.Clone())
+
+	if len(f.responses) == 0 {
+		w.Header().Set("X-Amzn-Errortype", "ValidationException:http://internal.amazon.com/coral/com.amazon.bedrock/")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"message":"unexpected request"}`))
+		return
+	}
+	respond := f.responses[0]
+	f.responses = f.responses[1:]
+	respond(w)
+}
+
+func jsonResponse(body string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}
+}
+
+func newTestClient(t *testing.T, responses ...func(w http.ResponseWriter)) (*BedrockClient, *fakeRuntime) {
+	runtime := &fakeRuntime{t: t, responses: responses}
+	server := httptest.NewServer(runtime)
+	t.Cleanup(server.Close)
+	client := NewClient(
+		WithEndpoint(server.URL),
+		WithRegion("eu-west-1"),
+		WithCredentials("AKID", "secret", ""),
+		WithAPIKey(""),
+		WithModel(Claude37Sonnet),
+	)
+	return client, runtime
+}
+
+// echoTool returns its arguments, or an error when asked to fail
+type echoTool struct{}
+
+func (echoTool) Name() string        { return "echo" }
+func (echoTool) Description() string { return "Echoes the message" }
+func (echoTool) Parameters() map[string]interfaces.ParameterSpec {
+	return map[string]interfaces.ParameterSpec{
+		"message": {Type: "string", Description: "Message to echo", Required: true},
+	}
+}
+func (t echoTool) Run(ctx context.Context, input string) (string, error) {
+	return t.Execute(ctx, input)
+}
+func (echoTool) Execute(ctx context.Context, args string) (string, error) {
+	var params struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", err
+	}
+	if params.Message == "fail" {
+		return "", fmt.Errorf("echo failed")
+	}
+	return "echo: " + params.Message, nil
+}
+
+func TestGenerateDetailed(t *testing.T) {
+	client, runtime := newTestClient(t, jsonResponse(`{
+		"output": {"message": {"role": "assistant", "content": [
+			{"reasoningContent": {"reasoningText": {"text": "Thinking.", "signature": "sig"}}},
+			{"text": "Hello"}
+		]}},
+		"stopReason": "end_turn",
+		"usage": {"inputTokens": 12, "outputTokens": 4, "totalTokens": 16, "cacheReadInputTokens": 8, "cacheWriteInputTokens": 2}
+	}`))
+
+	resp, err := client.GenerateDetailed(context.Background(), "Hi",
+		interfaces.WithSystemMessage("Be brief."),
+		interfaces.WithReasoning(true, 2000),
+		func(o *interfaces.GenerateOptions) {
+			o.CacheConfig = &interfaces.CacheConfig{CacheSystemMessage: true, CacheConversation: true}
+		})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Hello", resp.Content)
+	assert.Equal(t, "end_turn", resp.StopReason)
+	assert.Equal(t, "Thinking.", resp.Metadata["thinking"])
+	assert.Equal(t, &interfaces.TokenUsage{InputTokens: 12, OutputTokens: 4, TotalTokens: 16, CacheReadInputTokens: 8, CacheCreationInputTokens: 2}, resp.Usage)
+
+	require.Len(t, runtime.requests, 1)
+	assert.Equal(t, "/model/anthropic.claude-3-7-sonnet-20250219-v1%3A0/converse", runtime.paths[0])
+	assert.True(t, strings.HasPrefix(runtime.headers[0].Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+	assert.Contains(t, runtime.headers[0].Get("Authorization"), "/eu-west-1/bedrock/aws4_request")
+
+	req := runtime.requests[0]
+	assert.Equal(t, []ContentBlock{{Text: "Be brief."}, {CachePoint: &CachePoint{Type: "default"}}}, req.System)
+	assert.Equal(t, []ContentBlock{{Text: "Hi"}, {CachePoint: &CachePoint{Type: "default"}}}, req.Messages[0].Content)
+	assert.Equal(t, map[string]interface{}{"type": "enabled", "budget_tokens": float64(2000)}, req.AdditionalModelRequestFields["thinking"])
+	assert.Equal(t, 1.0, *req.InferenceConfig.Temperature)
+	assert.Equal(t, 2000+defaultMaxTokens, req.InferenceConfig.MaxTokens)
+}
+
+func TestGenerate_ReasoningIgnoredForNonAnthropicModels(t *testing.T) {
+	client, runtime := newTestClient(t, jsonResponse(`{"output": {"message": {"role": "assistant", "content": [{"text": "ok"}]}}}`))
+	client.Model = NovaPro
+
+	_, err := client.Generate(context.Background(), "Hi", interfaces.WithReasoning(true))
+	require.NoError(t, err)
+	assert.Nil(t, runtime.requests[0].AdditionalModelRequestFields)
+	assert.Equal(t, 0.7, *runtime.requests[0].InferenceConfig.Temperature)
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	client, _ := newTestClient(t)
+	_, err := client.Generate(context.Background(), "Hi")
+	assert.ErrorContains(t, err, "status 400, ValidationException): unexpected request")
+
+	client = NewClient(WithCredentials("", "", ""), WithAPIKey(""), WithEndpoint("http://127.0.0.1:1"))
+	_, err = client.Generate(context.Background(), "Hi")
+	assert.ErrorContains(t, err, "no AWS credentials")
+}
+
+func TestGenerate_APIKey(t *testing.T) {
+	client, runtime := newTestClient(t, jsonResponse(`{"output": {"message": {"role": "assistant", "content": [{"text": "ok"}]}}}`))
+	WithAPIKey("bedrock-key")(client)
+
+	_, err := client.Generate(context.Background(), "Hi")
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer bedrock-key", runtime.headers[0].Get("Authorization"))
+	assert.Empty(t, runtime.headers[0].Get("X-Amz-Date"))
+}
+
+func TestGenerateWithToolsDetailed(t *testing.T) {
+	client, runtime := newTestClient(t,
+		jsonResponse(`{
+			"output": {"message": {"role": "assistant", "content": [
+				{"text": "Let me check."},
+				{"toolUse": {"toolUseId": "t1", "name": "echo", "input": {"message": "one"}}},
+				{"toolUse": {"toolUseId": "t2", "name": "echo", "input": {"message": "fail"}}}
+			]}},
+			"stopReason": "tool_use",
+			"usage": {"inputTokens": 20, "outputTokens": 10, "totalTokens": 30}
+		}`),
+		jsonResponse(`{
+			"output": {"message": {"role": "assistant", "content": [{"text": "Done"}]}},
+			"stopReason": "end_turn",
+			"usage": {"inputTokens": 40, "outputTokens": 2, "totalTokens": 42}
+		}`))
+
+	resp, err := client.GenerateWithToolsDetailed(context.Background(), "Echo", []interfaces.Tool{echoTool{}},
+		func(o *interfaces.GenerateOptions) { o.CacheConfig = &interfaces.CacheConfig{CacheTools: true} })
+	require.NoError(t, err)
+	assert.Equal(t, "Done", resp.Content)
+	assert.Equal(t, 72, resp.Usage.TotalTokens)
+
+	require.Len(t, runtime.requests, 2)
+	tools := runtime.requests[0].ToolConfig.Tools
+	require.Len(t, tools, 2)
+	assert.Equal(t, "echo", tools[0].ToolSpec.Name)
+	assert.Equal(t, []interface{}{"message"}, tools[0].ToolSpec.InputSchema.JSON["required"])
+	assert.Equal(t, &CachePoint{Type: "default"}, tools[1].CachePoint)
+
+	messages := runtime.requests[1].Messages
+	require.Len(t, messages, 3)
+	assert.Equal(t, "assistant", messages[1].Role)
+	results := messages[2].Content
+	require.Len(t, results, 2)
+	assert.Equal(t, &ToolResultBlock{ToolUseID: "t1", Content: []ToolResultContent{{Text: "echo: one"}}}, results[0].ToolResult)
+	assert.Equal(t, &ToolResultBlock{ToolUseID: "t2", Content: []ToolResultContent{{Text: "Error: echo failed"}}, Status: "error"}, results[1].ToolResult)
+}
+
+func TestBuildMessages_FromMemory(t *testing.T) {
+	client := NewClient()
+	memory := &recordingMemory{messages: []interfaces.Message{
+		{Role: interfaces.MessageRoleAssistant, Content: "Greeting before the user spoke"},
+		{Role: interfaces.MessageRoleUser, Content: "Weather?"},
+		{Role: interfaces.MessageRoleAssistant, ToolCalls: []interfaces.ToolCall{{ID: "c1", Name: "weather", Arguments: `{"city":"Oslo"}`}}},
+		{Role: interfaces.MessageRoleTool, ToolCallID: "c1", Content: "Sunny"},
+		{Role: interfaces.MessageRoleTool, ToolCallID: "unknown", Content: "Orphan"},
+		{Role: interfaces.MessageRoleAssistant, Content: "It is sunny."},
+	}}
+
+	messages, err := client.buildMessages(context.Background(), "ignored", &interfaces.GenerateOptions{Memory: memory})
+	require.NoError(t, err)
+	require.Len(t, messages, 4)
+	assert.Equal(t, "Weather?", messages[0].Content[0].Text)
+	assert.Equal(t, json.RawMessage(`{"city":"Oslo"}`), messages[1].Content[0].ToolUse.Input)
+	assert.Equal(t, "c1", messages[2].Content[0].ToolResult.ToolUseID)
+	assert.Equal(t, "Tool result for unknown: Orphan", messages[2].Content[1].Text)
+	assert.Equal(t, "It is sunny.", messages[3].Content[0].Text)
+}
+
+func TestBuildMessages_ContentParts(t *testing.T) {
+	client := NewClient()
+	params := &interfaces.GenerateOptions{ContentParts: []interfaces.ContentPart{
+		interfaces.ImagePart([]byte("png"), "image/png"),
+		interfaces.DocumentPart([]byte("%PDF"), "application/pdf", "q3_report.pdf"),
+		{Type: interfaces.ContentPartDocument, URL: "s3://bucket/notes.md", MediaType: "text/markdown"},
+	}}
+
+	messages, err := client.buildMessages(context.Background(), "Summarize", params)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	blocks := messages[0].Content
+	require.Len(t, blocks, 4)
+	assert.Equal(t, "Summarize", blocks[0].Text)
+	assert.Equal(t, &ImageBlock{Format: "png", Source: MediaSource{Bytes: "cG5n"}}, blocks[1].Image)
+	assert.Equal(t, "pdf", blocks[2].Document.Format)
+	assert.Equal(t, "q3 report", blocks[2].Document.Name)
+	assert.Equal(t, &S3Location{URI: "s3://bucket/notes.md"}, blocks[3].Document.Source.S3Location)
+	assert.Equal(t, "document 3", blocks[3].Document.Name)
+
+	params.ContentParts = []interfaces.ContentPart{interfaces.ImageURLPart("https://example.com/cat.png")}
+	_, err = client.buildMessages(context.Background(), "Describe", params)
+	assert.ErrorIs(t, err, interfaces.ErrUnsupportedContent)
+}
+
+// recordingMemory stores messages in a slice
+type recordingMemory struct {
+	mu       sync.Mutex
+	messages []interfaces.Message
+}
+
+func (m *recordingMemory) AddMessage(ctx context.Context, message interfaces.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+func (m *recordingMemory) GetMessages(ctx context.Context, options ...interfaces.GetMessagesOption) ([]interfaces.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]interfaces.Message(nil), m.messages...), nil
+}
+
+func (m *recordingMemory) Clear(ctx context.Context) error {
+	m.messages = nil
+	return nil
+}
@@ -0,0 +1,122 @@
+package bedrock
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// ImageBlock is an image content block
+type ImageBlock struct {
+	Format string      `json:"format"` // png, jpeg, gif or webp
+	Source MediaSource `json:"source"`
+}
+
+// DocumentBlock is a document content block
+type DocumentBlock struct {
+	Format string      `json:"format"` // pdf, csv, doc, docx, xls, xlsx, html, txt or md
+	Name   string      `json:"name"`
+	Source MediaSource `json:"source"`
+}
+
+// MediaSource is the source of an image or document, either inline bytes or an S3 object
+type MediaSource struct {
+	Bytes      string      `json:"bytes,omitempty"` // Base64 encoded
+	S3Location *S3Location `json:"s3Location,omitempty"`
+}
+
+// S3Location references an object in Amazon S3
+type S3Location struct {
+	URI string `json:"uri"`
+}
+
+var (
+	imageFormats = map[string]string{
+		"image/png":  "png",
+		"image/jpeg": "jpeg",
+		"image/gif":  "gif",
+		"image/webp": "webp",
+	}
+	documentFormats = map[string]string{
+		"application/pdf":    "pdf",
+		"text/csv":           "csv",
+		"application/msword": "doc",
+		"application/vnd.openxmlformats-officedocument.wordprocessingml.document": "docx",
+		"application/vnd.ms-excel": "xls",
+		"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": "xlsx",
+		"text/html":     "html",
+		"text/plain":    "txt",
+		"text/markdown": "md",
+	}
+
+	// invalidDocumentNameChars matches characters not allowed in document names
+	invalidDocumentNameChars = regexp.MustCompile(`[^A-Za-z0-9 \-()\[\]]+`)
+)
+
+// userContent converts the text and content parts of a user message to content blocks
+func userContent(model, text string, parts []interfaces.ContentPart) ([]ContentBlock, error) {
+	blocks := make([]ContentBlock, 0, len(parts)+1)
+	if text != "" {
+		blocks = append(blocks, ContentBlock{Text: text})
+	}
+	for i, part := range parts {
+		if err := part.Validate(); err != nil {
+			return nil, err
+		}
+		if part.Type == interfaces.ContentPartText {
+			blocks = append(blocks, ContentBlock{Text: part.Text})
+			continue
+		}
+
+		unsupported := func(reason string) error {
+			return &interfaces.UnsupportedContentError{Provider: "bedrock", Model: model, Type: part.Type, Reason: reason}
+		}
+		if part.Type == interfaces.ContentPartAudio {
+			return nil, unsupported("")
+		}
+
+		var source MediaSource
+		switch {
+		case len(part.Data) > 0:
+			source.Bytes = part.Base64()
+		case strings.HasPrefix(part.URL, "s3://"):
+			source.S3Location = &S3Location{URI: part.URL}
+		default:
+			return nil, unsupported("content must be inline data or an s3:// URL")
+		}
+
+		mediaType := strings.ToLower(part.MediaType)
+		if part.Type == interfaces.ContentPartImage {
+			format, ok := imageFormats[mediaType]
+			if !ok {
+				return nil, unsupported("image media type must be png, jpeg, gif or webp")
+			}
+			blocks = append(blocks, ContentBlock{Image: &ImageBlock{Format: format, Source: source}})
+			continue
+		}
+
+		format, ok := documentFormats[mediaType]
+		if !ok {
+			return nil, unsupported("unsupported document media type " + part.MediaType)
+		}
+		blocks = append(blocks, ContentBlock{Document: &DocumentBlock{
+			Format: format,
+			Name:   documentName(part.Name, i),
+			Source: source,
+		}})
+	}
+	return blocks, nil
+}
+
+// documentName returns a document name that satisfies the Converse naming rules
+func documentName(name string, index int) string {
+	name = strings.TrimSuffix(name, path.Ext(name))
+	name = strings.TrimSpace(invalidDocumentNameChars.ReplaceAllString(name, " "))
+	if name == "" {
+		return fmt.Sprintf("document %d", index+1)
+	}
+	return name
+}
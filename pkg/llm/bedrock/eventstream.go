@@ -0,0 +1,104 @@
+package bedrock
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// maxEventMessageSize bounds the size of a single event stream message
+const maxEventMessageSize = 16 * 1024 * 1024
+
+// eventMessage is a message of the AWS event stream encoding used by ConverseStream
+type eventMessage struct {
+	Headers map[string]string
+	Payload []byte
+}
+
+// readEventMessage reads one message of the binary event stream framing:
+//
+//	total length (4) | headers length (4) | prelude CRC (4) | headers | payload | message CRC (4)
+//
+// It returns io.EOF when the stream ends cleanly between messages. Only string
+// headers are kept; headers of other types are skipped.
+func readEventMessage(r io.Reader) (*eventMessage, error) {
+	var prelude [12]byte
+	if _, err := io.ReadFull(r, prelude[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("truncated event stream prelude")
+		}
+		return nil, err
+	}
+
+	totalLength := binary.BigEndian.Uint32(prelude[0:4])
+	headersLength := binary.BigEndian.Uint32(prelude[4:8])
+	if crc32.ChecksumIEEE(prelude[:8]) != binary.BigEndian.Uint32(prelude[8:12]) {
+		return nil, fmt.Errorf("event stream prelude checksum mismatch")
+	}
+	if totalLength < 16 || totalLength > maxEventMessageSize || headersLength > totalLength-16 {
+		return nil, fmt.Errorf("invalid event stream message length %d (headers %d)", totalLength, headersLength)
+	}
+
+	rest := make([]byte, totalLength-12)
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return nil, fmt.Errorf("truncated event stream message: %w", err)
+	}
+	body := rest[:len(rest)-4]
+	checksum := crc32.Update(crc32.ChecksumIEEE(prelude[:]), crc32.IEEETable, body)
+	if checksum != binary.BigEndian.Uint32(rest[len(rest)-4:]) {
+		return nil, fmt.Errorf("event stream message checksum mismatch")
+	}
+
+	headers, err := parseEventHeaders(body[:headersLength])
+	if err != nil {
+		return nil, err
+	}
+	return &eventMessage{Headers: headers, Payload: body[headersLength:]}, nil
+}
+
+// parseEventHeaders decodes the header section of an event stream message
+func parseEventHeaders(data []byte) (map[string]string, error) {
+	headers := make(map[string]string)
+	for len(data) > 0 {
+		nameLength := int(data[0])
+		if len(data) < 1+nameLength+1 {
+			return nil, fmt.Errorf("truncated event stream header")
+		}
+		name := string(data[1 : 1+nameLength])
+		valueType := data[1+nameLength]
+		data = data[2+nameLength:]
+
+		var size int
+		switch valueType {
+		case 0, 1: // bool true, bool false
+			size = 0
+		case 2: // byte
+			size = 1
+		case 3: // short
+			size = 2
+		case 4: // int
+			size = 4
+		case 5, 8: // long, timestamp
+			size = 8
+		case 9: // uuid
+			size = 16
+		case 6, 7: // bytes, string
+			if len(data) < 2 {
+				return nil, fmt.Errorf("truncated event stream header %q", name)
+			}
+			size = 2 + int(binary.BigEndian.Uint16(data[:2]))
+		default:
+			return nil, fmt.Errorf("unknown event stream header type %d", valueType)
+		}
+		if len(data) < size {
+			return nil, fmt.Errorf("truncated event stream header %q", name)
+		}
+		if valueType == 7 {
+			headers[name] = string(data[2:size])
+		}
+		data = data[size:]
+	}
+	return headers, nil
+}
@@ -0,0 +1,70 @@
+package bedrock
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encodeEventMessage encodes a message with string headers in the event stream framing
+func encodeEventMessage(headers map[string]string, payload []byte) []byte {
+	var h bytes.Buffer
+	for name, value := range headers {
+		h.WriteByte(byte(len(name)))
+		h.WriteString(name)
+		h.WriteByte(7)
+		_ = binary.Write(&h, binary.BigEndian, uint16(len(value)))
+		h.WriteString(value)
+	}
+
+	var msg bytes.Buffer
+	_ = binary.Write(&msg, binary.BigEndian, uint32(16+h.Len()+len(payload)))
+	_ = binary.Write(&msg, binary.BigEndian, uint32(h.Len()))
+	_ = binary.Write(&msg, binary.BigEndian, crc32.ChecksumIEEE(msg.Bytes()))
+	msg.Write(h.Bytes())
+	msg.Write(payload)
+	_ = binary.Write(&msg, binary.BigEndian, crc32.ChecksumIEEE(msg.Bytes()))
+	return msg.Bytes()
+}
+
+func TestReadEventMessage(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write(encodeEventMessage(map[string]string{":event-type": "messageStart", ":message-type": "event"}, []byte(`{"role":"assistant"}`)))
+	stream.Write(encodeEventMessage(map[string]string{":event-type": "messageStop"}, nil))
+
+	msg, err := readEventMessage(&stream)
+	require.NoError(t, err)
+	assert.Equal(t, "messageStart", msg.Headers[":event-type"])
+	assert.Equal(t, "event", msg.Headers[":message-type"])
+	assert.JSONEq(t, `{"role":"assistant"}`, string(msg.Payload))
+
+	msg, err = readEventMessage(&stream)
+	require.NoError(t, err)
+	assert.Equal(t, "messageStop", msg.Headers[":event-type"])
+	assert.Empty(t, msg.Payload)
+
+	_, err = readEventMessage(&stream)
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestReadEventMessage_Corrupt(t *testing.T) {
+	encoded := encodeEventMessage(map[string]string{":event-type": "metadata"}, []byte(`{}`))
+
+	corrupt := append([]byte(nil), encoded...)
+	corrupt[len(corrupt)-5] ^= 0xff
+	_, err := readEventMessage(bytes.NewReader(corrupt))
+	assert.ErrorContains(t, err, "message checksum")
+
+	corrupt = append([]byte(nil), encoded...)
+	corrupt[0] ^= 0xff
+	_, err = readEventMessage(bytes.NewReader(corrupt))
+	assert.ErrorContains(t, err, "prelude checksum")
+
+	_, err = readEventMessage(bytes.NewReader(encoded[:len(encoded)-2]))
+	assert.ErrorContains(t, err, "truncated")
+}
@@ -0,0 +1,121 @@
+package bedrock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/llm"
+)
+
+// buildMessages constructs Bedrock messages from memory and the current prompt.
+// With memory, the prompt is expected to be stored in memory already. Content
+// parts are attached to the current user turn.
+func (c *BedrockClient) buildMessages(ctx context.Context, prompt string, params *interfaces.GenerateOptions) ([]Message, error) {
+	var memoryMessages []interfaces.Message
+	if params.Memory != nil {
+		var err error
+		memoryMessages, err = params.Memory.GetMessages(ctx)
+		if err != nil {
+			c.logger.Error(ctx, "Failed to retrieve memory messages", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	messages := []Message{}
+	toolUseIDs := make(map[string]bool)
+	for _, msg := range llm.AttachContentParts(memoryMessages, prompt, params.ContentParts) {
+		message, err := c.convertMemoryMessage(ctx, msg, toolUseIDs)
+		if err != nil {
+			return nil, err
+		}
+		if message == nil {
+			continue
+		}
+		// A conversation must start with a user message
+		if len(messages) == 0 && message.Role != "user" {
+			continue
+		}
+		// Roles must alternate, so consecutive messages of the same role are merged
+		if last := len(messages) - 1; last >= 0 && messages[last].Role == message.Role {
+			messages[last].Content = append(messages[last].Content, message.Content...)
+			continue
+		}
+		messages = append(messages, *message)
+	}
+
+	if len(messages) == 0 {
+		messages = append(messages, Message{Role: "user", Content: []ContentBlock{{Text: prompt}}})
+	}
+
+	if params.CacheConfig != nil && params.CacheConfig.CacheConversation {
+		last := &messages[len(messages)-1]
+		last.Content = append(last.Content, ContentBlock{CachePoint: defaultCachePoint()})
+	}
+
+	return messages, nil
+}
+
+// convertMemoryMessage converts a memory message to Bedrock format. toolUseIDs
+// collects the IDs of tool calls so that results without a call are sent as text.
+func (c *BedrockClient) convertMemoryMessage(ctx context.Context, msg interfaces.Message, toolUseIDs map[string]bool) (*Message, error) {
+	switch msg.Role {
+	case interfaces.MessageRoleUser:
+		blocks, err := userContent(c.Model, msg.Content, msg.Parts)
+		if err != nil || len(blocks) == 0 {
+			return nil, err
+		}
+		return &Message{Role: "user", Content: blocks}, nil
+
+	case interfaces.MessageRoleAssistant:
+		var blocks []ContentBlock
+		if msg.Content != "" {
+			blocks = append(blocks, ContentBlock{Text: msg.Content})
+		}
+		for _, toolCall := range msg.ToolCalls {
+			input := json.RawMessage(toolCall.Arguments)
+			if toolCall.Arguments == "" {
+				input = json.RawMessage("{}")
+			} else if !json.Valid(input) {
+				c.logger.Warn(ctx, "Failed to parse tool call arguments", map[string]interface{}{
+					"arguments": toolCall.Arguments,
+				})
+				continue
+			}
+			if toolCall.ID == "" {
+				continue // Results cannot be matched to a call without an ID
+			}
+			toolUseIDs[toolCall.ID] = true
+			blocks = append(blocks, ContentBlock{ToolUse: &ToolUseBlock{
+				ToolUseID: toolCall.ID,
+				Name:      toolCall.Name,
+				Input:     input,
+			}})
+		}
+		if len(blocks) == 0 {
+			return nil, nil
+		}
+		return &Message{Role: "assistant", Content: blocks}, nil
+
+	case interfaces.MessageRoleTool:
+		content := msg.Content
+		if content == "" {
+			content = "(no output)"
+		}
+		if !toolUseIDs[msg.ToolCallID] {
+			return &Message{Role: "user", Content: []ContentBlock{{Text: fmt.Sprintf("Tool result for %s: %s", msg.ToolCallID, content)}}}, nil
+		}
+		return &Message{Role: "user", Content: []ContentBlock{{ToolResult: &ToolResultBlock{
+			ToolUseID: msg.ToolCallID,
+			Content:   []ToolResultContent{{Text: content}},
+		}}}}, nil
+
+	case interfaces.MessageRoleSystem:
+		// The system prompt is sent separately, other system messages (like summaries) are passed as user messages
+		return &Message{Role: "user", Content: []ContentBlock{{Text: fmt.Sprintf("System: %s", msg.Content)}}}, nil
+	}
+
+	return nil, nil
+}
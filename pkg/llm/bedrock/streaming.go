@@ -0,0 +1,412 @@
+package bedrock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/llm"
+)
+
+// GenerateStream generates text with a streaming response using ConverseStream
+func (c *BedrockClient) GenerateStream(
+	ctx context.Context,
+	prompt string,
+	options ...interfaces.GenerateOption,
+) (<-chan interfaces.StreamEvent, error) {
+	if c.Model == "" {
+		return nil, fmt.Errorf("model not specified: use WithModel option when creating the client")
+	}
+
+	params := c.applyOptions(options)
+	ctx = ensureOrgID(ctx)
+	messages, err := c.buildMessages(ctx, prompt, params)
+	if err != nil {
+		return nil, err
+	}
+	req := c.newConverseRequest(messages, params, nil)
+
+	eventChan := make(chan interfaces.StreamEvent, bufferSize(params))
+	go func() {
+		defer close(eventChan)
+		s := &streamer{ctx: ctx, events: eventChan, includeThinking: includeThinking(params)}
+
+		s.send(interfaces.StreamEvent{Type: interfaces.StreamEventMessageStart, Metadata: map[string]interface{}{"model": c.Model}})
+		result, err := c.converseStream(ctx, req, s.send, s.sendThinking)
+		if err != nil {
+			s.send(interfaces.StreamEvent{Type: interfaces.StreamEventError, Error: err})
+			return
+		}
+		usage := &interfaces.TokenUsage{}
+		addUsage(usage, result.usage)
+		s.finish(result.stopReason, usage)
+	}()
+
+	return eventChan, nil
+}
+
+// GenerateWithToolsStream generates text with tools and a streaming response.
+// Text of intermediate tool iterations is only streamed when the stream config
+// includes intermediate messages.
+func (c *BedrockClient) GenerateWithToolsStream(
+	ctx context.Context,
+	prompt string,
+	tools []interfaces.Tool,
+	options ...interfaces.GenerateOption,
+) (<-chan interfaces.StreamEvent, error) {
+	if c.Model == "" {
+		return nil, fmt.Errorf("model not specified: use WithModel option when creating the client")
+	}
+
+	params := c.applyOptions(options)
+	ctx = ensureOrgID(ctx)
+
+	eventChan := make(chan interfaces.StreamEvent, bufferSize(params))
+	go func() {
+		defer close(eventChan)
+		s := &streamer{ctx: ctx, events: eventChan, includeThinking: includeThinking(params)}
+
+		s.send(interfaces.StreamEvent{Type: interfaces.StreamEventMessageStart, Metadata: map[string]interface{}{"model": c.Model}})
+		stopReason, usage, err := c.streamWithTools(ctx, prompt, tools, params, s)
+		if err != nil {
+			s.send(interfaces.StreamEvent{Type: interfaces.StreamEventError, Error: err})
+			return
+		}
+		s.finish(stopReason, usage)
+	}()
+
+	return eventChan, nil
+}
+
+// streamWithTools runs the tool loop, streaming tool events as tools are called
+func (c *BedrockClient) streamWithTools(
+	ctx context.Context,
+	prompt string,
+	tools []interfaces.Tool,
+	params *interfaces.GenerateOptions,
+	s *streamer,
+) (string, *interfaces.TokenUsage, error) {
+	maxIterations := params.MaxIterations
+	if maxIterations == 0 {
+		maxIterations = 2
+	}
+	includeIntermediate := params.StreamConfig != nil && params.StreamConfig.IncludeIntermediateMessages
+
+	messages, err := c.buildMessages(ctx, prompt, params)
+	if err != nil {
+		return "", nil, err
+	}
+	toolConfig := c.toolConfig(tools, params.CacheConfig)
+	toolCallHistory := make(map[string]int)
+	usage := &interfaces.TokenUsage{}
+
+	for iteration := 0; iteration < maxIterations; iteration++ {
+		// Text is held back until it is known whether this is the final answer
+		var buffered []interfaces.StreamEvent
+		onText := s.send
+		if !includeIntermediate {
+			onText = func(event interfaces.StreamEvent) bool {
+				buffered = append(buffered, event)
+				return true
+			}
+		}
+
+		if err := llm.BeforeCall(ctx, params); err != nil {
+			return "", nil, err
+		}
+
+		result, err := c.converseStream(ctx, c.newConverseRequest(messages, params, toolConfig), onText, s.sendThinking)
+		if err != nil {
+			return "", nil, fmt.Errorf("iteration %d: %w", iteration+1, err)
+		}
+		c.afterCall(ctx, params, result.usage)
+		addUsage(usage, result.usage)
+
+		toolUses := toolUseBlocks(&result.message)
+		if len(toolUses) == 0 {
+			for _, event := range buffered {
+				if !s.send(event) {
+					return "", nil, ctx.Err()
+				}
+			}
+			return result.stopReason, usage, nil
+		}
+
+		for _, toolUse := range toolUses {
+			s.send(interfaces.StreamEvent{
+				Type:     interfaces.StreamEventToolUse,
+				ToolCall: &interfaces.ToolCall{ID: toolUse.ToolUseID, Name: toolUse.Name, Arguments: string(toolUse.Input)},
+			})
+		}
+
+		results := c.executeToolsParallel(ctx, toolUses, tools, params, toolCallHistory, iteration)
+		for i, block := range results {
+			s.send(interfaces.StreamEvent{
+				Type:     interfaces.StreamEventToolResult,
+				ToolCall: &interfaces.ToolCall{ID: toolUses[i].ToolUseID, Name: toolUses[i].Name, Arguments: string(toolUses[i].Input)},
+				Content:  block.ToolResult.Content[0].Text,
+			})
+		}
+		if ctx.Err() != nil {
+			return "", nil, ctx.Err()
+		}
+
+		messages = append(messages, result.message, Message{Role: "user", Content: results})
+	}
+
+	c.logger.Info(ctx, "Maximum iterations reached, making final streaming call without tools", map[string]interface{}{
+		"maxIterations": maxIterations,
+	})
+
+	last := &messages[len(messages)-1]
+	last.Content = append(last.Content, ContentBlock{
+		Text: "Please provide your final response based on the information available. Do not request any additional tools.",
+	})
+
+	if err := llm.BeforeCall(ctx, params); err != nil {
+		return "", nil, err
+	}
+
+	result, err := c.converseStream(ctx, c.newConverseRequest(messages, params, toolConfig), s.send, s.sendThinking)
+	if err != nil {
+		return "", nil, fmt.Errorf("final call: %w", err)
+	}
+	c.afterCall(ctx, params, result.usage)
+	addUsage(usage, result.usage)
+	return result.stopReason, usage, nil
+}
+
+// streamResult is the accumulated outcome of one ConverseStream request
+type streamResult struct {
+	message    Message
+	usage      *Usage
+	stopReason string
+}
+
+// streamPayload holds the fields of all ConverseStream event payloads
+type streamPayload struct {
+	ContentBlockIndex int `json:"contentBlockIndex"`
+	Start             *struct {
+		ToolUse *struct {
+			ToolUseID string `json:"toolUseId"`
+			Name      string `json:"name"`
+		} `json:"toolUse"`
+	} `json:"start"`
+	Delta *struct {
+		Text    string `json:"text"`
+		ToolUse *struct {
+			Input string `json:"input"`
+		} `json:"toolUse"`
+		ReasoningContent *struct {
+			Text            string `json:"text"`
+			Signature       string `json:"signature"`
+			RedactedContent []byte `json:"redactedContent"`
+		} `json:"reasoningContent"`
+	} `json:"delta"`
+	StopReason string `json:"stopReason"`
+	Usage      *Usage `json:"usage"`
+	Message    string `json:"message"`
+}
+
+// blockBuilder accumulates the deltas of one content block
+type blockBuilder struct {
+	text      strings.Builder
+	toolUse   *ToolUseBlock
+	input     strings.Builder
+	reasoning *ReasoningContent
+}
+
+// converseStream sends a ConverseStream request and decodes the event stream,
+// passing text and reasoning deltas to the callbacks as they arrive
+func (c *BedrockClient) converseStream(
+	ctx context.Context,
+	req *ConverseRequest,
+	onText func(interfaces.StreamEvent) bool,
+	onThinking func(interfaces.StreamEvent) bool,
+) (*streamResult, error) {
+	httpReq, err := c.newHTTPRequest(ctx, "converse-stream", req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpResp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to bedrock: %w", err)
+	}
+	defer func() {
+		_ = httpResp.Body.Close()
+	}()
+
+	if httpResp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(httpResp.Body)
+		return nil, apiError(httpResp, body)
+	}
+
+	result := &streamResult{message: Message{Role: "assistant"}}
+	blocks := make(map[int]*blockBuilder)
+	block := func(index int) *blockBuilder {
+		if blocks[index] == nil {
+			blocks[index] = &blockBuilder{}
+		}
+		return blocks[index]
+	}
+
+	for {
+		msg, err := readEventMessage(httpResp.Body)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bedrock stream: %w", err)
+		}
+
+		var payload streamPayload
+		if len(msg.Payload) > 0 {
+			if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+				return nil, fmt.Errorf("failed to parse bedrock stream event: %w", err)
+			}
+		}
+
+		if messageType := msg.Headers[":message-type"]; messageType != "" && messageType != "event" {
+			errorType := firstNonEmpty(msg.Headers[":exception-type"], msg.Headers[":error-code"], messageType)
+			message := firstNonEmpty(payload.Message, msg.Headers[":error-message"], string(msg.Payload))
+			return nil, llm.NewError("bedrock", llm.ClassifyError(0, errorType, message), errorType, message)
+		}
+
+		switch msg.Headers[":event-type"] {
+		case "contentBlockStart":
+			if payload.Start != nil && payload.Start.ToolUse != nil {
+				block(payload.ContentBlockIndex).toolUse = &ToolUseBlock{
+					ToolUseID: payload.Start.ToolUse.ToolUseID,
+					Name:      payload.Start.ToolUse.Name,
+				}
+			}
+
+		case "contentBlockDelta":
+			if payload.Delta == nil {
+				continue
+			}
+			b := block(payload.ContentBlockIndex)
+			switch {
+			case payload.Delta.ToolUse != nil:
+				b.input.WriteString(payload.Delta.ToolUse.Input)
+			case payload.Delta.ReasoningContent != nil:
+				delta := payload.Delta.ReasoningContent
+				if b.reasoning == nil {
+					b.reasoning = &ReasoningContent{}
+				}
+				if len(delta.RedactedContent) > 0 {
+					b.reasoning.RedactedContent = append(b.reasoning.RedactedContent, delta.RedactedContent...)
+					continue
+				}
+				if b.reasoning.ReasoningText == nil {
+					b.reasoning.ReasoningText = &ReasoningText{}
+				}
+				b.reasoning.ReasoningText.Text += delta.Text
+				b.reasoning.ReasoningText.Signature += delta.Signature
+				if delta.Text != "" && !onThinking(interfaces.StreamEvent{Type: interfaces.StreamEventThinking, Content: delta.Text}) {
+					return nil, ctx.Err()
+				}
+			case payload.Delta.Text != "":
+				b.text.WriteString(payload.Delta.Text)
+				if !onText(interfaces.StreamEvent{Type: interfaces.StreamEventContentDelta, Content: payload.Delta.Text}) {
+					return nil, ctx.Err()
+				}
+			}
+
+		case "messageStop":
+			result.stopReason = payload.StopReason
+
+		case "metadata":
+			result.usage = payload.Usage
+		}
+	}
+
+	indexes := make([]int, 0, len(blocks))
+	for index := range blocks {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	for _, index := range indexes {
+		b := blocks[index]
+		switch {
+		case b.toolUse != nil:
+			input := b.input.String()
+			if input == "" {
+				input = "{}"
+			}
+			b.toolUse.Input = json.RawMessage(input)
+			result.message.Content = append(result.message.Content, ContentBlock{ToolUse: b.toolUse})
+		case b.reasoning != nil:
+			result.message.Content = append(result.message.Content, ContentBlock{ReasoningContent: b.reasoning})
+		case b.text.Len() > 0:
+			result.message.Content = append(result.message.Content, ContentBlock{Text: b.text.String()})
+		}
+	}
+	return result, nil
+}
+
+// bufferSize returns the event channel buffer size from the stream config
+func bufferSize(params *interfaces.GenerateOptions) int {
+	if params.StreamConfig != nil && params.StreamConfig.BufferSize > 0 {
+		return params.StreamConfig.BufferSize
+	}
+	return interfaces.DefaultStreamConfig().BufferSize
+}
+
+// includeThinking reports whether reasoning should be streamed
+func includeThinking(params *interfaces.GenerateOptions) bool {
+	if params.StreamConfig == nil {
+		return true
+	}
+	return params.StreamConfig.IncludeThinking
+}
+
+// streamer sends events until the context is cancelled
+type streamer struct {
+	ctx             context.Context
+	events          chan<- interfaces.StreamEvent
+	includeThinking bool
+}
+
+// send sends an event, returning false if the context was cancelled
+func (s *streamer) send(event interfaces.StreamEvent) bool {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	select {
+	case s.events <- event:
+		return true
+	case <-s.ctx.Done():
+		return false
+	}
+}
+
+// sendThinking sends a thinking event unless thinking is excluded from the stream
+func (s *streamer) sendThinking(event interfaces.StreamEvent) bool {
+	if !s.includeThinking {
+		return s.ctx.Err() == nil
+	}
+	return s.send(event)
+}
+
+// finish sends the completion events with the stop reason and token usage
+func (s *streamer) finish(stopReason string, usage *interfaces.TokenUsage) {
+	if !s.send(interfaces.StreamEvent{Type: interfaces.StreamEventContentComplete}) {
+		return
+	}
+	s.send(interfaces.StreamEvent{
+		Type: interfaces.StreamEventMessageStop,
+		Metadata: map[string]interface{}{
+			"stop_reason": stopReason,
+			"usage":       usage,
+		},
+	})
+}
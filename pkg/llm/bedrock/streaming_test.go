@@ -0,0 +1,131 @@
+package bedrock
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// eventStream responds with the given events in the event stream framing.
+// Each event is an event type followed by its JSON payload.
+func eventStream(events ...string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/vnd.amazon.eventstream")
+		for i := 0; i+1 < len(events); i += 2 {
+			headers := map[string]string{":event-type": events[i], ":message-type": "event", ":content-type": "application/json"}
+			_, _ = w.Write(encodeEventMessage(headers, []byte(events[i+1])))
+		}
+	}
+}
+
+func collect(t *testing.T, events <-chan interfaces.StreamEvent) []interfaces.StreamEvent {
+	var all []interfaces.StreamEvent
+	for event := range events {
+		all = append(all, event)
+	}
+	require.NotEmpty(t, all)
+	return all
+}
+
+func eventTypes(events []interfaces.StreamEvent) []interfaces.StreamEventType {
+	types := make([]interfaces.StreamEventType, len(events))
+	for i, event := range events {
+		types[i] = event.Type
+	}
+	return types
+}
+
+func TestGenerateStream(t *testing.T) {
+	client, runtime := newTestClient(t, eventStream(
+		"messageStart", `{"role":"assistant"}`,
+		"contentBlockDelta", `{"contentBlockIndex":0,"delta":{"reasoningContent":{"text":"Hmm"}}}`,
+		"contentBlockDelta", `{"contentBlockIndex":0,"delta":{"reasoningContent":{"signature":"sig"}}}`,
+		"contentBlockStop", `{"contentBlockIndex":0}`,
+		"contentBlockDelta", `{"contentBlockIndex":1,"delta":{"text":"Hel"}}`,
+		"contentBlockDelta", `{"contentBlockIndex":1,"delta":{"text":"lo"}}`,
+		"contentBlockStop", `{"contentBlockIndex":1}`,
+		"messageStop", `{"stopReason":"end_turn"}`,
+		"metadata", `{"usage":{"inputTokens":4,"outputTokens":2,"totalTokens":6},"metrics":{"latencyMs":10}}`,
+	))
+
+	events, err := client.GenerateStream(context.Background(), "Hi")
+	require.NoError(t, err)
+	all := collect(t, events)
+
+	assert.Equal(t, []interfaces.StreamEventType{
+		interfaces.StreamEventMessageStart,
+		interfaces.StreamEventThinking,
+		interfaces.StreamEventContentDelta,
+		interfaces.StreamEventContentDelta,
+		interfaces.StreamEventContentComplete,
+		interfaces.StreamEventMessageStop,
+	}, eventTypes(all))
+	assert.Equal(t, "Hmm", all[1].Content)
+	assert.Equal(t, "Hel", all[2].Content)
+
+	stop := all[len(all)-1]
+	assert.Equal(t, "end_turn", stop.Metadata["stop_reason"])
+	assert.Equal(t, &interfaces.TokenUsage{InputTokens: 4, OutputTokens: 2, TotalTokens: 6}, stop.Metadata["usage"])
+	assert.Equal(t, "/model/anthropic.claude-3-7-sonnet-20250219-v1%3A0/converse-stream", runtime.paths[0])
+}
+
+func TestGenerateWithToolsStream(t *testing.T) {
+	client, runtime := newTestClient(t,
+		eventStream(
+			"contentBlockDelta", `{"contentBlockIndex":0,"delta":{"text":"Calling echo."}}`,
+			"contentBlockStart", `{"contentBlockIndex":1,"start":{"toolUse":{"toolUseId":"t1","name":"echo"}}}`,
+			"contentBlockDelta", `{"contentBlockIndex":1,"delta":{"toolUse":{"input":"{\"message\":"}}}`,
+			"contentBlockDelta", `{"contentBlockIndex":1,"delta":{"toolUse":{"input":"\"hi\"}"}}}`,
+			"contentBlockStop", `{"contentBlockIndex":1}`,
+			"messageStop", `{"stopReason":"tool_use"}`,
+		),
+		eventStream(
+			"contentBlockDelta", `{"contentBlockIndex":0,"delta":{"text":"It said hi."}}`,
+			"messageStop", `{"stopReason":"end_turn"}`,
+		))
+
+	events, err := client.GenerateWithToolsStream(context.Background(), "Echo hi", []interfaces.Tool{echoTool{}})
+	require.NoError(t, err)
+	all := collect(t, events)
+
+	// Text of the tool iteration is not streamed by default
+	assert.Equal(t, []interfaces.StreamEventType{
+		interfaces.StreamEventMessageStart,
+		interfaces.StreamEventToolUse,
+		interfaces.StreamEventToolResult,
+		interfaces.StreamEventContentDelta,
+		interfaces.StreamEventContentComplete,
+		interfaces.StreamEventMessageStop,
+	}, eventTypes(all))
+	assert.Equal(t, &interfaces.ToolCall{ID: "t1", Name: "echo", Arguments: `{"message":"hi"}`}, all[1].ToolCall)
+	assert.Equal(t, "echo: hi", all[2].Content)
+	assert.Equal(t, "It said hi.", all[3].Content)
+
+	// The assistant turn is sent back with its text and tool use
+	require.Len(t, runtime.requests, 2)
+	assistant := runtime.requests[1].Messages[1]
+	require.Len(t, assistant.Content, 2)
+	assert.Equal(t, "Calling echo.", assistant.Content[0].Text)
+	assert.Equal(t, "t1", assistant.Content[1].ToolUse.ToolUseID)
+	assert.Equal(t, "t1", runtime.requests[1].Messages[2].Content[0].ToolResult.ToolUseID)
+}
+
+func TestGenerateStream_Exception(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter) {
+		headers := map[string]string{":message-type": "exception", ":exception-type": "throttlingException"}
+		_, _ = w.Write(encodeEventMessage(headers, []byte(`{"message":"Too many requests"}`)))
+	})
+
+	events, err := client.GenerateStream(context.Background(), "Hi")
+	require.NoError(t, err)
+	all := collect(t, events)
+
+	last := all[len(all)-1]
+	assert.Equal(t, interfaces.StreamEventError, last.Type)
+	assert.ErrorContains(t, last.Error, "throttlingException): Too many requests")
+}
@@ -0,0 +1,257 @@
+// Package cache provides an LLM that serves repeated requests from a cache of
+// previous responses.
+//
+// Requests are matched exactly by a hash of the prompt, system message,
+// history, content parts, LLM configuration and response format. In semantic
+// mode, a request that misses is matched with the most similar prompt of the
+// requests that differ only by their prompt, using an embedding client and a
+// similarity threshold. Responses are cached per organization.
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/tagus/agent-sdk-go/pkg/embedding"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// DefaultSimilarityThreshold is the minimum cosine similarity of a semantic match
+const DefaultSimilarityThreshold = 0.95
+
+// Metadata keys set on cached responses and replayed stream events
+const (
+	// MetadataCacheHit is true for responses served from the cache
+	MetadataCacheHit = "cache_hit"
+
+	// MetadataCacheSimilarity is the similarity of a semantic match
+	MetadataCacheSimilarity = "cache_similarity"
+)
+
+// Stats reports the effectiveness of a cached LLM
+type Stats struct {
+	// Hits is the number of requests served by an exact match
+	Hits int64
+
+	// SemanticHits is the number of requests served by a semantic match
+	SemanticHits int64
+
+	// Misses is the number of requests sent to the LLM
+	Misses int64
+
+	// Errors is the number of failed cache reads, writes and embeddings. A
+	// failed read is treated as a miss and a failed write is ignored.
+	Errors int64
+}
+
+// LLM is an interfaces.LLM that caches the responses of Generate,
+// GenerateDetailed and GenerateStream. Requests with tools are not cached
+// because their tools have side effects.
+type LLM struct {
+	llm   interfaces.LLM
+	store Store
+	ttl   time.Duration
+
+	embedder  embedding.Client
+	threshold float32
+
+	hits         atomic.Int64
+	semanticHits atomic.Int64
+	misses       atomic.Int64
+	errors       atomic.Int64
+}
+
+// Option configures a cached LLM
+type Option func(*LLM)
+
+// WithTTL sets the expiration of cached responses. Zero keeps them until
+// evicted by the store.
+func WithTTL(ttl time.Duration) Option {
+	return func(c *LLM) {
+		c.ttl = ttl
+	}
+}
+
+// WithSemanticMatching matches prompts that miss the exact cache with the most
+// similar cached prompt, if their cosine similarity reaches the threshold.
+// Zero uses DefaultSimilarityThreshold.
+func WithSemanticMatching(embedder embedding.Client, threshold float32) Option {
+	return func(c *LLM) {
+		c.embedder = embedder
+		c.threshold = threshold
+		if c.threshold <= 0 {
+			c.threshold = DefaultSimilarityThreshold
+		}
+	}
+}
+
+// New wraps an LLM with a response cache
+func New(llm interfaces.LLM, store Store, options ...Option) *LLM {
+	c := &LLM{llm: llm, store: store}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// Generate generates text, using the cache when possible
+func (c *LLM) Generate(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (string, error) {
+	resp, err := c.GenerateDetailed(ctx, prompt, options...)
+	if err != nil {
+		return "", err
+	}
+	return resp.Content, nil
+}
+
+// GenerateDetailed generates text and returns detailed response information,
+// using the cache when possible. Cached responses report no token usage.
+func (c *LLM) GenerateDetailed(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (*interfaces.LLMResponse, error) {
+	lookup := c.lookup(ctx, prompt, options)
+	if lookup.response != nil {
+		return lookup.response, nil
+	}
+
+	resp, err := c.llm.GenerateDetailed(ctx, prompt, options...)
+	if err != nil {
+		return nil, err
+	}
+	c.save(ctx, lookup, prompt, resp)
+	return resp, nil
+}
+
+// GenerateWithTools generates text with tools without caching
+func (c *LLM) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (string, error) {
+	return c.llm.GenerateWithTools(ctx, prompt, tools, options...)
+}
+
+// GenerateWithToolsDetailed generates text with tools without caching
+func (c *LLM) GenerateWithToolsDetailed(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (*interfaces.LLMResponse, error) {
+	return c.llm.GenerateWithToolsDetailed(ctx, prompt, tools, options...)
+}
+
+// Name returns the name of the wrapped LLM
+func (c *LLM) Name() string {
+	return c.llm.Name()
+}
+
+// SupportsStreaming returns true if the wrapped LLM supports streaming
+func (c *LLM) SupportsStreaming() bool {
+	_, ok := c.llm.(interfaces.StreamingLLM)
+	return ok && c.llm.SupportsStreaming()
+}
+
+// GetModel returns the model of the wrapped LLM
+func (c *LLM) GetModel() string {
+	if m, ok := c.llm.(interface{ GetModel() string }); ok {
+		return m.GetModel()
+	}
+	return ""
+}
+
+// Stats returns the cache hit, miss and error counts
+func (c *LLM) Stats() Stats {
+	return Stats{
+		Hits:         c.hits.Load(),
+		SemanticHits: c.semanticHits.Load(),
+		Misses:       c.misses.Load(),
+		Errors:       c.errors.Load(),
+	}
+}
+
+// lookupResult is the outcome of a cache lookup. On a miss it carries what is
+// needed to store the response.
+type lookupResult struct {
+	response  *interfaces.LLMResponse
+	key       string
+	scope     string
+	embedding []float32
+}
+
+// lookup returns the cached response of a request, matched exactly or
+// semantically
+func (c *LLM) lookup(ctx context.Context, prompt string, options []interfaces.GenerateOption) lookupResult {
+	opts := &interfaces.GenerateOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+
+	key, scope, err := c.keys(ctx, prompt, opts)
+	if err != nil {
+		c.errors.Add(1)
+		c.misses.Add(1)
+		return lookupResult{}
+	}
+	result := lookupResult{key: key, scope: scope}
+
+	entry, err := c.store.Get(ctx, key)
+	if err != nil {
+		c.errors.Add(1)
+	} else if entry != nil && entry.Response != nil {
+		c.hits.Add(1)
+		result.response = cachedResponse(entry.Response, 1)
+		return result
+	}
+
+	if c.embedder != nil {
+		vector, err := c.embedder.Embed(ctx, prompt)
+		if err != nil {
+			c.errors.Add(1)
+		} else {
+			result.embedding = vector
+			entry, similarity, err := c.store.Nearest(ctx, scope, vector)
+			if err != nil {
+				c.errors.Add(1)
+			} else if entry != nil && entry.Response != nil && similarity >= c.threshold {
+				c.semanticHits.Add(1)
+				result.response = cachedResponse(entry.Response, similarity)
+				return result
+			}
+		}
+	}
+
+	c.misses.Add(1)
+	return result
+}
+
+// save caches the response of a request that missed
+func (c *LLM) save(ctx context.Context, lookup lookupResult, prompt string, resp *interfaces.LLMResponse) {
+	if lookup.key == "" || resp == nil {
+		return
+	}
+	entry := &Entry{
+		Key:       lookup.key,
+		Scope:     lookup.scope,
+		Prompt:    prompt,
+		Embedding: lookup.embedding,
+		Response:  resp,
+		CreatedAt: time.Now(),
+	}
+	if err := c.store.Set(ctx, entry, c.ttl); err != nil {
+		c.errors.Add(1)
+	}
+}
+
+// cachedResponse returns a copy of a cached response marked as a cache hit.
+// The usage is reset since the cached response cost nothing.
+func cachedResponse(resp *interfaces.LLMResponse, similarity float32) *interfaces.LLMResponse {
+	cached := *resp
+	cached.Usage = &interfaces.TokenUsage{}
+	cached.Metadata = make(map[string]interface{}, len(resp.Metadata)+2)
+	for key, value := range resp.Metadata {
+		cached.Metadata[key] = value
+	}
+	cached.Metadata[MetadataCacheHit] = true
+	cached.Metadata[MetadataCacheSimilarity] = similarity
+	return &cached
+}
+
+// streamingLLM returns the wrapped LLM if it supports streaming
+func (c *LLM) streamingLLM() (interfaces.StreamingLLM, error) {
+	streaming, ok := c.llm.(interfaces.StreamingLLM)
+	if !ok {
+		return nil, fmt.Errorf("LLM '%s' does not support streaming", c.llm.Name())
+	}
+	return streaming, nil
+}
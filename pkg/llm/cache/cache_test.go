@@ -0,0 +1,269 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tagus/agent-sdk-go/pkg/embedding"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/memory"
+	"github.com/tagus/agent-sdk-go/pkg/multitenancy"
+)
+
+// countingLLM answers with the prompt and counts the requests it receives
+type countingLLM struct {
+	calls int
+}
+
+func (m *countingLLM) Generate(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (string, error) {
+	resp, err := m.GenerateDetailed(ctx, prompt, options...)
+	if err != nil {
+		return "", err
+	}
+	return resp.Content, nil
+}
+
+func (m *countingLLM) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (string, error) {
+	return m.Generate(ctx, prompt, options...)
+}
+
+func (m *countingLLM) GenerateDetailed(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (*interfaces.LLMResponse, error) {
+	m.calls++
+	return &interfaces.LLMResponse{
+		Content: "answer to " + prompt,
+		Model:   "test-model",
+		Usage:   &interfaces.TokenUsage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15},
+	}, nil
+}
+
+func (m *countingLLM) GenerateWithToolsDetailed(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (*interfaces.LLMResponse, error) {
+	return m.GenerateDetailed(ctx, prompt, options...)
+}
+
+func (m *countingLLM) Name() string {
+	return "counting"
+}
+
+func (m *countingLLM) SupportsStreaming() bool {
+	return true
+}
+
+func (m *countingLLM) GenerateStream(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (<-chan interfaces.StreamEvent, error) {
+	m.calls++
+	events := make(chan interfaces.StreamEvent, 4)
+	events <- interfaces.StreamEvent{Type: interfaces.StreamEventMessageStart}
+	events <- interfaces.StreamEvent{Type: interfaces.StreamEventContentDelta, Content: "answer to "}
+	events <- interfaces.StreamEvent{Type: interfaces.StreamEventContentDelta, Content: prompt}
+	events <- interfaces.StreamEvent{Type: interfaces.StreamEventMessageStop}
+	close(events)
+	return events, nil
+}
+
+func (m *countingLLM) GenerateWithToolsStream(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (<-chan interfaces.StreamEvent, error) {
+	return m.GenerateStream(ctx, prompt, options...)
+}
+
+func TestLLM_ExactMatch(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingLLM{}
+	llm := New(inner, NewMemoryStore(0))
+
+	first, err := llm.GenerateDetailed(ctx, "What is Go?", interfaces.WithSystemMessage("be brief"))
+	require.NoError(t, err)
+	assert.Nil(t, first.Metadata[MetadataCacheHit])
+
+	second, err := llm.GenerateDetailed(ctx, "What is Go?", interfaces.WithSystemMessage("be brief"))
+	require.NoError(t, err)
+	assert.Equal(t, first.Content, second.Content)
+	assert.Equal(t, true, second.Metadata[MetadataCacheHit])
+	assert.Equal(t, 0, second.Usage.TotalTokens)
+	assert.Equal(t, 1, inner.calls)
+
+	// Any difference in the request is a miss
+	_, err = llm.Generate(ctx, "What is Go?", interfaces.WithSystemMessage("be verbose"))
+	require.NoError(t, err)
+	_, err = llm.Generate(ctx, "What is Go?", interfaces.WithSystemMessage("be brief"), interfaces.WithTemperature(0.5))
+	require.NoError(t, err)
+	assert.Equal(t, 3, inner.calls)
+
+	// Requests with tools are never cached
+	_, err = llm.GenerateWithTools(ctx, "What is Go?", nil, interfaces.WithSystemMessage("be brief"))
+	require.NoError(t, err)
+	assert.Equal(t, 4, inner.calls)
+
+	assert.Equal(t, Stats{Hits: 1, Misses: 3}, llm.Stats())
+}
+
+func TestLLM_History(t *testing.T) {
+	ctx := memory.WithConversationID(multitenancy.WithOrgID(context.Background(), "org"), "conversation")
+	inner := &countingLLM{}
+	llm := New(inner, NewMemoryStore(0))
+
+	mem := memory.NewConversationBuffer()
+	require.NoError(t, mem.AddMessage(ctx, interfaces.Message{Role: interfaces.MessageRoleUser, Content: "My name is Ada"}))
+	_, err := llm.Generate(ctx, "What is my name?", interfaces.WithMemory(mem))
+	require.NoError(t, err)
+
+	other := memory.NewConversationBuffer()
+	require.NoError(t, other.AddMessage(ctx, interfaces.Message{Role: interfaces.MessageRoleUser, Content: "My name is Bob"}))
+	_, err = llm.Generate(ctx, "What is my name?", interfaces.WithMemory(other))
+	require.NoError(t, err)
+	assert.Equal(t, 2, inner.calls)
+
+	// The prompt added to the history by agents does not change the key
+	require.NoError(t, mem.AddMessage(ctx, interfaces.Message{Role: interfaces.MessageRoleUser, Content: "What is my name?"}))
+	_, err = llm.Generate(ctx, "What is my name?", interfaces.WithMemory(mem))
+	require.NoError(t, err)
+	assert.Equal(t, 2, inner.calls)
+}
+
+func TestLLM_Namespaces(t *testing.T) {
+	inner := &countingLLM{}
+	llm := New(inner, NewMemoryStore(0))
+
+	_, err := llm.Generate(multitenancy.WithOrgID(context.Background(), "org-1"), "hello")
+	require.NoError(t, err)
+	_, err = llm.Generate(multitenancy.WithOrgID(context.Background(), "org-2"), "hello")
+	require.NoError(t, err)
+	_, err = llm.Generate(context.Background(), "hello", func(o *interfaces.GenerateOptions) { o.OrgID = "org-1" })
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, inner.calls)
+}
+
+func TestLLM_SemanticMatch(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingLLM{}
+	llm := New(inner, NewMemoryStore(0), WithSemanticMatching(embedding.NewHashEmbedder(256), 0.8))
+
+	_, err := llm.Generate(ctx, "How do I reset my password?")
+	require.NoError(t, err)
+
+	resp, err := llm.GenerateDetailed(ctx, "How do I reset my password, please?")
+	require.NoError(t, err)
+	assert.Equal(t, "answer to How do I reset my password?", resp.Content)
+	assert.Equal(t, true, resp.Metadata[MetadataCacheHit])
+	similarity, ok := resp.Metadata[MetadataCacheSimilarity].(float32)
+	require.True(t, ok)
+	assert.Less(t, similarity, float32(1))
+
+	_, err = llm.Generate(ctx, "What are your opening hours on weekends?")
+	require.NoError(t, err)
+
+	// Semantic matches are limited to requests with the same system message
+	_, err = llm.Generate(ctx, "How do I reset my password?", interfaces.WithSystemMessage("answer in French"))
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, inner.calls)
+	assert.Equal(t, Stats{SemanticHits: 1, Misses: 3}, llm.Stats())
+}
+
+func TestLLM_Streaming(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingLLM{}
+	llm := New(inner, NewMemoryStore(0))
+
+	collect := func(events <-chan interfaces.StreamEvent) (string, []interfaces.StreamEventType) {
+		var content string
+		var types []interfaces.StreamEventType
+		for event := range events {
+			types = append(types, event.Type)
+			if event.Type == interfaces.StreamEventContentDelta {
+				content += event.Content
+			}
+		}
+		return content, types
+	}
+
+	events, err := llm.GenerateStream(ctx, "stream me")
+	require.NoError(t, err)
+	content, _ := collect(events)
+	assert.Equal(t, "answer to stream me", content)
+
+	// The streamed response is served to both streaming and detailed requests
+	events, err = llm.GenerateStream(ctx, "stream me")
+	require.NoError(t, err)
+	content, types := collect(events)
+	assert.Equal(t, "answer to stream me", content)
+	assert.Equal(t, []interfaces.StreamEventType{
+		interfaces.StreamEventMessageStart,
+		interfaces.StreamEventContentDelta,
+		interfaces.StreamEventContentComplete,
+		interfaces.StreamEventMessageStop,
+	}, types)
+
+	text, err := llm.Generate(ctx, "stream me")
+	require.NoError(t, err)
+	assert.Equal(t, "answer to stream me", text)
+	assert.Equal(t, 1, inner.calls)
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(2)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	entry := func(key string) *Entry {
+		return &Entry{Key: key, Scope: "scope", Embedding: []float32{1, 0}, Response: &interfaces.LLMResponse{Content: key}}
+	}
+
+	require.NoError(t, store.Set(ctx, entry("a"), time.Minute))
+	require.NoError(t, store.Set(ctx, entry("b"), 0))
+	_, err := store.Get(ctx, "a")
+	require.NoError(t, err)
+
+	// The least recently used entry is evicted
+	require.NoError(t, store.Set(ctx, entry("c"), 0))
+	got, err := store.Get(ctx, "b")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+	assert.Equal(t, 2, store.Len())
+
+	// Expired entries are neither returned nor matched
+	now = now.Add(2 * time.Minute)
+	got, err = store.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	nearest, similarity, err := store.Nearest(ctx, "scope", []float32{1, 0})
+	require.NoError(t, err)
+	require.NotNil(t, nearest)
+	assert.Equal(t, "c", nearest.Key)
+	assert.InDelta(t, 1, similarity, 1e-6)
+}
+
+func TestRedisStore(t *testing.T) {
+	server, err := miniredis.Run()
+	require.NoError(t, err)
+	defer server.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	ctx := context.Background()
+	inner := &countingLLM{}
+	llm := New(inner, NewRedisStore(client), WithTTL(time.Hour), WithSemanticMatching(embedding.NewHashEmbedder(256), 0.8))
+
+	_, err = llm.Generate(ctx, "How do I reset my password?")
+	require.NoError(t, err)
+	resp, err := llm.GenerateDetailed(ctx, "How do I reset my password?")
+	require.NoError(t, err)
+	assert.Equal(t, true, resp.Metadata[MetadataCacheHit])
+	resp, err = llm.GenerateDetailed(ctx, "how do I reset my password")
+	require.NoError(t, err)
+	assert.Equal(t, true, resp.Metadata[MetadataCacheHit])
+	assert.Equal(t, 1, inner.calls)
+
+	// Expired entries are removed from their scope
+	server.FastForward(2 * time.Hour)
+	_, err = llm.Generate(ctx, "how do I reset my password")
+	require.NoError(t, err)
+	assert.Equal(t, 2, inner.calls)
+}
@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/multitenancy"
+)
+
+// defaultNamespace is used for requests without an organization
+const defaultNamespace = "default"
+
+// request is the canonical form of a generation request. It is serialized
+// with encoding/json, which writes struct fields in declaration order and map
+// keys sorted, so equal requests always hash to the same key.
+type request struct {
+	Provider       string                     `json:"provider"`
+	Model          string                     `json:"model"`
+	SystemMessage  string                     `json:"system_message"`
+	LLMConfig      *interfaces.LLMConfig      `json:"llm_config"`
+	ResponseFormat *interfaces.ResponseFormat `json:"response_format"`
+	History        []message                  `json:"history"`
+	Parts          []interfaces.ContentPart   `json:"parts"`
+	Prompt         string                     `json:"prompt,omitempty"`
+}
+
+// message is the part of a history message that affects the response
+type message struct {
+	Role       string                   `json:"role"`
+	Content    string                   `json:"content"`
+	ToolCallID string                   `json:"tool_call_id,omitempty"`
+	ToolCalls  []interfaces.ToolCall    `json:"tool_calls,omitempty"`
+	Parts      []interfaces.ContentPart `json:"parts,omitempty"`
+}
+
+// keys returns the exact key of a request and its scope, the key of the
+// request without its prompt. Semantic lookups only match entries of the same
+// scope. Both are prefixed with the namespace of the organization.
+func (c *LLM) keys(ctx context.Context, prompt string, options *interfaces.GenerateOptions) (string, string, error) {
+	req := request{
+		Provider:       c.llm.Name(),
+		SystemMessage:  options.SystemMessage,
+		LLMConfig:      options.LLMConfig,
+		ResponseFormat: options.ResponseFormat,
+		Parts:          options.ContentParts,
+	}
+	if m, ok := c.llm.(interface{ GetModel() string }); ok {
+		req.Model = m.GetModel()
+	}
+
+	if options.Memory != nil {
+		history, err := options.Memory.GetMessages(ctx)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to read history: %w", err)
+		}
+		// Agents add the prompt to the history before generating, it is
+		// part of the key as the prompt and must not be part of the scope
+		if n := len(history); n > 0 && history[n-1].Role == interfaces.MessageRoleUser && history[n-1].Content == prompt {
+			history = history[:n-1]
+		}
+		req.History = make([]message, len(history))
+		for i, msg := range history {
+			req.History[i] = message{
+				Role:       string(msg.Role),
+				Content:    msg.Content,
+				ToolCallID: msg.ToolCallID,
+				ToolCalls:  msg.ToolCalls,
+				Parts:      msg.Parts,
+			}
+		}
+	}
+
+	namespace := namespaceOf(ctx, options)
+	scope, err := hashRequest(req)
+	if err != nil {
+		return "", "", err
+	}
+	req.Prompt = prompt
+	key, err := hashRequest(req)
+	if err != nil {
+		return "", "", err
+	}
+	return namespace + ":" + key, namespace + ":" + scope, nil
+}
+
+func hashRequest(req request) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// namespaceOf returns the organization of a request, so that organizations
+// never share cached responses
+func namespaceOf(ctx context.Context, options *interfaces.GenerateOptions) string {
+	if options.OrgID != "" {
+		return options.OrgID
+	}
+	if orgID, err := multitenancy.GetOrgID(ctx); err == nil && orgID != "" {
+		return orgID
+	}
+	return defaultNamespace
+}
@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/tagus/agent-sdk-go/pkg/embedding"
+)
+
+// DefaultRedisPrefix is prepended to every Redis key
+const DefaultRedisPrefix = "llmcache:"
+
+// RedisStore stores cached responses in Redis as JSON. The keys of the entries
+// of each scope are kept in a set for semantic lookups, which compare the
+// prompt with every entry of the scope.
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// RedisOption represents an option for configuring the Redis store
+type RedisOption func(*RedisStore)
+
+// WithRedisPrefix sets the prefix of the Redis keys
+func WithRedisPrefix(prefix string) RedisOption {
+	return func(s *RedisStore) {
+		s.prefix = prefix
+	}
+}
+
+// NewRedisStore creates a Redis response store on an existing client, such as
+// the one used by memory.RedisMemory
+func NewRedisStore(client redis.UniversalClient, options ...RedisOption) *RedisStore {
+	s := &RedisStore{
+		client: client,
+		prefix: DefaultRedisPrefix,
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+func (s *RedisStore) entryKey(key string) string {
+	return s.prefix + "entry:" + key
+}
+
+func (s *RedisStore) scopeKey(scope string) string {
+	return s.prefix + "scope:" + scope
+}
+
+// Get returns the entry of a key, or nil if there is none
+func (s *RedisStore) Get(ctx context.Context, key string) (*Entry, error) {
+	data, err := s.client.Get(ctx, s.entryKey(key)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from redis: %w", err)
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		// Treat corrupt entries as misses; they are overwritten on the next Set
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+// Set stores an entry. The TTL of the scope set is extended to the TTL of its
+// newest entry.
+func (s *RedisStore) Set(ctx context.Context, entry *Entry, ttl time.Duration) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode response: %w", err)
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, s.entryKey(entry.Key), data, ttl)
+		if len(entry.Embedding) > 0 {
+			pipe.SAdd(ctx, s.scopeKey(entry.Scope), entry.Key)
+			if ttl > 0 {
+				pipe.Expire(ctx, s.scopeKey(entry.Scope), ttl)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write response to redis: %w", err)
+	}
+	return nil
+}
+
+// Nearest returns the most similar entry of a scope. Keys of expired entries
+// are removed from the scope.
+func (s *RedisStore) Nearest(ctx context.Context, scope string, vector []float32) (*Entry, float32, error) {
+	keys, err := s.client.SMembers(ctx, s.scopeKey(scope)).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read scope from redis: %w", err)
+	}
+	if len(keys) == 0 {
+		return nil, 0, nil
+	}
+
+	entryKeys := make([]string, len(keys))
+	for i, key := range keys {
+		entryKeys[i] = s.entryKey(key)
+	}
+	values, err := s.client.MGet(ctx, entryKeys...).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read responses from redis: %w", err)
+	}
+
+	var (
+		best       *Entry
+		similarity float32
+		expired    []interface{}
+	)
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			expired = append(expired, keys[i])
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal([]byte(data), &entry); err != nil || len(entry.Embedding) != len(vector) {
+			continue
+		}
+		score, err := embedding.CalculateSimilarity(vector, entry.Embedding, "cosine")
+		if err != nil {
+			continue
+		}
+		if best == nil || score > similarity {
+			best, similarity = &entry, score
+		}
+	}
+
+	if len(expired) > 0 {
+		// Best effort, a failure only leaves stale keys in the set
+		s.client.SRem(ctx, s.scopeKey(scope), expired...)
+	}
+	return best, similarity, nil
+}
@@ -0,0 +1,181 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/tagus/agent-sdk-go/pkg/embedding"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// DefaultMemoryStoreSize is the default number of responses kept by a MemoryStore
+const DefaultMemoryStoreSize = 1000
+
+// Entry is a cached response
+type Entry struct {
+	// Key is the exact key of the request
+	Key string `json:"key"`
+
+	// Scope is the key of the request without its prompt
+	Scope string `json:"scope"`
+
+	// Prompt is the prompt of the request
+	Prompt string `json:"prompt"`
+
+	// Embedding is the embedding of the prompt, set in semantic mode
+	Embedding []float32 `json:"embedding,omitempty"`
+
+	// Response is the response of the LLM
+	Response *interfaces.LLMResponse `json:"response"`
+
+	// CreatedAt is the time the response was generated
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Store stores cached responses. Implementations must be safe for concurrent use.
+type Store interface {
+	// Get returns the entry of a key, or nil if there is none
+	Get(ctx context.Context, key string) (*Entry, error)
+
+	// Set stores an entry. A zero TTL keeps the entry until it is evicted.
+	Set(ctx context.Context, entry *Entry, ttl time.Duration) error
+
+	// Nearest returns the entry of a scope whose embedding is the most similar
+	// to the vector and its cosine similarity, or nil if the scope has no
+	// entries with embeddings
+	Nearest(ctx context.Context, scope string, vector []float32) (*Entry, float32, error)
+}
+
+// MemoryStore is an in-memory Store that evicts the least recently used
+// responses. Expired responses are removed when they are read.
+type MemoryStore struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	scopes   map[string]map[string]struct{}
+	order    *list.List
+	now      func() time.Time
+}
+
+type memoryEntry struct {
+	entry   *Entry
+	expires time.Time
+}
+
+// NewMemoryStore creates an in-memory store holding up to capacity responses.
+// Zero uses DefaultMemoryStoreSize.
+func NewMemoryStore(capacity int) *MemoryStore {
+	if capacity <= 0 {
+		capacity = DefaultMemoryStoreSize
+	}
+	return &MemoryStore{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		scopes:   make(map[string]map[string]struct{}),
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+// Get returns the entry of a key, or nil if there is none
+func (s *MemoryStore) Get(ctx context.Context, key string) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	if s.expired(elem) {
+		s.remove(elem)
+		return nil, nil
+	}
+	s.order.MoveToFront(elem)
+	return elem.Value.(*memoryEntry).entry, nil
+}
+
+// Set stores an entry
+func (s *MemoryStore) Set(ctx context.Context, entry *Entry, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[entry.Key]; ok {
+		s.remove(elem)
+	}
+
+	value := &memoryEntry{entry: entry}
+	if ttl > 0 {
+		value.expires = s.now().Add(ttl)
+	}
+	s.entries[entry.Key] = s.order.PushFront(value)
+	if s.scopes[entry.Scope] == nil {
+		s.scopes[entry.Scope] = make(map[string]struct{})
+	}
+	s.scopes[entry.Scope][entry.Key] = struct{}{}
+
+	for s.order.Len() > s.capacity {
+		s.remove(s.order.Back())
+	}
+	return nil
+}
+
+// Nearest returns the most similar entry of a scope
+func (s *MemoryStore) Nearest(ctx context.Context, scope string, vector []float32) (*Entry, float32, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		best       *list.Element
+		similarity float32
+	)
+	for key := range s.scopes[scope] {
+		elem := s.entries[key]
+		if s.expired(elem) {
+			s.remove(elem)
+			continue
+		}
+		entry := elem.Value.(*memoryEntry).entry
+		if len(entry.Embedding) != len(vector) {
+			continue
+		}
+		score, err := embedding.CalculateSimilarity(vector, entry.Embedding, "cosine")
+		if err != nil {
+			continue
+		}
+		if best == nil || score > similarity {
+			best, similarity = elem, score
+		}
+	}
+
+	if best == nil {
+		return nil, 0, nil
+	}
+	s.order.MoveToFront(best)
+	return best.Value.(*memoryEntry).entry, similarity, nil
+}
+
+// Len returns the number of stored responses, including expired ones not yet removed
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+func (s *MemoryStore) expired(elem *list.Element) bool {
+	expires := elem.Value.(*memoryEntry).expires
+	return !expires.IsZero() && !s.now().Before(expires)
+}
+
+func (s *MemoryStore) remove(elem *list.Element) {
+	entry := elem.Value.(*memoryEntry).entry
+	s.order.Remove(elem)
+	delete(s.entries, entry.Key)
+	if keys := s.scopes[entry.Scope]; keys != nil {
+		delete(keys, entry.Key)
+		if len(keys) == 0 {
+			delete(s.scopes, entry.Scope)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// GenerateStream streams text, replaying a cached response as stream events
+// when possible. A streamed response is cached once the stream completes
+// without errors.
+func (c *LLM) GenerateStream(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (<-chan interfaces.StreamEvent, error) {
+	streaming, err := c.streamingLLM()
+	if err != nil {
+		return nil, err
+	}
+
+	lookup := c.lookup(ctx, prompt, options)
+	if lookup.response != nil {
+		return replay(lookup.response), nil
+	}
+
+	events, err := streaming.GenerateStream(ctx, prompt, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan interfaces.StreamEvent, cap(events))
+	go func() {
+		defer close(out)
+
+		var (
+			content  strings.Builder
+			complete bool
+			failed   bool
+		)
+		for event := range events {
+			switch event.Type {
+			case interfaces.StreamEventContentDelta:
+				content.WriteString(event.Content)
+			case interfaces.StreamEventMessageStop:
+				complete = true
+			case interfaces.StreamEventError, interfaces.StreamEventToolUse:
+				failed = true
+			}
+			out <- event
+		}
+
+		if complete && !failed {
+			c.save(ctx, lookup, prompt, &interfaces.LLMResponse{
+				Content: content.String(),
+				Model:   c.GetModel(),
+			})
+		}
+	}()
+
+	return out, nil
+}
+
+// GenerateWithToolsStream streams text with tools without caching
+func (c *LLM) GenerateWithToolsStream(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (<-chan interfaces.StreamEvent, error) {
+	streaming, err := c.streamingLLM()
+	if err != nil {
+		return nil, err
+	}
+	return streaming.GenerateWithToolsStream(ctx, prompt, tools, options...)
+}
+
+// replay returns the events of a stream that delivers a cached response
+func replay(resp *interfaces.LLMResponse) <-chan interfaces.StreamEvent {
+	events := make(chan interfaces.StreamEvent, 4)
+	now := time.Now()
+	events <- interfaces.StreamEvent{Type: interfaces.StreamEventMessageStart, Metadata: resp.Metadata, Timestamp: now}
+	events <- interfaces.StreamEvent{Type: interfaces.StreamEventContentDelta, Content: resp.Content, Metadata: resp.Metadata, Timestamp: now}
+	events <- interfaces.StreamEvent{Type: interfaces.StreamEventContentComplete, Content: resp.Content, Metadata: resp.Metadata, Timestamp: now}
+	events <- interfaces.StreamEvent{Type: interfaces.StreamEventMessageStop, Metadata: resp.Metadata, Timestamp: now}
+	close(events)
+	return events
+}
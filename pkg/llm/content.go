@@ -0,0 +1,52 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// AttachContentParts adds content parts to the current user turn, which is the
+// last user message of the conversation. If there is no user message, a user
+// message with the prompt and the parts is appended. The input slice is not modified.
+func AttachContentParts(messages []interfaces.Message, prompt string, parts []interfaces.ContentPart) []interfaces.Message {
+	if len(parts) == 0 {
+		return messages
+	}
+
+	result := make([]interfaces.Message, len(messages), len(messages)+1)
+	copy(result, messages)
+
+	for i := len(result) - 1; i >= 0; i-- {
+		if result[i].Role == interfaces.MessageRoleUser {
+			merged := make([]interfaces.ContentPart, 0, len(result[i].Parts)+len(parts))
+			merged = append(merged, result[i].Parts...)
+			result[i].Parts = append(merged, parts...)
+			return result
+		}
+	}
+
+	return append(result, interfaces.Message{
+		Role:    interfaces.MessageRoleUser,
+		Content: prompt,
+		Parts:   parts,
+	})
+}
+
+// CurrentTurnParts returns the content parts of the current user turn: the parts
+// of the last user message in memory followed by the parts of the options. It is
+// meant for clients that send the conversation history as a single prompt.
+func CurrentTurnParts(ctx context.Context, options *interfaces.GenerateOptions) []interfaces.ContentPart {
+	var parts []interfaces.ContentPart
+	if options.Memory != nil {
+		// Failures to read memory are reported when the history is built
+		messages, _ := options.Memory.GetMessages(ctx)
+		for i := len(messages) - 1; i >= 0; i-- {
+			if messages[i].Role == interfaces.MessageRoleUser {
+				parts = append(parts, messages[i].Parts...)
+				break
+			}
+		}
+	}
+	return append(parts, options.ContentParts...)
+}
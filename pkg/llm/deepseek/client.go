@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/llm"
 	"github.com/tagus/agent-sdk-go/pkg/logging"
 	"github.com/tagus/agent-sdk-go/pkg/multitenancy"
 	"github.com/tagus/agent-sdk-go/pkg/retry"
@@ -58,7 +59,7 @@ func WithLogger(logger logging.Logger) Option {
 // WithRetry configures retry policy for the client
 func WithRetry(opts ...retry.Option) Option {
 	return func(c *DeepSeekClient) {
-		c.retryExecutor = retry.NewExecutor(retry.NewPolicy(opts...))
+		c.retryExecutor = retry.NewExecutor(llm.NewRetryPolicy(opts...))
 	}
 }
 
@@ -344,7 +345,7 @@ func (c *DeepSeekClient) doRequest(ctx context.Context, req ChatCompletionReques
 	// Make request
 	httpResp, err := c.HTTPClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+		return nil, fmt.Errorf("failed to make request: %w", llm.WrapError("deepseek", err))
 	}
 	defer func() {
 		if err := httpResp.Body.Close(); err != nil {
@@ -362,7 +363,7 @@ func (c *DeepSeekClient) doRequest(ctx context.Context, req ChatCompletionReques
 
 	// Check for errors
 	if httpResp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("DeepSeek API error: status=%d, body=%s", httpResp.StatusCode, string(body))
+		return nil, llm.NewAPIError("deepseek", httpResp.StatusCode, httpResp.Header, body)
 	}
 
 	// Parse response
@@ -465,6 +466,9 @@ func (c *DeepSeekClient) GenerateWithToolsDetailed(ctx context.Context, prompt s
 		})
 
 		// Make request
+		if err := llm.BeforeCall(ctx, params); err != nil {
+			return nil, err
+		}
 		resp, err := c.doRequest(ctx, req)
 		if err != nil {
 			c.logger.Error(ctx, "Error from DeepSeek API", map[string]interface{}{
@@ -473,6 +477,7 @@ func (c *DeepSeekClient) GenerateWithToolsDetailed(ctx context.Context, prompt s
 			})
 			return nil, fmt.Errorf("failed to generate text with tools: %w", err)
 		}
+		llm.AfterCall(ctx, params, resp.Model, responseUsage(resp))
 
 		if len(resp.Choices) == 0 {
 			return nil, fmt.Errorf("no response from DeepSeek API")
@@ -578,10 +583,14 @@ func (c *DeepSeekClient) GenerateWithToolsDetailed(ctx context.Context, prompt s
 		}
 	}
 
+	if err := llm.BeforeCall(ctx, params); err != nil {
+		return nil, err
+	}
 	resp, err := c.doRequest(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make final request: %w", err)
 	}
+	llm.AfterCall(ctx, params, resp.Model, responseUsage(resp))
 
 	if len(resp.Choices) == 0 {
 		return nil, fmt.Errorf("no response from DeepSeek API")
@@ -607,6 +616,15 @@ func (c *DeepSeekClient) GenerateWithToolsDetailed(ctx context.Context, prompt s
 	}, nil
 }
 
+// responseUsage returns the token usage of a chat completion response
+func responseUsage(resp *ChatCompletionResponse) *interfaces.TokenUsage {
+	return &interfaces.TokenUsage{
+		InputTokens:  resp.Usage.PromptTokens,
+		OutputTokens: resp.Usage.CompletionTokens,
+		TotalTokens:  resp.Usage.PromptTokens + resp.Usage.CompletionTokens,
+	}
+}
+
 // ToolResult represents the result of a tool execution
 type ToolResult struct {
 	ToolCallID string
@@ -705,50 +723,12 @@ func (c *DeepSeekClient) convertToolsToDeepSeekFormat(tools []interfaces.Tool) [
 	deepseekTools := make([]Tool, len(tools))
 
 	for i, tool := range tools {
-		// Convert parameters to JSON schema
-		properties := make(map[string]interface{})
-		required := []string{}
-
-		for name, param := range tool.Parameters() {
-			propDef := map[string]interface{}{
-				"type":        param.Type,
-				"description": param.Description,
-			}
-
-			if param.Default != nil {
-				propDef["default"] = param.Default
-			}
-
-			if param.Items != nil {
-				propDef["items"] = map[string]interface{}{
-					"type": param.Items.Type,
-				}
-				if param.Items.Enum != nil {
-					propDef["items"].(map[string]interface{})["enum"] = param.Items.Enum
-				}
-			}
-
-			if param.Enum != nil {
-				propDef["enum"] = param.Enum
-			}
-
-			properties[name] = propDef
-
-			if param.Required {
-				required = append(required, name)
-			}
-		}
-
 		deepseekTools[i] = Tool{
 			Type: "function",
 			Function: FunctionDef{
 				Name:        tool.Name(),
 				Description: tool.Description(),
-				Parameters: map[string]interface{}{
-					"type":       "object",
-					"properties": properties,
-					"required":   required,
-				},
+				Parameters:  llm.ToolSchema(tool),
 			},
 		}
 	}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/llm"
 	"github.com/tagus/agent-sdk-go/pkg/multitenancy"
 )
 
@@ -393,6 +394,15 @@ func (c *DeepSeekClient) GenerateWithToolsStream(
 				"message_count": len(messages),
 			})
 
+			if err := llm.BeforeCall(ctx, params); err != nil {
+				eventChan <- interfaces.StreamEvent{
+					Type:      interfaces.StreamEventError,
+					Error:     err,
+					Timestamp: time.Now(),
+				}
+				return
+			}
+
 			// Make streaming HTTP request
 			resp, err := c.doStreamRequest(ctx, req)
 			if err != nil {
@@ -414,6 +424,8 @@ func (c *DeepSeekClient) GenerateWithToolsStream(
 			assistantResponse.Role = "assistant"
 			var hasContent bool
 
+			round := streamRound{model: c.Model}
+
 			// Process stream chunks
 			scanner := bufio.NewScanner(resp.Body)
 			for scanner.Scan() {
@@ -445,6 +457,7 @@ func (c *DeepSeekClient) GenerateWithToolsStream(
 					})
 					continue
 				}
+				round.add(chunk)
 
 				// Process choices
 				for _, choice := range chunk.Choices {
@@ -569,6 +582,8 @@ func (c *DeepSeekClient) GenerateWithToolsStream(
 				return
 			}
 
+			llm.AfterCall(ctx, params, round.model, round.usage)
+
 			// Check if the model wants to use tools
 			if len(assistantResponse.ToolCalls) == 0 {
 				// No tool calls, we're done
@@ -716,6 +731,15 @@ func (c *DeepSeekClient) GenerateWithToolsStream(
 			"model": c.Model,
 		})
 
+		if err := llm.BeforeCall(ctx, params); err != nil {
+			eventChan <- interfaces.StreamEvent{
+				Type:      interfaces.StreamEventError,
+				Error:     err,
+				Timestamp: time.Now(),
+			}
+			return
+		}
+
 		// Make final streaming request
 		finalResp, err := c.doStreamRequest(ctx, finalReq)
 		if err != nil {
@@ -737,6 +761,8 @@ func (c *DeepSeekClient) GenerateWithToolsStream(
 			}
 		}()
 
+		finalRound := streamRound{model: c.Model}
+
 		// Process final stream
 		finalScanner := bufio.NewScanner(finalResp.Body)
 		for finalScanner.Scan() {
@@ -768,6 +794,7 @@ func (c *DeepSeekClient) GenerateWithToolsStream(
 				})
 				continue
 			}
+			finalRound.add(chunk)
 
 			for _, choice := range chunk.Choices {
 				// Handle final content
@@ -812,6 +839,8 @@ func (c *DeepSeekClient) GenerateWithToolsStream(
 			return
 		}
 
+		llm.AfterCall(ctx, params, finalRound.model, finalRound.usage)
+
 		// Send final message stop event
 		eventChan <- interfaces.StreamEvent{
 			Type:      interfaces.StreamEventMessageStop,
@@ -848,17 +877,39 @@ func (c *DeepSeekClient) doStreamRequest(ctx context.Context, req ChatCompletion
 	// Make request
 	resp, err := c.HTTPClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+		return nil, fmt.Errorf("failed to make request: %w", llm.WrapError("deepseek", err))
 	}
 
 	// Check status code
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
+		apiErr := llm.NewAPIError("deepseek", resp.StatusCode, resp.Header, body)
 		if err := resp.Body.Close(); err != nil {
-			return nil, fmt.Errorf("deepseek API error (status %d): %s (close error: %w)", resp.StatusCode, string(body), err)
+			return nil, fmt.Errorf("%w (close error: %w)", apiErr, err)
 		}
-		return nil, fmt.Errorf("deepseek API error (status %d): %s", resp.StatusCode, string(body))
+		return nil, apiErr
 	}
 
 	return resp, nil
 }
+
+// streamRound collects the model and usage of a streamed request, reported
+// to the call hook once its stream ends
+type streamRound struct {
+	model string
+	usage *interfaces.TokenUsage
+}
+
+// add records the model and usage carried by a stream chunk
+func (r *streamRound) add(chunk StreamChunk) {
+	if chunk.Model != "" {
+		r.model = chunk.Model
+	}
+	if chunk.Usage != nil && (chunk.Usage.PromptTokens > 0 || chunk.Usage.CompletionTokens > 0) {
+		r.usage = &interfaces.TokenUsage{
+			InputTokens:  chunk.Usage.PromptTokens,
+			OutputTokens: chunk.Usage.CompletionTokens,
+			TotalTokens:  chunk.Usage.PromptTokens + chunk.Usage.CompletionTokens,
+		}
+	}
+}
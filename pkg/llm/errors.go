@@ -0,0 +1,389 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/retry"
+)
+
+// ErrorKind classifies the errors returned by LLM providers
+type ErrorKind string
+
+const (
+	// ErrorKindRateLimited is a rate limit or quota error
+	ErrorKindRateLimited ErrorKind = "rate_limited"
+	// ErrorKindOverloaded is an overloaded or unavailable provider
+	ErrorKindOverloaded ErrorKind = "overloaded"
+	// ErrorKindContextLengthExceeded is a request that exceeds the context window of the model
+	ErrorKindContextLengthExceeded ErrorKind = "context_length_exceeded"
+	// ErrorKindContentFiltered is a request or response blocked by a content filter
+	ErrorKindContentFiltered ErrorKind = "content_filtered"
+	// ErrorKindAuth is a missing, invalid or unauthorized credential
+	ErrorKindAuth ErrorKind = "auth"
+	// ErrorKindInvalidRequest is a request the provider rejected as malformed
+	ErrorKindInvalidRequest ErrorKind = "invalid_request"
+	// ErrorKindTimeout is a request that timed out
+	ErrorKindTimeout ErrorKind = "timeout"
+	// ErrorKindUnknown is an error that could not be classified
+	ErrorKindUnknown ErrorKind = "unknown"
+)
+
+// Sentinel errors matched by errors.Is for each error kind
+var (
+	ErrRateLimited           = errors.New("rate limited")
+	ErrOverloaded            = errors.New("provider overloaded")
+	ErrContextLengthExceeded = errors.New("context length exceeded")
+	ErrContentFiltered       = errors.New("content filtered")
+	ErrAuth                  = errors.New("authentication failed")
+	ErrInvalidRequest        = errors.New("invalid request")
+	ErrTimeout               = errors.New("request timed out")
+)
+
+var kindErrors = map[ErrorKind]error{
+	ErrorKindRateLimited:           ErrRateLimited,
+	ErrorKindOverloaded:            ErrOverloaded,
+	ErrorKindContextLengthExceeded: ErrContextLengthExceeded,
+	ErrorKindContentFiltered:       ErrContentFiltered,
+	ErrorKindAuth:                  ErrAuth,
+	ErrorKindInvalidRequest:        ErrInvalidRequest,
+	ErrorKindTimeout:               ErrTimeout,
+}
+
+// Error is an error returned by an LLM provider. It matches the sentinel
+// error of its kind with errors.Is:
+//
+//	if errors.Is(err, llm.ErrRateLimited) {
+//		wait := llm.RetryAfter(err)
+//	}
+type Error struct {
+	// Kind classifies the error
+	Kind ErrorKind
+
+	// Provider is the name of the provider, such as "openai" or "anthropic"
+	Provider string
+
+	// StatusCode is the HTTP status code, or 0 for errors without a response
+	StatusCode int
+
+	// Code is the provider's error type or code, such as "rate_limit_error"
+	Code string
+
+	// Message is the error message of the provider
+	Message string
+
+	// RetryAfter is the delay requested by the provider before retrying, or 0
+	RetryAfter time.Duration
+
+	// Err is the underlying error, if any
+	Err error
+}
+
+// Error returns the error message
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Provider)
+	b.WriteString(" API error")
+
+	var details []string
+	if e.StatusCode != 0 {
+		details = append(details, "status "+strconv.Itoa(e.StatusCode))
+	}
+	if e.Code != "" {
+		details = append(details, e.Code)
+	}
+	if len(details) > 0 {
+		b.WriteString(" (" + strings.Join(details, ", ") + ")")
+	}
+
+	switch {
+	case e.Message != "":
+		b.WriteString(": " + e.Message)
+	case e.Err != nil:
+		b.WriteString(": " + e.Err.Error())
+	default:
+		b.WriteString(": " + string(e.Kind))
+	}
+	return b.String()
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the error matches the sentinel error of its kind
+func (e *Error) Is(target error) bool {
+	return kindErrors[e.Kind] == target
+}
+
+// RetryDelay returns the delay requested by the provider before retrying.
+// It is honored by retry.Executor.
+func (e *Error) RetryDelay() time.Duration {
+	return e.RetryAfter
+}
+
+// NewAPIError creates an error from the HTTP status, headers and body of a
+// failed provider response. The message, code and retry delay are read from
+// the common error formats of the providers.
+func NewAPIError(provider string, statusCode int, header http.Header, body []byte) *Error {
+	code, message := parseErrorBody(body)
+	if code == "" && header != nil {
+		// Bedrock reports the error type in a header such as "ThrottlingException:http://..."
+		code, _, _ = strings.Cut(header.Get("X-Amzn-Errortype"), ":")
+	}
+
+	return &Error{
+		Kind:       ClassifyError(statusCode, code, message),
+		Provider:   provider,
+		StatusCode: statusCode,
+		Code:       code,
+		Message:    message,
+		RetryAfter: retryAfter(header, body),
+	}
+}
+
+// NewError creates an error of the given kind for failures reported without
+// an HTTP error status, such as error events of a stream
+func NewError(provider string, kind ErrorKind, code, message string) *Error {
+	return &Error{Kind: kind, Provider: provider, Code: code, Message: message}
+}
+
+// WrapError classifies a transport error, such as a timeout while sending a
+// request. Provider errors and nil are returned unchanged, as are errors that
+// cannot be classified.
+func WrapError(provider string, err error) error {
+	var providerErr *Error
+	if err == nil || errors.As(err, &providerErr) || errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	var timeout interface{ Timeout() bool }
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &timeout) && timeout.Timeout()) {
+		return &Error{Kind: ErrorKindTimeout, Provider: provider, Err: err}
+	}
+	return err
+}
+
+var (
+	contextLengthPatterns = []string{
+		"context_length_exceeded", "context length", "context window", "maximum context",
+		"prompt is too long", "input is too long", "too many input tokens", "exceeds the context",
+		"max_tokens_exceeded", "request too large",
+	}
+	contentFilterPatterns = []string{
+		"content_filter", "content filter", "content_policy_violation", "content policy",
+		"responsibleaipolicyviolation", "blocked by safety", "prohibited_content", "blocklist",
+	}
+	rateLimitPatterns = []string{
+		"rate limit", "rate_limit", "ratelimit", "too many requests", "throttl",
+		"resource_exhausted", "quota",
+	}
+	overloadedPatterns = []string{
+		"overloaded", "unavailable", "server is busy", "capacity",
+	}
+	invalidRequestPatterns = []string{
+		"invalid_request", "invalid request", "validationexception", "bad request",
+	}
+	authPatterns = []string{
+		"authentication", "unauthorized", "permission_denied", "permission denied",
+		"access denied", "accessdenied", "invalid api key", "invalid_api_key", "unauthenticated",
+	}
+	timeoutPatterns = []string{
+		"timeout", "timed out", "deadline exceeded", "deadline_exceeded",
+	}
+)
+
+// ClassifyError returns the kind of a provider error from its HTTP status
+// code, error code and message. A status code of 0 classifies by code and
+// message only.
+func ClassifyError(statusCode int, code, message string) ErrorKind {
+	text := strings.ToLower(code + " " + message)
+
+	switch statusCode {
+	case http.StatusTooManyRequests:
+		return ErrorKindRateLimited
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrorKindAuth
+	case http.StatusRequestEntityTooLarge:
+		return ErrorKindContextLengthExceeded
+	}
+
+	switch {
+	case containsAny(text, contextLengthPatterns):
+		return ErrorKindContextLengthExceeded
+	case containsAny(text, contentFilterPatterns):
+		return ErrorKindContentFiltered
+	}
+
+	switch {
+	case statusCode == http.StatusRequestTimeout || statusCode == http.StatusGatewayTimeout:
+		return ErrorKindTimeout
+	case statusCode >= 500:
+		return ErrorKindOverloaded
+	case statusCode >= 400:
+		if containsAny(text, rateLimitPatterns) {
+			// Bedrock reports throttling with status 400
+			return ErrorKindRateLimited
+		}
+		return ErrorKindInvalidRequest
+	}
+
+	switch {
+	case containsAny(text, rateLimitPatterns):
+		return ErrorKindRateLimited
+	case containsAny(text, overloadedPatterns):
+		return ErrorKindOverloaded
+	case containsAny(text, authPatterns):
+		return ErrorKindAuth
+	case containsAny(text, timeoutPatterns):
+		return ErrorKindTimeout
+	case containsAny(text, invalidRequestPatterns):
+		return ErrorKindInvalidRequest
+	}
+	return ErrorKindUnknown
+}
+
+// IsRetryable reports whether a failed request may succeed when retried. Rate
+// limits, overloads, timeouts and server errors are retryable, errors caused
+// by the request or the credentials are not. Errors that are not provider
+// errors, such as network errors, are retryable unless the context was canceled.
+// It can be used as the retry.Policy predicate.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, interfaces.ErrUnsupportedContent) {
+		return false
+	}
+
+	var providerErr *Error
+	if !errors.As(err, &providerErr) {
+		return true
+	}
+	switch providerErr.Kind {
+	case ErrorKindRateLimited, ErrorKindOverloaded, ErrorKindTimeout:
+		return true
+	case ErrorKindUnknown:
+		return providerErr.StatusCode == 0 || providerErr.StatusCode >= 500
+	}
+	return false
+}
+
+// RetryAfter returns the delay requested by the provider before retrying, or
+// 0 if the error does not carry one
+func RetryAfter(err error) time.Duration {
+	var providerErr *Error
+	if errors.As(err, &providerErr) {
+		return providerErr.RetryAfter
+	}
+	return 0
+}
+
+// NewRetryPolicy creates a retry policy that only retries errors for which
+// IsRetryable returns true. The options can override the predicate.
+func NewRetryPolicy(opts ...retry.Option) *retry.Policy {
+	return retry.NewPolicy(append([]retry.Option{retry.WithRetryable(IsRetryable)}, opts...)...)
+}
+
+// errorBody covers the error formats of the providers:
+//
+//	{"error": {"message": "...", "type": "...", "code": "..."}}   OpenAI, Anthropic, DeepSeek
+//	{"error": {"message": "...", "status": "RESOURCE_EXHAUSTED"}} Gemini
+//	{"error": "..."}                                              Ollama
+//	{"message": "...", "type": "...", "code": "..."}              Bedrock, vLLM, unwrapped OpenAI errors
+type errorBody struct {
+	Error   json.RawMessage `json:"error"`
+	Message string          `json:"message"`
+	Type    string          `json:"type"`
+	Code    json.RawMessage `json:"code"`
+}
+
+type errorDetail struct {
+	Message string          `json:"message"`
+	Type    string          `json:"type"`
+	Code    json.RawMessage `json:"code"`
+	Status  json.RawMessage `json:"status"`
+}
+
+// parseErrorBody returns the error code and message of a response body. Bodies
+// that are not JSON are returned as the message.
+func parseErrorBody(body []byte) (code, message string) {
+	var parsed errorBody
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return "", strings.TrimSpace(string(body))
+	}
+
+	var text string
+	var detail errorDetail
+	switch {
+	case json.Unmarshal(parsed.Error, &text) == nil:
+		return parsed.Type, text
+	case json.Unmarshal(parsed.Error, &detail) == nil && (detail.Message != "" || detail.Type != ""):
+		return firstNonEmpty(stringCode(detail.Code), stringCode(detail.Status), detail.Type), detail.Message
+	case parsed.Message != "":
+		return firstNonEmpty(stringCode(parsed.Code), parsed.Type), parsed.Message
+	}
+	return "", strings.TrimSpace(string(body))
+}
+
+// stringCode returns an error code that is a JSON string, numeric codes
+// repeat the status code and are ignored
+func stringCode(raw json.RawMessage) string {
+	var code string
+	if json.Unmarshal(raw, &code) != nil {
+		return ""
+	}
+	return code
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// retryDelayPattern matches the retry delay of Gemini's RetryInfo error detail
+var retryDelayPattern = regexp.MustCompile(`"retryDelay"\s*:\s*"([0-9.]+)s"`)
+
+// retryAfter returns the retry delay of a response from the retry-after-ms
+// and Retry-After headers or the body
+func retryAfter(header http.Header, body []byte) time.Duration {
+	if header != nil {
+		if ms, err := strconv.ParseFloat(header.Get("Retry-After-Ms"), 64); err == nil && ms > 0 {
+			return time.Duration(ms * float64(time.Millisecond))
+		}
+		if value := header.Get("Retry-After"); value != "" {
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				return time.Duration(seconds * float64(time.Second))
+			}
+			if date, err := http.ParseTime(value); err == nil {
+				if delay := time.Until(date); delay > 0 {
+					return delay
+				}
+			}
+		}
+	}
+	if match := retryDelayPattern.FindSubmatch(body); match != nil {
+		if seconds, err := strconv.ParseFloat(string(match[1]), 64); err == nil {
+			return time.Duration(seconds * float64(time.Second))
+		}
+	}
+	return 0
+}
+
+func containsAny(text string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if strings.Contains(text, pattern) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,267 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/retry"
+)
+
+func TestNewAPIError(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		status   int
+		header   http.Header
+		body     string
+		kind     ErrorKind
+		code     string
+		message  string
+	}{
+		{
+			name:     "OpenAI rate limit",
+			provider: "openai",
+			status:   429,
+			body:     `{"error": {"message": "Rate limit reached", "type": "requests", "code": "rate_limit_exceeded"}}`,
+			kind:     ErrorKindRateLimited,
+			code:     "rate_limit_exceeded",
+			message:  "Rate limit reached",
+		},
+		{
+			name:     "OpenAI context length",
+			provider: "openai",
+			status:   400,
+			body:     `{"message": "This model's maximum context length is 8192 tokens", "type": "invalid_request_error", "code": "context_length_exceeded"}`,
+			kind:     ErrorKindContextLengthExceeded,
+			code:     "context_length_exceeded",
+			message:  "This model's maximum context length is 8192 tokens",
+		},
+		{
+			name:     "Azure content filter",
+			provider: "azure-openai",
+			status:   400,
+			body:     `{"error": {"message": "The response was filtered", "code": "content_filter", "status": 400}}`,
+			kind:     ErrorKindContentFiltered,
+			code:     "content_filter",
+			message:  "The response was filtered",
+		},
+		{
+			name:     "Anthropic overloaded",
+			provider: "anthropic",
+			status:   529,
+			body:     `{"type": "error", "error": {"type": "overloaded_error", "message": "Overloaded"}}`,
+			kind:     ErrorKindOverloaded,
+			code:     "overloaded_error",
+			message:  "Overloaded",
+		},
+		{
+			name:     "Anthropic auth",
+			provider: "anthropic",
+			status:   401,
+			body:     `{"type": "error", "error": {"type": "authentication_error", "message": "invalid x-api-key"}}`,
+			kind:     ErrorKindAuth,
+			code:     "authentication_error",
+			message:  "invalid x-api-key",
+		},
+		{
+			name:     "Gemini quota",
+			provider: "gemini",
+			status:   429,
+			body:     `{"error": {"code": 429, "message": "Quota exceeded", "status": "RESOURCE_EXHAUSTED"}}`,
+			kind:     ErrorKindRateLimited,
+			code:     "RESOURCE_EXHAUSTED",
+			message:  "Quota exceeded",
+		},
+		{
+			name:     "Bedrock throttling",
+			provider: "bedrock",
+			status:   400,
+			header:   http.Header{"X-Amzn-Errortype": []string{"ThrottlingException:http://internal.amazon.com/coral/"}},
+			body:     `{"message": "Too many tokens, please wait"}`,
+			kind:     ErrorKindRateLimited,
+			code:     "ThrottlingException",
+			message:  "Too many tokens, please wait",
+		},
+		{
+			name:     "Bedrock validation",
+			provider: "bedrock",
+			status:   400,
+			header:   http.Header{"X-Amzn-Errortype": []string{"ValidationException"}},
+			body:     `{"message": "malformed input"}`,
+			kind:     ErrorKindInvalidRequest,
+			code:     "ValidationException",
+			message:  "malformed input",
+		},
+		{
+			name:     "Ollama",
+			provider: "ollama",
+			status:   404,
+			body:     `{"error": "model 'llama3' not found"}`,
+			kind:     ErrorKindInvalidRequest,
+			message:  "model 'llama3' not found",
+		},
+		{
+			name:     "plain text",
+			provider: "vllm",
+			status:   502,
+			body:     "Bad Gateway\n",
+			kind:     ErrorKindOverloaded,
+			message:  "Bad Gateway",
+		},
+		{
+			name:     "stream error event",
+			provider: "anthropic",
+			body:     `{"type": "error", "error": {"type": "overloaded_error", "message": "Overloaded"}}`,
+			kind:     ErrorKindOverloaded,
+			code:     "overloaded_error",
+			message:  "Overloaded",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewAPIError(tt.provider, tt.status, tt.header, []byte(tt.body))
+			assert.Equal(t, tt.kind, err.Kind)
+			assert.Equal(t, tt.provider, err.Provider)
+			assert.Equal(t, tt.status, err.StatusCode)
+			assert.Equal(t, tt.code, err.Code)
+			assert.Equal(t, tt.message, err.Message)
+		})
+	}
+}
+
+func TestError_Error(t *testing.T) {
+	err := NewAPIError("bedrock", 400, http.Header{"X-Amzn-Errortype": []string{"ValidationException"}}, []byte(`{"message": "unexpected request"}`))
+	assert.Equal(t, "bedrock API error (status 400, ValidationException): unexpected request", err.Error())
+
+	err = NewError("gemini", ErrorKindContentFiltered, "SAFETY", "prompt blocked")
+	assert.Equal(t, "gemini API error (SAFETY): prompt blocked", err.Error())
+}
+
+func TestError_Is(t *testing.T) {
+	err := fmt.Errorf("iteration 1: %w", NewAPIError("openai", 429, nil, nil))
+	assert.ErrorIs(t, err, ErrRateLimited)
+	assert.NotErrorIs(t, err, ErrOverloaded)
+
+	var providerErr *Error
+	require.ErrorAs(t, err, &providerErr)
+	assert.Equal(t, "openai", providerErr.Provider)
+}
+
+func TestNewAPIError_RetryAfter(t *testing.T) {
+	err := NewAPIError("openai", 429, http.Header{"Retry-After": []string{"20"}}, nil)
+	assert.Equal(t, 20*time.Second, err.RetryAfter)
+
+	err = NewAPIError("openai", 429, http.Header{"Retry-After-Ms": []string{"1500"}, "Retry-After": []string{"2"}}, nil)
+	assert.Equal(t, 1500*time.Millisecond, err.RetryAfter)
+
+	body := `{"error": {"code": 429, "status": "RESOURCE_EXHAUSTED", "message": "quota", "details": [{"@type": "type.googleapis.com/google.rpc.RetryInfo", "retryDelay": "37s"}]}}`
+	err = NewAPIError("gemini", 429, nil, []byte(body))
+	assert.Equal(t, 37*time.Second, err.RetryAfter)
+
+	assert.Equal(t, 37*time.Second, RetryAfter(fmt.Errorf("wrapped: %w", err)))
+	assert.Zero(t, RetryAfter(errors.New("other")))
+}
+
+func TestWrapError(t *testing.T) {
+	err := WrapError("ollama", fmt.Errorf("request failed: %w", context.DeadlineExceeded))
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	err = WrapError("ollama", &net.DNSError{Err: "i/o timeout", IsTimeout: true})
+	assert.ErrorIs(t, err, ErrTimeout)
+
+	other := errors.New("connection refused")
+	assert.Equal(t, other, WrapError("ollama", other))
+	assert.Nil(t, WrapError("ollama", nil))
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"network error", errors.New("connection reset by peer"), true},
+		{"canceled", fmt.Errorf("request: %w", context.Canceled), false},
+		{"unsupported content", &interfaces.UnsupportedContentError{Provider: "ollama", Type: interfaces.ContentPartAudio}, false},
+		{"rate limited", NewAPIError("openai", 429, nil, nil), true},
+		{"overloaded", NewAPIError("anthropic", 529, nil, nil), true},
+		{"timeout", WrapError("openai", context.DeadlineExceeded), true},
+		{"auth", NewAPIError("openai", 401, nil, nil), false},
+		{"invalid request", NewAPIError("openai", 400, nil, []byte(`{"error": {"message": "bad tool schema"}}`)), false},
+		{"context length", NewAPIError("openai", 400, nil, []byte(`{"error": {"code": "context_length_exceeded"}}`)), false},
+		{"content filtered", NewError("gemini", ErrorKindContentFiltered, "SAFETY", "prompt blocked"), false},
+		{"unknown stream error", NewError("bedrock", ErrorKindUnknown, "InternalFailure", "failure"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsRetryable(tt.err))
+		})
+	}
+}
+
+func TestNewRetryPolicy(t *testing.T) {
+	executor := retry.NewExecutor(NewRetryPolicy(retry.WithInitialInterval(time.Millisecond), retry.WithMaxAttempts(3)))
+
+	attempts := 0
+	err := executor.Execute(context.Background(), func() error {
+		attempts++
+		return NewAPIError("openai", 400, nil, nil)
+	})
+	assert.ErrorIs(t, err, ErrInvalidRequest)
+	assert.Equal(t, 1, attempts)
+
+	attempts = 0
+	err = executor.Execute(context.Background(), func() error {
+		attempts++
+		if attempts < 3 {
+			return NewAPIError("openai", 503, nil, nil)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+
+	// The delay requested by the provider overrides the shorter backoff
+	attempts = 0
+	start := time.Now()
+	err = executor.Execute(context.Background(), func() error {
+		attempts++
+		if attempts < 2 {
+			return NewAPIError("openai", 429, http.Header{"Retry-After-Ms": []string{"50"}}, nil)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	// The requested delay is capped at the maximum interval
+	executor = retry.NewExecutor(NewRetryPolicy(
+		retry.WithInitialInterval(time.Millisecond),
+		retry.WithMaximumInterval(10*time.Millisecond),
+		retry.WithMaxAttempts(2),
+	))
+	attempts = 0
+	start = time.Now()
+	err = executor.Execute(context.Background(), func() error {
+		attempts++
+		if attempts < 2 {
+			return NewAPIError("openai", 429, http.Header{"Retry-After": []string{"60"}}, nil)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+}
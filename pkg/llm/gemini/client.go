@@ -0,0 +1,947 @@
+package gemini
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/llm"
+	"github.com/tagus/agent-sdk-go/pkg/logging"
+	"github.com/tagus/agent-sdk-go/pkg/multitenancy"
+	"github.com/tagus/agent-sdk-go/pkg/retry"
+)
+
+// ModelName constants for supported Gemini models
+const (
+	Gemini25Pro       = "gemini-2.5-pro"
+	Gemini25Flash     = "gemini-2.5-flash"
+	Gemini25FlashLite = "gemini-2.5-flash-lite"
+	Gemini20Flash     = "gemini-2.0-flash"
+)
+
+const (
+	// DefaultBaseURL is the base URL of the Gemini API
+	DefaultBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+
+	cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+	jsonSystemInstruction = "You must respond with valid JSON that matches the specified schema. Return ONLY the raw JSON object without any markdown formatting, code blocks, or wrapper text."
+)
+
+// GeminiClient implements the LLM interface for Google Gemini, either through
+// the Gemini API with an API key or through Vertex AI
+type GeminiClient struct {
+	APIKey        string
+	Model         string
+	BaseURL       string
+	HTTPClient    *http.Client
+	logger        logging.Logger
+	retryExecutor *retry.Executor
+	tokenSource   oauth2.TokenSource
+}
+
+// Option represents an option for configuring the Gemini client
+type Option func(*GeminiClient)
+
+// WithModel sets the model for the Gemini client
+func WithModel(model string) Option {
+	return func(c *GeminiClient) {
+		c.Model = model
+	}
+}
+
+// WithLogger sets the logger for the Gemini client
+func WithLogger(logger logging.Logger) Option {
+	return func(c *GeminiClient) {
+		c.logger = logger
+	}
+}
+
+// WithRetry configures retry policy for the client
+func WithRetry(opts ...retry.Option) Option {
+	return func(c *GeminiClient) {
+		c.retryExecutor = retry.NewExecutor(llm.NewRetryPolicy(opts...))
+	}
+}
+
+// WithBaseURL sets the base URL for the Gemini API
+func WithBaseURL(baseURL string) Option {
+	return func(c *GeminiClient) {
+		c.BaseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithHTTPClient sets the HTTP client for the Gemini client
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *GeminiClient) {
+		c.HTTPClient = httpClient
+	}
+}
+
+// WithTokenSource authenticates requests with OAuth2 bearer tokens instead of an API key
+func WithTokenSource(tokenSource oauth2.TokenSource) Option {
+	return func(c *GeminiClient) {
+		c.tokenSource = tokenSource
+	}
+}
+
+// WithVertexAI configures the client for Google Vertex AI using Application Default Credentials
+func WithVertexAI(region, projectID string) Option {
+	return func(c *GeminiClient) {
+		ctx := context.Background()
+		credentials, err := google.FindDefaultCredentials(ctx, cloudPlatformScope)
+		if err != nil {
+			c.logger.Error(ctx, "Failed to configure Vertex AI", map[string]interface{}{
+				"error":     err.Error(),
+				"region":    region,
+				"projectID": projectID,
+			})
+			return
+		}
+		c.configureVertex(region, projectID, credentials.TokenSource)
+	}
+}
+
+// WithGoogleApplicationCredentials configures Vertex AI with explicit service account credentials
+func WithGoogleApplicationCredentials(region, projectID, credentialsContent string) Option {
+	return func(c *GeminiClient) {
+		ctx := context.Background()
+		credentials, err := google.CredentialsFromJSON(ctx, []byte(credentialsContent), cloudPlatformScope)
+		if err != nil {
+			c.logger.Error(ctx, "Failed to configure Vertex AI with credentials content", map[string]interface{}{
+				"error":     err.Error(),
+				"region":    region,
+				"projectID": projectID,
+			})
+			return
+		}
+		c.configureVertex(region, projectID, credentials.TokenSource)
+	}
+}
+
+// configureVertex points the client at the Vertex AI publisher endpoint of the region
+func (c *GeminiClient) configureVertex(region, projectID string, tokenSource oauth2.TokenSource) {
+	host := region + "-aiplatform.googleapis.com"
+	if region == "global" {
+		host = "aiplatform.googleapis.com"
+	}
+	c.BaseURL = fmt.Sprintf("https://%s/v1/projects/%s/locations/%s/publishers/google", host, projectID, region)
+	c.tokenSource = tokenSource
+	c.logger.Info(context.Background(), "Configured Gemini client for Vertex AI", map[string]interface{}{
+		"region":    region,
+		"projectID": projectID,
+		"baseURL":   c.BaseURL,
+	})
+}
+
+// NewClient creates a new Gemini client. The API key may be empty when Vertex AI is configured.
+func NewClient(apiKey string, options ...Option) *GeminiClient {
+	client := &GeminiClient{
+		APIKey:     apiKey,
+		Model:      Gemini25Flash,
+		BaseURL:    DefaultBaseURL,
+		HTTPClient: &http.Client{Timeout: 10 * time.Minute},
+		logger:     logging.New(),
+	}
+
+	for _, option := range options {
+		option(client)
+	}
+
+	return client
+}
+
+// Content is a single turn of a conversation
+type Content struct {
+	Role  string `json:"role,omitempty"`
+	Parts []Part `json:"parts"`
+}
+
+// Part is a piece of a Content, holding text, a function call or a function response
+type Part struct {
+	Text             string            `json:"text,omitempty"`
+	Thought          bool              `json:"thought,omitempty"`
+	ThoughtSignature string            `json:"thoughtSignature,omitempty"`
+	FunctionCall     *FunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *FunctionResponse `json:"functionResponse,omitempty"`
+	InlineData       *Blob             `json:"inlineData,omitempty"`
+	FileData         *FileData         `json:"fileData,omitempty"`
+}
+
+// FunctionCall is a tool call requested by the model
+type FunctionCall struct {
+	ID   string                 `json:"id,omitempty"`
+	Name string                 `json:"name"`
+	Args map[string]interface{} `json:"args,omitempty"`
+}
+
+// FunctionResponse is the result of a tool call sent back to the model
+type FunctionResponse struct {
+	ID       string                 `json:"id,omitempty"`
+	Name     string                 `json:"name"`
+	Response map[string]interface{} `json:"response"`
+}
+
+// Tool declares functions the model may call
+type Tool struct {
+	FunctionDeclarations []FunctionDeclaration `json:"functionDeclarations"`
+}
+
+// FunctionDeclaration describes a callable function
+type FunctionDeclaration struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// ToolConfig controls how the model uses the declared tools
+type ToolConfig struct {
+	FunctionCallingConfig *FunctionCallingConfig `json:"functionCallingConfig,omitempty"`
+}
+
+// FunctionCallingConfig sets the function calling mode (AUTO, ANY or NONE)
+type FunctionCallingConfig struct {
+	Mode string `json:"mode"`
+}
+
+// GenerationConfig contains sampling and output options
+type GenerationConfig struct {
+	Temperature        *float64               `json:"temperature,omitempty"`
+	TopP               *float64               `json:"topP,omitempty"`
+	PresencePenalty    *float64               `json:"presencePenalty,omitempty"`
+	FrequencyPenalty   *float64               `json:"frequencyPenalty,omitempty"`
+	StopSequences      []string               `json:"stopSequences,omitempty"`
+	MaxOutputTokens    int                    `json:"maxOutputTokens,omitempty"`
+	ResponseMimeType   string                 `json:"responseMimeType,omitempty"`
+	ResponseJSONSchema map[string]interface{} `json:"responseJsonSchema,omitempty"`
+	ThinkingConfig     *ThinkingConfig        `json:"thinkingConfig,omitempty"`
+}
+
+// ThinkingConfig controls the model's internal reasoning. A budget of -1 lets the model decide.
+type ThinkingConfig struct {
+	ThinkingBudget  *int `json:"thinkingBudget,omitempty"`
+	IncludeThoughts bool `json:"includeThoughts,omitempty"`
+}
+
+// GenerateContentRequest is the request body of generateContent and streamGenerateContent
+type GenerateContentRequest struct {
+	Contents          []Content         `json:"contents"`
+	SystemInstruction *Content          `json:"systemInstruction,omitempty"`
+	Tools             []Tool            `json:"tools,omitempty"`
+	ToolConfig        *ToolConfig       `json:"toolConfig,omitempty"`
+	GenerationConfig  *GenerationConfig `json:"generationConfig,omitempty"`
+}
+
+// GenerateContentResponse is the response of generateContent, or one chunk of a stream
+type GenerateContentResponse struct {
+	Candidates     []Candidate     `json:"candidates"`
+	UsageMetadata  *UsageMetadata  `json:"usageMetadata,omitempty"`
+	ModelVersion   string          `json:"modelVersion,omitempty"`
+	PromptFeedback *PromptFeedback `json:"promptFeedback,omitempty"`
+}
+
+// Candidate is a generated response
+type Candidate struct {
+	Content      Content `json:"content"`
+	FinishReason string  `json:"finishReason,omitempty"`
+}
+
+// UsageMetadata reports token usage
+type UsageMetadata struct {
+	PromptTokenCount        int `json:"promptTokenCount"`
+	CandidatesTokenCount    int `json:"candidatesTokenCount"`
+	ThoughtsTokenCount      int `json:"thoughtsTokenCount"`
+	CachedContentTokenCount int `json:"cachedContentTokenCount"`
+	TotalTokenCount         int `json:"totalTokenCount"`
+}
+
+// PromptFeedback reports why a prompt was blocked
+type PromptFeedback struct {
+	BlockReason string `json:"blockReason,omitempty"`
+}
+
+// Generate generates text based on the provided prompt
+func (c *GeminiClient) Generate(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (string, error) {
+	response, err := c.GenerateDetailed(ctx, prompt, options...)
+	if err != nil {
+		return "", err
+	}
+	return response.Content, nil
+}
+
+// GenerateDetailed generates text and returns detailed response information including token usage
+func (c *GeminiClient) GenerateDetailed(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (*interfaces.LLMResponse, error) {
+	if c.Model == "" {
+		return nil, fmt.Errorf("model not specified: use WithModel option when creating the client")
+	}
+
+	params := c.applyOptions(options)
+	ctx = ensureOrgID(ctx)
+
+	contents, err := c.buildContents(ctx, prompt, params)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &GenerateContentRequest{
+		Contents:          contents,
+		SystemInstruction: systemInstruction(params.SystemMessage),
+		GenerationConfig:  c.generationConfig(params),
+	}
+	if params.ResponseFormat != nil {
+		req.GenerationConfig.ResponseMimeType = "application/json"
+		req.GenerationConfig.ResponseJSONSchema = map[string]interface{}(params.ResponseFormat.Schema)
+	}
+
+	resp, err := c.generateContent(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	candidate, err := firstCandidate(resp)
+	if err != nil {
+		return nil, err
+	}
+	text, thinking := splitText(candidate.Content)
+	if text == "" {
+		return nil, fmt.Errorf("no text content in response (finish reason: %s)", candidate.FinishReason)
+	}
+
+	usage := &interfaces.TokenUsage{}
+	addUsage(usage, resp.UsageMetadata)
+	return c.newResponse(text, thinking, candidate.FinishReason, resp.ModelVersion, usage, false), nil
+}
+
+// GenerateWithTools generates text and can use tools
+func (c *GeminiClient) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (string, error) {
+	response, err := c.GenerateWithToolsDetailed(ctx, prompt, tools, options...)
+	if err != nil {
+		return "", err
+	}
+	return response.Content, nil
+}
+
+// GenerateWithToolsDetailed generates text with tools and returns detailed response
+// information, with token usage summed over all tool iterations
+func (c *GeminiClient) GenerateWithToolsDetailed(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (*interfaces.LLMResponse, error) {
+	if c.Model == "" {
+		return nil, fmt.Errorf("model not specified: use WithModel option when creating the client")
+	}
+
+	params := c.applyOptions(options)
+	ctx = ensureOrgID(ctx)
+
+	maxIterations := params.MaxIterations
+	if maxIterations == 0 {
+		maxIterations = 2
+	}
+
+	contents, err := c.buildContents(ctx, prompt, params)
+	if err != nil {
+		return nil, err
+	}
+	geminiTools := convertTools(tools)
+	toolCallHistory := make(map[string]int)
+	usage := &interfaces.TokenUsage{}
+
+	for iteration := 0; iteration < maxIterations; iteration++ {
+		req := &GenerateContentRequest{
+			Contents:          contents,
+			SystemInstruction: systemInstruction(toolsSystemMessage(params)),
+			Tools:             geminiTools,
+			ToolConfig:        &ToolConfig{FunctionCallingConfig: &FunctionCallingConfig{Mode: "AUTO"}},
+			GenerationConfig:  c.generationConfig(params),
+		}
+
+		c.logger.Debug(ctx, "Sending request with tools to Gemini", map[string]interface{}{
+			"model":         c.Model,
+			"contents":      len(req.Contents),
+			"tools":         len(tools),
+			"iteration":     iteration + 1,
+			"maxIterations": maxIterations,
+		})
+
+		if err := llm.BeforeCall(ctx, params); err != nil {
+			return nil, err
+		}
+		resp, err := c.generateContent(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("iteration %d: %w", iteration+1, err)
+		}
+		c.afterCall(ctx, params, resp)
+		addUsage(usage, resp.UsageMetadata)
+
+		candidate, err := firstCandidate(resp)
+		if err != nil {
+			return nil, err
+		}
+
+		calls := functionCalls(candidate.Content)
+		if len(calls) == 0 {
+			text, thinking := splitText(candidate.Content)
+			if text == "" {
+				return nil, fmt.Errorf("no text content in response (iteration %d, finish reason: %s)", iteration+1, candidate.FinishReason)
+			}
+			if params.ResponseFormat != nil {
+				text = extractJSON(text)
+			}
+			return c.newResponse(text, thinking, candidate.FinishReason, resp.ModelVersion, usage, true), nil
+		}
+
+		c.logger.Info(ctx, "Processing tool calls", map[string]interface{}{
+			"count":     len(calls),
+			"iteration": iteration + 1,
+		})
+
+		// The model turn is sent back unchanged so that thought signatures are preserved
+		candidate.Content.Role = "model"
+		contents = append(contents, candidate.Content)
+		contents = append(contents, Content{
+			Role:  "user",
+			Parts: c.executeToolsParallel(ctx, calls, tools, params, toolCallHistory, iteration),
+		})
+	}
+
+	// The model still wants tools after the last iteration; ask for a final answer without them
+	c.logger.Info(ctx, "Maximum iterations reached, making final call without tools", map[string]interface{}{
+		"maxIterations": maxIterations,
+	})
+
+	contents = append(contents, Content{
+		Role:  "user",
+		Parts: []Part{{Text: "Please provide your final response based on the information available. Do not request any additional tools."}},
+	})
+	req := &GenerateContentRequest{
+		Contents:          contents,
+		SystemInstruction: systemInstruction(params.SystemMessage),
+		GenerationConfig:  c.generationConfig(params),
+	}
+	if params.ResponseFormat != nil {
+		req.GenerationConfig.ResponseMimeType = "application/json"
+		req.GenerationConfig.ResponseJSONSchema = map[string]interface{}(params.ResponseFormat.Schema)
+	}
+
+	if err := llm.BeforeCall(ctx, params); err != nil {
+		return nil, err
+	}
+	resp, err := c.generateContent(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("final call: %w", err)
+	}
+	c.afterCall(ctx, params, resp)
+	addUsage(usage, resp.UsageMetadata)
+
+	candidate, err := firstCandidate(resp)
+	if err != nil {
+		return nil, err
+	}
+	text, thinking := splitText(candidate.Content)
+	if text == "" {
+		return nil, fmt.Errorf("no text content in final response (finish reason: %s)", candidate.FinishReason)
+	}
+	if params.ResponseFormat != nil {
+		text = extractJSON(text)
+	}
+	return c.newResponse(text, thinking, candidate.FinishReason, resp.ModelVersion, usage, true), nil
+}
+
+// Name returns the name of the LLM provider
+func (c *GeminiClient) Name() string {
+	return "gemini"
+}
+
+// SupportsStreaming returns true as Gemini supports streaming
+func (c *GeminiClient) SupportsStreaming() bool {
+	return true
+}
+
+// GetModel returns the model name being used
+func (c *GeminiClient) GetModel() string {
+	return c.Model
+}
+
+// applyOptions applies generate options over the client defaults
+func (c *GeminiClient) applyOptions(options []interfaces.GenerateOption) *interfaces.GenerateOptions {
+	params := &interfaces.GenerateOptions{
+		LLMConfig: &interfaces.LLMConfig{
+			Temperature: 0.7, // Default temperature
+		},
+	}
+	for _, option := range options {
+		if option != nil {
+			option(params)
+		}
+	}
+	if params.LLMConfig == nil {
+		params.LLMConfig = &interfaces.LLMConfig{}
+	}
+	return params
+}
+
+// generationConfig maps the LLM config, including the reasoning budget, to Gemini
+func (c *GeminiClient) generationConfig(params *interfaces.GenerateOptions) *GenerationConfig {
+	cfg := params.LLMConfig
+	config := &GenerationConfig{
+		Temperature:   &cfg.Temperature,
+		StopSequences: cfg.StopSequences,
+	}
+	if cfg.TopP > 0 {
+		config.TopP = &cfg.TopP
+	}
+	if cfg.PresencePenalty != 0 {
+		config.PresencePenalty = &cfg.PresencePenalty
+	}
+	if cfg.FrequencyPenalty != 0 {
+		config.FrequencyPenalty = &cfg.FrequencyPenalty
+	}
+	if cfg.EnableReasoning {
+		budget := -1 // Dynamic thinking
+		if cfg.ReasoningBudget > 0 {
+			budget = cfg.ReasoningBudget
+		}
+		config.ThinkingConfig = &ThinkingConfig{ThinkingBudget: &budget, IncludeThoughts: true}
+	}
+	return config
+}
+
+// generateContent sends a generateContent request, with retries if configured
+func (c *GeminiClient) generateContent(ctx context.Context, req *GenerateContentRequest) (*GenerateContentResponse, error) {
+	var resp GenerateContentResponse
+	operation := func() error {
+		httpReq, err := c.newHTTPRequest(ctx, "generateContent", req)
+		if err != nil {
+			return err
+		}
+
+		httpResp, err := c.HTTPClient.Do(httpReq)
+		if err != nil {
+			return fmt.Errorf("failed to send request to gemini: %w", llm.WrapError("gemini", err))
+		}
+		defer func() {
+			_ = httpResp.Body.Close()
+		}()
+
+		body, err := io.ReadAll(httpResp.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read gemini response: %w", err)
+		}
+		if httpResp.StatusCode != http.StatusOK {
+			return llm.NewAPIError("gemini", httpResp.StatusCode, httpResp.Header, body)
+		}
+
+		resp = GenerateContentResponse{}
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return fmt.Errorf("failed to parse gemini response: %w", err)
+		}
+		return nil
+	}
+
+	var err error
+	if c.retryExecutor != nil {
+		err = c.retryExecutor.Execute(ctx, operation)
+	} else {
+		err = operation()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// newHTTPRequest creates a request for a model method, authenticated with the
+// token source when configured and the API key otherwise
+func (c *GeminiClient) newHTTPRequest(ctx context.Context, method string, req *GenerateContentRequest) (*http.Request, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/models/%s:%s", c.BaseURL, c.Model, method)
+	if method == "streamGenerateContent" {
+		url += "?alt=sse"
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	if c.tokenSource != nil {
+		token, err := c.tokenSource.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get access token: %w", err)
+		}
+		httpReq.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	} else if c.APIKey != "" {
+		httpReq.Header.Set("x-goog-api-key", c.APIKey)
+	}
+	return httpReq, nil
+}
+
+// newResponse builds the detailed response returned to callers
+func (c *GeminiClient) newResponse(text, thinking, finishReason, modelVersion string, usage *interfaces.TokenUsage, toolsUsed bool) *interfaces.LLMResponse {
+	model := modelVersion
+	if model == "" {
+		model = c.Model
+	}
+	metadata := map[string]interface{}{
+		"provider": "gemini",
+	}
+	if toolsUsed {
+		metadata["tools_used"] = true
+	}
+	if thinking != "" {
+		metadata["thinking"] = thinking
+	}
+	return &interfaces.LLMResponse{
+		Content:    text,
+		Model:      model,
+		StopReason: finishReason,
+		Usage:      usage,
+		Metadata:   metadata,
+	}
+}
+
+// convertTools converts tools to Gemini function declarations
+func convertTools(tools []interfaces.Tool) []Tool {
+	if len(tools) == 0 {
+		return nil
+	}
+
+	declarations := make([]FunctionDeclaration, len(tools))
+	for i, tool := range tools {
+		declaration := FunctionDeclaration{
+			Name:        tool.Name(),
+			Description: tool.Description(),
+		}
+		// Gemini rejects an object schema without properties
+		schema := llm.ToolSchema(tool)
+		if properties, _ := schema["properties"].(map[string]interface{}); len(properties) > 0 {
+			declaration.Parameters = convertSchema(schema)
+		}
+		declarations[i] = declaration
+	}
+	return []Tool{{FunctionDeclarations: declarations}}
+}
+
+// geminiSchemaKeywords are the JSON schema keywords supported by the Gemini
+// schema, which is a subset of the OpenAPI schema
+var geminiSchemaKeywords = map[string]bool{
+	"description": true, "format": true, "title": true, "nullable": true,
+	"minimum": true, "maximum": true, "minLength": true, "maxLength": true,
+	"pattern": true, "minItems": true, "maxItems": true, "default": true,
+	"minProperties": true, "maxProperties": true, "required": true,
+}
+
+// convertSchema converts a JSON schema to a Gemini schema, which uses
+// upper-case type names and drops unsupported keywords
+func convertSchema(schema map[string]interface{}) map[string]interface{} {
+	converted := map[string]interface{}{}
+	for key, value := range schema {
+		if geminiSchemaKeywords[key] {
+			converted[key] = value
+		}
+	}
+
+	switch schemaType := schema["type"].(type) {
+	case string:
+		converted["type"] = strings.ToUpper(schemaType)
+	case []interface{}:
+		// A nullable type is written as a list with "null"
+		for _, t := range schemaType {
+			if name, ok := t.(string); ok && name != "null" {
+				converted["type"] = strings.ToUpper(name)
+			} else if ok {
+				converted["nullable"] = true
+			}
+		}
+	case []string:
+		for _, name := range schemaType {
+			if name != "null" {
+				converted["type"] = strings.ToUpper(name)
+			} else {
+				converted["nullable"] = true
+			}
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		values := make([]string, len(enum))
+		for i, value := range enum {
+			values[i] = fmt.Sprint(value)
+		}
+		converted["enum"] = values
+	}
+	if properties, ok := schema["properties"].(map[string]interface{}); ok {
+		convertedProperties := make(map[string]interface{}, len(properties))
+		for name, property := range properties {
+			if propertySchema, ok := property.(map[string]interface{}); ok {
+				convertedProperties[name] = convertSchema(propertySchema)
+			}
+		}
+		converted["properties"] = convertedProperties
+	}
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		converted["items"] = convertSchema(items)
+	} else if converted["type"] == "ARRAY" {
+		converted["items"] = map[string]interface{}{"type": "STRING"}
+	}
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		variants := make([]interface{}, 0, len(anyOf))
+		for _, variant := range anyOf {
+			if variantSchema, ok := variant.(map[string]interface{}); ok {
+				variants = append(variants, convertSchema(variantSchema))
+			}
+		}
+		converted["anyOf"] = variants
+	}
+	return converted
+}
+
+type toolExecResult struct {
+	index int
+	part  Part
+}
+
+// executeToolsParallel executes the function calls concurrently and returns
+// their responses in call order
+func (c *GeminiClient) executeToolsParallel(
+	ctx context.Context,
+	calls []FunctionCall,
+	tools []interfaces.Tool,
+	params *interfaces.GenerateOptions,
+	toolCallHistory map[string]int,
+	iteration int,
+) []Part {
+	c.logger.Info(ctx, "Executing tools in parallel", map[string]interface{}{
+		"count":     len(calls),
+		"iteration": iteration + 1,
+	})
+
+	resultChan := make(chan toolExecResult, len(calls))
+	var wg sync.WaitGroup
+	var historyMu sync.Mutex
+
+	for i, call := range calls {
+		wg.Add(1)
+		go func(idx int, call FunctionCall) {
+			defer wg.Done()
+
+			args, err := json.Marshal(call.Args)
+			if err != nil || call.Args == nil {
+				args = []byte("{}")
+			}
+
+			var selectedTool interfaces.Tool
+			for _, tool := range tools {
+				if tool.Name() == call.Name {
+					selectedTool = tool
+					break
+				}
+			}
+
+			var result string
+			var execErr error
+			if selectedTool == nil {
+				execErr = fmt.Errorf("tool not found: %s", call.Name)
+				c.logger.Error(ctx, "Tool not found", map[string]interface{}{
+					"toolName":  call.Name,
+					"iteration": iteration + 1,
+				})
+			} else {
+				c.logger.Info(ctx, "Executing tool (parallel)", map[string]interface{}{
+					"toolName":  call.Name,
+					"iteration": iteration + 1,
+				})
+				result, execErr = selectedTool.Execute(ctx, string(args))
+			}
+
+			// Check for repetitive calls
+			historyMu.Lock()
+			cacheKey := call.Name + ":" + string(args)
+			toolCallHistory[cacheKey]++
+			callCount := toolCallHistory[cacheKey]
+			historyMu.Unlock()
+			if callCount > 2 && execErr == nil {
+				result += fmt.Sprintf("\n\n[WARNING: This is call #%d to %s with identical parameters. You may be in a loop.]", callCount, call.Name)
+				c.logger.Warn(ctx, "Repetitive tool call detected", map[string]interface{}{
+					"toolName":  call.Name,
+					"callCount": callCount,
+					"iteration": iteration + 1,
+				})
+			}
+
+			content := result
+			response := map[string]interface{}{"result": result}
+			if execErr != nil {
+				content = fmt.Sprintf("Error: %v", execErr)
+				response = map[string]interface{}{"error": execErr.Error()}
+				if selectedTool != nil {
+					c.logger.Error(ctx, "Error executing tool", map[string]interface{}{
+						"toolName":  call.Name,
+						"error":     execErr.Error(),
+						"iteration": iteration + 1,
+					})
+				}
+			}
+
+			if params.Memory != nil {
+				_ = params.Memory.AddMessage(ctx, interfaces.Message{
+					Role: interfaces.MessageRoleAssistant,
+					ToolCalls: []interfaces.ToolCall{{
+						ID:        call.ID,
+						Name:      call.Name,
+						Arguments: string(args),
+					}},
+				})
+				_ = params.Memory.AddMessage(ctx, interfaces.Message{
+					Role:       interfaces.MessageRoleTool,
+					Content:    content,
+					ToolCallID: call.ID,
+					Metadata:   map[string]interface{}{"tool_name": call.Name},
+				})
+			}
+
+			resultChan <- toolExecResult{
+				index: idx,
+				part: Part{FunctionResponse: &FunctionResponse{
+					ID:       call.ID,
+					Name:     call.Name,
+					Response: response,
+				}},
+			}
+		}(i, call)
+	}
+
+	wg.Wait()
+	close(resultChan)
+
+	parts := make([]Part, len(calls))
+	for result := range resultChan {
+		parts[result.index] = result.part
+	}
+
+	c.logger.Info(ctx, "Parallel tool execution completed", map[string]interface{}{
+		"count":     len(parts),
+		"iteration": iteration + 1,
+	})
+	return parts
+}
+
+// ensureOrgID adds the default organization ID to the context if missing, so that tools can rely on it
+func ensureOrgID(ctx context.Context) context.Context {
+	if _, err := multitenancy.GetOrgID(ctx); err == nil {
+		return ctx
+	}
+	return multitenancy.WithOrgID(ctx, "default")
+}
+
+// systemInstruction wraps a system message, or returns nil if empty
+func systemInstruction(message string) *Content {
+	if message == "" {
+		return nil
+	}
+	return &Content{Parts: []Part{{Text: message}}}
+}
+
+// toolsSystemMessage returns the system message for tool iterations. Gemini
+// cannot combine function calling with a response schema, so structured output
+// is requested in the instructions instead.
+func toolsSystemMessage(params *interfaces.GenerateOptions) string {
+	if params.ResponseFormat == nil {
+		return params.SystemMessage
+	}
+	schema, _ := json.Marshal(params.ResponseFormat.Schema)
+	instruction := jsonSystemInstruction + "\nSchema: " + string(schema)
+	if params.SystemMessage == "" {
+		return instruction
+	}
+	return params.SystemMessage + "\n\n" + instruction
+}
+
+// firstCandidate returns the first candidate, or an error explaining why there is none
+func firstCandidate(resp *GenerateContentResponse) (*Candidate, error) {
+	if len(resp.Candidates) == 0 {
+		if resp.PromptFeedback != nil && resp.PromptFeedback.BlockReason != "" {
+			return nil, llm.NewError("gemini", llm.ErrorKindContentFiltered, resp.PromptFeedback.BlockReason, "prompt blocked")
+		}
+		return nil, fmt.Errorf("no candidates in gemini response")
+	}
+	return &resp.Candidates[0], nil
+}
+
+// splitText returns the answer text and the thought summaries of a content
+func splitText(content Content) (string, string) {
+	var text, thinking strings.Builder
+	for _, part := range content.Parts {
+		if part.Thought {
+			thinking.WriteString(part.Text)
+		} else {
+			text.WriteString(part.Text)
+		}
+	}
+	return text.String(), thinking.String()
+}
+
+// functionCalls returns the function calls of a content
+func functionCalls(content Content) []FunctionCall {
+	var calls []FunctionCall
+	for _, part := range content.Parts {
+		if part.FunctionCall != nil {
+			calls = append(calls, *part.FunctionCall)
+		}
+	}
+	return calls
+}
+
+// afterCall reports the model and usage of one response to the call hook
+func (c *GeminiClient) afterCall(ctx context.Context, params *interfaces.GenerateOptions, resp *GenerateContentResponse) {
+	usage := &interfaces.TokenUsage{}
+	addUsage(usage, resp.UsageMetadata)
+	model := resp.ModelVersion
+	if model == "" {
+		model = c.Model
+	}
+	llm.AfterCall(ctx, params, model, usage)
+}
+
+// addUsage adds the usage metadata of one response to the running total
+func addUsage(usage *interfaces.TokenUsage, metadata *UsageMetadata) {
+	if metadata == nil {
+		return
+	}
+	usage.InputTokens += metadata.PromptTokenCount
+	usage.OutputTokens += metadata.CandidatesTokenCount
+	usage.ReasoningTokens += metadata.ThoughtsTokenCount
+	usage.CacheReadInputTokens += metadata.CachedContentTokenCount
+	usage.TotalTokens += metadata.TotalTokenCount
+}
+
+// extractJSON strips a markdown code fence or surrounding text from a JSON response
+func extractJSON(response string) string {
+	response = strings.TrimSpace(response)
+	if strings.HasPrefix(response, "```") {
+		response = strings.TrimPrefix(response, "```")
+		if newline := strings.Index(response, "\n"); newline >= 0 {
+			response = response[newline+1:]
+		}
+		response = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(response), "```"))
+	}
+	start := strings.IndexAny(response, "{[")
+	end := strings.LastIndexAny(response, "}]")
+	if start >= 0 && end > start {
+		return response[start : end+1]
+	}
+	return response
+}
@@ -0,0 +1,353 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// fakeServer answers generateContent requests with canned responses in order
+type fakeServer struct {
+	t         *testing.T
+	responses []string
+	mu        sync.Mutex
+	requests  []GenerateContentRequest
+	paths     []string
+	headers   []http.Header
+}
+
+func (s *fakeServer) handler(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var req GenerateContentRequest
+	require.NoError(s.t, json.NewDecoder(r.Body).Decode(&req))
+	s.requests = append(s.requests, req)
+	s.paths = append(s.paths, r.URL.String())
+	s.headers = append(s.headers, r.Header.Clone())
+
+	if len(s.responses) == 0 {
+		http.Error(w, `{"error":{"message":"unexpected request"}}`, http.StatusInternalServerError)
+		return
+	}
+	response := s.responses[0]
+	s.responses = s.responses[1:]
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(response))
+}
+
+func newTestClient(t *testing.T, responses ...string) (*GeminiClient, *fakeServer) {
+	s := &fakeServer{t: t, responses: responses}
+	server := httptest.NewServer(http.HandlerFunc(s.handler))
+	t.Cleanup(server.Close)
+	return NewClient("test-key", WithBaseURL(server.URL), WithModel(Gemini25Flash)), s
+}
+
+// echoTool returns its arguments, or an error when asked to fail
+type echoTool struct{}
+
+func (echoTool) Name() string        { return "echo" }
+func (echoTool) Description() string { return "Echoes the message" }
+func (echoTool) Parameters() map[string]interfaces.ParameterSpec {
+	return map[string]interfaces.ParameterSpec{
+		"message": {Type: "string", Description: "Message to echo", Required: true},
+		"tags":    {Type: "array", Items: &interfaces.ParameterSpec{Type: "string"}},
+	}
+}
+func (t echoTool) Run(ctx context.Context, input string) (string, error) {
+	return t.Execute(ctx, input)
+}
+func (echoTool) Execute(ctx context.Context, args string) (string, error) {
+	var params struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", err
+	}
+	if params.Message == "fail" {
+		return "", fmt.Errorf("echo failed")
+	}
+	return "echo: " + params.Message, nil
+}
+
+// recordingMemory stores messages in a slice
+type recordingMemory struct {
+	mu       sync.Mutex
+	messages []interfaces.Message
+}
+
+func (m *recordingMemory) AddMessage(ctx context.Context, message interfaces.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+func (m *recordingMemory) GetMessages(ctx context.Context, options ...interfaces.GetMessagesOption) ([]interfaces.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]interfaces.Message(nil), m.messages...), nil
+}
+
+func (m *recordingMemory) Clear(ctx context.Context) error {
+	m.messages = nil
+	return nil
+}
+
+func TestGenerateDetailed(t *testing.T) {
+	client, server := newTestClient(t, `{
+		"candidates": [{"content": {"role": "model", "parts": [
+			{"text": "Let me think.", "thought": true},
+			{"text": "Hello"}, {"text": " world"}
+		]}, "finishReason": "STOP"}],
+		"usageMetadata": {"promptTokenCount": 10, "candidatesTokenCount": 5, "thoughtsTokenCount": 7, "cachedContentTokenCount": 3, "totalTokenCount": 22},
+		"modelVersion": "gemini-2.5-flash-001"
+	}`)
+
+	resp, err := client.GenerateDetailed(context.Background(), "Hi",
+		interfaces.WithSystemMessage("Be brief."),
+		interfaces.WithReasoning(true, 1024))
+	require.NoError(t, err)
+
+	assert.Equal(t, "Hello world", resp.Content)
+	assert.Equal(t, "gemini-2.5-flash-001", resp.Model)
+	assert.Equal(t, "STOP", resp.StopReason)
+	assert.Equal(t, "Let me think.", resp.Metadata["thinking"])
+	assert.Equal(t, &interfaces.TokenUsage{InputTokens: 10, OutputTokens: 5, ReasoningTokens: 7, CacheReadInputTokens: 3, TotalTokens: 22}, resp.Usage)
+
+	require.Len(t, server.requests, 1)
+	req := server.requests[0]
+	assert.Equal(t, "/models/gemini-2.5-flash:generateContent", server.paths[0])
+	assert.Equal(t, "test-key", server.headers[0].Get("x-goog-api-key"))
+	assert.Equal(t, "Be brief.", req.SystemInstruction.Parts[0].Text)
+	assert.Equal(t, []Content{{Role: "user", Parts: []Part{{Text: "Hi"}}}}, req.Contents)
+	require.NotNil(t, req.GenerationConfig.ThinkingConfig)
+	assert.Equal(t, 1024, *req.GenerationConfig.ThinkingConfig.ThinkingBudget)
+	assert.True(t, req.GenerationConfig.ThinkingConfig.IncludeThoughts)
+	assert.InDelta(t, 0.7, *req.GenerationConfig.Temperature, 1e-9)
+}
+
+func TestGenerateDetailed_DynamicThinkingBudget(t *testing.T) {
+	client, server := newTestClient(t, `{"candidates": [{"content": {"parts": [{"text": "ok"}]}}]}`)
+
+	_, err := client.Generate(context.Background(), "Hi", interfaces.WithReasoning(true))
+	require.NoError(t, err)
+	assert.Equal(t, -1, *server.requests[0].GenerationConfig.ThinkingConfig.ThinkingBudget)
+}
+
+func TestGenerate_StructuredOutput(t *testing.T) {
+	client, server := newTestClient(t, `{"candidates": [{"content": {"parts": [{"text": "{\"city\":\"Paris\"}"}]}}]}`)
+
+	format := interfaces.ResponseFormat{
+		Type:   interfaces.ResponseFormatJSON,
+		Name:   "Answer",
+		Schema: interfaces.JSONSchema{"type": "object", "properties": map[string]interface{}{"city": map[string]interface{}{"type": "string"}}},
+	}
+	resp, err := client.Generate(context.Background(), "Capital of France?", interfaces.WithResponseFormat(format))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"city":"Paris"}`, resp)
+
+	config := server.requests[0].GenerationConfig
+	assert.Equal(t, "application/json", config.ResponseMimeType)
+	assert.Equal(t, "object", config.ResponseJSONSchema["type"])
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	client, _ := newTestClient(t, `{"promptFeedback": {"blockReason": "SAFETY"}}`)
+	_, err := client.Generate(context.Background(), "Hi")
+	assert.ErrorContains(t, err, "SAFETY")
+
+	client, _ = newTestClient(t)
+	_, err = client.Generate(context.Background(), "Hi")
+	assert.ErrorContains(t, err, "status 500")
+
+	_, err = NewClient("key", WithModel("")).Generate(context.Background(), "Hi")
+	assert.ErrorContains(t, err, "model not specified")
+}
+
+func TestGenerateWithToolsDetailed(t *testing.T) {
+	client, server := newTestClient(t,
+		`{
+			"candidates": [{"content": {"role": "model", "parts": [
+				{"functionCall": {"name": "echo", "args": {"message": "one"}}, "thoughtSignature": "sig"},
+				{"functionCall": {"name": "echo", "args": {"message": "fail"}}},
+				{"functionCall": {"name": "missing", "args": {}}}
+			]}}],
+			"usageMetadata": {"promptTokenCount": 20, "candidatesTokenCount": 10, "totalTokenCount": 30}
+		}`,
+		`{
+			"candidates": [{"content": {"role": "model", "parts": [{"text": "Done"}]}, "finishReason": "STOP"}],
+			"usageMetadata": {"promptTokenCount": 40, "candidatesTokenCount": 2, "totalTokenCount": 42}
+		}`)
+
+	memory := &recordingMemory{}
+	require.NoError(t, memory.AddMessage(context.Background(), interfaces.Message{Role: interfaces.MessageRoleUser, Content: "Echo things"}))
+
+	resp, err := client.GenerateWithToolsDetailed(context.Background(), "Echo things", []interfaces.Tool{echoTool{}},
+		interfaces.WithMemory(memory))
+	require.NoError(t, err)
+	assert.Equal(t, "Done", resp.Content)
+	assert.Equal(t, 60, resp.Usage.InputTokens)
+	assert.Equal(t, 72, resp.Usage.TotalTokens)
+
+	require.Len(t, server.requests, 2)
+	declarations := server.requests[0].Tools[0].FunctionDeclarations
+	require.Len(t, declarations, 1)
+	assert.Equal(t, "echo", declarations[0].Name)
+	assert.Equal(t, "OBJECT", declarations[0].Parameters["type"])
+	assert.Equal(t, []interface{}{"message"}, declarations[0].Parameters["required"])
+	assert.Equal(t, "AUTO", server.requests[0].ToolConfig.FunctionCallingConfig.Mode)
+
+	contents := server.requests[1].Contents
+	require.Len(t, contents, 3)
+	assert.Equal(t, "model", contents[1].Role)
+	assert.Equal(t, "sig", contents[1].Parts[0].ThoughtSignature)
+
+	responses := contents[2].Parts
+	require.Len(t, responses, 3)
+	assert.Equal(t, map[string]interface{}{"result": "echo: one"}, responses[0].FunctionResponse.Response)
+	assert.Equal(t, map[string]interface{}{"error": "echo failed"}, responses[1].FunctionResponse.Response)
+	assert.Equal(t, "missing", responses[2].FunctionResponse.Name)
+	assert.Contains(t, responses[2].FunctionResponse.Response["error"], "tool not found")
+
+	// The user message plus a call and a result per tool call
+	assert.Len(t, memory.messages, 7)
+}
+
+func TestGenerateWithTools_FinalCallAfterMaxIterations(t *testing.T) {
+	call := `{"candidates": [{"content": {"role": "model", "parts": [{"functionCall": {"name": "echo", "args": {"message": "again"}}}]}}]}`
+	client, server := newTestClient(t, call, call,
+		"{\"candidates\": [{\"content\": {\"parts\": [{\"text\": \"```json\\n{\\\"ok\\\": true}\\n```\"}]}}]}")
+
+	format := interfaces.ResponseFormat{Name: "Result", Schema: interfaces.JSONSchema{"type": "object"}}
+	resp, err := client.GenerateWithTools(context.Background(), "Loop", []interfaces.Tool{echoTool{}},
+		interfaces.WithResponseFormat(format))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"ok": true}`, resp)
+
+	require.Len(t, server.requests, 3)
+	assert.Contains(t, server.requests[0].SystemInstruction.Parts[0].Text, "valid JSON")
+	final := server.requests[2]
+	assert.Empty(t, final.Tools)
+	assert.Equal(t, "application/json", final.GenerationConfig.ResponseMimeType)
+	last := final.Contents[len(final.Contents)-1]
+	assert.True(t, strings.HasPrefix(last.Parts[0].Text, "Please provide your final response"))
+}
+
+func TestConvertSchema(t *testing.T) {
+	schema := convertSchema(map[string]interface{}{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"type":                 "object",
+		"additionalProperties": false,
+		"required":             []string{"when"},
+		"properties": map[string]interface{}{
+			"when": map[string]interface{}{"type": "string", "format": "date-time"},
+			"note": map[string]interface{}{"type": []interface{}{"string", "null"}, "maxLength": 200},
+			"level": map[string]interface{}{
+				"type": "integer", "minimum": 1, "maximum": 5, "enum": []interface{}{1, 3, 5},
+			},
+			"ids": map[string]interface{}{"type": "array"},
+		},
+	})
+
+	assert.Equal(t, map[string]interface{}{
+		"type":     "OBJECT",
+		"required": []string{"when"},
+		"properties": map[string]interface{}{
+			"when":  map[string]interface{}{"type": "STRING", "format": "date-time"},
+			"note":  map[string]interface{}{"type": "STRING", "nullable": true, "maxLength": 200},
+			"level": map[string]interface{}{"type": "INTEGER", "minimum": 1, "maximum": 5, "enum": []string{"1", "3", "5"}},
+			"ids":   map[string]interface{}{"type": "ARRAY", "items": map[string]interface{}{"type": "STRING"}},
+		},
+	}, schema)
+}
+
+func TestBuildContents_FromMemory(t *testing.T) {
+	client := NewClient("key")
+	memory := &recordingMemory{messages: []interfaces.Message{
+		{Role: interfaces.MessageRoleSystem, Content: "Summary of earlier turns"},
+		{Role: interfaces.MessageRoleUser, Content: "Weather?"},
+		{Role: interfaces.MessageRoleAssistant, ToolCalls: []interfaces.ToolCall{{ID: "c1", Name: "weather", Arguments: `{"city":"Oslo"}`}}},
+		{Role: interfaces.MessageRoleTool, ToolCallID: "c1", Content: "Sunny"},
+		{Role: interfaces.MessageRoleAssistant, Content: "It is sunny."},
+	}}
+
+	contents, err := client.buildContents(context.Background(), "ignored", &interfaces.GenerateOptions{Memory: memory})
+	require.NoError(t, err)
+	require.Len(t, contents, 4)
+
+	// The system summary and the question are merged into one user turn
+	assert.Equal(t, "user", contents[0].Role)
+	assert.Equal(t, "System: Summary of earlier turns", contents[0].Parts[0].Text)
+	assert.Equal(t, "Weather?", contents[0].Parts[1].Text)
+
+	assert.Equal(t, "model", contents[1].Role)
+	assert.Equal(t, &FunctionCall{ID: "c1", Name: "weather", Args: map[string]interface{}{"city": "Oslo"}}, contents[1].Parts[0].FunctionCall)
+
+	// The tool name is resolved from the call ID
+	assert.Equal(t, "weather", contents[2].Parts[0].FunctionResponse.Name)
+	assert.Equal(t, "It is sunny.", contents[3].Parts[0].Text)
+}
+
+func TestBuildContents_ContentParts(t *testing.T) {
+	client := NewClient("key")
+
+	contents, err := client.buildContents(context.Background(), "Compare", &interfaces.GenerateOptions{
+		ContentParts: []interfaces.ContentPart{
+			interfaces.ImagePart([]byte("png"), "image/png"),
+			interfaces.DocumentURLPart("gs://bucket/report.pdf"),
+			interfaces.AudioPart([]byte("mp3"), "audio/mpeg"),
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, contents, 1)
+	assert.Equal(t, []Part{
+		{Text: "Compare"},
+		{InlineData: &Blob{MimeType: "image/png", Data: "cG5n"}},
+		{FileData: &FileData{MimeType: "application/pdf", FileURI: "gs://bucket/report.pdf"}},
+		{InlineData: &Blob{MimeType: "audio/mpeg", Data: "bXAz"}},
+	}, contents[0].Parts)
+
+	// With memory the parts are added to the last user message
+	memory := &recordingMemory{messages: []interfaces.Message{{Role: interfaces.MessageRoleUser, Content: "Describe"}}}
+	contents, err = client.buildContents(context.Background(), "Describe", &interfaces.GenerateOptions{
+		Memory:       memory,
+		ContentParts: []interfaces.ContentPart{interfaces.ImageURLPart("https://example.com/cat.jpg")},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &FileData{MimeType: "image/jpeg", FileURI: "https://example.com/cat.jpg"}, contents[0].Parts[1].FileData)
+}
+
+func TestWithTokenSource_UsesBearerToken(t *testing.T) {
+	client, server := newTestClient(t, `{"candidates": [{"content": {"parts": [{"text": "ok"}]}}]}`)
+	WithTokenSource(staticTokenSource("token-123"))(client)
+
+	_, err := client.Generate(context.Background(), "Hi")
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer token-123", server.headers[0].Get("Authorization"))
+	assert.Empty(t, server.headers[0].Get("x-goog-api-key"))
+}
+
+func TestConfigureVertex(t *testing.T) {
+	client := NewClient("")
+	client.configureVertex("us-central1", "my-project", staticTokenSource("t"))
+	assert.Equal(t, "https://us-central1-aiplatform.googleapis.com/v1/projects/my-project/locations/us-central1/publishers/google", client.BaseURL)
+
+	client.configureVertex("global", "my-project", staticTokenSource("t"))
+	assert.Equal(t, "https://aiplatform.googleapis.com/v1/projects/my-project/locations/global/publishers/google", client.BaseURL)
+}
+
+func staticTokenSource(token string) oauth2.TokenSource {
+	return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+}
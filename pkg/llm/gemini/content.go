@@ -0,0 +1,46 @@
+package gemini
+
+import (
+	"mime"
+	"path"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// Blob is inline media data
+type Blob struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"` // Base64 encoded
+}
+
+// FileData references media by URI, such as a Files API or Cloud Storage URI
+type FileData struct {
+	MimeType string `json:"mimeType,omitempty"`
+	FileURI  string `json:"fileUri"`
+}
+
+// userParts converts the text and content parts of a user message to Gemini parts
+func userParts(text string, parts []interfaces.ContentPart) ([]Part, error) {
+	result := make([]Part, 0, len(parts)+1)
+	if text != "" {
+		result = append(result, Part{Text: text})
+	}
+	for _, part := range parts {
+		if err := part.Validate(); err != nil {
+			return nil, err
+		}
+		switch {
+		case part.Type == interfaces.ContentPartText:
+			result = append(result, Part{Text: part.Text})
+		case len(part.Data) > 0:
+			result = append(result, Part{InlineData: &Blob{MimeType: part.MediaType, Data: part.Base64()}})
+		default:
+			mimeType := part.MediaType
+			if mimeType == "" {
+				mimeType = mime.TypeByExtension(path.Ext(part.URL))
+			}
+			result = append(result, Part{FileData: &FileData{MimeType: mimeType, FileURI: part.URL}})
+		}
+	}
+	return result, nil
+}
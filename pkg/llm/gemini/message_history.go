@@ -0,0 +1,113 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/llm"
+)
+
+// buildContents constructs Gemini contents from memory and the current prompt.
+// With memory, the prompt is expected to be stored in memory already. Content
+// parts are attached to the current user turn.
+func (c *GeminiClient) buildContents(ctx context.Context, prompt string, params *interfaces.GenerateOptions) ([]Content, error) {
+	var memoryMessages []interfaces.Message
+	if params.Memory != nil {
+		var err error
+		memoryMessages, err = params.Memory.GetMessages(ctx)
+		if err != nil {
+			c.logger.Error(ctx, "Failed to retrieve memory messages", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	contents := []Content{}
+	toolNames := make(map[string]string)
+	for _, msg := range llm.AttachContentParts(memoryMessages, prompt, params.ContentParts) {
+		content, err := c.convertMemoryMessage(ctx, msg, toolNames)
+		if err != nil {
+			return nil, err
+		}
+		if content == nil {
+			continue
+		}
+		// Gemini expects alternating turns, so consecutive messages of the same role are merged
+		if last := len(contents) - 1; last >= 0 && contents[last].Role == content.Role {
+			contents[last].Parts = append(contents[last].Parts, content.Parts...)
+			continue
+		}
+		contents = append(contents, *content)
+	}
+
+	if len(contents) == 0 {
+		contents = append(contents, Content{Role: "user", Parts: []Part{{Text: prompt}}})
+	}
+	return contents, nil
+}
+
+// convertMemoryMessage converts a memory message to Gemini format. toolNames
+// maps tool call IDs to tool names so that tool results can be matched to calls.
+func (c *GeminiClient) convertMemoryMessage(ctx context.Context, msg interfaces.Message, toolNames map[string]string) (*Content, error) {
+	switch msg.Role {
+	case interfaces.MessageRoleUser:
+		parts, err := userParts(msg.Content, msg.Parts)
+		if err != nil || len(parts) == 0 {
+			return nil, err
+		}
+		return &Content{Role: "user", Parts: parts}, nil
+
+	case interfaces.MessageRoleAssistant:
+		var parts []Part
+		if msg.Content != "" {
+			parts = append(parts, Part{Text: msg.Content})
+		}
+		for _, toolCall := range msg.ToolCalls {
+			var args map[string]interface{}
+			if toolCall.Arguments != "" {
+				if err := json.Unmarshal([]byte(toolCall.Arguments), &args); err != nil {
+					c.logger.Warn(ctx, "Failed to parse tool call arguments", map[string]interface{}{
+						"error":     err.Error(),
+						"arguments": toolCall.Arguments,
+					})
+					continue
+				}
+			}
+			if toolCall.ID != "" {
+				toolNames[toolCall.ID] = toolCall.Name
+			}
+			parts = append(parts, Part{FunctionCall: &FunctionCall{
+				ID:   toolCall.ID,
+				Name: toolCall.Name,
+				Args: args,
+			}})
+		}
+		if len(parts) == 0 {
+			return nil, nil
+		}
+		return &Content{Role: "model", Parts: parts}, nil
+
+	case interfaces.MessageRoleTool:
+		name, _ := msg.Metadata["tool_name"].(string)
+		if name == "" {
+			name = toolNames[msg.ToolCallID]
+		}
+		if name == "" {
+			// Without a name the result cannot be sent as a function response
+			return &Content{Role: "user", Parts: []Part{{Text: fmt.Sprintf("Tool result for %s: %s", msg.ToolCallID, msg.Content)}}}, nil
+		}
+		return &Content{Role: "user", Parts: []Part{{FunctionResponse: &FunctionResponse{
+			ID:       msg.ToolCallID,
+			Name:     name,
+			Response: map[string]interface{}{"result": msg.Content},
+		}}}}, nil
+
+	case interfaces.MessageRoleSystem:
+		// The system instruction is sent separately, other system messages (like summaries) are passed as user messages
+		return &Content{Role: "user", Parts: []Part{{Text: fmt.Sprintf("System: %s", msg.Content)}}}, nil
+	}
+
+	return nil, nil
+}
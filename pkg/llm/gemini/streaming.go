@@ -0,0 +1,387 @@
+package gemini
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/llm"
+)
+
+// GenerateStream generates text with a streaming response
+func (c *GeminiClient) GenerateStream(
+	ctx context.Context,
+	prompt string,
+	options ...interfaces.GenerateOption,
+) (<-chan interfaces.StreamEvent, error) {
+	if c.Model == "" {
+		return nil, fmt.Errorf("model not specified: use WithModel option when creating the client")
+	}
+
+	params := c.applyOptions(options)
+	ctx = ensureOrgID(ctx)
+
+	contents, err := c.buildContents(ctx, prompt, params)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &GenerateContentRequest{
+		Contents:          contents,
+		SystemInstruction: systemInstruction(params.SystemMessage),
+		GenerationConfig:  c.generationConfig(params),
+	}
+	if params.ResponseFormat != nil {
+		req.GenerationConfig.ResponseMimeType = "application/json"
+		req.GenerationConfig.ResponseJSONSchema = map[string]interface{}(params.ResponseFormat.Schema)
+	}
+
+	eventChan := make(chan interfaces.StreamEvent, bufferSize(params))
+	go func() {
+		defer close(eventChan)
+		s := &streamer{ctx: ctx, events: eventChan, includeThinking: includeThinking(params)}
+
+		s.send(interfaces.StreamEvent{Type: interfaces.StreamEventMessageStart, Metadata: map[string]interface{}{"model": c.Model}})
+		result, err := c.streamContent(ctx, req, s.send, s.sendThinking)
+		if err != nil {
+			s.send(interfaces.StreamEvent{Type: interfaces.StreamEventError, Error: err})
+			return
+		}
+		usage := &interfaces.TokenUsage{}
+		addUsage(usage, result.usage)
+		s.finish(result.finishReason, usage)
+	}()
+
+	return eventChan, nil
+}
+
+// GenerateWithToolsStream generates text with tools and a streaming response.
+// Text of intermediate tool iterations is only streamed when the stream config
+// includes intermediate messages.
+func (c *GeminiClient) GenerateWithToolsStream(
+	ctx context.Context,
+	prompt string,
+	tools []interfaces.Tool,
+	options ...interfaces.GenerateOption,
+) (<-chan interfaces.StreamEvent, error) {
+	if c.Model == "" {
+		return nil, fmt.Errorf("model not specified: use WithModel option when creating the client")
+	}
+
+	params := c.applyOptions(options)
+	ctx = ensureOrgID(ctx)
+
+	eventChan := make(chan interfaces.StreamEvent, bufferSize(params))
+	go func() {
+		defer close(eventChan)
+		s := &streamer{ctx: ctx, events: eventChan, includeThinking: includeThinking(params)}
+
+		s.send(interfaces.StreamEvent{Type: interfaces.StreamEventMessageStart, Metadata: map[string]interface{}{"model": c.Model}})
+		finishReason, usage, err := c.streamWithTools(ctx, prompt, tools, params, s)
+		if err != nil {
+			s.send(interfaces.StreamEvent{Type: interfaces.StreamEventError, Error: err})
+			return
+		}
+		s.finish(finishReason, usage)
+	}()
+
+	return eventChan, nil
+}
+
+// streamWithTools runs the tool loop, streaming tool events as tools are called
+func (c *GeminiClient) streamWithTools(
+	ctx context.Context,
+	prompt string,
+	tools []interfaces.Tool,
+	params *interfaces.GenerateOptions,
+	s *streamer,
+) (string, *interfaces.TokenUsage, error) {
+	maxIterations := params.MaxIterations
+	if maxIterations == 0 {
+		maxIterations = 2
+	}
+	includeIntermediate := params.StreamConfig != nil && params.StreamConfig.IncludeIntermediateMessages
+
+	contents, err := c.buildContents(ctx, prompt, params)
+	if err != nil {
+		return "", nil, err
+	}
+	geminiTools := convertTools(tools)
+	toolCallHistory := make(map[string]int)
+	usage := &interfaces.TokenUsage{}
+
+	for iteration := 0; iteration < maxIterations; iteration++ {
+		req := &GenerateContentRequest{
+			Contents:          contents,
+			SystemInstruction: systemInstruction(toolsSystemMessage(params)),
+			Tools:             geminiTools,
+			ToolConfig:        &ToolConfig{FunctionCallingConfig: &FunctionCallingConfig{Mode: "AUTO"}},
+			GenerationConfig:  c.generationConfig(params),
+		}
+
+		// Text is held back until it is known whether this is the final answer
+		var buffered []interfaces.StreamEvent
+		onText := s.send
+		if !includeIntermediate {
+			onText = func(event interfaces.StreamEvent) bool {
+				buffered = append(buffered, event)
+				return true
+			}
+		}
+
+		if err := llm.BeforeCall(ctx, params); err != nil {
+			return "", nil, err
+		}
+
+		result, err := c.streamContent(ctx, req, onText, s.sendThinking)
+		if err != nil {
+			return "", nil, fmt.Errorf("iteration %d: %w", iteration+1, err)
+		}
+		c.afterCall(ctx, params, &GenerateContentResponse{UsageMetadata: result.usage})
+		addUsage(usage, result.usage)
+
+		calls := functionCalls(result.content)
+		if len(calls) == 0 {
+			for _, event := range buffered {
+				if !s.send(event) {
+					return "", nil, ctx.Err()
+				}
+			}
+			return result.finishReason, usage, nil
+		}
+
+		callIDs := make([]string, len(calls))
+		for i, call := range calls {
+			callIDs[i] = call.ID
+			if callIDs[i] == "" {
+				callIDs[i] = fmt.Sprintf("%s_%d_%d", call.Name, iteration+1, i)
+			}
+			args, _ := json.Marshal(call.Args)
+			s.send(interfaces.StreamEvent{
+				Type:     interfaces.StreamEventToolUse,
+				ToolCall: &interfaces.ToolCall{ID: callIDs[i], Name: call.Name, Arguments: string(args)},
+			})
+		}
+
+		parts := c.executeToolsParallel(ctx, calls, tools, params, toolCallHistory, iteration)
+		for i, part := range parts {
+			args, _ := json.Marshal(calls[i].Args)
+			s.send(interfaces.StreamEvent{
+				Type:     interfaces.StreamEventToolResult,
+				ToolCall: &interfaces.ToolCall{ID: callIDs[i], Name: calls[i].Name, Arguments: string(args)},
+				Content:  responseText(part.FunctionResponse),
+			})
+		}
+		if ctx.Err() != nil {
+			return "", nil, ctx.Err()
+		}
+
+		result.content.Role = "model"
+		contents = append(contents, result.content, Content{Role: "user", Parts: parts})
+	}
+
+	c.logger.Info(ctx, "Maximum iterations reached, making final streaming call without tools", map[string]interface{}{
+		"maxIterations": maxIterations,
+	})
+
+	contents = append(contents, Content{
+		Role:  "user",
+		Parts: []Part{{Text: "Please provide your final response based on the information available. Do not request any additional tools."}},
+	})
+	req := &GenerateContentRequest{
+		Contents:          contents,
+		SystemInstruction: systemInstruction(params.SystemMessage),
+		GenerationConfig:  c.generationConfig(params),
+	}
+	if params.ResponseFormat != nil {
+		req.GenerationConfig.ResponseMimeType = "application/json"
+		req.GenerationConfig.ResponseJSONSchema = map[string]interface{}(params.ResponseFormat.Schema)
+	}
+
+	if err := llm.BeforeCall(ctx, params); err != nil {
+		return "", nil, err
+	}
+
+	result, err := c.streamContent(ctx, req, s.send, s.sendThinking)
+	if err != nil {
+		return "", nil, fmt.Errorf("final call: %w", err)
+	}
+	c.afterCall(ctx, params, &GenerateContentResponse{UsageMetadata: result.usage})
+	addUsage(usage, result.usage)
+	return result.finishReason, usage, nil
+}
+
+// streamResult is the accumulated outcome of one streamed request
+type streamResult struct {
+	content      Content
+	usage        *UsageMetadata
+	finishReason string
+}
+
+// streamContent sends a streamGenerateContent request and reads the server-sent
+// events, passing text and thought deltas to the callbacks as they arrive. The
+// returned content holds all parts with consecutive text merged.
+func (c *GeminiClient) streamContent(
+	ctx context.Context,
+	req *GenerateContentRequest,
+	onText func(interfaces.StreamEvent) bool,
+	onThinking func(interfaces.StreamEvent) bool,
+) (*streamResult, error) {
+	httpReq, err := c.newHTTPRequest(ctx, "streamGenerateContent", req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	httpResp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to gemini: %w", llm.WrapError("gemini", err))
+	}
+	defer func() {
+		_ = httpResp.Body.Close()
+	}()
+
+	if httpResp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(httpResp.Body)
+		return nil, llm.NewAPIError("gemini", httpResp.StatusCode, httpResp.Header, body)
+	}
+
+	result := &streamResult{content: Content{Role: "model"}}
+	scanner := bufio.NewScanner(httpResp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "" {
+			continue
+		}
+
+		var chunk GenerateContentResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("failed to parse gemini stream chunk: %w", err)
+		}
+		if chunk.UsageMetadata != nil {
+			// Usage is cumulative, the last chunk holds the totals
+			result.usage = chunk.UsageMetadata
+		}
+		if len(chunk.Candidates) == 0 {
+			if chunk.PromptFeedback != nil && chunk.PromptFeedback.BlockReason != "" {
+				return nil, llm.NewError("gemini", llm.ErrorKindContentFiltered, chunk.PromptFeedback.BlockReason, "prompt blocked")
+			}
+			continue
+		}
+
+		candidate := chunk.Candidates[0]
+		if candidate.FinishReason != "" {
+			result.finishReason = candidate.FinishReason
+		}
+		for _, part := range candidate.Content.Parts {
+			result.content.Parts = appendPart(result.content.Parts, part)
+			if part.Text == "" {
+				continue
+			}
+			event := interfaces.StreamEvent{Type: interfaces.StreamEventContentDelta, Content: part.Text}
+			callback := onText
+			if part.Thought {
+				event.Type = interfaces.StreamEventThinking
+				callback = onThinking
+			}
+			if !callback(event) {
+				return nil, ctx.Err()
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read gemini stream: %w", err)
+	}
+	return result, nil
+}
+
+// appendPart appends a streamed part, merging text into the previous part of the same kind
+func appendPart(parts []Part, part Part) []Part {
+	if last := len(parts) - 1; last >= 0 && part.Text != "" && part.ThoughtSignature == "" &&
+		parts[last].FunctionCall == nil && parts[last].Text != "" && parts[last].Thought == part.Thought {
+		parts[last].Text += part.Text
+		return parts
+	}
+	return append(parts, part)
+}
+
+// responseText returns the text of a function response as shown in tool result events
+func responseText(response *FunctionResponse) string {
+	if response == nil {
+		return ""
+	}
+	if errText, ok := response.Response["error"].(string); ok {
+		return "Error: " + errText
+	}
+	result, _ := response.Response["result"].(string)
+	return result
+}
+
+// bufferSize returns the event channel buffer size from the stream config
+func bufferSize(params *interfaces.GenerateOptions) int {
+	if params.StreamConfig != nil && params.StreamConfig.BufferSize > 0 {
+		return params.StreamConfig.BufferSize
+	}
+	return interfaces.DefaultStreamConfig().BufferSize
+}
+
+// includeThinking reports whether thought summaries should be streamed
+func includeThinking(params *interfaces.GenerateOptions) bool {
+	if params.StreamConfig == nil {
+		return true
+	}
+	return params.StreamConfig.IncludeThinking
+}
+
+// streamer sends events until the context is cancelled
+type streamer struct {
+	ctx             context.Context
+	events          chan<- interfaces.StreamEvent
+	includeThinking bool
+}
+
+// send sends an event, returning false if the context was cancelled
+func (s *streamer) send(event interfaces.StreamEvent) bool {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	select {
+	case s.events <- event:
+		return true
+	case <-s.ctx.Done():
+		return false
+	}
+}
+
+// sendThinking sends a thinking event unless thinking is excluded from the stream
+func (s *streamer) sendThinking(event interfaces.StreamEvent) bool {
+	if !s.includeThinking {
+		return s.ctx.Err() == nil
+	}
+	return s.send(event)
+}
+
+// finish sends the completion events with the finish reason and token usage
+func (s *streamer) finish(finishReason string, usage *interfaces.TokenUsage) {
+	if !s.send(interfaces.StreamEvent{Type: interfaces.StreamEventContentComplete}) {
+		return
+	}
+	s.send(interfaces.StreamEvent{
+		Type: interfaces.StreamEventMessageStop,
+		Metadata: map[string]interface{}{
+			"stop_reason": finishReason,
+			"usage":       usage,
+		},
+	})
+}
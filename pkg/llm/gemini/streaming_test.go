@@ -0,0 +1,125 @@
+package gemini
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// newStreamClient returns a client whose server answers each request with the
+// next list of server-sent event chunks
+func newStreamClient(t *testing.T, streams ...[]string) *GeminiClient {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "sse", r.URL.Query().Get("alt"))
+		assert.True(t, strings.HasSuffix(r.URL.Path, ":streamGenerateContent"))
+		if len(streams) == 0 {
+			http.Error(w, "unexpected request", http.StatusInternalServerError)
+			return
+		}
+		chunks := streams[0]
+		streams = streams[1:]
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range chunks {
+			_, _ = fmt.Fprintf(w, "data: %s\r\n\r\n", chunk)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return NewClient("test-key", WithBaseURL(server.URL))
+}
+
+func collect(t *testing.T, events <-chan interfaces.StreamEvent) []interfaces.StreamEvent {
+	var all []interfaces.StreamEvent
+	for event := range events {
+		all = append(all, event)
+	}
+	require.NotEmpty(t, all)
+	return all
+}
+
+func eventTypes(events []interfaces.StreamEvent) []interfaces.StreamEventType {
+	types := make([]interfaces.StreamEventType, len(events))
+	for i, event := range events {
+		types[i] = event.Type
+	}
+	return types
+}
+
+func TestGenerateStream(t *testing.T) {
+	client := newStreamClient(t, []string{
+		`{"candidates": [{"content": {"role": "model", "parts": [{"text": "Hmm", "thought": true}]}}]}`,
+		`{"candidates": [{"content": {"role": "model", "parts": [{"text": "Hel"}]}}]}`,
+		`{"candidates": [{"content": {"role": "model", "parts": [{"text": "lo"}]}, "finishReason": "STOP"}], "usageMetadata": {"promptTokenCount": 4, "candidatesTokenCount": 2, "totalTokenCount": 6}}`,
+	})
+
+	events, err := client.GenerateStream(context.Background(), "Hi")
+	require.NoError(t, err)
+	all := collect(t, events)
+
+	assert.Equal(t, []interfaces.StreamEventType{
+		interfaces.StreamEventMessageStart,
+		interfaces.StreamEventThinking,
+		interfaces.StreamEventContentDelta,
+		interfaces.StreamEventContentDelta,
+		interfaces.StreamEventContentComplete,
+		interfaces.StreamEventMessageStop,
+	}, eventTypes(all))
+	assert.Equal(t, "Hmm", all[1].Content)
+	assert.Equal(t, "Hel", all[2].Content)
+
+	stop := all[len(all)-1]
+	assert.Equal(t, "STOP", stop.Metadata["stop_reason"])
+	assert.Equal(t, &interfaces.TokenUsage{InputTokens: 4, OutputTokens: 2, TotalTokens: 6}, stop.Metadata["usage"])
+}
+
+func TestGenerateWithToolsStream(t *testing.T) {
+	client := newStreamClient(t,
+		[]string{
+			`{"candidates": [{"content": {"role": "model", "parts": [{"text": "Calling echo."}]}}]}`,
+			`{"candidates": [{"content": {"role": "model", "parts": [{"functionCall": {"name": "echo", "args": {"message": "hi"}}}]}}]}`,
+		},
+		[]string{
+			`{"candidates": [{"content": {"role": "model", "parts": [{"text": "It said hi."}]}, "finishReason": "STOP"}]}`,
+		})
+
+	events, err := client.GenerateWithToolsStream(context.Background(), "Echo hi", []interfaces.Tool{echoTool{}})
+	require.NoError(t, err)
+	all := collect(t, events)
+
+	// Text of the tool iteration is not streamed by default
+	assert.Equal(t, []interfaces.StreamEventType{
+		interfaces.StreamEventMessageStart,
+		interfaces.StreamEventToolUse,
+		interfaces.StreamEventToolResult,
+		interfaces.StreamEventContentDelta,
+		interfaces.StreamEventContentComplete,
+		interfaces.StreamEventMessageStop,
+	}, eventTypes(all))
+
+	use, result := all[1], all[2]
+	assert.Equal(t, "echo", use.ToolCall.Name)
+	assert.JSONEq(t, `{"message": "hi"}`, use.ToolCall.Arguments)
+	assert.NotEmpty(t, use.ToolCall.ID)
+	assert.Equal(t, use.ToolCall.ID, result.ToolCall.ID)
+	assert.Equal(t, "echo: hi", result.Content)
+	assert.Equal(t, "It said hi.", all[3].Content)
+}
+
+func TestGenerateStream_Error(t *testing.T) {
+	client := newStreamClient(t)
+
+	events, err := client.GenerateStream(context.Background(), "Hi")
+	require.NoError(t, err)
+	all := collect(t, events)
+
+	last := all[len(all)-1]
+	assert.Equal(t, interfaces.StreamEventError, last.Type)
+	assert.ErrorContains(t, last.Error, "status 500")
+}
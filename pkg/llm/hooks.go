@@ -0,0 +1,24 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// BeforeCall runs the call hook of the options before a request to the
+// provider and returns its error
+func BeforeCall(ctx context.Context, params *interfaces.GenerateOptions) error {
+	if params == nil || params.CallHook == nil {
+		return nil
+	}
+	return params.CallHook.BeforeCall(ctx)
+}
+
+// AfterCall runs the call hook of the options after a response of the provider
+func AfterCall(ctx context.Context, params *interfaces.GenerateOptions, model string, usage *interfaces.TokenUsage) {
+	if params == nil || params.CallHook == nil {
+		return
+	}
+	params.CallHook.AfterCall(ctx, model, usage)
+}
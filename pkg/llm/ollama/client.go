@@ -46,7 +46,7 @@ func WithLogger(logger logging.Logger) Option {
 // WithRetry configures retry policy for the client
 func WithRetry(opts ...retry.Option) Option {
 	return func(c *OllamaClient) {
-		c.retryExecutor = retry.NewExecutor(retry.NewPolicy(opts...))
+		c.retryExecutor = retry.NewExecutor(llm.NewRetryPolicy(opts...))
 	}
 }
 
@@ -84,17 +84,17 @@ func NewClient(options ...Option) *OllamaClient {
 
 // Ollama API request/response structures
 type GenerateRequest struct {
-	Model     string   `json:"model"`
-	Prompt    string   `json:"prompt"`
-	Stream    bool     `json:"stream"`
-	Options   *Options `json:"options,omitempty"`
-	System    string   `json:"system,omitempty"`
-	Template  string   `json:"template,omitempty"`
-	Context   []int    `json:"context,omitempty"`
-	Format    string   `json:"format,omitempty"`
-	Raw       bool     `json:"raw,omitempty"`
-	KeepAlive string   `json:"keep_alive,omitempty"`
-	Images    []string `json:"images,omitempty"`
+	Model     string      `json:"model"`
+	Prompt    string      `json:"prompt"`
+	Stream    bool        `json:"stream"`
+	Options   *Options    `json:"options,omitempty"`
+	System    string      `json:"system,omitempty"`
+	Template  string      `json:"template,omitempty"`
+	Context   []int       `json:"context,omitempty"`
+	Format    interface{} `json:"format,omitempty"` // "json" or a JSON schema
+	Raw       bool        `json:"raw,omitempty"`
+	KeepAlive string      `json:"keep_alive,omitempty"`
+	Images    []string    `json:"images,omitempty"`
 }
 
 type Options struct {
@@ -126,13 +126,14 @@ type ChatRequest struct {
 	Messages  []ChatMessage `json:"messages"`
 	Stream    bool          `json:"stream"`
 	Options   *Options      `json:"options,omitempty"`
-	Format    string        `json:"format,omitempty"`
+	Format    interface{}   `json:"format,omitempty"` // "json" or a JSON schema
 	KeepAlive string        `json:"keep_alive,omitempty"`
 }
 
 type ChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role    string   `json:"role"`
+	Content string   `json:"content"`
+	Images  []string `json:"images,omitempty"`
 }
 
 type ChatResponse struct {
@@ -164,10 +165,17 @@ func (c *OllamaClient) Generate(ctx context.Context, prompt string, options ...i
 	// Build prompt with memory context
 	finalPrompt := c.buildPromptWithMemory(ctx, prompt, params)
 
+	// Attach the content parts of the current turn
+	finalPrompt, images, err := convertContentParts(c.Model, finalPrompt, llm.CurrentTurnParts(ctx, params))
+	if err != nil {
+		return "", err
+	}
+
 	// Create request
 	req := GenerateRequest{
 		Model:  c.Model,
 		Prompt: finalPrompt,
+		Images: images,
 		Stream: false,
 		Options: &Options{
 			Temperature: params.LLMConfig.Temperature,
@@ -198,7 +206,12 @@ Ensure your response is a valid JSON object that strictly follows the schema abo
 			string(schemaJSON))
 
 		req.Prompt = schemaPrompt
-		req.Format = "json"
+		// Constrain the output to the schema, or to any JSON without one
+		if len(params.ResponseFormat.Schema) > 0 {
+			req.Format = params.ResponseFormat.Schema
+		} else {
+			req.Format = "json"
+		}
 	}
 
 	// Make request
@@ -245,9 +258,14 @@ func (c *OllamaClient) Chat(ctx context.Context, messages []llm.Message, params
 	// Convert messages to Ollama format
 	var chatMessages []ChatMessage
 	for _, msg := range messages {
+		content, images, err := convertContentParts(c.Model, msg.Content, msg.Parts)
+		if err != nil {
+			return "", err
+		}
 		chatMessages = append(chatMessages, ChatMessage{
 			Role:    msg.Role,
-			Content: msg.Content,
+			Content: content,
+			Images:  images,
 		})
 	}
 
@@ -321,7 +339,7 @@ func (c *OllamaClient) makeRequest(ctx context.Context, endpoint string, payload
 	}
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+		return nil, fmt.Errorf("failed to execute request: %w", llm.WrapError("ollama", err))
 	}
 	defer func() {
 		if resp != nil {
@@ -337,7 +355,7 @@ func (c *OllamaClient) makeRequest(ctx context.Context, endpoint string, payload
 
 	// Check status code
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, llm.NewAPIError("ollama", resp.StatusCode, resp.Header, body)
 	}
 
 	return body, nil
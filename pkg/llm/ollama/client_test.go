@@ -118,6 +118,48 @@ func TestGenerateWithSystemMessage(t *testing.T) {
 	assert.Equal(t, "System message received", response)
 }
 
+func TestGenerateWithResponseFormat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		err := json.NewDecoder(r.Body).Decode(&req)
+		require.NoError(t, err)
+
+		// The schema is sent as the format to constrain the output
+		format, ok := req["format"].(map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, "object", format["type"])
+
+		response := GenerateResponse{
+			Model:    "test-model",
+			Response: `{"city": "Paris"}`,
+			Done:     true,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(response)
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	client := NewClient(
+		WithModel("test-model"),
+		WithBaseURL(server.URL),
+	)
+
+	response, err := client.Generate(
+		context.Background(),
+		"test prompt",
+		WithResponseFormat(interfaces.ResponseFormat{
+			Type:   interfaces.ResponseFormatJSON,
+			Name:   "City",
+			Schema: interfaces.JSONSchema{"type": "object"},
+		}),
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, `{"city": "Paris"}`, response)
+}
+
 func TestChat(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		assert.Equal(t, "POST", r.Method)
@@ -171,6 +213,40 @@ func TestChat(t *testing.T) {
 	assert.Equal(t, "Hello! How can I help you?", response)
 }
 
+func TestGenerateWithImages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req GenerateRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		assert.Equal(t, "User: What is shown?\nAnswer briefly.", req.Prompt)
+		assert.Equal(t, []string{"cG5n"}, req.Images)
+
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(GenerateResponse{Response: "A cat", Done: true}))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithModel("llava"), WithBaseURL(server.URL))
+
+	response, err := client.Generate(context.Background(), "What is shown?", interfaces.WithContentParts(
+		interfaces.ImagePart([]byte("png"), "image/png"),
+		interfaces.TextPart("Answer briefly."),
+	))
+	require.NoError(t, err)
+	assert.Equal(t, "A cat", response)
+
+	_, err = client.Generate(context.Background(), "What is shown?", interfaces.WithContentParts(
+		interfaces.ImageURLPart("https://example.com/cat.png"),
+	))
+	assert.ErrorIs(t, err, interfaces.ErrUnsupportedContent)
+
+	_, err = client.Chat(context.Background(), []llm.Message{{
+		Role:  "user",
+		Parts: []interfaces.ContentPart{interfaces.DocumentPart([]byte("%PDF"), "application/pdf", "")},
+	}}, &llm.GenerateParams{})
+	assert.ErrorIs(t, err, interfaces.ErrUnsupportedContent)
+}
+
 func TestGenerateWithTools(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var req GenerateRequest
@@ -298,7 +374,7 @@ func TestMakeRequestError(t *testing.T) {
 	_, err := client.Generate(context.Background(), "test prompt")
 
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "API request failed with status 500")
+	assert.Contains(t, err.Error(), "ollama API error (status 500)")
 }
 
 func TestName(t *testing.T) {
@@ -0,0 +1,35 @@
+package ollama
+
+import (
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// convertContentParts converts content parts to Ollama's prompt format. Text
+// parts are appended to the text and images are returned as base64 data.
+// Ollama only accepts inline images, other parts are rejected.
+func convertContentParts(model, text string, parts []interfaces.ContentPart) (string, []string, error) {
+	var images []string
+	for _, part := range parts {
+		if err := part.Validate(); err != nil {
+			return "", nil, err
+		}
+
+		switch part.Type {
+		case interfaces.ContentPartText:
+			if text != "" {
+				text += "\n"
+			}
+			text += part.Text
+		case interfaces.ContentPartImage:
+			if len(part.Data) == 0 {
+				return "", nil, &interfaces.UnsupportedContentError{
+					Provider: "ollama", Model: model, Type: part.Type, Reason: "images must be sent as inline data",
+				}
+			}
+			images = append(images, part.Base64())
+		default:
+			return "", nil, &interfaces.UnsupportedContentError{Provider: "ollama", Model: model, Type: part.Type}
+		}
+	}
+	return text, images, nil
+}
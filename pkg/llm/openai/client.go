@@ -88,7 +88,7 @@ func WithLogger(logger logging.Logger) Option {
 // WithRetry configures retry policy for the client
 func WithRetry(opts ...retry.Option) Option {
 	return func(c *OpenAIClient) {
-		c.retryExecutor = retry.NewExecutor(retry.NewPolicy(opts...))
+		c.retryExecutor = retry.NewExecutor(llm.NewRetryPolicy(opts...))
 	}
 }
 
@@ -162,8 +162,12 @@ func (c *OpenAIClient) generateInternal(ctx context.Context, prompt string, opti
 	}
 
 	// Build messages using unified builder
-	builder := newMessageHistoryBuilder(c.logger)
-	messages = append(messages, builder.buildMessages(ctx, prompt, params.Memory)...)
+	builder := newMessageHistoryBuilder(c.logger, c.Model)
+	historyMessages, err := builder.buildMessages(ctx, prompt, params.Memory, params.ContentParts...)
+	if err != nil {
+		return nil, err
+	}
+	messages = append(messages, historyMessages...)
 
 	// Create request
 	req := openai.ChatCompletionNewParams{
@@ -212,7 +216,6 @@ func (c *OpenAIClient) generateInternal(ctx context.Context, prompt string, opti
 	}
 
 	var resp *openai.ChatCompletion
-	var err error
 
 	operation := func() error {
 		var reasoningEffort string
@@ -240,7 +243,7 @@ func (c *OpenAIClient) generateInternal(ctx context.Context, prompt string, opti
 				"error": err.Error(),
 				"model": c.Model,
 			})
-			return fmt.Errorf("failed to generate text: %w", err)
+			return fmt.Errorf("failed to generate text: %w", convertError(err))
 		}
 		return nil
 	}
@@ -275,18 +278,7 @@ func (c *OpenAIClient) generateInternal(ctx context.Context, prompt string, opti
 		}
 
 		// Extract token usage if available
-		usage := &interfaces.TokenUsage{
-			InputTokens:  int(resp.Usage.PromptTokens),
-			OutputTokens: int(resp.Usage.CompletionTokens),
-			TotalTokens:  int(resp.Usage.TotalTokens),
-		}
-
-		// Add reasoning tokens if available (for o1 models)
-		if resp.Usage.CompletionTokensDetails.ReasoningTokens > 0 {
-			usage.ReasoningTokens = int(resp.Usage.CompletionTokensDetails.ReasoningTokens)
-		}
-
-		response.Usage = usage
+		response.Usage = completionUsage(resp)
 
 		return response, nil
 	}
@@ -294,6 +286,21 @@ func (c *OpenAIClient) generateInternal(ctx context.Context, prompt string, opti
 	return nil, fmt.Errorf("no response from OpenAI API")
 }
 
+// completionUsage returns the token usage of a chat completion
+func completionUsage(resp *openai.ChatCompletion) *interfaces.TokenUsage {
+	usage := &interfaces.TokenUsage{
+		InputTokens:  int(resp.Usage.PromptTokens),
+		OutputTokens: int(resp.Usage.CompletionTokens),
+		TotalTokens:  int(resp.Usage.TotalTokens),
+	}
+
+	// Add reasoning tokens if available (for o1 models)
+	if resp.Usage.CompletionTokensDetails.ReasoningTokens > 0 {
+		usage.ReasoningTokens = int(resp.Usage.CompletionTokensDetails.ReasoningTokens)
+	}
+	return usage
+}
+
 // Chat uses the ChatCompletion API to have a conversation (messages) with a model
 func (c *OpenAIClient) Chat(ctx context.Context, messages []llm.Message, params *llm.GenerateParams) (string, error) {
 	if params == nil {
@@ -307,7 +314,11 @@ func (c *OpenAIClient) Chat(ctx context.Context, messages []llm.Message, params
 		case "system":
 			chatMessages[i] = openai.SystemMessage(msg.Content)
 		case "user":
-			chatMessages[i] = openai.UserMessage(msg.Content)
+			userMsg, err := convertUserMessage(c.Model, msg.Content, msg.Parts)
+			if err != nil {
+				return "", err
+			}
+			chatMessages[i] = userMsg
 		case "assistant":
 			chatMessages[i] = openai.AssistantMessage(msg.Content)
 		case "tool":
@@ -365,7 +376,7 @@ func (c *OpenAIClient) Chat(ctx context.Context, messages []llm.Message, params
 				"error": err.Error(),
 				"model": c.Model,
 			})
-			return fmt.Errorf("failed to create chat completion: %w", err)
+			return fmt.Errorf("failed to create chat completion: %w", convertError(err))
 		}
 		return nil
 	}
@@ -430,48 +441,19 @@ func (c *OpenAIClient) GenerateWithTools(ctx context.Context, prompt string, too
 	// Convert tools to OpenAI format
 	openaiTools := make([]openai.ChatCompletionToolUnionParam, len(tools))
 	for i, tool := range tools {
-		// Convert ParameterSpec to JSON Schema
-		properties := make(map[string]interface{})
-		required := []string{}
-
-		for name, param := range tool.Parameters() {
-			properties[name] = map[string]interface{}{
-				"type":        param.Type,
-				"description": param.Description,
-			}
-			if param.Default != nil {
-				properties[name].(map[string]interface{})["default"] = param.Default
-			}
-			if param.Required {
-				required = append(required, name)
-			}
-			if param.Items != nil {
-				properties[name].(map[string]interface{})["items"] = map[string]interface{}{
-					"type": param.Items.Type,
-				}
-				if param.Items.Enum != nil {
-					properties[name].(map[string]interface{})["items"].(map[string]interface{})["enum"] = param.Items.Enum
-				}
-			}
-			if param.Enum != nil {
-				properties[name].(map[string]interface{})["enum"] = param.Enum
-			}
-		}
-
 		openaiTools[i] = openai.ChatCompletionFunctionTool(shared.FunctionDefinitionParam{
 			Name:        tool.Name(),
 			Description: openai.String(tool.Description()),
-			Parameters: map[string]interface{}{
-				"type":       "object",
-				"properties": properties,
-				"required":   required,
-			},
+			Parameters:  llm.ToolSchema(tool),
 		})
 	}
 
 	// Build messages with memory and current prompt
-	builder := newMessageHistoryBuilder(c.logger)
-	messages := builder.buildMessages(ctx, prompt, params.Memory)
+	builder := newMessageHistoryBuilder(c.logger, c.Model)
+	messages, err := builder.buildMessages(ctx, prompt, params.Memory, params.ContentParts...)
+	if err != nil {
+		return "", err
+	}
 
 	// Track tool call repetitions for loop detection
 	toolCallHistory := make(map[string]int)
@@ -557,11 +539,15 @@ func (c *OpenAIClient) GenerateWithTools(ctx context.Context, prompt string, too
 			"iteration":         iteration + 1,
 			"maxIterations":     maxIterations,
 		})
+		if err := llm.BeforeCall(ctx, params); err != nil {
+			return "", err
+		}
 		resp, err := c.ChatService.Completions.New(ctx, req)
 		if err != nil {
 			c.logger.Error(ctx, "Error from OpenAI API", map[string]interface{}{"error": err.Error()})
-			return "", fmt.Errorf("failed to create chat completion: %w", err)
+			return "", fmt.Errorf("failed to create chat completion: %w", convertError(err))
 		}
+		llm.AfterCall(ctx, params, string(resp.Model), completionUsage(resp))
 
 		if len(resp.Choices) == 0 {
 			return "", fmt.Errorf("no completions returned")
@@ -921,11 +907,15 @@ func (c *OpenAIClient) GenerateWithTools(ctx context.Context, prompt string, too
 		"messages": len(finalReq.Messages),
 	})
 
+	if err := llm.BeforeCall(ctx, params); err != nil {
+		return "", err
+	}
 	finalResp, err := c.ChatService.Completions.New(ctx, finalReq)
 	if err != nil {
 		c.logger.Error(ctx, "Error in final call without tools", map[string]interface{}{"error": err.Error()})
-		return "", fmt.Errorf("failed to create final chat completion: %w", err)
+		return "", fmt.Errorf("failed to create final chat completion: %w", convertError(err))
 	}
+	llm.AfterCall(ctx, params, string(finalResp.Model), completionUsage(finalResp))
 
 	if len(finalResp.Choices) == 0 {
 		return "", fmt.Errorf("no completions returned in final call")
@@ -2,6 +2,9 @@ package openai
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/tagus/agent-sdk-go/pkg/interfaces"
@@ -10,7 +13,7 @@ import (
 
 func TestMessageHistoryBuilder_BuildMessages(t *testing.T) {
 	logger := logging.New()
-	builder := newMessageHistoryBuilder(logger)
+	builder := newMessageHistoryBuilder(logger, "gpt-4o")
 
 	tests := []struct {
 		name     string
@@ -65,7 +68,10 @@ func TestMessageHistoryBuilder_BuildMessages(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			messages := builder.buildMessages(context.Background(), tt.prompt, tt.memory)
+			messages, err := builder.buildMessages(context.Background(), tt.prompt, tt.memory)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
 			if len(messages) != tt.expected {
 				t.Errorf("Expected %d messages, got %d", tt.expected, len(messages))
 			}
@@ -73,6 +79,70 @@ func TestMessageHistoryBuilder_BuildMessages(t *testing.T) {
 	}
 }
 
+func TestMessageHistoryBuilder_ContentParts(t *testing.T) {
+	builder := newMessageHistoryBuilder(logging.New(), "gpt-4o")
+	image := interfaces.ImagePart([]byte("png"), "image/png")
+
+	messages, err := builder.buildMessages(context.Background(), "Describe this", nil, image)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, _ := json.Marshal(messages[0])
+	want := `{"content":[{"text":"Describe this","type":"text"},{"image_url":{"url":"data:image/png;base64,cG5n"},"type":"image_url"}],"role":"user"}`
+	if string(data) != want {
+		t.Errorf("Expected %s, got %s", want, data)
+	}
+
+	// With memory the parts are attached to the last user message
+	memory := &mockMemory{messages: []interfaces.Message{
+		{Role: interfaces.MessageRoleUser, Content: "Hi"},
+		{Role: interfaces.MessageRoleAssistant, Content: "Hello!"},
+		{Role: interfaces.MessageRoleUser, Content: "Read this", Parts: []interfaces.ContentPart{
+			interfaces.DocumentPart([]byte("%PDF"), "application/pdf", "report.pdf"),
+		}},
+	}}
+	messages, err = builder.buildMessages(context.Background(), "Read this", memory, interfaces.ImageURLPart("https://example.com/chart.png"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, _ = json.Marshal(messages[2])
+	for _, fragment := range []string{`"file_data":"data:application/pdf;base64,JVBERg=="`, `"filename":"report.pdf"`, `"url":"https://example.com/chart.png"`} {
+		if !strings.Contains(string(data), fragment) {
+			t.Errorf("Expected %s in %s", fragment, data)
+		}
+	}
+	if len(memory.messages[2].Parts) != 1 {
+		t.Errorf("Memory messages must not be modified")
+	}
+}
+
+func TestMessageHistoryBuilder_UnsupportedContent(t *testing.T) {
+	tests := []struct {
+		name  string
+		model string
+		part  interfaces.ContentPart
+	}{
+		{name: "image on text-only model", model: "gpt-3.5-turbo", part: interfaces.ImageURLPart("https://example.com/a.png")},
+		{name: "audio on non-audio model", model: "gpt-4o", part: interfaces.AudioPart([]byte("wav"), "audio/wav")},
+		{name: "audio by URL", model: "gpt-4o-audio-preview", part: interfaces.ContentPart{Type: interfaces.ContentPartAudio, URL: "https://example.com/a.wav"}},
+		{name: "document by URL", model: "gpt-4o", part: interfaces.DocumentURLPart("https://example.com/a.pdf")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := newMessageHistoryBuilder(logging.New(), tt.model)
+			_, err := builder.buildMessages(context.Background(), "Hi", nil, tt.part)
+			var contentErr *interfaces.UnsupportedContentError
+			if !errors.As(err, &contentErr) || !errors.Is(err, interfaces.ErrUnsupportedContent) {
+				t.Fatalf("Expected unsupported content error, got %v", err)
+			}
+			if contentErr.Type != tt.part.Type {
+				t.Errorf("Expected type %s, got %s", tt.part.Type, contentErr.Type)
+			}
+		})
+	}
+}
+
 // mockMemory is a simple mock implementation for testing
 type mockMemory struct {
 	messages []interfaces.Message
@@ -0,0 +1,316 @@
+package openai2
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openai/openai-go/v2"
+	"github.com/openai/openai-go/v2/responses"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/llm"
+)
+
+// defaultBatchPollInterval is the default interval between batch status checks
+const defaultBatchPollInterval = 30 * time.Second
+
+// batchCancelTimeout bounds the request that cancels the batch of a done context
+const batchCancelTimeout = 30 * time.Second
+
+// WithBatchPollInterval sets the interval between status checks of the
+// batches of GenerateBatch
+func WithBatchPollInterval(interval time.Duration) Option {
+	return func(c *OpenAIClient) {
+		if interval > 0 {
+			c.batchPollInterval = interval
+		}
+	}
+}
+
+// BatchError is returned by GenerateBatch when some prompts of a completed
+// batch failed
+type BatchError struct {
+	// BatchID is the ID of the batch
+	BatchID string
+
+	// Errors maps the index of each failed prompt to its error
+	Errors map[int]error
+}
+
+func (e *BatchError) Error() string {
+	indexes := make([]int, 0, len(e.Errors))
+	for index := range e.Errors {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	return fmt.Sprintf("%d prompts of batch %s failed, first at index %d: %v",
+		len(indexes), e.BatchID, indexes[0], e.Errors[indexes[0]])
+}
+
+// batchRequest is a line of the input file of a batch
+type batchRequest struct {
+	CustomID string      `json:"custom_id"`
+	Method   string      `json:"method"`
+	URL      string      `json:"url"`
+	Body     interface{} `json:"body"`
+}
+
+// batchResult is a line of the output or error file of a batch
+type batchResult struct {
+	CustomID string `json:"custom_id"`
+	Response *struct {
+		StatusCode int             `json:"status_code"`
+		Body       json.RawMessage `json:"body"`
+	} `json:"response"`
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// GenerateBatch generates the responses of many prompts with the Batch API,
+// which costs less than individual requests but completes within 24 hours.
+// The prompts are uploaded as a JSONL file of chat completion requests, or of
+// Responses API requests with WithResponsesAPI, all with the same options.
+// GenerateBatch then checks the status of the batch every 30 seconds, or as
+// set with WithBatchPollInterval, until it ends or ctx is done. The batch is
+// cancelled when ctx is done, so that it stops running and being billed.
+//
+// The responses are returned in the order of the prompts. When some prompts
+// failed, their responses are nil and a *BatchError is returned with the
+// other responses.
+func (c *OpenAIClient) GenerateBatch(ctx context.Context, prompts []string, options ...interfaces.GenerateOption) ([]*interfaces.LLMResponse, error) {
+	if len(prompts) == 0 {
+		return nil, nil
+	}
+
+	params := &interfaces.GenerateOptions{
+		LLMConfig: &interfaces.LLMConfig{
+			Temperature: 0.7,
+		},
+	}
+	for _, option := range options {
+		option(params)
+	}
+
+	endpoint := openai.BatchNewParamsEndpointV1ChatCompletions
+	if c.responsesAPI {
+		endpoint = openai.BatchNewParamsEndpointV1Responses
+	}
+
+	// Build the input file
+	var input bytes.Buffer
+	encoder := json.NewEncoder(&input)
+	for i, prompt := range prompts {
+		var body interface{}
+		var err error
+		if c.responsesAPI {
+			// The prompts of a batch are independent of each other and of the
+			// conversation, so they never continue from a previous response
+			body, err = c.buildResponseRequestFrom(ctx, prompt, params, false, "")
+		} else {
+			body, err = c.buildChatRequest(ctx, prompt, params)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to build request for prompt %d: %w", i, err)
+		}
+
+		if err := encoder.Encode(batchRequest{
+			CustomID: strconv.Itoa(i),
+			Method:   http.MethodPost,
+			URL:      string(endpoint),
+			Body:     body,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to encode request for prompt %d: %w", i, err)
+		}
+	}
+
+	file, err := c.Client.Files.New(ctx, openai.FileNewParams{
+		File:    openai.File(&input, "batch.jsonl", "application/jsonl"),
+		Purpose: openai.FilePurposeBatch,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload batch input: %w", convertError(err))
+	}
+
+	batch, err := c.Client.Batches.New(ctx, openai.BatchNewParams{
+		InputFileID:      file.ID,
+		Endpoint:         endpoint,
+		CompletionWindow: openai.BatchNewParamsCompletionWindow24h,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create batch: %w", convertError(err))
+	}
+
+	c.logger.Info(ctx, "Created OpenAI batch", map[string]interface{}{
+		"batch_id": batch.ID,
+		"prompts":  len(prompts),
+		"endpoint": endpoint,
+	})
+
+	batch, err = c.waitForBatch(ctx, batch)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]batchResult, len(prompts))
+	for _, fileID := range []string{batch.OutputFileID, batch.ErrorFileID} {
+		if fileID == "" {
+			continue
+		}
+		if err := c.readBatchResults(ctx, fileID, results); err != nil {
+			return nil, fmt.Errorf("failed to read results of batch %s: %w", batch.ID, err)
+		}
+	}
+
+	generated := make([]*interfaces.LLMResponse, len(prompts))
+	failures := make(map[int]error)
+	for i := range prompts {
+		result, ok := results[strconv.Itoa(i)]
+		if !ok {
+			failures[i] = fmt.Errorf("no result in batch with status %s", batch.Status)
+			continue
+		}
+		generated[i], err = c.convertBatchResult(result)
+		if err != nil {
+			failures[i] = err
+		}
+	}
+
+	if len(failures) > 0 {
+		return generated, &BatchError{BatchID: batch.ID, Errors: failures}
+	}
+	return generated, nil
+}
+
+// waitForBatch checks the status of a batch until it ends. Expired and
+// cancelled batches are returned, as their output holds the requests that
+// completed in time.
+func (c *OpenAIClient) waitForBatch(ctx context.Context, batch *openai.Batch) (*openai.Batch, error) {
+	ticker := time.NewTicker(c.batchPollInterval)
+	defer ticker.Stop()
+
+	for {
+		switch batch.Status {
+		case openai.BatchStatusCompleted, openai.BatchStatusExpired, openai.BatchStatusCancelled:
+			c.logger.Info(ctx, "OpenAI batch ended", map[string]interface{}{
+				"batch_id":  batch.ID,
+				"status":    batch.Status,
+				"completed": batch.RequestCounts.Completed,
+				"failed":    batch.RequestCounts.Failed,
+			})
+			return batch, nil
+		case openai.BatchStatusFailed:
+			messages := make([]string, len(batch.Errors.Data))
+			for i, batchErr := range batch.Errors.Data {
+				messages[i] = batchErr.Message
+			}
+			return nil, fmt.Errorf("batch %s failed: %s", batch.ID, strings.Join(messages, "; "))
+		}
+
+		select {
+		case <-ctx.Done():
+			c.cancelBatch(ctx, batch.ID)
+			return nil, fmt.Errorf("stopped waiting for batch %s: %w", batch.ID, ctx.Err())
+		case <-ticker.C:
+		}
+
+		current, err := c.Client.Batches.Get(ctx, batch.ID)
+		if err != nil {
+			if ctx.Err() != nil {
+				c.cancelBatch(ctx, batch.ID)
+				return nil, fmt.Errorf("stopped waiting for batch %s: %w", batch.ID, ctx.Err())
+			}
+			return nil, fmt.Errorf("failed to get status of batch %s: %w", batch.ID, convertError(err))
+		}
+		batch = current
+
+		c.logger.Debug(ctx, "Checked OpenAI batch status", map[string]interface{}{
+			"batch_id":  batch.ID,
+			"status":    batch.Status,
+			"completed": batch.RequestCounts.Completed,
+			"total":     batch.RequestCounts.Total,
+		})
+	}
+}
+
+// cancelBatch cancels a batch that is no longer waited for. The request is
+// sent with a context detached from the done ctx.
+func (c *OpenAIClient) cancelBatch(ctx context.Context, batchID string) {
+	cancelCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), batchCancelTimeout)
+	defer cancel()
+
+	if _, err := c.Client.Batches.Cancel(cancelCtx, batchID); err != nil {
+		c.logger.Warn(ctx, "Failed to cancel OpenAI batch", map[string]interface{}{
+			"batch_id": batchID,
+			"error":    convertError(err).Error(),
+		})
+		return
+	}
+	c.logger.Info(ctx, "Cancelled OpenAI batch", map[string]interface{}{
+		"batch_id": batchID,
+	})
+}
+
+// readBatchResults reads the results of a batch output or error file
+func (c *OpenAIClient) readBatchResults(ctx context.Context, fileID string, results map[string]batchResult) error {
+	resp, err := c.Client.Files.Content(ctx, fileID)
+	if err != nil {
+		return convertError(err)
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var result batchResult
+		if err := json.Unmarshal(line, &result); err != nil {
+			return fmt.Errorf("invalid result line: %w", err)
+		}
+		results[result.CustomID] = result
+	}
+	return scanner.Err()
+}
+
+// convertBatchResult converts the result of a batch request to a response
+func (c *OpenAIClient) convertBatchResult(result batchResult) (*interfaces.LLMResponse, error) {
+	if result.Error != nil {
+		return nil, fmt.Errorf("%s: %s", result.Error.Code, result.Error.Message)
+	}
+	if result.Response == nil {
+		return nil, fmt.Errorf("empty result")
+	}
+	if result.Response.StatusCode != http.StatusOK {
+		return nil, llm.NewAPIError("openai", result.Response.StatusCode, nil, result.Response.Body)
+	}
+
+	if c.responsesAPI {
+		var resp responses.Response
+		if err := json.Unmarshal(result.Response.Body, &resp); err != nil {
+			return nil, fmt.Errorf("invalid response: %w", err)
+		}
+		return convertResponse(&resp), nil
+	}
+
+	var completion openai.ChatCompletion
+	if err := json.Unmarshal(result.Response.Body, &completion); err != nil {
+		return nil, fmt.Errorf("invalid chat completion: %w", err)
+	}
+	if len(completion.Choices) == 0 {
+		return nil, fmt.Errorf("no choices in chat completion")
+	}
+	return chatCompletionResponse(&completion), nil
+}
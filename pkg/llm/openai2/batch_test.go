@@ -0,0 +1,256 @@
+package openai2
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// batchServer is an httptest stand-in for the Files and Batch APIs. The batch
+// is in progress on the first status check and ends with status on the next.
+type batchServer struct {
+	*httptest.Server
+	mu        sync.Mutex
+	input     []map[string]interface{}
+	checks    int
+	cancelled bool
+}
+
+func newBatchServer(t *testing.T, status, output, errorFile string) *batchServer {
+	t.Helper()
+
+	s := &batchServer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/files", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("failed to parse upload: %v", err)
+		}
+		if purpose := r.FormValue("purpose"); purpose != "batch" {
+			t.Errorf("expected batch purpose, got %q", purpose)
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("missing uploaded file: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		s.mu.Lock()
+		for scanner.Scan() {
+			var line map[string]interface{}
+			if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+				t.Errorf("invalid input line: %v", err)
+			}
+			s.input = append(s.input, line)
+		}
+		s.mu.Unlock()
+
+		writeJSON(w, `{"id":"file-in","object":"file","purpose":"batch","filename":"batch.jsonl","bytes":1,"created_at":1,"status":"processed"}`)
+	})
+	mux.HandleFunc("/batches", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["input_file_id"] != "file-in" || body["completion_window"] != "24h" {
+			t.Errorf("unexpected batch request: %v", body)
+		}
+		writeJSON(w, `{"id":"batch-1","object":"batch","endpoint":"`+body["endpoint"].(string)+`","input_file_id":"file-in","completion_window":"24h","status":"validating","created_at":1}`)
+	})
+	mux.HandleFunc("/batches/batch-1", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.checks++
+		current := "in_progress"
+		if s.checks > 1 {
+			current = status
+		}
+		s.mu.Unlock()
+
+		writeJSON(w, `{"id":"batch-1","object":"batch","endpoint":"/v1/chat/completions","input_file_id":"file-in","completion_window":"24h","created_at":1,`+
+			`"status":"`+current+`","output_file_id":"file-out","error_file_id":"file-err",`+
+			`"errors":{"object":"list","data":[{"code":"invalid_request","message":"input is invalid"}]}}`)
+	})
+	mux.HandleFunc("/batches/batch-1/cancel", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.cancelled = true
+		s.mu.Unlock()
+
+		writeJSON(w, `{"id":"batch-1","object":"batch","endpoint":"/v1/chat/completions","input_file_id":"file-in","completion_window":"24h","created_at":1,"status":"cancelling"}`)
+	})
+	mux.HandleFunc("/files/file-out/content", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, output)
+	})
+	mux.HandleFunc("/files/file-err/content", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, errorFile)
+	})
+
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func writeJSON(w http.ResponseWriter, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = io.WriteString(w, body)
+}
+
+func chatResult(customID, content string) string {
+	return `{"id":"req-` + customID + `","custom_id":"` + customID + `","response":{"status_code":200,"body":` +
+		`{"id":"chatcmpl-` + customID + `","object":"chat.completion","created":1,"model":"gpt-4o-mini",` +
+		`"choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"` + content + `"}}],` +
+		`"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7}}}}` + "\n"
+}
+
+func TestGenerateBatch(t *testing.T) {
+	// Results come back in any order
+	output := chatResult("1", "Two") + chatResult("0", "One")
+	server := newBatchServer(t, "completed", output, "")
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithBatchPollInterval(time.Millisecond))
+
+	responses, err := client.GenerateBatch(context.Background(), []string{"First", "Second"}, WithSystemMessage("Be brief"))
+	if err != nil {
+		t.Fatalf("GenerateBatch returned error: %v", err)
+	}
+
+	if len(responses) != 2 || responses[0].Content != "One" || responses[1].Content != "Two" {
+		t.Fatalf("unexpected responses: %+v", responses)
+	}
+	if responses[0].Usage == nil || responses[0].Usage.TotalTokens != 7 {
+		t.Errorf("expected usage of the response, got %+v", responses[0].Usage)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.checks != 2 {
+		t.Errorf("expected 2 status checks, got %d", server.checks)
+	}
+	if len(server.input) != 2 {
+		t.Fatalf("expected 2 input lines, got %d", len(server.input))
+	}
+	line := server.input[1]
+	if line["custom_id"] != "1" || line["method"] != "POST" || line["url"] != "/v1/chat/completions" {
+		t.Errorf("unexpected input line: %v", line)
+	}
+	body, _ := json.Marshal(line["body"])
+	if !strings.Contains(string(body), "Second") || !strings.Contains(string(body), "Be brief") {
+		t.Errorf("expected the prompt and system message in the request body, got %s", body)
+	}
+}
+
+func TestGenerateBatchWithResponsesAPI(t *testing.T) {
+	output := `{"custom_id":"0","response":{"status_code":200,"body":` + responseBody("resp_1", messageOutput("Batched")) + `}}` + "\n"
+	server := newBatchServer(t, "completed", output, "")
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithResponsesAPI(), WithBatchPollInterval(time.Millisecond))
+
+	responses, err := client.GenerateBatch(context.Background(), []string{"Prompt"})
+	if err != nil {
+		t.Fatalf("GenerateBatch returned error: %v", err)
+	}
+	if responses[0].Content != "Batched" || responses[0].Metadata["response_id"] != "resp_1" {
+		t.Errorf("unexpected response: %+v", responses[0])
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.input[0]["url"] != "/v1/responses" {
+		t.Errorf("expected Responses API requests, got %v", server.input[0]["url"])
+	}
+}
+
+func TestGenerateBatchWithoutPreviousResponse(t *testing.T) {
+	output := `{"custom_id":"0","response":{"status_code":200,"body":` + responseBody("resp_2", messageOutput("Batched")) + `}}` + "\n"
+	server := newBatchServer(t, "completed", output, "")
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithResponsesAPI(), WithServerSideState(), WithBatchPollInterval(time.Millisecond))
+
+	ctx := WithPreviousResponseID(context.Background(), "resp_1")
+	if _, err := client.GenerateBatch(ctx, []string{"Prompt"}, WithSystemMessage("Be brief")); err != nil {
+		t.Fatalf("GenerateBatch returned error: %v", err)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	body, _ := json.Marshal(server.input[0]["body"])
+	if strings.Contains(string(body), "previous_response_id") {
+		t.Errorf("expected no previous response in batch requests, got %s", body)
+	}
+	if !strings.Contains(string(body), "Prompt") || !strings.Contains(string(body), "Be brief") {
+		t.Errorf("expected the prompt and system message in the request body, got %s", body)
+	}
+}
+
+func TestGenerateBatchPartialFailure(t *testing.T) {
+	output := chatResult("0", "One") +
+		`{"custom_id":"1","response":{"status_code":400,"body":{"error":{"message":"bad prompt","code":"invalid_prompt"}}}}` + "\n"
+	errorFile := `{"custom_id":"2","response":null,"error":{"code":"batch_expired","message":"request expired"}}` + "\n"
+	server := newBatchServer(t, "expired", output, errorFile)
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithBatchPollInterval(time.Millisecond))
+
+	responses, err := client.GenerateBatch(context.Background(), []string{"a", "b", "c", "d"})
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("expected a BatchError, got %v", err)
+	}
+	if batchErr.BatchID != "batch-1" || len(batchErr.Errors) != 3 {
+		t.Fatalf("unexpected batch error: %+v", batchErr)
+	}
+	if !strings.Contains(batchErr.Errors[1].Error(), "bad prompt") {
+		t.Errorf("expected API error for prompt 1, got %v", batchErr.Errors[1])
+	}
+	if !strings.Contains(batchErr.Errors[2].Error(), "request expired") {
+		t.Errorf("expected expiry error for prompt 2, got %v", batchErr.Errors[2])
+	}
+	if batchErr.Errors[3] == nil {
+		t.Errorf("expected missing result error for prompt 3")
+	}
+
+	if responses[0] == nil || responses[0].Content != "One" {
+		t.Errorf("expected the completed response, got %+v", responses[0])
+	}
+	if responses[1] != nil || responses[2] != nil || responses[3] != nil {
+		t.Errorf("expected nil responses for failed prompts")
+	}
+}
+
+func TestGenerateBatchFailed(t *testing.T) {
+	server := newBatchServer(t, "failed", "", "")
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithBatchPollInterval(time.Millisecond))
+
+	_, err := client.GenerateBatch(context.Background(), []string{"a"})
+	if err == nil || !strings.Contains(err.Error(), "input is invalid") {
+		t.Fatalf("expected batch failure, got %v", err)
+	}
+}
+
+func TestGenerateBatchCancelledContext(t *testing.T) {
+	server := newBatchServer(t, "in_progress", "", "")
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithBatchPollInterval(time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.GenerateBatch(ctx, []string{"a"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if !server.cancelled {
+		t.Fatal("expected the batch to be cancelled")
+	}
+}
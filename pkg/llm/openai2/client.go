@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/openai/openai-go/v2"
 	"github.com/openai/openai-go/v2/option"
@@ -34,6 +35,15 @@ type OpenAIClient struct {
 	baseURL         string
 	logger          logging.Logger
 	retryExecutor   *retry.Executor
+
+	// Responses API mode, see responses.go
+	responsesAPI    bool
+	serverSideState bool
+	builtinTools    []responses.ToolUnionParam
+	responseIDs     *responseIDCache
+
+	// batchPollInterval is the interval between batch status checks
+	batchPollInterval time.Duration
 }
 
 // Option represents an option for configuring the OpenAI client
@@ -89,7 +99,7 @@ func WithLogger(logger logging.Logger) Option {
 // WithRetry configures retry policy for the client
 func WithRetry(opts ...retry.Option) Option {
 	return func(c *OpenAIClient) {
-		c.retryExecutor = retry.NewExecutor(retry.NewPolicy(opts...))
+		c.retryExecutor = retry.NewExecutor(llm.NewRetryPolicy(opts...))
 	}
 }
 
@@ -110,13 +120,14 @@ func NewClient(apiKey string, options ...Option) *OpenAIClient {
 
 	// Create client with default options
 	client := &OpenAIClient{
-		Client:          openaiClient,
-		ChatService:     openai.NewChatService(option.WithAPIKey(apiKey), option.WithBaseURL("https://api.openai.com/v1")),
-		ResponseService: openaiClient.Responses,
-		Model:           "gpt-4o-mini",
-		apiKey:          apiKey,
-		baseURL:         "https://api.openai.com/v1",
-		logger:          logging.New(),
+		Client:            openaiClient,
+		ChatService:       openai.NewChatService(option.WithAPIKey(apiKey), option.WithBaseURL("https://api.openai.com/v1")),
+		ResponseService:   openaiClient.Responses,
+		Model:             "gpt-4o-mini",
+		apiKey:            apiKey,
+		baseURL:           "https://api.openai.com/v1",
+		logger:            logging.New(),
+		batchPollInterval: defaultBatchPollInterval,
 	}
 
 	// Apply options
@@ -149,6 +160,73 @@ func (c *OpenAIClient) generateInternal(ctx context.Context, prompt string, opti
 		option(params)
 	}
 
+	if c.responsesAPI {
+		return c.generateResponse(ctx, prompt, params)
+	}
+
+	req, err := c.buildChatRequest(ctx, prompt, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp *openai.ChatCompletion
+
+	operation := func() error {
+		var reasoningEffort string
+		if params.LLMConfig != nil && params.LLMConfig.Reasoning != "" {
+			reasoningEffort = params.LLMConfig.Reasoning
+		} else {
+			reasoningEffort = "none"
+		}
+
+		c.logger.Debug(ctx, "Executing OpenAI API request", map[string]interface{}{
+			"model":             c.Model,
+			"temperature":       req.Temperature,
+			"top_p":             req.TopP,
+			"frequency_penalty": req.FrequencyPenalty,
+			"presence_penalty":  req.PresencePenalty,
+			"stop_sequences":    req.Stop,
+			"messages":          len(req.Messages),
+			"response_format":   params.ResponseFormat != nil,
+			"reasoning_effort":  reasoningEffort,
+		})
+
+		resp, err = c.ChatService.Completions.New(ctx, req)
+		if err != nil {
+			c.logger.Error(ctx, "Error from OpenAI API", map[string]interface{}{
+				"error": err.Error(),
+				"model": c.Model,
+			})
+			return fmt.Errorf("failed to generate text: %w", convertError(err))
+		}
+		return nil
+	}
+
+	if c.retryExecutor != nil {
+		c.logger.Debug(ctx, "Using retry mechanism for OpenAI request", map[string]interface{}{
+			"model": c.Model,
+		})
+		err = c.retryExecutor.Execute(ctx, operation)
+	} else {
+		err = operation()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no response from OpenAI API")
+	}
+
+	c.logger.Debug(ctx, "Successfully received response from OpenAI", map[string]interface{}{
+		"model": c.Model,
+	})
+	return chatCompletionResponse(resp), nil
+}
+
+// buildChatRequest builds the chat completion request of a prompt
+func (c *OpenAIClient) buildChatRequest(ctx context.Context, prompt string, params *interfaces.GenerateOptions) (openai.ChatCompletionNewParams, error) {
 	// Get organization ID from context if available
 	orgID, _ := multitenancy.GetOrgID(ctx)
 	if orgID != "" {
@@ -165,8 +243,12 @@ func (c *OpenAIClient) generateInternal(ctx context.Context, prompt string, opti
 	}
 
 	// Build messages using unified builder
-	builder := newMessageHistoryBuilder(c.logger)
-	messages = append(messages, builder.buildMessages(ctx, prompt, params.Memory)...)
+	builder := newMessageHistoryBuilder(c.logger, c.Model)
+	historyMessages, err := builder.buildMessages(ctx, prompt, params.Memory, params.ContentParts...)
+	if err != nil {
+		return openai.ChatCompletionNewParams{}, err
+	}
+	messages = append(messages, historyMessages...)
 
 	// Create request
 	req := openai.ChatCompletionNewParams{
@@ -214,87 +296,39 @@ func (c *OpenAIClient) generateInternal(ctx context.Context, prompt string, opti
 		req.User = openai.String(orgID)
 	}
 
-	var resp *openai.ChatCompletion
-	var err error
-
-	operation := func() error {
-		var reasoningEffort string
-		if params.LLMConfig != nil && params.LLMConfig.Reasoning != "" {
-			reasoningEffort = params.LLMConfig.Reasoning
-		} else {
-			reasoningEffort = "none"
-		}
-
-		c.logger.Debug(ctx, "Executing OpenAI API request", map[string]interface{}{
-			"model":             c.Model,
-			"temperature":       req.Temperature,
-			"top_p":             req.TopP,
-			"frequency_penalty": req.FrequencyPenalty,
-			"presence_penalty":  req.PresencePenalty,
-			"stop_sequences":    req.Stop,
-			"messages":          len(req.Messages),
-			"response_format":   params.ResponseFormat != nil,
-			"reasoning_effort":  reasoningEffort,
-		})
+	return req, nil
+}
 
-		resp, err = c.ChatService.Completions.New(ctx, req)
-		if err != nil {
-			c.logger.Error(ctx, "Error from OpenAI API", map[string]interface{}{
-				"error": err.Error(),
-				"model": c.Model,
-			})
-			return fmt.Errorf("failed to generate text: %w", err)
-		}
-		return nil
+// chatCompletionResponse converts a chat completion to a detailed response
+func chatCompletionResponse(resp *openai.ChatCompletion) *interfaces.LLMResponse {
+	// Create detailed response with token usage
+	response := &interfaces.LLMResponse{
+		Content:    resp.Choices[0].Message.Content,
+		Model:      string(resp.Model),
+		StopReason: string(resp.Choices[0].FinishReason),
+		Metadata: map[string]interface{}{
+			"provider": "openai",
+		},
 	}
 
-	if c.retryExecutor != nil {
-		c.logger.Debug(ctx, "Using retry mechanism for OpenAI request", map[string]interface{}{
-			"model": c.Model,
-		})
-		err = c.retryExecutor.Execute(ctx, operation)
-	} else {
-		err = operation()
-	}
+	// Extract token usage if available
+	response.Usage = completionUsage(resp)
+	return response
+}
 
-	if err != nil {
-		return nil, err
+// completionUsage returns the token usage of a chat completion
+func completionUsage(resp *openai.ChatCompletion) *interfaces.TokenUsage {
+	usage := &interfaces.TokenUsage{
+		InputTokens:  int(resp.Usage.PromptTokens),
+		OutputTokens: int(resp.Usage.CompletionTokens),
+		TotalTokens:  int(resp.Usage.TotalTokens),
 	}
 
-	// Return response
-	if len(resp.Choices) > 0 {
-		c.logger.Debug(ctx, "Successfully received response from OpenAI", map[string]interface{}{
-			"model": c.Model,
-		})
-
-		// Create detailed response with token usage
-		response := &interfaces.LLMResponse{
-			Content:    resp.Choices[0].Message.Content,
-			Model:      string(resp.Model),
-			StopReason: string(resp.Choices[0].FinishReason),
-			Metadata: map[string]interface{}{
-				"provider": "openai",
-			},
-		}
-
-		// Extract token usage if available
-		usage := &interfaces.TokenUsage{
-			InputTokens:  int(resp.Usage.PromptTokens),
-			OutputTokens: int(resp.Usage.CompletionTokens),
-			TotalTokens:  int(resp.Usage.TotalTokens),
-		}
-
-		// Add reasoning tokens if available (for o1 models)
-		if resp.Usage.CompletionTokensDetails.ReasoningTokens > 0 {
-			usage.ReasoningTokens = int(resp.Usage.CompletionTokensDetails.ReasoningTokens)
-		}
-
-		response.Usage = usage
-
-		return response, nil
+	// Add reasoning tokens if available (for o1 models)
+	if resp.Usage.CompletionTokensDetails.ReasoningTokens > 0 {
+		usage.ReasoningTokens = int(resp.Usage.CompletionTokensDetails.ReasoningTokens)
 	}
-
-	return nil, fmt.Errorf("no response from OpenAI API")
+	return usage
 }
 
 // Chat uses the ChatCompletion API to have a conversation (messages) with a model
@@ -310,7 +344,11 @@ func (c *OpenAIClient) Chat(ctx context.Context, messages []llm.Message, params
 		case "system":
 			chatMessages[i] = openai.SystemMessage(msg.Content)
 		case "user":
-			chatMessages[i] = openai.UserMessage(msg.Content)
+			userMsg, err := convertUserMessage(c.Model, msg.Content, msg.Parts)
+			if err != nil {
+				return "", err
+			}
+			chatMessages[i] = userMsg
 		case "assistant":
 			chatMessages[i] = openai.AssistantMessage(msg.Content)
 		case "tool":
@@ -368,7 +406,7 @@ func (c *OpenAIClient) Chat(ctx context.Context, messages []llm.Message, params
 				"error": err.Error(),
 				"model": c.Model,
 			})
-			return fmt.Errorf("failed to create chat completion: %w", err)
+			return fmt.Errorf("failed to create chat completion: %w", convertError(err))
 		}
 		return nil
 	}
@@ -399,6 +437,14 @@ func (c *OpenAIClient) Chat(ctx context.Context, messages []llm.Message, params
 
 // GenerateWithTools implements interfaces.LLM.GenerateWithTools
 func (c *OpenAIClient) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (string, error) {
+	if c.responsesAPI {
+		response, err := c.GenerateWithToolsDetailed(ctx, prompt, tools, options...)
+		if err != nil {
+			return "", err
+		}
+		return response.Content, nil
+	}
+
 	// Convert options to params
 	params := &interfaces.GenerateOptions{}
 	for _, opt := range options {
@@ -433,48 +479,19 @@ func (c *OpenAIClient) GenerateWithTools(ctx context.Context, prompt string, too
 	// Convert tools to OpenAI format
 	openaiTools := make([]openai.ChatCompletionToolUnionParam, len(tools))
 	for i, tool := range tools {
-		// Convert ParameterSpec to JSON Schema
-		properties := make(map[string]interface{})
-		required := []string{}
-
-		for name, param := range tool.Parameters() {
-			properties[name] = map[string]interface{}{
-				"type":        param.Type,
-				"description": param.Description,
-			}
-			if param.Default != nil {
-				properties[name].(map[string]interface{})["default"] = param.Default
-			}
-			if param.Required {
-				required = append(required, name)
-			}
-			if param.Items != nil {
-				properties[name].(map[string]interface{})["items"] = map[string]interface{}{
-					"type": param.Items.Type,
-				}
-				if param.Items.Enum != nil {
-					properties[name].(map[string]interface{})["items"].(map[string]interface{})["enum"] = param.Items.Enum
-				}
-			}
-			if param.Enum != nil {
-				properties[name].(map[string]interface{})["enum"] = param.Enum
-			}
-		}
-
 		openaiTools[i] = openai.ChatCompletionFunctionTool(shared.FunctionDefinitionParam{
 			Name:        tool.Name(),
 			Description: openai.String(tool.Description()),
-			Parameters: map[string]interface{}{
-				"type":       "object",
-				"properties": properties,
-				"required":   required,
-			},
+			Parameters:  llm.ToolSchema(tool),
 		})
 	}
 
 	// Build messages with memory and current prompt
-	builder := newMessageHistoryBuilder(c.logger)
-	messages := builder.buildMessages(ctx, prompt, params.Memory)
+	builder := newMessageHistoryBuilder(c.logger, c.Model)
+	messages, err := builder.buildMessages(ctx, prompt, params.Memory, params.ContentParts...)
+	if err != nil {
+		return "", err
+	}
 
 	// Track tool call repetitions for loop detection
 	toolCallHistory := make(map[string]int)
@@ -560,11 +577,15 @@ func (c *OpenAIClient) GenerateWithTools(ctx context.Context, prompt string, too
 			"iteration":         iteration + 1,
 			"maxIterations":     maxIterations,
 		})
+		if err := llm.BeforeCall(ctx, params); err != nil {
+			return "", err
+		}
 		resp, err := c.ChatService.Completions.New(ctx, req)
 		if err != nil {
 			c.logger.Error(ctx, "Error from OpenAI API", map[string]interface{}{"error": err.Error()})
-			return "", fmt.Errorf("failed to create chat completion: %w", err)
+			return "", fmt.Errorf("failed to create chat completion: %w", convertError(err))
 		}
+		llm.AfterCall(ctx, params, string(resp.Model), completionUsage(resp))
 
 		if len(resp.Choices) == 0 {
 			return "", fmt.Errorf("no completions returned")
@@ -924,11 +945,15 @@ func (c *OpenAIClient) GenerateWithTools(ctx context.Context, prompt string, too
 		"messages": len(finalReq.Messages),
 	})
 
+	if err := llm.BeforeCall(ctx, params); err != nil {
+		return "", err
+	}
 	finalResp, err := c.ChatService.Completions.New(ctx, finalReq)
 	if err != nil {
 		c.logger.Error(ctx, "Error in final call without tools", map[string]interface{}{"error": err.Error()})
-		return "", fmt.Errorf("failed to create final chat completion: %w", err)
+		return "", fmt.Errorf("failed to create final chat completion: %w", convertError(err))
 	}
+	llm.AfterCall(ctx, params, string(finalResp.Model), completionUsage(finalResp))
 
 	if len(finalResp.Choices) == 0 {
 		return "", fmt.Errorf("no completions returned in final call")
@@ -1023,6 +1048,20 @@ func (c *OpenAIClient) GenerateDetailed(ctx context.Context, prompt string, opti
 
 // GenerateWithToolsDetailed generates text with tools and returns detailed response information including token usage
 func (c *OpenAIClient) GenerateWithToolsDetailed(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (*interfaces.LLMResponse, error) {
+	if c.responsesAPI {
+		params := &interfaces.GenerateOptions{
+			LLMConfig: &interfaces.LLMConfig{
+				Temperature: 0.7,
+			},
+		}
+		for _, opt := range options {
+			if opt != nil {
+				opt(params)
+			}
+		}
+		return c.generateWithToolsResponse(ctx, prompt, tools, params)
+	}
+
 	// For now, call the existing method and construct a detailed response
 	// TODO: Implement full detailed version that tracks token usage across all tool iterations
 	content, err := c.GenerateWithTools(ctx, prompt, tools, options...)
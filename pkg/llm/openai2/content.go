@@ -0,0 +1,185 @@
+package openai2
+
+import (
+	"strings"
+
+	"github.com/openai/openai-go/v2"
+	"github.com/openai/openai-go/v2/responses"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// convertUserMessage converts the text and content parts of a user message to
+// an OpenAI user message. Messages without parts are sent as plain text.
+func convertUserMessage(model, text string, parts []interfaces.ContentPart) (openai.ChatCompletionMessageParamUnion, error) {
+	if len(parts) == 0 {
+		return openai.UserMessage(text), nil
+	}
+
+	contentParts := make([]openai.ChatCompletionContentPartUnionParam, 0, len(parts)+1)
+	if text != "" {
+		contentParts = append(contentParts, openai.TextContentPart(text))
+	}
+	for _, part := range parts {
+		contentPart, err := convertContentPart(model, part)
+		if err != nil {
+			return openai.ChatCompletionMessageParamUnion{}, err
+		}
+		contentParts = append(contentParts, contentPart)
+	}
+
+	return openai.UserMessage(contentParts), nil
+}
+
+// convertContentPart converts a content part to an OpenAI chat content part
+func convertContentPart(model string, part interfaces.ContentPart) (openai.ChatCompletionContentPartUnionParam, error) {
+	if err := part.Validate(); err != nil {
+		return openai.ChatCompletionContentPartUnionParam{}, err
+	}
+	if !modelSupportsContent(model, part.Type) {
+		return openai.ChatCompletionContentPartUnionParam{}, &interfaces.UnsupportedContentError{Provider: "openai", Model: model, Type: part.Type}
+	}
+
+	switch part.Type {
+	case interfaces.ContentPartImage:
+		return openai.ImageContentPart(openai.ChatCompletionContentPartImageImageURLParam{
+			URL:    part.DataURL(),
+			Detail: part.Detail,
+		}), nil
+
+	case interfaces.ContentPartDocument:
+		if len(part.Data) == 0 {
+			return openai.ChatCompletionContentPartUnionParam{}, &interfaces.UnsupportedContentError{
+				Provider: "openai", Model: model, Type: part.Type, Reason: "documents must be sent as inline data",
+			}
+		}
+		return openai.FileContentPart(openai.ChatCompletionContentPartFileFileParam{
+			FileData: openai.String(part.DataURL()),
+			Filename: openai.String(documentFilename(part)),
+		}), nil
+
+	case interfaces.ContentPartAudio:
+		format := audioFormat(part.MediaType)
+		if len(part.Data) == 0 || format == "" {
+			return openai.ChatCompletionContentPartUnionParam{}, &interfaces.UnsupportedContentError{
+				Provider: "openai", Model: model, Type: part.Type, Reason: "audio must be sent as inline wav or mp3 data",
+			}
+		}
+		return openai.InputAudioContentPart(openai.ChatCompletionContentPartInputAudioInputAudioParam{
+			Data:   part.Base64(),
+			Format: format,
+		}), nil
+	}
+
+	return openai.TextContentPart(part.Text), nil
+}
+
+// convertUserInputItem converts the text and content parts of a user message
+// to a Responses API input message. Messages without parts are sent as plain text.
+func convertUserInputItem(model, text string, parts []interfaces.ContentPart) (responses.ResponseInputItemUnionParam, error) {
+	if len(parts) == 0 {
+		return responses.ResponseInputItemParamOfMessage(text, responses.EasyInputMessageRoleUser), nil
+	}
+
+	content := make(responses.ResponseInputMessageContentListParam, 0, len(parts)+1)
+	if text != "" {
+		content = append(content, responses.ResponseInputContentParamOfInputText(text))
+	}
+	for _, part := range parts {
+		inputContent, err := convertInputContent(model, part)
+		if err != nil {
+			return responses.ResponseInputItemUnionParam{}, err
+		}
+		content = append(content, inputContent)
+	}
+
+	return responses.ResponseInputItemParamOfMessage(content, responses.EasyInputMessageRoleUser), nil
+}
+
+// convertInputContent converts a content part to Responses API input content
+func convertInputContent(model string, part interfaces.ContentPart) (responses.ResponseInputContentUnionParam, error) {
+	if err := part.Validate(); err != nil {
+		return responses.ResponseInputContentUnionParam{}, err
+	}
+	if !modelSupportsContent(model, part.Type) {
+		return responses.ResponseInputContentUnionParam{}, &interfaces.UnsupportedContentError{Provider: "openai", Model: model, Type: part.Type}
+	}
+
+	switch part.Type {
+	case interfaces.ContentPartImage:
+		detail := responses.ResponseInputImageDetail(part.Detail)
+		if detail == "" {
+			detail = responses.ResponseInputImageDetailAuto
+		}
+		image := responses.ResponseInputContentParamOfInputImage(detail)
+		image.OfInputImage.ImageURL = openai.String(part.DataURL())
+		return image, nil
+
+	case interfaces.ContentPartDocument:
+		file := &responses.ResponseInputFileParam{}
+		if len(part.Data) > 0 {
+			file.FileData = openai.String(part.DataURL())
+			file.Filename = openai.String(documentFilename(part))
+		} else {
+			file.FileURL = openai.String(part.URL)
+		}
+		return responses.ResponseInputContentUnionParam{OfInputFile: file}, nil
+
+	case interfaces.ContentPartAudio:
+		format := audioFormat(part.MediaType)
+		if len(part.Data) == 0 || format == "" {
+			return responses.ResponseInputContentUnionParam{}, &interfaces.UnsupportedContentError{
+				Provider: "openai", Model: model, Type: part.Type, Reason: "audio must be sent as inline wav or mp3 data",
+			}
+		}
+		return responses.ResponseInputContentParamOfInputAudio(responses.ResponseInputAudioInputAudioParam{
+			Data:   part.Base64(),
+			Format: format,
+		}), nil
+	}
+
+	return responses.ResponseInputContentParamOfInputText(part.Text), nil
+}
+
+// modelSupportsContent reports whether a model accepts a content type. Models
+// that are not known to lack a modality are assumed to support it.
+func modelSupportsContent(model string, contentType interfaces.ContentPartType) bool {
+	model = strings.ToLower(model)
+	switch contentType {
+	case interfaces.ContentPartImage, interfaces.ContentPartDocument:
+		if model == "gpt-4" || strings.HasPrefix(model, "gpt-4-0") || strings.Contains(model, "audio") {
+			return false
+		}
+		for _, prefix := range []string{"gpt-3.5", "o1-mini", "o3-mini"} {
+			if strings.HasPrefix(model, prefix) {
+				return false
+			}
+		}
+		return true
+	case interfaces.ContentPartAudio:
+		return strings.Contains(model, "audio")
+	}
+	return true
+}
+
+// audioFormat returns the input audio format for a media type, or an empty
+// string when the format is not supported
+func audioFormat(mediaType string) string {
+	switch strings.ToLower(mediaType) {
+	case "audio/wav", "audio/x-wav", "audio/wave":
+		return "wav"
+	case "audio/mpeg", "audio/mp3":
+		return "mp3"
+	}
+	return ""
+}
+
+// documentFilename returns the name of a document part, the API requires a file name
+func documentFilename(part interfaces.ContentPart) string {
+	if part.Name != "" {
+		return part.Name
+	}
+	if part.MediaType == "application/pdf" {
+		return "document.pdf"
+	}
+	return "document"
+}
@@ -0,0 +1,26 @@
+package openai2
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/openai/openai-go/v2"
+	"github.com/tagus/agent-sdk-go/pkg/llm"
+)
+
+// convertError converts errors of the OpenAI SDK to provider errors, so that
+// callers can tell rate limits, auth failures and context overflows apart
+func convertError(err error) error {
+	var apiErr *openai.Error
+	if !errors.As(err, &apiErr) {
+		return llm.WrapError("openai", err)
+	}
+
+	var header http.Header
+	if apiErr.Response != nil {
+		header = apiErr.Response.Header
+	}
+	providerErr := llm.NewAPIError("openai", apiErr.StatusCode, header, []byte(apiErr.RawJSON()))
+	providerErr.Err = apiErr
+	return providerErr
+}
@@ -6,24 +6,28 @@ import (
 	"github.com/openai/openai-go/v2"
 	"github.com/openai/openai-go/v2/responses"
 	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/llm"
 	"github.com/tagus/agent-sdk-go/pkg/logging"
 )
 
 // messageHistoryBuilder builds OpenAI-compatible message history from memory and current prompt
 type messageHistoryBuilder struct {
 	logger logging.Logger
+	model  string
 }
 
 // newMessageHistoryBuilder creates a new message history builder
-func newMessageHistoryBuilder(logger logging.Logger) *messageHistoryBuilder {
+func newMessageHistoryBuilder(logger logging.Logger, model string) *messageHistoryBuilder {
 	return &messageHistoryBuilder{
 		logger: logger,
+		model:  model,
 	}
 }
 
 // buildMessages constructs OpenAI messages from memory and current prompt
-// Returns messages ready for OpenAI API calls, preserving chronological order
-func (b *messageHistoryBuilder) buildMessages(ctx context.Context, prompt string, memory interfaces.Memory) []openai.ChatCompletionMessageParamUnion {
+// Returns messages ready for OpenAI API calls, preserving chronological order.
+// Content parts are attached to the current user turn.
+func (b *messageHistoryBuilder) buildMessages(ctx context.Context, prompt string, memory interfaces.Memory, parts ...interfaces.ContentPart) ([]openai.ChatCompletionMessageParamUnion, error) {
 	messages := []openai.ChatCompletionMessageParamUnion{}
 
 	// Add memory messages
@@ -35,8 +39,11 @@ func (b *messageHistoryBuilder) buildMessages(ctx context.Context, prompt string
 			})
 		} else {
 			// Convert memory messages to OpenAI format, preserving chronological order
-			for _, msg := range memoryMessages {
-				openaiMsg := b.convertMemoryMessage(msg)
+			for _, msg := range llm.AttachContentParts(memoryMessages, prompt, parts) {
+				openaiMsg, err := b.convertMemoryMessage(msg)
+				if err != nil {
+					return nil, err
+				}
 				if openaiMsg != nil {
 					messages = append(messages, *openaiMsg)
 				}
@@ -44,14 +51,19 @@ func (b *messageHistoryBuilder) buildMessages(ctx context.Context, prompt string
 		}
 	} else {
 		// Only append current user message when memory is nil
-		messages = append(messages, openai.UserMessage(prompt))
+		userMsg, err := convertUserMessage(b.model, prompt, parts)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, userMsg)
 	}
 
-	return messages
+	return messages, nil
 }
 
-// buildResponseInputItems constructs Response API input items from memory and current prompt
-func (b *messageHistoryBuilder) buildResponseInputItems(ctx context.Context, prompt string, memory interfaces.Memory) []responses.ResponseInputItemUnionParam {
+// buildResponseInputItems constructs Response API input items from memory and current prompt.
+// Content parts are attached to the current user turn.
+func (b *messageHistoryBuilder) buildResponseInputItems(ctx context.Context, prompt string, memory interfaces.Memory, parts ...interfaces.ContentPart) ([]responses.ResponseInputItemUnionParam, error) {
 	items := []responses.ResponseInputItemUnionParam{}
 
 	// Add memory messages if present
@@ -62,26 +74,35 @@ func (b *messageHistoryBuilder) buildResponseInputItems(ctx context.Context, pro
 				"error": err.Error(),
 			})
 		} else {
-			for _, msg := range memoryMessages {
-				if converted := b.convertMemoryMessageToResponseInput(msg); len(converted) > 0 {
-					items = append(items, converted...)
+			for _, msg := range llm.AttachContentParts(memoryMessages, prompt, parts) {
+				converted, err := b.convertMemoryMessageToResponseInput(msg)
+				if err != nil {
+					return nil, err
 				}
+				items = append(items, converted...)
 			}
 		}
 	} else {
 		// Only append current user message when memory is nil
-		items = append(items, responses.ResponseInputItemParamOfMessage(prompt, responses.EasyInputMessageRoleUser))
+		item, err := convertUserInputItem(b.model, prompt, parts)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
 	}
 
-	return items
+	return items, nil
 }
 
 // convertMemoryMessage converts a memory message to OpenAI format
-func (b *messageHistoryBuilder) convertMemoryMessage(msg interfaces.Message) *openai.ChatCompletionMessageParamUnion {
+func (b *messageHistoryBuilder) convertMemoryMessage(msg interfaces.Message) (*openai.ChatCompletionMessageParamUnion, error) {
 	switch msg.Role {
 	case interfaces.MessageRoleUser:
-		userMsg := openai.UserMessage(msg.Content)
-		return &userMsg
+		userMsg, err := convertUserMessage(b.model, msg.Content, msg.Parts)
+		if err != nil {
+			return nil, err
+		}
+		return &userMsg, nil
 
 	case interfaces.MessageRoleAssistant:
 		if len(msg.ToolCalls) > 0 {
@@ -106,34 +127,37 @@ func (b *messageHistoryBuilder) convertMemoryMessage(msg interfaces.Message) *op
 				ToolCalls: toolCalls,
 			}
 			param := assistantMsg.ToParam()
-			return &param
+			return &param, nil
 		} else if msg.Content != "" {
 			// Regular assistant message
 			assistantMsg := openai.AssistantMessage(msg.Content)
-			return &assistantMsg
+			return &assistantMsg, nil
 		}
 
 	case interfaces.MessageRoleTool:
 		if msg.ToolCallID != "" {
 			toolMsg := openai.ToolMessage(msg.Content, msg.ToolCallID)
-			return &toolMsg
+			return &toolMsg, nil
 		}
 
 	case interfaces.MessageRoleSystem:
 		// Convert system messages from memory to OpenAI system messages
 		systemMsg := openai.SystemMessage(msg.Content)
-		return &systemMsg
+		return &systemMsg, nil
 	}
 
-	return nil
+	return nil, nil
 }
 
 // convertMemoryMessageToResponseInput converts a memory message to Responses API input items
-func (b *messageHistoryBuilder) convertMemoryMessageToResponseInput(msg interfaces.Message) []responses.ResponseInputItemUnionParam {
+func (b *messageHistoryBuilder) convertMemoryMessageToResponseInput(msg interfaces.Message) ([]responses.ResponseInputItemUnionParam, error) {
 	switch msg.Role {
 	case interfaces.MessageRoleUser:
-		item := responses.ResponseInputItemParamOfMessage(msg.Content, responses.EasyInputMessageRoleUser)
-		return []responses.ResponseInputItemUnionParam{item}
+		item, err := convertUserInputItem(b.model, msg.Content, msg.Parts)
+		if err != nil {
+			return nil, err
+		}
+		return []responses.ResponseInputItemUnionParam{item}, nil
 	case interfaces.MessageRoleAssistant:
 		items := []responses.ResponseInputItemUnionParam{}
 
@@ -147,16 +171,16 @@ func (b *messageHistoryBuilder) convertMemoryMessageToResponseInput(msg interfac
 			items = append(items, responses.ResponseInputItemParamOfFunctionCall(toolCall.Arguments, toolCall.ID, toolCall.Name))
 		}
 
-		return items
+		return items, nil
 	case interfaces.MessageRoleTool:
 		if msg.ToolCallID != "" {
 			item := responses.ResponseInputItemParamOfFunctionCallOutput(msg.ToolCallID, msg.Content)
-			return []responses.ResponseInputItemUnionParam{item}
+			return []responses.ResponseInputItemUnionParam{item}, nil
 		}
 	case interfaces.MessageRoleSystem:
 		item := responses.ResponseInputItemParamOfMessage(msg.Content, responses.EasyInputMessageRoleSystem)
-		return []responses.ResponseInputItemUnionParam{item}
+		return []responses.ResponseInputItemUnionParam{item}, nil
 	}
 
-	return nil
+	return nil, nil
 }
@@ -2,6 +2,8 @@ package openai2
 
 import (
 	"context"
+	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/tagus/agent-sdk-go/pkg/interfaces"
@@ -10,7 +12,7 @@ import (
 
 func TestMessageHistoryBuilder_BuildMessages(t *testing.T) {
 	logger := logging.New()
-	builder := newMessageHistoryBuilder(logger)
+	builder := newMessageHistoryBuilder(logger, "gpt-4o")
 
 	tests := []struct {
 		name     string
@@ -65,7 +67,10 @@ func TestMessageHistoryBuilder_BuildMessages(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			messages := builder.buildMessages(context.Background(), tt.prompt, tt.memory)
+			messages, err := builder.buildMessages(context.Background(), tt.prompt, tt.memory)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
 			if len(messages) != tt.expected {
 				t.Errorf("Expected %d messages, got %d", tt.expected, len(messages))
 			}
@@ -73,6 +78,37 @@ func TestMessageHistoryBuilder_BuildMessages(t *testing.T) {
 	}
 }
 
+func TestMessageHistoryBuilder_ResponseInputContentParts(t *testing.T) {
+	builder := newMessageHistoryBuilder(logging.New(), "gpt-4.1")
+
+	items, err := builder.buildResponseInputItems(context.Background(), "Compare", nil,
+		interfaces.ImageURLPart("https://example.com/chart.png"),
+		interfaces.DocumentURLPart("https://example.com/report.pdf"),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(items))
+	}
+
+	data, _ := json.Marshal(items[0])
+	for _, fragment := range []string{
+		`{"text":"Compare","type":"input_text"}`,
+		`{"detail":"auto","image_url":"https://example.com/chart.png","type":"input_image"}`,
+		`{"file_url":"https://example.com/report.pdf","type":"input_file"}`,
+	} {
+		if !strings.Contains(string(data), fragment) {
+			t.Errorf("Expected %s in %s", fragment, data)
+		}
+	}
+
+	builder = newMessageHistoryBuilder(logging.New(), "o3-mini")
+	if _, err := builder.buildResponseInputItems(context.Background(), "Compare", nil, interfaces.ImageURLPart("https://example.com/chart.png")); err == nil {
+		t.Error("Expected an error for an image on a text-only model")
+	}
+}
+
 // mockMemory is a simple mock implementation for testing
 type mockMemory struct {
 	messages []interfaces.Message
@@ -0,0 +1,518 @@
+package openai2
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openai/openai-go/v2/packages/param"
+	"github.com/openai/openai-go/v2/responses"
+	"github.com/openai/openai-go/v2/shared"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/llm"
+	"github.com/tagus/agent-sdk-go/pkg/memory"
+	"github.com/tagus/agent-sdk-go/pkg/multitenancy"
+)
+
+const previousResponseIDKey contextKey = "previous_response_id"
+
+// WithResponsesAPI makes Generate, GenerateDetailed, GenerateWithTools and
+// GenerateBatch use the Responses API instead of chat completions. Streaming
+// always uses the Responses API.
+func WithResponsesAPI() Option {
+	return func(c *OpenAIClient) {
+		c.responsesAPI = true
+	}
+}
+
+const (
+	// DefaultServerSideStateSize is the default number of conversations whose
+	// last response is remembered with WithServerSideState
+	DefaultServerSideStateSize = 10000
+
+	// responseRetention is how long OpenAI stores responses by default
+	responseRetention = 30 * 24 * time.Hour
+)
+
+// WithServerSideState keeps conversations on OpenAI's servers. The client
+// remembers the last response of each conversation, identified by the
+// organization and the conversation ID of the context, and continues from it
+// with previous_response_id, sending only the new prompt instead of the whole
+// history from memory. The system message of the first request stays part of
+// the conversation. The state is kept in the memory of the client, so a new
+// client starts again from the history in memory. The client remembers the
+// most recently used DefaultServerSideStateSize conversations, or as set with
+// WithServerSideStateSize, for the 30 days that OpenAI stores responses.
+func WithServerSideState() Option {
+	return func(c *OpenAIClient) {
+		c.serverSideState = true
+		if c.responseIDs == nil {
+			c.responseIDs = newResponseIDCache(DefaultServerSideStateSize)
+		}
+	}
+}
+
+// WithServerSideStateSize sets the number of conversations whose last response
+// is remembered with WithServerSideState. The least recently used
+// conversations are forgotten first and continue from the history in memory.
+func WithServerSideStateSize(conversations int) Option {
+	return func(c *OpenAIClient) {
+		if conversations > 0 {
+			c.responseIDs = newResponseIDCache(conversations)
+		}
+	}
+}
+
+// WithBuiltinTools adds OpenAI built-in tools, such as web search, file search
+// or code interpreter, to Responses API requests. They are run by OpenAI and
+// their results are part of the response, so they need no local execution.
+func WithBuiltinTools(tools ...responses.ToolUnionParam) Option {
+	return func(c *OpenAIClient) {
+		c.builtinTools = append(c.builtinTools, tools...)
+	}
+}
+
+// WithPreviousResponseID returns a context whose Responses API requests
+// continue the conversation of the response with the given ID. Only the new
+// prompt is sent. The ID of a response is in the "response_id" metadata of
+// detailed responses and of the message stop event of streams.
+func WithPreviousResponseID(ctx context.Context, responseID string) context.Context {
+	return context.WithValue(ctx, previousResponseIDKey, responseID)
+}
+
+// previousResponseID returns the response a request continues from, if any
+func (c *OpenAIClient) previousResponseID(ctx context.Context) string {
+	if id, ok := ctx.Value(previousResponseIDKey).(string); ok && id != "" {
+		return id
+	}
+	if !c.serverSideState {
+		return ""
+	}
+
+	key, ok := conversationKey(ctx)
+	if !ok {
+		return ""
+	}
+	return c.responseIDs.get(key)
+}
+
+// rememberResponse records the last response of the conversation of ctx
+func (c *OpenAIClient) rememberResponse(ctx context.Context, responseID string) {
+	if !c.serverSideState || responseID == "" {
+		return
+	}
+
+	key, ok := conversationKey(ctx)
+	if !ok {
+		return
+	}
+	c.responseIDs.set(key, responseID)
+}
+
+// responseIDCache holds the last response ID of the most recently used
+// conversations. IDs older than the retention of OpenAI are dropped.
+type responseIDCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+	now      func() time.Time
+}
+
+type responseIDEntry struct {
+	key        string
+	responseID string
+	stored     time.Time
+}
+
+func newResponseIDCache(capacity int) *responseIDCache {
+	return &responseIDCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+// get returns the response ID of a conversation, or "" if it is unknown or
+// expired
+func (c *responseIDCache) get(key string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return ""
+	}
+	entry := elem.Value.(*responseIDEntry)
+	if c.now().Sub(entry.stored) > responseRetention {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return ""
+	}
+	c.order.MoveToFront(elem)
+	return entry.responseID
+}
+
+// set records the response ID of a conversation and evicts the least recently
+// used conversations above the capacity
+func (c *responseIDCache) set(key, responseID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*responseIDEntry)
+		entry.responseID = responseID
+		entry.stored = c.now()
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&responseIDEntry{key: key, responseID: responseID, stored: c.now()})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*responseIDEntry).key)
+	}
+}
+
+// conversationKey identifies the conversation of ctx across organizations
+func conversationKey(ctx context.Context) (string, bool) {
+	conversationID, ok := memory.GetConversationID(ctx)
+	if !ok || conversationID == "" {
+		return "", false
+	}
+	orgID, err := multitenancy.GetOrgID(ctx)
+	if err != nil {
+		orgID = "default"
+	}
+	return orgID + "/" + conversationID, true
+}
+
+// buildResponseRequest builds the Responses API request of a prompt, which
+// continues from the previous response of the conversation if there is one.
+// Reasoning summaries are requested from reasoning models when summary is true.
+func (c *OpenAIClient) buildResponseRequest(ctx context.Context, prompt string, params *interfaces.GenerateOptions, summary bool) (responses.ResponseNewParams, error) {
+	return c.buildResponseRequestFrom(ctx, prompt, params, summary, c.previousResponseID(ctx))
+}
+
+// buildResponseRequestFrom builds the Responses API request of a prompt that
+// continues from the response with the given ID, or that sends the history of
+// the memory when the ID is empty
+func (c *OpenAIClient) buildResponseRequestFrom(ctx context.Context, prompt string, params *interfaces.GenerateOptions, summary bool, previousID string) (responses.ResponseNewParams, error) {
+	orgID := "default"
+	if id, err := multitenancy.GetOrgID(ctx); err == nil {
+		orgID = id
+	}
+
+	var inputItems []responses.ResponseInputItemUnionParam
+	if previousID != "" {
+		// The server holds the conversation, only the new turn is sent
+		item, err := convertUserInputItem(c.Model, prompt, params.ContentParts)
+		if err != nil {
+			return responses.ResponseNewParams{}, err
+		}
+		inputItems = []responses.ResponseInputItemUnionParam{item}
+	} else {
+		builder := newMessageHistoryBuilder(c.logger, c.Model)
+		historyItems, err := builder.buildResponseInputItems(ctx, prompt, params.Memory, params.ContentParts...)
+		if err != nil {
+			return responses.ResponseNewParams{}, err
+		}
+		inputItems = historyItems
+
+		// Prepend system message when provided
+		if params.SystemMessage != "" {
+			inputItems = append([]responses.ResponseInputItemUnionParam{
+				responses.ResponseInputItemParamOfMessage(params.SystemMessage, responses.EasyInputMessageRoleSystem),
+			}, inputItems...)
+		}
+
+		// Ensure we always send at least the current prompt
+		if len(inputItems) == 0 {
+			inputItems = []responses.ResponseInputItemUnionParam{
+				responses.ResponseInputItemParamOfMessage(prompt, responses.EasyInputMessageRoleUser),
+			}
+		}
+	}
+
+	req := responses.ResponseNewParams{
+		Model: shared.ResponsesModel(c.Model),
+		Input: responses.ResponseNewParamsInputUnion{
+			OfInputItemList: responses.ResponseInputParam(inputItems),
+		},
+		User: param.NewOpt(orgID),
+	}
+	if previousID != "" {
+		req.PreviousResponseID = param.NewOpt(previousID)
+	}
+	if c.serverSideState {
+		req.Store = param.NewOpt(true)
+	}
+
+	if params.LLMConfig != nil {
+		req.Temperature = param.NewOpt(c.getTemperatureForModel(params.LLMConfig.Temperature))
+		if params.LLMConfig.TopP > 0 && !isReasoningModel(c.Model) {
+			req.TopP = param.NewOpt(params.LLMConfig.TopP)
+		}
+
+		if params.LLMConfig.Reasoning != "" {
+			req.Reasoning.Effort = shared.ReasoningEffort(params.LLMConfig.Reasoning)
+		}
+	}
+
+	// Only reasoning models accept reasoning summaries
+	if summary && isReasoningModel(c.Model) {
+		req.Reasoning.Summary = shared.ReasoningSummaryAuto
+	}
+
+	if params.ResponseFormat != nil {
+		jsonSchema := responses.ResponseFormatTextJSONSchemaConfigParam{
+			Name:   params.ResponseFormat.Name,
+			Schema: params.ResponseFormat.Schema,
+			Type:   "json_schema",
+		}
+
+		req.Text.Format = responses.ResponseFormatTextConfigUnionParam{
+			OfJSONSchema: &jsonSchema,
+		}
+	}
+
+	if len(c.builtinTools) > 0 {
+		req.Tools = append(req.Tools, c.builtinTools...)
+	}
+
+	return req, nil
+}
+
+// newResponse sends a Responses API request, retrying when configured
+func (c *OpenAIClient) newResponse(ctx context.Context, req responses.ResponseNewParams) (*responses.Response, error) {
+	var resp *responses.Response
+
+	operation := func() error {
+		c.logger.Debug(ctx, "Executing OpenAI Responses API request", map[string]interface{}{
+			"model":                c.Model,
+			"tools":                len(req.Tools),
+			"previous_response_id": req.PreviousResponseID.Value,
+		})
+
+		var err error
+		resp, err = c.ResponseService.New(ctx, req)
+		if err != nil {
+			c.logger.Error(ctx, "Error from OpenAI Responses API", map[string]interface{}{
+				"error": err.Error(),
+				"model": c.Model,
+			})
+			return fmt.Errorf("failed to create response: %w", convertError(err))
+		}
+		return nil
+	}
+
+	var err error
+	if c.retryExecutor != nil {
+		err = c.retryExecutor.Execute(ctx, operation)
+	} else {
+		err = operation()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Status == responses.ResponseStatusFailed {
+		return nil, fmt.Errorf("response failed: %s", resp.Error.Message)
+	}
+	return resp, nil
+}
+
+// generateResponse generates a response with the Responses API
+func (c *OpenAIClient) generateResponse(ctx context.Context, prompt string, params *interfaces.GenerateOptions) (*interfaces.LLMResponse, error) {
+	enableReasoning := params.LLMConfig != nil && params.LLMConfig.EnableReasoning
+	req, err := c.buildResponseRequest(ctx, prompt, params, enableReasoning)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.newResponse(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	c.rememberResponse(ctx, resp.ID)
+
+	return convertResponse(resp), nil
+}
+
+// generateWithToolsResponse runs the tool calling loop with the Responses
+// API. Each iteration continues from the previous response with the tool
+// outputs, and built-in tools are run by OpenAI within each response.
+func (c *OpenAIClient) generateWithToolsResponse(ctx context.Context, prompt string, tools []interfaces.Tool, params *interfaces.GenerateOptions) (*interfaces.LLMResponse, error) {
+	maxIterations := params.MaxIterations
+	if maxIterations == 0 {
+		maxIterations = 2
+	}
+
+	enableReasoning := params.LLMConfig != nil && params.LLMConfig.EnableReasoning
+	req, err := c.buildResponseRequest(ctx, prompt, params, enableReasoning)
+	if err != nil {
+		return nil, err
+	}
+
+	functionTools := make([]responses.ToolUnionParam, len(tools))
+	for i, tool := range tools {
+		functionTools[i] = responses.ToolParamOfFunction(tool.Name(), llm.ToolSchema(tool), false)
+		functionTools[i].OfFunction.Description = param.NewOpt(tool.Description())
+	}
+	req.Tools = append(functionTools, req.Tools...)
+
+	usage := &interfaces.TokenUsage{}
+	for iteration := 0; ; iteration++ {
+		if iteration == maxIterations {
+			// Ask for a final answer without further tool calls
+			req.ToolChoice = responses.ResponseNewParamsToolChoiceUnion{
+				OfToolChoiceMode: param.NewOpt(responses.ToolChoiceOptionsNone),
+			}
+		}
+
+		if err := llm.BeforeCall(ctx, params); err != nil {
+			return nil, err
+		}
+		resp, err := c.newResponse(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		callUsage := &interfaces.TokenUsage{}
+		addResponseUsage(callUsage, resp)
+		llm.AfterCall(ctx, params, string(resp.Model), callUsage)
+		addResponseUsage(usage, resp)
+
+		var outputs []responses.ResponseInputItemUnionParam
+		for _, item := range resp.Output {
+			if item.Type != "function_call" {
+				continue
+			}
+			outputs = append(outputs, responses.ResponseInputItemParamOfFunctionCallOutput(
+				item.CallID, c.executeResponseToolCall(ctx, tools, item, params.Memory)))
+		}
+
+		if len(outputs) == 0 || iteration == maxIterations {
+			c.rememberResponse(ctx, resp.ID)
+			response := convertResponse(resp)
+			response.Usage = usage
+			response.Metadata["tools_used"] = iteration > 0
+			return response, nil
+		}
+
+		c.logger.Debug(ctx, "Continuing response with tool outputs", map[string]interface{}{
+			"iteration":     iteration + 1,
+			"maxIterations": maxIterations,
+			"tool_outputs":  len(outputs),
+		})
+
+		req.PreviousResponseID = param.NewOpt(resp.ID)
+		req.Input = responses.ResponseNewParamsInputUnion{
+			OfInputItemList: responses.ResponseInputParam(outputs),
+		}
+	}
+}
+
+// executeResponseToolCall runs the tool of a function call and returns its
+// output, recording the call and its output in memory
+func (c *OpenAIClient) executeResponseToolCall(ctx context.Context, tools []interfaces.Tool, call responses.ResponseOutputItemUnion, mem interfaces.Memory) string {
+	var result string
+	var selected interfaces.Tool
+	for _, tool := range tools {
+		if tool.Name() == call.Name {
+			selected = tool
+			break
+		}
+	}
+
+	if selected == nil {
+		result = fmt.Sprintf("Error: tool not found: %s", call.Name)
+	} else {
+		output, err := selected.Execute(ctx, call.Arguments)
+		if err != nil {
+			c.logger.Error(ctx, "Error executing tool", map[string]interface{}{
+				"tool_name": call.Name,
+				"error":     err.Error(),
+			})
+			output = fmt.Sprintf("Error executing tool: %v", err)
+		}
+		result = output
+	}
+
+	if mem != nil {
+		_ = mem.AddMessage(ctx, interfaces.Message{
+			Role: interfaces.MessageRoleAssistant,
+			ToolCalls: []interfaces.ToolCall{{
+				ID:        call.CallID,
+				Name:      call.Name,
+				Arguments: call.Arguments,
+			}},
+		})
+		_ = mem.AddMessage(ctx, interfaces.Message{
+			Role:       interfaces.MessageRoleTool,
+			Content:    result,
+			ToolCallID: call.CallID,
+			Metadata: map[string]interface{}{
+				"tool_name": call.Name,
+			},
+		})
+	}
+
+	return result
+}
+
+// convertResponse converts a Responses API response to a detailed response.
+// The response ID and the reasoning summary, if any, are in the metadata.
+func convertResponse(resp *responses.Response) *interfaces.LLMResponse {
+	stopReason := string(resp.Status)
+	if resp.IncompleteDetails.Reason != "" {
+		stopReason = resp.IncompleteDetails.Reason
+	}
+
+	response := &interfaces.LLMResponse{
+		Content:    resp.OutputText(),
+		Model:      string(resp.Model),
+		StopReason: stopReason,
+		Usage:      &interfaces.TokenUsage{},
+		Metadata: map[string]interface{}{
+			"provider":    "openai",
+			"response_id": resp.ID,
+		},
+	}
+	addResponseUsage(response.Usage, resp)
+
+	var summary []string
+	var builtinCalls []string
+	for _, item := range resp.Output {
+		switch item.Type {
+		case "message", "function_call":
+		case "reasoning":
+			for _, part := range item.Summary {
+				summary = append(summary, part.Text)
+			}
+		default:
+			builtinCalls = append(builtinCalls, item.Type)
+		}
+	}
+	if len(summary) > 0 {
+		response.Metadata["reasoning_summary"] = strings.Join(summary, "\n\n")
+	}
+	if len(builtinCalls) > 0 {
+		response.Metadata["builtin_tool_calls"] = builtinCalls
+	}
+
+	return response
+}
+
+// addResponseUsage adds the token usage of a response to usage
+func addResponseUsage(usage *interfaces.TokenUsage, resp *responses.Response) {
+	usage.InputTokens += int(resp.Usage.InputTokens)
+	usage.OutputTokens += int(resp.Usage.OutputTokens)
+	usage.TotalTokens += int(resp.Usage.TotalTokens)
+	usage.ReasoningTokens += int(resp.Usage.OutputTokensDetails.ReasoningTokens)
+	usage.CacheReadInputTokens += int(resp.Usage.InputTokensDetails.CachedTokens)
+}
@@ -0,0 +1,328 @@
+package openai2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openai/openai-go/v2/responses"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/memory"
+)
+
+// responsesServer is an httptest stand-in for the Responses API that replies
+// with the given bodies in turn and records the requests
+type responsesServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []map[string]interface{}
+}
+
+func newResponsesServer(t *testing.T, contentType string, bodies ...string) *responsesServer {
+	t.Helper()
+
+	s := &responsesServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/responses" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+
+		s.mu.Lock()
+		s.requests = append(s.requests, body)
+		index := len(s.requests) - 1
+		s.mu.Unlock()
+		if index >= len(bodies) {
+			index = len(bodies) - 1
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		_, _ = io.WriteString(w, bodies[index])
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *responsesServer) request(t *testing.T, index int) map[string]interface{} {
+	t.Helper()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if index >= len(s.requests) {
+		t.Fatalf("expected at least %d requests, got %d", index+1, len(s.requests))
+	}
+	return s.requests[index]
+}
+
+func responseBody(id string, output ...string) string {
+	return fmt.Sprintf(`{"id":%q,"object":"response","model":"o3-mini","status":"completed","output":[%s],"usage":{"input_tokens":10,"output_tokens":4,"output_tokens_details":{"reasoning_tokens":2},"total_tokens":14,"input_tokens_details":{"cached_tokens":3}}}`,
+		id, strings.Join(output, ","))
+}
+
+func messageOutput(text string) string {
+	return fmt.Sprintf(`{"type":"message","id":"msg_1","role":"assistant","status":"completed","content":[{"type":"output_text","text":%q,"annotations":[]}]}`, text)
+}
+
+func TestGenerateDetailedWithResponsesAPI(t *testing.T) {
+	server := newResponsesServer(t, "application/json", responseBody("resp_1",
+		`{"type":"reasoning","id":"rs_1","summary":[{"type":"summary_text","text":"Compared options"}]}`,
+		`{"type":"web_search_call","id":"ws_1","status":"completed","action":{"type":"search","query":"weather"}}`,
+		messageOutput("Sunny"),
+	))
+
+	client := NewClient("test-key",
+		WithModel("o3-mini"),
+		WithBaseURL(server.URL),
+		WithResponsesAPI(),
+		WithBuiltinTools(responses.ToolParamOfWebSearchPreview(responses.WebSearchPreviewToolTypeWebSearchPreview)),
+	)
+
+	resp, err := client.GenerateDetailed(context.Background(), "What is the weather?",
+		WithSystemMessage("Be brief"),
+		interfaces.WithReasoning(true),
+	)
+	if err != nil {
+		t.Fatalf("GenerateDetailed returned error: %v", err)
+	}
+
+	if resp.Content != "Sunny" {
+		t.Errorf("expected content 'Sunny', got %q", resp.Content)
+	}
+	if resp.Metadata["response_id"] != "resp_1" {
+		t.Errorf("expected response_id metadata, got %v", resp.Metadata["response_id"])
+	}
+	if resp.Metadata["reasoning_summary"] != "Compared options" {
+		t.Errorf("expected reasoning summary metadata, got %v", resp.Metadata["reasoning_summary"])
+	}
+	if calls, _ := resp.Metadata["builtin_tool_calls"].([]string); len(calls) != 1 || calls[0] != "web_search_call" {
+		t.Errorf("expected web search call metadata, got %v", resp.Metadata["builtin_tool_calls"])
+	}
+	if resp.Usage == nil || resp.Usage.TotalTokens != 14 || resp.Usage.ReasoningTokens != 2 || resp.Usage.CacheReadInputTokens != 3 {
+		t.Errorf("unexpected usage: %+v", resp.Usage)
+	}
+
+	req := server.request(t, 0)
+	tools, _ := req["tools"].([]interface{})
+	if len(tools) != 1 || tools[0].(map[string]interface{})["type"] != "web_search_preview" {
+		t.Errorf("expected built-in web search tool, got %v", req["tools"])
+	}
+	reasoning, _ := req["reasoning"].(map[string]interface{})
+	if reasoning["summary"] != "auto" {
+		t.Errorf("expected reasoning summary to be requested, got %v", req["reasoning"])
+	}
+	if input, _ := req["input"].([]interface{}); len(input) != 2 {
+		t.Errorf("expected system message and prompt as input, got %v", req["input"])
+	}
+}
+
+func TestGenerateWithServerSideState(t *testing.T) {
+	server := newResponsesServer(t, "application/json",
+		responseBody("resp_1", messageOutput("Hi Ada")),
+		responseBody("resp_2", messageOutput("Your name is Ada")),
+		responseBody("resp_3", messageOutput("Hello")),
+	)
+
+	client := NewClient("test-key",
+		WithModel("gpt-4o"),
+		WithBaseURL(server.URL),
+		WithResponsesAPI(),
+		WithServerSideState(),
+	)
+
+	mem := memory.NewConversationBuffer()
+	ctx := memory.WithConversationID(context.Background(), "conversation-1")
+	_ = mem.AddMessage(ctx, interfaces.Message{Role: interfaces.MessageRoleUser, Content: "I am Ada"})
+
+	if _, err := client.Generate(ctx, "I am Ada", interfaces.WithMemory(mem), WithSystemMessage("Be friendly")); err != nil {
+		t.Fatalf("first Generate returned error: %v", err)
+	}
+
+	_ = mem.AddMessage(ctx, interfaces.Message{Role: interfaces.MessageRoleAssistant, Content: "Hi Ada"})
+	_ = mem.AddMessage(ctx, interfaces.Message{Role: interfaces.MessageRoleUser, Content: "What is my name?"})
+
+	answer, err := client.Generate(ctx, "What is my name?", interfaces.WithMemory(mem), WithSystemMessage("Be friendly"))
+	if err != nil {
+		t.Fatalf("second Generate returned error: %v", err)
+	}
+	if answer != "Your name is Ada" {
+		t.Errorf("unexpected answer %q", answer)
+	}
+
+	first := server.request(t, 0)
+	if _, ok := first["previous_response_id"]; ok {
+		t.Errorf("first request should not continue a response: %v", first["previous_response_id"])
+	}
+	if first["store"] != true {
+		t.Errorf("expected responses to be stored, got %v", first["store"])
+	}
+
+	second := server.request(t, 1)
+	if second["previous_response_id"] != "resp_1" {
+		t.Errorf("expected previous_response_id resp_1, got %v", second["previous_response_id"])
+	}
+	input, _ := second["input"].([]interface{})
+	if len(input) != 1 || !strings.Contains(fmt.Sprint(input[0]), "What is my name?") {
+		t.Errorf("expected only the new prompt as input, got %v", second["input"])
+	}
+
+	// Another conversation starts from its own history
+	otherCtx := memory.WithConversationID(context.Background(), "conversation-2")
+	if _, err := client.Generate(otherCtx, "Hello"); err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	if third := server.request(t, 2); third["previous_response_id"] != nil {
+		t.Errorf("other conversation should not continue a response: %v", third["previous_response_id"])
+	}
+}
+
+func TestGenerateWithPreviousResponseID(t *testing.T) {
+	server := newResponsesServer(t, "application/json", responseBody("resp_2", messageOutput("Sure")))
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithResponsesAPI())
+
+	ctx := WithPreviousResponseID(context.Background(), "resp_1")
+	if _, err := client.Generate(ctx, "Go on"); err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	if req := server.request(t, 0); req["previous_response_id"] != "resp_1" {
+		t.Errorf("expected previous_response_id resp_1, got %v", req["previous_response_id"])
+	}
+}
+
+func TestGenerateWithToolsResponsesAPI(t *testing.T) {
+	server := newResponsesServer(t, "application/json",
+		responseBody("resp_1", `{"type":"function_call","id":"fc_1","call_id":"call_1","name":"echo","arguments":"{\"param\":\"hi\"}","status":"completed"}`),
+		responseBody("resp_2", messageOutput("Echoed")),
+	)
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithResponsesAPI())
+	tool := &mockTool{name: "echo", description: "echo tool"}
+
+	resp, err := client.GenerateWithToolsDetailed(context.Background(), "Echo hi", []interfaces.Tool{tool})
+	if err != nil {
+		t.Fatalf("GenerateWithToolsDetailed returned error: %v", err)
+	}
+
+	if resp.Content != "Echoed" {
+		t.Errorf("expected content 'Echoed', got %q", resp.Content)
+	}
+	if resp.Usage == nil || resp.Usage.TotalTokens != 28 {
+		t.Errorf("expected usage of both responses, got %+v", resp.Usage)
+	}
+	if resp.Metadata["tools_used"] != true {
+		t.Errorf("expected tools_used metadata, got %v", resp.Metadata["tools_used"])
+	}
+
+	first := server.request(t, 0)
+	tools, _ := first["tools"].([]interface{})
+	if len(tools) != 1 || tools[0].(map[string]interface{})["name"] != "echo" {
+		t.Errorf("expected echo function tool, got %v", first["tools"])
+	}
+
+	second := server.request(t, 1)
+	if second["previous_response_id"] != "resp_1" {
+		t.Errorf("expected previous_response_id resp_1, got %v", second["previous_response_id"])
+	}
+	input, _ := second["input"].([]interface{})
+	if len(input) != 1 {
+		t.Fatalf("expected the tool output as input, got %v", second["input"])
+	}
+	output := input[0].(map[string]interface{})
+	if output["type"] != "function_call_output" || output["call_id"] != "call_1" || output["output"] != `Result from echo: {"param":"hi"}` {
+		t.Errorf("unexpected tool output item: %v", output)
+	}
+}
+
+func TestGenerateStreamSurfacesReasoningSummary(t *testing.T) {
+	stream := "" +
+		"data: {\"type\":\"response.reasoning_summary_text.delta\",\"item_id\":\"rs_1\",\"output_index\":0,\"summary_index\":0,\"delta\":\"Weighing options\"}\n\n" +
+		"data: {\"type\":\"response.output_text.delta\",\"item_id\":\"msg_1\",\"output_index\":1,\"content_index\":0,\"delta\":\"Done\"}\n\n" +
+		"data: {\"type\":\"response.completed\",\"response\":{\"id\":\"resp_1\",\"usage\":{\"input_tokens\":3,\"output_tokens\":2,\"output_tokens_details\":{\"reasoning_tokens\":1},\"total_tokens\":5,\"input_tokens_details\":{\"cached_tokens\":0}}}}\n\n"
+	server := newResponsesServer(t, "text/event-stream", stream)
+
+	client := NewClient("test-key", WithModel("o3-mini"), WithBaseURL(server.URL), WithServerSideState())
+
+	ctx := memory.WithConversationID(context.Background(), "conversation-1")
+	events, err := client.GenerateStream(ctx, "Decide")
+	if err != nil {
+		t.Fatalf("GenerateStream returned error: %v", err)
+	}
+
+	var thinking string
+	var stop interfaces.StreamEvent
+	for event := range events {
+		switch event.Type {
+		case interfaces.StreamEventThinking:
+			thinking += event.Content
+		case interfaces.StreamEventMessageStop:
+			stop = event
+		case interfaces.StreamEventError:
+			t.Fatalf("unexpected error event: %v", event.Error)
+		}
+	}
+
+	if thinking != "Weighing options" {
+		t.Errorf("expected reasoning summary as thinking, got %q", thinking)
+	}
+	if stop.Metadata["response_id"] != "resp_1" {
+		t.Errorf("expected response_id on message stop, got %v", stop.Metadata["response_id"])
+	}
+	reasoning, _ := server.request(t, 0)["reasoning"].(map[string]interface{})
+	if reasoning["summary"] != "auto" {
+		t.Errorf("expected reasoning summary to be requested, got %v", reasoning)
+	}
+	if id := client.previousResponseID(ctx); id != "resp_1" {
+		t.Errorf("expected streamed response to be remembered, got %q", id)
+	}
+}
+
+func TestResponseIDCache(t *testing.T) {
+	now := time.Now()
+	cache := newResponseIDCache(2)
+	cache.now = func() time.Time { return now }
+
+	cache.set("org/a", "resp_a")
+	cache.set("org/b", "resp_b")
+	if id := cache.get("org/a"); id != "resp_a" {
+		t.Fatalf("expected resp_a, got %q", id)
+	}
+
+	// b is the least recently used conversation
+	cache.set("org/c", "resp_c")
+	if id := cache.get("org/b"); id != "" {
+		t.Errorf("expected least recently used conversation to be evicted, got %q", id)
+	}
+	if id := cache.get("org/a"); id != "resp_a" {
+		t.Errorf("expected resp_a to be kept, got %q", id)
+	}
+
+	cache.set("org/a", "resp_a2")
+	if id := cache.get("org/a"); id != "resp_a2" {
+		t.Errorf("expected updated response ID, got %q", id)
+	}
+	if len(cache.entries) != 2 || cache.order.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", len(cache.entries))
+	}
+
+	now = now.Add(responseRetention + time.Minute)
+	if id := cache.get("org/a"); id != "" {
+		t.Errorf("expected expired response ID to be dropped, got %q", id)
+	}
+	if _, ok := cache.entries["org/a"]; ok {
+		t.Error("expected expired entry to be removed")
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/openai/openai-go/v2/responses"
 	"github.com/openai/openai-go/v2/shared"
 	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/llm"
 	"github.com/tagus/agent-sdk-go/pkg/multitenancy"
 )
 
@@ -31,13 +32,8 @@ func (c *OpenAIClient) GenerateStream(
 	}
 
 	// Check for organization ID in context
-	defaultOrgID := "default"
-	orgID := defaultOrgID
-	if id, err := multitenancy.GetOrgID(ctx); err == nil {
-		orgID = id
-		ctx = multitenancy.WithOrgID(ctx, id)
-	} else {
-		ctx = multitenancy.WithOrgID(ctx, defaultOrgID)
+	if _, err := multitenancy.GetOrgID(ctx); err != nil {
+		ctx = multitenancy.WithOrgID(ctx, "default")
 	}
 
 	// Get buffer size from stream config
@@ -46,61 +42,18 @@ func (c *OpenAIClient) GenerateStream(
 		bufferSize = params.StreamConfig.BufferSize
 	}
 
+	// Request reasoning summaries so that they stream as thinking events
+	includeThinking := params.StreamConfig == nil || params.StreamConfig.IncludeThinking
+	req, err := c.buildResponseRequest(ctx, prompt, params, includeThinking)
+	if err != nil {
+		return nil, err
+	}
+
 	eventChan := make(chan interfaces.StreamEvent, bufferSize)
 
 	go func() {
 		defer close(eventChan)
 
-		builder := newMessageHistoryBuilder(c.logger)
-		inputItems := builder.buildResponseInputItems(ctx, prompt, params.Memory)
-
-		// Prepend system message when provided
-		if params.SystemMessage != "" {
-			inputItems = append([]responses.ResponseInputItemUnionParam{
-				responses.ResponseInputItemParamOfMessage(params.SystemMessage, responses.EasyInputMessageRoleSystem),
-			}, inputItems...)
-		}
-
-		// Ensure we always send at least the current prompt
-		if len(inputItems) == 0 {
-			inputItems = []responses.ResponseInputItemUnionParam{
-				responses.ResponseInputItemParamOfMessage(prompt, responses.EasyInputMessageRoleUser),
-			}
-		}
-
-		req := responses.ResponseNewParams{
-			Model: shared.ResponsesModel(c.Model),
-			Input: responses.ResponseNewParamsInputUnion{
-				OfInputItemList: responses.ResponseInputParam(inputItems),
-			},
-			User: param.NewOpt(orgID),
-		}
-
-		if params.LLMConfig != nil {
-			req.Temperature = param.NewOpt(c.getTemperatureForModel(params.LLMConfig.Temperature))
-			if params.LLMConfig.TopP > 0 && !isReasoningModel(c.Model) {
-				req.TopP = param.NewOpt(params.LLMConfig.TopP)
-			}
-
-			if params.LLMConfig.Reasoning != "" {
-				req.Reasoning = shared.ReasoningParam{
-					Effort: shared.ReasoningEffort(params.LLMConfig.Reasoning),
-				}
-			}
-		}
-
-		if params.ResponseFormat != nil {
-			jsonSchema := responses.ResponseFormatTextJSONSchemaConfigParam{
-				Name:   params.ResponseFormat.Name,
-				Schema: params.ResponseFormat.Schema,
-				Type:   "json_schema",
-			}
-
-			req.Text.Format = responses.ResponseFormatTextConfigUnionParam{
-				OfJSONSchema: &jsonSchema,
-			}
-		}
-
 		c.logger.Debug(ctx, "Creating OpenAI Responses streaming request", map[string]interface{}{
 			"model":       c.Model,
 			"temperature": params.LLMConfig.Temperature,
@@ -118,10 +71,9 @@ func (c *OpenAIClient) GenerateStream(
 			},
 		}
 
-		includeThinking := params.StreamConfig == nil || params.StreamConfig.IncludeThinking
 		var reasoningTextSent bool
 		var reasoningSummarySent bool
-		var usageMetadata map[string]interface{}
+		stopMetadata := map[string]interface{}{}
 
 		for stream.Next() {
 			event := stream.Current()
@@ -214,15 +166,17 @@ func (c *OpenAIClient) GenerateStream(
 				}
 			case "response.completed":
 				completed := event.AsResponseCompleted()
+				if completed.Response.ID != "" {
+					c.rememberResponse(ctx, completed.Response.ID)
+					stopMetadata["response_id"] = completed.Response.ID
+				}
 				if completed.Response.Usage.TotalTokens > 0 {
-					usageMetadata = map[string]interface{}{
-						"usage": map[string]interface{}{
-							"prompt_tokens":       completed.Response.Usage.InputTokens,
-							"completion_tokens":   completed.Response.Usage.OutputTokens,
-							"reasoning_tokens":    completed.Response.Usage.OutputTokensDetails.ReasoningTokens,
-							"total_tokens":        completed.Response.Usage.TotalTokens,
-							"cached_input_tokens": completed.Response.Usage.InputTokensDetails.CachedTokens,
-						},
+					stopMetadata["usage"] = map[string]interface{}{
+						"prompt_tokens":       completed.Response.Usage.InputTokens,
+						"completion_tokens":   completed.Response.Usage.OutputTokens,
+						"reasoning_tokens":    completed.Response.Usage.OutputTokensDetails.ReasoningTokens,
+						"total_tokens":        completed.Response.Usage.TotalTokens,
+						"cached_input_tokens": completed.Response.Usage.InputTokensDetails.CachedTokens,
 					}
 				}
 			case "error":
@@ -245,7 +199,7 @@ func (c *OpenAIClient) GenerateStream(
 			})
 			eventChan <- interfaces.StreamEvent{
 				Type:      interfaces.StreamEventError,
-				Error:     fmt.Errorf("openai streaming error: %w", err),
+				Error:     fmt.Errorf("openai streaming error: %w", convertError(err)),
 				Timestamp: time.Now(),
 			}
 			return
@@ -254,7 +208,7 @@ func (c *OpenAIClient) GenerateStream(
 		eventChan <- interfaces.StreamEvent{
 			Type:      interfaces.StreamEventMessageStop,
 			Timestamp: time.Now(),
-			Metadata:  usageMetadata,
+			Metadata:  stopMetadata,
 		}
 
 		c.logger.Debug(ctx, "Successfully completed OpenAI streaming request", map[string]interface{}{
@@ -305,14 +259,20 @@ func (c *OpenAIClient) GenerateWithToolsStream(
 		bufferSize = params.StreamConfig.BufferSize
 	}
 
+	// Build input items from memory and the current prompt
+	builder := newMessageHistoryBuilder(c.logger, c.Model)
+	historyItems, err := builder.buildResponseInputItems(ctx, prompt, params.Memory, params.ContentParts...)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create event channel
 	eventChan := make(chan interfaces.StreamEvent, bufferSize)
 
 	go func() {
 		defer close(eventChan)
 
-		builder := newMessageHistoryBuilder(c.logger)
-		inputItems := builder.buildResponseInputItems(ctx, prompt, params.Memory)
+		inputItems := historyItems
 
 		// Prepend system message when provided
 		if params.SystemMessage != "" {
@@ -331,9 +291,10 @@ func (c *OpenAIClient) GenerateWithToolsStream(
 		// Convert tools to Responses format
 		responseTools := make([]responses.ToolUnionParam, len(tools))
 		for i, tool := range tools {
-			schema := c.convertToOpenAISchema(tool.Parameters())
+			schema := llm.ToolSchema(tool)
 			responseTools[i] = responses.ToolParamOfFunction(tool.Name(), schema, false)
 		}
+		responseTools = append(responseTools, c.builtinTools...)
 
 		// Send initial message start event
 		eventChan <- interfaces.StreamEvent{
@@ -393,11 +354,20 @@ func (c *OpenAIClient) GenerateWithToolsStream(
 				"input_count":   len(inputItems),
 			})
 
+			if err := llm.BeforeCall(ctx, params); err != nil {
+				eventChan <- interfaces.StreamEvent{
+					Type:      interfaces.StreamEventError,
+					Error:     err,
+					Timestamp: time.Now(),
+				}
+				return
+			}
+
 			stream := c.ResponseService.NewStreaming(ctx, req)
 			if stream.Err() != nil {
 				eventChan <- interfaces.StreamEvent{
 					Type:      interfaces.StreamEventError,
-					Error:     fmt.Errorf("openai streaming error: %w", stream.Err()),
+					Error:     fmt.Errorf("openai streaming error: %w", convertError(stream.Err())),
 					Timestamp: time.Now(),
 				}
 				return
@@ -413,6 +383,8 @@ func (c *OpenAIClient) GenerateWithToolsStream(
 			toolCallHasDelta := map[string]bool{}
 
 			var usageMetadata map[string]interface{}
+			var callUsage *interfaces.TokenUsage
+			callModel := c.Model
 			var receivedContent bool
 			var reasoningTextSent bool
 			var reasoningSummarySent bool
@@ -549,7 +521,12 @@ func (c *OpenAIClient) GenerateWithToolsStream(
 					}
 				case "response.completed":
 					completed := event.AsResponseCompleted()
+					if completed.Response.Model != "" {
+						callModel = string(completed.Response.Model)
+					}
 					if completed.Response.Usage.TotalTokens > 0 {
+						callUsage = &interfaces.TokenUsage{}
+						addResponseUsage(callUsage, &completed.Response)
 						usageMetadata = map[string]interface{}{
 							"usage": map[string]interface{}{
 								"prompt_tokens":       completed.Response.Usage.InputTokens,
@@ -576,12 +553,14 @@ func (c *OpenAIClient) GenerateWithToolsStream(
 			if err := stream.Err(); err != nil {
 				eventChan <- interfaces.StreamEvent{
 					Type:      interfaces.StreamEventError,
-					Error:     fmt.Errorf("openai streaming error: %w", err),
+					Error:     fmt.Errorf("openai streaming error: %w", convertError(err)),
 					Timestamp: time.Now(),
 				}
 				return
 			}
 
+			llm.AfterCall(ctx, params, callModel, callUsage)
+
 			// If no tool calls, we're done
 			if len(toolCalls) == 0 {
 				if receivedContent {
@@ -672,45 +651,3 @@ func (c *OpenAIClient) GenerateWithToolsStream(
 
 	return eventChan, nil
 }
-
-// convertToOpenAISchema converts tool parameters to OpenAI function schema
-func (c *OpenAIClient) convertToOpenAISchema(params map[string]interfaces.ParameterSpec) map[string]interface{} {
-	properties := make(map[string]interface{})
-	required := []string{}
-
-	for name, param := range params {
-		property := map[string]interface{}{
-			"type":        param.Type,
-			"description": param.Description,
-		}
-
-		if param.Default != nil {
-			property["default"] = param.Default
-		}
-
-		if param.Items != nil {
-			property["items"] = map[string]interface{}{
-				"type": param.Items.Type,
-			}
-			if param.Items.Enum != nil {
-				property["items"].(map[string]interface{})["enum"] = param.Items.Enum
-			}
-		}
-
-		if param.Enum != nil {
-			property["enum"] = param.Enum
-		}
-
-		properties[name] = property
-
-		if param.Required {
-			required = append(required, name)
-		}
-	}
-
-	return map[string]interface{}{
-		"type":       "object",
-		"properties": properties,
-		"required":   required,
-	}
-}
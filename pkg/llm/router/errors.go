@@ -0,0 +1,135 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/llm"
+)
+
+// ErrNoProvider is returned when no provider is available for a request, for
+// example because all circuit breakers are open
+var ErrNoProvider = errors.New("no provider available")
+
+// ErrorClass classifies provider errors for fallback decisions
+type ErrorClass string
+
+const (
+	// ErrorClassUnknown is an error that could not be classified
+	ErrorClassUnknown ErrorClass = "unknown"
+	// ErrorClassRateLimit is a rate limit or quota error
+	ErrorClassRateLimit ErrorClass = "rate_limit"
+	// ErrorClassOverloaded is an overloaded or unavailable provider
+	ErrorClassOverloaded ErrorClass = "overloaded"
+	// ErrorClassTimeout is a request that timed out
+	ErrorClassTimeout ErrorClass = "timeout"
+	// ErrorClassContextLength is a prompt that exceeds the context window of the model
+	ErrorClassContextLength ErrorClass = "context_length"
+	// ErrorClassUnsupportedContent is a content part the model does not accept
+	ErrorClassUnsupportedContent ErrorClass = "unsupported_content"
+)
+
+// DefaultFallbackClasses are the error classes that trigger a fallback to the
+// next provider by default
+var DefaultFallbackClasses = []ErrorClass{
+	ErrorClassRateLimit,
+	ErrorClassOverloaded,
+	ErrorClassTimeout,
+	ErrorClassContextLength,
+	ErrorClassUnsupportedContent,
+}
+
+// statusPattern matches the HTTP status codes reported by the provider
+// clients, such as "status 429", "HTTP 503" or `POST "https://...": 429`
+var statusPattern = regexp.MustCompile(`(?i)(?:status|http)\D{0,3}(\d{3})\b|": (\d{3}) `)
+
+var statusClasses = map[int]ErrorClass{
+	408: ErrorClassTimeout,
+	413: ErrorClassContextLength,
+	429: ErrorClassRateLimit,
+	500: ErrorClassOverloaded,
+	502: ErrorClassOverloaded,
+	503: ErrorClassOverloaded,
+	504: ErrorClassTimeout,
+	529: ErrorClassOverloaded,
+}
+
+var kindClasses = map[llm.ErrorKind]ErrorClass{
+	llm.ErrorKindRateLimited:           ErrorClassRateLimit,
+	llm.ErrorKindOverloaded:            ErrorClassOverloaded,
+	llm.ErrorKindTimeout:               ErrorClassTimeout,
+	llm.ErrorKindContextLengthExceeded: ErrorClassContextLength,
+}
+
+var classPatterns = []struct {
+	class    ErrorClass
+	patterns []string
+}{
+	{ErrorClassContextLength, []string{
+		"context_length_exceeded", "context length", "context window", "maximum context",
+		"prompt is too long", "input is too long", "too many tokens", "token limit",
+	}},
+	{ErrorClassRateLimit, []string{
+		"rate limit", "rate_limit", "ratelimit", "too many requests",
+		"resource_exhausted", "resource exhausted", "throttlingexception", "quota",
+	}},
+	{ErrorClassOverloaded, []string{
+		"overloaded", "service unavailable", "bad gateway", "internal server error",
+		"serviceunavailableexception", "server is busy",
+	}},
+	{ErrorClassTimeout, []string{
+		"timeout", "timed out", "deadline exceeded",
+	}},
+}
+
+// Classify returns the class of a provider error. Errors are classified by
+// type where possible and otherwise by the status codes and messages that
+// the provider clients report.
+func Classify(err error) ErrorClass {
+	switch {
+	case err == nil:
+		return ErrorClassUnknown
+	case errors.Is(err, interfaces.ErrUnsupportedContent):
+		return ErrorClassUnsupportedContent
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorClassTimeout
+	}
+
+	var providerErr *llm.Error
+	if errors.As(err, &providerErr) && providerErr.Kind != llm.ErrorKindUnknown {
+		if class, ok := kindClasses[providerErr.Kind]; ok {
+			return class
+		}
+		return ErrorClassUnknown
+	}
+
+	message := strings.ToLower(err.Error())
+	for _, entry := range classPatterns {
+		for _, pattern := range entry.patterns {
+			if strings.Contains(message, pattern) {
+				return entry.class
+			}
+		}
+	}
+	for _, match := range statusPattern.FindAllStringSubmatch(message, -1) {
+		code, _ := strconv.Atoi(match[1] + match[2])
+		if class, ok := statusClasses[code]; ok {
+			return class
+		}
+	}
+	return ErrorClassUnknown
+}
+
+// countsAsFailure reports whether an error class indicates an unhealthy
+// provider. Errors caused by the request itself do not open the circuit.
+func countsAsFailure(class ErrorClass) bool {
+	switch class {
+	case ErrorClassRateLimit, ErrorClassOverloaded, ErrorClassTimeout:
+		return true
+	}
+	return false
+}
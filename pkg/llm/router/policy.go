@@ -0,0 +1,86 @@
+package router
+
+import (
+	"sort"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// Request describes a request for routing policies
+type Request struct {
+	// Prompt is the prompt of the request
+	Prompt string
+
+	// Options are the generate options of the request
+	Options *interfaces.GenerateOptions
+
+	// Tools is the number of tools available to the request
+	Tools int
+
+	// EstimatedTokens is an estimate of the input tokens of the request,
+	// including the system message and the conversation history
+	EstimatedTokens int
+}
+
+// Policy orders and filters the candidate providers of a request. The
+// providers are tried in the returned order.
+type Policy func(req Request, candidates []Provider) []Provider
+
+// PromptSizePolicy drops the providers whose MaxContextTokens are smaller than
+// the estimated size of the request. If no provider has a large enough
+// context window, the candidates are returned unchanged.
+func PromptSizePolicy() Policy {
+	return func(req Request, candidates []Provider) []Provider {
+		var fitting []Provider
+		for _, provider := range candidates {
+			if provider.MaxContextTokens == 0 || provider.MaxContextTokens >= req.EstimatedTokens {
+				fitting = append(fitting, provider)
+			}
+		}
+		if len(fitting) == 0 {
+			return candidates
+		}
+		return fitting
+	}
+}
+
+// CheapestFirstPolicy orders the providers by the estimated cost of the
+// request, assuming expectedOutputTokens output tokens. Providers with equal
+// cost keep their order.
+func CheapestFirstPolicy(expectedOutputTokens int) Policy {
+	return func(req Request, candidates []Provider) []Provider {
+		ordered := append([]Provider(nil), candidates...)
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return ordered[i].EstimatedCost(req.EstimatedTokens, expectedOutputTokens) <
+				ordered[j].EstimatedCost(req.EstimatedTokens, expectedOutputTokens)
+		})
+		return ordered
+	}
+}
+
+// MaxCostPolicy drops the providers whose estimated cost of the request
+// exceeds maxCost, assuming expectedOutputTokens output tokens
+func MaxCostPolicy(maxCost float64, expectedOutputTokens int) Policy {
+	return func(req Request, candidates []Provider) []Provider {
+		var affordable []Provider
+		for _, provider := range candidates {
+			if provider.EstimatedCost(req.EstimatedTokens, expectedOutputTokens) <= maxCost {
+				affordable = append(affordable, provider)
+			}
+		}
+		return affordable
+	}
+}
+
+// estimateTokens estimates the input tokens of a request at four characters
+// per token
+func estimateTokens(prompt string, options *interfaces.GenerateOptions, history []interfaces.Message) int {
+	chars := len(prompt) + len(options.SystemMessage)
+	for _, message := range history {
+		chars += len(message.Content)
+		for _, call := range message.ToolCalls {
+			chars += len(call.Name) + len(call.Arguments)
+		}
+	}
+	return (chars + 3) / 4
+}
@@ -0,0 +1,420 @@
+// Package router provides an LLM that spreads requests over several providers
+// with fallback, weighted load balancing, circuit breakers and routing policies.
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/logging"
+)
+
+const (
+	// DefaultFailureThreshold is the number of consecutive failures after
+	// which the circuit breaker of a provider opens
+	DefaultFailureThreshold = 5
+
+	// DefaultCooldown is the time an open circuit breaker rejects requests
+	DefaultCooldown = 30 * time.Second
+)
+
+// Metadata keys set on detailed responses and stream events
+const (
+	// MetadataProvider is the name of the provider that served the request
+	MetadataProvider = "router_provider"
+
+	// MetadataAttempts is the number of providers that were tried
+	MetadataAttempts = "router_attempts"
+)
+
+// Provider is an LLM the router can send requests to
+type Provider struct {
+	// Name identifies the provider in metadata and logs, defaults to the LLM name
+	Name string
+
+	// LLM is the client of the provider
+	LLM interfaces.LLM
+
+	// Weight is the share of requests the provider receives with weighted load
+	// balancing, defaults to 1
+	Weight int
+
+	// Timeout limits the duration of a request to the provider. A request that
+	// times out falls back to the next provider.
+	Timeout time.Duration
+
+	// MaxContextTokens is the context window of the model, used by PromptSizePolicy
+	MaxContextTokens int
+
+	// InputCostPerMillion and OutputCostPerMillion are the prices per million
+	// tokens, used by the cost policies
+	InputCostPerMillion  float64
+	OutputCostPerMillion float64
+}
+
+// EstimatedCost returns the cost of a request with the given token counts
+func (p Provider) EstimatedCost(inputTokens, outputTokens int) float64 {
+	return (float64(inputTokens)*p.InputCostPerMillion + float64(outputTokens)*p.OutputCostPerMillion) / 1e6
+}
+
+// Router is an LLM that sends each request to one of several providers. If a
+// provider fails with an error of a fallback class, the request is retried
+// with the next provider.
+//
+// Tool calls are executed by the provider clients, so a request that fails
+// during a tool-calling loop is restarted on the next provider and its tools
+// may run again.
+type Router struct {
+	providers []Provider
+	breakers  map[string]*circuitBreaker
+
+	weighted         bool
+	policies         []Policy
+	fallbackClasses  map[ErrorClass]bool
+	failureThreshold int
+	cooldown         time.Duration
+	logger           logging.Logger
+
+	mu   sync.Mutex
+	rand *rand.Rand
+	now  func() time.Time
+}
+
+// Option configures a Router
+type Option func(*Router)
+
+// WithWeightedLoadBalancing spreads requests over the providers according to
+// their weights. The remaining providers are tried in weighted random order
+// on fallback. Without it providers are tried in the configured order.
+func WithWeightedLoadBalancing() Option {
+	return func(r *Router) {
+		r.weighted = true
+	}
+}
+
+// WithPolicy adds a routing policy. Policies are applied in order after load
+// balancing.
+func WithPolicy(policy Policy) Option {
+	return func(r *Router) {
+		r.policies = append(r.policies, policy)
+	}
+}
+
+// WithFallbackOn sets the error classes that trigger a fallback, replacing
+// DefaultFallbackClasses. Other errors are returned to the caller.
+func WithFallbackOn(classes ...ErrorClass) Option {
+	return func(r *Router) {
+		r.fallbackClasses = make(map[ErrorClass]bool, len(classes))
+		for _, class := range classes {
+			r.fallbackClasses[class] = true
+		}
+	}
+}
+
+// WithCircuitBreaker configures the circuit breakers. A provider is skipped
+// for the cooldown after threshold consecutive rate limit, overload or
+// timeout errors. A threshold of zero disables the circuit breakers.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(r *Router) {
+		r.failureThreshold = threshold
+		r.cooldown = cooldown
+	}
+}
+
+// WithLogger sets the logger
+func WithLogger(logger logging.Logger) Option {
+	return func(r *Router) {
+		r.logger = logger
+	}
+}
+
+// New creates a router over the given providers
+func New(providers []Provider, options ...Option) (*Router, error) {
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("router requires at least one provider")
+	}
+
+	r := &Router{
+		failureThreshold: DefaultFailureThreshold,
+		cooldown:         DefaultCooldown,
+		logger:           logging.New(),
+		rand:             rand.New(rand.NewSource(time.Now().UnixNano())),
+		now:              time.Now,
+	}
+	WithFallbackOn(DefaultFallbackClasses...)(r)
+	for _, option := range options {
+		option(r)
+	}
+
+	r.breakers = make(map[string]*circuitBreaker, len(providers))
+	for _, provider := range providers {
+		if provider.LLM == nil {
+			return nil, fmt.Errorf("provider %q has no LLM", provider.Name)
+		}
+		if provider.Name == "" {
+			provider.Name = provider.LLM.Name()
+		}
+		if _, exists := r.breakers[provider.Name]; exists {
+			return nil, fmt.Errorf("duplicate provider name %q", provider.Name)
+		}
+		if provider.Weight <= 0 {
+			provider.Weight = 1
+		}
+		r.providers = append(r.providers, provider)
+		r.breakers[provider.Name] = newCircuitBreaker(r.failureThreshold, r.cooldown, r.now)
+	}
+
+	return r, nil
+}
+
+// Name returns the name of the LLM provider
+func (r *Router) Name() string {
+	return "router"
+}
+
+// SupportsStreaming returns true if any provider supports streaming
+func (r *Router) SupportsStreaming() bool {
+	for _, provider := range r.providers {
+		if _, ok := provider.LLM.(interfaces.StreamingLLM); ok && provider.LLM.SupportsStreaming() {
+			return true
+		}
+	}
+	return false
+}
+
+// BreakerStates returns the circuit breaker state of each provider
+func (r *Router) BreakerStates() map[string]BreakerState {
+	states := make(map[string]BreakerState, len(r.breakers))
+	for name, breaker := range r.breakers {
+		states[name] = breaker.currentState()
+	}
+	return states
+}
+
+// Generate generates text with the first provider that succeeds
+func (r *Router) Generate(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (string, error) {
+	var content string
+	_, err := r.route(ctx, prompt, 0, options, false, func(ctx context.Context, provider Provider) error {
+		var err error
+		content, err = provider.LLM.Generate(ctx, prompt, options...)
+		return err
+	})
+	return content, err
+}
+
+// GenerateWithTools generates text with tools with the first provider that succeeds
+func (r *Router) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (string, error) {
+	var content string
+	_, err := r.route(ctx, prompt, len(tools), options, false, func(ctx context.Context, provider Provider) error {
+		var err error
+		content, err = provider.LLM.GenerateWithTools(ctx, prompt, tools, options...)
+		return err
+	})
+	return content, err
+}
+
+// GenerateDetailed generates text with the first provider that succeeds and
+// records the provider in the response metadata
+func (r *Router) GenerateDetailed(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (*interfaces.LLMResponse, error) {
+	var response *interfaces.LLMResponse
+	result, err := r.route(ctx, prompt, 0, options, false, func(ctx context.Context, provider Provider) error {
+		var err error
+		response, err = provider.LLM.GenerateDetailed(ctx, prompt, options...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return withRouteMetadata(response, result), nil
+}
+
+// GenerateWithToolsDetailed generates text with tools with the first provider
+// that succeeds and records the provider in the response metadata
+func (r *Router) GenerateWithToolsDetailed(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (*interfaces.LLMResponse, error) {
+	var response *interfaces.LLMResponse
+	result, err := r.route(ctx, prompt, len(tools), options, false, func(ctx context.Context, provider Provider) error {
+		var err error
+		response, err = provider.LLM.GenerateWithToolsDetailed(ctx, prompt, tools, options...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return withRouteMetadata(response, result), nil
+}
+
+// routeResult describes the provider that served a request
+type routeResult struct {
+	provider string
+	attempts int
+}
+
+// route calls the candidate providers in order until one succeeds or fails
+// with an error that does not trigger a fallback
+func (r *Router) route(ctx context.Context, prompt string, tools int, options []interfaces.GenerateOption, streaming bool, call func(ctx context.Context, provider Provider) error) (routeResult, error) {
+	candidates := r.candidates(ctx, prompt, tools, options, streaming)
+	if len(candidates) == 0 {
+		return routeResult{}, ErrNoProvider
+	}
+
+	var errs []error
+	attempts := 0
+	for _, provider := range candidates {
+		breaker := r.breakers[provider.Name]
+		if !breaker.allow() {
+			r.logger.Debug(ctx, "Skipping provider with open circuit breaker", map[string]interface{}{
+				"provider": provider.Name,
+			})
+			continue
+		}
+		attempts++
+
+		err := r.attempt(ctx, provider, streaming, call)
+		if err == nil {
+			breaker.success()
+			return routeResult{provider: provider.Name, attempts: attempts}, nil
+		}
+
+		// The caller gave up, other providers cannot help
+		if ctx.Err() != nil {
+			breaker.release()
+			return routeResult{}, err
+		}
+
+		class := Classify(err)
+		if countsAsFailure(class) {
+			breaker.failure()
+		} else {
+			breaker.release()
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", provider.Name, err))
+		if !r.fallbackClasses[class] {
+			return routeResult{}, err
+		}
+
+		r.logger.Warn(ctx, "Provider failed, falling back", map[string]interface{}{
+			"provider":    provider.Name,
+			"error_class": string(class),
+			"error":       err.Error(),
+		})
+	}
+
+	if len(errs) == 0 {
+		return routeResult{}, ErrNoProvider
+	}
+	return routeResult{}, fmt.Errorf("all providers failed: %w", errors.Join(errs...))
+}
+
+// attempt calls a provider, applying its timeout. Streaming calls apply the
+// timeout to the start of the stream themselves.
+func (r *Router) attempt(ctx context.Context, provider Provider, streaming bool, call func(ctx context.Context, provider Provider) error) error {
+	if provider.Timeout > 0 && !streaming {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, provider.Timeout)
+		defer cancel()
+	}
+	return call(ctx, provider)
+}
+
+// candidates returns the providers to try for a request, in order
+func (r *Router) candidates(ctx context.Context, prompt string, tools int, options []interfaces.GenerateOption, streaming bool) []Provider {
+	candidates := make([]Provider, 0, len(r.providers))
+	for _, provider := range r.providers {
+		if streaming {
+			if _, ok := provider.LLM.(interfaces.StreamingLLM); !ok || !provider.LLM.SupportsStreaming() {
+				continue
+			}
+		}
+		candidates = append(candidates, provider)
+	}
+
+	if r.weighted {
+		candidates = r.shuffleByWeight(candidates)
+	}
+
+	if len(r.policies) > 0 {
+		req := r.request(ctx, prompt, tools, options)
+		for _, policy := range r.policies {
+			candidates = policy(req, candidates)
+		}
+	}
+
+	return candidates
+}
+
+// request builds the routing description of a request
+func (r *Router) request(ctx context.Context, prompt string, tools int, options []interfaces.GenerateOption) Request {
+	params := &interfaces.GenerateOptions{}
+	for _, option := range options {
+		if option != nil {
+			option(params)
+		}
+	}
+
+	var history []interfaces.Message
+	if params.Memory != nil {
+		var err error
+		history, err = params.Memory.GetMessages(ctx)
+		if err != nil {
+			r.logger.Warn(ctx, "Failed to read memory for routing", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	return Request{
+		Prompt:          prompt,
+		Options:         params,
+		Tools:           tools,
+		EstimatedTokens: estimateTokens(prompt, params, history),
+	}
+}
+
+// shuffleByWeight orders providers by weighted random sampling without replacement
+func (r *Router) shuffleByWeight(providers []Provider) []Provider {
+	remaining := append([]Provider(nil), providers...)
+	ordered := make([]Provider, 0, len(providers))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for len(remaining) > 0 {
+		total := 0
+		for _, provider := range remaining {
+			total += provider.Weight
+		}
+		pick := r.rand.Intn(total)
+		for i, provider := range remaining {
+			if pick < provider.Weight {
+				ordered = append(ordered, provider)
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				break
+			}
+			pick -= provider.Weight
+		}
+	}
+	return ordered
+}
+
+// withRouteMetadata records the provider that served a request in a response
+func withRouteMetadata(response *interfaces.LLMResponse, result routeResult) *interfaces.LLMResponse {
+	if response == nil {
+		return nil
+	}
+	metadata := make(map[string]interface{}, len(response.Metadata)+2)
+	for key, value := range response.Metadata {
+		metadata[key] = value
+	}
+	metadata[MetadataProvider] = result.provider
+	metadata[MetadataAttempts] = result.attempts
+
+	routed := *response
+	routed.Metadata = metadata
+	return &routed
+}
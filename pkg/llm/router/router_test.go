@@ -0,0 +1,283 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/llm"
+)
+
+// fakeLLM answers with its name or fails with the configured error
+type fakeLLM struct {
+	name  string
+	err   error
+	delay time.Duration
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (f *fakeLLM) generate(ctx context.Context) (string, error) {
+	f.mu.Lock()
+	f.calls++
+	f.mu.Unlock()
+
+	if f.delay > 0 {
+		select {
+		case <-time.After(f.delay):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	if f.err != nil {
+		return "", f.err
+	}
+	return f.name, nil
+}
+
+func (f *fakeLLM) callCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func (f *fakeLLM) Generate(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (string, error) {
+	return f.generate(ctx)
+}
+
+func (f *fakeLLM) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (string, error) {
+	return f.generate(ctx)
+}
+
+func (f *fakeLLM) GenerateDetailed(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (*interfaces.LLMResponse, error) {
+	content, err := f.generate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &interfaces.LLMResponse{Content: content, Metadata: map[string]interface{}{"provider": f.name}}, nil
+}
+
+func (f *fakeLLM) GenerateWithToolsDetailed(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (*interfaces.LLMResponse, error) {
+	return f.GenerateDetailed(ctx, prompt, options...)
+}
+
+func (f *fakeLLM) Name() string { return f.name }
+
+func (f *fakeLLM) SupportsStreaming() bool { return true }
+
+func (f *fakeLLM) GenerateStream(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (<-chan interfaces.StreamEvent, error) {
+	content, err := f.generate(ctx)
+	events := make(chan interfaces.StreamEvent, 2)
+	if err != nil {
+		events <- interfaces.StreamEvent{Type: interfaces.StreamEventError, Error: err}
+	} else {
+		events <- interfaces.StreamEvent{Type: interfaces.StreamEventContentDelta, Content: content}
+		events <- interfaces.StreamEvent{Type: interfaces.StreamEventMessageStop}
+	}
+	close(events)
+	return events, nil
+}
+
+func (f *fakeLLM) GenerateWithToolsStream(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (<-chan interfaces.StreamEvent, error) {
+	return f.GenerateStream(ctx, prompt, options...)
+}
+
+func TestRouter_FallbackOnOverload(t *testing.T) {
+	primary := &fakeLLM{name: "anthropic", err: errors.New("anthropic API error (status 529): overloaded_error")}
+	secondary := &fakeLLM{name: "openai"}
+	r, err := New([]Provider{{LLM: primary}, {LLM: secondary}})
+	require.NoError(t, err)
+
+	response, err := r.GenerateDetailed(context.Background(), "hello")
+	require.NoError(t, err)
+	assert.Equal(t, "openai", response.Content)
+	assert.Equal(t, "openai", response.Metadata["provider"])
+	assert.Equal(t, "openai", response.Metadata[MetadataProvider])
+	assert.Equal(t, 2, response.Metadata[MetadataAttempts])
+}
+
+func TestRouter_NoFallbackOnOtherErrors(t *testing.T) {
+	primary := &fakeLLM{name: "anthropic", err: errors.New("anthropic API error (status 401): invalid x-api-key")}
+	secondary := &fakeLLM{name: "openai"}
+	r, err := New([]Provider{{LLM: primary}, {LLM: secondary}})
+	require.NoError(t, err)
+
+	_, err = r.Generate(context.Background(), "hello")
+	assert.ErrorIs(t, err, primary.err)
+	assert.Equal(t, 0, secondary.callCount())
+}
+
+func TestRouter_AllProvidersFail(t *testing.T) {
+	first := &fakeLLM{name: "first", err: errors.New("rate limit exceeded")}
+	second := &fakeLLM{name: "second", err: errors.New("status 503: service unavailable")}
+	r, err := New([]Provider{{LLM: first}, {LLM: second}})
+	require.NoError(t, err)
+
+	_, err = r.Generate(context.Background(), "hello")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, first.err)
+	assert.ErrorIs(t, err, second.err)
+	assert.Contains(t, err.Error(), "all providers failed")
+}
+
+func TestRouter_ProviderTimeout(t *testing.T) {
+	slow := &fakeLLM{name: "slow", delay: time.Second}
+	fast := &fakeLLM{name: "fast"}
+	r, err := New([]Provider{{LLM: slow, Timeout: 10 * time.Millisecond}, {LLM: fast}})
+	require.NoError(t, err)
+
+	content, err := r.Generate(context.Background(), "hello")
+	require.NoError(t, err)
+	assert.Equal(t, "fast", content)
+}
+
+func TestRouter_CircuitBreaker(t *testing.T) {
+	primary := &fakeLLM{name: "primary", err: errors.New("429 Too Many Requests")}
+	secondary := &fakeLLM{name: "secondary"}
+	r, err := New([]Provider{{LLM: primary}, {LLM: secondary}}, WithCircuitBreaker(2, time.Minute))
+	require.NoError(t, err)
+
+	now := time.Now()
+	for _, breaker := range r.breakers {
+		breaker.now = func() time.Time { return now }
+	}
+
+	for i := 0; i < 3; i++ {
+		content, err := r.Generate(context.Background(), "hello")
+		require.NoError(t, err)
+		assert.Equal(t, "secondary", content)
+	}
+	assert.Equal(t, 2, primary.callCount())
+	assert.Equal(t, BreakerOpen, r.BreakerStates()["primary"])
+
+	// After the cooldown a trial request is let through and closes the breaker
+	now = now.Add(2 * time.Minute)
+	primary.err = nil
+	content, err := r.Generate(context.Background(), "hello")
+	require.NoError(t, err)
+	assert.Equal(t, "primary", content)
+	assert.Equal(t, BreakerClosed, r.BreakerStates()["primary"])
+}
+
+func TestRouter_AllBreakersOpen(t *testing.T) {
+	only := &fakeLLM{name: "only", err: errors.New("overloaded")}
+	r, err := New([]Provider{{LLM: only}}, WithCircuitBreaker(1, time.Minute))
+	require.NoError(t, err)
+
+	_, err = r.Generate(context.Background(), "hello")
+	require.Error(t, err)
+	_, err = r.Generate(context.Background(), "hello")
+	assert.ErrorIs(t, err, ErrNoProvider)
+}
+
+func TestRouter_WeightedLoadBalancing(t *testing.T) {
+	light := &fakeLLM{name: "light"}
+	heavy := &fakeLLM{name: "heavy"}
+	r, err := New([]Provider{{LLM: light, Weight: 1}, {LLM: heavy, Weight: 3}}, WithWeightedLoadBalancing())
+	require.NoError(t, err)
+
+	for i := 0; i < 1000; i++ {
+		_, err := r.Generate(context.Background(), "hello")
+		require.NoError(t, err)
+	}
+	assert.InDelta(t, 750, heavy.callCount(), 75)
+	assert.Equal(t, 1000, light.callCount()+heavy.callCount())
+}
+
+func TestRouter_Policies(t *testing.T) {
+	small := &fakeLLM{name: "small"}
+	large := &fakeLLM{name: "large"}
+	providers := []Provider{
+		{LLM: small, MaxContextTokens: 100, InputCostPerMillion: 0.1},
+		{LLM: large, MaxContextTokens: 100000, InputCostPerMillion: 3},
+	}
+
+	t.Run("PromptSize", func(t *testing.T) {
+		r, err := New(providers, WithPolicy(PromptSizePolicy()))
+		require.NoError(t, err)
+
+		content, err := r.Generate(context.Background(), "short")
+		require.NoError(t, err)
+		assert.Equal(t, "small", content)
+
+		content, err = r.Generate(context.Background(), strings.Repeat("long prompt ", 100))
+		require.NoError(t, err)
+		assert.Equal(t, "large", content)
+	})
+
+	t.Run("Cost", func(t *testing.T) {
+		reversed := []Provider{providers[1], providers[0]}
+		r, err := New(reversed, WithPolicy(CheapestFirstPolicy(500)))
+		require.NoError(t, err)
+
+		content, err := r.Generate(context.Background(), "hello")
+		require.NoError(t, err)
+		assert.Equal(t, "small", content)
+
+		r, err = New(reversed, WithPolicy(MaxCostPolicy(0.000001, 0)))
+		require.NoError(t, err)
+		_, err = r.Generate(context.Background(), strings.Repeat("x", 400))
+		assert.ErrorIs(t, err, ErrNoProvider)
+	})
+}
+
+func TestRouter_StreamFallback(t *testing.T) {
+	primary := &fakeLLM{name: "primary", err: errors.New("rate_limit_error")}
+	secondary := &fakeLLM{name: "secondary"}
+	r, err := New([]Provider{{LLM: primary}, {LLM: secondary}})
+	require.NoError(t, err)
+
+	events, err := r.GenerateStream(context.Background(), "hello")
+	require.NoError(t, err)
+
+	var received []interfaces.StreamEvent
+	for event := range events {
+		received = append(received, event)
+	}
+	require.Len(t, received, 2)
+	assert.Equal(t, "secondary", received[0].Content)
+	assert.Equal(t, "secondary", received[0].Metadata[MetadataProvider])
+	assert.Equal(t, 2, received[1].Metadata[MetadataAttempts])
+}
+
+func TestNew_Validation(t *testing.T) {
+	_, err := New(nil)
+	assert.Error(t, err)
+
+	_, err = New([]Provider{{LLM: &fakeLLM{name: "a"}}, {LLM: &fakeLLM{name: "a"}}})
+	assert.Error(t, err)
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		err  error
+		want ErrorClass
+	}{
+		{errors.New("anthropic API error (status 429): rate limited"), ErrorClassRateLimit},
+		{errors.New(`POST "https://api.openai.com/v1/chat/completions": 429 Too Many Requests`), ErrorClassRateLimit},
+		{errors.New("bedrock API error (status 400, ThrottlingException): slow down"), ErrorClassRateLimit},
+		{errors.New("error from Anthropic API: HTTP 529"), ErrorClassOverloaded},
+		{errors.New("gemini API error (status 503): UNAVAILABLE"), ErrorClassOverloaded},
+		{fmt.Errorf("request failed: %w", context.DeadlineExceeded), ErrorClassTimeout},
+		{errors.New("This model's maximum context length is 8192 tokens"), ErrorClassContextLength},
+		{errors.New("prompt is too long: 210000 tokens > 200000 maximum"), ErrorClassContextLength},
+		{&interfaces.UnsupportedContentError{Provider: "ollama", Type: interfaces.ContentPartAudio}, ErrorClassUnsupportedContent},
+		{errors.New("API request failed with status 401: unauthorized"), ErrorClassUnknown},
+		{errors.New("invalid request: 500 tools"), ErrorClassUnknown},
+		{fmt.Errorf("iteration 2: %w", llm.NewAPIError("openai", 429, nil, nil)), ErrorClassRateLimit},
+		{llm.NewAPIError("anthropic", 400, nil, []byte(`{"error":{"type":"invalid_request_error","message":"prompt is too long"}}`)), ErrorClassContextLength},
+		{llm.NewError("gemini", llm.ErrorKindContentFiltered, "SAFETY", "prompt blocked"), ErrorClassUnknown},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Classify(tt.err), tt.err.Error())
+	}
+}
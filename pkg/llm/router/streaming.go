@@ -0,0 +1,121 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// GenerateStream streams text from the first provider that starts a stream.
+// A provider can only be replaced until it has sent its first event, errors
+// after that are forwarded to the caller.
+func (r *Router) GenerateStream(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (<-chan interfaces.StreamEvent, error) {
+	return r.stream(ctx, prompt, 0, options, func(ctx context.Context, llm interfaces.StreamingLLM) (<-chan interfaces.StreamEvent, error) {
+		return llm.GenerateStream(ctx, prompt, options...)
+	})
+}
+
+// GenerateWithToolsStream streams text with tools from the first provider that
+// starts a stream
+func (r *Router) GenerateWithToolsStream(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (<-chan interfaces.StreamEvent, error) {
+	return r.stream(ctx, prompt, len(tools), options, func(ctx context.Context, llm interfaces.StreamingLLM) (<-chan interfaces.StreamEvent, error) {
+		return llm.GenerateWithToolsStream(ctx, prompt, tools, options...)
+	})
+}
+
+func (r *Router) stream(ctx context.Context, prompt string, tools int, options []interfaces.GenerateOption, start func(ctx context.Context, llm interfaces.StreamingLLM) (<-chan interfaces.StreamEvent, error)) (<-chan interfaces.StreamEvent, error) {
+	var (
+		events <-chan interfaces.StreamEvent
+		first  interfaces.StreamEvent
+		cancel context.CancelFunc
+	)
+
+	result, err := r.route(ctx, prompt, tools, options, true, func(ctx context.Context, provider Provider) error {
+		var err error
+		events, first, cancel, err = startStream(ctx, provider, start)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan interfaces.StreamEvent, cap(events)+1)
+	go func() {
+		defer close(out)
+		defer cancel()
+
+		out <- withEventMetadata(first, result)
+		for event := range events {
+			out <- withEventMetadata(event, result)
+		}
+	}()
+
+	return out, nil
+}
+
+// startStream starts a stream and waits for its first event, so that a
+// provider that fails right away can be replaced. The provider timeout
+// applies to the first event only.
+func startStream(ctx context.Context, provider Provider, start func(ctx context.Context, llm interfaces.StreamingLLM) (<-chan interfaces.StreamEvent, error)) (<-chan interfaces.StreamEvent, interfaces.StreamEvent, context.CancelFunc, error) {
+	streamCtx, cancel := context.WithCancel(ctx)
+
+	var timer *time.Timer
+	if provider.Timeout > 0 {
+		timer = time.AfterFunc(provider.Timeout, cancel)
+	}
+	var events <-chan interfaces.StreamEvent
+	fail := func(err error) (<-chan interfaces.StreamEvent, interfaces.StreamEvent, context.CancelFunc, error) {
+		cancel()
+		if events != nil {
+			// Drain the abandoned stream so that its producer can finish
+			go func() {
+				for range events {
+				}
+			}()
+		}
+		if timer != nil && !timer.Stop() && ctx.Err() == nil {
+			err = fmt.Errorf("stream did not start within %s: %w", provider.Timeout, context.DeadlineExceeded)
+		}
+		return nil, interfaces.StreamEvent{}, nil, err
+	}
+
+	var err error
+	events, err = start(streamCtx, provider.LLM.(interfaces.StreamingLLM))
+	if err != nil {
+		return fail(err)
+	}
+
+	var first interfaces.StreamEvent
+	select {
+	case event, ok := <-events:
+		if !ok {
+			return fail(fmt.Errorf("stream closed without events"))
+		}
+		if event.Type == interfaces.StreamEventError && event.Error != nil {
+			return fail(event.Error)
+		}
+		first = event
+	case <-streamCtx.Done():
+		return fail(streamCtx.Err())
+	}
+
+	if timer != nil && !timer.Stop() {
+		// The timeout fired together with the first event
+		return fail(context.DeadlineExceeded)
+	}
+	return events, first, cancel, nil
+}
+
+// withEventMetadata records the provider that serves a stream in an event
+func withEventMetadata(event interfaces.StreamEvent, result routeResult) interfaces.StreamEvent {
+	metadata := make(map[string]interface{}, len(event.Metadata)+2)
+	for key, value := range event.Metadata {
+		metadata[key] = value
+	}
+	metadata[MetadataProvider] = result.provider
+	metadata[MetadataAttempts] = result.attempts
+	event.Metadata = metadata
+	return event
+}
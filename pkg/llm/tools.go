@@ -0,0 +1,60 @@
+package llm
+
+import (
+	"sort"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// ToolSchema returns the JSON schema of the arguments of a tool: its own
+// schema when it implements interfaces.ToolWithJSONSchema, and the schema of
+// its parameters otherwise. The schema is an object schema with properties
+// and required fields. Clients convert it to the tool format of their
+// provider and must not modify it.
+func ToolSchema(tool interfaces.Tool) map[string]interface{} {
+	if withSchema, ok := tool.(interfaces.ToolWithJSONSchema); ok {
+		if schema := withSchema.JSONSchema(); schema != nil {
+			return map[string]interface{}(schema)
+		}
+	}
+	return ParametersSchema(tool.Parameters())
+}
+
+// ParametersSchema converts parameter specs to an object schema
+func ParametersSchema(params map[string]interfaces.ParameterSpec) map[string]interface{} {
+	properties := make(map[string]interface{}, len(params))
+	required := []string{}
+	for name, param := range params {
+		properties[name] = parameterSchema(param)
+		if param.Required {
+			required = append(required, name)
+		}
+	}
+	sort.Strings(required)
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// parameterSchema converts a parameter spec to a property schema
+func parameterSchema(param interfaces.ParameterSpec) map[string]interface{} {
+	schema := map[string]interface{}{
+		"type": param.Type,
+	}
+	if param.Description != "" {
+		schema["description"] = param.Description
+	}
+	if param.Default != nil {
+		schema["default"] = param.Default
+	}
+	if param.Enum != nil {
+		schema["enum"] = param.Enum
+	}
+	if param.Items != nil {
+		schema["items"] = parameterSchema(*param.Items)
+	}
+	return schema
+}
@@ -0,0 +1,71 @@
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+type schemaTool struct {
+	params map[string]interfaces.ParameterSpec
+	schema interfaces.JSONSchema
+}
+
+func (t schemaTool) Name() string        { return "lookup" }
+func (t schemaTool) Description() string { return "Look up records" }
+func (t schemaTool) Run(ctx context.Context, input string) (string, error) {
+	return input, nil
+}
+func (t schemaTool) Parameters() map[string]interfaces.ParameterSpec { return t.params }
+func (t schemaTool) Execute(ctx context.Context, args string) (string, error) {
+	return args, nil
+}
+func (t schemaTool) JSONSchema() interfaces.JSONSchema { return t.schema }
+
+func TestToolSchema_Parameters(t *testing.T) {
+	tool := schemaTool{params: map[string]interfaces.ParameterSpec{
+		"query": {Type: "string", Description: "Search query", Required: true},
+		"tags":  {Type: "array", Items: &interfaces.ParameterSpec{Type: "object"}},
+		"limit": {Type: "integer", Default: 10, Required: true},
+	}}
+
+	schema := ToolSchema(tool)
+	assert.Equal(t, "object", schema["type"])
+	assert.Equal(t, []string{"limit", "query"}, schema["required"])
+
+	properties := schema["properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"type": "string", "description": "Search query"}, properties["query"])
+	assert.Equal(t, map[string]interface{}{"type": "integer", "default": 10}, properties["limit"])
+	assert.Equal(t, map[string]interface{}{"type": "object"}, properties["tags"].(map[string]interface{})["items"])
+}
+
+func TestToolSchema_JSONSchema(t *testing.T) {
+	full := interfaces.JSONSchema{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"range": map[string]interface{}{
+				"oneOf": []interface{}{
+					map[string]interface{}{"type": "integer", "minimum": 0},
+					map[string]interface{}{"type": "string", "pattern": "^[0-9]+-[0-9]+$"},
+				},
+			},
+		},
+	}
+	tool := schemaTool{
+		params: map[string]interfaces.ParameterSpec{"range": {Type: "string"}},
+		schema: full,
+	}
+
+	schema := ToolSchema(tool)
+	require.Contains(t, schema, "properties")
+	assert.Equal(t, map[string]interface{}(full), schema)
+
+	// Tools without a schema fall back to their parameters
+	tool.schema = nil
+	schema = ToolSchema(tool)
+	assert.Equal(t, map[string]interface{}{"type": "string"}, schema["properties"].(map[string]interface{})["range"])
+}
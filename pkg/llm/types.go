@@ -1,10 +1,13 @@
 package llm
 
+import "github.com/tagus/agent-sdk-go/pkg/interfaces"
+
 // Message represents a message in a chat conversation
 type Message struct {
 	Role       string // "system", "user", "assistant", "tool"
 	Content    string
-	ToolCallID string // ID of the tool call this message is responding to (for tool messages)
+	Parts      []interfaces.ContentPart // Additional content such as images or documents (user messages)
+	ToolCallID string                   // ID of the tool call this message is responding to (for tool messages)
 }
 
 // GenerateParams contains parameters for text generation
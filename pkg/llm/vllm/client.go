@@ -46,7 +46,7 @@ func WithLogger(logger logging.Logger) Option {
 // WithRetry configures retry policy for the client
 func WithRetry(opts ...retry.Option) Option {
 	return func(c *VLLMClient) {
-		c.retryExecutor = retry.NewExecutor(retry.NewPolicy(opts...))
+		c.retryExecutor = retry.NewExecutor(llm.NewRetryPolicy(opts...))
 	}
 }
 
@@ -130,8 +130,9 @@ type ChatRequest struct {
 }
 
 type ChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role    string            `json:"role"`
+	Content string            `json:"content"`
+	Parts   []ChatContentPart `json:"-"` // Images and audio sent after the text content
 }
 
 type ChatResponse struct {
@@ -213,6 +214,11 @@ Ensure your response is a valid JSON object that strictly follows the schema abo
 
 	}
 
+	// Content parts of the current turn need the chat endpoint
+	if parts := llm.CurrentTurnParts(ctx, params); len(parts) > 0 {
+		return c.generateWithContentParts(ctx, req, params.SystemMessage, parts)
+	}
+
 	// Make request
 	resp, err := c.makeRequest(ctx, "/v1/completions", req)
 	if err != nil {
@@ -265,9 +271,14 @@ func (c *VLLMClient) Chat(ctx context.Context, messages []llm.Message, params *l
 	// Convert messages to vLLM format
 	var chatMessages []ChatMessage
 	for _, msg := range messages {
+		parts, err := convertContentParts(c.Model, msg.Parts)
+		if err != nil {
+			return "", err
+		}
 		chatMessages = append(chatMessages, ChatMessage{
 			Role:    msg.Role,
 			Content: msg.Content,
+			Parts:   parts,
 		})
 	}
 
@@ -343,7 +354,7 @@ func (c *VLLMClient) makeRequest(ctx context.Context, endpoint string, payload i
 	}
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+		return nil, fmt.Errorf("failed to execute request: %w", llm.WrapError("vllm", err))
 	}
 	defer func() {
 		if resp != nil {
@@ -359,7 +370,7 @@ func (c *VLLMClient) makeRequest(ctx context.Context, endpoint string, payload i
 
 	// Check status code
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, llm.NewAPIError("vllm", resp.StatusCode, resp.Header, body)
 	}
 
 	return body, nil
@@ -386,7 +397,7 @@ func (c *VLLMClient) makeGETRequest(ctx context.Context, endpoint string) ([]byt
 	}
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+		return nil, fmt.Errorf("failed to execute request: %w", llm.WrapError("vllm", err))
 	}
 	defer func() {
 		if resp != nil {
@@ -402,7 +413,7 @@ func (c *VLLMClient) makeGETRequest(ctx context.Context, endpoint string) ([]byt
 
 	// Check status code
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, llm.NewAPIError("vllm", resp.StatusCode, resp.Header, body)
 	}
 
 	return body, nil
@@ -2,6 +2,9 @@ package vllm
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/tagus/agent-sdk-go/pkg/interfaces"
@@ -185,6 +188,35 @@ func TestChatRequestStructure(t *testing.T) {
 	assert.Equal(t, 1, req.N)
 }
 
+func TestGenerateWithContentParts(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		_, _ = w.Write([]byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"A chart"}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithModel("qwen2-vl"), WithBaseURL(server.URL))
+	response, err := client.Generate(context.Background(), "What is shown?",
+		interfaces.WithSystemMessage("Be brief."),
+		interfaces.WithContentParts(interfaces.ImageURLPart("https://example.com/chart.png")),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "A chart", response)
+
+	messages := body["messages"].([]interface{})
+	assert.Equal(t, map[string]interface{}{"role": "system", "content": "Be brief."}, messages[0])
+	assert.Equal(t, map[string]interface{}{"role": "user", "content": []interface{}{
+		map[string]interface{}{"type": "text", "text": "User: What is shown?"},
+		map[string]interface{}{"type": "image_url", "image_url": map[string]interface{}{"url": "https://example.com/chart.png"}},
+	}}, messages[1])
+
+	_, err = client.Generate(context.Background(), "Summarize",
+		interfaces.WithContentParts(interfaces.DocumentURLPart("https://example.com/report.pdf")))
+	assert.ErrorIs(t, err, interfaces.ErrUnsupportedContent)
+}
+
 func TestGenerateResponseStructure(t *testing.T) {
 	// Test that GenerateResponse can handle typical response structure
 	resp := GenerateResponse{
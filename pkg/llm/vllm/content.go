@@ -0,0 +1,106 @@
+package vllm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// ChatContentPart is a part of a multimodal chat message
+type ChatContentPart struct {
+	Type     string    `json:"type"` // "text", "image_url" or "audio_url"
+	Text     string    `json:"text,omitempty"`
+	ImageURL *MediaURL `json:"image_url,omitempty"`
+	AudioURL *MediaURL `json:"audio_url,omitempty"`
+}
+
+// MediaURL references media by http(s) URL or data URL
+type MediaURL struct {
+	URL string `json:"url"`
+}
+
+// MarshalJSON sends messages with parts as an array of content parts and
+// other messages with plain string content
+func (m ChatMessage) MarshalJSON() ([]byte, error) {
+	if len(m.Parts) == 0 {
+		return json.Marshal(struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		}{m.Role, m.Content})
+	}
+
+	content := make([]ChatContentPart, 0, len(m.Parts)+1)
+	if m.Content != "" {
+		content = append(content, ChatContentPart{Type: "text", Text: m.Content})
+	}
+	content = append(content, m.Parts...)
+
+	return json.Marshal(struct {
+		Role    string            `json:"role"`
+		Content []ChatContentPart `json:"content"`
+	}{m.Role, content})
+}
+
+// convertContentParts converts content parts to vLLM chat content parts.
+// vLLM accepts images and audio for multimodal models, documents are rejected.
+func convertContentParts(model string, parts []interfaces.ContentPart) ([]ChatContentPart, error) {
+	var converted []ChatContentPart
+	for _, part := range parts {
+		if err := part.Validate(); err != nil {
+			return nil, err
+		}
+
+		switch part.Type {
+		case interfaces.ContentPartText:
+			converted = append(converted, ChatContentPart{Type: "text", Text: part.Text})
+		case interfaces.ContentPartImage:
+			converted = append(converted, ChatContentPart{Type: "image_url", ImageURL: &MediaURL{URL: part.DataURL()}})
+		case interfaces.ContentPartAudio:
+			converted = append(converted, ChatContentPart{Type: "audio_url", AudioURL: &MediaURL{URL: part.DataURL()}})
+		default:
+			return nil, &interfaces.UnsupportedContentError{Provider: "vllm", Model: model, Type: part.Type}
+		}
+	}
+	return converted, nil
+}
+
+// generateWithContentParts sends a prompt with content parts to the chat
+// endpoint, since the completions endpoint only accepts text
+func (c *VLLMClient) generateWithContentParts(ctx context.Context, req GenerateRequest, system string, parts []interfaces.ContentPart) (string, error) {
+	contentParts, err := convertContentParts(c.Model, parts)
+	if err != nil {
+		return "", err
+	}
+
+	var messages []ChatMessage
+	if system != "" {
+		messages = append(messages, ChatMessage{Role: "system", Content: system})
+	}
+	messages = append(messages, ChatMessage{Role: "user", Content: req.Prompt, Parts: contentParts})
+
+	chatReq := ChatRequest{
+		Model:       req.Model,
+		Messages:    messages,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		Stop:        req.Stop,
+	}
+
+	resp, err := c.makeRequest(ctx, "/v1/chat/completions", chatReq)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate text: %w", err)
+	}
+
+	var chatResp ChatResponse
+	if err := json.Unmarshal(resp, &chatResp); err != nil {
+		return "", fmt.Errorf("failed to unmarshal chat response: %w", err)
+	}
+
+	if len(chatResp.Choices) == 0 {
+		return "", fmt.Errorf("no choices in chat response")
+	}
+
+	return chatResp.Choices[0].Message.Content, nil
+}
@@ -14,6 +14,7 @@ type MCPToolAdapter struct {
 	toolName  string
 	toolDesc  string
 	params    map[string]ParameterSpec
+	schema    map[string]interface{}
 }
 
 // ParameterSpec represents a parameter specification for an MCP tool
@@ -33,6 +34,31 @@ func NewMCPToolAdapter(server interfaces.MCPServer, name, description string, pa
 	}
 }
 
+// NewMCPToolAdapterWithSchema creates a new adapter for an MCP tool with the
+// input schema of the tool, which is passed to the LLM as is
+func NewMCPToolAdapterWithSchema(server interfaces.MCPServer, name, description string, schema map[string]interface{}) *MCPToolAdapter {
+	params := make(map[string]ParameterSpec)
+	properties, _ := schema["properties"].(map[string]interface{})
+	for paramName, property := range properties {
+		spec, ok := property.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		paramType, _ := spec["type"].(string)
+		paramDesc, _ := spec["description"].(string)
+		params[paramName] = ParameterSpec{
+			Type:        paramType,
+			Description: paramDesc,
+			Required:    isRequired(schema, paramName),
+		}
+	}
+
+	adapter := NewMCPToolAdapter(server, name, description, params)
+	adapter.schema = schema
+	return adapter
+}
+
 // Name returns the name of the tool
 func (a *MCPToolAdapter) Name() string {
 	return a.toolName
@@ -72,6 +98,12 @@ func (a *MCPToolAdapter) Parameters() map[string]interfaces.ParameterSpec {
 	return result
 }
 
+// JSONSchema implements interfaces.ToolWithJSONSchema.JSONSchema. It returns
+// nil for adapters created without an input schema.
+func (a *MCPToolAdapter) JSONSchema() interfaces.JSONSchema {
+	return a.schema
+}
+
 // InputSchema returns the schema for the tool's input
 func (a *MCPToolAdapter) InputSchema() map[string]interfaces.ParameterSpec {
 	return a.Parameters()
@@ -110,6 +142,12 @@ func ConvertMCPTools(server interfaces.MCPServer, mcpTools []map[string]interfac
 			description = "No description provided"
 		}
 
+		// Prefer the full input schema of the tool
+		if inputSchema, ok := mcpTool["inputSchema"].(map[string]interface{}); ok {
+			tools = append(tools, NewMCPToolAdapterWithSchema(server, name, description, inputSchema))
+			continue
+		}
+
 		// Extract parameters
 		paramsMap, ok := mcpTool["parameters"].(map[string]interface{})
 		if !ok {
@@ -142,3 +180,22 @@ func ConvertMCPTools(server interfaces.MCPServer, mcpTools []map[string]interfac
 
 	return tools, nil
 }
+
+// isRequired reports whether a property is required by an object schema
+func isRequired(schema map[string]interface{}, name string) bool {
+	switch required := schema["required"].(type) {
+	case []interface{}:
+		for _, value := range required {
+			if value == name {
+				return true
+			}
+		}
+	case []string:
+		for _, value := range required {
+			if value == name {
+				return true
+			}
+		}
+	}
+	return false
+}
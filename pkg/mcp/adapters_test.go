@@ -0,0 +1,65 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+func TestConvertMCPTools_InputSchema(t *testing.T) {
+	inputSchema := map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"filter"},
+		"properties": map[string]interface{}{
+			"filter": map[string]interface{}{
+				"type":        "object",
+				"description": "Filter of the query",
+				"properties": map[string]interface{}{
+					"since": map[string]interface{}{"type": "string", "format": "date-time"},
+					"limit": map[string]interface{}{"type": "integer", "minimum": 1, "maximum": 100},
+				},
+			},
+		},
+	}
+
+	tools, err := ConvertMCPTools(&mockMCPServer{}, []map[string]interface{}{
+		{"name": "query", "description": "Query events", "inputSchema": inputSchema},
+		{"name": "ping", "parameters": map[string]interface{}{
+			"host": map[string]interface{}{"type": "string", "required": true},
+		}},
+	})
+	require.NoError(t, err)
+	require.Len(t, tools, 2)
+
+	// The input schema is kept as is
+	query := tools[0].(interfaces.ToolWithJSONSchema)
+	assert.Equal(t, interfaces.JSONSchema(inputSchema), query.JSONSchema())
+
+	params := tools[0].Parameters()
+	assert.Equal(t, interfaces.ParameterSpec{Type: "object", Description: "Filter of the query", Required: true}, params["filter"])
+
+	// Tools described by parameters have no schema of their own
+	assert.Nil(t, tools[1].(interfaces.ToolWithJSONSchema).JSONSchema())
+	assert.True(t, tools[1].Parameters()["host"].Required)
+}
+
+func TestMCPTool_JSONSchema(t *testing.T) {
+	schema := &jsonschema.Schema{
+		Type:     "object",
+		Required: []string{"tags"},
+		Properties: map[string]*jsonschema.Schema{
+			"tags": {Type: "array", Items: &jsonschema.Schema{Type: "string", Pattern: "^[a-z]+$"}},
+		},
+	}
+
+	tool := NewMCPTool("tag", "Tag an item", schema, &mockMCPServer{})
+
+	full := tool.(interfaces.ToolWithJSONSchema).JSONSchema()
+	tags := full["properties"].(map[string]interface{})["tags"].(map[string]interface{})
+	assert.Equal(t, "^[a-z]+$", tags["items"].(map[string]interface{})["pattern"])
+	assert.Equal(t, []interface{}{"tags"}, full["required"])
+}
@@ -622,6 +622,31 @@ func (t *LazyMCPTool) Parameters() map[string]interfaces.ParameterSpec {
 	return params
 }
 
+// JSONSchema implements interfaces.ToolWithJSONSchema.JSONSchema with the
+// input schema discovered from the MCP server
+func (t *LazyMCPTool) JSONSchema() interfaces.JSONSchema {
+	ctx := context.Background() // Use background context for schema discovery
+	if err := t.discoverSchema(ctx); err != nil {
+		t.logger.Warn(ctx, "Failed to discover schema for tool", map[string]interface{}{
+			"tool_name": t.name,
+			"error":     err.Error(),
+		})
+		return nil
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	schema, err := toSchemaMap(t.schema)
+	if err != nil {
+		t.logger.Warn(ctx, "Failed to convert schema of tool", map[string]interface{}{
+			"tool_name": t.name,
+			"error":     err.Error(),
+		})
+		return nil
+	}
+	return schema
+}
+
 // Execute executes the tool with the given arguments
 func (t *LazyMCPTool) Execute(ctx context.Context, args string) (string, error) {
 	// This is the same as Run for LazyMCPTool
@@ -135,8 +135,45 @@ func (t *MCPTool) Parameters() map[string]interfaces.ParameterSpec {
 	return params
 }
 
+// JSONSchema implements interfaces.ToolWithJSONSchema.JSONSchema with the
+// input schema of the tool, including nested objects and constraints
+func (t *MCPTool) JSONSchema() interfaces.JSONSchema {
+	schema, err := toSchemaMap(t.schema)
+	if err != nil {
+		return nil
+	}
+	return schema
+}
+
 // Execute executes the tool with the given arguments
 func (t *MCPTool) Execute(ctx context.Context, args string) (string, error) {
 	// This is the same as Run for MCPTool
 	return t.Run(ctx, args)
 }
+
+// toSchemaMap converts an input schema, which MCP servers describe with
+// different types, to a JSON schema map
+func toSchemaMap(schema interface{}) (map[string]interface{}, error) {
+	switch s := schema.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		return s, nil
+	case string:
+		var schemaMap map[string]interface{}
+		if err := json.Unmarshal([]byte(s), &schemaMap); err != nil {
+			return nil, fmt.Errorf("failed to parse schema JSON string: %w", err)
+		}
+		return schemaMap, nil
+	default:
+		data, err := json.Marshal(s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal schema: %w", err)
+		}
+		var schemaMap map[string]interface{}
+		if err := json.Unmarshal(data, &schemaMap); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal schema: %w", err)
+		}
+		return schemaMap, nil
+	}
+}
@@ -3,10 +3,16 @@ package memory
 import (
 	"context"
 	"fmt"
+	"os"
+	"strings"
 	"time"
 
-	"github.com/tagus/agent-sdk-go/pkg/interfaces"
 	"github.com/go-redis/redis/v8"
+
+	"github.com/tagus/agent-sdk-go/pkg/embedding"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/vectorstore/inmemory"
+	"github.com/tagus/agent-sdk-go/pkg/vectorstore/pgvector"
 )
 
 // MemoryFactory provides factory functions to create memory instances from configuration
@@ -215,13 +221,122 @@ func (f *MemoryFactory) createBufferMemory(config map[string]interface{}, llmCli
 
 // createVectorMemory creates a vector memory instance from configuration
 func (f *MemoryFactory) createVectorMemory(config map[string]interface{}, llmClient interfaces.LLM) (interfaces.Memory, error) {
-	// Vector memory would require additional dependencies and configuration
-	// This is a placeholder for future implementation
-	return nil, fmt.Errorf("vector memory not yet implemented")
+	store, err := f.createVectorStore(config)
+	if err != nil {
+		return nil, err
+	}
+	return NewVectorStoreRetriever(store), nil
+}
+
+// createVectorStore creates a vector store from configuration
+func (f *MemoryFactory) createVectorStore(config map[string]interface{}) (interfaces.VectorStore, error) {
+	storeType, _ := config["vector_store"].(string)
+	if storeType == "" {
+		storeType = "inmemory"
+	}
+
+	storeConfig := &interfaces.VectorStoreConfig{}
+	if metric, ok := config["distance_metric"].(string); ok {
+		storeConfig.DistanceMetric = metric
+	}
+	if prefix, ok := config["class_prefix"].(string); ok {
+		storeConfig.ClassPrefix = prefix
+	}
+
+	embedder, err := f.createEmbedder(config)
+	if err != nil {
+		return nil, err
+	}
+
+	switch storeType {
+	case "inmemory", "memory":
+		var options []inmemory.Option
+		if embedder != nil {
+			options = append(options, inmemory.WithEmbedder(embedder))
+		}
+		if path, ok := config["snapshot_path"].(string); ok && path != "" {
+			options = append(options, inmemory.WithSnapshotPath(path))
+		}
+
+		store, err := inmemory.New(storeConfig, options...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create in-memory vector store: %w", err)
+		}
+		return store, nil
+	case "pgvector", "postgres":
+		connectionString, ok := config["connection_string"].(string)
+		if !ok || connectionString == "" {
+			return nil, fmt.Errorf("pgvector connection_string not specified or empty")
+		}
+
+		var options []pgvector.Option
+		if embedder != nil {
+			options = append(options, pgvector.WithEmbedder(embedder))
+		}
+		if mode, ok := config["tenant_mode"].(string); ok && mode != "" {
+			options = append(options, pgvector.WithTenantMode(pgvector.TenantMode(mode)))
+		}
+		if schema, ok := config["schema"].(string); ok && schema != "" {
+			options = append(options, pgvector.WithSchema(schema))
+		}
+		if dimVal, ok := config["dimensions"]; ok {
+			switch v := dimVal.(type) {
+			case int:
+				options = append(options, pgvector.WithDimensions(v))
+			case float64:
+				options = append(options, pgvector.WithDimensions(int(v)))
+			}
+		}
+
+		store, err := pgvector.NewFromConnectionString(connectionString, storeConfig, options...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create pgvector store: %w", err)
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unsupported vector store: %s", storeType)
+	}
+}
+
+// createEmbedder creates an embedding client for vector memory from the
+// embedding_* keys. Without an embedding_provider it returns an OpenAI embedder
+// when an API key is available and nil otherwise, in which case the store falls
+// back to keyword search. A positive embedding_cache_size wraps the client with
+// an in-memory LRU cache.
+func (f *MemoryFactory) createEmbedder(config map[string]interface{}) (embedding.Client, error) {
+	embeddingConfig := map[string]interface{}{}
+	for key, value := range config {
+		if name, ok := strings.CutPrefix(key, "embedding_"); ok {
+			embeddingConfig[name] = value
+		}
+	}
+
+	if _, ok := embeddingConfig["provider"]; !ok {
+		apiKey, _ := embeddingConfig["api_key"].(string)
+		if apiKey == "" && os.Getenv("OPENAI_API_KEY") == "" {
+			return nil, nil
+		}
+	}
+
+	embedder, err := embedding.NewClientFromMap(embeddingConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedder: %w", err)
+	}
+	if size, ok := embeddingConfig["cache_size"].(int); ok && size > 0 {
+		return embedding.NewCachedClient(embedder, embedding.NewLRUCache(size)), nil
+	}
+	return embedder, nil
 }
 
 // NewMemoryFromConfig is a convenience function to create memory from config map
 func NewMemoryFromConfig(config map[string]interface{}, llmClient interfaces.LLM) (interfaces.Memory, error) {
 	factory := NewMemoryFactory()
 	return factory.CreateMemory(config, llmClient)
-}
\ No newline at end of file
+}
+
+// NewVectorStoreFromConfig creates a vector store from the same configuration map
+// used by vector memory
+func NewVectorStoreFromConfig(config map[string]interface{}) (interfaces.VectorStore, error) {
+	factory := NewMemoryFactory()
+	return factory.createVectorStore(config)
+}
@@ -282,3 +282,27 @@ func TestRedisMemoryOptions(t *testing.T) {
 		assert.Equal(t, "custom:summary:", memory.summaryKeyPrefix)
 	})
 }
+
+func TestRedisMemoryContentParts(t *testing.T) {
+	client, mr := setupTestRedisClient(t)
+	defer mr.Close()
+
+	memory := NewRedisMemory(client)
+	ctx := multitenancy.WithOrgID(context.Background(), "test-org")
+	ctx = WithConversationID(ctx, "test-conversation")
+
+	msg := interfaces.Message{
+		Role:    interfaces.MessageRoleUser,
+		Content: "What is in this image?",
+		Parts: []interfaces.ContentPart{
+			interfaces.ImagePart([]byte{0x89, 'P', 'N', 'G'}, "image/png"),
+			interfaces.DocumentURLPart("https://example.com/report.pdf"),
+		},
+	}
+	assert.NoError(t, memory.AddMessage(ctx, msg))
+
+	messages, err := memory.GetMessages(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, msg.Parts, messages[0].Parts)
+}
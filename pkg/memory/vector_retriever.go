@@ -12,17 +12,40 @@ import (
 type VectorStoreRetriever struct {
 	buffer      *ConversationBuffer
 	vectorStore interfaces.VectorStore
+	reranker    interfaces.Reranker
+	candidates  int
 	mu          sync.RWMutex
 }
 
+// DefaultRerankCandidates is the default number of candidates fetched per
+// requested message when a reranker is configured
+const DefaultRerankCandidates = 3
+
 // RetrieverOption represents an option for configuring the vector store retriever
 type RetrieverOption func(*VectorStoreRetriever)
 
+// WithReranker reorders the vector store results with a reranker before they
+// are returned. The store is asked for candidates times the requested limit.
+func WithReranker(reranker interfaces.Reranker) RetrieverOption {
+	return func(v *VectorStoreRetriever) {
+		v.reranker = reranker
+	}
+}
+
+// WithRerankCandidates sets how many candidates per requested message are
+// fetched for the reranker
+func WithRerankCandidates(candidates int) RetrieverOption {
+	return func(v *VectorStoreRetriever) {
+		v.candidates = candidates
+	}
+}
+
 // NewVectorStoreRetriever creates a new vector store retriever memory
 func NewVectorStoreRetriever(vectorStore interfaces.VectorStore, options ...RetrieverOption) *VectorStoreRetriever {
 	retriever := &VectorStoreRetriever{
 		buffer:      NewConversationBuffer(),
 		vectorStore: vectorStore,
+		candidates:  DefaultRerankCandidates,
 	}
 
 	for _, option := range options {
@@ -76,11 +99,25 @@ func (v *VectorStoreRetriever) GetMessages(ctx context.Context, options ...inter
 	}
 
 	// Search for relevant messages in vector store
-	results, err := v.vectorStore.Search(ctx, opts.Query, opts.Limit)
+	limit := opts.Limit
+	if v.reranker != nil && limit > 0 && v.candidates > 1 {
+		limit *= v.candidates
+	}
+	results, err := v.vectorStore.Search(ctx, opts.Query, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search vector store: %w", err)
 	}
 
+	if v.reranker != nil {
+		results, err = v.reranker.Rerank(ctx, opts.Query, results)
+		if err != nil {
+			return nil, fmt.Errorf("failed to rerank messages: %w", err)
+		}
+		if opts.Limit > 0 && len(results) > opts.Limit {
+			results = results[:opts.Limit]
+		}
+	}
+
 	// Convert search results to messages
 	var messages []interfaces.Message
 	for _, result := range results {
@@ -0,0 +1,282 @@
+package microservice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/tagus/agent-sdk-go/pkg/approval"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/memory"
+	"github.com/tagus/agent-sdk-go/pkg/multitenancy"
+)
+
+// approvalsPath is the prefix of the approval endpoints, followed by the approval ID
+const approvalsPath = "/api/v1/agent/approvals/"
+
+// ApprovalDecisionRequest represents the JSON request deciding on a tool call
+// waiting for approval
+type ApprovalDecisionRequest struct {
+	Action    string `json:"action"`              // "approve", "deny" or "edit"
+	Arguments string `json:"arguments,omitempty"` // JSON arguments replacing those of the call for "edit"
+	Reason    string `json:"reason,omitempty"`
+}
+
+// ApprovalDecisionResponse represents the JSON response to an approval decision
+type ApprovalDecisionResponse struct {
+	ApprovalID string `json:"approval_id"`
+	Resumed    bool   `json:"resumed"`          // false when a waiting run applies the decision
+	Output     string `json:"output,omitempty"` // Output of the resumed run
+}
+
+// WebSocketMessage represents a message sent by clients of the WebSocket chat
+// endpoint. Messages of type "run" start a streamed run, and messages of type
+// "approval" decide on a tool call waiting for approval.
+type WebSocketMessage struct {
+	Type           string `json:"type"`
+	Input          string `json:"input,omitempty"`
+	OrgID          string `json:"org_id,omitempty"`
+	ConversationID string `json:"conversation_id,omitempty"`
+	ApprovalID     string `json:"approval_id,omitempty"`
+	Action         string `json:"action,omitempty"`
+	Arguments      string `json:"arguments,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+}
+
+// handleApproval returns a tool call waiting for approval on GET, and
+// decides on it on POST
+func (h *HTTPServer) handleApproval(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, approvalsPath)
+	if id == "" || strings.Contains(id, "/") {
+		http.Error(w, "Approval ID is required", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		approver := h.agent.GetApprover()
+		if approver == nil {
+			writeApprovalError(w, fmt.Errorf("tool approval is not enabled"), http.StatusNotImplemented)
+			return
+		}
+		req, err := callerApproval(r.Context(), approver, id)
+		if err != nil {
+			writeApprovalError(w, err, approvalErrorStatus(err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(req); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		}
+
+	case "POST":
+		var req ApprovalDecisionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+			return
+		}
+		decision := approval.Decision{
+			Action:    approval.Action(req.Action),
+			Arguments: req.Arguments,
+			Reason:    req.Reason,
+		}
+		if err := decision.Validate(); err != nil {
+			writeApprovalError(w, err, http.StatusBadRequest)
+			return
+		}
+		if approver := h.agent.GetApprover(); approver != nil {
+			if _, err := callerApproval(r.Context(), approver, id); err != nil {
+				writeApprovalError(w, err, approvalErrorStatus(err))
+				return
+			}
+		}
+
+		output, resumed, err := h.agent.ResolveApproval(r.Context(), id, decision)
+		if err != nil {
+			writeApprovalError(w, err, approvalErrorStatus(err))
+			return
+		}
+		log.Printf("[HTTP Server] Approval %s resolved with action %s (resumed: %t)", id, req.Action, resumed)
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(ApprovalDecisionResponse{
+			ApprovalID: id,
+			Resumed:    resumed,
+			Output:     output,
+		}); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		}
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// callerApproval returns an approval request if it belongs to the
+// organization of the caller, set in the context. The requests of other
+// organizations are reported as not found.
+func callerApproval(ctx context.Context, approver *approval.Approver, id string) (*approval.Request, error) {
+	req, err := approver.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	orgID, _ := multitenancy.GetOrgID(ctx)
+	if req.OrgID != orgID {
+		return nil, approval.ErrNotFound
+	}
+	return req, nil
+}
+
+// approvalErrorStatus maps approval errors to HTTP status codes
+func approvalErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, approval.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, approval.ErrAlreadyDecided), errors.Is(err, approval.ErrAlreadyApplied):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeApprovalError(w http.ResponseWriter, err error, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": err.Error(),
+	})
+}
+
+// handleWebSocket streams runs over a WebSocket connection. Runs stream in
+// the background so that the client can decide on the tool calls they wait
+// for on the same connection.
+func (h *HTTPServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	// The CORS middleware allows any origin, so the origin is not checked
+	server := websocket.Server{Handler: h.serveWebSocket}
+	server.ServeHTTP(w, r)
+}
+
+func (h *HTTPServer) serveWebSocket(ws *websocket.Conn) {
+	defer ws.Close()
+
+	ctx, cancel := context.WithCancel(ws.Request().Context())
+	defer cancel()
+
+	var mu sync.Mutex
+	send := func(data StreamEventData) {
+		data.Timestamp = time.Now().UnixMilli()
+		mu.Lock()
+		defer mu.Unlock()
+		if err := websocket.JSON.Send(ws, data); err != nil {
+			cancel()
+		}
+	}
+
+	send(StreamEventData{
+		Type: "connected",
+		Metadata: map[string]interface{}{
+			"agent": h.agent.GetName(),
+		},
+	})
+
+	var runs sync.WaitGroup
+	defer runs.Wait()
+
+	for {
+		var msg WebSocketMessage
+		if err := websocket.JSON.Receive(ws, &msg); err != nil {
+			// The client closed the connection
+			cancel()
+			return
+		}
+
+		switch msg.Type {
+		case "run":
+			if msg.Input == "" {
+				send(StreamEventData{Type: "error", Error: "Input is required"})
+				continue
+			}
+			runCtx := ctx
+			if msg.OrgID != "" {
+				runCtx = multitenancy.WithOrgID(runCtx, msg.OrgID)
+			}
+			if msg.ConversationID != "" {
+				runCtx = memory.WithConversationID(runCtx, msg.ConversationID)
+			}
+			runs.Add(1)
+			go func() {
+				defer runs.Done()
+				h.streamWebSocketRun(runCtx, msg.Input, send)
+			}()
+
+		case "approval":
+			decision := approval.Decision{
+				Action:    approval.Action(msg.Action),
+				Arguments: msg.Arguments,
+				Reason:    msg.Reason,
+			}
+			if err := decision.Validate(); err != nil {
+				send(StreamEventData{Type: "error", Error: err.Error()})
+				continue
+			}
+			approvalCtx := ctx
+			if msg.OrgID != "" {
+				approvalCtx = multitenancy.WithOrgID(approvalCtx, msg.OrgID)
+			}
+			if approver := h.agent.GetApprover(); approver != nil {
+				if _, err := callerApproval(approvalCtx, approver, msg.ApprovalID); err != nil {
+					send(StreamEventData{Type: "error", Error: err.Error()})
+					continue
+				}
+			}
+			runs.Add(1)
+			go func() {
+				defer runs.Done()
+				output, resumed, err := h.agent.ResolveApproval(approvalCtx, msg.ApprovalID, decision)
+				if err != nil {
+					send(StreamEventData{Type: "error", Error: err.Error()})
+					return
+				}
+				send(StreamEventData{
+					Type:    "approval_resolved",
+					Content: output,
+					Metadata: map[string]interface{}{
+						"approval_id": msg.ApprovalID,
+						"resumed":     resumed,
+					},
+					IsFinal: resumed,
+				})
+			}()
+
+		default:
+			send(StreamEventData{Type: "error", Error: fmt.Sprintf("unknown message type %q", msg.Type)})
+		}
+	}
+}
+
+// streamWebSocketRun streams the events of a run to a WebSocket connection
+func (h *HTTPServer) streamWebSocketRun(ctx context.Context, input string, send func(StreamEventData)) {
+	eventChan, err := h.agent.RunStream(ctx, input)
+	if err != nil {
+		send(StreamEventData{Type: "error", Error: err.Error(), IsFinal: true})
+		return
+	}
+
+	for event := range eventChan {
+		eventData := h.convertAgentEventToHTTPEvent(event)
+		if event.Type == interfaces.AgentEventComplete {
+			eventData.IsFinal = true
+		}
+		send(eventData)
+	}
+
+	send(StreamEventData{Type: "done", IsFinal: true})
+}
@@ -0,0 +1,188 @@
+package microservice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/tagus/agent-sdk-go/pkg/agent"
+	"github.com/tagus/agent-sdk-go/pkg/approval"
+	"github.com/tagus/agent-sdk-go/pkg/multitenancy"
+)
+
+func TestHTTPServer_Approval(t *testing.T) {
+	agentInstance, err := agent.NewAgent(
+		agent.WithLLM(&MockLLM{response: "ok"}),
+		agent.WithName("TestAgent"),
+		agent.WithToolApproval(approval.New()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+	server := NewHTTPServer(agentInstance, 8080)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"get unknown", "GET", approvalsPath + "unknown", "", http.StatusNotFound},
+		{"resolve unknown", "POST", approvalsPath + "unknown", `{"action":"approve"}`, http.StatusNotFound},
+		{"invalid action", "POST", approvalsPath + "unknown", `{"action":"maybe"}`, http.StatusBadRequest},
+		{"invalid edit", "POST", approvalsPath + "unknown", `{"action":"edit","arguments":"nope"}`, http.StatusBadRequest},
+		{"missing ID", "GET", approvalsPath, "", http.StatusBadRequest},
+		{"wrong method", "DELETE", approvalsPath + "unknown", "", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+
+			server.handleApproval(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHTTPServer_ApprovalOtherOrg(t *testing.T) {
+	store := approval.NewMemoryStore()
+	if err := store.Create(context.Background(), &approval.Request{ID: "req-1", ToolName: "delete", OrgID: "org-a"}, time.Hour); err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	agentInstance, err := agent.NewAgent(
+		agent.WithLLM(&MockLLM{response: "ok"}),
+		agent.WithName("TestAgent"),
+		agent.WithToolApproval(approval.New(approval.WithStore(store))),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+	server := NewHTTPServer(agentInstance, 8080)
+
+	tests := []struct {
+		name   string
+		method string
+		orgID  string
+		body   string
+		status int
+	}{
+		{"get from other org", "GET", "org-b", "", http.StatusNotFound},
+		{"get without org", "GET", "", "", http.StatusNotFound},
+		{"resolve from other org", "POST", "org-b", `{"action":"approve"}`, http.StatusNotFound},
+		{"get from same org", "GET", "org-a", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, approvalsPath+"req-1", bytes.NewBufferString(tt.body))
+			if tt.orgID != "" {
+				req = req.WithContext(multitenancy.WithOrgID(req.Context(), tt.orgID))
+			}
+			w := httptest.NewRecorder()
+
+			server.handleApproval(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	stored, err := store.Get(context.Background(), "req-1")
+	if err != nil {
+		t.Fatalf("Failed to get request: %v", err)
+	}
+	if stored.Decision != nil {
+		t.Errorf("Expected the request of org-a to stay pending, got %+v", stored.Decision)
+	}
+}
+
+func TestHTTPServer_ApprovalDisabled(t *testing.T) {
+	testAgent := createTestAgent("test response", nil)
+	server := NewHTTPServer(testAgent.(*MockStreamingAgent).Agent, 8080)
+
+	req := httptest.NewRequest("GET", approvalsPath+"some-id", nil)
+	w := httptest.NewRecorder()
+
+	server.handleApproval(w, req)
+
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("Expected status 501, got %d", w.Code)
+	}
+}
+
+func TestHTTPServer_WebSocket(t *testing.T) {
+	testAgent := createTestAgent("Hello websocket world", nil)
+	server := NewHTTPServer(testAgent.(*MockStreamingAgent).Agent, 8080)
+
+	httpServer := httptest.NewServer(http.HandlerFunc(server.handleWebSocket))
+	defer httpServer.Close()
+
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http"), "", httpServer.URL)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer ws.Close()
+
+	receive := func() StreamEventData {
+		var event StreamEventData
+		_ = ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+		if err := websocket.JSON.Receive(ws, &event); err != nil {
+			t.Fatalf("Failed to receive event: %v", err)
+		}
+		return event
+	}
+
+	if event := receive(); event.Type != "connected" {
+		t.Fatalf("Expected connected event, got %q", event.Type)
+	}
+
+	// Unknown messages are answered with an error
+	if err := websocket.JSON.Send(ws, WebSocketMessage{Type: "unknown"}); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+	if event := receive(); event.Type != "error" {
+		t.Errorf("Expected error event, got %q", event.Type)
+	}
+
+	if err := websocket.JSON.Send(ws, WebSocketMessage{Type: "run", Input: "hello", OrgID: "test-org", ConversationID: "test-conversation"}); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+
+	var content strings.Builder
+	for {
+		event := receive()
+		if event.Type == "content" {
+			content.WriteString(event.Content)
+		}
+		if event.Type == "done" {
+			break
+		}
+	}
+	if !strings.Contains(content.String(), "Hello websocket world") {
+		t.Errorf("Expected streamed content, got %q", content.String())
+	}
+}
+
+func TestApprovalDecisionResponse_JSON(t *testing.T) {
+	data, err := json.Marshal(ApprovalDecisionResponse{ApprovalID: "id", Resumed: true, Output: "done"})
+	if err != nil {
+		t.Fatalf("Failed to marshal response: %v", err)
+	}
+	if string(data) != `{"approval_id":"id","resumed":true,"output":"done"}` {
+		t.Errorf("Unexpected JSON: %s", data)
+	}
+}
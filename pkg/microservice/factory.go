@@ -407,11 +407,14 @@ func convertGRPCResponseToAgentEvent(response *pb.RunStreamResponse) interfaces.
 		event.Type = interfaces.AgentEventContent
 		event.Content = response.Chunk
 
-	case pb.EventType_EVENT_TYPE_TOOL_CALL, pb.EventType_EVENT_TYPE_TOOL_RESULT:
-		if response.EventType == pb.EventType_EVENT_TYPE_TOOL_CALL {
+	case pb.EventType_EVENT_TYPE_TOOL_CALL, pb.EventType_EVENT_TYPE_TOOL_RESULT, pb.EventType_EVENT_TYPE_APPROVAL_REQUEST:
+		switch response.EventType {
+		case pb.EventType_EVENT_TYPE_TOOL_CALL:
 			event.Type = interfaces.AgentEventToolCall
-		} else {
+		case pb.EventType_EVENT_TYPE_TOOL_RESULT:
 			event.Type = interfaces.AgentEventToolResult
+		default:
+			event.Type = interfaces.AgentEventApprovalRequest
 		}
 
 		if response.ToolCall != nil {
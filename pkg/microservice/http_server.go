@@ -81,6 +81,8 @@ func (h *HTTPServer) Start() error {
 	mux.HandleFunc("/api/v1/agent/run", h.handleRun)
 	mux.HandleFunc("/api/v1/agent/stream", h.handleStream)
 	mux.HandleFunc("/api/v1/agent/metadata", h.handleMetadata)
+	mux.HandleFunc(approvalsPath, h.handleApproval)
+	mux.HandleFunc("/ws/chat", h.handleWebSocket)
 
 	// Serve static files for browser example (if they exist)
 	mux.Handle("/", http.FileServer(http.Dir("./web/")))
@@ -98,6 +100,8 @@ func (h *HTTPServer) Start() error {
 	fmt.Printf("  - POST /api/v1/agent/run (non-streaming)\n")
 	fmt.Printf("  - POST /api/v1/agent/stream (SSE streaming)\n")
 	fmt.Printf("  - GET /api/v1/agent/metadata\n")
+	fmt.Printf("  - GET/POST /api/v1/agent/approvals/{id}\n")
+	fmt.Printf("  - GET /ws/chat (WebSocket streaming)\n")
 	fmt.Printf("  - GET /health\n")
 
 	return h.server.ListenAndServe()
@@ -347,6 +351,10 @@ func (h *HTTPServer) handleStream(w http.ResponseWriter, r *http.Request) {
 			sseEventType = "tool_call"
 		case interfaces.AgentEventToolResult:
 			sseEventType = "tool_result"
+		case interfaces.AgentEventPartialObject:
+			sseEventType = "partial_object"
+		case interfaces.AgentEventApprovalRequest:
+			sseEventType = "approval_request"
 		case interfaces.AgentEventError:
 			sseEventType = "error"
 		case interfaces.AgentEventComplete:
@@ -394,12 +402,15 @@ func (h *HTTPServer) handleMetadata(w http.ResponseWriter, r *http.Request) {
 			"run",
 			"stream",
 			"metadata",
+			"approvals",
 		},
 		"endpoints": map[string]string{
-			"run":      "/api/v1/agent/run",
-			"stream":   "/api/v1/agent/stream",
-			"metadata": "/api/v1/agent/metadata",
-			"health":   "/health",
+			"run":       "/api/v1/agent/run",
+			"stream":    "/api/v1/agent/stream",
+			"metadata":  "/api/v1/agent/metadata",
+			"approvals": "/api/v1/agent/approvals/{id}",
+			"websocket": "/ws/chat",
+			"health":    "/health",
 		},
 	}); err != nil {
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
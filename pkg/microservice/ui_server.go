@@ -246,6 +246,7 @@ func (h *HTTPServerWithUI) Start() error {
 	fmt.Printf("  - POST /api/v1/agent/run (non-streaming)\n")
 	fmt.Printf("  - POST /api/v1/agent/stream (SSE streaming)\n")
 	fmt.Printf("  - GET /api/v1/agent/metadata\n")
+	fmt.Printf("  - GET/POST /api/v1/agent/approvals/{id}\n")
 	fmt.Printf("  - GET /health\n")
 
 	if h.uiConfig.Enabled {
@@ -256,6 +257,7 @@ func (h *HTTPServerWithUI) Start() error {
 		fmt.Printf("  - GET /api/v1/memory\n")
 		fmt.Printf("  - GET /api/v1/memory/search\n")
 		fmt.Printf("  - GET /api/v1/tools\n")
+		fmt.Printf("  - GET /ws/chat (WebSocket streaming)\n")
 
 	}
 
@@ -271,6 +273,7 @@ func (h *HTTPServerWithUI) registerAPIEndpoints(mux *http.ServeMux) {
 	mux.HandleFunc("/api/v1/agent/run", h.withOrgContext(h.handleRun))
 	mux.HandleFunc("/api/v1/agent/stream", h.withOrgContext(h.handleStream))
 	mux.HandleFunc("/api/v1/agent/metadata", h.handleMetadata)
+	mux.HandleFunc(approvalsPath, h.withOrgContext(h.handleApproval))
 
 	// UI-specific endpoints (only when UI is enabled)
 	if h.uiConfig.Enabled {
@@ -280,7 +283,7 @@ func (h *HTTPServerWithUI) registerAPIEndpoints(mux *http.ServeMux) {
 		mux.HandleFunc("/api/v1/memory", h.withOrgContext(h.handleMemory))
 		mux.HandleFunc("/api/v1/memory/search", h.withOrgContext(h.handleMemorySearch))
 		mux.HandleFunc("/api/v1/tools", h.handleTools)
-		mux.HandleFunc("/ws/chat", h.handleWebSocketChat)
+		mux.HandleFunc("/ws/chat", h.withOrgContext(h.handleWebSocketChat))
 	}
 }
 
@@ -494,9 +497,7 @@ func (h *HTTPServerWithUI) handleTools(w http.ResponseWriter, r *http.Request) {
 
 // handleWebSocketChat handles WebSocket connections for real-time chat
 func (h *HTTPServerWithUI) handleWebSocketChat(w http.ResponseWriter, r *http.Request) {
-	// WebSocket implementation would go here
-	// For now, return not implemented
-	http.Error(w, "WebSocket not yet implemented", http.StatusNotImplemented)
+	h.handleWebSocket(w, r)
 }
 
 // getSubAgentsList returns list of sub-agents
@@ -0,0 +1,162 @@
+package rerank
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/structuredoutput"
+)
+
+const (
+	// DefaultLLMBatchSize is the default number of documents judged per LLM call
+	DefaultLLMBatchSize = 10
+
+	// DefaultMaxContentLength is the default number of characters of each document shown to the judge
+	DefaultMaxContentLength = 1000
+
+	llmSystemPrompt = "You are a search relevance judge. Rate how well each document answers the query " +
+		"on a scale from 0 (irrelevant) to 10 (fully answers the query). Judge each document on its own."
+)
+
+// LLMReranker uses a language model as a relevance judge. Documents are scored
+// in batches and sorted by the judge's score, normalized to 0-1.
+type LLMReranker struct {
+	llm              interfaces.LLM
+	batchSize        int
+	maxContentLength int
+}
+
+// LLMOption configures an LLMReranker
+type LLMOption func(*LLMReranker)
+
+// WithBatchSize sets the number of documents judged per LLM call
+func WithBatchSize(size int) LLMOption {
+	return func(r *LLMReranker) {
+		r.batchSize = size
+	}
+}
+
+// WithMaxContentLength sets the number of characters of each document shown to the judge
+func WithMaxContentLength(length int) LLMOption {
+	return func(r *LLMReranker) {
+		r.maxContentLength = length
+	}
+}
+
+// NewLLMReranker creates a reranker that asks the LLM to judge relevance
+func NewLLMReranker(llm interfaces.LLM, options ...LLMOption) *LLMReranker {
+	r := &LLMReranker{
+		llm:              llm,
+		batchSize:        DefaultLLMBatchSize,
+		maxContentLength: DefaultMaxContentLength,
+	}
+	for _, option := range options {
+		option(r)
+	}
+	if r.batchSize <= 0 {
+		r.batchSize = DefaultLLMBatchSize
+	}
+	return r
+}
+
+type judgment struct {
+	Scores []struct {
+		Index int     `json:"index" description:"Number of the document as given in the prompt"`
+		Score float64 `json:"score" description:"Relevance from 0 to 10"`
+	} `json:"scores"`
+}
+
+// Rerank scores every result with the LLM and sorts them by score. Results the
+// judge leaves out are scored 0; ties keep the incoming order.
+func (r *LLMReranker) Rerank(ctx context.Context, query string, results []interfaces.SearchResult) ([]interfaces.SearchResult, error) {
+	if len(results) == 0 {
+		return results, nil
+	}
+
+	scores := make([]float32, len(results))
+	var batches [][2]int
+	for start := 0; start < len(results); start += r.batchSize {
+		batches = append(batches, [2]int{start, min(start+r.batchSize, len(results))})
+	}
+
+	errs := make([]error, len(batches))
+	var wg sync.WaitGroup
+	for i, batch := range batches {
+		wg.Add(1)
+		go func(i, start, end int) {
+			defer wg.Done()
+			errs[i] = r.judge(ctx, query, results[start:end], scores[start:end])
+		}(i, batch[0], batch[1])
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("failed to rerank with LLM: %w", err)
+	}
+
+	order := make([]int, len(results))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return scores[order[i]] > scores[order[j]]
+	})
+
+	reranked := make([]interfaces.SearchResult, len(results))
+	for i, index := range order {
+		reranked[i] = interfaces.SearchResult{Document: results[index].Document, Score: scores[index]}
+	}
+	return reranked, nil
+}
+
+// judge asks the LLM to score one batch, writing normalized scores into scores
+func (r *LLMReranker) judge(ctx context.Context, query string, results []interfaces.SearchResult, scores []float32) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Query: %s\n\nDocuments:\n", query)
+	for i, result := range results {
+		content := result.Document.Content
+		if r.maxContentLength > 0 && len([]rune(content)) > r.maxContentLength {
+			content = string([]rune(content)[:r.maxContentLength]) + "..."
+		}
+		fmt.Fprintf(&b, "\n[%d]\n%s\n", i+1, content)
+	}
+	b.WriteString("\nReturn a score for every document, referring to documents by their number.")
+
+	response, err := r.llm.Generate(ctx, b.String(),
+		interfaces.WithSystemMessage(llmSystemPrompt),
+		interfaces.WithResponseFormat(*structuredoutput.NewResponseFormat(judgment{})),
+		interfaces.WithTemperature(0))
+	if err != nil {
+		return err
+	}
+
+	var parsed judgment
+	if err := json.Unmarshal([]byte(stripCodeFence(response)), &parsed); err != nil {
+		return fmt.Errorf("invalid LLM response: %w", err)
+	}
+	for _, s := range parsed.Scores {
+		if s.Index < 1 || s.Index > len(results) {
+			continue
+		}
+		scores[s.Index-1] = float32(min(max(s.Score, 0), 10) / 10)
+	}
+	return nil
+}
+
+// stripCodeFence removes a surrounding markdown code fence from an LLM response
+func stripCodeFence(response string) string {
+	response = strings.TrimSpace(response)
+	if !strings.HasPrefix(response, "```") {
+		return response
+	}
+	response = strings.TrimPrefix(response, "```")
+	if newline := strings.Index(response, "\n"); newline >= 0 {
+		response = response[newline+1:]
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(response), "```"))
+}
@@ -0,0 +1,131 @@
+package rerank
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tagus/agent-sdk-go/pkg/embedding"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// DefaultMMRLambda balances relevance and diversity equally
+const DefaultMMRLambda = 0.5
+
+// MMR reorders results with maximal marginal relevance, so that documents
+// similar to ones already ranked are pushed down. Result scores are kept.
+type MMR struct {
+	embedder embedding.Client
+	lambda   float32
+}
+
+// MMROption configures an MMR reranker
+type MMROption func(*MMR)
+
+// WithLambda sets the weight of relevance against diversity, from 0 (only
+// diversity) to 1 (only relevance)
+func WithLambda(lambda float32) MMROption {
+	return func(m *MMR) {
+		m.lambda = lambda
+	}
+}
+
+// NewMMR creates a maximal marginal relevance reranker. The embedder embeds
+// the query and any result without a stored vector.
+func NewMMR(embedder embedding.Client, options ...MMROption) *MMR {
+	m := &MMR{
+		embedder: embedder,
+		lambda:   DefaultMMRLambda,
+	}
+	for _, option := range options {
+		option(m)
+	}
+	return m
+}
+
+// Rerank greedily picks the result with the best trade-off between similarity
+// to the query and dissimilarity to the results picked before it
+func (m *MMR) Rerank(ctx context.Context, query string, results []interfaces.SearchResult) ([]interfaces.SearchResult, error) {
+	if len(results) < 2 {
+		return results, nil
+	}
+
+	queryVector, vectors, err := m.vectors(ctx, query, results)
+	if err != nil {
+		return nil, err
+	}
+
+	relevance := make([]float32, len(results))
+	for i, vec := range vectors {
+		relevance[i] = cosine(queryVector, vec)
+	}
+
+	// maxSimilarity[i] is the highest similarity of result i to a picked result
+	maxSimilarity := make([]float32, len(results))
+	picked := make([]bool, len(results))
+	reranked := make([]interfaces.SearchResult, 0, len(results))
+	for len(reranked) < len(results) {
+		best := -1
+		var bestScore float32
+		for i := range results {
+			if picked[i] {
+				continue
+			}
+			score := m.lambda * relevance[i]
+			if len(reranked) > 0 {
+				score -= (1 - m.lambda) * maxSimilarity[i]
+			}
+			if best < 0 || score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+
+		picked[best] = true
+		reranked = append(reranked, results[best])
+		for i := range results {
+			if !picked[i] {
+				if similarity := cosine(vectors[i], vectors[best]); similarity > maxSimilarity[i] {
+					maxSimilarity[i] = similarity
+				}
+			}
+		}
+	}
+	return reranked, nil
+}
+
+// vectors embeds the query and the results that have no vector in one batch
+func (m *MMR) vectors(ctx context.Context, query string, results []interfaces.SearchResult) ([]float32, [][]float32, error) {
+	texts := []string{query}
+	var missing []int
+	for i, result := range results {
+		if len(result.Document.Vector) == 0 {
+			texts = append(texts, result.Document.Content)
+			missing = append(missing, i)
+		}
+	}
+
+	embedded, err := m.embedder.EmbedBatch(ctx, texts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to embed documents for MMR: %w", err)
+	}
+	if len(embedded) != len(texts) {
+		return nil, nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(embedded))
+	}
+
+	vectors := make([][]float32, len(results))
+	for i, result := range results {
+		vectors[i] = result.Document.Vector
+	}
+	for i, index := range missing {
+		vectors[index] = embedded[i+1]
+	}
+	return embedded[0], vectors, nil
+}
+
+// cosine returns the cosine similarity of two vectors, or zero if they cannot be compared
+func cosine(vec1, vec2 []float32) float32 {
+	similarity, err := embedding.CalculateSimilarity(vec1, vec2, "cosine")
+	if err != nil {
+		return 0
+	}
+	return similarity
+}
@@ -0,0 +1,86 @@
+// Package rerank provides second-stage rankers for vector search results: an
+// LLM-as-judge reranker, reciprocal rank fusion of lexical and vector rankings,
+// and maximal marginal relevance for diversity. All implement
+// interfaces.Reranker and can be combined with Chain.
+package rerank
+
+import (
+	"context"
+	"sort"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// DefaultRRFK is the default rank constant of reciprocal rank fusion. Larger
+// values dampen the influence of the top ranks.
+const DefaultRRFK = 60
+
+type chain []interfaces.Reranker
+
+// Chain runs rerankers in sequence, each reordering the output of the previous one
+func Chain(rerankers ...interfaces.Reranker) interfaces.Reranker {
+	return chain(rerankers)
+}
+
+// Rerank applies every reranker in order
+func (c chain) Rerank(ctx context.Context, query string, results []interfaces.SearchResult) ([]interfaces.SearchResult, error) {
+	var err error
+	for _, reranker := range c {
+		results, err = reranker.Rerank(ctx, query, results)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// Fuse merges ranked result lists with reciprocal rank fusion, for example the
+// results of a BM25 search and a vector search of the same store. Results are
+// matched by document ID and scored by the sum of 1/(k+rank) over the lists
+// they appear in. A k of zero uses DefaultRRFK.
+func Fuse(k int, lists ...[]interfaces.SearchResult) []interfaces.SearchResult {
+	if k <= 0 {
+		k = DefaultRRFK
+	}
+
+	type candidate struct {
+		result interfaces.SearchResult
+		score  float64
+		order  int
+	}
+
+	var candidates []*candidate
+	byID := map[string]*candidate{}
+	for _, list := range lists {
+		for rank, result := range list {
+			c, ok := byID[result.Document.ID]
+			if !ok || result.Document.ID == "" {
+				c = &candidate{result: result, order: len(candidates)}
+				candidates = append(candidates, c)
+				byID[result.Document.ID] = c
+			}
+			c.score += 1.0 / float64(k+rank+1)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].order < candidates[j].order
+	})
+
+	results := make([]interfaces.SearchResult, len(candidates))
+	for i, c := range candidates {
+		results[i] = interfaces.SearchResult{Document: c.result.Document, Score: float32(c.score)}
+	}
+	return results
+}
+
+// Truncate returns at most limit results. A limit of zero or less keeps all results.
+func Truncate(results []interfaces.SearchResult, limit int) []interfaces.SearchResult {
+	if limit > 0 && len(results) > limit {
+		return results[:limit]
+	}
+	return results
+}
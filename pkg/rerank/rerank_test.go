@@ -0,0 +1,124 @@
+package rerank
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tagus/agent-sdk-go/pkg/embedding"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// fakeLLM answers each prompt with the response of the first matching key
+type fakeLLM struct {
+	interfaces.LLM
+	mu        sync.Mutex
+	responses map[string]string
+	err       error
+	prompts   []string
+}
+
+func (l *fakeLLM) Generate(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prompts = append(l.prompts, prompt)
+	for key, response := range l.responses {
+		if strings.Contains(prompt, key) {
+			return response, l.err
+		}
+	}
+	return `{"scores": []}`, l.err
+}
+
+func result(id, content string, score float32) interfaces.SearchResult {
+	return interfaces.SearchResult{Document: interfaces.Document{ID: id, Content: content}, Score: score}
+}
+
+func ids(results []interfaces.SearchResult) []string {
+	out := make([]string, len(results))
+	for i, r := range results {
+		out[i] = r.Document.ID
+	}
+	return out
+}
+
+func TestFuse(t *testing.T) {
+	vector := []interfaces.SearchResult{result("a", "", 0.9), result("b", "", 0.8), result("c", "", 0.7)}
+	bm25 := []interfaces.SearchResult{result("c", "", 12), result("d", "", 9), result("a", "", 3)}
+
+	fused := Fuse(0, vector, bm25)
+	assert.Equal(t, []string{"a", "c", "b", "d"}, ids(fused))
+	assert.InDelta(t, 1.0/61+1.0/63, fused[0].Score, 1e-6)
+	assert.Len(t, Truncate(fused, 2), 2)
+	assert.Len(t, Truncate(fused, 0), 4)
+}
+
+func TestRRF(t *testing.T) {
+	results := []interfaces.SearchResult{
+		result("a", "Reset your password from the settings page.", 0.91),
+		result("b", "Account recovery is handled by support.", 0.90),
+		result("c", "Error E-4012 means the token expired; request a new one.", 0.89),
+		result("d", "Tokens are valid for one hour.", 0.88),
+	}
+
+	reranked, err := NewRRF().Rerank(context.Background(), "what is error E-4012", results)
+	require.NoError(t, err)
+	assert.Equal(t, "c", reranked[0].Document.ID)
+	assert.ElementsMatch(t, []string{"a", "b", "c", "d"}, ids(reranked))
+}
+
+func TestMMR(t *testing.T) {
+	results := []interfaces.SearchResult{
+		result("a", "go channels and goroutines", 0.9),
+		result("b", "go channels and goroutines explained", 0.85),
+		result("c", "go modules and versioning", 0.8),
+	}
+
+	embedder := embedding.NewHashEmbedder(64)
+	reranked, err := NewMMR(embedder, WithLambda(0.3)).Rerank(context.Background(), "go channels", results)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "c", "b"}, ids(reranked))
+	assert.Equal(t, float32(0.8), reranked[1].Score)
+
+	reranked, err = NewMMR(embedder, WithLambda(1)).Rerank(context.Background(), "go channels", results)
+	require.NoError(t, err)
+	assert.Equal(t, "c", reranked[2].Document.ID)
+}
+
+func TestLLMReranker(t *testing.T) {
+	llm := &fakeLLM{responses: map[string]string{
+		"First doc": "```json\n{\"scores\": [{\"index\": 1, \"score\": 2}, {\"index\": 2, \"score\": 9}]}\n```",
+		"Third doc": `{"scores": [{"index": 1, "score": 7}, {"index": 5, "score": 10}]}`,
+	}}
+	results := []interfaces.SearchResult{
+		result("a", "First doc", 0.9),
+		result("b", "Second doc", 0.8),
+		result("c", "Third doc", 0.7),
+	}
+
+	reranked, err := NewLLMReranker(llm, WithBatchSize(2)).Rerank(context.Background(), "query", results)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "c", "a"}, ids(reranked))
+	assert.InDelta(t, 0.9, reranked[0].Score, 1e-6)
+	assert.Len(t, llm.prompts, 2)
+
+	llm.err = errors.New("unavailable")
+	_, err = NewLLMReranker(llm).Rerank(context.Background(), "query", results)
+	assert.Error(t, err)
+}
+
+func TestChain(t *testing.T) {
+	llm := &fakeLLM{responses: map[string]string{
+		"query": `{"scores": [{"index": 3, "score": 10}, {"index": 1, "score": 5}]}`,
+	}}
+	results := []interfaces.SearchResult{result("a", "x", 0.9), result("b", "y", 0.8), result("c", "z", 0.7)}
+
+	reranked, err := Chain(NewLLMReranker(llm), NewRRF()).Rerank(context.Background(), "query", results)
+	require.NoError(t, err)
+	assert.Equal(t, "c", reranked[0].Document.ID)
+}
@@ -0,0 +1,145 @@
+package rerank
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// RRF reranks vector search results by fusing their order with a BM25 ranking
+// of the same candidates. This rewards documents that match the query terms
+// exactly, such as names and identifiers that embeddings tend to blur.
+type RRF struct {
+	k  int
+	k1 float64
+	b  float64
+}
+
+// RRFOption configures an RRF reranker
+type RRFOption func(*RRF)
+
+// WithK sets the rank constant of the fusion
+func WithK(k int) RRFOption {
+	return func(r *RRF) {
+		r.k = k
+	}
+}
+
+// WithBM25Parameters sets the term frequency saturation (k1) and length
+// normalization (b) of the BM25 ranking
+func WithBM25Parameters(k1, b float64) RRFOption {
+	return func(r *RRF) {
+		r.k1 = k1
+		r.b = b
+	}
+}
+
+// NewRRF creates a reciprocal rank fusion reranker
+func NewRRF(options ...RRFOption) *RRF {
+	r := &RRF{
+		k:  DefaultRRFK,
+		k1: 1.2,
+		b:  0.75,
+	}
+	for _, option := range options {
+		option(r)
+	}
+	return r
+}
+
+// Rerank fuses the incoming order with the BM25 order of the results
+func (r *RRF) Rerank(ctx context.Context, query string, results []interfaces.SearchResult) ([]interfaces.SearchResult, error) {
+	if len(results) < 2 {
+		return results, nil
+	}
+
+	scores := r.bm25(query, results)
+	indexes := make([]int, len(results))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return scores[indexes[i]] > scores[indexes[j]]
+	})
+
+	lexicalRank := make([]int, len(results))
+	for rank, index := range indexes {
+		lexicalRank[index] = rank
+	}
+
+	// Results without any query term are left out of the lexical ranking, as
+	// a BM25 search would not return them
+	fused := make([]float64, len(results))
+	for i := range results {
+		fused[i] = 1.0 / float64(r.k+i+1)
+		if scores[i] > 0 {
+			fused[i] += 1.0 / float64(r.k+lexicalRank[i]+1)
+		}
+	}
+
+	order := make([]int, len(results))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return fused[order[i]] > fused[order[j]]
+	})
+
+	reranked := make([]interfaces.SearchResult, len(results))
+	for i, index := range order {
+		reranked[i] = interfaces.SearchResult{Document: results[index].Document, Score: float32(fused[index])}
+	}
+	return reranked, nil
+}
+
+// bm25 scores each result against the query, using the candidates as the corpus
+func (r *RRF) bm25(query string, results []interfaces.SearchResult) []float64 {
+	terms := tokenize(query)
+	docs := make([]map[string]int, len(results))
+	lengths := make([]int, len(results))
+	df := map[string]int{}
+	total := 0
+	for i, result := range results {
+		tokens := tokenize(result.Document.Content)
+		docs[i] = map[string]int{}
+		for _, token := range tokens {
+			docs[i][token]++
+		}
+		for token := range docs[i] {
+			df[token]++
+		}
+		lengths[i] = len(tokens)
+		total += len(tokens)
+	}
+
+	avgLength := float64(total) / float64(len(results))
+	if avgLength == 0 {
+		avgLength = 1
+	}
+
+	n := float64(len(results))
+	scores := make([]float64, len(results))
+	for i, tf := range docs {
+		for _, term := range terms {
+			freq := float64(tf[term])
+			if freq == 0 {
+				continue
+			}
+			idf := math.Log(1 + (n-float64(df[term])+0.5)/(float64(df[term])+0.5))
+			norm := freq * (r.k1 + 1) / (freq + r.k1*(1-r.b+r.b*float64(lengths[i])/avgLength))
+			scores[i] += idf * norm
+		}
+	}
+	return scores
+}
+
+// tokenize splits text into lowercase words
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
@@ -0,0 +1,444 @@
+// Package retrieval provides retrieval-augmented generation for agents. A
+// Retriever searches one or more VectorStores with the user input, fuses and
+// deduplicates the results, and formats the best documents into a context
+// block that fits a token budget.
+package retrieval
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/tagus/agent-sdk-go/pkg/guardrails"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/logging"
+)
+
+const (
+	// DefaultTopK is the default number of results requested from each store per query
+	DefaultTopK = 5
+
+	// DefaultMaxDocuments is the default number of documents injected into the prompt
+	DefaultMaxDocuments = 5
+
+	// DefaultTokenBudget is the default token budget for the injected documents
+	DefaultTokenBudget = 2000
+
+	// DefaultInstructions introduce the retrieved documents in the system prompt
+	DefaultInstructions = "Use the following documents retrieved from the knowledge base to answer the user's request. " +
+		"Cite the documents you use by their ID in square brackets, for example [doc-1]. " +
+		"If the documents are not relevant, answer without them."
+
+	// rrfK dampens the influence of top ranks in reciprocal rank fusion
+	rrfK = 60
+)
+
+// ErrNoStores is returned when a Retriever has no stores configured
+var ErrNoStores = errors.New("retriever has no vector stores")
+
+// Retriever searches vector stores for documents relevant to a query
+type Retriever struct {
+	stores       []store
+	topK         int
+	maxDocuments int
+	minScore     float32
+	tokenBudget  int
+	counter      guardrails.TokenCounter
+	rewriter     interfaces.LLM
+	reranker     interfaces.Reranker
+	instructions string
+	logger       logging.Logger
+}
+
+type store struct {
+	vectorStore interfaces.VectorStore
+	options     []interfaces.SearchOption
+}
+
+// Option configures a Retriever
+type Option func(*Retriever)
+
+// WithStore adds a vector store to search. The search options, such as a class,
+// tenant or metadata filters, apply to every search on this store.
+func WithStore(vectorStore interfaces.VectorStore, options ...interfaces.SearchOption) Option {
+	return func(r *Retriever) {
+		if vectorStore != nil {
+			r.stores = append(r.stores, store{vectorStore: vectorStore, options: options})
+		}
+	}
+}
+
+// WithTopK sets the number of results requested from each store per query
+func WithTopK(k int) Option {
+	return func(r *Retriever) {
+		if k > 0 {
+			r.topK = k
+		}
+	}
+}
+
+// WithMaxDocuments sets the maximum number of documents injected into the prompt
+func WithMaxDocuments(n int) Option {
+	return func(r *Retriever) {
+		if n > 0 {
+			r.maxDocuments = n
+		}
+	}
+}
+
+// WithMinScore drops store results scoring below the threshold
+func WithMinScore(score float32) Option {
+	return func(r *Retriever) {
+		r.minScore = score
+	}
+}
+
+// WithTokenBudget sets the maximum number of tokens of the formatted documents
+func WithTokenBudget(tokens int) Option {
+	return func(r *Retriever) {
+		if tokens > 0 {
+			r.tokenBudget = tokens
+		}
+	}
+}
+
+// WithTokenCounter sets the counter used to enforce the token budget. By
+// default tokens are estimated at four characters each.
+func WithTokenCounter(counter guardrails.TokenCounter) Option {
+	return func(r *Retriever) {
+		r.counter = counter
+	}
+}
+
+// WithQueryRewriter rewrites the user input into a standalone search query with
+// the LLM. Both the original and the rewritten query are searched.
+func WithQueryRewriter(llm interfaces.LLM) Option {
+	return func(r *Retriever) {
+		r.rewriter = llm
+	}
+}
+
+// WithReranker reorders the fused results with a reranker before they are
+// selected for the prompt. If reranking fails the fused order is kept.
+func WithReranker(reranker interfaces.Reranker) Option {
+	return func(r *Retriever) {
+		r.reranker = reranker
+	}
+}
+
+// WithInstructions replaces the text introducing the documents in the prompt
+func WithInstructions(instructions string) Option {
+	return func(r *Retriever) {
+		r.instructions = instructions
+	}
+}
+
+// WithLogger sets the logger
+func WithLogger(logger logging.Logger) Option {
+	return func(r *Retriever) {
+		r.logger = logger
+	}
+}
+
+// New creates a retriever. At least one store must be added with WithStore.
+func New(options ...Option) *Retriever {
+	r := &Retriever{
+		topK:         DefaultTopK,
+		maxDocuments: DefaultMaxDocuments,
+		tokenBudget:  DefaultTokenBudget,
+		counter:      estimateCounter{},
+		instructions: DefaultInstructions,
+		logger:       logging.New(),
+	}
+	for _, option := range options {
+		option(r)
+	}
+	return r
+}
+
+// Result holds the documents retrieved for a query
+type Result struct {
+	// Query is the original query
+	Query string
+
+	// RewrittenQuery is the LLM-rewritten query, empty when rewriting is disabled or failed
+	RewrittenQuery string
+
+	// Documents are the injected documents in rank order. Scores are fused
+	// reciprocal rank scores or reranker scores, not raw similarities.
+	Documents []interfaces.SearchResult
+
+	// Context is the formatted block to append to the system prompt
+	Context string
+
+	// Tokens is the token count of Context
+	Tokens int
+}
+
+// DocumentIDs returns the IDs of the retrieved documents
+func (r *Result) DocumentIDs() []string {
+	if r == nil {
+		return nil
+	}
+	ids := make([]string, len(r.Documents))
+	for i, doc := range r.Documents {
+		ids[i] = doc.Document.ID
+	}
+	return ids
+}
+
+var citationPattern = regexp.MustCompile(`\[([^\[\]\n]+)\]`)
+
+// Citations returns the IDs of retrieved documents cited in square brackets in
+// the response, in order of first citation
+func (r *Result) Citations(response string) []string {
+	if r == nil || len(r.Documents) == 0 {
+		return nil
+	}
+	known := make(map[string]bool, len(r.Documents))
+	for _, doc := range r.Documents {
+		known[doc.Document.ID] = true
+	}
+
+	var cited []string
+	seen := map[string]bool{}
+	for _, match := range citationPattern.FindAllStringSubmatch(response, -1) {
+		// Allow grouped citations such as [doc-1, doc-2]
+		for _, id := range strings.Split(match[1], ",") {
+			id = strings.TrimSpace(id)
+			if known[id] && !seen[id] {
+				seen[id] = true
+				cited = append(cited, id)
+			}
+		}
+	}
+	return cited
+}
+
+// Retrieve searches every store with the query and returns the top documents
+// that fit the token budget
+func (r *Retriever) Retrieve(ctx context.Context, query string) (*Result, error) {
+	if len(r.stores) == 0 {
+		return nil, ErrNoStores
+	}
+
+	result := &Result{Query: query}
+	queries := []string{query}
+	if r.rewriter != nil {
+		rewritten, err := r.rewrite(ctx, query)
+		if err != nil {
+			r.logger.Warn(ctx, "Failed to rewrite retrieval query", map[string]interface{}{
+				"error": err.Error(),
+			})
+		} else if rewritten != "" && rewritten != query {
+			result.RewrittenQuery = rewritten
+			queries = append(queries, rewritten)
+		}
+	}
+
+	lists, err := r.search(ctx, queries)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := fuse(lists)
+	if r.reranker != nil {
+		reranked, err := r.reranker.Rerank(ctx, query, candidates)
+		if err != nil {
+			r.logger.Warn(ctx, "Failed to rerank retrieved documents", map[string]interface{}{
+				"error": err.Error(),
+			})
+		} else {
+			candidates = reranked
+		}
+	}
+
+	for _, candidate := range candidates {
+		if len(result.Documents) >= r.maxDocuments {
+			break
+		}
+		block := formatDocument(candidate.Document)
+		tokens, err := r.counter.CountTokens(block)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count tokens: %w", err)
+		}
+		// Skip documents that do not fit; a shorter one further down may
+		if result.Tokens+tokens > r.tokenBudget {
+			continue
+		}
+		result.Tokens += tokens
+		result.Documents = append(result.Documents, candidate)
+	}
+
+	if len(result.Documents) > 0 {
+		var b strings.Builder
+		b.WriteString(r.instructions)
+		b.WriteString("\n\n")
+		for i, doc := range result.Documents {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			b.WriteString(formatDocument(doc.Document))
+		}
+		result.Context = b.String()
+	}
+
+	r.logger.Debug(ctx, "Retrieved documents", map[string]interface{}{
+		"queries":   len(queries),
+		"documents": len(result.Documents),
+		"tokens":    result.Tokens,
+	})
+	return result, nil
+}
+
+// search runs every query against every store concurrently and returns one
+// ranked list per search. A failing store is logged and skipped unless every
+// search fails.
+func (r *Retriever) search(ctx context.Context, queries []string) ([][]interfaces.SearchResult, error) {
+	lists := make([][]interfaces.SearchResult, len(queries)*len(r.stores))
+	errs := make([]error, len(lists))
+
+	var wg sync.WaitGroup
+	for qi, query := range queries {
+		for si, s := range r.stores {
+			wg.Add(1)
+			go func(i int, query string, s store) {
+				defer wg.Done()
+				results, err := s.vectorStore.Search(ctx, query, r.topK, s.options...)
+				if err != nil {
+					errs[i] = err
+					return
+				}
+				for _, result := range results {
+					if result.Score >= r.minScore {
+						lists[i] = append(lists[i], result)
+					}
+				}
+			}(qi*len(r.stores)+si, query, s)
+		}
+	}
+	wg.Wait()
+
+	var failed []error
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	if len(failed) == len(lists) {
+		return nil, fmt.Errorf("failed to search vector stores: %w", errors.Join(failed...))
+	}
+	for _, err := range failed {
+		r.logger.Warn(ctx, "Vector store search failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+	return lists, nil
+}
+
+// fuse merges ranked lists with reciprocal rank fusion. Results with the same
+// ID or the same content are merged.
+func fuse(lists [][]interfaces.SearchResult) []interfaces.SearchResult {
+	type candidate struct {
+		result   interfaces.SearchResult
+		score    float64
+		rawScore float32
+		order    int
+	}
+
+	var candidates []*candidate
+	byKey := map[string]*candidate{}
+	for _, list := range lists {
+		for rank, result := range list {
+			idKey := "id:" + result.Document.ID
+			contentKey := "content:" + contentHash(result.Document.Content)
+
+			c := byKey[contentKey]
+			if result.Document.ID != "" && byKey[idKey] != nil {
+				c = byKey[idKey]
+			}
+			if c == nil {
+				c = &candidate{result: result, order: len(candidates)}
+				candidates = append(candidates, c)
+			}
+			if result.Document.ID != "" {
+				byKey[idKey] = c
+			}
+			byKey[contentKey] = c
+
+			c.score += 1.0 / float64(rrfK+rank+1)
+			if result.Score > c.rawScore {
+				c.rawScore = result.Score
+			}
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		if candidates[i].rawScore != candidates[j].rawScore {
+			return candidates[i].rawScore > candidates[j].rawScore
+		}
+		return candidates[i].order < candidates[j].order
+	})
+
+	results := make([]interfaces.SearchResult, len(candidates))
+	for i, c := range candidates {
+		results[i] = interfaces.SearchResult{Document: c.result.Document, Score: float32(c.score)}
+	}
+	return results
+}
+
+// contentHash identifies documents by whitespace-normalized content
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(strings.Join(strings.Fields(content), " ")))
+	return string(sum[:])
+}
+
+// formatDocument renders a document for the prompt
+func formatDocument(doc interfaces.Document) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<document id=%q", doc.ID)
+	for _, key := range []string{"source", "title", "heading_path"} {
+		if value, ok := doc.Metadata[key].(string); ok && value != "" {
+			fmt.Fprintf(&b, " %s=%q", key, value)
+		}
+	}
+	b.WriteString(">\n")
+	b.WriteString(strings.TrimSpace(doc.Content))
+	b.WriteString("\n</document>")
+	return b.String()
+}
+
+const rewritePrompt = `Rewrite the user's message as a concise standalone search query for a knowledge base.
+Keep names, identifiers and technical terms. Reply with the query only.
+
+Message: %s`
+
+// rewrite asks the LLM for a standalone search query
+func (r *Retriever) rewrite(ctx context.Context, query string) (string, error) {
+	response, err := r.rewriter.Generate(ctx, fmt.Sprintf(rewritePrompt, query))
+	if err != nil {
+		return "", err
+	}
+	rewritten := strings.TrimSpace(response)
+	rewritten = strings.Trim(rewritten, "\"'`")
+	if i := strings.IndexByte(rewritten, '\n'); i >= 0 {
+		rewritten = strings.TrimSpace(rewritten[:i])
+	}
+	return rewritten, nil
+}
+
+// estimateCounter estimates four characters per token
+type estimateCounter struct{}
+
+func (estimateCounter) CountTokens(text string) (int, error) {
+	return (utf8.RuneCountInString(text) + 3) / 4, nil
+}
@@ -0,0 +1,178 @@
+package retrieval
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/vectorstore/inmemory"
+)
+
+// fakeStore returns canned results per query
+type fakeStore struct {
+	interfaces.VectorStore
+	results map[string][]interfaces.SearchResult
+	err     error
+}
+
+func (s *fakeStore) Search(ctx context.Context, query string, limit int, options ...interfaces.SearchOption) ([]interfaces.SearchResult, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	results := s.results[query]
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// fakeLLM answers every prompt with a fixed response
+type fakeLLM struct {
+	interfaces.LLM
+	response string
+	err      error
+	prompts  []string
+}
+
+func (l *fakeLLM) Generate(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (string, error) {
+	l.prompts = append(l.prompts, prompt)
+	return l.response, l.err
+}
+
+func result(id, content string, score float32) interfaces.SearchResult {
+	return interfaces.SearchResult{Document: interfaces.Document{ID: id, Content: content}, Score: score}
+}
+
+func TestRetriever_FusesAndDeduplicates(t *testing.T) {
+	first := &fakeStore{results: map[string][]interfaces.SearchResult{
+		"reset password": {result("a", "Open settings.", 0.9), result("b", "Click reset.", 0.8), result("low", "Unrelated.", 0.1)},
+	}}
+	second := &fakeStore{results: map[string][]interfaces.SearchResult{
+		// "c" has the same content as "b" and is merged into it
+		"reset password": {result("c", "Click  reset.", 0.95), result("d", "Check your email.", 0.7)},
+	}}
+
+	r := New(WithStore(first), WithStore(second), WithMinScore(0.5))
+	res, err := r.Retrieve(context.Background(), "reset password")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"b", "a", "d"}, res.DocumentIDs())
+	assert.True(t, strings.HasPrefix(res.Context, DefaultInstructions))
+	assert.Contains(t, res.Context, "<document id=\"b\">\nClick reset.\n</document>")
+	assert.NotContains(t, res.Context, "Unrelated")
+	assert.Empty(t, res.RewrittenQuery)
+}
+
+func TestRetriever_TokenBudgetAndLimits(t *testing.T) {
+	long := strings.Repeat("word ", 200)
+	store := &fakeStore{results: map[string][]interfaces.SearchResult{
+		"q": {result("long", long, 0.9), result("s1", "short one", 0.8), result("s2", "short two", 0.7), result("s3", "short three", 0.6)},
+	}}
+
+	res, err := New(WithStore(store), WithTokenBudget(50), WithMaxDocuments(2)).Retrieve(context.Background(), "q")
+	require.NoError(t, err)
+	// The long document does not fit the budget and is skipped
+	assert.Equal(t, []string{"s1", "s2"}, res.DocumentIDs())
+	assert.LessOrEqual(t, res.Tokens, 50)
+
+	res, err = New(WithStore(store), WithTokenBudget(5)).Retrieve(context.Background(), "q")
+	require.NoError(t, err)
+	assert.Empty(t, res.Documents)
+	assert.Empty(t, res.Context)
+}
+
+func TestRetriever_QueryRewriting(t *testing.T) {
+	store := &fakeStore{results: map[string][]interfaces.SearchResult{
+		"how do I do that?":    {result("vague", "Vague match.", 0.5)},
+		"reset admin password": {result("exact", "Reset the admin password from the console.", 0.9)},
+	}}
+	llm := &fakeLLM{response: "\"reset admin password\"\n"}
+
+	res, err := New(WithStore(store), WithQueryRewriter(llm)).Retrieve(context.Background(), "how do I do that?")
+	require.NoError(t, err)
+	assert.Equal(t, "reset admin password", res.RewrittenQuery)
+	assert.ElementsMatch(t, []string{"vague", "exact"}, res.DocumentIDs())
+	require.Len(t, llm.prompts, 1)
+	assert.Contains(t, llm.prompts[0], "how do I do that?")
+
+	// A failing rewriter falls back to the original query
+	res, err = New(WithStore(store), WithQueryRewriter(&fakeLLM{err: errors.New("unavailable")})).Retrieve(context.Background(), "how do I do that?")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"vague"}, res.DocumentIDs())
+}
+
+func TestRetriever_StoreErrors(t *testing.T) {
+	ctx := context.Background()
+	healthy := &fakeStore{results: map[string][]interfaces.SearchResult{"q": {result("a", "A.", 1)}}}
+	broken := &fakeStore{err: errors.New("connection refused")}
+
+	res, err := New(WithStore(healthy), WithStore(broken)).Retrieve(ctx, "q")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, res.DocumentIDs())
+
+	_, err = New(WithStore(broken)).Retrieve(ctx, "q")
+	assert.ErrorContains(t, err, "connection refused")
+
+	_, err = New().Retrieve(ctx, "q")
+	assert.ErrorIs(t, err, ErrNoStores)
+}
+
+// reverseReranker reverses the result order
+type reverseReranker struct{ err error }
+
+func (r reverseReranker) Rerank(ctx context.Context, query string, results []interfaces.SearchResult) ([]interfaces.SearchResult, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	reversed := make([]interfaces.SearchResult, len(results))
+	for i, result := range results {
+		reversed[len(results)-1-i] = result
+	}
+	return reversed, nil
+}
+
+func TestRetriever_Reranker(t *testing.T) {
+	ctx := context.Background()
+	s := &fakeStore{results: map[string][]interfaces.SearchResult{
+		"q": {result("a", "A.", 0.9), result("b", "B.", 0.8), result("c", "C.", 0.7)},
+	}}
+
+	res, err := New(WithStore(s), WithMaxDocuments(2), WithReranker(reverseReranker{})).Retrieve(ctx, "q")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "b"}, res.DocumentIDs())
+
+	// A failing reranker keeps the fused order
+	res, err = New(WithStore(s), WithMaxDocuments(2), WithReranker(reverseReranker{err: errors.New("timeout")})).Retrieve(ctx, "q")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, res.DocumentIDs())
+}
+
+func TestRetriever_InMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := inmemory.New(nil)
+	require.NoError(t, err)
+	require.NoError(t, store.Store(ctx, []interfaces.Document{
+		{ID: "billing", Content: "Invoices are sent on the first day of each month.", Metadata: map[string]interface{}{"source": "billing.md"}},
+		{ID: "support", Content: "Support is available around the clock."},
+	}, interfaces.WithTenant("acme")))
+
+	res, err := New(WithStore(store, interfaces.WithTenantSearch("acme"))).Retrieve(ctx, "when are invoices sent")
+	require.NoError(t, err)
+	require.NotEmpty(t, res.Documents)
+	assert.Equal(t, "billing", res.Documents[0].Document.ID)
+	assert.Contains(t, res.Context, `<document id="billing" source="billing.md">`)
+}
+
+func TestResult_Citations(t *testing.T) {
+	res := &Result{Documents: []interfaces.SearchResult{result("doc-1", "", 0), result("doc-2", "", 0), result("doc-3", "", 0)}}
+
+	cited := res.Citations("Reset it in settings [doc-2]. See also [doc-1, doc-2] and [unknown].")
+	assert.Equal(t, []string{"doc-2", "doc-1"}, cited)
+	assert.Empty(t, res.Citations("No citations here."))
+	assert.Nil(t, (*Result)(nil).Citations("[doc-1]"))
+}
@@ -2,11 +2,18 @@ package retry
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/tagus/agent-sdk-go/pkg/logging"
 )
 
+// retryDelayer is implemented by errors that specify how long to wait before
+// retrying, such as rate limit errors of LLM providers
+type retryDelayer interface {
+	RetryDelay() time.Duration
+}
+
 // Executor handles the execution of operations with retries
 type Executor struct {
 	policy *Policy
@@ -50,6 +57,14 @@ func (e *Executor) Execute(ctx context.Context, operation func() error) error {
 				lastErr = err
 				attempt++
 
+				if e.policy.Retryable != nil && !e.policy.Retryable(err) {
+					e.logger.Debug(ctx, "Operation failed with non-retryable error", map[string]interface{}{
+						"attempt": attempt,
+						"error":   err.Error(),
+					})
+					return err
+				}
+
 				if attempt >= e.policy.MaximumAttempts {
 					e.logger.Debug(ctx, "Maximum attempts reached", map[string]interface{}{
 						"attempt": attempt,
@@ -64,6 +79,17 @@ func (e *Executor) Execute(ctx context.Context, operation func() error) error {
 					nextInterval = e.policy.MaximumInterval
 				}
 
+				// Wait at least as long as the error asks for, such as the
+				// Retry-After header of a rate limited request, but never
+				// longer than the maximum interval
+				var delayed retryDelayer
+				if errors.As(err, &delayed) && delayed.RetryDelay() > currentInterval {
+					currentInterval = delayed.RetryDelay()
+					if currentInterval > e.policy.MaximumInterval {
+						currentInterval = e.policy.MaximumInterval
+					}
+				}
+
 				e.logger.Debug(ctx, "Operation failed, scheduling retry", map[string]interface{}{
 					"attempt":          attempt,
 					"error":            err.Error(),
@@ -8,6 +8,10 @@ type Policy struct {
 	BackoffCoefficient float64
 	MaximumInterval    time.Duration
 	MaximumAttempts    int32
+
+	// Retryable reports whether a failed operation should be retried. A nil
+	// predicate retries all errors.
+	Retryable func(error) bool
 }
 
 // Option represents a retry policy option
@@ -27,7 +31,8 @@ func WithBackoffCoefficient(coefficient float64) Option {
 	}
 }
 
-// WithMaximumInterval sets the maximum interval between retries
+// WithMaximumInterval sets the maximum interval between retries. It also caps
+// the delay requested by an error, such as a Retry-After header.
 func WithMaximumInterval(interval time.Duration) Option {
 	return func(p *Policy) {
 		p.MaximumInterval = interval
@@ -41,6 +46,13 @@ func WithMaxAttempts(attempts int32) Option {
 	}
 }
 
+// WithRetryable sets the predicate that decides which errors are retried
+func WithRetryable(retryable func(error) bool) Option {
+	return func(p *Policy) {
+		p.Retryable = retryable
+	}
+}
+
 // NewPolicy creates a new retry policy with default values
 func NewPolicy(opts ...Option) *Policy {
 	policy := &Policy{
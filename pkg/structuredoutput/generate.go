@@ -0,0 +1,170 @@
+package structuredoutput
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// DefaultMaxRetries is the number of times Generate asks the LLM to repair an
+// invalid response
+const DefaultMaxRetries = 2
+
+// ValidationError is returned by Generate when the LLM did not produce a valid
+// response within the allowed attempts
+type ValidationError struct {
+	// Response is the last response of the LLM
+	Response string
+
+	// Attempts is the number of responses generated
+	Attempts int
+
+	// Err is the validation error of the last response
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid structured output after %d attempts: %v", e.Attempts, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+type generateConfig struct {
+	maxRetries int
+	options    []interfaces.GenerateOption
+}
+
+// Option configures Generate
+type Option func(*generateConfig)
+
+// WithMaxRetries sets the number of times an invalid response is sent back to
+// the LLM with its validation error to be repaired
+func WithMaxRetries(maxRetries int) Option {
+	return func(c *generateConfig) {
+		if maxRetries >= 0 {
+			c.maxRetries = maxRetries
+		}
+	}
+}
+
+// WithGenerateOptions sets the options of the LLM requests, such as the system
+// message or the temperature
+func WithGenerateOptions(options ...interfaces.GenerateOption) Option {
+	return func(c *generateConfig) {
+		c.options = append(c.options, options...)
+	}
+}
+
+// Generate asks the LLM for a response that follows the JSON schema of T, a
+// struct type, and returns it unmarshalled. The schema is passed as the
+// response format, which the LLM clients map to their native structured
+// output: json_schema for OpenAI, a forced tool call for Anthropic and the
+// format parameter for Ollama.
+//
+// Responses are validated against the schema. An invalid response is sent
+// back to the LLM with the validation error to be repaired, up to
+// DefaultMaxRetries times unless set with WithMaxRetries. When no valid
+// response is produced, a *ValidationError is returned.
+func Generate[T any](ctx context.Context, llm interfaces.LLM, prompt string, options ...Option) (T, error) {
+	var result T
+
+	format, err := responseFormatOf[T]()
+	if err != nil {
+		return result, err
+	}
+	validator, err := NewValidator(format.Schema)
+	if err != nil {
+		return result, err
+	}
+
+	config := newGenerateConfig(options)
+	generateOptions := append(append([]interfaces.GenerateOption{}, config.options...), interfaces.WithResponseFormat(*format))
+
+	var (
+		response string
+		lastErr  error
+		request  = prompt
+	)
+	for attempt := 0; attempt <= config.maxRetries; attempt++ {
+		response, err = llm.Generate(ctx, request, generateOptions...)
+		if err != nil {
+			return result, fmt.Errorf("failed to generate structured output: %w", err)
+		}
+
+		data := []byte(extractJSON(response))
+		if lastErr = validator.Validate(data); lastErr == nil {
+			if lastErr = json.Unmarshal(data, &result); lastErr == nil {
+				return result, nil
+			}
+		}
+		request = repairPrompt(prompt, response, lastErr)
+	}
+
+	return result, &ValidationError{
+		Response: response,
+		Attempts: config.maxRetries + 1,
+		Err:      lastErr,
+	}
+}
+
+// responseFormatOf returns the response format of T, which must be a struct
+// or a pointer to a struct
+func responseFormatOf[T any]() (*interfaces.ResponseFormat, error) {
+	var value T
+	t := reflect.TypeOf(value)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("structured output requires a struct type, got %T", value)
+	}
+	return NewResponseFormat(value), nil
+}
+
+func newGenerateConfig(options []Option) *generateConfig {
+	config := &generateConfig{maxRetries: DefaultMaxRetries}
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// repairPrompt asks the LLM to fix an invalid response
+func repairPrompt(prompt, response string, err error) string {
+	return fmt.Sprintf(`%s
+
+Your previous response was:
+%s
+
+It does not follow the required JSON schema: %v
+
+Respond again with only the corrected JSON object.`, prompt, response, err)
+}
+
+// extractJSON returns the JSON document of a response, removing markdown code
+// fences and text around the object
+func extractJSON(response string) string {
+	text := strings.TrimSpace(response)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text, "```")
+		text = strings.TrimPrefix(text, "json")
+		text = strings.TrimSuffix(strings.TrimSpace(text), "```")
+		text = strings.TrimSpace(text)
+	}
+	if strings.HasPrefix(text, "{") || strings.HasPrefix(text, "[") {
+		return text
+	}
+
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start >= 0 && end > start {
+		return text[start : end+1]
+	}
+	return text
+}
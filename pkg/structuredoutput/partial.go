@@ -0,0 +1,176 @@
+package structuredoutput
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// PartialParser incrementally parses a JSON document received in chunks, such
+// as the content deltas of a stream. At any point it returns the completed
+// part of the document: the fields and array items whose values are complete,
+// with the open objects and arrays closed. Text before the document, such as
+// a markdown code fence, is skipped.
+type PartialParser struct {
+	buf   strings.Builder
+	pos   int
+	start int
+	stack []frame
+	done  bool
+	last  string
+
+	inString bool
+	escaped  bool
+	isKey    bool
+	token    int // start of the pending number or literal, or -1
+
+	// safe is the end of the completed part and closers the brackets that
+	// close it
+	safe    int
+	closers string
+}
+
+// frame is an open object or array
+type frame struct {
+	closer    byte
+	expectKey bool
+}
+
+// NewPartialParser creates a partial JSON parser
+func NewPartialParser() *PartialParser {
+	return &PartialParser{start: -1, token: -1}
+}
+
+// Write appends a chunk of the document. It returns the JSON of the completed
+// part of the document and whether it changed since the previous call.
+func (p *PartialParser) Write(chunk string) (string, bool) {
+	p.buf.WriteString(chunk)
+	p.scan()
+
+	partial := p.Partial()
+	if partial == "" || partial == p.last {
+		return partial, false
+	}
+	p.last = partial
+	return partial, true
+}
+
+// Partial returns the JSON of the completed part of the document, or an empty
+// string before the document starts
+func (p *PartialParser) Partial() string {
+	if p.start < 0 || p.safe <= p.start {
+		return ""
+	}
+	return p.buf.String()[p.start:p.safe] + p.closers
+}
+
+// Complete reports whether the whole document has been received
+func (p *PartialParser) Complete() bool {
+	return p.done
+}
+
+// Unmarshal decodes the completed part of the document into v
+func (p *PartialParser) Unmarshal(v interface{}) error {
+	return json.Unmarshal([]byte(p.Partial()), v)
+}
+
+// scan advances through the new characters of the buffer
+func (p *PartialParser) scan() {
+	s := p.buf.String()
+	for ; p.pos < len(s) && !p.done; p.pos++ {
+		c := s[p.pos]
+
+		if p.start < 0 {
+			if c == '{' || c == '[' {
+				p.start = p.pos
+				p.open(c)
+			}
+			continue
+		}
+
+		if p.inString {
+			switch {
+			case p.escaped:
+				p.escaped = false
+			case c == '\\':
+				p.escaped = true
+			case c == '"':
+				p.inString = false
+				if p.isKey {
+					p.top().expectKey = false
+				} else {
+					p.completed(p.pos + 1)
+				}
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			p.endToken()
+			p.inString = true
+			top := p.top()
+			p.isKey = top != nil && top.closer == '}' && top.expectKey
+		case '{', '[':
+			p.endToken()
+			p.open(c)
+		case '}', ']':
+			p.endToken()
+			p.stack = p.stack[:len(p.stack)-1]
+			if len(p.stack) == 0 {
+				p.done = true
+			}
+			p.completed(p.pos + 1)
+		case ',':
+			p.endToken()
+			if top := p.top(); top != nil && top.closer == '}' {
+				top.expectKey = true
+			}
+		case ':', ' ', '\t', '\n', '\r':
+			p.endToken()
+		default:
+			if p.token < 0 {
+				p.token = p.pos
+			}
+			switch s[p.token : p.pos+1] {
+			case "true", "false", "null":
+				p.token = -1
+				p.completed(p.pos + 1)
+			}
+		}
+	}
+}
+
+// open starts an object or an array, which is complete as an empty value
+func (p *PartialParser) open(c byte) {
+	closer := byte(']')
+	if c == '{' {
+		closer = '}'
+	}
+	p.stack = append(p.stack, frame{closer: closer, expectKey: closer == '}'})
+	p.completed(p.pos + 1)
+}
+
+// endToken completes the pending number when a delimiter is reached
+func (p *PartialParser) endToken() {
+	if p.token >= 0 {
+		p.token = -1
+		p.completed(p.pos)
+	}
+}
+
+// completed records that the document is complete up to end
+func (p *PartialParser) completed(end int) {
+	p.safe = end
+	closers := make([]byte, len(p.stack))
+	for i := range p.stack {
+		closers[i] = p.stack[len(p.stack)-1-i].closer
+	}
+	p.closers = string(closers)
+}
+
+func (p *PartialParser) top() *frame {
+	if len(p.stack) == 0 {
+		return nil
+	}
+	return &p.stack[len(p.stack)-1]
+}
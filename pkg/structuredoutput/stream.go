@@ -0,0 +1,80 @@
+package structuredoutput
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// Metadata keys of partial object stream events
+const (
+	// MetadataPartialObject is the completed part of the response decoded
+	// into the requested type
+	MetadataPartialObject = "partial_object"
+
+	// MetadataPartialComplete is true once the whole response has been received
+	MetadataPartialComplete = "partial_complete"
+)
+
+// StreamPartial forwards the events of a stream whose content is a JSON
+// document. After each content delta that completes a field or an array item,
+// it adds a StreamEventPartialObject event whose Content is the JSON of the
+// completed part of the document and whose MetadataPartialObject metadata is
+// that part decoded into a T.
+func StreamPartial[T any](events <-chan interfaces.StreamEvent) <-chan interfaces.StreamEvent {
+	out := make(chan interfaces.StreamEvent, cap(events))
+	go func() {
+		defer close(out)
+
+		parser := NewPartialParser()
+		for event := range events {
+			out <- event
+			if event.Type != interfaces.StreamEventContentDelta {
+				continue
+			}
+
+			partial, changed := parser.Write(event.Content)
+			if !changed {
+				continue
+			}
+
+			metadata := map[string]interface{}{
+				MetadataPartialComplete: parser.Complete(),
+			}
+			var value T
+			if err := json.Unmarshal([]byte(partial), &value); err == nil {
+				metadata[MetadataPartialObject] = value
+			}
+			out <- interfaces.StreamEvent{
+				Type:      interfaces.StreamEventPartialObject,
+				Content:   partial,
+				Metadata:  metadata,
+				Timestamp: time.Now(),
+			}
+		}
+	}()
+	return out
+}
+
+// GenerateStream streams a response that follows the JSON schema of T, a
+// struct type, adding partial object events as described in StreamPartial.
+// Streamed responses are not validated nor repaired; validate the final
+// response with Validate if needed. WithMaxRetries has no effect.
+func GenerateStream[T any](ctx context.Context, llm interfaces.StreamingLLM, prompt string, options ...Option) (<-chan interfaces.StreamEvent, error) {
+	format, err := responseFormatOf[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	config := newGenerateConfig(options)
+	generateOptions := append(append([]interfaces.GenerateOption{}, config.options...), interfaces.WithResponseFormat(*format))
+
+	events, err := llm.GenerateStream(ctx, prompt, generateOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stream structured output: %w", err)
+	}
+	return StreamPartial[T](events), nil
+}
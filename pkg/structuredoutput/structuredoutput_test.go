@@ -0,0 +1,313 @@
+package structuredoutput
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// scriptedLLM returns its responses in order and records the prompts
+type scriptedLLM struct {
+	responses []string
+	prompts   []string
+	formats   []*interfaces.ResponseFormat
+}
+
+func (m *scriptedLLM) Generate(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (string, error) {
+	opts := &interfaces.GenerateOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+	m.prompts = append(m.prompts, prompt)
+	m.formats = append(m.formats, opts.ResponseFormat)
+	if len(m.responses) == 0 {
+		return "", errors.New("no more responses")
+	}
+	response := m.responses[0]
+	m.responses = m.responses[1:]
+	return response, nil
+}
+
+func (m *scriptedLLM) GenerateWithTools(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (string, error) {
+	return m.Generate(ctx, prompt, options...)
+}
+
+func (m *scriptedLLM) GenerateDetailed(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (*interfaces.LLMResponse, error) {
+	content, err := m.Generate(ctx, prompt, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &interfaces.LLMResponse{Content: content}, nil
+}
+
+func (m *scriptedLLM) GenerateWithToolsDetailed(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (*interfaces.LLMResponse, error) {
+	return m.GenerateDetailed(ctx, prompt, options...)
+}
+
+func (m *scriptedLLM) Name() string {
+	return "scripted"
+}
+
+func (m *scriptedLLM) SupportsStreaming() bool {
+	return false
+}
+
+type Address struct {
+	City    string `json:"city" description:"City name" example:"Paris"`
+	Country string `json:"country,omitempty"`
+}
+
+// Contact is either an email address or a phone number
+type Contact struct {
+	Email *string
+	Phone *int
+}
+
+func (Contact) JSONSchemaOneOf() []interface{} {
+	return []interface{}{"", 0}
+}
+
+type Person struct {
+	Name      string              `json:"name" description:"Full name"`
+	Priority  string              `json:"priority" enum:"low,medium,high"`
+	Level     int                 `json:"level,omitempty" enum:"1,2,3"`
+	Tags      []string            `json:"tags,omitempty" enum:"a,b"`
+	Address   **Address           `json:"address"`
+	Previous  []*Address          `json:"previous,omitempty"`
+	Addresses map[string]*Address `json:"addresses,omitempty"`
+	Contact   Contact             `json:"contact,omitempty"`
+	Extra     interface{}         `json:"extra,omitempty"`
+	Friend    *Person             `json:"friend,omitempty"`
+	Ignored   string              `json:"-"`
+}
+
+func TestNewResponseFormat(t *testing.T) {
+	format := NewResponseFormat(&Person{})
+	assert.Equal(t, "Person", format.Name)
+	assert.Equal(t, interfaces.ResponseFormatJSON, format.Type)
+
+	schema := format.Schema
+	assert.Equal(t, []string{"name", "priority", "address"}, schema["required"])
+
+	properties := schema["properties"].(map[string]any)
+	assert.Len(t, properties, 10)
+	assert.Equal(t, "Full name", properties["name"].(map[string]any)["description"])
+	assert.Equal(t, []any{"low", "medium", "high"}, properties["priority"].(map[string]any)["enum"])
+	assert.Equal(t, []any{int64(1), int64(2), int64(3)}, properties["level"].(map[string]any)["enum"])
+	assert.Equal(t, []any{"a", "b"}, properties["tags"].(map[string]any)["items"].(map[string]any)["enum"])
+
+	// Nested pointers resolve to the struct schema
+	address := properties["address"].(map[string]any)
+	assert.Equal(t, "object", address["type"])
+	assert.Equal(t, []string{"city"}, address["required"])
+	city := address["properties"].(map[string]any)["city"].(map[string]any)
+	assert.Equal(t, []any{"Paris"}, city["examples"])
+	assert.Equal(t, "City name", city["description"])
+
+	assert.Equal(t, "object", properties["previous"].(map[string]any)["items"].(map[string]any)["type"])
+	assert.Equal(t, "object", properties["addresses"].(map[string]any)["additionalProperties"].(map[string]any)["type"])
+
+	assert.Equal(t, []any{
+		map[string]any{"type": "string"},
+		map[string]any{"type": "integer"},
+	}, properties["contact"].(map[string]any)["oneOf"])
+	assert.Nil(t, properties["extra"].(map[string]any)["type"])
+
+	// Recursive types end with a plain object
+	friend := properties["friend"].(map[string]any)
+	assert.Equal(t, "object", friend["type"])
+	assert.Nil(t, friend["properties"])
+
+	// The schema is valid JSON schema
+	_, err := NewValidator(schema)
+	require.NoError(t, err)
+}
+
+func TestSchemaOf_Constraints(t *testing.T) {
+	type query struct {
+		Text  string   `json:"text" minLength:"1" maxLength:"100" pattern:"^[a-z ]+$"`
+		Limit int      `json:"limit,omitempty" minimum:"1" maximum:"50" default:"10"`
+		Since string   `json:"since,omitempty" format:"date"`
+		Tags  []string `json:"tags,omitempty" minItems:"1" maxItems:"3" maxLength:"10"`
+	}
+
+	schema := SchemaOf(query{})
+	properties := schema["properties"].(map[string]any)
+
+	text := properties["text"].(map[string]any)
+	assert.Equal(t, float64(1), text["minLength"])
+	assert.Equal(t, "^[a-z ]+$", text["pattern"])
+
+	limit := properties["limit"].(map[string]any)
+	assert.Equal(t, float64(50), limit["maximum"])
+	assert.Equal(t, int64(10), limit["default"])
+	assert.Equal(t, "date", properties["since"].(map[string]any)["format"])
+
+	// Array constraints apply to the array and string constraints to its items
+	tags := properties["tags"].(map[string]any)
+	assert.Equal(t, float64(3), tags["maxItems"])
+	assert.Equal(t, float64(10), tags["items"].(map[string]any)["maxLength"])
+
+	assert.NoError(t, Validate(schema, []byte(`{"text": "go tools", "limit": 5, "tags": ["a"]}`)))
+	assert.Error(t, Validate(schema, []byte(`{"text": "Go!"}`)))
+	assert.Error(t, Validate(schema, []byte(`{"text": "go", "limit": 51}`)))
+	assert.Error(t, Validate(schema, []byte(`{"text": "go", "tags": ["a", "b", "c", "d"]}`)))
+	assert.Error(t, Validate(schema, []byte(`{"text": "go", "tags": ["a very long tag"]}`)))
+}
+
+func TestValidate(t *testing.T) {
+	schema := NewResponseFormat(Person{}).Schema
+
+	assert.NoError(t, Validate(schema, []byte(`{"name": "Ada", "priority": "high", "address": {"city": "London"}, "contact": 42}`)))
+	assert.Error(t, Validate(schema, []byte(`{"name": "Ada", "priority": "urgent", "address": {"city": "London"}}`)))
+	assert.Error(t, Validate(schema, []byte(`{"name": "Ada", "priority": "high"}`)))
+	assert.Error(t, Validate(schema, []byte(`{"name": "Ada", "priority": "high", "address": {"city": "London"}, "contact": true}`)))
+	assert.Error(t, Validate(schema, []byte(`not json`)))
+}
+
+func TestGenerate(t *testing.T) {
+	llm := &scriptedLLM{responses: []string{
+		"```json\n{\"name\": \"Ada\", \"priority\": \"urgent\", \"address\": {\"city\": \"London\"}}\n```",
+		`Here it is: {"name": "Ada", "priority": "high", "address": {"city": "London"}}`,
+	}}
+
+	person, err := Generate[Person](context.Background(), llm, "Describe Ada",
+		WithGenerateOptions(interfaces.WithSystemMessage("be precise")))
+	require.NoError(t, err)
+	assert.Equal(t, "Ada", person.Name)
+	assert.Equal(t, "high", person.Priority)
+	require.NotNil(t, person.Address)
+	assert.Equal(t, "London", (**person.Address).City)
+
+	// The invalid response is sent back with its validation error
+	require.Len(t, llm.prompts, 2)
+	assert.Equal(t, "Describe Ada", llm.prompts[0])
+	assert.Contains(t, llm.prompts[1], "Describe Ada")
+	assert.Contains(t, llm.prompts[1], "urgent")
+	assert.Contains(t, llm.prompts[1], "enum")
+	require.NotNil(t, llm.formats[0])
+	assert.Equal(t, "Person", llm.formats[0].Name)
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	llm := &scriptedLLM{responses: []string{`{}`, `{"name": "Ada"}`}}
+	_, err := Generate[Person](ctx, llm, "Describe Ada", WithMaxRetries(1))
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, 2, validationErr.Attempts)
+	assert.Equal(t, `{"name": "Ada"}`, validationErr.Response)
+
+	// Errors of the LLM are not retried
+	_, err = Generate[Person](ctx, &scriptedLLM{}, "Describe Ada")
+	require.Error(t, err)
+	assert.False(t, errors.As(err, &validationErr))
+
+	_, err = Generate[[]Person](ctx, &scriptedLLM{}, "Describe Ada")
+	assert.Error(t, err)
+
+	// Pointer types are supported
+	person, err := Generate[*Address](ctx, &scriptedLLM{responses: []string{`{"city": "Paris"}`}}, "Where?")
+	require.NoError(t, err)
+	assert.Equal(t, "Paris", person.City)
+}
+
+func TestExtractJSON(t *testing.T) {
+	for input, expected := range map[string]string{
+		`{"a": 1}`:                  `{"a": 1}`,
+		"```json\n{\"a\": 1}\n```":  `{"a": 1}`,
+		"```\n{\"a\": 1}\n```":      `{"a": 1}`,
+		`Sure! {"a": {"b": 2}} Bye`: `{"a": {"b": 2}}`,
+	} {
+		assert.True(t, json.Valid([]byte(extractJSON(input))), input)
+		assert.Equal(t, expected, extractJSON(input))
+	}
+}
+
+func TestPartialParser(t *testing.T) {
+	document := "```json\n" + `{"name": "Ada \"the first\"", "level": 12, "tags": ["a", "b"], "address": {"city": "London"}, "active": true, "extra": null}` + "\n```"
+
+	parser := NewPartialParser()
+	var partials []string
+	for _, c := range document {
+		if partial, changed := parser.Write(string(c)); changed {
+			require.True(t, json.Valid([]byte(partial)), partial)
+			partials = append(partials, partial)
+		}
+	}
+
+	assert.Equal(t, []string{
+		`{}`,
+		`{"name": "Ada \"the first\""}`,
+		`{"name": "Ada \"the first\"", "level": 12}`,
+		`{"name": "Ada \"the first\"", "level": 12, "tags": []}`,
+		`{"name": "Ada \"the first\"", "level": 12, "tags": ["a"]}`,
+		`{"name": "Ada \"the first\"", "level": 12, "tags": ["a", "b"]}`,
+		`{"name": "Ada \"the first\"", "level": 12, "tags": ["a", "b"], "address": {}}`,
+		`{"name": "Ada \"the first\"", "level": 12, "tags": ["a", "b"], "address": {"city": "London"}}`,
+		`{"name": "Ada \"the first\"", "level": 12, "tags": ["a", "b"], "address": {"city": "London"}, "active": true}`,
+		`{"name": "Ada \"the first\"", "level": 12, "tags": ["a", "b"], "address": {"city": "London"}, "active": true, "extra": null}`,
+	}, partials)
+	assert.True(t, parser.Complete())
+
+	var person Person
+	require.NoError(t, parser.Unmarshal(&person))
+	assert.Equal(t, "London", (**person.Address).City)
+}
+
+func TestGenerateStream(t *testing.T) {
+	llm := &streamingLLM{chunks: []string{`{"city": "Pa`, `ris", "coun`, `try": "France"}`}}
+
+	events, err := GenerateStream[Address](context.Background(), llm, "Where?")
+	require.NoError(t, err)
+
+	var addresses []Address
+	var complete bool
+	for event := range events {
+		if event.Type != interfaces.StreamEventPartialObject {
+			continue
+		}
+		addresses = append(addresses, event.Metadata[MetadataPartialObject].(Address))
+		complete = event.Metadata[MetadataPartialComplete].(bool)
+	}
+
+	assert.Equal(t, []Address{{}, {City: "Paris"}, {City: "Paris", Country: "France"}}, addresses)
+	assert.True(t, complete)
+	require.NotNil(t, llm.format)
+	assert.Equal(t, "Address", llm.format.Name)
+}
+
+// streamingLLM streams its chunks as content deltas
+type streamingLLM struct {
+	scriptedLLM
+	chunks []string
+	format *interfaces.ResponseFormat
+}
+
+func (m *streamingLLM) GenerateStream(ctx context.Context, prompt string, options ...interfaces.GenerateOption) (<-chan interfaces.StreamEvent, error) {
+	opts := &interfaces.GenerateOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+	m.format = opts.ResponseFormat
+
+	events := make(chan interfaces.StreamEvent, len(m.chunks)+2)
+	events <- interfaces.StreamEvent{Type: interfaces.StreamEventMessageStart}
+	for _, chunk := range m.chunks {
+		events <- interfaces.StreamEvent{Type: interfaces.StreamEventContentDelta, Content: chunk}
+	}
+	events <- interfaces.StreamEvent{Type: interfaces.StreamEventMessageStop}
+	close(events)
+	return events, nil
+}
+
+func (m *streamingLLM) GenerateWithToolsStream(ctx context.Context, prompt string, tools []interfaces.Tool, options ...interfaces.GenerateOption) (<-chan interfaces.StreamEvent, error) {
+	return m.GenerateStream(ctx, prompt, options...)
+}
@@ -1,107 +1,290 @@
 package structuredoutput
 
 import (
+	"encoding/json"
 	"reflect"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/tagus/agent-sdk-go/pkg/interfaces"
 )
 
-// NewResponseFormat creates a ResponseFormat from a struct type
+// OneOf is implemented by types whose JSON value follows one of several
+// schemas, such as union types with a custom UnmarshalJSON. JSONSchemaOneOf
+// returns a value of each alternative type; the schema of the type is the
+// oneOf of their schemas.
+type OneOf interface {
+	JSONSchemaOneOf() []interface{}
+}
+
+var (
+	oneOfType = reflect.TypeOf((*OneOf)(nil)).Elem()
+	timeType  = reflect.TypeOf(time.Time{})
+)
+
+// NewResponseFormat creates a ResponseFormat from a struct type, with the
+// schema described in SchemaOf.
 func NewResponseFormat(v interface{}) *interfaces.ResponseFormat {
 	t := reflect.TypeOf(v)
-	if t.Kind() == reflect.Ptr {
+	for t.Kind() == reflect.Ptr {
 		t = t.Elem()
 	}
 
-	schema := interfaces.JSONSchema{
-		"type":       "object",
-		"properties": getJSONSchema(t),
-		"required":   getRequiredFields(t),
-	}
-
 	return &interfaces.ResponseFormat{
 		Type:   interfaces.ResponseFormatJSON,
 		Name:   t.Name(),
-		Schema: schema,
+		Schema: SchemaOf(v),
+	}
+}
+
+// SchemaOf returns the JSON schema of the type of v.
+//
+// The schema of each field is derived from its type, following pointers,
+// slices, maps and nested structs. Fields are required unless their json tag
+// has omitempty. The following struct tags add to the schema of a field:
+//
+//   - description: the description of the field
+//   - enum: a comma-separated list of the allowed values
+//   - example: an example value
+//   - default: the default value
+//   - minimum, maximum: the range of a number
+//   - minLength, maxLength, pattern, format: the constraints of a string
+//   - minItems, maxItems: the number of items of an array
+func SchemaOf(v interface{}) interfaces.JSONSchema {
+	return interfaces.JSONSchema(getTypeSchema(reflect.TypeOf(v), map[reflect.Type]bool{}))
+}
+
+// constraintTags are the struct tags that set a keyword of the schema of a
+// field, with whether their value is a number
+var constraintTags = []struct {
+	tag     string
+	numeric bool
+}{
+	{"minimum", true},
+	{"maximum", true},
+	{"minLength", true},
+	{"maxLength", true},
+	{"minItems", true},
+	{"maxItems", true},
+	{"pattern", false},
+	{"format", false},
+}
+
+// getTypeSchema returns the JSON schema of a type. Seen holds the structs
+// being described, so that recursive types end with a plain object.
+func getTypeSchema(t reflect.Type, seen map[reflect.Type]bool) map[string]any {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if variants, ok := oneOfVariants(t); ok {
+		var schemas []any
+		for _, variant := range variants {
+			if variant == nil {
+				schemas = append(schemas, map[string]any{"type": "null"})
+				continue
+			}
+			schemas = append(schemas, getTypeSchema(reflect.TypeOf(variant), seen))
+		}
+		return map[string]any{"oneOf": schemas}
+	}
+
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if seen[t] {
+			return map[string]any{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		return map[string]any{
+			"type":       "object",
+			"properties": getJSONSchema(t, seen),
+			"required":   getRequiredFields(t),
+		}
+	case reflect.Slice, reflect.Array:
+		// Byte slices are encoded as base64 strings
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string"}
+		}
+		return map[string]any{
+			"type":  "array",
+			"items": getTypeSchema(t.Elem(), seen),
+		}
+	case reflect.Map:
+		return map[string]any{
+			"type":                 "object",
+			"additionalProperties": getTypeSchema(t.Elem(), seen),
+		}
+	case reflect.Interface:
+		// Any JSON value
+		return map[string]any{}
+	default:
+		return map[string]any{"type": getJSONType(t)}
 	}
 }
 
-func getJSONSchema(t reflect.Type) map[string]any {
+// getJSONSchema returns the schemas of the properties of a struct
+func getJSONSchema(t reflect.Type, seen map[reflect.Type]bool) map[string]any {
 	properties := make(map[string]any)
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		jsonTag := strings.Split(field.Tag.Get("json"), ",")[0]
-		if jsonTag == "" {
-			jsonTag = field.Name
+	for _, field := range jsonFields(t) {
+		name, _ := jsonName(field)
+		properties[name] = getFieldSchema(field, seen)
+	}
+	return properties
+}
+
+// getFieldSchema returns the schema of a struct field, including the
+// keywords of its struct tags
+func getFieldSchema(field reflect.StructField, seen map[reflect.Type]bool) map[string]any {
+	schema := getTypeSchema(field.Type, seen)
+	schema["description"] = field.Tag.Get("description")
+
+	// Enums and examples of slices apply to their items
+	valueType := field.Type
+	for valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+	}
+	target := schema
+	if items, ok := schema["items"].(map[string]any); ok && schema["type"] == "array" {
+		valueType = valueType.Elem()
+		for valueType.Kind() == reflect.Ptr {
+			valueType = valueType.Elem()
 		}
+		target = items
+	}
 
-		fieldType := field.Type
-		// Handle pointer types by getting the underlying element type
-		if fieldType.Kind() == reflect.Ptr {
-			fieldType = fieldType.Elem()
+	if enum, ok := field.Tag.Lookup("enum"); ok {
+		var values []any
+		for _, value := range strings.Split(enum, ",") {
+			values = append(values, parseTagValue(strings.TrimSpace(value), valueType))
 		}
+		target["enum"] = values
+	}
+	if example, ok := field.Tag.Lookup("example"); ok {
+		schema["examples"] = []any{parseTagValue(example, field.Type)}
+	}
+	if value, ok := field.Tag.Lookup("default"); ok {
+		schema["default"] = parseTagValue(value, field.Type)
+	}
 
-		// Handle nested structs (including pointer to structs)
-		if fieldType.Kind() == reflect.Struct {
-			requiredFields := getRequiredFields(fieldType)
-			// Ensure required is an empty array instead of null when no required fields
-			if requiredFields == nil {
-				requiredFields = []string{}
-			}
+	// String and number constraints of slices apply to their items, while
+	// minItems and maxItems apply to the array
+	for _, constraint := range constraintTags {
+		value, ok := field.Tag.Lookup(constraint.tag)
+		if !ok {
+			continue
+		}
+		keywordTarget := target
+		if strings.HasSuffix(constraint.tag, "Items") {
+			keywordTarget = schema
+		}
+		if !constraint.numeric {
+			keywordTarget[constraint.tag] = value
+		} else if n, err := strconv.ParseFloat(value, 64); err == nil {
+			keywordTarget[constraint.tag] = n
+		}
+	}
+	return schema
+}
 
-			properties[jsonTag] = map[string]any{
-				"type":        "object",
-				"description": field.Tag.Get("description"),
-				"properties":  getJSONSchema(fieldType),
-				"required":    requiredFields,
-			}
-		} else if fieldType.Kind() == reflect.Slice || fieldType.Kind() == reflect.Array {
-			// Handle arrays/slices with items property
-			itemType := fieldType.Elem()
-			// Handle pointer element types in slices
-			if itemType.Kind() == reflect.Ptr {
-				itemType = itemType.Elem()
-			}
+// parseTagValue converts a struct tag value to the JSON value of a type
+func parseTagValue(value string, t reflect.Type) any {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
 
-			// If the slice contains structs, we need to handle them specially
-			if itemType.Kind() == reflect.Struct {
-				properties[jsonTag] = map[string]any{
-					"type":        "array",
-					"description": field.Tag.Get("description"),
-					"items": map[string]any{
-						"type":       "object",
-						"properties": getJSONSchema(itemType),
-						"required":   getRequiredFields(itemType),
-					},
-				}
-			} else {
-				properties[jsonTag] = map[string]any{
-					"type":        "array",
-					"description": field.Tag.Get("description"),
-					"items": map[string]string{
-						"type": getJSONType(itemType),
-					},
-				}
-			}
-		} else if fieldType.Kind() == reflect.Map {
-			// For maps, we can specify that it's an object with additional properties
-			valueType := fieldType.Elem()
-			properties[jsonTag] = map[string]any{
-				"type":        "object",
-				"description": field.Tag.Get("description"),
-				"additionalProperties": map[string]string{
-					"type": getJSONType(valueType),
-				},
+	switch t.Kind() {
+	case reflect.String:
+		return value
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	case reflect.Float32, reflect.Float64:
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	default:
+		var v any
+		if err := json.Unmarshal([]byte(value), &v); err == nil {
+			return v
+		}
+	}
+	return value
+}
+
+// oneOfVariants returns the alternatives of a type implementing OneOf
+func oneOfVariants(t reflect.Type) ([]interface{}, bool) {
+	if t.Kind() == reflect.Interface {
+		return nil, false
+	}
+	if t.Implements(oneOfType) {
+		return reflect.Zero(t).Interface().(OneOf).JSONSchemaOneOf(), true
+	}
+	if reflect.PointerTo(t).Implements(oneOfType) {
+		return reflect.New(t).Interface().(OneOf).JSONSchemaOneOf(), true
+	}
+	return nil, false
+}
+
+// jsonFields returns the fields of a struct that are encoded in JSON, with the
+// fields of embedded structs promoted as encoding/json does
+func jsonFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _ := jsonName(field)
+		if name == "-" {
+			continue
+		}
+
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
 			}
-		} else {
-			properties[jsonTag] = map[string]interface{}{
-				"type":        getJSONType(fieldType),
-				"description": field.Tag.Get("description"),
+			if embedded.Kind() == reflect.Struct {
+				fields = append(fields, jsonFields(embedded)...)
+				continue
 			}
 		}
+		if !field.IsExported() {
+			continue
+		}
+		fields = append(fields, field)
 	}
-	return properties
+	return fields
+}
+
+// jsonName returns the JSON name of a field and whether it has omitempty
+func jsonName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "-", false
+	}
+	parts := strings.Split(tag, ",")
+	name := parts[0]
+	if name == "" {
+		name = field.Name
+	}
+	omitempty := false
+	for _, option := range parts[1:] {
+		if option == "omitempty" || option == "omitzero" {
+			omitempty = true
+		}
+	}
+	return name, omitempty
 }
 
 func getJSONType(t reflect.Type) string {
@@ -113,7 +296,8 @@ func getJSONType(t reflect.Type) string {
 	switch t.Kind() {
 	case reflect.String:
 		return "string"
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		return "integer"
 	case reflect.Float32, reflect.Float64:
 		return "number"
@@ -133,20 +317,11 @@ func getJSONType(t reflect.Type) string {
 }
 
 func getRequiredFields(t reflect.Type) []string {
-	var required []string
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		if !strings.Contains(field.Tag.Get("json"), "omitempty") {
-			jsonTag := strings.Split(field.Tag.Get("json"), ",")[0]
-			if jsonTag == "" {
-				jsonTag = field.Name
-			}
-			required = append(required, jsonTag)
+	required := []string{}
+	for _, field := range jsonFields(t) {
+		if name, omitempty := jsonName(field); !omitempty {
+			required = append(required, name)
 		}
 	}
-	// Ensure we return an empty array instead of nil
-	if required == nil {
-		return []string{}
-	}
 	return required
 }
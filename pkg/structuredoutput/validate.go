@@ -0,0 +1,67 @@
+package structuredoutput
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/jsonschema-go/jsonschema"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// Validator validates JSON documents against a JSON schema
+type Validator struct {
+	resolved *jsonschema.Resolved
+}
+
+// NewValidator compiles a JSON schema
+func NewValidator(schema interfaces.JSONSchema) (*Validator, error) {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode schema: %w", err)
+	}
+
+	var s jsonschema.Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	resolved, err := s.Resolve(nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return &Validator{resolved: resolved}, nil
+}
+
+// Validate checks that data is a JSON document that follows the schema
+func (v *Validator) Validate(data []byte) error {
+	var instance any
+	if err := json.Unmarshal(data, &instance); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return v.resolved.Validate(instance)
+}
+
+// Validate checks that data is a JSON document that follows the schema
+func Validate(schema interfaces.JSONSchema, data []byte) error {
+	v, err := NewValidator(schema)
+	if err != nil {
+		return err
+	}
+	return v.Validate(data)
+}
+
+// ApplyDefaults returns data with the missing optional properties of the
+// top-level object set to their default values
+func (v *Validator) ApplyDefaults(data []byte) ([]byte, error) {
+	var instance any
+	if err := json.Unmarshal(data, &instance); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if _, ok := instance.(map[string]any); !ok {
+		return data, nil
+	}
+	if err := v.resolved.ApplyDefaults(&instance); err != nil {
+		return nil, fmt.Errorf("failed to apply defaults: %w", err)
+	}
+	return json.Marshal(instance)
+}
@@ -0,0 +1,233 @@
+package tokens
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Encoding names of the OpenAI tokenizers
+const (
+	// CL100KBase is the encoding of GPT-4, GPT-3.5 and the text-embedding-3 models
+	CL100KBase = "cl100k_base"
+	// O200KBase is the encoding of GPT-4o, GPT-4.1, GPT-5 and the o-series models
+	O200KBase = "o200k_base"
+)
+
+// splitters are the pre-tokenizers of the supported encodings
+var splitters = map[string]func(text string) []string{
+	CL100KBase: splitCL100K,
+	O200KBase:  splitO200K,
+}
+
+var (
+	encodingsMu sync.RWMutex
+	encodings   = make(map[string]*BPE)
+)
+
+// BPE is a byte pair encoding tokenizer that produces the same tokens as
+// OpenAI's tiktoken for the same vocabulary. Special tokens such as
+// <|endoftext|> are encoded as ordinary text.
+type BPE struct {
+	name    string
+	ranks   map[string]int
+	decoder map[int]string
+	split   func(text string) []string
+}
+
+// NewBPE creates a tokenizer from the ranks of an encoding, as returned by
+// ParseRanks. The encoding name selects the pre-tokenizer and must be
+// CL100KBase or O200KBase. The ranks must include every single byte.
+func NewBPE(name string, ranks map[string]int) (*BPE, error) {
+	split, ok := splitters[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported encoding: %s", name)
+	}
+	for b := 0; b < 256; b++ {
+		if _, ok := ranks[string([]byte{byte(b)})]; !ok {
+			return nil, fmt.Errorf("encoding %s has no token for byte %#02x", name, b)
+		}
+	}
+
+	decoder := make(map[int]string, len(ranks))
+	for token, rank := range ranks {
+		decoder[rank] = token
+	}
+	return &BPE{name: name, ranks: ranks, decoder: decoder, split: split}, nil
+}
+
+// ParseRanks reads token ranks in the tiktoken format, one base64 encoded
+// token and its rank per line, as in the cl100k_base.tiktoken file
+func ParseRanks(r io.Reader) (map[string]int, error) {
+	ranks := make(map[string]int)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		encoded, rankText, ok := strings.Cut(text, " ")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected token and rank", line)
+		}
+		token, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid token: %w", line, err)
+		}
+		rank, err := strconv.Atoi(rankText)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid rank: %w", line, err)
+		}
+		ranks[string(token)] = rank
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ranks: %w", err)
+	}
+	return ranks, nil
+}
+
+// LoadEncoding reads the ranks of an encoding in the tiktoken format and
+// registers its tokenizer, which then replaces the embedded one for the
+// models of the encoding. The ranks are read from the reader only, nothing is
+// downloaded.
+func LoadEncoding(name string, r io.Reader) (*BPE, error) {
+	ranks, err := ParseRanks(r)
+	if err != nil {
+		return nil, err
+	}
+	bpe, err := NewBPE(name, ranks)
+	if err != nil {
+		return nil, err
+	}
+
+	encodingsMu.Lock()
+	encodings[name] = bpe
+	encodingsMu.Unlock()
+	return bpe, nil
+}
+
+// LoadEncodingFile registers the tokenizer of an encoding from a tiktoken
+// rank file, such as cl100k_base.tiktoken
+func LoadEncodingFile(name, path string) (*BPE, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open encoding file: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	return LoadEncoding(name, file)
+}
+
+// GetEncoding returns the tokenizer of an encoding: the one registered with
+// LoadEncoding, otherwise the one built from the embedded rank files. The
+// embedded ranks are parsed on first use.
+func GetEncoding(name string) (*BPE, bool) {
+	encodingsMu.RLock()
+	bpe, ok := encodings[name]
+	encodingsMu.RUnlock()
+	if ok {
+		return bpe, true
+	}
+
+	bpe, err := loadEmbedded(name)
+	return bpe, err == nil
+}
+
+// Name returns the name of the encoding
+func (b *BPE) Name() string {
+	return b.name
+}
+
+// Encode returns the tokens of a text
+func (b *BPE) Encode(text string) []int {
+	var tokens []int
+	for _, piece := range b.split(text) {
+		tokens = b.encodePiece(piece, tokens)
+	}
+	return tokens
+}
+
+// Spans returns the byte offsets of the tokens of a text, in order. A token
+// may start or end inside a multi-byte character.
+func (b *BPE) Spans(text string) [][2]int {
+	var spans [][2]int
+	offset := 0
+	for _, piece := range b.split(text) {
+		if _, ok := b.ranks[piece]; ok {
+			spans = append(spans, [2]int{offset, offset + len(piece)})
+		} else {
+			bounds := b.mergePiece(piece)
+			for i := 0; i+1 < len(bounds); i++ {
+				spans = append(spans, [2]int{offset + bounds[i], offset + bounds[i+1]})
+			}
+		}
+		offset += len(piece)
+	}
+	return spans
+}
+
+// Decode returns the text of tokens. Unknown tokens are skipped.
+func (b *BPE) Decode(tokens []int) string {
+	var sb strings.Builder
+	for _, token := range tokens {
+		sb.WriteString(b.decoder[token])
+	}
+	return sb.String()
+}
+
+// CountTokens returns the number of tokens of a text
+func (b *BPE) CountTokens(text string) (int, error) {
+	count := 0
+	for _, piece := range b.split(text) {
+		if _, ok := b.ranks[piece]; ok {
+			count++
+			continue
+		}
+		count += len(b.mergePiece(piece)) - 1
+	}
+	return count, nil
+}
+
+// encodePiece appends the tokens of a pre-tokenized piece
+func (b *BPE) encodePiece(piece string, tokens []int) []int {
+	if rank, ok := b.ranks[piece]; ok {
+		return append(tokens, rank)
+	}
+	bounds := b.mergePiece(piece)
+	for i := 0; i+1 < len(bounds); i++ {
+		tokens = append(tokens, b.ranks[piece[bounds[i]:bounds[i+1]]])
+	}
+	return tokens
+}
+
+// mergePiece repeatedly merges the adjacent pair of parts with the lowest
+// rank, starting from single bytes, and returns the boundaries of the
+// resulting tokens
+func (b *BPE) mergePiece(piece string) []int {
+	bounds := make([]int, len(piece)+1)
+	for i := range bounds {
+		bounds[i] = i
+	}
+
+	for len(bounds) > 2 {
+		best, bestRank := -1, math.MaxInt
+		for i := 0; i+2 < len(bounds); i++ {
+			if rank, ok := b.ranks[piece[bounds[i]:bounds[i+2]]]; ok && rank < bestRank {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		bounds = append(bounds[:best+1], bounds[best+2:]...)
+	}
+	return bounds
+}
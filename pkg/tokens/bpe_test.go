@@ -0,0 +1,139 @@
+package tokens
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRanks returns a rank file with all single bytes and a few merges
+func testRanks() string {
+	var sb strings.Builder
+	for b := 0; b < 256; b++ {
+		fmt.Fprintf(&sb, "%s %d\n", base64.StdEncoding.EncodeToString([]byte{byte(b)}), b)
+	}
+	for i, merge := range []string{"th", "the", " t", " the", "in", "ing"} {
+		fmt.Fprintf(&sb, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(merge)), 256+i)
+	}
+	return sb.String()
+}
+
+func TestBPE_Encode(t *testing.T) {
+	ranks, err := ParseRanks(strings.NewReader(testRanks()))
+	require.NoError(t, err)
+	bpe, err := NewBPE(CL100KBase, ranks)
+	require.NoError(t, err)
+
+	tokens := bpe.Encode("the thing")
+	// "the" is a single token, " thing" merges "th", "in" and then "ing"
+	assert.Equal(t, []int{257, ' ', 256, 261}, tokens)
+	assert.Equal(t, "the thing", bpe.Decode(tokens))
+
+	count, err := bpe.CountTokens("the thing")
+	require.NoError(t, err)
+	assert.Equal(t, 4, count)
+
+	text := "Ünïcödé — 日本語 ✓"
+	assert.Equal(t, text, bpe.Decode(bpe.Encode(text)))
+}
+
+func TestNewBPE_Validation(t *testing.T) {
+	_, err := NewBPE("p50k_base", map[string]int{})
+	assert.Error(t, err)
+
+	_, err = NewBPE(CL100KBase, map[string]int{"a": 0})
+	assert.Error(t, err)
+
+	_, err = ParseRanks(strings.NewReader("not-a-rank-line\n"))
+	assert.Error(t, err)
+}
+
+func TestLoadEncoding(t *testing.T) {
+	embedded := ForModel("gpt-4o")
+	require.IsType(t, &BPE{}, embedded)
+
+	loaded, err := LoadEncoding(O200KBase, strings.NewReader(testRanks()))
+	require.NoError(t, err)
+	defer func() {
+		encodingsMu.Lock()
+		delete(encodings, O200KBase)
+		encodingsMu.Unlock()
+	}()
+
+	// Registered encodings replace the embedded ones
+	assert.Same(t, loaded, ForModel("gpt-4o"))
+	assert.NotSame(t, loaded, ForModel("gpt-4"))
+	assert.IsType(t, &Estimator{}, ForModel("claude-3-5-sonnet"))
+}
+
+func TestEmbeddedEncodings(t *testing.T) {
+	tests := []struct {
+		encoding string
+		text     string
+		tokens   []int
+	}{
+		{CL100KBase, "hello world", []int{15339, 1917}},
+		{CL100KBase, "hello   world", []int{15339, 256, 1917}},
+		{CL100KBase, "'RE", []int{95253}},
+		{CL100KBase, "supercalifragilistic", []int{13066, 3035, 278, 333, 4193, 321, 4633}},
+		{CL100KBase, "We know what we are, but know not what we may be.", []int{1687, 1440, 1148, 584, 527, 11, 719, 1440, 539, 1148, 584, 1253, 387, 13}},
+		{CL100KBase, "tiktoken is great!", []int{83, 1609, 5963, 374, 2294, 0}},
+		{CL100KBase, "2 + 2 = 4", []int{17, 489, 220, 17, 284, 220, 19}},
+		{CL100KBase, "お誕生日おめでとう", []int{33334, 45918, 243, 21990, 9080, 33334, 62004, 16556, 78699}},
+		{O200KBase, "hello world", []int{24912, 2375}},
+		{O200KBase, "hello   world", []int{24912, 256, 2375}},
+		{O200KBase, "'RE", []int{6, 1099}},
+		{O200KBase, "supercalifragilistic", []int{17789, 5842, 366, 17764, 311, 6207}},
+		{O200KBase, "We know what we are, but know not what we may be.", []int{2167, 1761, 1412, 581, 553, 11, 889, 1761, 625, 1412, 581, 1340, 413, 13}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.encoding+"/"+tt.text, func(t *testing.T) {
+			bpe, ok := GetEncoding(tt.encoding)
+			require.True(t, ok)
+
+			assert.Equal(t, tt.tokens, bpe.Encode(tt.text))
+			assert.Equal(t, tt.text, bpe.Decode(tt.tokens))
+			count, err := bpe.CountTokens(tt.text)
+			require.NoError(t, err)
+			assert.Equal(t, len(tt.tokens), count)
+		})
+	}
+}
+
+func TestSplitCL100K(t *testing.T) {
+	tests := map[string][]string{
+		"Hello world":      {"Hello", " world"},
+		"I'm  here\n\n":    {"I", "'m", " ", " here", "\n\n"},
+		"12345":            {"123", "45"},
+		"foo!!!\n\nbar":    {"foo", "!!!\n\n", "bar"},
+		"x  \n  y":         {"x", "  \n", " ", " y"},
+		"end   ":           {"end", "   "},
+		"HelloWorld it's":  {"HelloWorld", " it", "'s"},
+		"func(a, b) {}":    {"func", "(a", ",", " b", ")", " {}"},
+		"naïve café":       {"naïve", " café"},
+		"  indented\tline": {" ", " indented", "\tline"},
+	}
+	for text, want := range tests {
+		assert.Equal(t, want, splitCL100K(text), text)
+	}
+}
+
+func TestSplitO200K(t *testing.T) {
+	tests := map[string][]string{
+		"HelloWorld":  {"Hello", "World"},
+		"it's":        {"it's"},
+		"CamelCASE":   {"Camel", "CASE"},
+		"a/b\n":       {"a", "/b", "\n"},
+		"12345":       {"123", "45"},
+		"foo!!!/\n":   {"foo", "!!!/\n"},
+		"Hello world": {"Hello", " world"},
+	}
+	for text, want := range tests {
+		assert.Equal(t, want, splitO200K(text), text)
+	}
+}
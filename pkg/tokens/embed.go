@@ -0,0 +1,60 @@
+package tokens
+
+import (
+	"compress/gzip"
+	"embed"
+	"fmt"
+	"sync"
+)
+
+// embeddedRanks holds the gzip compressed rank files of the supported
+// encodings, as published by OpenAI for tiktoken
+//
+//go:embed assets/cl100k_base.tiktoken.gz assets/o200k_base.tiktoken.gz
+var embeddedRanks embed.FS
+
+// embeddedEncoding is an encoding loaded from the embedded rank files on
+// first use
+type embeddedEncoding struct {
+	once sync.Once
+	bpe  *BPE
+	err  error
+}
+
+var embeddedEncodings = map[string]*embeddedEncoding{
+	CL100KBase: {},
+	O200KBase:  {},
+}
+
+// loadEmbedded returns the tokenizer of an encoding from the embedded rank
+// files, parsing them once
+func loadEmbedded(name string) (*BPE, error) {
+	encoding, ok := embeddedEncodings[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported encoding: %s", name)
+	}
+
+	encoding.once.Do(func() {
+		file, err := embeddedRanks.Open("assets/" + name + ".tiktoken.gz")
+		if err != nil {
+			encoding.err = fmt.Errorf("failed to open ranks of %s: %w", name, err)
+			return
+		}
+		defer func() {
+			_ = file.Close()
+		}()
+
+		reader, err := gzip.NewReader(file)
+		if err != nil {
+			encoding.err = fmt.Errorf("failed to decompress ranks of %s: %w", name, err)
+			return
+		}
+		ranks, err := ParseRanks(reader)
+		if err != nil {
+			encoding.err = err
+			return
+		}
+		encoding.bpe, encoding.err = NewBPE(name, ranks)
+	})
+	return encoding.bpe, encoding.err
+}
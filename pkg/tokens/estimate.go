@@ -0,0 +1,114 @@
+package tokens
+
+import (
+	"math"
+	"unicode"
+)
+
+// Tokenizer families of the models in the registry
+const (
+	FamilyOpenAI    = "openai"
+	FamilyAnthropic = "anthropic"
+	FamilyGemini    = "gemini"
+	FamilyLlama     = "llama"
+	FamilyMistral   = "mistral"
+	FamilyDeepSeek  = "deepseek"
+	FamilyQwen      = "qwen"
+)
+
+// Estimator approximates the token count of a text for models whose
+// tokenizer is not available. Words are counted by their length, numbers in
+// groups of three digits, and symbols and ideographs one token each, which
+// is then scaled to the density of the tokenizer family.
+type Estimator struct {
+	// CharsPerToken is the number of letters of a word that fit in one token
+	CharsPerToken float64
+
+	// Scale multiplies the estimate, for tokenizers that need more tokens
+	// than the OpenAI encodings for the same text
+	Scale float64
+}
+
+// estimators reflect how many more tokens each family needs than the OpenAI
+// encodings for English prose and source code
+var estimators = map[string]*Estimator{
+	FamilyOpenAI:    {CharsPerToken: 6, Scale: 1},
+	FamilyAnthropic: {CharsPerToken: 6, Scale: 1.15},
+	FamilyGemini:    {CharsPerToken: 6, Scale: 1},
+	FamilyLlama:     {CharsPerToken: 6, Scale: 1.05},
+	FamilyMistral:   {CharsPerToken: 5, Scale: 1.1},
+	FamilyDeepSeek:  {CharsPerToken: 6, Scale: 1.05},
+	FamilyQwen:      {CharsPerToken: 6, Scale: 1.05},
+}
+
+// defaultEstimator is used for models of unknown families
+var defaultEstimator = &Estimator{CharsPerToken: 5, Scale: 1.1}
+
+// EstimatorFor returns the estimator of a tokenizer family, or a
+// conservative default for unknown families
+func EstimatorFor(family string) *Estimator {
+	if estimator, ok := estimators[family]; ok {
+		return estimator
+	}
+	return defaultEstimator
+}
+
+// CountTokens returns the estimated number of tokens of a text
+func (e *Estimator) CountTokens(text string) (int, error) {
+	charsPerToken := e.CharsPerToken
+	if charsPerToken <= 0 {
+		charsPerToken = 4
+	}
+
+	var count float64
+	letters, digits, spaces := 0, 0, 0
+	newline := false
+	flush := func() {
+		if letters > 0 {
+			count += math.Ceil(float64(letters) / charsPerToken)
+		}
+		if digits > 0 {
+			count += math.Ceil(float64(digits) / 3)
+		}
+		if newline || spaces > 1 {
+			// Line breaks and indentation are separate tokens, single
+			// spaces are part of the next word
+			count++
+		}
+		letters, digits, spaces, newline = 0, 0, 0, false
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			flush()
+			count++
+		case unicode.IsLetter(r) || unicode.IsMark(r):
+			if digits > 0 || spaces > 0 {
+				flush()
+			}
+			letters++
+		case unicode.IsNumber(r):
+			if letters > 0 || spaces > 0 {
+				flush()
+			}
+			digits++
+		case unicode.IsSpace(r):
+			if letters > 0 || digits > 0 {
+				flush()
+			}
+			spaces++
+			newline = newline || r == '\n' || r == '\r'
+		default:
+			flush()
+			count++
+		}
+	}
+	flush()
+
+	scale := e.Scale
+	if scale <= 0 {
+		scale = 1
+	}
+	return int(math.Ceil(count * scale)), nil
+}
@@ -0,0 +1,106 @@
+package tokens
+
+import (
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// MessageOverhead is the number of tokens the chat format adds to each
+// message for its role and delimiters
+const MessageOverhead = 4
+
+// CountMessage returns the tokens of a message, including its tool calls and
+// the chat format overhead. Content parts such as images are not counted.
+func CountMessage(counter Counter, message interfaces.Message) (int, error) {
+	count, err := counter.CountTokens(message.Content)
+	if err != nil {
+		return 0, err
+	}
+	for _, call := range message.ToolCalls {
+		arguments, err := counter.CountTokens(call.Name + " " + call.Arguments)
+		if err != nil {
+			return 0, err
+		}
+		count += arguments + MessageOverhead
+	}
+	return count + MessageOverhead, nil
+}
+
+// CountMessages returns the tokens of messages
+func CountMessages(counter Counter, messages []interfaces.Message) (int, error) {
+	total := 0
+	for _, message := range messages {
+		count, err := CountMessage(counter, message)
+		if err != nil {
+			return 0, err
+		}
+		total += count
+	}
+	return total, nil
+}
+
+// TrimMessages drops the oldest messages until the rest fit in the token
+// budget. System messages and the last user message with the messages after
+// it are always kept, even if they exceed the budget. Tool results whose
+// tool call was dropped are dropped as well.
+func TrimMessages(counter Counter, messages []interfaces.Message, budget int) ([]interfaces.Message, error) {
+	counts := make([]int, len(messages))
+	total := 0
+	for i, message := range messages {
+		count, err := CountMessage(counter, message)
+		if err != nil {
+			return nil, err
+		}
+		counts[i] = count
+		total += count
+	}
+	if total <= budget {
+		return messages, nil
+	}
+
+	// The current turn starts at the last user message
+	current := len(messages)
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == interfaces.MessageRoleUser {
+			current = i
+			break
+		}
+	}
+
+	keep := make([]bool, len(messages))
+	used := 0
+	for i, message := range messages {
+		if message.Role == interfaces.MessageRoleSystem || i >= current {
+			keep[i] = true
+			used += counts[i]
+		}
+	}
+
+	// Keep the newest history that fits, stopping at the first message that
+	// does not so that the kept history has no gaps
+	for i := current - 1; i >= 0; i-- {
+		if keep[i] {
+			continue
+		}
+		if used+counts[i] > budget {
+			break
+		}
+		keep[i] = true
+		used += counts[i]
+	}
+
+	trimmed := make([]interfaces.Message, 0, len(messages))
+	calls := make(map[string]bool)
+	for i, message := range messages {
+		if !keep[i] {
+			continue
+		}
+		if message.Role == interfaces.MessageRoleTool && message.ToolCallID != "" && !calls[message.ToolCallID] {
+			continue
+		}
+		for _, call := range message.ToolCalls {
+			calls[call.ID] = true
+		}
+		trimmed = append(trimmed, message)
+	}
+	return trimmed, nil
+}
@@ -0,0 +1,128 @@
+package tokens
+
+import (
+	"strings"
+	"sync"
+)
+
+// ModelInfo describes the token limits and tokenizer of a model
+type ModelInfo struct {
+	// Name is the model name, or a prefix of the names of a model series
+	Name string
+
+	// Family is the tokenizer family, such as FamilyOpenAI
+	Family string
+
+	// Encoding is the BPE encoding of the model, if it uses one of the
+	// supported encodings
+	Encoding string
+
+	// ContextWindow is the maximum number of input and output tokens
+	ContextWindow int
+
+	// MaxOutputTokens is the maximum number of tokens the model generates
+	MaxOutputTokens int
+}
+
+var (
+	modelsMu sync.RWMutex
+	models   = make(map[string]ModelInfo)
+)
+
+func init() {
+	for _, info := range []ModelInfo{
+		// OpenAI
+		{Name: "gpt-5", Family: FamilyOpenAI, Encoding: O200KBase, ContextWindow: 400000, MaxOutputTokens: 128000},
+		{Name: "gpt-4.1", Family: FamilyOpenAI, Encoding: O200KBase, ContextWindow: 1047576, MaxOutputTokens: 32768},
+		{Name: "gpt-4o", Family: FamilyOpenAI, Encoding: O200KBase, ContextWindow: 128000, MaxOutputTokens: 16384},
+		{Name: "gpt-4-turbo", Family: FamilyOpenAI, Encoding: CL100KBase, ContextWindow: 128000, MaxOutputTokens: 4096},
+		{Name: "gpt-4-32k", Family: FamilyOpenAI, Encoding: CL100KBase, ContextWindow: 32768, MaxOutputTokens: 4096},
+		{Name: "gpt-4", Family: FamilyOpenAI, Encoding: CL100KBase, ContextWindow: 8192, MaxOutputTokens: 4096},
+		{Name: "gpt-3.5-turbo", Family: FamilyOpenAI, Encoding: CL100KBase, ContextWindow: 16385, MaxOutputTokens: 4096},
+		{Name: "o1", Family: FamilyOpenAI, Encoding: O200KBase, ContextWindow: 200000, MaxOutputTokens: 100000},
+		{Name: "o1-mini", Family: FamilyOpenAI, Encoding: O200KBase, ContextWindow: 128000, MaxOutputTokens: 65536},
+		{Name: "o3", Family: FamilyOpenAI, Encoding: O200KBase, ContextWindow: 200000, MaxOutputTokens: 100000},
+		{Name: "o4-mini", Family: FamilyOpenAI, Encoding: O200KBase, ContextWindow: 200000, MaxOutputTokens: 100000},
+		{Name: "text-embedding-3", Family: FamilyOpenAI, Encoding: CL100KBase, ContextWindow: 8191},
+		{Name: "text-embedding-ada-002", Family: FamilyOpenAI, Encoding: CL100KBase, ContextWindow: 8191},
+
+		// Anthropic
+		{Name: "claude-opus-4", Family: FamilyAnthropic, ContextWindow: 200000, MaxOutputTokens: 32000},
+		{Name: "claude-sonnet-4", Family: FamilyAnthropic, ContextWindow: 200000, MaxOutputTokens: 64000},
+		{Name: "claude-3-7-sonnet", Family: FamilyAnthropic, ContextWindow: 200000, MaxOutputTokens: 64000},
+		{Name: "claude-3-5-sonnet", Family: FamilyAnthropic, ContextWindow: 200000, MaxOutputTokens: 8192},
+		{Name: "claude-3-5-haiku", Family: FamilyAnthropic, ContextWindow: 200000, MaxOutputTokens: 8192},
+		{Name: "claude-3", Family: FamilyAnthropic, ContextWindow: 200000, MaxOutputTokens: 4096},
+		{Name: "claude", Family: FamilyAnthropic, ContextWindow: 200000, MaxOutputTokens: 4096},
+
+		// Google
+		{Name: "gemini-2.5", Family: FamilyGemini, ContextWindow: 1048576, MaxOutputTokens: 65536},
+		{Name: "gemini-2.0", Family: FamilyGemini, ContextWindow: 1048576, MaxOutputTokens: 8192},
+		{Name: "gemini-1.5-pro", Family: FamilyGemini, ContextWindow: 2097152, MaxOutputTokens: 8192},
+		{Name: "gemini-1.5-flash", Family: FamilyGemini, ContextWindow: 1048576, MaxOutputTokens: 8192},
+		{Name: "gemini", Family: FamilyGemini, ContextWindow: 32768, MaxOutputTokens: 8192},
+
+		// Open models, as named by Ollama, vLLM and Bedrock
+		{Name: "llama3.1", Family: FamilyLlama, ContextWindow: 131072, MaxOutputTokens: 4096},
+		{Name: "llama3.2", Family: FamilyLlama, ContextWindow: 131072, MaxOutputTokens: 4096},
+		{Name: "llama3.3", Family: FamilyLlama, ContextWindow: 131072, MaxOutputTokens: 4096},
+		{Name: "llama3-1", Family: FamilyLlama, ContextWindow: 131072, MaxOutputTokens: 4096},
+		{Name: "llama3-2", Family: FamilyLlama, ContextWindow: 131072, MaxOutputTokens: 4096},
+		{Name: "llama3-3", Family: FamilyLlama, ContextWindow: 131072, MaxOutputTokens: 4096},
+		{Name: "llama3", Family: FamilyLlama, ContextWindow: 8192, MaxOutputTokens: 2048},
+		{Name: "meta-llama/llama-3", Family: FamilyLlama, ContextWindow: 131072, MaxOutputTokens: 4096},
+		{Name: "mistral", Family: FamilyMistral, ContextWindow: 32768, MaxOutputTokens: 4096},
+		{Name: "mixtral", Family: FamilyMistral, ContextWindow: 32768, MaxOutputTokens: 4096},
+		{Name: "mistralai/", Family: FamilyMistral, ContextWindow: 32768, MaxOutputTokens: 4096},
+		{Name: "deepseek-chat", Family: FamilyDeepSeek, ContextWindow: 65536, MaxOutputTokens: 8192},
+		{Name: "deepseek-reasoner", Family: FamilyDeepSeek, ContextWindow: 65536, MaxOutputTokens: 32768},
+		{Name: "deepseek", Family: FamilyDeepSeek, ContextWindow: 65536, MaxOutputTokens: 8192},
+		{Name: "qwen", Family: FamilyQwen, ContextWindow: 32768, MaxOutputTokens: 8192},
+	} {
+		models[info.Name] = info
+	}
+}
+
+// RegisterModel adds a model to the registry or replaces its entry. The name
+// is matched as a prefix, so "my-model" also applies to "my-model-v2".
+func RegisterModel(info ModelInfo) {
+	modelsMu.Lock()
+	defer modelsMu.Unlock()
+	info.Name = strings.ToLower(info.Name)
+	models[info.Name] = info
+}
+
+// LookupModel returns the registry entry with the longest name that is a
+// prefix of the model. Provider prefixes of Bedrock and Gemini model IDs,
+// such as "us.anthropic." and "models/", are ignored.
+func LookupModel(model string) (ModelInfo, bool) {
+	name := NormalizeModel(model)
+
+	modelsMu.RLock()
+	defer modelsMu.RUnlock()
+
+	var best ModelInfo
+	found := false
+	for prefix, info := range models {
+		if strings.HasPrefix(name, prefix) && (!found || len(prefix) > len(best.Name)) {
+			best, found = info, true
+		}
+	}
+	return best, found
+}
+
+// bedrockProviders are the provider prefixes of Bedrock model IDs
+var bedrockProviders = []string{"anthropic.", "meta.", "mistral.", "deepseek.", "qwen."}
+
+// NormalizeModel lowercases a model name and strips the "models/" prefix of
+// Gemini and the region and provider prefixes of Bedrock model IDs
+func NormalizeModel(model string) string {
+	name := strings.ToLower(strings.TrimSpace(model))
+	name = strings.TrimPrefix(name, "models/")
+	for _, provider := range bedrockProviders {
+		if i := strings.Index(name, provider); i >= 0 && !strings.Contains(name[:i], "/") {
+			return name[i+len(provider):]
+		}
+	}
+	return name
+}
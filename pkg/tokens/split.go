@@ -0,0 +1,225 @@
+package tokens
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The pre-tokenizers split text into the pieces that are encoded separately.
+// They follow the regular expressions of tiktoken, which use lookahead and
+// therefore cannot be expressed with the regexp package:
+//
+//	cl100k_base: (?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}|
+//	             ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+(?!\S)|\s+
+//
+//	o200k_base:  [^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?|
+//	             [^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?|
+//	             \p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n/]*|\s*[\r\n]+|\s+(?!\S)|\s+
+
+var contractions = []string{"s", "t", "re", "ve", "m", "ll", "d"}
+
+func splitCL100K(text string) []string {
+	return splitWith(text, matchCL100K)
+}
+
+func splitO200K(text string) []string {
+	return splitWith(text, matchO200K)
+}
+
+func splitWith(text string, match func(s string, i int) int) []string {
+	var pieces []string
+	for i := 0; i < len(text); {
+		n := match(text, i)
+		if n <= 0 {
+			_, n = utf8.DecodeRuneInString(text[i:])
+		}
+		pieces = append(pieces, text[i:i+n])
+		i += n
+	}
+	return pieces
+}
+
+// matchCL100K returns the length of the cl100k_base piece starting at i
+func matchCL100K(s string, i int) int {
+	if n := contraction(s, i); n > 0 {
+		return n
+	}
+
+	r, size := runeAt(s, i)
+	switch {
+	case unicode.IsLetter(r):
+		return size + run(s, i+size, unicode.IsLetter)
+	case r != '\r' && r != '\n' && !unicode.IsNumber(r):
+		if n := run(s, i+size, unicode.IsLetter); n > 0 {
+			return size + n
+		}
+	}
+	if unicode.IsNumber(r) {
+		return numbers(s, i)
+	}
+	if n := punctuation(s, i, "\r\n"); n > 0 {
+		return n
+	}
+	return whitespace(s, i)
+}
+
+// matchO200K returns the length of the o200k_base piece starting at i
+func matchO200K(s string, i int) int {
+	r, size := runeAt(s, i)
+	prefixed := r != '\r' && r != '\n' && !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	for _, word := range []func(s string, i int) int{casedWord, upperWord} {
+		if prefixed {
+			if n := word(s, i+size); n > 0 {
+				return size + n
+			}
+		}
+		if n := word(s, i); n > 0 {
+			return n
+		}
+	}
+
+	if unicode.IsNumber(r) {
+		return numbers(s, i)
+	}
+	if n := punctuation(s, i, "\r\n/"); n > 0 {
+		return n
+	}
+	return whitespace(s, i)
+}
+
+// casedWord matches [upper]*[lower]+ followed by an optional contraction
+func casedWord(s string, i int) int {
+	ends := []int{i}
+	for j := i; ; {
+		r, size := runeAt(s, j)
+		if size == 0 || !isUpperClass(r) {
+			break
+		}
+		j += size
+		ends = append(ends, j)
+	}
+
+	// Give back upper case letters until the lower case part matches, as the
+	// classes overlap
+	for k := len(ends) - 1; k >= 0; k-- {
+		if n := run(s, ends[k], isLowerClass); n > 0 {
+			end := ends[k] + n
+			return end - i + contraction(s, end)
+		}
+	}
+	return 0
+}
+
+// upperWord matches [upper]+[lower]* followed by an optional contraction
+func upperWord(s string, i int) int {
+	n := run(s, i, isUpperClass)
+	if n == 0 {
+		return 0
+	}
+	end := i + n
+	end += run(s, end, isLowerClass)
+	return end - i + contraction(s, end)
+}
+
+func isUpperClass(r rune) bool {
+	return unicode.In(r, unicode.Lu, unicode.Lt, unicode.Lm, unicode.Lo, unicode.M)
+}
+
+func isLowerClass(r rune) bool {
+	return unicode.In(r, unicode.Ll, unicode.Lm, unicode.Lo, unicode.M)
+}
+
+// contraction matches (?i:'s|'t|'re|'ve|'m|'ll|'d)
+func contraction(s string, i int) int {
+	if i >= len(s) || s[i] != '\'' {
+		return 0
+	}
+	for _, c := range contractions {
+		end := i + 1 + len(c)
+		if end <= len(s) && strings.EqualFold(s[i+1:end], c) {
+			return 1 + len(c)
+		}
+	}
+	return 0
+}
+
+// numbers matches \p{N}{1,3}
+func numbers(s string, i int) int {
+	n := 0
+	for k := 0; k < 3; k++ {
+		r, size := runeAt(s, i+n)
+		if size == 0 || !unicode.IsNumber(r) {
+			break
+		}
+		n += size
+	}
+	return n
+}
+
+// punctuation matches ` ?[^\s\p{L}\p{N}]+` followed by any of the trailing characters
+func punctuation(s string, i int, trailing string) int {
+	n := 0
+	if i < len(s) && s[i] == ' ' {
+		n = 1
+	}
+	start := n
+	n += run(s, i+n, func(r rune) bool {
+		return !unicode.IsSpace(r) && !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if n == start {
+		return 0
+	}
+	return n + run(s, i+n, func(r rune) bool {
+		return strings.ContainsRune(trailing, r)
+	})
+}
+
+// whitespace matches `\s*[\r\n]+|\s+(?!\S)|\s+`
+func whitespace(s string, i int) int {
+	end, newline := i, -1
+	for {
+		r, size := runeAt(s, end)
+		if size == 0 || !unicode.IsSpace(r) {
+			break
+		}
+		end += size
+		if r == '\r' || r == '\n' {
+			newline = end
+		}
+	}
+
+	switch {
+	case newline > i:
+		// Up to and including the last line break
+		return newline - i
+	case end == len(s):
+		return end - i
+	}
+	// Leave the last space for the next word
+	_, last := utf8.DecodeLastRuneInString(s[i:end])
+	if end-last > i {
+		return end - last - i
+	}
+	return end - i
+}
+
+// run returns the length of the characters starting at i that match the predicate
+func run(s string, i int, match func(r rune) bool) int {
+	n := 0
+	for {
+		r, size := runeAt(s, i+n)
+		if size == 0 || !match(r) {
+			return n
+		}
+		n += size
+	}
+}
+
+// runeAt returns the character at i and its size, or a size of 0 at the end
+func runeAt(s string, i int) (rune, int) {
+	if i >= len(s) {
+		return 0, 0
+	}
+	return utf8.DecodeRuneInString(s[i:])
+}
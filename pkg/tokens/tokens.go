@@ -0,0 +1,32 @@
+// Package tokens counts tokens and fits conversations into the context
+// window of a model.
+//
+// OpenAI models are counted exactly with the BPE tokenizer of their encoding,
+// built from the cl100k_base and o200k_base rank files embedded in the
+// package. Other models are counted with an estimator for their tokenizer
+// family.
+package tokens
+
+// Counter counts the tokens of a text. Every Counter is also a
+// guardrails.TokenCounter.
+type Counter interface {
+	CountTokens(text string) (int, error)
+}
+
+// ForModel returns the counter for a model: the BPE tokenizer of its
+// encoding, or the estimator of its tokenizer family for models without a
+// supported encoding
+func ForModel(model string) Counter {
+	info, _ := LookupModel(model)
+	if info.Encoding != "" {
+		if bpe, ok := GetEncoding(info.Encoding); ok {
+			return bpe
+		}
+	}
+	return EstimatorFor(info.Family)
+}
+
+// Default returns the counter used when the model is not known
+func Default() Counter {
+	return defaultEstimator
+}
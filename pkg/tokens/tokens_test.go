@@ -0,0 +1,107 @@
+package tokens
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// wordCounter counts words, which keeps the expected counts readable
+type wordCounter struct{}
+
+func (wordCounter) CountTokens(text string) (int, error) {
+	return len(strings.Fields(text)), nil
+}
+
+func TestEstimator(t *testing.T) {
+	count := func(e *Estimator, text string) int {
+		n, err := e.CountTokens(text)
+		require.NoError(t, err)
+		return n
+	}
+	openai := EstimatorFor(FamilyOpenAI)
+
+	assert.Equal(t, 0, count(openai, ""))
+	assert.Equal(t, 2, count(openai, "Hello world"))
+	assert.Equal(t, 4, count(openai, "Hello, world!"))
+	assert.Equal(t, 3, count(openai, "1234567"))
+	assert.Equal(t, 3, count(openai, "日本語"))
+	assert.Equal(t, 3, count(openai, "line\n\nbreak"))
+
+	text := "The quick brown fox jumps over the lazy dog. Tokenization estimates are approximate."
+	assert.Greater(t, count(EstimatorFor(FamilyAnthropic), text), count(openai, text))
+	assert.Same(t, defaultEstimator, EstimatorFor("unknown"))
+}
+
+func TestLookupModel(t *testing.T) {
+	tests := map[string]string{
+		"gpt-4o-mini-2024-07-18":                       "gpt-4o",
+		"gpt-4-0613":                                   "gpt-4",
+		"gpt-4-turbo-preview":                          "gpt-4-turbo",
+		"claude-3-5-sonnet-20241022":                   "claude-3-5-sonnet",
+		"us.anthropic.claude-3-7-sonnet-20250219-v1:0": "claude-3-7-sonnet",
+		"claude-3-5-sonnet@20240620":                   "claude-3-5-sonnet",
+		"models/gemini-1.5-pro-latest":                 "gemini-1.5-pro",
+		"meta.llama3-1-70b-instruct-v1:0":              "llama3-1",
+		"llama3.1:8b":                                  "llama3.1",
+		"deepseek-chat":                                "deepseek-chat",
+	}
+	for model, want := range tests {
+		info, ok := LookupModel(model)
+		if assert.True(t, ok, model) {
+			assert.Equal(t, want, info.Name, model)
+		}
+	}
+
+	_, ok := LookupModel("custom-model")
+	assert.False(t, ok)
+
+	RegisterModel(ModelInfo{Name: "Custom-Model", ContextWindow: 1000})
+	info, ok := LookupModel("custom-model-v2")
+	require.True(t, ok)
+	assert.Equal(t, 1000, info.ContextWindow)
+}
+
+func TestCountMessages(t *testing.T) {
+	messages := []interfaces.Message{
+		{Role: interfaces.MessageRoleUser, Content: "what is the weather"},
+		{Role: interfaces.MessageRoleAssistant, ToolCalls: []interfaces.ToolCall{{ID: "1", Name: "weather", Arguments: `{"city": "Paris"}`}}},
+	}
+	count, err := CountMessages(wordCounter{}, messages)
+	require.NoError(t, err)
+	assert.Equal(t, 4+MessageOverhead+3+2*MessageOverhead, count)
+}
+
+func TestTrimMessages(t *testing.T) {
+	messages := []interfaces.Message{
+		{Role: interfaces.MessageRoleSystem, Content: "be brief"},
+		{Role: interfaces.MessageRoleUser, Content: "first question here"},
+		{Role: interfaces.MessageRoleAssistant, ToolCalls: []interfaces.ToolCall{{ID: "c1", Name: "lookup", Arguments: "{}"}}},
+		{Role: interfaces.MessageRoleTool, ToolCallID: "c1", Content: "result with many words in it"},
+		{Role: interfaces.MessageRoleAssistant, Content: "the answer"},
+		{Role: interfaces.MessageRoleUser, Content: "second question"},
+	}
+
+	t.Run("Fits", func(t *testing.T) {
+		trimmed, err := TrimMessages(wordCounter{}, messages, 100)
+		require.NoError(t, err)
+		assert.Equal(t, messages, trimmed)
+	})
+
+	t.Run("DropsOrphanedToolResults", func(t *testing.T) {
+		// The tool result fits but its call does not, so both are dropped
+		trimmed, err := TrimMessages(wordCounter{}, messages, 30)
+		require.NoError(t, err)
+		assert.Equal(t, []interfaces.Message{messages[0], messages[4], messages[5]}, trimmed)
+	})
+
+	t.Run("KeepsCurrentTurn", func(t *testing.T) {
+		trimmed, err := TrimMessages(wordCounter{}, messages, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []interfaces.Message{messages[0], messages[5]}, trimmed)
+	})
+}
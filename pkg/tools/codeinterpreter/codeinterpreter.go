@@ -0,0 +1,685 @@
+// Package codeinterpreter provides a tool that runs Python and shell snippets
+// in a sandboxed subprocess. On Linux, snippets run in new user, PID, mount,
+// IPC, UTS and network namespaces when the kernel allows it, confined to the
+// working directory of their conversation and a read-only runtime, without
+// capabilities and with a seccomp filter. Elsewhere they run with resource
+// limits only. In both cases CPU time, memory, file size and wall time are
+// limited, and each conversation gets its own working directory.
+package codeinterpreter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/memory"
+	"github.com/tagus/agent-sdk-go/pkg/multitenancy"
+)
+
+// Language is a language the tool runs
+type Language string
+
+const (
+	// Python runs snippets with python3
+	Python Language = "python"
+
+	// Shell runs snippets with /bin/sh
+	Shell Language = "shell"
+)
+
+// Isolation selects how snippets are isolated from the host
+type Isolation string
+
+const (
+	// IsolationAuto runs snippets in the sandbox where available. Otherwise,
+	// snippets run with resource limits only when network access is
+	// allowed, and are refused when it is disabled, since it could not be
+	// enforced.
+	IsolationAuto Isolation = "auto"
+
+	// IsolationRequired refuses to run snippets when namespaces are not available
+	IsolationRequired Isolation = "required"
+
+	// IsolationNone runs snippets with resource limits only. Snippets can
+	// read the files of the host, and disabled network access is only
+	// enforced on a best-effort basis.
+	IsolationNone Isolation = "none"
+)
+
+// maxArtifacts is the maximum number of artifacts listed in a result
+const maxArtifacts = 50
+
+// sandboxSetupFailed is the exit code of a sandbox that could not be set up
+const sandboxSetupFailed = 125
+
+// sandboxErrorPrefix prefixes the setup errors of a sandbox written to stderr
+const sandboxErrorPrefix = "code interpreter sandbox: "
+
+// Tool runs code snippets in a sandboxed subprocess
+type Tool struct {
+	workDir       string
+	timeout       time.Duration
+	cpuTime       time.Duration
+	memoryLimit   int64
+	fileSizeLimit int64
+	maxOutput     int
+	languages     []Language
+	allowNetwork  bool
+	isolation     Isolation
+	python        string
+	env           map[string]string
+
+	pythonOnce sync.Once
+	pythonPath string
+	pythonDirs []string
+	pythonErr  error
+}
+
+// sandbox describes the filesystem of an isolated snippet
+type sandbox struct {
+	// Root is the directory on which the new root is mounted
+	Root string `json:"root"`
+
+	// WorkDir is the working directory, the only writable directory of the host
+	WorkDir string `json:"work_dir"`
+
+	// ReadOnly are the paths of the host available read-only in addition
+	// to the runtime of the system
+	ReadOnly []string `json:"read_only,omitempty"`
+
+	// Network reports whether the snippet shares the network of the host
+	Network bool `json:"network,omitempty"`
+}
+
+// Input represents the input for the code interpreter tool
+type Input struct {
+	Language Language `json:"language"`
+	Code     string   `json:"code"`
+}
+
+// Result is the result of a snippet, returned to the LLM as JSON
+type Result struct {
+	// ExitCode is the exit code of the snippet, -1 if it was killed
+	ExitCode int `json:"exit_code"`
+
+	// Stdout and Stderr are the output of the snippet, truncated to the output limit
+	Stdout string `json:"stdout"`
+	Stderr string `json:"stderr"`
+
+	// Truncated reports whether the output was truncated
+	Truncated bool `json:"truncated,omitempty"`
+
+	// TimedOut reports whether the snippet was killed at the timeout
+	TimedOut bool `json:"timed_out,omitempty"`
+
+	// Artifacts are the files created or modified by the snippet
+	Artifacts []Artifact `json:"artifacts,omitempty"`
+
+	// Sandbox is "namespaces" or "rlimits"
+	Sandbox string `json:"sandbox"`
+}
+
+// Artifact is a reference to a file created or modified by a snippet
+type Artifact struct {
+	// Name is the path of the file relative to the working directory
+	Name string `json:"name"`
+
+	// URI is the file URI of the file
+	URI string `json:"uri"`
+
+	// Size is the size of the file in bytes
+	Size int64 `json:"size"`
+}
+
+// Option represents an option for configuring the tool
+type Option func(*Tool)
+
+// WithWorkDir sets the directory under which the working directories of the
+// conversations are created
+func WithWorkDir(dir string) Option {
+	return func(t *Tool) {
+		t.workDir = dir
+	}
+}
+
+// WithTimeout sets the wall time limit of a snippet
+func WithTimeout(timeout time.Duration) Option {
+	return func(t *Tool) {
+		t.timeout = timeout
+	}
+}
+
+// WithCPUTime sets the CPU time limit of a snippet, rounded up to seconds
+func WithCPUTime(cpuTime time.Duration) Option {
+	return func(t *Tool) {
+		t.cpuTime = cpuTime
+	}
+}
+
+// WithMemoryLimit sets the address space limit of a snippet in bytes
+func WithMemoryLimit(bytes int64) Option {
+	return func(t *Tool) {
+		t.memoryLimit = bytes
+	}
+}
+
+// WithFileSizeLimit sets the size limit of the files written by a snippet in bytes
+func WithFileSizeLimit(bytes int64) Option {
+	return func(t *Tool) {
+		t.fileSizeLimit = bytes
+	}
+}
+
+// WithMaxOutput sets the number of bytes of stdout and stderr returned
+func WithMaxOutput(bytes int) Option {
+	return func(t *Tool) {
+		t.maxOutput = bytes
+	}
+}
+
+// WithLanguages sets the languages the tool runs
+func WithLanguages(languages ...Language) Option {
+	return func(t *Tool) {
+		t.languages = languages
+	}
+}
+
+// WithNetwork sets whether snippets may access the network. Network access
+// is disabled by default.
+func WithNetwork(allowed bool) Option {
+	return func(t *Tool) {
+		t.allowNetwork = allowed
+	}
+}
+
+// WithIsolation sets how snippets are isolated from the host
+func WithIsolation(isolation Isolation) Option {
+	return func(t *Tool) {
+		t.isolation = isolation
+	}
+}
+
+// WithPython sets the Python interpreter. By default, the interpreter of
+// python3 on the PATH is used.
+func WithPython(path string) Option {
+	return func(t *Tool) {
+		t.python = path
+	}
+}
+
+// WithEnv sets environment variables of the snippets. Snippets do not
+// inherit the environment of the process, which may hold credentials.
+func WithEnv(env map[string]string) Option {
+	return func(t *Tool) {
+		t.env = env
+	}
+}
+
+// New creates a new code interpreter tool
+func New(options ...Option) *Tool {
+	tool := &Tool{
+		workDir:       filepath.Join(os.TempDir(), "agent-code-interpreter"),
+		timeout:       30 * time.Second,
+		cpuTime:       10 * time.Second,
+		memoryLimit:   512 << 20,
+		fileSizeLimit: 64 << 20,
+		maxOutput:     16 << 10,
+		languages:     []Language{Python, Shell},
+		isolation:     IsolationAuto,
+	}
+
+	for _, option := range options {
+		option(tool)
+	}
+
+	return tool
+}
+
+// Name implements interfaces.Tool.Name
+func (t *Tool) Name() string {
+	return "code_interpreter"
+}
+
+// DisplayName implements interfaces.ToolWithDisplayName.DisplayName
+func (t *Tool) DisplayName() string {
+	return "Code Interpreter"
+}
+
+// Description implements interfaces.Tool.Description. It describes the
+// isolation snippets actually get on this system.
+func (t *Tool) Description() string {
+	isolated, err := t.isolated()
+	if err != nil {
+		return "Run a short " + t.languageList() + " snippet. Not available on this system: " + err.Error() + "."
+	}
+	description := "Run a short " + t.languageList() + " snippet and return its stdout, stderr, exit code and the files it wrote. " +
+		"Files persist in the working directory of the conversation between calls. "
+	switch {
+	case isolated && t.allowNetwork:
+		return description + "The snippet runs in a sandbox that only sees this directory and a read-only runtime. Network access is allowed."
+	case isolated:
+		return description + "The snippet runs in a sandbox that only sees this directory and a read-only runtime, without network access."
+	case t.allowNetwork:
+		return description + "The snippet runs with resource limits only and can access the files of the host. Network access is allowed."
+	default:
+		return description + "The snippet runs with resource limits only and can access the files of the host. Network access is disabled but not reliably blocked."
+	}
+}
+
+// Internal implements interfaces.InternalTool.Internal
+func (t *Tool) Internal() bool {
+	return false
+}
+
+// Parameters implements interfaces.Tool.Parameters
+func (t *Tool) Parameters() map[string]interfaces.ParameterSpec {
+	languages := make([]interface{}, len(t.languages))
+	for i, language := range t.languages {
+		languages[i] = string(language)
+	}
+	return map[string]interfaces.ParameterSpec{
+		"language": {
+			Type:        "string",
+			Description: "The language of the code",
+			Required:    true,
+			Enum:        languages,
+		},
+		"code": {
+			Type:        "string",
+			Description: "The code to run. Print the results to stdout.",
+			Required:    true,
+		},
+	}
+}
+
+func (t *Tool) languageList() string {
+	names := make([]string, len(t.languages))
+	for i, language := range t.languages {
+		names[i] = string(language)
+	}
+	return strings.Join(names, " or ")
+}
+
+// Run implements interfaces.Tool.Run. Input that is not JSON is run as Python.
+func (t *Tool) Run(ctx context.Context, input string) (string, error) {
+	var in Input
+	if err := json.Unmarshal([]byte(input), &in); err != nil || in.Code == "" {
+		in = Input{Language: Python, Code: input}
+	}
+	return t.run(ctx, in)
+}
+
+// Execute implements interfaces.Tool.Execute
+func (t *Tool) Execute(ctx context.Context, args string) (string, error) {
+	var in Input
+	if err := json.Unmarshal([]byte(args), &in); err != nil {
+		return "", fmt.Errorf("failed to parse input: %w", err)
+	}
+	return t.run(ctx, in)
+}
+
+func (t *Tool) run(ctx context.Context, in Input) (string, error) {
+	if in.Language == "" {
+		in.Language = Python
+	}
+	result, err := t.Exec(ctx, in.Language, in.Code)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode result: %w", err)
+	}
+	return string(data), nil
+}
+
+// Exec runs a snippet in the working directory of the conversation of the
+// context. A snippet that fails or times out is not an error; its exit code
+// and output are in the result.
+func (t *Tool) Exec(ctx context.Context, language Language, code string) (*Result, error) {
+	if err := checkPlatform(); err != nil {
+		return nil, err
+	}
+	if !t.supports(language) {
+		return nil, fmt.Errorf("unsupported language %q, expected %s", language, t.languageList())
+	}
+	if strings.TrimSpace(code) == "" {
+		return nil, fmt.Errorf("code is required")
+	}
+	isolated, err := t.isolated()
+	if err != nil {
+		return nil, err
+	}
+
+	workDir, err := t.conversationDir(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The snippet is written outside the working directory, so that it is
+	// not reported as an artifact
+	scriptDir, err := os.MkdirTemp("", "code-interpreter-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create script directory: %w", err)
+	}
+	defer os.RemoveAll(scriptDir)
+
+	command, err := t.command(language, code, scriptDir, isolated)
+	if err != nil {
+		return nil, err
+	}
+
+	before := snapshot(workDir)
+
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	stdout := &limitedBuffer{limit: t.maxOutput}
+	stderr := &limitedBuffer{limit: t.maxOutput}
+
+	cmd := exec.CommandContext(ctx, "/bin/sh", append([]string{"-c", t.limitScript(), "sh"}, command...)...)
+	cmd.Dir = workDir
+	cmd.Env = t.environment(workDir, scriptDir, isolated)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	// Processes left behind by the snippet may keep its output open
+	cmd.WaitDelay = time.Second
+
+	var sb *sandbox
+	if isolated {
+		rootDir, err := os.MkdirTemp("", "code-interpreter-root-")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create sandbox root: %w", err)
+		}
+		defer os.RemoveAll(rootDir)
+		sb = &sandbox{Root: rootDir, WorkDir: workDir, ReadOnly: []string{scriptDir}, Network: t.allowNetwork}
+		if language == Python {
+			sb.ReadOnly = append(sb.ReadOnly, t.pythonDirs...)
+		}
+	}
+	if err := configureCommand(cmd, sb); err != nil {
+		return nil, err
+	}
+
+	result := &Result{Sandbox: "rlimits"}
+	if isolated {
+		result.Sandbox = "namespaces"
+	}
+
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		result.TimedOut = true
+		result.ExitCode = -1
+	case errors.As(err, &exitErr) && isolated && exitErr.ExitCode() == sandboxSetupFailed && strings.HasPrefix(stderr.String(), sandboxErrorPrefix):
+		return nil, fmt.Errorf("failed to set up sandbox: %s", strings.TrimSpace(strings.TrimPrefix(stderr.String(), sandboxErrorPrefix)))
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		return nil, fmt.Errorf("failed to run code: %w", err)
+	}
+
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+	result.Truncated = stdout.truncated || stderr.truncated
+	result.Artifacts = artifacts(workDir, before)
+	return result, nil
+}
+
+func (t *Tool) supports(language Language) bool {
+	for _, supported := range t.languages {
+		if supported == language {
+			return true
+		}
+	}
+	return false
+}
+
+// isolated reports whether snippets run in the sandbox
+func (t *Tool) isolated() (bool, error) {
+	switch t.isolation {
+	case IsolationNone:
+		return false, nil
+	case IsolationRequired:
+		if !namespacesAvailable() {
+			return false, fmt.Errorf("sandbox namespaces are not available on this system")
+		}
+		return true, nil
+	default:
+		if namespacesAvailable() {
+			return true, nil
+		}
+		if !t.allowNetwork {
+			return false, fmt.Errorf("sandbox namespaces are not available on this system, so network access cannot be disabled; " +
+				"allow it with WithNetwork(true) or run snippets without isolation with WithIsolation(IsolationNone)")
+		}
+		return false, nil
+	}
+}
+
+// command returns the command that runs a snippet
+func (t *Tool) command(language Language, code, scriptDir string, isolated bool) ([]string, error) {
+	switch language {
+	case Python:
+		python, err := t.pythonInterpreter()
+		if err != nil {
+			return nil, err
+		}
+		script := filepath.Join(scriptDir, "main.py")
+		if err := os.WriteFile(script, []byte(code), 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write code: %w", err)
+		}
+		if !isolated && !t.allowNetwork {
+			// Without a network namespace, sockets are disabled when Python starts
+			if err := os.WriteFile(filepath.Join(scriptDir, "sitecustomize.py"), []byte(pythonNetworkGuard), 0o644); err != nil {
+				return nil, fmt.Errorf("failed to write code: %w", err)
+			}
+		}
+		return []string{python, script}, nil
+	default:
+		script := filepath.Join(scriptDir, "main.sh")
+		if err := os.WriteFile(script, []byte(code), 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write code: %w", err)
+		}
+		return []string{"/bin/sh", script}, nil
+	}
+}
+
+// pythonNetworkGuard disables the socket functions that reach the network
+const pythonNetworkGuard = `import socket as _socket
+
+
+def _network_disabled(*args, **kwargs):
+    raise PermissionError("network access is disabled in the code interpreter")
+
+
+for _name in ("connect", "connect_ex", "sendto"):
+    setattr(_socket.socket, _name, _network_disabled)
+_socket.getaddrinfo = _network_disabled
+_socket.create_connection = _network_disabled
+del _socket, _name
+`
+
+// pythonInterpreter resolves the Python interpreter once, so that version
+// manager shims, which need the environment of the process, are not run
+// in the sandbox. The directories of its installation are made available
+// to isolated snippets.
+func (t *Tool) pythonInterpreter() (string, error) {
+	t.pythonOnce.Do(func() {
+		python := t.python
+		if python == "" {
+			python = "python3"
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		out, err := exec.CommandContext(ctx, python, "-c", "import sys; print(sys.executable, sys.prefix, sys.base_prefix, sep='\\n')").Output()
+		if err != nil {
+			t.pythonErr = fmt.Errorf("python interpreter %s not available: %w", python, err)
+			return
+		}
+		lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+		t.pythonPath = lines[0]
+		t.pythonDirs = append(lines[1:], filepath.Dir(t.pythonPath))
+		if resolved, err := filepath.EvalSymlinks(t.pythonPath); err == nil {
+			t.pythonDirs = append(t.pythonDirs, filepath.Dir(resolved))
+		}
+	})
+	return t.pythonPath, t.pythonErr
+}
+
+// limitScript applies the resource limits before running the snippet
+func (t *Tool) limitScript() string {
+	var limits []string
+	if t.cpuTime > 0 {
+		limits = append(limits, fmt.Sprintf("ulimit -t %d", int64((t.cpuTime+time.Second-1)/time.Second)))
+	}
+	if t.memoryLimit > 0 {
+		limits = append(limits, fmt.Sprintf("ulimit -v %d", t.memoryLimit>>10))
+	}
+	if t.fileSizeLimit > 0 {
+		// ulimit -f counts blocks of 512 bytes
+		limits = append(limits, fmt.Sprintf("ulimit -f %d", (t.fileSizeLimit+511)/512))
+	}
+	limits = append(limits, "ulimit -n 256", `exec "$@"`)
+	return strings.Join(limits, " && ")
+}
+
+// environment returns the environment of the snippets
+func (t *Tool) environment(workDir, scriptDir string, isolated bool) []string {
+	env := []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + workDir,
+		"TMPDIR=" + workDir,
+		"LANG=C.UTF-8",
+		"PYTHONDONTWRITEBYTECODE=1",
+		"PYTHONUNBUFFERED=1",
+	}
+	if !isolated && !t.allowNetwork {
+		env = append(env,
+			"PYTHONPATH="+scriptDir,
+			"http_proxy=http://127.0.0.1:9",
+			"https_proxy=http://127.0.0.1:9",
+			"HTTP_PROXY=http://127.0.0.1:9",
+			"HTTPS_PROXY=http://127.0.0.1:9",
+		)
+	}
+	keys := make([]string, 0, len(t.env))
+	for key := range t.env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		env = append(env, key+"="+t.env[key])
+	}
+	return env
+}
+
+// conversationDir returns the working directory of the conversation of the
+// context, creating it if needed. Calls without a conversation get a new
+// directory.
+func (t *Tool) conversationDir(ctx context.Context) (string, error) {
+	orgID := "default"
+	if id, err := multitenancy.GetOrgID(ctx); err == nil && id != "" {
+		orgID = id
+	}
+	conversationID, ok := memory.GetConversationID(ctx)
+	if !ok || conversationID == "" {
+		conversationID = "run-" + uuid.New().String()
+	}
+
+	dir := filepath.Join(t.workDir, safeName(orgID), safeName(conversationID))
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create working directory: %w", err)
+	}
+	return dir, nil
+}
+
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// safeName turns an ID into a single path element
+func safeName(id string) string {
+	name := unsafeNameChars.ReplaceAllString(id, "_")
+	if name == "" || name == "." || name == ".." {
+		return "_"
+	}
+	return name
+}
+
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// snapshot records the regular files of a directory
+func snapshot(dir string) map[string]fileState {
+	files := make(map[string]fileState)
+	_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+		files[path] = fileState{size: info.Size(), modTime: info.ModTime()}
+		return nil
+	})
+	return files
+}
+
+// artifacts returns the files of a directory created or modified since a snapshot
+func artifacts(dir string, before map[string]fileState) []Artifact {
+	var result []Artifact
+	for path, state := range snapshot(dir) {
+		if previous, ok := before[path]; ok && previous == state {
+			continue
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			continue
+		}
+		result = append(result, Artifact{
+			Name: filepath.ToSlash(name),
+			URI:  "file://" + filepath.ToSlash(path),
+			Size: state.size,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	if len(result) > maxArtifacts {
+		result = result[:maxArtifacts]
+	}
+	return result
+}
+
+// limitedBuffer keeps the first bytes written to it
+type limitedBuffer struct {
+	data      []byte
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	remaining := b.limit - len(b.data)
+	if remaining < len(p) {
+		b.truncated = true
+		if remaining > 0 {
+			b.data = append(b.data, p[:remaining]...)
+		}
+		return len(p), nil
+	}
+	b.data = append(b.data, p...)
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	return strings.ToValidUTF8(string(b.data), "")
+}
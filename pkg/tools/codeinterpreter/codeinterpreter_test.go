@@ -0,0 +1,291 @@
+package codeinterpreter_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tagus/agent-sdk-go/pkg/memory"
+	"github.com/tagus/agent-sdk-go/pkg/multitenancy"
+	"github.com/tagus/agent-sdk-go/pkg/tools/codeinterpreter"
+)
+
+func newTool(t *testing.T, options ...codeinterpreter.Option) *codeinterpreter.Tool {
+	if runtime.GOOS == "windows" {
+		t.Skip("the code interpreter is not supported on Windows")
+	}
+	defaults := []codeinterpreter.Option{codeinterpreter.WithWorkDir(t.TempDir())}
+	if !sandboxAvailable(t) {
+		// Without the sandbox, snippets without network access are refused
+		defaults = append(defaults, codeinterpreter.WithIsolation(codeinterpreter.IsolationNone))
+	}
+	return codeinterpreter.New(append(defaults, options...)...)
+}
+
+func sandboxAvailable(t *testing.T) bool {
+	tool := codeinterpreter.New(codeinterpreter.WithWorkDir(t.TempDir()), codeinterpreter.WithIsolation(codeinterpreter.IsolationRequired))
+	_, err := tool.Exec(context.Background(), codeinterpreter.Shell, "true")
+	return err == nil
+}
+
+func requirePython(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		if _, err := exec.LookPath("python"); err != nil {
+			t.Skip("python is not installed")
+		}
+	}
+}
+
+func TestExec(t *testing.T) {
+	tests := []struct {
+		name     string
+		language codeinterpreter.Language
+		code     string
+		exitCode int
+		stdout   string
+		stderr   string
+	}{
+		{"shell", codeinterpreter.Shell, "echo hello", 0, "hello\n", ""},
+		{"shell exit code", codeinterpreter.Shell, "echo oops >&2; exit 3", 3, "", "oops\n"},
+		{"python", codeinterpreter.Python, "print(6 * 7)", 0, "42\n", ""},
+		{"python exception", codeinterpreter.Python, "raise SystemExit('failed')", 1, "", "failed\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.language == codeinterpreter.Python {
+				requirePython(t)
+			}
+			result, err := newTool(t).Exec(context.Background(), tt.language, tt.code)
+			if err != nil {
+				t.Fatalf("Exec failed: %v", err)
+			}
+			if result.ExitCode != tt.exitCode {
+				t.Errorf("Expected exit code %d, got %d", tt.exitCode, result.ExitCode)
+			}
+			if result.Stdout != tt.stdout {
+				t.Errorf("Expected stdout %q, got %q", tt.stdout, result.Stdout)
+			}
+			if result.Stderr != tt.stderr {
+				t.Errorf("Expected stderr %q, got %q", tt.stderr, result.Stderr)
+			}
+		})
+	}
+}
+
+func TestExec_InvalidInput(t *testing.T) {
+	tool := newTool(t, codeinterpreter.WithLanguages(codeinterpreter.Shell))
+
+	if _, err := tool.Exec(context.Background(), codeinterpreter.Python, "print(1)"); err == nil {
+		t.Error("Expected an error for a disabled language")
+	}
+	if _, err := tool.Exec(context.Background(), codeinterpreter.Shell, "  "); err == nil {
+		t.Error("Expected an error for empty code")
+	}
+}
+
+func TestExec_Timeout(t *testing.T) {
+	tool := newTool(t, codeinterpreter.WithTimeout(200*time.Millisecond))
+
+	start := time.Now()
+	result, err := tool.Exec(context.Background(), codeinterpreter.Shell, "sleep 10")
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if !result.TimedOut || result.ExitCode != -1 {
+		t.Errorf("Expected a timed out result, got %+v", result)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the snippet to be killed at the timeout, took %v", elapsed)
+	}
+}
+
+func TestExec_TruncatesOutput(t *testing.T) {
+	tool := newTool(t, codeinterpreter.WithMaxOutput(10))
+
+	result, err := tool.Exec(context.Background(), codeinterpreter.Shell, "printf '0123456789abcdef'")
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if !result.Truncated {
+		t.Error("Expected the output to be truncated")
+	}
+	if !strings.HasPrefix(result.Stdout, "0123456789") || strings.Contains(result.Stdout, "abcdef") {
+		t.Errorf("Unexpected stdout %q", result.Stdout)
+	}
+}
+
+func TestExec_ConversationWorkDir(t *testing.T) {
+	workDir := t.TempDir()
+	tool := newTool(t, codeinterpreter.WithWorkDir(workDir))
+
+	ctx := multitenancy.WithOrgID(context.Background(), "acme")
+	ctx = memory.WithConversationID(ctx, "conv/1")
+
+	result, err := tool.Exec(ctx, codeinterpreter.Shell, "mkdir -p out && echo data > out/report.txt")
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if len(result.Artifacts) != 1 {
+		t.Fatalf("Expected one artifact, got %+v", result.Artifacts)
+	}
+	artifact := result.Artifacts[0]
+	path := filepath.Join(workDir, "acme", "conv_1", "out", "report.txt")
+	if artifact.Name != "out/report.txt" || artifact.Size != 5 || artifact.URI != "file://"+filepath.ToSlash(path) {
+		t.Errorf("Unexpected artifact %+v", artifact)
+	}
+
+	// Files persist across the calls of a conversation and are only
+	// reported again when modified
+	result, err = tool.Exec(ctx, codeinterpreter.Shell, "cat out/report.txt")
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if result.Stdout != "data\n" || len(result.Artifacts) != 0 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	// Other conversations do not see the files
+	other := memory.WithConversationID(multitenancy.WithOrgID(context.Background(), "acme"), "conv-2")
+	result, err = tool.Exec(other, codeinterpreter.Shell, "test -e out/report.txt")
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if result.ExitCode == 0 {
+		t.Error("Expected the file of another conversation to be missing")
+	}
+}
+
+func TestExec_Confinement(t *testing.T) {
+	if !sandboxAvailable(t) {
+		t.Skip("the sandbox is not available")
+	}
+	workDir := t.TempDir()
+	tool := newTool(t, codeinterpreter.WithWorkDir(workDir), codeinterpreter.WithIsolation(codeinterpreter.IsolationRequired))
+
+	sibling := filepath.Join(workDir, "acme", "conv-2")
+	if err := os.MkdirAll(sibling, 0o700); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(sibling, "secret.txt"), []byte("sibling secret"), 0o600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	t.Setenv("CODE_INTERPRETER_SECRET", "environ secret")
+
+	ctx := memory.WithConversationID(multitenancy.WithOrgID(context.Background(), "acme"), "conv-1")
+	code := fmt.Sprintf(`cat ../conv-2/secret.txt %[1]s/secret.txt 2>/dev/null || echo sibling denied
+cat /proc/%[2]d/environ /proc/*/environ 2>/dev/null | tr '\0' '\n'
+echo data > own.txt && cat own.txt
+touch /usr/escape 2>/dev/null || echo runtime read-only`, sibling, os.Getpid())
+
+	result, err := tool.Exec(ctx, codeinterpreter.Shell, code)
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if result.Sandbox != "namespaces" {
+		t.Errorf("Expected the namespaces sandbox, got %q", result.Sandbox)
+	}
+	if strings.Contains(result.Stdout, "secret") {
+		t.Errorf("Expected the snippet not to read the files of another conversation or the environment of the process, got %q", result.Stdout)
+	}
+	for _, expected := range []string{"sibling denied", "data", "runtime read-only"} {
+		if !strings.Contains(result.Stdout, expected) {
+			t.Errorf("Expected stdout to contain %q, got %q (stderr %q)", expected, result.Stdout, result.Stderr)
+		}
+	}
+}
+
+func TestExec_IsolationAuto(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the code interpreter is not supported on Windows")
+	}
+	tool := codeinterpreter.New(codeinterpreter.WithWorkDir(t.TempDir()))
+	allowed := codeinterpreter.New(codeinterpreter.WithWorkDir(t.TempDir()), codeinterpreter.WithNetwork(true))
+
+	if sandboxAvailable(t) {
+		result, err := tool.Exec(context.Background(), codeinterpreter.Shell, "echo hi")
+		if err != nil {
+			t.Fatalf("Exec failed: %v", err)
+		}
+		if result.Sandbox != "namespaces" {
+			t.Errorf("Expected the namespaces sandbox, got %q", result.Sandbox)
+		}
+		if !strings.Contains(tool.Description(), "without network access") {
+			t.Errorf("Unexpected description %q", tool.Description())
+		}
+		return
+	}
+
+	// Without the sandbox, disabled network access cannot be enforced
+	if _, err := tool.Exec(context.Background(), codeinterpreter.Shell, "echo hi"); err == nil {
+		t.Error("Expected snippets without network access to be refused")
+	}
+	if !strings.Contains(tool.Description(), "Not available") {
+		t.Errorf("Unexpected description %q", tool.Description())
+	}
+	result, err := allowed.Exec(context.Background(), codeinterpreter.Shell, "echo hi")
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if result.Sandbox != "rlimits" {
+		t.Errorf("Expected the rlimits sandbox, got %q", result.Sandbox)
+	}
+	if !strings.Contains(allowed.Description(), "can access the files of the host") {
+		t.Errorf("Unexpected description %q", allowed.Description())
+	}
+}
+
+func TestExec_PythonNetworkDisabled(t *testing.T) {
+	requirePython(t)
+	tool := newTool(t, codeinterpreter.WithIsolation(codeinterpreter.IsolationNone))
+
+	result, err := tool.Exec(context.Background(), codeinterpreter.Python,
+		"import socket\nsocket.create_connection(('127.0.0.1', 9), timeout=1)")
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if result.ExitCode == 0 || !strings.Contains(result.Stderr, "network access is disabled") {
+		t.Errorf("Expected the connection to be refused by the sandbox, got %+v", result)
+	}
+	if result.Sandbox != "rlimits" {
+		t.Errorf("Expected the rlimits sandbox, got %q", result.Sandbox)
+	}
+}
+
+func TestTool_Execute(t *testing.T) {
+	tool := newTool(t)
+
+	if tool.Name() != "code_interpreter" {
+		t.Errorf("Unexpected name %q", tool.Name())
+	}
+	params := tool.Parameters()
+	if !params["code"].Required || len(params["language"].Enum) != 2 {
+		t.Errorf("Unexpected parameters %+v", params)
+	}
+
+	output, err := tool.Execute(context.Background(), `{"language":"shell","code":"echo hi > hi.txt; echo done"}`)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	var result codeinterpreter.Result
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	if result.Stdout != "done\n" || len(result.Artifacts) != 1 || result.Artifacts[0].Name != "hi.txt" {
+		t.Errorf("Unexpected result %s", output)
+	}
+	if _, err := os.Stat(strings.TrimPrefix(result.Artifacts[0].URI, "file://")); err != nil {
+		t.Errorf("Expected the artifact to exist: %v", err)
+	}
+
+	if _, err := tool.Execute(context.Background(), `not json`); err == nil {
+		t.Error("Expected an error for invalid arguments")
+	}
+}
@@ -0,0 +1,468 @@
+package codeinterpreter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// sandboxInitArg is the name under which the process re-executes itself in
+// the namespaces of a snippet, to confine it before running it
+const sandboxInitArg = "code-interpreter-sandbox-init"
+
+func init() {
+	if len(os.Args) == 2 && os.Args[0] == sandboxInitArg {
+		runSandboxInit(os.Args[1])
+	}
+}
+
+var (
+	namespacesOnce      sync.Once
+	namespacesSupported bool
+)
+
+// namespaceFlags are the namespaces of isolated snippets. Without network
+// access, snippets also get a network namespace, which has no interface but a
+// loopback that is down.
+const namespaceFlags = syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWPID |
+	syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS
+
+// runtimePaths are the paths of the host that isolated snippets see,
+// read-only, when they exist
+var runtimePaths = []string{
+	"/usr", "/bin", "/sbin", "/lib", "/lib32", "/lib64", "/libx32",
+	"/etc/alternatives", "/etc/ld.so.cache", "/etc/ld.so.conf", "/etc/ld.so.conf.d", "/etc/localtime",
+}
+
+// networkPaths are the configuration files that snippets with network access
+// also see, read-only, to resolve names and verify certificates
+var networkPaths = []string{
+	"/etc/resolv.conf", "/etc/hosts", "/etc/nsswitch.conf", "/etc/ssl", "/etc/pki", "/etc/ca-certificates",
+}
+
+// devices are the devices of the host that isolated snippets see
+var devices = []string{"/dev/null", "/dev/zero", "/dev/full", "/dev/random", "/dev/urandom"}
+
+func checkPlatform() error {
+	return nil
+}
+
+// namespacesAvailable reports whether snippets can be confined, which
+// unprivileged user namespaces, container profiles or an unsupported
+// architecture may prevent. An empty sandbox is set up once to find out.
+func namespacesAvailable() bool {
+	namespacesOnce.Do(func() {
+		if seccompArch == 0 {
+			return
+		}
+		dir, err := os.MkdirTemp("", "code-interpreter-probe-")
+		if err != nil {
+			return
+		}
+		defer os.RemoveAll(dir)
+		workDir := filepath.Join(dir, "work")
+		if err := os.Mkdir(workDir, 0o700); err != nil {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		cmd := exec.CommandContext(ctx, "/bin/sh", "-c", "exit 0")
+		cmd.Dir = workDir
+		cmd.Env = []string{}
+		sb := &sandbox{Root: filepath.Join(dir, "root"), WorkDir: workDir}
+		if err := configureCommand(cmd, sb); err != nil {
+			return
+		}
+		namespacesSupported = cmd.Run() == nil
+	})
+	return namespacesSupported
+}
+
+// sandboxInit is the configuration passed to the re-executed process
+type sandboxInit struct {
+	Sandbox sandbox  `json:"sandbox"`
+	Path    string   `json:"path"`
+	Args    []string `json:"args"`
+}
+
+// configureCommand starts the snippet in its own process group, and kills
+// the whole group on timeout. Isolated snippets are started by the process
+// itself, re-executed in new namespaces, which confines them to the sandbox
+// before running them.
+func configureCommand(cmd *exec.Cmd, sb *sandbox) error {
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	if sb == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Pdeathsig: syscall.SIGKILL}
+		return nil
+	}
+
+	config, err := json.Marshal(sandboxInit{Sandbox: *sb, Path: cmd.Path, Args: cmd.Args})
+	if err != nil {
+		return fmt.Errorf("failed to encode sandbox: %w", err)
+	}
+	cmd.Path = "/proc/self/exe"
+	cmd.Args = []string{sandboxInitArg, string(config)}
+
+	flags := uintptr(namespaceFlags)
+	if !sb.Network {
+		flags |= syscall.CLONE_NEWNET
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags: flags,
+		// The user of the process is root in the sandbox, so that it can set
+		// it up, and owns the files written by snippets outside of it
+		UidMappings:                []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getuid(), Size: 1}},
+		GidMappings:                []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getgid(), Size: 1}},
+		GidMappingsEnableSetgroups: false,
+		Pdeathsig:                  syscall.SIGKILL,
+		Setpgid:                    true,
+	}
+	return nil
+}
+
+// runSandboxInit confines the process to the sandbox of a snippet and
+// executes the snippet. It does not return.
+func runSandboxInit(data string) {
+	// The capabilities, no_new_privs flag and seccomp filter are set for the
+	// thread that executes the snippet
+	runtime.LockOSThread()
+
+	var config sandboxInit
+	err := json.Unmarshal([]byte(data), &config)
+	if err == nil {
+		err = enterSandbox(&config.Sandbox)
+	}
+	if err == nil {
+		err = syscall.Exec(config.Path, config.Args, os.Environ())
+	}
+	fmt.Fprintln(os.Stderr, sandboxErrorPrefix+err.Error())
+	os.Exit(sandboxSetupFailed)
+}
+
+// enterSandbox replaces the root of the process with a new root in which only
+// the working directory is writable, mounts a new /proc, drops the
+// capabilities of the process and installs the seccomp filter
+func enterSandbox(sb *sandbox) error {
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("failed to make mounts private: %w", err)
+	}
+	if err := os.MkdirAll(sb.Root, 0o700); err != nil {
+		return fmt.Errorf("failed to create root: %w", err)
+	}
+	if err := unix.Mount("tmpfs", sb.Root, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "mode=0755,size=1m"); err != nil {
+		return fmt.Errorf("failed to mount root: %w", err)
+	}
+	for _, dir := range []string{"tmp", "proc", "dev"} {
+		if err := os.Mkdir(filepath.Join(sb.Root, dir), 0o755); err != nil {
+			return fmt.Errorf("failed to create /%s: %w", dir, err)
+		}
+	}
+	if err := unix.Mount("tmpfs", filepath.Join(sb.Root, "tmp"), "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "mode=1777,size=64m"); err != nil {
+		return fmt.Errorf("failed to mount /tmp: %w", err)
+	}
+
+	// Parents are mounted before the paths in them
+	readOnly := append(append(append([]string(nil), runtimePaths...), sb.ReadOnly...), devices...)
+	if sb.Network {
+		readOnly = append(readOnly, networkPaths...)
+	}
+	sort.Strings(readOnly)
+	var mounted []string
+	for _, path := range readOnly {
+		if covered(mounted, path) {
+			continue
+		}
+		ok, err := bindPath(sb.Root, path)
+		if err != nil {
+			return err
+		}
+		if ok {
+			mounted = append(mounted, path)
+		}
+	}
+	if err := bind(sb.WorkDir, filepath.Join(sb.Root, sb.WorkDir), true); err != nil {
+		return err
+	}
+	for _, path := range mounted {
+		if err := remountReadOnly(path, filepath.Join(sb.Root, path)); err != nil {
+			return err
+		}
+	}
+	for name, target := range map[string]string{"fd": "/proc/self/fd", "stdin": "/proc/self/fd/0", "stdout": "/proc/self/fd/1", "stderr": "/proc/self/fd/2"} {
+		if err := os.Symlink(target, filepath.Join(sb.Root, "dev", name)); err != nil {
+			return fmt.Errorf("failed to create /dev/%s: %w", name, err)
+		}
+	}
+
+	// The kernel only allows mounting /proc in a user namespace while the
+	// /proc of the host is still mounted, so before changing root. It shows
+	// the processes of the PID namespace of the snippet only.
+	if err := unix.Mount("proc", filepath.Join(sb.Root, "proc"), "proc", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC, ""); err != nil {
+		return fmt.Errorf("failed to mount /proc: %w", err)
+	}
+	if err := pivotRoot(sb.Root); err != nil {
+		return err
+	}
+	if err := unix.Mount("", "/", "", unix.MS_REMOUNT|unix.MS_RDONLY|unix.MS_NOSUID|unix.MS_NODEV, ""); err != nil {
+		return fmt.Errorf("failed to make the root read-only: %w", err)
+	}
+	if err := os.Chdir(sb.WorkDir); err != nil {
+		return fmt.Errorf("failed to enter the working directory: %w", err)
+	}
+	_ = unix.Sethostname([]byte("sandbox"))
+
+	if err := dropCapabilities(); err != nil {
+		return err
+	}
+	return installSeccomp()
+}
+
+// covered reports whether a path is in one of the mounted paths
+func covered(mounted []string, path string) bool {
+	for _, parent := range mounted {
+		if path == parent || strings.HasPrefix(path, parent+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// bindPath makes a path of the host available at the same path under the
+// root. Symbolic links are copied rather than followed. It reports false for
+// paths that do not exist.
+func bindPath(root, path string) (bool, error) {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	target := filepath.Join(root, path)
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return false, fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		link, err := os.Readlink(path)
+		if err != nil {
+			return false, fmt.Errorf("failed to read link %s: %w", path, err)
+		}
+		if err := os.Symlink(link, target); err != nil {
+			return false, fmt.Errorf("failed to create link %s: %w", path, err)
+		}
+		return false, nil
+	}
+	return true, bind(path, target, info.IsDir())
+}
+
+// bind bind-mounts a file or directory on a new mount point
+func bind(source, target string, dir bool) error {
+	if dir {
+		if err := os.MkdirAll(target, 0o755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", target, err)
+		}
+	} else {
+		file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", target, err)
+		}
+		_ = file.Close()
+	}
+	if err := unix.Mount(source, target, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+		return fmt.Errorf("failed to mount %s: %w", source, err)
+	}
+	return nil
+}
+
+// remountReadOnly makes a bind mount read-only. The flags of the mount of
+// the source that user namespaces may not clear are kept.
+func remountReadOnly(source, target string) error {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(source, &stat); err != nil {
+		return fmt.Errorf("failed to stat %s: %w", source, err)
+	}
+	flags := uintptr(unix.MS_BIND | unix.MS_REMOUNT | unix.MS_RDONLY | unix.MS_NOSUID)
+	for statFlag, mountFlag := range map[int64]uintptr{
+		unix.ST_NODEV:      unix.MS_NODEV,
+		unix.ST_NOEXEC:     unix.MS_NOEXEC,
+		unix.ST_NOATIME:    unix.MS_NOATIME,
+		unix.ST_NODIRATIME: unix.MS_NODIRATIME,
+		unix.ST_RELATIME:   unix.MS_RELATIME,
+	} {
+		if int64(stat.Flags)&statFlag != 0 {
+			flags |= mountFlag
+		}
+	}
+	if err := unix.Mount("", target, "", flags, ""); err != nil {
+		return fmt.Errorf("failed to make %s read-only: %w", source, err)
+	}
+	return nil
+}
+
+// pivotRoot makes a directory the root of the process and detaches the
+// previous root, so that the files of the host are no longer reachable
+func pivotRoot(root string) error {
+	if err := os.Chdir(root); err != nil {
+		return fmt.Errorf("failed to enter root: %w", err)
+	}
+	if err := os.Mkdir(".oldroot", 0o700); err != nil {
+		return fmt.Errorf("failed to create the mount point of the previous root: %w", err)
+	}
+	if err := unix.PivotRoot(".", ".oldroot"); err != nil {
+		return fmt.Errorf("failed to change root: %w", err)
+	}
+	if err := os.Chdir("/"); err != nil {
+		return fmt.Errorf("failed to enter root: %w", err)
+	}
+	if err := unix.Unmount("/.oldroot", unix.MNT_DETACH); err != nil {
+		return fmt.Errorf("failed to detach the previous root: %w", err)
+	}
+	if err := os.Remove("/.oldroot"); err != nil {
+		return fmt.Errorf("failed to remove the mount point of the previous root: %w", err)
+	}
+	return nil
+}
+
+// dropCapabilities empties the capability bounding set, so that the snippet,
+// which runs as root in its user namespace, has no capabilities
+func dropCapabilities() error {
+	if err := unix.Prctl(unix.PR_CAP_AMBIENT, unix.PR_CAP_AMBIENT_CLEAR_ALL, 0, 0, 0); err != nil {
+		return fmt.Errorf("failed to clear ambient capabilities: %w", err)
+	}
+	for capability := 0; ; capability++ {
+		err := unix.Prctl(unix.PR_CAPBSET_DROP, uintptr(capability), 0, 0, 0)
+		if err == unix.EINVAL {
+			// The last capability of the kernel was dropped
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to drop capability %d: %w", capability, err)
+		}
+	}
+}
+
+// deniedSyscalls are the system calls that snippets may not make. They
+// change mounts or namespaces, inspect other processes, or reach kernel
+// interfaces that sandboxed code has no use for.
+var deniedSyscalls = []uintptr{
+	unix.SYS_MOUNT, unix.SYS_UMOUNT2, unix.SYS_PIVOT_ROOT, unix.SYS_CHROOT,
+	unix.SYS_UNSHARE, unix.SYS_SETNS,
+	unix.SYS_FSOPEN, unix.SYS_FSCONFIG, unix.SYS_FSMOUNT, unix.SYS_FSPICK,
+	unix.SYS_MOVE_MOUNT, unix.SYS_OPEN_TREE, unix.SYS_MOUNT_SETATTR,
+	unix.SYS_PTRACE, unix.SYS_PROCESS_VM_READV, unix.SYS_PROCESS_VM_WRITEV,
+	unix.SYS_KEXEC_LOAD, unix.SYS_INIT_MODULE, unix.SYS_FINIT_MODULE, unix.SYS_DELETE_MODULE,
+	unix.SYS_BPF, unix.SYS_PERF_EVENT_OPEN, unix.SYS_USERFAULTFD,
+	unix.SYS_KEYCTL, unix.SYS_ADD_KEY, unix.SYS_REQUEST_KEY,
+	unix.SYS_OPEN_BY_HANDLE_AT, unix.SYS_NAME_TO_HANDLE_AT,
+	unix.SYS_SWAPON, unix.SYS_SWAPOFF, unix.SYS_REBOOT, unix.SYS_ACCT, unix.SYS_QUOTACTL,
+	unix.SYS_SETHOSTNAME, unix.SYS_SETDOMAINNAME,
+}
+
+// cloneNamespaceFlags are the flags of clone that create namespaces
+const cloneNamespaceFlags = unix.CLONE_NEWUSER | unix.CLONE_NEWNS | unix.CLONE_NEWPID | unix.CLONE_NEWNET |
+	unix.CLONE_NEWIPC | unix.CLONE_NEWUTS | unix.CLONE_NEWCGROUP | unix.CLONE_NEWTIME
+
+// installSeccomp sets no_new_privs and installs the seccomp filter of the
+// snippets. System calls of other architectures kill the process, denied
+// system calls and clone calls that create namespaces fail with EPERM, and
+// clone3, whose flags cannot be inspected, fails with ENOSYS so that the C
+// library falls back to clone.
+func installSeccomp() error {
+	if seccompArch == 0 {
+		return fmt.Errorf("seccomp filters are not supported on %s", runtime.GOARCH)
+	}
+
+	const (
+		archOffset    = 4
+		nrOffset      = 0
+		arg0Offset    = 16
+		x32SyscallBit = 0x40000000
+	)
+	load := func(offset uint32) unix.SockFilter {
+		return unix.SockFilter{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: offset}
+	}
+	ret := func(value uint32) unix.SockFilter {
+		return unix.SockFilter{Code: unix.BPF_RET | unix.BPF_K, K: value}
+	}
+
+	// The jumps to the returns at the end are resolved once the length of
+	// the program is known
+	const (
+		toAllow = iota + 1
+		toErrno
+		toNoSys
+		toKill
+	)
+	type jump struct {
+		index  int
+		target int
+		onTrue bool
+	}
+	var program []unix.SockFilter
+	var jumps []jump
+	jumpIf := func(code uint16, value uint32, target int) {
+		jumps = append(jumps, jump{index: len(program), target: target, onTrue: true})
+		program = append(program, unix.SockFilter{Code: code, K: value})
+	}
+
+	program = append(program, load(archOffset))
+	jumps = append(jumps, jump{index: len(program), target: toKill})
+	program = append(program, unix.SockFilter{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, K: seccompArch})
+	program = append(program, load(nrOffset))
+	if seccompX32 {
+		jumpIf(unix.BPF_JMP|unix.BPF_JGE|unix.BPF_K, x32SyscallBit, toKill)
+	}
+	for _, nr := range deniedSyscalls {
+		jumpIf(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, uint32(nr), toErrno)
+	}
+	jumpIf(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, unix.SYS_CLONE3, toNoSys)
+	jumps = append(jumps, jump{index: len(program), target: toAllow})
+	program = append(program, unix.SockFilter{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, K: unix.SYS_CLONE})
+	program = append(program, load(arg0Offset))
+	jumpIf(unix.BPF_JMP|unix.BPF_JSET|unix.BPF_K, cloneNamespaceFlags, toErrno)
+
+	returns := map[int]int{toAllow: len(program)}
+	program = append(program, ret(unix.SECCOMP_RET_ALLOW))
+	returns[toErrno] = len(program)
+	program = append(program, ret(unix.SECCOMP_RET_ERRNO|uint32(unix.EPERM)))
+	returns[toNoSys] = len(program)
+	program = append(program, ret(unix.SECCOMP_RET_ERRNO|uint32(unix.ENOSYS)))
+	returns[toKill] = len(program)
+	program = append(program, ret(unix.SECCOMP_RET_KILL_PROCESS))
+
+	for _, j := range jumps {
+		offset := returns[j.target] - j.index - 1
+		if offset > 255 {
+			return fmt.Errorf("seccomp filter is too long")
+		}
+		if j.onTrue {
+			program[j.index].Jt = uint8(offset)
+		} else {
+			program[j.index].Jf = uint8(offset)
+		}
+	}
+
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("failed to set no_new_privs: %w", err)
+	}
+	filter := unix.SockFprog{Len: uint16(len(program)), Filter: &program[0]}
+	if err := unix.Prctl(unix.PR_SET_SECCOMP, unix.SECCOMP_MODE_FILTER, uintptr(unsafe.Pointer(&filter)), 0, 0); err != nil {
+		return fmt.Errorf("failed to install seccomp filter: %w", err)
+	}
+	return nil
+}
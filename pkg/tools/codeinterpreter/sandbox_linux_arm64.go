@@ -0,0 +1,10 @@
+package codeinterpreter
+
+import "golang.org/x/sys/unix"
+
+// seccompArch is the audit architecture of the system calls of snippets
+const seccompArch = unix.AUDIT_ARCH_AARCH64
+
+// seccompX32 reports whether system calls of the x32 ABI, which share the
+// architecture, must be rejected
+const seccompX32 = false
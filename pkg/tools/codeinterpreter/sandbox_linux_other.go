@@ -0,0 +1,9 @@
+//go:build linux && !amd64 && !arm64
+
+package codeinterpreter
+
+// seccompArch is zero, the seccomp filter of snippets is only written for
+// amd64 and arm64, so snippets cannot be isolated
+const seccompArch = 0
+
+const seccompX32 = false
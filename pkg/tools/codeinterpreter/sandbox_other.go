@@ -0,0 +1,21 @@
+//go:build !unix
+
+package codeinterpreter
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+func checkPlatform() error {
+	return fmt.Errorf("the code interpreter is not supported on %s", runtime.GOOS)
+}
+
+func namespacesAvailable() bool {
+	return false
+}
+
+func configureCommand(cmd *exec.Cmd, sb *sandbox) error {
+	return nil
+}
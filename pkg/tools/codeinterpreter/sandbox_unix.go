@@ -0,0 +1,27 @@
+//go:build unix && !linux
+
+package codeinterpreter
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+func checkPlatform() error {
+	return nil
+}
+
+// namespacesAvailable reports false, namespaces are specific to Linux
+func namespacesAvailable() bool {
+	return false
+}
+
+// configureCommand starts the snippet in its own process group, and kills
+// the whole group on timeout
+func configureCommand(cmd *exec.Cmd, sb *sandbox) error {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	return nil
+}
@@ -0,0 +1,280 @@
+// Package filesystem provides tools that list, read, search, write, patch,
+// move and delete files. The tools of a Workspace are confined to its root
+// directory: paths that leave the root, directly or through symbolic links,
+// are rejected.
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// Operation is an operation of the workspace tools
+type Operation string
+
+const (
+	// OperationList lists the entries of a directory
+	OperationList Operation = "list"
+
+	// OperationRead reads a range of lines of a file
+	OperationRead Operation = "read"
+
+	// OperationSearch searches files for a regular expression
+	OperationSearch Operation = "search"
+
+	// OperationWrite creates or replaces a file
+	OperationWrite Operation = "write"
+
+	// OperationPatch replaces text in a file
+	OperationPatch Operation = "patch"
+
+	// OperationMove moves or renames a file or directory
+	OperationMove Operation = "move"
+
+	// OperationDelete deletes a file or directory
+	OperationDelete Operation = "delete"
+)
+
+// Operations returns all the operations, in the order of their tools
+func Operations() []Operation {
+	return []Operation{
+		OperationList, OperationRead, OperationSearch,
+		OperationWrite, OperationPatch, OperationMove, OperationDelete,
+	}
+}
+
+// modifies reports whether an operation modifies the workspace
+func (o Operation) modifies() bool {
+	switch o {
+	case OperationWrite, OperationPatch, OperationMove, OperationDelete:
+		return true
+	}
+	return false
+}
+
+var (
+	// ErrOutsideRoot is returned for paths outside the root of the workspace
+	ErrOutsideRoot = errors.New("path is outside the workspace")
+
+	// ErrReadOnly is returned for modifications of a read-only workspace
+	ErrReadOnly = errors.New("the workspace is read-only")
+
+	// ErrTooLarge is returned for files and contents above the size limit
+	ErrTooLarge = errors.New("file is too large")
+)
+
+const (
+	// DefaultMaxFileSize is the default size limit of files and contents
+	DefaultMaxFileSize = 1 << 20
+
+	// DefaultMaxResults is the default number of entries and matches returned
+	DefaultMaxResults = 200
+)
+
+// Workspace confines file operations to a root directory
+type Workspace struct {
+	root        string
+	rootAlias   string
+	readOnly    bool
+	maxFileSize int64
+	maxResults  int
+	approval    map[Operation]bool
+}
+
+// Option represents an option for configuring a Workspace
+type Option func(*Workspace)
+
+// WithReadOnly disables the write, patch, move and delete operations
+func WithReadOnly(readOnly bool) Option {
+	return func(w *Workspace) {
+		w.readOnly = readOnly
+	}
+}
+
+// WithMaxFileSize sets the size limit, in bytes, of the files that are read
+// or searched and of the contents that are written
+func WithMaxFileSize(bytes int64) Option {
+	return func(w *Workspace) {
+		w.maxFileSize = bytes
+	}
+}
+
+// WithMaxResults sets the number of entries listed and matches found
+func WithMaxResults(results int) Option {
+	return func(w *Workspace) {
+		w.maxResults = results
+	}
+}
+
+// WithApproval requires approval of every call to the tools of the
+// operations. The calls are paused by the approver of the agent, see
+// agent.WithToolApproval.
+func WithApproval(operations ...Operation) Option {
+	return func(w *Workspace) {
+		for _, operation := range operations {
+			w.approval[operation] = true
+		}
+	}
+}
+
+// New creates a workspace rooted at an existing directory
+func New(root string, options ...Option) (*Workspace, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute root path: %w", err)
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve root: %w", err)
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat root: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("root %s is not a directory", root)
+	}
+
+	w := &Workspace{
+		root:        resolved,
+		rootAlias:   abs,
+		maxFileSize: DefaultMaxFileSize,
+		maxResults:  DefaultMaxResults,
+		approval:    make(map[Operation]bool),
+	}
+	for _, option := range options {
+		option(w)
+	}
+	return w, nil
+}
+
+// Root returns the root directory of the workspace, with symbolic links resolved
+func (w *Workspace) Root() string {
+	return w.root
+}
+
+// ReadOnly reports whether the workspace is read-only
+func (w *Workspace) ReadOnly() bool {
+	return w.readOnly
+}
+
+// Tools returns the tools of the workspace. Read-only workspaces only have
+// the list, read and search tools.
+func (w *Workspace) Tools() []interfaces.Tool {
+	var tools []interfaces.Tool
+	for _, operation := range Operations() {
+		if w.readOnly && operation.modifies() {
+			continue
+		}
+		tools = append(tools, w.Tool(operation))
+	}
+	return tools
+}
+
+// Tool returns the tool of an operation
+func (w *Workspace) Tool(operation Operation) interfaces.Tool {
+	t := &tool{workspace: w, operation: operation}
+	if w.approval[operation] {
+		return &approvalRequiredTool{t}
+	}
+	return t
+}
+
+// resolve returns the real path of a path relative to the root, or of an
+// absolute path in the root. Symbolic links in the existing part of the path
+// are resolved, and the result must be in the root.
+func (w *Workspace) resolve(path string) (string, error) {
+	full, err := w.join(path)
+	if err != nil {
+		return "", err
+	}
+
+	// Resolve the longest existing prefix of the path
+	existing, rest := full, ""
+	for {
+		real, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			full = filepath.Join(real, rest)
+			break
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("failed to resolve %s: %w", path, err)
+		}
+		if _, err := os.Lstat(existing); err == nil {
+			return "", fmt.Errorf("%s: broken symbolic link", path)
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = filepath.Dir(existing)
+	}
+
+	if !within(w.root, full) {
+		return "", fmt.Errorf("%s: %w", path, ErrOutsideRoot)
+	}
+	return full, nil
+}
+
+// resolveEntry resolves the directory of a path but not its last element,
+// so that symbolic links are moved and deleted rather than their targets
+func (w *Workspace) resolveEntry(path string) (string, error) {
+	full, err := w.join(path)
+	if err != nil {
+		return "", err
+	}
+	if full == w.root {
+		return "", fmt.Errorf("%s: the workspace root cannot be modified", path)
+	}
+	dir, err := w.resolve(w.rel(filepath.Dir(full)))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, filepath.Base(full)), nil
+}
+
+// join joins a path to the root, and checks that the result is in the root
+func (w *Workspace) join(path string) (string, error) {
+	if path == "" {
+		path = "."
+	}
+	path = filepath.FromSlash(path)
+
+	var full string
+	if filepath.IsAbs(path) {
+		full = filepath.Clean(path)
+		if !within(w.root, full) && within(w.rootAlias, full) {
+			rel, _ := filepath.Rel(w.rootAlias, full)
+			full = filepath.Join(w.root, rel)
+		}
+	} else {
+		full = filepath.Join(w.root, path)
+	}
+
+	if !within(w.root, full) {
+		return "", fmt.Errorf("%s: %w", path, ErrOutsideRoot)
+	}
+	return full, nil
+}
+
+// rel returns the path of a file of the workspace relative to the root, in
+// slash form
+func (w *Workspace) rel(path string) string {
+	rel, err := filepath.Rel(w.root, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// within reports whether path is root or in root
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
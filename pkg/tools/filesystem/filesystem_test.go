@@ -0,0 +1,321 @@
+package filesystem_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tagus/agent-sdk-go/pkg/approval"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/tools/filesystem"
+)
+
+// newWorkspace creates a workspace with a few files
+func newWorkspace(t *testing.T, options ...filesystem.Option) (*filesystem.Workspace, string) {
+	root := t.TempDir()
+	files := map[string]string{
+		"README.md":       "# Project\n",
+		"src/main.go":     "package main\n\nfunc main() {\n\tprintln(\"hello\")\n}\n",
+		"src/util/log.go": "package util\n\n// TODO: add levels\nfunc Log() {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	workspace, err := filesystem.New(root, options...)
+	if err != nil {
+		t.Fatalf("Failed to create workspace: %v", err)
+	}
+	return workspace, workspace.Root()
+}
+
+func toolByName(t *testing.T, workspace *filesystem.Workspace, name string) interfaces.Tool {
+	for _, tool := range workspace.Tools() {
+		if tool.Name() == name {
+			return tool
+		}
+	}
+	t.Fatalf("Tool %s not found", name)
+	return nil
+}
+
+func execute(t *testing.T, workspace *filesystem.Workspace, name, args string) (string, error) {
+	return toolByName(t, workspace, name).Execute(context.Background(), args)
+}
+
+func TestTools(t *testing.T) {
+	workspace, _ := newWorkspace(t)
+
+	var names []string
+	for _, tool := range workspace.Tools() {
+		names = append(names, tool.Name())
+	}
+	expected := "list_files read_file search_files write_file patch_file move_file delete_file"
+	if strings.Join(names, " ") != expected {
+		t.Errorf("Expected tools %s, got %v", expected, names)
+	}
+
+	readOnly, _ := newWorkspace(t, filesystem.WithReadOnly(true))
+	if len(readOnly.Tools()) != 3 {
+		t.Errorf("Expected 3 tools in a read-only workspace, got %d", len(readOnly.Tools()))
+	}
+	if _, err := readOnly.Tool(filesystem.OperationWrite).Execute(context.Background(), `{"path":"x","content":"y"}`); !errors.Is(err, filesystem.ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly, got %v", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	workspace, _ := newWorkspace(t)
+
+	tests := []struct {
+		name     string
+		args     string
+		expected string
+	}{
+		{"root", `{}`, "README.md (10 bytes)\nsrc/"},
+		{"directory", `{"path":"src"}`, "src/main.go (48 bytes)\nsrc/util/"},
+		{"recursive", `{"path":"src","recursive":true}`, "src/main.go (48 bytes)\nsrc/util/\nsrc/util/log.go (48 bytes)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := execute(t, workspace, "list_files", tt.args)
+			if err != nil {
+				t.Fatalf("list_files failed: %v", err)
+			}
+			if output != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, output)
+			}
+		})
+	}
+
+	truncated, _ := newWorkspace(t, filesystem.WithMaxResults(2))
+	output, err := execute(t, truncated, "list_files", `{"recursive":true}`)
+	if err != nil {
+		t.Fatalf("list_files failed: %v", err)
+	}
+	if !strings.HasSuffix(output, "... truncated after 2 entries") {
+		t.Errorf("Expected a truncated listing, got %q", output)
+	}
+}
+
+func TestRead(t *testing.T) {
+	workspace, root := newWorkspace(t)
+
+	output, err := execute(t, workspace, "read_file", `{"path":"src/main.go","start_line":3,"end_line":4}`)
+	if err != nil {
+		t.Fatalf("read_file failed: %v", err)
+	}
+	if output != "3\tfunc main() {\n4\t\tprintln(\"hello\")" {
+		t.Errorf("Unexpected lines %q", output)
+	}
+
+	if _, err := execute(t, workspace, "read_file", `{"path":"src/main.go","start_line":10}`); err == nil {
+		t.Error("Expected an error for a start line past the end")
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "data.bin"), []byte{0x7f, 'E', 0, 1}, 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := execute(t, workspace, "read_file", `{"path":"data.bin"}`); err == nil {
+		t.Error("Expected an error for a binary file")
+	}
+
+	small, _ := newWorkspace(t, filesystem.WithMaxFileSize(20))
+	output, err = execute(t, small, "read_file", `{"path":"src/main.go"}`)
+	if err != nil {
+		t.Fatalf("read_file failed: %v", err)
+	}
+	if !strings.Contains(output, "truncated at 20 bytes, continue from line") {
+		t.Errorf("Expected a truncated read, got %q", output)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	workspace, _ := newWorkspace(t)
+
+	output, err := execute(t, workspace, "search_files", `{"pattern":"^func \\w+"}`)
+	if err != nil {
+		t.Fatalf("search_files failed: %v", err)
+	}
+	if output != "src/main.go:3: func main() {\nsrc/util/log.go:4: func Log() {}" {
+		t.Errorf("Unexpected matches %q", output)
+	}
+
+	output, err = execute(t, workspace, "search_files", `{"pattern":"TODO","include":"*.md"}`)
+	if err != nil {
+		t.Fatalf("search_files failed: %v", err)
+	}
+	if output != "No matches found" {
+		t.Errorf("Expected no matches, got %q", output)
+	}
+
+	if _, err := execute(t, workspace, "search_files", `{"pattern":"("}`); err == nil {
+		t.Error("Expected an error for an invalid pattern")
+	}
+}
+
+func TestWriteAndPatch(t *testing.T) {
+	workspace, root := newWorkspace(t, filesystem.WithMaxFileSize(100))
+
+	if _, err := execute(t, workspace, "write_file", `{"path":"docs/notes.txt","content":"one\ntwo\none\n"}`); err != nil {
+		t.Fatalf("write_file failed: %v", err)
+	}
+	if _, err := execute(t, workspace, "write_file", `{"path":"big.txt","content":"`+strings.Repeat("x", 101)+`"}`); !errors.Is(err, filesystem.ErrTooLarge) {
+		t.Errorf("Expected ErrTooLarge, got %v", err)
+	}
+
+	if _, err := execute(t, workspace, "patch_file", `{"path":"docs/notes.txt","old_text":"one","new_text":"1"}`); err == nil {
+		t.Error("Expected an error for ambiguous text")
+	}
+	if _, err := execute(t, workspace, "patch_file", `{"path":"docs/notes.txt","old_text":"three","new_text":"3"}`); err == nil {
+		t.Error("Expected an error for missing text")
+	}
+	output, err := execute(t, workspace, "patch_file", `{"path":"docs/notes.txt","old_text":"one","new_text":"1","replace_all":true}`)
+	if err != nil {
+		t.Fatalf("patch_file failed: %v", err)
+	}
+	if output != "Replaced 2 occurrence(s) in docs/notes.txt" {
+		t.Errorf("Unexpected output %q", output)
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, "docs", "notes.txt"))
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if string(data) != "1\ntwo\n1\n" {
+		t.Errorf("Unexpected content %q", data)
+	}
+}
+
+func TestMoveAndDelete(t *testing.T) {
+	workspace, root := newWorkspace(t)
+
+	if _, err := execute(t, workspace, "move_file", `{"source":"src/util","destination":"lib/util"}`); err != nil {
+		t.Fatalf("move_file failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "lib", "util", "log.go")); err != nil {
+		t.Errorf("Expected the directory to be moved: %v", err)
+	}
+	if _, err := execute(t, workspace, "move_file", `{"source":"README.md","destination":"src/main.go"}`); err == nil {
+		t.Error("Expected an error for an existing destination")
+	}
+
+	if _, err := execute(t, workspace, "delete_file", `{"path":"lib"}`); err == nil {
+		t.Error("Expected an error for a directory that is not empty")
+	}
+	if _, err := execute(t, workspace, "delete_file", `{"path":"lib","recursive":true}`); err != nil {
+		t.Fatalf("delete_file failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "lib")); !os.IsNotExist(err) {
+		t.Errorf("Expected the directory to be deleted, got %v", err)
+	}
+	if _, err := execute(t, workspace, "delete_file", `{"path":".","recursive":true}`); err == nil {
+		t.Error("Expected an error for the workspace root")
+	}
+}
+
+func TestPathsOutsideRoot(t *testing.T) {
+	workspace, root := newWorkspace(t)
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		tool string
+		args string
+	}{
+		{"parent directory", "read_file", `{"path":"../secret.txt"}`},
+		{"nested parent directory", "list_files", `{"path":"src/../../"}`},
+		{"absolute path", "read_file", `{"path":"` + filepath.ToSlash(filepath.Join(outside, "secret.txt")) + `"}`},
+		{"write outside", "write_file", `{"path":"../x.txt","content":"x"}`},
+		{"move outside", "move_file", `{"source":"README.md","destination":"../README.md"}`},
+	}
+
+	if runtime.GOOS != "windows" {
+		if err := os.Symlink(outside, filepath.Join(root, "link")); err != nil {
+			t.Fatalf("Failed to create symlink: %v", err)
+		}
+		if err := os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(root, "secret.txt")); err != nil {
+			t.Fatalf("Failed to create symlink: %v", err)
+		}
+		if err := os.Symlink(filepath.Join(outside, "missing.txt"), filepath.Join(root, "dangling.txt")); err != nil {
+			t.Fatalf("Failed to create symlink: %v", err)
+		}
+		tests = append(tests, []struct {
+			name string
+			tool string
+			args string
+		}{
+			{"symlinked directory", "read_file", `{"path":"link/secret.txt"}`},
+			{"symlinked file", "read_file", `{"path":"secret.txt"}`},
+			{"write through symlinked directory", "write_file", `{"path":"link/new/x.txt","content":"x"}`},
+			{"patch through symlink", "patch_file", `{"path":"secret.txt","old_text":"secret","new_text":"x"}`},
+			{"write through dangling symlink", "write_file", `{"path":"dangling.txt","content":"x"}`},
+			{"search symlinked directory", "search_files", `{"pattern":"secret","path":"link"}`},
+		}...)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := execute(t, workspace, tt.tool, tt.args); err == nil {
+				t.Error("Expected the path to be rejected")
+			}
+		})
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	// Symbolic links themselves are deleted, not their targets
+	if _, err := execute(t, workspace, "delete_file", `{"path":"secret.txt"}`); err != nil {
+		t.Fatalf("delete_file failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "secret.txt")); err != nil {
+		t.Errorf("Expected the target of the link to remain: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "missing.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected no file to be created outside the workspace, got %v", err)
+	}
+}
+
+func TestWithApproval(t *testing.T) {
+	workspace, _ := newWorkspace(t, filesystem.WithApproval(filesystem.OperationDelete))
+
+	for _, tool := range workspace.Tools() {
+		policy, ok := tool.(approval.ToolPolicy)
+		if tool.Name() == "delete_file" {
+			if !ok || !policy.RequiresApproval(`{"path":"README.md"}`) {
+				t.Error("Expected delete_file to require approval")
+			}
+		} else if ok {
+			t.Errorf("Expected %s not to require approval", tool.Name())
+		}
+	}
+
+	// Approvers pause the calls without a policy of their own
+	approver := approval.New(approval.WithTimeout(10 * time.Millisecond))
+	wrapped := approver.Wrap([]interfaces.Tool{workspace.Tool(filesystem.OperationDelete)})[0]
+	_, err := wrapped.Execute(context.Background(), `{"path":"README.md"}`)
+	var suspended *approval.SuspendedError
+	if !errors.As(err, &suspended) {
+		t.Fatalf("Expected the call to be suspended, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(workspace.Root(), "README.md")); err != nil {
+		t.Errorf("Expected the file to remain: %v", err)
+	}
+}
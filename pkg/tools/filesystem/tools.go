@@ -0,0 +1,615 @@
+package filesystem
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/tagus/agent-sdk-go/pkg/approval"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// maxLineLength is the length at which search matches are cut
+const maxLineLength = 500
+
+// Input represents the input of the workspace tools. Each tool uses the
+// fields of its parameters.
+type Input struct {
+	Path        string `json:"path,omitempty"`
+	Recursive   bool   `json:"recursive,omitempty"`
+	StartLine   int    `json:"start_line,omitempty"`
+	EndLine     int    `json:"end_line,omitempty"`
+	Pattern     string `json:"pattern,omitempty"`
+	Include     string `json:"include,omitempty"`
+	Content     string `json:"content,omitempty"`
+	OldText     string `json:"old_text,omitempty"`
+	NewText     string `json:"new_text,omitempty"`
+	ReplaceAll  bool   `json:"replace_all,omitempty"`
+	Source      string `json:"source,omitempty"`
+	Destination string `json:"destination,omitempty"`
+}
+
+// toolSpec describes the tool of an operation
+type toolSpec struct {
+	name        string
+	displayName string
+	description string
+	parameters  map[string]interfaces.ParameterSpec
+}
+
+var pathParameter = interfaces.ParameterSpec{
+	Type:        "string",
+	Description: "The path of the file, relative to the workspace root",
+	Required:    true,
+}
+
+var toolSpecs = map[Operation]toolSpec{
+	OperationList: {
+		name:        "list_files",
+		displayName: "List Files",
+		description: "List the files and directories in a directory of the workspace. Directories end with / and symbolic links with @.",
+		parameters: map[string]interfaces.ParameterSpec{
+			"path": {
+				Type:        "string",
+				Description: "The directory to list, relative to the workspace root (default: the root)",
+			},
+			"recursive": {
+				Type:        "boolean",
+				Description: "Whether to list the subdirectories too",
+				Default:     false,
+			},
+		},
+	},
+	OperationRead: {
+		name:        "read_file",
+		displayName: "Read File",
+		description: "Read a text file of the workspace, or a range of its lines. Each line is prefixed with its number and a tab.",
+		parameters: map[string]interfaces.ParameterSpec{
+			"path": pathParameter,
+			"start_line": {
+				Type:        "integer",
+				Description: "The first line to read, starting at 1 (default: 1)",
+			},
+			"end_line": {
+				Type:        "integer",
+				Description: "The last line to read (default: the end of the file)",
+			},
+		},
+	},
+	OperationSearch: {
+		name:        "search_files",
+		displayName: "Search Files",
+		description: "Search the text files of the workspace for a regular expression. Matches are returned as path:line: text.",
+		parameters: map[string]interfaces.ParameterSpec{
+			"pattern": {
+				Type:        "string",
+				Description: "The regular expression to search for (Go RE2 syntax)",
+				Required:    true,
+			},
+			"path": {
+				Type:        "string",
+				Description: "The file or directory to search, relative to the workspace root (default: the root)",
+			},
+			"include": {
+				Type:        "string",
+				Description: "A glob matched against file names, such as *.go",
+			},
+		},
+	},
+	OperationWrite: {
+		name:        "write_file",
+		displayName: "Write File",
+		description: "Create a file in the workspace, or replace its content. Missing directories are created.",
+		parameters: map[string]interfaces.ParameterSpec{
+			"path": pathParameter,
+			"content": {
+				Type:        "string",
+				Description: "The content of the file",
+				Required:    true,
+			},
+		},
+	},
+	OperationPatch: {
+		name:        "patch_file",
+		displayName: "Patch File",
+		description: "Replace text in a file of the workspace. The text to replace must occur exactly once unless replace_all is set.",
+		parameters: map[string]interfaces.ParameterSpec{
+			"path": pathParameter,
+			"old_text": {
+				Type:        "string",
+				Description: "The exact text to replace, without line numbers",
+				Required:    true,
+			},
+			"new_text": {
+				Type:        "string",
+				Description: "The replacement text",
+				Required:    true,
+			},
+			"replace_all": {
+				Type:        "boolean",
+				Description: "Whether to replace every occurrence",
+				Default:     false,
+			},
+		},
+	},
+	OperationMove: {
+		name:        "move_file",
+		displayName: "Move File",
+		description: "Move or rename a file or directory of the workspace. The destination must not exist.",
+		parameters: map[string]interfaces.ParameterSpec{
+			"source": {
+				Type:        "string",
+				Description: "The path to move, relative to the workspace root",
+				Required:    true,
+			},
+			"destination": {
+				Type:        "string",
+				Description: "The new path, relative to the workspace root",
+				Required:    true,
+			},
+		},
+	},
+	OperationDelete: {
+		name:        "delete_file",
+		displayName: "Delete File",
+		description: "Delete a file or directory of the workspace.",
+		parameters: map[string]interfaces.ParameterSpec{
+			"path": pathParameter,
+			"recursive": {
+				Type:        "boolean",
+				Description: "Whether to delete a directory that is not empty with its content",
+				Default:     false,
+			},
+		},
+	},
+}
+
+// tool is the tool of an operation of a workspace
+type tool struct {
+	workspace *Workspace
+	operation Operation
+}
+
+// Name implements interfaces.Tool.Name
+func (t *tool) Name() string {
+	return toolSpecs[t.operation].name
+}
+
+// DisplayName implements interfaces.ToolWithDisplayName.DisplayName
+func (t *tool) DisplayName() string {
+	return toolSpecs[t.operation].displayName
+}
+
+// Description implements interfaces.Tool.Description
+func (t *tool) Description() string {
+	description := toolSpecs[t.operation].description
+	if t.operation == OperationRead || t.operation == OperationSearch || t.operation == OperationWrite {
+		description += fmt.Sprintf(" Files are limited to %d bytes.", t.workspace.maxFileSize)
+	}
+	return description
+}
+
+// Internal implements interfaces.InternalTool.Internal
+func (t *tool) Internal() bool {
+	return false
+}
+
+// Parameters implements interfaces.Tool.Parameters
+func (t *tool) Parameters() map[string]interfaces.ParameterSpec {
+	return toolSpecs[t.operation].parameters
+}
+
+// Run implements interfaces.Tool.Run
+func (t *tool) Run(ctx context.Context, input string) (string, error) {
+	return t.Execute(ctx, input)
+}
+
+// Execute implements interfaces.Tool.Execute
+func (t *tool) Execute(ctx context.Context, args string) (string, error) {
+	var in Input
+	if err := json.Unmarshal([]byte(args), &in); err != nil {
+		return "", fmt.Errorf("failed to parse input: %w", err)
+	}
+
+	w := t.workspace
+	if w.readOnly && t.operation.modifies() {
+		return "", ErrReadOnly
+	}
+
+	switch t.operation {
+	case OperationList:
+		return w.list(ctx, in.Path, in.Recursive)
+	case OperationRead:
+		return w.read(in.Path, in.StartLine, in.EndLine)
+	case OperationSearch:
+		return w.search(ctx, in.Pattern, in.Path, in.Include)
+	case OperationWrite:
+		return w.write(in.Path, in.Content)
+	case OperationPatch:
+		return w.patch(in.Path, in.OldText, in.NewText, in.ReplaceAll)
+	case OperationMove:
+		return w.move(in.Source, in.Destination)
+	case OperationDelete:
+		return w.delete(in.Path, in.Recursive)
+	default:
+		return "", fmt.Errorf("unknown operation: %s", t.operation)
+	}
+}
+
+// approvalRequiredTool is the tool of an operation whose calls need approval
+type approvalRequiredTool struct {
+	*tool
+}
+
+var _ approval.ToolPolicy = (*approvalRequiredTool)(nil)
+
+// RequiresApproval implements approval.ToolPolicy.RequiresApproval
+func (t *approvalRequiredTool) RequiresApproval(arguments string) bool {
+	return true
+}
+
+func (w *Workspace) list(ctx context.Context, path string, recursive bool) (string, error) {
+	dir, err := w.resolve(path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to list %s: %w", path, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", path)
+	}
+
+	var entries []string
+	truncated := false
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == dir {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if len(entries) == w.maxResults {
+			truncated = true
+			return filepath.SkipAll
+		}
+
+		entry := w.rel(p)
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			entry += "@"
+		case d.IsDir():
+			entry += "/"
+		default:
+			if info, err := d.Info(); err == nil {
+				entry += fmt.Sprintf(" (%d bytes)", info.Size())
+			}
+		}
+		entries = append(entries, entry)
+
+		if d.IsDir() && !recursive {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list %s: %w", path, err)
+	}
+
+	if len(entries) == 0 {
+		return fmt.Sprintf("%s is empty", w.rel(dir)), nil
+	}
+	if truncated {
+		entries = append(entries, fmt.Sprintf("... truncated after %d entries", w.maxResults))
+	}
+	return strings.Join(entries, "\n"), nil
+}
+
+func (w *Workspace) read(path string, startLine, endLine int) (string, error) {
+	file, err := w.openText(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if startLine < 1 {
+		startLine = 1
+	}
+	if endLine != 0 && endLine < startLine {
+		return "", fmt.Errorf("end_line %d is before start_line %d", endLine, startLine)
+	}
+
+	var out strings.Builder
+	reader := bufio.NewReader(file)
+	lineNumber := 0
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			lineNumber++
+			if lineNumber >= startLine && (endLine == 0 || lineNumber <= endLine) {
+				numbered := fmt.Sprintf("%d\t%s", lineNumber, strings.TrimSuffix(line, "\n"))
+				if int64(out.Len()+len(numbered)+1) > w.maxFileSize {
+					fmt.Fprintf(&out, "... truncated at %d bytes, continue from line %d", w.maxFileSize, lineNumber)
+					return out.String(), nil
+				}
+				out.WriteString(numbered)
+				out.WriteByte('\n')
+			}
+		}
+		if err == io.EOF || (endLine != 0 && lineNumber >= endLine) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", path, err)
+		}
+	}
+
+	if lineNumber < startLine && lineNumber > 0 {
+		return "", fmt.Errorf("start_line %d is past the end of %s (%d lines)", startLine, path, lineNumber)
+	}
+	if out.Len() == 0 {
+		return fmt.Sprintf("%s is empty", path), nil
+	}
+	return strings.TrimSuffix(out.String(), "\n"), nil
+}
+
+func (w *Workspace) search(ctx context.Context, pattern, path, include string) (string, error) {
+	if pattern == "" {
+		return "", fmt.Errorf("pattern is required")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern: %w", err)
+	}
+	if include != "" {
+		if _, err := filepath.Match(include, ""); err != nil {
+			return "", fmt.Errorf("invalid include glob: %w", err)
+		}
+	}
+	start, err := w.resolve(path)
+	if err != nil {
+		return "", err
+	}
+
+	var matches []string
+	truncated := false
+	err = filepath.WalkDir(start, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if include != "" {
+			if ok, _ := filepath.Match(include, d.Name()); !ok {
+				return nil
+			}
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > w.maxFileSize {
+			return nil
+		}
+		data, err := os.ReadFile(p) // #nosec G304 - Path is in the resolved workspace root
+		if err != nil || isBinary(data) {
+			return nil
+		}
+
+		for i, line := range strings.Split(string(data), "\n") {
+			if !re.MatchString(line) {
+				continue
+			}
+			if len(matches) == w.maxResults {
+				truncated = true
+				return filepath.SkipAll
+			}
+			if len(line) > maxLineLength {
+				line = line[:maxLineLength] + "..."
+			}
+			matches = append(matches, fmt.Sprintf("%s:%d: %s", w.rel(p), i+1, line))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to search %s: %w", path, err)
+	}
+
+	if len(matches) == 0 {
+		return "No matches found", nil
+	}
+	if truncated {
+		matches = append(matches, fmt.Sprintf("... truncated after %d matches", w.maxResults))
+	}
+	return strings.Join(matches, "\n"), nil
+}
+
+func (w *Workspace) write(path, content string) (string, error) {
+	if int64(len(content)) > w.maxFileSize {
+		return "", fmt.Errorf("%w: the content has %d bytes, the limit is %d", ErrTooLarge, len(content), w.maxFileSize)
+	}
+	file, err := w.resolve(path)
+	if err != nil {
+		return "", err
+	}
+
+	mode := fs.FileMode(0o644)
+	if info, err := os.Stat(file); err == nil {
+		if info.IsDir() {
+			return "", fmt.Errorf("%s is a directory", path)
+		}
+		mode = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create the directory of %s: %w", path, err)
+	}
+	if err := os.WriteFile(file, []byte(content), mode); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return fmt.Sprintf("Wrote %d bytes to %s", len(content), w.rel(file)), nil
+}
+
+func (w *Workspace) patch(path, oldText, newText string, replaceAll bool) (string, error) {
+	if oldText == "" {
+		return "", fmt.Errorf("old_text is required")
+	}
+	file, err := w.openText(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	if info, err := file.Stat(); err == nil && info.Size() > w.maxFileSize {
+		return "", fmt.Errorf("%w: %s has %d bytes, the limit is %d", ErrTooLarge, path, info.Size(), w.maxFileSize)
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	content := string(data)
+
+	count := strings.Count(content, oldText)
+	switch {
+	case count == 0:
+		return "", fmt.Errorf("old_text was not found in %s", path)
+	case count > 1 && !replaceAll:
+		return "", fmt.Errorf("old_text occurs %d times in %s; include more context to make it unique, or set replace_all", count, path)
+	}
+	if !replaceAll {
+		count = 1
+	}
+	patched := strings.Replace(content, oldText, newText, count)
+	if int64(len(patched)) > w.maxFileSize {
+		return "", fmt.Errorf("%w: the patched file has %d bytes, the limit is %d", ErrTooLarge, len(patched), w.maxFileSize)
+	}
+
+	resolved, err := w.resolve(path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	if err := os.WriteFile(resolved, []byte(patched), info.Mode().Perm()); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return fmt.Sprintf("Replaced %d occurrence(s) in %s", count, w.rel(resolved)), nil
+}
+
+func (w *Workspace) move(source, destination string) (string, error) {
+	if source == "" || destination == "" {
+		return "", fmt.Errorf("source and destination are required")
+	}
+	from, err := w.resolveEntry(source)
+	if err != nil {
+		return "", err
+	}
+	to, err := w.resolveEntry(destination)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Lstat(from); err != nil {
+		return "", fmt.Errorf("failed to move %s: %w", source, err)
+	}
+	if _, err := os.Lstat(to); err == nil {
+		return "", fmt.Errorf("destination %s already exists", destination)
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create the directory of %s: %w", destination, err)
+	}
+	if err := os.Rename(from, to); err != nil {
+		return "", fmt.Errorf("failed to move %s: %w", source, err)
+	}
+	return fmt.Sprintf("Moved %s to %s", w.rel(from), w.rel(to)), nil
+}
+
+func (w *Workspace) delete(path string, recursive bool) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("path is required")
+	}
+	entry, err := w.resolveEntry(path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Lstat(entry)
+	if err != nil {
+		return "", fmt.Errorf("failed to delete %s: %w", path, err)
+	}
+
+	if info.IsDir() && recursive {
+		err = os.RemoveAll(entry)
+	} else {
+		err = os.Remove(entry)
+		if err != nil && info.IsDir() {
+			if entries, readErr := os.ReadDir(entry); readErr == nil && len(entries) > 0 {
+				return "", fmt.Errorf("directory %s is not empty; set recursive to delete it with its content", path)
+			}
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to delete %s: %w", path, err)
+	}
+	return fmt.Sprintf("Deleted %s", w.rel(entry)), nil
+}
+
+// openText opens a text file of the workspace within the size limit
+func (w *Workspace) openText(path string) (*os.File, error) {
+	if path == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+	resolved, err := w.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(resolved) // #nosec G304 - Path is validated with resolve() before use
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	if info.IsDir() {
+		file.Close()
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+
+	head := make([]byte, 8000)
+	n, err := file.Read(head)
+	if err != nil && !errors.Is(err, io.EOF) {
+		file.Close()
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if isBinary(head[:n]) {
+		file.Close()
+		return nil, fmt.Errorf("%s is a binary file", path)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return file, nil
+}
+
+// isBinary reports whether data looks binary, like git does
+func isBinary(data []byte) bool {
+	if len(data) > 8000 {
+		data = data[:8000]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
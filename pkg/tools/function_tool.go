@@ -0,0 +1,202 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/structuredoutput"
+)
+
+// FunctionTool is a tool backed by a typed Go function. Its parameters are
+// the JSON schema of the input type, and its arguments are validated against
+// the schema and decoded into the input type before the function is called.
+type FunctionTool[In, Out any] struct {
+	name        string
+	description string
+	fn          func(context.Context, In) (Out, error)
+	schema      interfaces.JSONSchema
+	validator   *structuredoutput.Validator
+}
+
+// ArgumentError is returned by function tools and tools wrapped by
+// WithArgumentValidation when their arguments do not follow their schema. Its message is a JSON object with the validation error
+// and the expected schema, so that the model can correct its call.
+type ArgumentError struct {
+	// Tool is the name of the tool
+	Tool string
+
+	// Err is the validation or decoding error
+	Err error
+
+	schema interfaces.JSONSchema
+}
+
+func (e *ArgumentError) Error() string {
+	message, err := json.Marshal(map[string]interface{}{
+		"error":           fmt.Sprintf("invalid arguments for tool %s", e.Tool),
+		"details":         e.Err.Error(),
+		"expected_schema": e.schema,
+	})
+	if err != nil {
+		return fmt.Sprintf("invalid arguments for tool %s: %v", e.Tool, e.Err)
+	}
+	return string(message)
+}
+
+func (e *ArgumentError) Unwrap() error {
+	return e.Err
+}
+
+// NewFunctionTool creates a tool that calls fn with its arguments decoded
+// into In, a struct type whose schema is derived as described in
+// structuredoutput.SchemaOf, so that fields are documented with struct tags:
+//
+//	type WeatherInput struct {
+//	    City  string `json:"city" description:"City name"`
+//	    Units string `json:"units,omitempty" enum:"metric,imperial" default:"metric"`
+//	}
+//
+//	weather, err := tools.NewFunctionTool("weather", "Get the current weather",
+//	    func(ctx context.Context, in WeatherInput) (Weather, error) { ... })
+//
+// The output is returned as is when Out is a string and as JSON otherwise.
+// Arguments that do not follow the schema return an *ArgumentError without
+// calling fn. NewFunctionTool returns an error if In is not a struct or a
+// pointer to a struct.
+func NewFunctionTool[In, Out any](name, description string, fn func(ctx context.Context, input In) (Out, error)) (*FunctionTool[In, Out], error) {
+	var input In
+	t := reflect.TypeOf(input)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("input of function tool %s must be a struct, got %T", name, input)
+	}
+
+	schema := structuredoutput.SchemaOf(input)
+	validator, err := structuredoutput.NewValidator(schema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema for function tool %s: %w", name, err)
+	}
+
+	return &FunctionTool[In, Out]{
+		name:        name,
+		description: description,
+		fn:          fn,
+		schema:      schema,
+		validator:   validator,
+	}, nil
+}
+
+// MustNewFunctionTool is like NewFunctionTool but panics if the tool cannot be
+// created. It simplifies the creation of tools in package variables and setup
+// code whose input types are known to be structs.
+func MustNewFunctionTool[In, Out any](name, description string, fn func(ctx context.Context, input In) (Out, error)) *FunctionTool[In, Out] {
+	tool, err := NewFunctionTool(name, description, fn)
+	if err != nil {
+		panic("tools: " + err.Error())
+	}
+	return tool
+}
+
+// Name returns the name of the tool
+func (t *FunctionTool[In, Out]) Name() string {
+	return t.name
+}
+
+// Description returns the description of what the tool does
+func (t *FunctionTool[In, Out]) Description() string {
+	return t.description
+}
+
+// JSONSchema returns the JSON schema of the arguments of the tool
+func (t *FunctionTool[In, Out]) JSONSchema() interfaces.JSONSchema {
+	return t.schema
+}
+
+// Parameters returns the top-level properties of the schema of the tool.
+// Nested objects are described by JSONSchema.
+func (t *FunctionTool[In, Out]) Parameters() map[string]interfaces.ParameterSpec {
+	properties, _ := t.schema["properties"].(map[string]any)
+	required := map[string]bool{}
+	if names, ok := t.schema["required"].([]string); ok {
+		for _, name := range names {
+			required[name] = true
+		}
+	}
+
+	params := make(map[string]interfaces.ParameterSpec, len(properties))
+	for name, property := range properties {
+		spec := parameterSpec(property)
+		spec.Required = required[name]
+		params[name] = spec
+	}
+	return params
+}
+
+// Run executes the tool with JSON arguments, like Execute
+func (t *FunctionTool[In, Out]) Run(ctx context.Context, input string) (string, error) {
+	return t.Execute(ctx, input)
+}
+
+// Execute validates the JSON arguments, sets the defaults of missing optional
+// arguments, decodes them into the input type and calls the function
+func (t *FunctionTool[In, Out]) Execute(ctx context.Context, args string) (string, error) {
+	data := []byte(strings.TrimSpace(args))
+	if len(data) == 0 {
+		data = []byte("{}")
+	}
+
+	if err := t.validator.Validate(data); err != nil {
+		return "", &ArgumentError{Tool: t.name, Err: err, schema: t.schema}
+	}
+	data, err := t.validator.ApplyDefaults(data)
+	if err != nil {
+		return "", &ArgumentError{Tool: t.name, Err: err, schema: t.schema}
+	}
+
+	var input In
+	if err := json.Unmarshal(data, &input); err != nil {
+		return "", &ArgumentError{Tool: t.name, Err: err, schema: t.schema}
+	}
+
+	output, err := t.fn(ctx, input)
+	if err != nil {
+		return "", err
+	}
+
+	if text, ok := any(output).(string); ok {
+		return text, nil
+	}
+	result, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode output of tool %s: %w", t.name, err)
+	}
+	return string(result), nil
+}
+
+// parameterSpec converts a property schema to a parameter spec
+func parameterSpec(property any) interfaces.ParameterSpec {
+	schema, _ := property.(map[string]any)
+
+	spec := interfaces.ParameterSpec{
+		Default: schema["default"],
+	}
+	spec.Type, _ = schema["type"].(string)
+	if spec.Type == "" {
+		// Union and untyped values are described as objects
+		spec.Type = "object"
+	}
+	spec.Description, _ = schema["description"].(string)
+	spec.Enum, _ = schema["enum"].([]any)
+
+	if items, ok := schema["items"]; ok {
+		itemSpec := parameterSpec(items)
+		spec.Items = &itemSpec
+	}
+	return spec
+}
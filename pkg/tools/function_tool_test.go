@@ -0,0 +1,175 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+type searchFilter struct {
+	Field string `json:"field" description:"Field to filter on"`
+	Value string `json:"value"`
+}
+
+type searchInput struct {
+	Query   string         `json:"query" description:"Search query" minLength:"1"`
+	Limit   int            `json:"limit,omitempty" minimum:"1" maximum:"20" default:"5"`
+	Sort    string         `json:"sort,omitempty" enum:"relevance,date"`
+	Filters []searchFilter `json:"filters,omitempty"`
+}
+
+type searchOutput struct {
+	Results []string `json:"results"`
+	Limit   int      `json:"limit"`
+}
+
+func newSearchTool(calls *int) *FunctionTool[searchInput, searchOutput] {
+	return MustNewFunctionTool("search", "Search documents",
+		func(ctx context.Context, in searchInput) (searchOutput, error) {
+			*calls++
+			results := []string{in.Query}
+			for _, filter := range in.Filters {
+				results = append(results, filter.Field+"="+filter.Value)
+			}
+			return searchOutput{Results: results, Limit: in.Limit}, nil
+		})
+}
+
+func TestFunctionTool_Schema(t *testing.T) {
+	var calls int
+	tool := newSearchTool(&calls)
+
+	var _ interfaces.Tool = tool
+	if tool.Name() != "search" || tool.Description() != "Search documents" {
+		t.Errorf("unexpected name or description: %s, %s", tool.Name(), tool.Description())
+	}
+
+	// Nested objects are part of the full schema
+	schema := tool.JSONSchema()
+	filters := schema["properties"].(map[string]any)["filters"].(map[string]any)
+	filter := filters["items"].(map[string]any)
+	if filter["type"] != "object" || filter["properties"].(map[string]any)["field"] == nil {
+		t.Errorf("expected filter object schema, got %v", filter)
+	}
+
+	params := tool.Parameters()
+	if len(params) != 4 {
+		t.Fatalf("expected 4 parameters, got %d", len(params))
+	}
+	if query := params["query"]; query.Type != "string" || !query.Required || query.Description != "Search query" {
+		t.Errorf("unexpected query parameter: %+v", query)
+	}
+	if limit := params["limit"]; limit.Type != "integer" || limit.Required || limit.Default != int64(5) {
+		t.Errorf("unexpected limit parameter: %+v", limit)
+	}
+	if sort := params["sort"]; len(sort.Enum) != 2 {
+		t.Errorf("expected sort enum, got %+v", sort)
+	}
+	if items := params["filters"].Items; items == nil || items.Type != "object" {
+		t.Errorf("expected filters of objects, got %+v", params["filters"])
+	}
+}
+
+func TestFunctionTool_Execute(t *testing.T) {
+	var calls int
+	tool := newSearchTool(&calls)
+
+	result, err := tool.Execute(context.Background(), `{"query": "go", "filters": [{"field": "lang", "value": "en"}]}`)
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+
+	var output searchOutput
+	if err := json.Unmarshal([]byte(result), &output); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+	if strings.Join(output.Results, ",") != "go,lang=en" {
+		t.Errorf("unexpected results: %v", output.Results)
+	}
+	if output.Limit != 5 {
+		t.Errorf("expected the default limit, got %d", output.Limit)
+	}
+}
+
+func TestFunctionTool_InvalidArguments(t *testing.T) {
+	var calls int
+	tool := newSearchTool(&calls)
+
+	for _, args := range []string{
+		`{"limit": 3}`,
+		`{"query": "go", "limit": 50}`,
+		`{"query": "go", "sort": "popularity"}`,
+		`{"query": "go", "filters": [{"field": "lang"}]}`,
+		`not json`,
+	} {
+		_, err := tool.Execute(context.Background(), args)
+
+		var argErr *ArgumentError
+		if !errors.As(err, &argErr) {
+			t.Errorf("expected ArgumentError for %s, got %v", args, err)
+			continue
+		}
+
+		var message map[string]interface{}
+		if err := json.Unmarshal([]byte(argErr.Error()), &message); err != nil {
+			t.Errorf("expected a JSON error message, got %s", argErr.Error())
+			continue
+		}
+		if message["details"] == "" || message["expected_schema"] == nil {
+			t.Errorf("expected details and schema in error, got %v", message)
+		}
+	}
+
+	if calls != 0 {
+		t.Errorf("function should not be called with invalid arguments, called %d times", calls)
+	}
+}
+
+func TestFunctionTool_Output(t *testing.T) {
+	type greetInput struct {
+		Name string `json:"name"`
+	}
+
+	greet := MustNewFunctionTool("greet", "Greet someone",
+		func(ctx context.Context, in *greetInput) (string, error) {
+			return "Hello " + in.Name, nil
+		})
+	result, err := greet.Run(context.Background(), `{"name": "Ada"}`)
+	if err != nil || result != "Hello Ada" {
+		t.Errorf("expected string output as is, got %q, %v", result, err)
+	}
+
+	failure := errors.New("service unavailable")
+	failing := MustNewFunctionTool("failing", "Always fails",
+		func(ctx context.Context, in greetInput) (int, error) {
+			return 0, failure
+		})
+	if _, err := failing.Execute(context.Background(), `{"name": "Ada"}`); !errors.Is(err, failure) {
+		t.Errorf("expected the function error, got %v", err)
+	}
+}
+
+func TestNewFunctionTool_RequiresStruct(t *testing.T) {
+	echo := func(ctx context.Context, in string) (string, error) {
+		return in, nil
+	}
+
+	tool, err := NewFunctionTool("echo", "Echo", echo)
+	if err == nil || tool != nil {
+		t.Fatalf("expected an error for a non-struct input, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "must be a struct") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected MustNewFunctionTool to panic for a non-struct input")
+		}
+	}()
+	MustNewFunctionTool("echo", "Echo", echo)
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strconv"
 
 	"github.com/tagus/agent-sdk-go/pkg/graphrag"
 	"github.com/tagus/agent-sdk-go/pkg/interfaces"
@@ -14,12 +15,41 @@ import (
 
 // SearchTool implements graph-based search in the knowledge graph.
 type SearchTool struct {
-	store interfaces.GraphRAGStore
+	store      interfaces.GraphRAGStore
+	reranker   interfaces.Reranker
+	candidates int
+}
+
+// DefaultRerankCandidates is the default number of candidates fetched per
+// requested result when a reranker is configured.
+const DefaultRerankCandidates = 3
+
+// SearchToolOption configures a SearchTool.
+type SearchToolOption func(*SearchTool)
+
+// WithReranker reorders the graph search results with a reranker. Entities
+// are ranked by their name, type and description.
+func WithReranker(reranker interfaces.Reranker) SearchToolOption {
+	return func(t *SearchTool) {
+		t.reranker = reranker
+	}
+}
+
+// WithRerankCandidates sets how many candidates per requested result are
+// fetched for the reranker.
+func WithRerankCandidates(candidates int) SearchToolOption {
+	return func(t *SearchTool) {
+		t.candidates = candidates
+	}
 }
 
 // NewSearchTool creates a new search tool.
-func NewSearchTool(store interfaces.GraphRAGStore) *SearchTool {
-	return &SearchTool{store: store}
+func NewSearchTool(store interfaces.GraphRAGStore, options ...SearchToolOption) *SearchTool {
+	t := &SearchTool{store: store, candidates: DefaultRerankCandidates}
+	for _, option := range options {
+		option(t)
+	}
+	return t
 }
 
 // Name returns the tool name.
@@ -139,8 +169,12 @@ func (t *SearchTool) Execute(ctx context.Context, args string) (string, error) {
 		results, err = t.store.GlobalSearch(ctx, params.Query, 1, opts...)
 	default: // hybrid
 		// Standard hybrid search
+		limit := params.Limit
+		if t.reranker != nil && t.candidates > 1 {
+			limit *= t.candidates
+		}
 		opts = append(opts, graphrag.WithMode(graphrag.SearchModeHybrid))
-		results, err = t.store.Search(ctx, params.Query, params.Limit, opts...)
+		results, err = t.store.Search(ctx, params.Query, limit, opts...)
 	}
 
 	if err != nil {
@@ -148,6 +182,16 @@ func (t *SearchTool) Execute(ctx context.Context, args string) (string, error) {
 		return "", fmt.Errorf("search failed: %w", err)
 	}
 
+	if t.reranker != nil {
+		results, err = t.rerank(ctx, params.Query, results)
+		if err != nil {
+			return "", fmt.Errorf("rerank failed: %w", err)
+		}
+		if len(results) > params.Limit {
+			results = results[:params.Limit]
+		}
+	}
+
 	log.Printf("[graphrag_search] Found %d results", len(results))
 	for i, r := range results {
 		log.Printf("[graphrag_search] Result %d: ID=%s, Name=%s, Type=%s, OrgID=%s",
@@ -159,6 +203,38 @@ func (t *SearchTool) Execute(ctx context.Context, args string) (string, error) {
 	return output, nil
 }
 
+// rerank reorders graph results by reranking their entities as documents.
+func (t *SearchTool) rerank(ctx context.Context, query string, results []interfaces.GraphSearchResult) ([]interfaces.GraphSearchResult, error) {
+	candidates := make([]interfaces.SearchResult, len(results))
+	for i, r := range results {
+		candidates[i] = interfaces.SearchResult{
+			Document: interfaces.Document{
+				ID:      strconv.Itoa(i),
+				Content: graphrag.EntityEmbeddingText(r.Entity),
+				Vector:  r.Entity.Embedding,
+			},
+			Score: r.Score,
+		}
+	}
+
+	reranked, err := t.reranker.Rerank(ctx, query, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]interfaces.GraphSearchResult, 0, len(reranked))
+	for _, c := range reranked {
+		i, err := strconv.Atoi(c.Document.ID)
+		if err != nil || i < 0 || i >= len(results) {
+			continue
+		}
+		r := results[i]
+		r.Score = c.Score
+		output = append(output, r)
+	}
+	return output, nil
+}
+
 // formatSearchResults formats search results as JSON.
 func formatSearchResults(results []interfaces.GraphSearchResult) string {
 	type entityOutput struct {
@@ -0,0 +1,168 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/llm"
+	"github.com/tagus/agent-sdk-go/pkg/structuredoutput"
+)
+
+// argumentValidator is implemented by tools that validate their own
+// arguments, which are not wrapped by WithArgumentValidation
+type argumentValidator interface {
+	validatesArguments()
+}
+
+func (t *FunctionTool[In, Out]) validatesArguments() {}
+
+// jsonSchemaTypes are the type names of JSON schema
+var jsonSchemaTypes = map[string]bool{
+	"object": true, "array": true, "string": true, "number": true,
+	"integer": true, "boolean": true, "null": true,
+}
+
+// ValidateArguments checks that the JSON arguments of a tool call follow the
+// schema of the tool, as sent to the LLM. Empty arguments are validated as an
+// empty object. It returns an *ArgumentError for invalid arguments, and nil
+// when the schema of the tool cannot be compiled or uses type names that are
+// not JSON schema types, which some providers accept.
+func ValidateArguments(tool interfaces.Tool, args string) error {
+	schema, validator := newArgumentValidator(tool)
+	if validator == nil {
+		return nil
+	}
+	return validateArguments(tool.Name(), validator, schema, args)
+}
+
+// newArgumentValidator compiles the schema of a tool, and returns a nil
+// validator when the schema cannot be validated
+func newArgumentValidator(tool interfaces.Tool) (interfaces.JSONSchema, *structuredoutput.Validator) {
+	schema := interfaces.JSONSchema(llm.ToolSchema(tool))
+	if !hasValidTypes(schema) {
+		return schema, nil
+	}
+	validator, err := structuredoutput.NewValidator(schema)
+	if err != nil {
+		return schema, nil
+	}
+	return schema, validator
+}
+
+// hasValidTypes reports whether all the types of a schema and its subschemas
+// are JSON schema types
+func hasValidTypes(value interface{}) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if key == "type" {
+				if name, ok := child.(string); ok && !jsonSchemaTypes[name] {
+					return false
+				}
+			}
+			if !hasValidTypes(child) {
+				return false
+			}
+		}
+	case interfaces.JSONSchema:
+		return hasValidTypes(map[string]interface{}(v))
+	case []interface{}:
+		for _, child := range v {
+			if !hasValidTypes(child) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func validateArguments(name string, validator *structuredoutput.Validator, schema interfaces.JSONSchema, args string) error {
+	data := []byte(strings.TrimSpace(args))
+	if len(data) == 0 {
+		data = []byte("{}")
+	}
+	if err := validator.Validate(data); err != nil {
+		return &ArgumentError{Tool: name, Err: err, schema: schema}
+	}
+	return nil
+}
+
+// validatedTool validates the arguments of a tool before executing it
+type validatedTool struct {
+	interfaces.Tool
+
+	once      sync.Once
+	schema    interfaces.JSONSchema
+	validator *structuredoutput.Validator
+}
+
+// WithArgumentValidation wraps tools so that their arguments are validated
+// with ValidateArguments before Execute, and invalid arguments are
+// returned to the LLM as an *ArgumentError without executing the tool. The
+// wrappers keep the display name, internal flag and schema of the tools.
+// Function tools, which validate their own arguments, are not wrapped.
+func WithArgumentValidation(tools []interfaces.Tool) []interfaces.Tool {
+	wrapped := make([]interfaces.Tool, len(tools))
+	for i, tool := range tools {
+		if _, ok := tool.(argumentValidator); ok {
+			wrapped[i] = tool
+			continue
+		}
+		wrapped[i] = &validatedTool{Tool: tool}
+	}
+	return wrapped
+}
+
+func (t *validatedTool) validatesArguments() {}
+
+// validate compiles the schema of the tool on first use. Tools whose schema
+// cannot be validated are executed without validation.
+func (t *validatedTool) validate(args string) error {
+	t.once.Do(func() {
+		t.schema, t.validator = newArgumentValidator(t.Tool)
+	})
+	if t.validator == nil {
+		return nil
+	}
+	return validateArguments(t.Name(), t.validator, t.schema, args)
+}
+
+// Execute validates the arguments and executes the tool
+func (t *validatedTool) Execute(ctx context.Context, args string) (string, error) {
+	if err := t.validate(args); err != nil {
+		return "", err
+	}
+	return t.Tool.Execute(ctx, args)
+}
+
+// Unwrap returns the wrapped tool, so that interfaces implemented by it,
+// such as approval.ToolPolicy, are found through the wrapper
+func (t *validatedTool) Unwrap() interfaces.Tool {
+	return t.Tool
+}
+
+// DisplayName implements interfaces.ToolWithDisplayName.DisplayName
+func (t *validatedTool) DisplayName() string {
+	if tool, ok := t.Tool.(interfaces.ToolWithDisplayName); ok {
+		return tool.DisplayName()
+	}
+	return ""
+}
+
+// Internal implements interfaces.InternalTool.Internal
+func (t *validatedTool) Internal() bool {
+	if tool, ok := t.Tool.(interfaces.InternalTool); ok {
+		return tool.Internal()
+	}
+	return false
+}
+
+// JSONSchema implements interfaces.ToolWithJSONSchema.JSONSchema
+func (t *validatedTool) JSONSchema() interfaces.JSONSchema {
+	if tool, ok := t.Tool.(interfaces.ToolWithJSONSchema); ok {
+		return tool.JSONSchema()
+	}
+	return nil
+}
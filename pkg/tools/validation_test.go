@@ -0,0 +1,119 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// rangeTool describes its arguments with a full JSON schema
+type rangeTool struct {
+	calls  int
+	schema interfaces.JSONSchema
+	params map[string]interfaces.ParameterSpec
+}
+
+func (t *rangeTool) Name() string        { return "range" }
+func (t *rangeTool) Description() string { return "Read a range of lines" }
+func (t *rangeTool) DisplayName() string { return "Line Range" }
+func (t *rangeTool) Run(ctx context.Context, input string) (string, error) {
+	return t.Execute(ctx, input)
+}
+func (t *rangeTool) Parameters() map[string]interfaces.ParameterSpec { return t.params }
+func (t *rangeTool) Execute(ctx context.Context, args string) (string, error) {
+	t.calls++
+	return "ok", nil
+}
+func (t *rangeTool) JSONSchema() interfaces.JSONSchema { return t.schema }
+
+func newRangeTool() *rangeTool {
+	return &rangeTool{schema: interfaces.JSONSchema{
+		"type":     "object",
+		"required": []interface{}{"file", "lines"},
+		"properties": map[string]interface{}{
+			"file": map[string]interface{}{"type": "string", "pattern": `\.go$`},
+			"lines": map[string]interface{}{
+				"type":     "object",
+				"required": []interface{}{"start"},
+				"properties": map[string]interface{}{
+					"start": map[string]interface{}{"type": "integer", "minimum": 1},
+					"end":   map[string]interface{}{"type": "integer", "minimum": 1},
+				},
+			},
+		},
+	}}
+}
+
+func TestWithArgumentValidation(t *testing.T) {
+	tool := newRangeTool()
+	wrapped := WithArgumentValidation([]interfaces.Tool{tool})[0]
+
+	if _, err := wrapped.Execute(context.Background(), `{"file": "main.go", "lines": {"start": 3}}`); err != nil {
+		t.Fatalf("Execute returned error for valid arguments: %v", err)
+	}
+
+	for _, args := range []string{
+		`{"file": "main.go"}`,
+		`{"file": "main.py", "lines": {"start": 3}}`,
+		`{"file": "main.go", "lines": {"start": 0}}`,
+		`{"file": "main.go", "lines": {"end": 3}}`,
+		``,
+	} {
+		_, err := wrapped.Execute(context.Background(), args)
+		var argErr *ArgumentError
+		if !errors.As(err, &argErr) || argErr.Tool != "range" {
+			t.Errorf("expected ArgumentError for %q, got %v", args, err)
+		}
+	}
+
+	if tool.calls != 1 {
+		t.Errorf("expected the tool to run for valid arguments only, ran %d times", tool.calls)
+	}
+
+	// The wrapper keeps the optional interfaces of the tool
+	if name := wrapped.(interfaces.ToolWithDisplayName).DisplayName(); name != "Line Range" {
+		t.Errorf("expected the display name of the tool, got %q", name)
+	}
+	if wrapped.(interfaces.ToolWithJSONSchema).JSONSchema() == nil {
+		t.Error("expected the schema of the tool")
+	}
+}
+
+func TestWithArgumentValidation_Parameters(t *testing.T) {
+	tool := &rangeTool{params: map[string]interfaces.ParameterSpec{
+		"mode": {Type: "string", Required: true, Enum: []interface{}{"fast", "full"}},
+	}}
+	wrapped := WithArgumentValidation([]interfaces.Tool{tool})[0]
+
+	if _, err := wrapped.Execute(context.Background(), `{"mode": "fast"}`); err != nil {
+		t.Errorf("Execute returned error for valid arguments: %v", err)
+	}
+	if _, err := wrapped.Execute(context.Background(), `{"mode": "slow"}`); err == nil {
+		t.Error("expected an error for a value outside the enum")
+	}
+
+	// Type names that are not JSON schema types are not validated
+	legacy := &rangeTool{params: map[string]interfaces.ParameterSpec{
+		"count": {Type: "int", Required: true},
+	}}
+	if err := ValidateArguments(legacy, `{"count": 3}`); err != nil {
+		t.Errorf("expected no validation of a legacy schema, got %v", err)
+	}
+}
+
+func TestWithArgumentValidation_SkipsFunctionTools(t *testing.T) {
+	var calls int
+	tool := newSearchTool(&calls)
+
+	wrapped := WithArgumentValidation([]interfaces.Tool{tool})
+	if wrapped[0] != interfaces.Tool(tool) {
+		t.Error("expected function tools not to be wrapped")
+	}
+
+	validated := WithArgumentValidation([]interfaces.Tool{newRangeTool()})
+	if again := WithArgumentValidation(validated); again[0] != validated[0] {
+		t.Error("expected validated tools not to be wrapped twice")
+	}
+}
@@ -0,0 +1,304 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/tagus/agent-sdk-go/pkg/embedding"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// BM25 tuning parameters
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// searchMode identifies how candidate documents are scored
+type searchMode int
+
+const (
+	modeVector searchMode = iota
+	modeBM25
+	modeHybrid
+	modeKeyword
+)
+
+// search scores the documents of a tenant against a query or vector and returns the top results
+func (s *Store) search(ctx context.Context, tenant, query string, vector []float32, limit int, opts *interfaces.SearchOptions) ([]interfaces.SearchResult, error) {
+	mode := s.searchMode(vector, opts)
+
+	// Embed the query outside the lock since it may call a remote service
+	if vector == nil && (mode == modeVector || mode == modeHybrid) {
+		if s.embedder == nil {
+			return nil, fmt.Errorf("cannot embed query: %w", ErrNoEmbedder)
+		}
+		queryVector, err := s.embedder.Embed(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed query: %w", err)
+		}
+		vector = queryVector
+	}
+
+	s.mu.RLock()
+	candidates := make([]interfaces.Document, 0, len(s.tenants[tenant][s.className(opts.Class)]))
+	for _, doc := range s.tenants[tenant][s.className(opts.Class)] {
+		candidates = append(candidates, doc)
+	}
+	s.mu.RUnlock()
+
+	if len(opts.Filters) > 0 {
+		candidates = embedding.ApplyFilters(candidates, embedding.FilterFromMap(opts.Filters))
+	}
+	if mode == modeVector || mode == modeHybrid {
+		candidates = s.matchingDimension(ctx, candidates, vector)
+	}
+	if len(candidates) == 0 {
+		return []interfaces.SearchResult{}, nil
+	}
+
+	var scores []float32
+	var err error
+	switch mode {
+	case modeVector:
+		scores, err = s.vectorScores(candidates, vector)
+	case modeBM25:
+		scores = bm25Scores(candidates, query)
+	case modeKeyword:
+		scores = keywordScores(candidates, query)
+	case modeHybrid:
+		var vectorScores []float32
+		vectorScores, err = s.vectorScores(candidates, vector)
+		if err == nil {
+			keywordScores := bm25Scores(candidates, query)
+			scores = make([]float32, len(candidates))
+			for i := range candidates {
+				scores[i] = s.hybridAlpha*vectorScores[i] + (1-s.hybridAlpha)*keywordScores[i]
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]interfaces.SearchResult, 0, len(candidates))
+	for i, doc := range candidates {
+		// Lexical modes only return documents that share at least one term with the query
+		if (mode == modeBM25 || mode == modeKeyword) && scores[i] <= 0 {
+			continue
+		}
+		if scores[i] < opts.MinScore {
+			continue
+		}
+		results = append(results, interfaces.SearchResult{
+			Document: copyDocument(doc, opts.Fields),
+			Score:    scores[i],
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score == results[j].Score {
+			return results[i].Document.ID < results[j].Document.ID
+		}
+		return results[i].Score > results[j].Score
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+// searchMode selects the scoring mode from the search options. Without an
+// embedder, text queries fall back to BM25 so the store remains usable in tests.
+func (s *Store) searchMode(vector []float32, opts *interfaces.SearchOptions) searchMode {
+	if vector != nil {
+		return modeVector
+	}
+
+	wantsVector := opts.UseEmbedding || opts.UseNearText
+	switch {
+	case opts.UseBM25 && wantsVector:
+		return modeHybrid
+	case opts.UseBM25:
+		return modeBM25
+	case opts.UseKeyword:
+		return modeKeyword
+	case wantsVector || s.embedder != nil:
+		return modeVector
+	default:
+		return modeBM25
+	}
+}
+
+// matchingDimension drops the candidates whose vector has another dimension
+// than the query vector, such as documents embedded with a previous model,
+// so that they do not fail the search. The skipped documents are logged.
+func (s *Store) matchingDimension(ctx context.Context, candidates []interfaces.Document, vector []float32) []interfaces.Document {
+	matching := candidates[:0]
+	var skipped []string
+	for _, doc := range candidates {
+		if len(doc.Vector) > 0 && len(doc.Vector) != len(vector) {
+			skipped = append(skipped, doc.ID)
+			continue
+		}
+		matching = append(matching, doc)
+	}
+	if len(skipped) > 0 {
+		s.logger.Warn(ctx, "Skipped documents with a vector dimension mismatch", map[string]interface{}{
+			"expected_dimension": len(vector),
+			"documents":          skipped,
+		})
+	}
+	return matching
+}
+
+// vectorScores computes the similarity of every candidate to the query vector.
+// Documents without a vector score zero.
+func (s *Store) vectorScores(candidates []interfaces.Document, vector []float32) ([]float32, error) {
+	metric := similarityMetric(s.config.DistanceMetric)
+	scores := make([]float32, len(candidates))
+
+	for i, doc := range candidates {
+		if len(doc.Vector) == 0 {
+			continue
+		}
+		score, err := embedding.CalculateSimilarity(vector, doc.Vector, metric)
+		if err != nil {
+			return nil, err
+		}
+		scores[i] = score
+	}
+
+	return scores, nil
+}
+
+// similarityMetric maps a VectorStoreConfig distance metric to an embedding similarity metric
+func similarityMetric(distanceMetric string) string {
+	switch strings.ToLower(distanceMetric) {
+	case "dot", "dot_product", "inner_product":
+		return "dot_product"
+	case "euclidean", "l2":
+		return "euclidean"
+	default:
+		return "cosine"
+	}
+}
+
+// bm25Scores scores candidates with Okapi BM25, normalized to 0-1 by the best match
+func bm25Scores(candidates []interfaces.Document, query string) []float32 {
+	scores := make([]float32, len(candidates))
+	queryTerms := uniqueTerms(tokenize(query))
+	if len(queryTerms) == 0 {
+		return scores
+	}
+
+	docTerms := make([]map[string]int, len(candidates))
+	docLengths := make([]int, len(candidates))
+	documentFrequency := make(map[string]int)
+	totalLength := 0
+
+	for i, doc := range candidates {
+		tokens := tokenize(doc.Content)
+		docLengths[i] = len(tokens)
+		totalLength += len(tokens)
+
+		frequencies := make(map[string]int)
+		for _, token := range tokens {
+			frequencies[token]++
+		}
+		docTerms[i] = frequencies
+
+		for _, term := range queryTerms {
+			if frequencies[term] > 0 {
+				documentFrequency[term]++
+			}
+		}
+	}
+
+	n := float64(len(candidates))
+	avgLength := float64(totalLength) / n
+	if avgLength == 0 {
+		return scores
+	}
+
+	var maxScore float64
+	raw := make([]float64, len(candidates))
+	for i := range candidates {
+		var score float64
+		for _, term := range queryTerms {
+			tf := float64(docTerms[i][term])
+			if tf == 0 {
+				continue
+			}
+			df := float64(documentFrequency[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			norm := tf + bm25K1*(1-bm25B+bm25B*float64(docLengths[i])/avgLength)
+			score += idf * (tf * (bm25K1 + 1)) / norm
+		}
+		raw[i] = score
+		if score > maxScore {
+			maxScore = score
+		}
+	}
+
+	if maxScore == 0 {
+		return scores
+	}
+	for i, score := range raw {
+		scores[i] = float32(score / maxScore)
+	}
+
+	return scores
+}
+
+// keywordScores scores candidates by the fraction of query terms they contain
+func keywordScores(candidates []interfaces.Document, query string) []float32 {
+	scores := make([]float32, len(candidates))
+	queryTerms := uniqueTerms(tokenize(query))
+	if len(queryTerms) == 0 {
+		return scores
+	}
+
+	for i, doc := range candidates {
+		present := make(map[string]bool)
+		for _, token := range tokenize(doc.Content) {
+			present[token] = true
+		}
+
+		matched := 0
+		for _, term := range queryTerms {
+			if present[term] {
+				matched++
+			}
+		}
+		scores[i] = float32(matched) / float32(len(queryTerms))
+	}
+
+	return scores
+}
+
+// tokenize lowercases text and splits it into alphanumeric terms
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// uniqueTerms removes duplicate terms while preserving order
+func uniqueTerms(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	unique := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			unique = append(unique, term)
+		}
+	}
+	return unique
+}
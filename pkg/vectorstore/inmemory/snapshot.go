@@ -0,0 +1,139 @@
+package inmemory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// snapshotVersion is incremented when the snapshot format changes incompatibly
+const snapshotVersion = 1
+
+// snapshot is the on-disk representation of the store
+type snapshot struct {
+	Version int                                               `json:"version"`
+	Tenants map[string]map[string]map[string]snapshotDocument `json:"tenants"`
+}
+
+// snapshotDocument is the on-disk representation of a document
+type snapshotDocument struct {
+	Content  string                 `json:"content"`
+	Vector   []float32              `json:"vector,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// SaveSnapshot writes the contents of the store to a file.
+// The file is written atomically by renaming a temporary file into place.
+func (s *Store) SaveSnapshot(path string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.writeSnapshotLocked(path)
+}
+
+// LoadSnapshot replaces the contents of the store with a snapshot file.
+// A missing file is not an error and leaves the store empty.
+// Metadata values are restored through JSON, so numbers are returned as float64.
+func (s *Store) LoadSnapshot(path string) error {
+	data, err := os.ReadFile(path) // #nosec G304 - Path is provided by the store owner
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version: %d", snap.Version)
+	}
+
+	tenants := map[string]map[string]map[string]interfaces.Document{
+		globalTenant: {},
+	}
+	documentCount := 0
+	for tenant, classes := range snap.Tenants {
+		tenants[tenant] = make(map[string]map[string]interfaces.Document, len(classes))
+		for class, docs := range classes {
+			tenants[tenant][class] = make(map[string]interfaces.Document, len(docs))
+			for id, doc := range docs {
+				tenants[tenant][class][id] = interfaces.Document{
+					ID:       id,
+					Content:  doc.Content,
+					Vector:   doc.Vector,
+					Metadata: doc.Metadata,
+				}
+				documentCount++
+			}
+		}
+	}
+
+	s.mu.Lock()
+	s.tenants = tenants
+	s.mu.Unlock()
+
+	s.logger.Debug(context.Background(), "Loaded vector store snapshot", map[string]interface{}{
+		"path":      path,
+		"tenants":   len(tenants) - 1,
+		"documents": documentCount,
+	})
+
+	return nil
+}
+
+// writeSnapshotLocked serializes the store to path. Callers must hold the lock.
+func (s *Store) writeSnapshotLocked(path string) error {
+	snap := snapshot{
+		Version: snapshotVersion,
+		Tenants: make(map[string]map[string]map[string]snapshotDocument, len(s.tenants)),
+	}
+	for tenant, classes := range s.tenants {
+		snap.Tenants[tenant] = make(map[string]map[string]snapshotDocument, len(classes))
+		for class, docs := range classes {
+			snap.Tenants[tenant][class] = make(map[string]snapshotDocument, len(docs))
+			for id, doc := range docs {
+				snap.Tenants[tenant][class][id] = snapshotDocument{
+					Content:  doc.Content,
+					Vector:   doc.Vector,
+					Metadata: doc.Metadata,
+				}
+			}
+		}
+	}
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("failed to serialize snapshot: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, ".vectorstore-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,435 @@
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/tagus/agent-sdk-go/pkg/embedding"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/logging"
+	"github.com/tagus/agent-sdk-go/pkg/multitenancy"
+)
+
+const (
+	// DefaultClass is the class used when no class is specified
+	DefaultClass = "Document"
+
+	// globalTenant is the namespace used for shared data and requests without a tenant
+	globalTenant = ""
+
+	// defaultHybridAlpha weights vector and BM25 scores equally in hybrid search
+	defaultHybridAlpha = 0.5
+)
+
+var (
+	// ErrTenantNotFound is returned when a tenant does not exist
+	ErrTenantNotFound = errors.New("tenant not found")
+
+	// ErrDocumentNotFound is returned when a document does not exist
+	ErrDocumentNotFound = errors.New("document not found")
+
+	// ErrNoEmbedder is returned when an operation requires an embedder but none is configured
+	ErrNoEmbedder = errors.New("no embedder configured")
+)
+
+// Store implements interfaces.VectorStore entirely in process memory.
+// Documents are partitioned by tenant and class, and can optionally be
+// snapshotted to a file so that they survive restarts.
+type Store struct {
+	mu           sync.RWMutex
+	config       interfaces.VectorStoreConfig
+	embedder     embedding.Client
+	logger       logging.Logger
+	snapshotPath string
+	hybridAlpha  float32
+
+	// tenants maps tenant name -> class name -> document ID -> document
+	tenants map[string]map[string]map[string]interfaces.Document
+}
+
+// Option represents an option for configuring the store
+type Option func(*Store)
+
+// WithEmbedder sets the embedding client used to generate document and query vectors
+func WithEmbedder(embedder embedding.Client) Option {
+	return func(s *Store) {
+		s.embedder = embedder
+	}
+}
+
+// WithLogger sets the logger for the store
+func WithLogger(logger logging.Logger) Option {
+	return func(s *Store) {
+		s.logger = logger
+	}
+}
+
+// WithSnapshotPath enables persistence to the given file. The snapshot is loaded
+// when the store is created and rewritten after every mutation.
+func WithSnapshotPath(path string) Option {
+	return func(s *Store) {
+		s.snapshotPath = path
+	}
+}
+
+// WithHybridAlpha sets the weight of the vector score in hybrid search (0-1).
+// The BM25 score is weighted by 1-alpha.
+func WithHybridAlpha(alpha float32) Option {
+	return func(s *Store) {
+		if alpha >= 0 && alpha <= 1 {
+			s.hybridAlpha = alpha
+		}
+	}
+}
+
+// New creates a new in-memory vector store. The config may be nil.
+func New(config *interfaces.VectorStoreConfig, options ...Option) (*Store, error) {
+	s := &Store{
+		logger:      logging.New(),
+		hybridAlpha: defaultHybridAlpha,
+		tenants: map[string]map[string]map[string]interfaces.Document{
+			globalTenant: {},
+		},
+	}
+
+	if config != nil {
+		s.config = *config
+	}
+	if s.config.DistanceMetric == "" {
+		s.config.DistanceMetric = "cosine"
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	if s.snapshotPath != "" {
+		if err := s.LoadSnapshot(s.snapshotPath); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Store stores documents in the tenant resolved from the options or context
+func (s *Store) Store(ctx context.Context, documents []interfaces.Document, options ...interfaces.StoreOption) error {
+	opts := applyStoreOptions(options)
+	return s.store(ctx, s.resolveTenant(ctx, opts.Tenant), documents, opts)
+}
+
+// GlobalStore stores documents in the shared namespace, ignoring tenant context
+func (s *Store) GlobalStore(ctx context.Context, documents []interfaces.Document, options ...interfaces.StoreOption) error {
+	return s.store(ctx, globalTenant, documents, applyStoreOptions(options))
+}
+
+// Get retrieves a document by ID
+func (s *Store) Get(ctx context.Context, id string, options ...interfaces.StoreOption) (*interfaces.Document, error) {
+	opts := applyStoreOptions(options)
+	tenant := s.resolveTenant(ctx, opts.Tenant)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	classes, ok := s.tenants[tenant]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTenantNotFound, tenant)
+	}
+
+	doc, ok := classes[s.className(opts.Class)][id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrDocumentNotFound, id)
+	}
+
+	result := copyDocument(doc, nil)
+	return &result, nil
+}
+
+// Search searches for documents matching the query in the resolved tenant
+func (s *Store) Search(ctx context.Context, query string, limit int, options ...interfaces.SearchOption) ([]interfaces.SearchResult, error) {
+	opts := applySearchOptions(options)
+	return s.search(ctx, s.resolveTenant(ctx, opts.Tenant), query, nil, limit, opts)
+}
+
+// SearchByVector searches for documents similar to the vector in the resolved tenant
+func (s *Store) SearchByVector(ctx context.Context, vector []float32, limit int, options ...interfaces.SearchOption) ([]interfaces.SearchResult, error) {
+	opts := applySearchOptions(options)
+	return s.search(ctx, s.resolveTenant(ctx, opts.Tenant), "", vector, limit, opts)
+}
+
+// GlobalSearch searches the shared namespace, ignoring tenant context
+func (s *Store) GlobalSearch(ctx context.Context, query string, limit int, options ...interfaces.SearchOption) ([]interfaces.SearchResult, error) {
+	return s.search(ctx, globalTenant, query, nil, limit, applySearchOptions(options))
+}
+
+// GlobalSearchByVector searches the shared namespace by vector, ignoring tenant context
+func (s *Store) GlobalSearchByVector(ctx context.Context, vector []float32, limit int, options ...interfaces.SearchOption) ([]interfaces.SearchResult, error) {
+	return s.search(ctx, globalTenant, "", vector, limit, applySearchOptions(options))
+}
+
+// Delete deletes documents by ID from the resolved tenant
+func (s *Store) Delete(ctx context.Context, ids []string, options ...interfaces.DeleteOption) error {
+	opts := applyDeleteOptions(options)
+	return s.delete(s.resolveTenant(ctx, opts.Tenant), ids, opts)
+}
+
+// GlobalDelete deletes documents by ID from the shared namespace
+func (s *Store) GlobalDelete(ctx context.Context, ids []string, options ...interfaces.DeleteOption) error {
+	return s.delete(globalTenant, ids, applyDeleteOptions(options))
+}
+
+// CreateTenant creates a tenant. Creating an existing tenant is a no-op.
+func (s *Store) CreateTenant(ctx context.Context, tenantName string) error {
+	if tenantName == "" {
+		return errors.New("tenant name cannot be empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.tenants[tenantName]; exists {
+		return nil
+	}
+	s.tenants[tenantName] = map[string]map[string]interfaces.Document{}
+
+	return s.persistLocked()
+}
+
+// DeleteTenant deletes a tenant and all of its documents
+func (s *Store) DeleteTenant(ctx context.Context, tenantName string) error {
+	if tenantName == "" {
+		return errors.New("tenant name cannot be empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.tenants[tenantName]; !exists {
+		return fmt.Errorf("%w: %s", ErrTenantNotFound, tenantName)
+	}
+	delete(s.tenants, tenantName)
+
+	return s.persistLocked()
+}
+
+// ListTenants returns the names of all tenants in sorted order
+func (s *Store) ListTenants(ctx context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tenants := make([]string, 0, len(s.tenants))
+	for name := range s.tenants {
+		if name != globalTenant {
+			tenants = append(tenants, name)
+		}
+	}
+	sort.Strings(tenants)
+
+	return tenants, nil
+}
+
+// Count returns the number of documents stored for a tenant and class.
+// An empty tenant refers to the shared namespace.
+func (s *Store) Count(tenant, class string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.tenants[tenant][s.className(class)])
+}
+
+// store generates missing vectors and writes documents to the given tenant
+func (s *Store) store(ctx context.Context, tenant string, documents []interfaces.Document, opts *interfaces.StoreOptions) error {
+	if len(documents) == 0 {
+		return nil
+	}
+
+	docs := make([]interfaces.Document, len(documents))
+	for i, doc := range documents {
+		docs[i] = copyDocument(doc, nil)
+		if docs[i].ID == "" {
+			docs[i].ID = uuid.New().String()
+		}
+	}
+
+	if err := s.generateVectors(ctx, docs, opts); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	classes, ok := s.tenants[tenant]
+	if !ok {
+		classes = map[string]map[string]interfaces.Document{}
+		s.tenants[tenant] = classes
+	}
+
+	class := s.className(opts.Class)
+	if classes[class] == nil {
+		classes[class] = map[string]interfaces.Document{}
+	}
+	for _, doc := range docs {
+		classes[class][doc.ID] = doc
+	}
+
+	return s.persistLocked()
+}
+
+// generateVectors fills in vectors for documents that lack one. When
+// GenerateVectors is set, every document is re-embedded and an embedder is required.
+func (s *Store) generateVectors(ctx context.Context, docs []interfaces.Document, opts *interfaces.StoreOptions) error {
+	if s.embedder == nil {
+		if opts.GenerateVectors {
+			return fmt.Errorf("cannot generate vectors: %w", ErrNoEmbedder)
+		}
+		return nil
+	}
+
+	var pending []int
+	for i, doc := range docs {
+		if opts.GenerateVectors || len(doc.Vector) == 0 {
+			pending = append(pending, i)
+		}
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = len(pending)
+	}
+
+	for start := 0; start < len(pending); start += batchSize {
+		end := start + batchSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+
+		texts := make([]string, 0, end-start)
+		for _, idx := range pending[start:end] {
+			texts = append(texts, docs[idx].Content)
+		}
+
+		vectors, err := s.embedder.EmbedBatch(ctx, texts)
+		if err != nil {
+			return fmt.Errorf("failed to generate vectors: %w", err)
+		}
+		if len(vectors) != len(texts) {
+			return fmt.Errorf("embedder returned %d vectors for %d documents", len(vectors), len(texts))
+		}
+
+		for i, idx := range pending[start:end] {
+			docs[idx].Vector = vectors[i]
+		}
+	}
+
+	return nil
+}
+
+// delete removes documents from the given tenant
+func (s *Store) delete(tenant string, ids []string, opts *interfaces.DeleteOptions) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	classes, ok := s.tenants[tenant]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrTenantNotFound, tenant)
+	}
+
+	docs := classes[s.className(opts.Class)]
+	for _, id := range ids {
+		delete(docs, id)
+	}
+
+	return s.persistLocked()
+}
+
+// resolveTenant determines the tenant for an operation. An explicit tenant wins,
+// then the organization ID from the context, then the shared namespace.
+func (s *Store) resolveTenant(ctx context.Context, tenant string) string {
+	if tenant != "" {
+		return tenant
+	}
+	if orgID, err := multitenancy.GetOrgID(ctx); err == nil {
+		return orgID
+	}
+	return globalTenant
+}
+
+// className returns the fully qualified class name, applying defaults and prefix
+func (s *Store) className(class string) string {
+	if class == "" {
+		class = DefaultClass
+	}
+	if s.config.ClassPrefix != "" && !strings.HasPrefix(class, s.config.ClassPrefix) {
+		class = s.config.ClassPrefix + class
+	}
+	return class
+}
+
+// persistLocked writes a snapshot if persistence is enabled. Callers must hold the write lock.
+func (s *Store) persistLocked() error {
+	if s.snapshotPath == "" {
+		return nil
+	}
+	return s.writeSnapshotLocked(s.snapshotPath)
+}
+
+// copyDocument returns a copy of the document so callers cannot mutate stored state.
+// If fields is non-empty, only those metadata keys are copied.
+func copyDocument(doc interfaces.Document, fields []string) interfaces.Document {
+	result := interfaces.Document{
+		ID:      doc.ID,
+		Content: doc.Content,
+	}
+
+	if doc.Vector != nil {
+		result.Vector = make([]float32, len(doc.Vector))
+		copy(result.Vector, doc.Vector)
+	}
+
+	if doc.Metadata != nil {
+		result.Metadata = make(map[string]interface{}, len(doc.Metadata))
+		if len(fields) == 0 {
+			for k, v := range doc.Metadata {
+				result.Metadata[k] = v
+			}
+		} else {
+			for _, field := range fields {
+				if v, ok := doc.Metadata[field]; ok {
+					result.Metadata[field] = v
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+func applyStoreOptions(options []interfaces.StoreOption) *interfaces.StoreOptions {
+	opts := &interfaces.StoreOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+	return opts
+}
+
+func applySearchOptions(options []interfaces.SearchOption) *interfaces.SearchOptions {
+	opts := &interfaces.SearchOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+	return opts
+}
+
+func applyDeleteOptions(options []interfaces.DeleteOption) *interfaces.DeleteOptions {
+	opts := &interfaces.DeleteOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+	return opts
+}
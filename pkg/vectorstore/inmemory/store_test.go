@@ -0,0 +1,312 @@
+package inmemory
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tagus/agent-sdk-go/pkg/embedding"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/multitenancy"
+)
+
+// keywordEmbedder produces one dimension per known keyword so similarity is predictable
+type keywordEmbedder struct {
+	keywords []string
+	calls    int
+}
+
+func (e *keywordEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	e.calls++
+	vector := make([]float32, len(e.keywords))
+	lower := strings.ToLower(text)
+	for i, keyword := range e.keywords {
+		if strings.Contains(lower, keyword) {
+			vector[i] = 1
+		}
+	}
+	return vector, nil
+}
+
+func (e *keywordEmbedder) EmbedWithConfig(ctx context.Context, text string, config embedding.EmbeddingConfig) ([]float32, error) {
+	return e.Embed(ctx, text)
+}
+
+func (e *keywordEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i], _ = e.Embed(ctx, text)
+	}
+	return vectors, nil
+}
+
+func (e *keywordEmbedder) EmbedBatchWithConfig(ctx context.Context, texts []string, config embedding.EmbeddingConfig) ([][]float32, error) {
+	return e.EmbedBatch(ctx, texts)
+}
+
+func (e *keywordEmbedder) CalculateSimilarity(vec1, vec2 []float32, metric string) (float32, error) {
+	return embedding.CalculateSimilarity(vec1, vec2, metric)
+}
+
+func testDocuments() []interfaces.Document {
+	return []interfaces.Document{
+		{ID: "go", Content: "Go is a language with goroutines and channels", Metadata: map[string]interface{}{"topic": "programming", "year": 2009}},
+		{ID: "rust", Content: "Rust is a language with ownership and borrowing", Metadata: map[string]interface{}{"topic": "programming", "year": 2015}},
+		{ID: "tea", Content: "Green tea is brewed at low temperature", Metadata: map[string]interface{}{"topic": "cooking", "year": 2020}},
+	}
+}
+
+func newTestStore(t *testing.T, options ...Option) *Store {
+	t.Helper()
+	store, err := New(nil, options...)
+	require.NoError(t, err)
+	return store
+}
+
+func TestStore_ImplementsVectorStore(t *testing.T) {
+	var store interfaces.VectorStore = newTestStore(t)
+	assert.NotNil(t, store)
+}
+
+func TestStore_StoreGetDelete(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	require.NoError(t, store.Store(ctx, testDocuments()))
+	assert.Equal(t, 3, store.Count("", ""))
+
+	doc, err := store.Get(ctx, "go")
+	require.NoError(t, err)
+	assert.Equal(t, "programming", doc.Metadata["topic"])
+
+	// Returned documents must not alias stored state
+	doc.Metadata["topic"] = "changed"
+	doc, err = store.Get(ctx, "go")
+	require.NoError(t, err)
+	assert.Equal(t, "programming", doc.Metadata["topic"])
+
+	require.NoError(t, store.Delete(ctx, []string{"go"}))
+	_, err = store.Get(ctx, "go")
+	assert.ErrorIs(t, err, ErrDocumentNotFound)
+}
+
+func TestStore_GeneratesIDs(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	require.NoError(t, store.Store(ctx, []interfaces.Document{{Content: "no id"}}))
+	results, err := store.Search(ctx, "id", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.NotEmpty(t, results[0].Document.ID)
+}
+
+func TestStore_SearchWithoutEmbedderUsesBM25(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	require.NoError(t, store.Store(ctx, testDocuments()))
+
+	results, err := store.Search(ctx, "ownership borrowing", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "rust", results[0].Document.ID)
+	assert.InDelta(t, 1.0, results[0].Score, 0.001)
+
+	_, err = store.Search(ctx, "tea", 10, interfaces.WithEmbedding(true))
+	assert.ErrorIs(t, err, ErrNoEmbedder)
+}
+
+func TestStore_VectorSearch(t *testing.T) {
+	ctx := context.Background()
+	embedder := &keywordEmbedder{keywords: []string{"language", "goroutines", "tea"}}
+	store := newTestStore(t, WithEmbedder(embedder))
+	require.NoError(t, store.Store(ctx, testDocuments()))
+
+	results, err := store.Search(ctx, "goroutines language", 2)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "go", results[0].Document.ID)
+	assert.Equal(t, "rust", results[1].Document.ID)
+	assert.Greater(t, results[0].Score, results[1].Score)
+
+	results, err = store.SearchByVector(ctx, []float32{0, 0, 1}, 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "tea", results[0].Document.ID)
+}
+
+func TestStore_VectorSearchSkipsDimensionMismatch(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	require.NoError(t, store.Store(ctx, []interfaces.Document{
+		{ID: "current", Content: "current model", Vector: []float32{1, 0, 0}},
+		{ID: "previous", Content: "previous model", Vector: []float32{1, 0}},
+	}))
+
+	results, err := store.SearchByVector(ctx, []float32{1, 0, 0}, 10)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "current", results[0].Document.ID)
+}
+
+func TestStore_GenerateVectorsRequiresEmbedder(t *testing.T) {
+	store := newTestStore(t)
+	err := store.Store(context.Background(), testDocuments(), interfaces.WithGenerateVectors(true))
+	assert.ErrorIs(t, err, ErrNoEmbedder)
+}
+
+func TestStore_GenerateVectorsBatches(t *testing.T) {
+	ctx := context.Background()
+	embedder := &keywordEmbedder{keywords: []string{"language"}}
+	store := newTestStore(t, WithEmbedder(embedder))
+
+	docs := testDocuments()
+	docs[0].Vector = []float32{0.5}
+	require.NoError(t, store.Store(ctx, docs, interfaces.WithBatchSize(1)))
+	assert.Equal(t, 2, embedder.calls, "documents with vectors should not be re-embedded")
+
+	doc, err := store.Get(ctx, "go")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{0.5}, doc.Vector)
+
+	require.NoError(t, store.Store(ctx, docs, interfaces.WithGenerateVectors(true)))
+	doc, err = store.Get(ctx, "go")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{1}, doc.Vector)
+}
+
+func TestStore_MinScoreAndFilters(t *testing.T) {
+	ctx := context.Background()
+	embedder := &keywordEmbedder{keywords: []string{"language", "goroutines", "tea"}}
+	store := newTestStore(t, WithEmbedder(embedder))
+	require.NoError(t, store.Store(ctx, testDocuments()))
+
+	results, err := store.Search(ctx, "goroutines language", 10, interfaces.WithMinScore(0.9))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "go", results[0].Document.ID)
+
+	filter := embedding.NewMetadataFilterGroup("and",
+		embedding.NewMetadataFilter("topic", "=", "programming"),
+		embedding.NewMetadataFilter("year", ">", 2010),
+	)
+	results, err = store.Search(ctx, "language", 10, interfaces.WithFilters(embedding.FilterToMap(filter)))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "rust", results[0].Document.ID)
+
+	results, err = store.Search(ctx, "language", 10, interfaces.WithFilters(map[string]interface{}{"topic": "cooking"}))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "tea", results[0].Document.ID)
+}
+
+func TestStore_HybridAndKeywordSearch(t *testing.T) {
+	ctx := context.Background()
+	embedder := &keywordEmbedder{keywords: []string{"language"}}
+	store := newTestStore(t, WithEmbedder(embedder))
+	require.NoError(t, store.Store(ctx, testDocuments()))
+
+	// Vector scores tie between go and rust; BM25 breaks the tie
+	results, err := store.Search(ctx, "language ownership", 3, interfaces.WithBM25(true), interfaces.WithEmbedding(true))
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, "rust", results[0].Document.ID)
+
+	results, err = store.Search(ctx, "green tea ownership", 3, interfaces.WithKeyword(true))
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "tea", results[0].Document.ID)
+	assert.InDelta(t, 2.0/3.0, results[0].Score, 0.001)
+}
+
+func TestStore_FieldsAndClasses(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	require.NoError(t, store.Store(ctx, testDocuments(), interfaces.WithClass("Articles")))
+
+	results, err := store.Search(ctx, "tea", 10)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	results, err = store.Search(ctx, "tea", 10, func(o *interfaces.SearchOptions) { o.Class = "Articles" }, interfaces.WithFields("topic"))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, map[string]interface{}{"topic": "cooking"}, results[0].Document.Metadata)
+}
+
+func TestStore_Tenants(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	require.NoError(t, store.CreateTenant(ctx, "acme"))
+	require.NoError(t, store.CreateTenant(ctx, "acme"))
+	assert.Error(t, store.CreateTenant(ctx, ""))
+
+	require.NoError(t, store.Store(ctx, testDocuments()[:1], interfaces.WithTenant("acme")))
+	require.NoError(t, store.GlobalStore(ctx, testDocuments()[2:]))
+
+	// Organization ID in the context selects the tenant implicitly
+	orgCtx := multitenancy.WithOrgID(ctx, "globex")
+	require.NoError(t, store.Store(orgCtx, testDocuments()[1:2]))
+
+	tenants, err := store.ListTenants(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acme", "globex"}, tenants)
+
+	results, err := store.Search(ctx, "language", 10, interfaces.WithTenantSearch("acme"))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "go", results[0].Document.ID)
+
+	results, err = store.Search(orgCtx, "language", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "rust", results[0].Document.ID)
+
+	// Global search ignores the tenant in the context
+	results, err = store.GlobalSearch(orgCtx, "tea", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "tea", results[0].Document.ID)
+
+	require.NoError(t, store.GlobalDelete(ctx, []string{"tea"}))
+	assert.Equal(t, 0, store.Count("", ""))
+
+	require.NoError(t, store.DeleteTenant(ctx, "acme"))
+	assert.ErrorIs(t, store.DeleteTenant(ctx, "acme"), ErrTenantNotFound)
+	_, err = store.Get(ctx, "go", interfaces.WithTenant("acme"))
+	assert.ErrorIs(t, err, ErrTenantNotFound)
+}
+
+func TestStore_Snapshot(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "store.json")
+
+	store := newTestStore(t, WithSnapshotPath(path))
+	require.NoError(t, store.CreateTenant(ctx, "acme"))
+	docs := testDocuments()
+	docs[0].Vector = []float32{0.1, 0.2}
+	require.NoError(t, store.Store(ctx, docs, interfaces.WithTenant("acme")))
+
+	reloaded := newTestStore(t, WithSnapshotPath(path))
+	tenants, err := reloaded.ListTenants(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acme"}, tenants)
+
+	doc, err := reloaded.Get(ctx, "go", interfaces.WithTenant("acme"))
+	require.NoError(t, err)
+	assert.Equal(t, docs[0].Content, doc.Content)
+	assert.Equal(t, []float32{0.1, 0.2}, doc.Vector)
+	assert.Equal(t, float64(2009), doc.Metadata["year"])
+}
+
+func TestSimilarityMetric(t *testing.T) {
+	assert.Equal(t, "cosine", similarityMetric(""))
+	assert.Equal(t, "dot_product", similarityMetric("dot"))
+	assert.Equal(t, "euclidean", similarityMetric("euclidean"))
+}
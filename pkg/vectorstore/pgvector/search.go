@@ -0,0 +1,416 @@
+package pgvector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"github.com/tagus/agent-sdk-go/pkg/embedding"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+// distance describes how a VectorStoreConfig metric maps onto pgvector
+type distance struct {
+	// operator is the pgvector distance operator
+	operator string
+
+	// opsClass is the operator class used for HNSW indexes
+	opsClass string
+
+	// score converts a distance expression into a similarity where higher is better
+	score func(expr string) string
+}
+
+// newDistance returns the pgvector distance for a metric name
+func newDistance(metric string) (distance, error) {
+	switch strings.ToLower(metric) {
+	case "cosine", "":
+		return distance{
+			operator: "<=>",
+			opsClass: "vector_cosine_ops",
+			score:    func(expr string) string { return "(1 - (" + expr + "))" },
+		}, nil
+	case "euclidean", "l2":
+		return distance{
+			operator: "<->",
+			opsClass: "vector_l2_ops",
+			score:    func(expr string) string { return "(1 / (1 + (" + expr + ")))" },
+		}, nil
+	case "dot", "dot_product", "inner_product":
+		// <#> returns the negative inner product
+		return distance{
+			operator: "<#>",
+			opsClass: "vector_ip_ops",
+			score:    func(expr string) string { return "(-(" + expr + "))" },
+		}, nil
+	default:
+		return distance{}, fmt.Errorf("unsupported distance metric: %s", metric)
+	}
+}
+
+// queryBuilder accumulates positional query arguments
+type queryBuilder struct {
+	args []interface{}
+}
+
+// arg adds an argument and returns its placeholder
+func (b *queryBuilder) arg(value interface{}) string {
+	b.args = append(b.args, value)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+// scoredDocument is a document with its search score
+type scoredDocument struct {
+	interfaces.Document
+	score float32
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// search runs a vector, text or hybrid query against the tenant's class table
+func (s *Store) search(ctx context.Context, tenant, query string, vector []float32, limit int, opts *interfaces.SearchOptions) ([]interfaces.SearchResult, error) {
+	useVector := vector != nil || opts.UseEmbedding || opts.UseNearText || (!opts.UseBM25 && !opts.UseKeyword && s.embedder != nil)
+	useText := vector == nil && (opts.UseBM25 || opts.UseKeyword || !useVector)
+
+	if useVector && vector == nil {
+		if s.embedder == nil {
+			return nil, fmt.Errorf("cannot embed query: %w", ErrNoEmbedder)
+		}
+		queryVector, err := s.embedder.Embed(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed query: %w", err)
+		}
+		vector = queryVector
+	}
+
+	sqlQuery, args, err := s.searchQuery(tenant, query, vector, useText, limit, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, sqlQuery, args...)
+	if isUndefinedTable(err) {
+		return []interfaces.SearchResult{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to search documents: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	results := []interfaces.SearchResult{}
+	for rows.Next() {
+		doc, err := scanDocument(rows, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		results = append(results, interfaces.SearchResult{
+			Document: selectFields(doc.Document, opts.Fields),
+			Score:    doc.score,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read search results: %w", err)
+	}
+
+	return results, nil
+}
+
+// searchQuery builds the query of a search with a query vector, a text query
+// or both, and returns it with its arguments
+func (s *Store) searchQuery(tenant, query string, vector []float32, useText bool, limit int, opts *interfaces.SearchOptions) (string, []interface{}, error) {
+	_, _, table := s.table(tenant, opts.Class)
+	b := &queryBuilder{}
+	conditions := []string{"tenant = " + b.arg(tenant)}
+
+	var vectorDistance, vectorScore, textScore string
+	useVector := vector != nil
+	if useVector {
+		dist, _ := newDistance(s.config.DistanceMetric)
+		vectorDistance = "embedding " + dist.operator + " " + b.arg(formatVector(vector)) + "::vector"
+		vectorScore = dist.score(vectorDistance)
+		conditions = append(conditions, "embedding IS NOT NULL")
+	}
+	if useText {
+		textConfig := b.arg(s.textConfig) + "::regconfig"
+		// Match any query term, like BM25, rather than requiring all of them
+		tsQuery := fmt.Sprintf("replace(plainto_tsquery(%s, %s)::text, '&', '|')::tsquery", textConfig, b.arg(query))
+		tsVector := fmt.Sprintf("to_tsvector(%s, content)", textConfig)
+		// Normalization flag 32 scales the rank into 0-1
+		textScore = fmt.Sprintf("ts_rank_cd(%s, %s, 32)", tsVector, tsQuery)
+		if !useVector {
+			conditions = append(conditions, tsVector+" @@ "+tsQuery)
+		}
+	}
+
+	var score string
+	switch {
+	case useVector && useText:
+		score = fmt.Sprintf("(%s * %s + %s * COALESCE(%s, 0))",
+			strconv.FormatFloat(float64(s.hybridAlpha), 'f', -1, 32), vectorScore,
+			strconv.FormatFloat(float64(1-s.hybridAlpha), 'f', -1, 32), textScore)
+	case useVector:
+		score = vectorScore
+	default:
+		score = textScore
+	}
+
+	if len(opts.Filters) > 0 {
+		clause, err := buildFilterClause(embedding.FilterFromMap(opts.Filters), b)
+		if err != nil {
+			return "", nil, err
+		}
+		if clause != "" {
+			conditions = append(conditions, clause)
+		}
+	}
+	if opts.MinScore > 0 {
+		conditions = append(conditions, score+" >= "+b.arg(opts.MinScore))
+	}
+
+	// Vector searches order by the bare distance so that PostgreSQL can use
+	// the HNSW index; the score is only computed for the returned rows
+	orderBy := "score DESC, id"
+	if useVector && !useText {
+		orderBy = vectorDistance
+	}
+
+	sqlQuery := fmt.Sprintf( // #nosec G201 - identifiers are quoted and values are parameterized
+		"SELECT id, content, metadata, embedding::text, %s AS score FROM %s WHERE %s ORDER BY %s",
+		score, table, strings.Join(conditions, " AND "), orderBy,
+	)
+	if limit > 0 {
+		sqlQuery += " LIMIT " + b.arg(limit)
+	}
+	return sqlQuery, b.args, nil
+}
+
+// scanDocument scans id, content, metadata, embedding and optionally score columns
+func scanDocument(row rowScanner, withScore bool) (scoredDocument, error) {
+	var (
+		doc         scoredDocument
+		metadata    []byte
+		vectorText  *string
+		scoreColumn float64
+	)
+
+	dest := []interface{}{&doc.ID, &doc.Content, &metadata, &vectorText}
+	if withScore {
+		dest = append(dest, &scoreColumn)
+	}
+	if err := row.Scan(dest...); err != nil {
+		return doc, err
+	}
+
+	if len(metadata) > 0 {
+		if err := json.Unmarshal(metadata, &doc.Metadata); err != nil {
+			return doc, fmt.Errorf("failed to parse metadata: %w", err)
+		}
+	}
+	if vectorText != nil {
+		vector, err := parseVector(*vectorText)
+		if err != nil {
+			return doc, err
+		}
+		doc.Vector = vector
+	}
+	doc.score = float32(scoreColumn)
+
+	return doc, nil
+}
+
+// selectFields restricts document metadata to the requested fields
+func selectFields(doc interfaces.Document, fields []string) interfaces.Document {
+	if len(fields) == 0 || doc.Metadata == nil {
+		return doc
+	}
+	selected := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		if v, ok := doc.Metadata[field]; ok {
+			selected[field] = v
+		}
+	}
+	doc.Metadata = selected
+	return doc
+}
+
+// buildFilterClause translates a metadata filter group into a SQL condition on the metadata column
+func buildFilterClause(group embedding.MetadataFilterGroup, b *queryBuilder) (string, error) {
+	var parts []string
+
+	for _, filter := range group.Filters {
+		clause, err := buildFilterCondition(filter, b)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, clause)
+	}
+
+	for _, subGroup := range group.SubGroups {
+		clause, err := buildFilterClause(subGroup, b)
+		if err != nil {
+			return "", err
+		}
+		if clause != "" {
+			parts = append(parts, clause)
+		}
+	}
+
+	if len(parts) == 0 {
+		return "", nil
+	}
+
+	var joiner string
+	switch strings.ToLower(group.Operator) {
+	case "or":
+		joiner = " OR "
+	case "and", "":
+		joiner = " AND "
+	default:
+		return "", fmt.Errorf("unsupported filter group operator: %s", group.Operator)
+	}
+
+	return "(" + strings.Join(parts, joiner) + ")", nil
+}
+
+// buildFilterCondition translates a single metadata filter into a SQL condition.
+// Nested fields use dot notation and are addressed with a JSON path.
+func buildFilterCondition(filter embedding.MetadataFilter, b *queryBuilder) (string, error) {
+	path := b.arg(pq.Array(strings.Split(filter.Field, ".")))
+	text := "(metadata #>> " + path + ")"
+
+	switch strings.ToLower(filter.Operator) {
+	case "=", "==", "eq":
+		return equalityCondition(text, path, filter.Value, b)
+	case "!=", "<>", "ne":
+		clause, err := equalityCondition(text, path, filter.Value, b)
+		if err != nil {
+			return "", err
+		}
+		// Like ApplyFilters, documents without the field never match, and
+		// values of another type are not equal
+		return "(" + text + " IS NOT NULL AND NOT COALESCE(" + clause + ", false))", nil
+	case ">", "gt", ">=", "gte", "<", "lt", "<=", "lte":
+		operator := comparisonOperator(filter.Operator)
+		if isNumber(filter.Value) {
+			return fmt.Sprintf("%s %s %s", typedValue(text, path, "number", "numeric"), operator, b.arg(filter.Value)), nil
+		}
+		return fmt.Sprintf("%s %s %s", text, operator, b.arg(fmt.Sprintf("%v", filter.Value))), nil
+	case "contains":
+		return fmt.Sprintf("strpos(%s, %s) > 0", text, b.arg(fmt.Sprintf("%v", filter.Value))), nil
+	case "in", "not_in":
+		values := stringValues(filter.Value)
+		clause := fmt.Sprintf("%s = ANY(%s)", text, b.arg(pq.Array(values)))
+		if strings.ToLower(filter.Operator) == "not_in" {
+			return "(" + text + " IS NOT NULL AND NOT " + clause + ")", nil
+		}
+		return clause, nil
+	default:
+		return "", fmt.Errorf("unsupported filter operator: %s", filter.Operator)
+	}
+}
+
+// equalityCondition compares a metadata value using the most precise representation for its type
+func equalityCondition(text, path string, value interface{}, b *queryBuilder) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return text + " = " + b.arg(v), nil
+	case bool:
+		return typedValue(text, path, "boolean", "boolean") + " = " + b.arg(v), nil
+	default:
+		if isNumber(value) {
+			return typedValue(text, path, "number", "numeric") + " = " + b.arg(value), nil
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode filter value: %w", err)
+		}
+		return "(metadata #> " + path + ") = " + b.arg(string(encoded)) + "::jsonb", nil
+	}
+}
+
+// typedValue casts a metadata value to a SQL type when its JSON type matches,
+// and is NULL otherwise. The CASE keeps the cast from being evaluated on
+// values of another type, which would fail the whole query.
+func typedValue(text, path, jsonType, sqlType string) string {
+	return fmt.Sprintf("(CASE WHEN jsonb_typeof(metadata #> %s) = '%s' THEN (%s)::%s END)", path, jsonType, text, sqlType)
+}
+
+// comparisonOperator normalizes comparison operators to SQL
+func comparisonOperator(operator string) string {
+	switch strings.ToLower(operator) {
+	case ">", "gt":
+		return ">"
+	case ">=", "gte":
+		return ">="
+	case "<", "lt":
+		return "<"
+	default:
+		return "<="
+	}
+}
+
+// isNumber reports whether a value is a Go numeric type
+func isNumber(value interface{}) bool {
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// stringValues converts a scalar or collection into the text values stored by JSONB
+func stringValues(value interface{}) []string {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return []string{fmt.Sprintf("%v", value)}
+	}
+
+	values := make([]string, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		values[i] = fmt.Sprintf("%v", v.Index(i).Interface())
+	}
+	return values
+}
+
+// formatVector encodes a vector in pgvector's text format
+func formatVector(vector []float32) string {
+	parts := make([]string, len(vector))
+	for i, v := range vector {
+		parts[i] = strconv.FormatFloat(float64(v), 'f', -1, 32)
+	}
+	return "[" + strings.Join(parts, ",") + "]"
+}
+
+// parseVector decodes pgvector's text format
+func parseVector(text string) ([]float32, error) {
+	text = strings.TrimSpace(text)
+	text = strings.TrimPrefix(text, "[")
+	text = strings.TrimSuffix(text, "]")
+	if text == "" {
+		return []float32{}, nil
+	}
+
+	parts := strings.Split(text, ",")
+	vector := make([]float32, len(parts))
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vector value %q: %w", part, err)
+		}
+		vector[i] = float32(value)
+	}
+	return vector, nil
+}
@@ -0,0 +1,740 @@
+package pgvector
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/tagus/agent-sdk-go/pkg/datastore/postgres"
+	"github.com/tagus/agent-sdk-go/pkg/embedding"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+	"github.com/tagus/agent-sdk-go/pkg/logging"
+	"github.com/tagus/agent-sdk-go/pkg/multitenancy"
+)
+
+// TenantMode controls how tenants are isolated in the database
+type TenantMode string
+
+const (
+	// TenantModeColumn stores all tenants in shared tables, distinguished by a tenant column
+	TenantModeColumn TenantMode = "column"
+
+	// TenantModeSchema stores each tenant in its own PostgreSQL schema
+	TenantModeSchema TenantMode = "schema"
+)
+
+const (
+	// DefaultClass is the class used when no class is specified
+	DefaultClass = "Document"
+
+	// DefaultSchema is the schema used for shared tables
+	DefaultSchema = "public"
+
+	// globalTenant is the tenant value used for shared data and requests without a tenant
+	globalTenant = ""
+
+	// tenantsTable records the tenants known to the store
+	tenantsTable = "vector_tenants"
+
+	// collectionsTable records the class tables created by the store
+	collectionsTable = "vector_collections"
+
+	// defaultHybridAlpha weights vector and text scores equally in hybrid search
+	defaultHybridAlpha = 0.5
+)
+
+var (
+	// ErrTenantNotFound is returned when a tenant does not exist
+	ErrTenantNotFound = errors.New("tenant not found")
+
+	// ErrDocumentNotFound is returned when a document does not exist
+	ErrDocumentNotFound = errors.New("document not found")
+
+	// ErrNoEmbedder is returned when an operation requires an embedder but none is configured
+	ErrNoEmbedder = errors.New("no embedder configured")
+
+	// identifierPattern matches characters that are not allowed in generated identifiers
+	identifierPattern = regexp.MustCompile(`[^a-z0-9_]+`)
+)
+
+// Store implements interfaces.VectorStore on PostgreSQL with the pgvector extension
+type Store struct {
+	db          *sql.DB
+	config      interfaces.VectorStoreConfig
+	embedder    embedding.Client
+	logger      logging.Logger
+	tenantMode  TenantMode
+	schema      string
+	dimensions  int
+	textConfig  string
+	hybridAlpha float32
+
+	// ensured caches tables and tenants that are known to exist
+	ensured  sync.Map
+	ensureMu sync.Mutex
+}
+
+// Option represents an option for configuring the store
+type Option func(*Store)
+
+// WithEmbedder sets the embedding client used to generate document and query vectors
+func WithEmbedder(embedder embedding.Client) Option {
+	return func(s *Store) {
+		s.embedder = embedder
+	}
+}
+
+// WithLogger sets the logger for the store
+func WithLogger(logger logging.Logger) Option {
+	return func(s *Store) {
+		s.logger = logger
+	}
+}
+
+// WithTenantMode sets how tenants are isolated
+func WithTenantMode(mode TenantMode) Option {
+	return func(s *Store) {
+		s.tenantMode = mode
+	}
+}
+
+// WithSchema sets the schema used for shared tables
+func WithSchema(schema string) Option {
+	return func(s *Store) {
+		s.schema = schema
+	}
+}
+
+// WithDimensions fixes the vector dimension of new tables and enables HNSW indexing
+func WithDimensions(dimensions int) Option {
+	return func(s *Store) {
+		s.dimensions = dimensions
+	}
+}
+
+// WithTextSearchConfig sets the PostgreSQL text search configuration used for
+// BM25, keyword and hybrid search (default "english")
+func WithTextSearchConfig(config string) Option {
+	return func(s *Store) {
+		s.textConfig = config
+	}
+}
+
+// WithHybridAlpha sets the weight of the vector score in hybrid search (0-1)
+func WithHybridAlpha(alpha float32) Option {
+	return func(s *Store) {
+		if alpha >= 0 && alpha <= 1 {
+			s.hybridAlpha = alpha
+		}
+	}
+}
+
+// New creates a pgvector store that shares the connection of an existing PostgreSQL client
+func New(client *postgres.Client, config *interfaces.VectorStoreConfig, options ...Option) (*Store, error) {
+	if client == nil {
+		return nil, errors.New("postgres client cannot be nil")
+	}
+	return NewWithDB(client.DB(), config, options...)
+}
+
+// NewFromConnectionString creates a PostgreSQL client and a pgvector store on top of it
+func NewFromConnectionString(connectionString string, config *interfaces.VectorStoreConfig, options ...Option) (*Store, error) {
+	client, err := postgres.New(connectionString)
+	if err != nil {
+		return nil, err
+	}
+	return New(client, config, options...)
+}
+
+// NewWithDB creates a pgvector store with an existing database connection
+func NewWithDB(db *sql.DB, config *interfaces.VectorStoreConfig, options ...Option) (*Store, error) {
+	s := &Store{
+		db:          db,
+		logger:      logging.New(),
+		tenantMode:  TenantModeColumn,
+		schema:      DefaultSchema,
+		textConfig:  "english",
+		hybridAlpha: defaultHybridAlpha,
+	}
+
+	if config != nil {
+		s.config = *config
+	}
+	if s.config.DistanceMetric == "" {
+		s.config.DistanceMetric = "cosine"
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	if _, err := newDistance(s.config.DistanceMetric); err != nil {
+		return nil, err
+	}
+	if s.tenantMode != TenantModeColumn && s.tenantMode != TenantModeSchema {
+		return nil, fmt.Errorf("unsupported tenant mode: %s", s.tenantMode)
+	}
+
+	if err := s.ensureBase(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Store stores documents in the tenant resolved from the options or context
+func (s *Store) Store(ctx context.Context, documents []interfaces.Document, options ...interfaces.StoreOption) error {
+	opts := applyStoreOptions(options)
+	return s.store(ctx, resolveTenant(ctx, opts.Tenant), documents, opts)
+}
+
+// GlobalStore stores documents in the shared namespace, ignoring tenant context
+func (s *Store) GlobalStore(ctx context.Context, documents []interfaces.Document, options ...interfaces.StoreOption) error {
+	return s.store(ctx, globalTenant, documents, applyStoreOptions(options))
+}
+
+// Get retrieves a document by ID
+func (s *Store) Get(ctx context.Context, id string, options ...interfaces.StoreOption) (*interfaces.Document, error) {
+	opts := applyStoreOptions(options)
+	tenant := resolveTenant(ctx, opts.Tenant)
+
+	_, _, table := s.table(tenant, opts.Class)
+	query := fmt.Sprintf( // #nosec G201 - table name is quoted
+		"SELECT id, content, metadata, embedding::text FROM %s WHERE tenant = $1 AND id = $2",
+		table,
+	)
+
+	doc, err := scanDocument(s.db.QueryRowContext(ctx, query, tenant, id), false)
+	if errors.Is(err, sql.ErrNoRows) || isUndefinedTable(err) {
+		return nil, fmt.Errorf("%w: %s", ErrDocumentNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+
+	return &doc.Document, nil
+}
+
+// Search searches for documents matching the query in the resolved tenant
+func (s *Store) Search(ctx context.Context, query string, limit int, options ...interfaces.SearchOption) ([]interfaces.SearchResult, error) {
+	opts := applySearchOptions(options)
+	return s.search(ctx, resolveTenant(ctx, opts.Tenant), query, nil, limit, opts)
+}
+
+// SearchByVector searches for documents similar to the vector in the resolved tenant
+func (s *Store) SearchByVector(ctx context.Context, vector []float32, limit int, options ...interfaces.SearchOption) ([]interfaces.SearchResult, error) {
+	opts := applySearchOptions(options)
+	return s.search(ctx, resolveTenant(ctx, opts.Tenant), "", vector, limit, opts)
+}
+
+// GlobalSearch searches the shared namespace, ignoring tenant context
+func (s *Store) GlobalSearch(ctx context.Context, query string, limit int, options ...interfaces.SearchOption) ([]interfaces.SearchResult, error) {
+	return s.search(ctx, globalTenant, query, nil, limit, applySearchOptions(options))
+}
+
+// GlobalSearchByVector searches the shared namespace by vector, ignoring tenant context
+func (s *Store) GlobalSearchByVector(ctx context.Context, vector []float32, limit int, options ...interfaces.SearchOption) ([]interfaces.SearchResult, error) {
+	return s.search(ctx, globalTenant, "", vector, limit, applySearchOptions(options))
+}
+
+// Delete deletes documents by ID from the resolved tenant
+func (s *Store) Delete(ctx context.Context, ids []string, options ...interfaces.DeleteOption) error {
+	opts := applyDeleteOptions(options)
+	return s.delete(ctx, resolveTenant(ctx, opts.Tenant), ids, opts)
+}
+
+// GlobalDelete deletes documents by ID from the shared namespace
+func (s *Store) GlobalDelete(ctx context.Context, ids []string, options ...interfaces.DeleteOption) error {
+	return s.delete(ctx, globalTenant, ids, applyDeleteOptions(options))
+}
+
+// CreateTenant creates a tenant. Creating an existing tenant is a no-op.
+func (s *Store) CreateTenant(ctx context.Context, tenantName string) error {
+	if tenantName == "" {
+		return errors.New("tenant name cannot be empty")
+	}
+	return s.ensureTenant(ctx, tenantName)
+}
+
+// DeleteTenant deletes a tenant and all of its documents
+func (s *Store) DeleteTenant(ctx context.Context, tenantName string) error {
+	if tenantName == "" {
+		return errors.New("tenant name cannot be empty")
+	}
+
+	s.ensureMu.Lock()
+	defer s.ensureMu.Unlock()
+
+	return s.transaction(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, fmt.Sprintf( // #nosec G201 - table name is quoted
+			"DELETE FROM %s WHERE name = $1", s.qualified(s.schema, tenantsTable),
+		), tenantName)
+		if err != nil {
+			return fmt.Errorf("failed to delete tenant: %w", err)
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return fmt.Errorf("%w: %s", ErrTenantNotFound, tenantName)
+		}
+
+		if s.tenantMode == TenantModeSchema {
+			schema := s.tenantSchema(tenantName)
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE", pq.QuoteIdentifier(schema))); err != nil {
+				return fmt.Errorf("failed to drop tenant schema: %w", err)
+			}
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf( // #nosec G201 - table name is quoted
+				"DELETE FROM %s WHERE schema_name = $1", s.qualified(s.schema, collectionsTable),
+			), schema); err != nil {
+				return fmt.Errorf("failed to unregister tenant collections: %w", err)
+			}
+		} else {
+			tables, err := s.collectionTables(ctx, tx, s.schema)
+			if err != nil {
+				return err
+			}
+			for _, table := range tables {
+				if _, err := tx.ExecContext(ctx, fmt.Sprintf( // #nosec G201 - table name is quoted
+					"DELETE FROM %s WHERE tenant = $1", s.qualified(s.schema, table),
+				), tenantName); err != nil {
+					return fmt.Errorf("failed to delete tenant documents: %w", err)
+				}
+			}
+		}
+
+		s.forgetTenant(tenantName)
+		return nil
+	})
+}
+
+// ListTenants returns the names of all tenants in sorted order
+func (s *Store) ListTenants(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf( // #nosec G201 - table name is quoted
+		"SELECT name FROM %s ORDER BY name", s.qualified(s.schema, tenantsTable),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	tenants := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan tenant: %w", err)
+		}
+		tenants = append(tenants, name)
+	}
+
+	return tenants, rows.Err()
+}
+
+// store generates missing vectors and upserts documents into the tenant's class table
+func (s *Store) store(ctx context.Context, tenant string, documents []interfaces.Document, opts *interfaces.StoreOptions) error {
+	if len(documents) == 0 {
+		return nil
+	}
+
+	docs := make([]interfaces.Document, len(documents))
+	copy(docs, documents)
+	for i := range docs {
+		if docs[i].ID == "" {
+			docs[i].ID = uuid.New().String()
+		}
+	}
+
+	if err := s.generateVectors(ctx, docs, opts); err != nil {
+		return err
+	}
+
+	if tenant != globalTenant {
+		if err := s.ensureTenant(ctx, tenant); err != nil {
+			return err
+		}
+	}
+
+	table, err := s.ensureTable(ctx, tenant, opts.Class)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf( // #nosec G201 - table name is quoted
+		`INSERT INTO %s (tenant, id, content, metadata, embedding)
+		VALUES ($1, $2, $3, $4, $5::vector)
+		ON CONFLICT (tenant, id) DO UPDATE
+		SET content = EXCLUDED.content, metadata = EXCLUDED.metadata, embedding = EXCLUDED.embedding`,
+		table,
+	)
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = len(docs)
+	}
+
+	for start := 0; start < len(docs); start += batchSize {
+		end := start + batchSize
+		if end > len(docs) {
+			end = len(docs)
+		}
+
+		err := s.transaction(ctx, func(tx *sql.Tx) error {
+			stmt, err := tx.PrepareContext(ctx, query)
+			if err != nil {
+				return fmt.Errorf("failed to prepare insert: %w", err)
+			}
+			defer func() {
+				_ = stmt.Close()
+			}()
+
+			for _, doc := range docs[start:end] {
+				metadata, err := json.Marshal(doc.Metadata)
+				if err != nil {
+					return fmt.Errorf("failed to serialize metadata for document %s: %w", doc.ID, err)
+				}
+				if doc.Metadata == nil {
+					metadata = []byte("{}")
+				}
+
+				var vector interface{}
+				if len(doc.Vector) > 0 {
+					vector = formatVector(doc.Vector)
+				}
+
+				if _, err := stmt.ExecContext(ctx, tenant, doc.ID, doc.Content, string(metadata), vector); err != nil {
+					return fmt.Errorf("failed to store document %s: %w", doc.ID, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// generateVectors fills in vectors for documents that lack one. When
+// GenerateVectors is set, every document is re-embedded and an embedder is required.
+func (s *Store) generateVectors(ctx context.Context, docs []interfaces.Document, opts *interfaces.StoreOptions) error {
+	if s.embedder == nil {
+		if opts.GenerateVectors {
+			return fmt.Errorf("cannot generate vectors: %w", ErrNoEmbedder)
+		}
+		return nil
+	}
+
+	var pending []int
+	var texts []string
+	for i, doc := range docs {
+		if opts.GenerateVectors || len(doc.Vector) == 0 {
+			pending = append(pending, i)
+			texts = append(texts, doc.Content)
+		}
+	}
+	if len(texts) == 0 {
+		return nil
+	}
+
+	vectors, err := s.embedder.EmbedBatch(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("failed to generate vectors: %w", err)
+	}
+	if len(vectors) != len(texts) {
+		return fmt.Errorf("embedder returned %d vectors for %d documents", len(vectors), len(texts))
+	}
+	for i, idx := range pending {
+		docs[idx].Vector = vectors[i]
+	}
+
+	return nil
+}
+
+// delete removes documents from the tenant's class table
+func (s *Store) delete(ctx context.Context, tenant string, ids []string, opts *interfaces.DeleteOptions) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, _, table := s.table(tenant, opts.Class)
+	query := fmt.Sprintf("DELETE FROM %s WHERE tenant = $1 AND id = ANY($2)", table) // #nosec G201 - table name is quoted
+	if _, err := s.db.ExecContext(ctx, query, tenant, pq.Array(ids)); err != nil && !isUndefinedTable(err) {
+		return fmt.Errorf("failed to delete documents: %w", err)
+	}
+
+	return nil
+}
+
+// ensureBase creates the extension and bookkeeping tables
+func (s *Store) ensureBase(ctx context.Context) error {
+	statements := []string{
+		"CREATE EXTENSION IF NOT EXISTS vector",
+		fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", pq.QuoteIdentifier(s.schema)),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			name TEXT PRIMARY KEY,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`, s.qualified(s.schema, tenantsTable)),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			schema_name TEXT NOT NULL,
+			table_name TEXT NOT NULL,
+			PRIMARY KEY (schema_name, table_name)
+		)`, s.qualified(s.schema, collectionsTable)),
+	}
+
+	for _, statement := range statements {
+		if _, err := s.db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to initialize pgvector store: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ensureTenant registers a tenant and creates its schema when using schema isolation
+func (s *Store) ensureTenant(ctx context.Context, tenant string) error {
+	key := "tenant:" + tenant
+	if _, ok := s.ensured.Load(key); ok {
+		return nil
+	}
+
+	s.ensureMu.Lock()
+	defer s.ensureMu.Unlock()
+
+	if s.tenantMode == TenantModeSchema {
+		if _, err := s.db.ExecContext(ctx, fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", pq.QuoteIdentifier(s.tenantSchema(tenant)))); err != nil {
+			return fmt.Errorf("failed to create tenant schema: %w", err)
+		}
+	}
+
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf( // #nosec G201 - table name is quoted
+		"INSERT INTO %s (name) VALUES ($1) ON CONFLICT (name) DO NOTHING", s.qualified(s.schema, tenantsTable),
+	), tenant); err != nil {
+		return fmt.Errorf("failed to create tenant: %w", err)
+	}
+
+	s.ensured.Store(key, true)
+	return nil
+}
+
+// table returns the schema, table name and quoted qualified name of the
+// table of a tenant and class
+func (s *Store) table(tenant, class string) (string, string, string) {
+	schema := s.schema
+	if s.tenantMode == TenantModeSchema && tenant != globalTenant {
+		schema = s.tenantSchema(tenant)
+	}
+	table := s.tableName(class)
+	return schema, table, s.qualified(schema, table)
+}
+
+// ensureTable creates the table for a tenant and class if needed and returns
+// its quoted name. Only writes create tables: reads and deletes treat a
+// missing table as empty, see isUndefinedTable.
+func (s *Store) ensureTable(ctx context.Context, tenant, class string) (string, error) {
+	schema, table, qualified := s.table(tenant, class)
+
+	key := "table:" + schema + "." + table
+	if _, ok := s.ensured.Load(key); ok {
+		return qualified, nil
+	}
+
+	s.ensureMu.Lock()
+	defer s.ensureMu.Unlock()
+
+	if schema != s.schema {
+		if _, err := s.db.ExecContext(ctx, fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", pq.QuoteIdentifier(schema))); err != nil {
+			return "", fmt.Errorf("failed to create schema: %w", err)
+		}
+	}
+
+	vectorType := "vector"
+	if s.dimensions > 0 {
+		vectorType = fmt.Sprintf("vector(%d)", s.dimensions)
+	}
+
+	statements := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			tenant TEXT NOT NULL DEFAULT '',
+			id TEXT NOT NULL,
+			content TEXT NOT NULL,
+			metadata JSONB NOT NULL DEFAULT '{}'::jsonb,
+			embedding %s,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			PRIMARY KEY (tenant, id)
+		)`, qualified, vectorType),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s USING gin (metadata)",
+			pq.QuoteIdentifier(table+"_metadata_idx"), qualified),
+	}
+
+	// HNSW indexes require a fixed dimension
+	if s.dimensions > 0 {
+		distance, _ := newDistance(s.config.DistanceMetric)
+		statements = append(statements, fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s USING hnsw (embedding %s)",
+			pq.QuoteIdentifier(table+"_embedding_idx"), qualified, distance.opsClass))
+	}
+
+	statements = append(statements, fmt.Sprintf(
+		"INSERT INTO %s (schema_name, table_name) VALUES (%s, %s) ON CONFLICT DO NOTHING",
+		s.qualified(s.schema, collectionsTable), pq.QuoteLiteral(schema), pq.QuoteLiteral(table),
+	))
+
+	for _, statement := range statements {
+		if _, err := s.db.ExecContext(ctx, statement); err != nil {
+			return "", fmt.Errorf("failed to create table %s: %w", qualified, err)
+		}
+	}
+
+	s.ensured.Store(key, true)
+	return qualified, nil
+}
+
+// forgetTenant drops cached state for a deleted tenant
+func (s *Store) forgetTenant(tenant string) {
+	s.ensured.Delete("tenant:" + tenant)
+	if s.tenantMode == TenantModeSchema {
+		prefix := "table:" + s.tenantSchema(tenant) + "."
+		s.ensured.Range(func(key, _ interface{}) bool {
+			if strings.HasPrefix(key.(string), prefix) {
+				s.ensured.Delete(key)
+			}
+			return true
+		})
+	}
+}
+
+// collectionTables lists the class tables registered in a schema
+func (s *Store) collectionTables(ctx context.Context, tx *sql.Tx, schema string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf( // #nosec G201 - table name is quoted
+		"SELECT table_name FROM %s WHERE schema_name = $1", s.qualified(s.schema, collectionsTable),
+	), schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return nil, fmt.Errorf("failed to scan collection: %w", err)
+		}
+		tables = append(tables, table)
+	}
+
+	return tables, rows.Err()
+}
+
+// transaction runs fn in a transaction, rolling back on error
+func (s *Store) transaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("transaction failed with error: %v, rollback failed with error: %w", err, rbErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// tableName converts a class name into a safe table name
+func (s *Store) tableName(class string) string {
+	if class == "" {
+		class = DefaultClass
+	}
+	return sanitizeIdentifier(s.config.ClassPrefix + class)
+}
+
+// tenantSchema returns the schema name for a tenant in schema isolation mode.
+// Sanitizing alone would map tenants such as "acme-1" and "acme_1" to the same
+// schema, so the name ends with a hash of the exact tenant name and keeps a
+// readable prefix for operators.
+func (s *Store) tenantSchema(tenant string) string {
+	sum := sha256.Sum256([]byte(tenant))
+	suffix := "_" + hex.EncodeToString(sum[:8])
+	readable := sanitizeIdentifier("tenant_" + tenant)
+	if len(readable) > 63-len(suffix) {
+		readable = readable[:63-len(suffix)]
+	}
+	return readable + suffix
+}
+
+// isUndefinedTable reports whether a query failed because its table or
+// schema has not been created yet
+func isUndefinedTable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == "42P01" || pqErr.Code == "3F000"
+}
+
+// qualified returns a quoted schema-qualified table name
+func (s *Store) qualified(schema, table string) string {
+	return pq.QuoteIdentifier(schema) + "." + pq.QuoteIdentifier(table)
+}
+
+// sanitizeIdentifier lowercases a name and replaces unsupported characters with underscores
+func sanitizeIdentifier(name string) string {
+	name = identifierPattern.ReplaceAllString(strings.ToLower(name), "_")
+	// PostgreSQL truncates identifiers to 63 bytes
+	if len(name) > 63 {
+		name = name[:63]
+	}
+	return name
+}
+
+// resolveTenant determines the tenant for an operation. An explicit tenant wins,
+// then the organization ID from the context, then the shared namespace.
+func resolveTenant(ctx context.Context, tenant string) string {
+	if tenant != "" {
+		return tenant
+	}
+	if orgID, err := multitenancy.GetOrgID(ctx); err == nil {
+		return orgID
+	}
+	return globalTenant
+}
+
+func applyStoreOptions(options []interfaces.StoreOption) *interfaces.StoreOptions {
+	opts := &interfaces.StoreOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+	return opts
+}
+
+func applySearchOptions(options []interfaces.SearchOption) *interfaces.SearchOptions {
+	opts := &interfaces.SearchOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+	return opts
+}
+
+func applyDeleteOptions(options []interfaces.DeleteOption) *interfaces.DeleteOptions {
+	opts := &interfaces.DeleteOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+	return opts
+}
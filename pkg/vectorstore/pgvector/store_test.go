@@ -0,0 +1,225 @@
+package pgvector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tagus/agent-sdk-go/pkg/embedding"
+	"github.com/tagus/agent-sdk-go/pkg/interfaces"
+)
+
+func TestBuildFilterClause(t *testing.T) {
+	t.Run("and group with typed values", func(t *testing.T) {
+		b := &queryBuilder{}
+		group := embedding.NewMetadataFilterGroup("and",
+			embedding.NewMetadataFilter("category", "=", "science"),
+			embedding.NewMetadataFilter("words", ">", 10),
+			embedding.NewMetadataFilter("published", "=", true),
+		)
+
+		clause, err := buildFilterClause(group, b)
+		require.NoError(t, err)
+		assert.Equal(t, "((metadata #>> $1) = $2"+
+			" AND (CASE WHEN jsonb_typeof(metadata #> $3) = 'number' THEN ((metadata #>> $3))::numeric END) > $4"+
+			" AND (CASE WHEN jsonb_typeof(metadata #> $5) = 'boolean' THEN ((metadata #>> $5))::boolean END) = $6)", clause)
+		require.Len(t, b.args, 6)
+		assert.Equal(t, "science", b.args[1])
+		assert.Equal(t, 10, b.args[3])
+	})
+
+	t.Run("nested or group from filter map", func(t *testing.T) {
+		b := &queryBuilder{}
+		group := embedding.NewMetadataFilterGroup("or", embedding.NewMetadataFilter("author.name", "contains", "Doe"))
+		group.AddSubGroup(embedding.NewMetadataFilterGroup("and", embedding.NewMetadataFilter("tag", "not_in", []string{"a", "b"})))
+
+		clause, err := buildFilterClause(embedding.FilterFromMap(embedding.FilterToMap(group)), b)
+		require.NoError(t, err)
+		assert.Contains(t, clause, "strpos((metadata #>> $")
+		assert.Contains(t, clause, " OR ")
+		assert.Contains(t, clause, "IS NOT NULL AND NOT")
+	})
+
+	t.Run("unsupported operator", func(t *testing.T) {
+		_, err := buildFilterClause(embedding.NewMetadataFilterGroup("and", embedding.NewMetadataFilter("a", "~", 1)), &queryBuilder{})
+		assert.Error(t, err)
+	})
+}
+
+func TestVectorFormat(t *testing.T) {
+	text := formatVector([]float32{0.5, -1, 2.25})
+	assert.Equal(t, "[0.5,-1,2.25]", text)
+
+	vector, err := parseVector(text)
+	require.NoError(t, err)
+	assert.Equal(t, []float32{0.5, -1, 2.25}, vector)
+
+	_, err = parseVector("[a]")
+	assert.Error(t, err)
+}
+
+func TestNewDistance(t *testing.T) {
+	for _, metric := range []string{"", "cosine", "euclidean", "dot"} {
+		_, err := newDistance(metric)
+		assert.NoError(t, err, metric)
+	}
+
+	d, err := newDistance("dot")
+	require.NoError(t, err)
+	assert.Equal(t, "<#>", d.operator)
+	assert.Equal(t, "(-(x))", d.score("x"))
+
+	_, err = newDistance("manhattan")
+	assert.Error(t, err)
+}
+
+func TestSearchQuery(t *testing.T) {
+	s := &Store{schema: DefaultSchema, textConfig: "english", hybridAlpha: 0.5}
+
+	t.Run("vector search orders by distance", func(t *testing.T) {
+		query, args, err := s.searchQuery("acme", "", []float32{1, 0}, false, 5, &interfaces.SearchOptions{MinScore: 0.2})
+		require.NoError(t, err)
+		assert.Contains(t, query, "(1 - (embedding <=> $2::vector)) AS score")
+		assert.True(t, strings.HasSuffix(query, "ORDER BY embedding <=> $2::vector LIMIT $4"), query)
+		assert.Equal(t, []interface{}{"acme", "[1,0]", float32(0.2), 5}, args)
+	})
+
+	t.Run("text and hybrid searches order by score", func(t *testing.T) {
+		query, _, err := s.searchQuery("acme", "tea", nil, true, 5, &interfaces.SearchOptions{})
+		require.NoError(t, err)
+		assert.Contains(t, query, "ORDER BY score DESC, id LIMIT")
+
+		query, _, err = s.searchQuery("acme", "tea", []float32{1, 0}, true, 5, &interfaces.SearchOptions{})
+		require.NoError(t, err)
+		assert.Contains(t, query, "ORDER BY score DESC, id LIMIT")
+	})
+}
+
+func TestSanitizeIdentifier(t *testing.T) {
+	assert.Equal(t, "agent_docs", sanitizeIdentifier("Agent-Docs"))
+	assert.Equal(t, "tenant_acme_corp", sanitizeIdentifier("tenant_acme corp"))
+	assert.Len(t, sanitizeIdentifier(strings.Repeat("a", 100)), 63)
+}
+
+func TestTenantSchema(t *testing.T) {
+	s := &Store{}
+
+	schema := s.tenantSchema("Acme Corp")
+	assert.Regexp(t, `^tenant_acme_corp_[0-9a-f]{16}$`, schema)
+	assert.Equal(t, schema, s.tenantSchema("Acme Corp"))
+
+	// Names that sanitize to the same identifier get distinct schemas
+	long := strings.Repeat("a", 100)
+	for _, pair := range [][2]string{{"acme-1", "acme_1"}, {"ACME", "acme"}, {long + "1", long + "2"}} {
+		assert.NotEqual(t, s.tenantSchema(pair[0]), s.tenantSchema(pair[1]), "%q and %q", pair[0], pair[1])
+	}
+	assert.Len(t, s.tenantSchema(long), 63)
+}
+
+func TestIsUndefinedTable(t *testing.T) {
+	assert.True(t, isUndefinedTable(fmt.Errorf("query: %w", &pq.Error{Code: "42P01"})))
+	assert.True(t, isUndefinedTable(&pq.Error{Code: "3F000"}))
+	assert.False(t, isUndefinedTable(&pq.Error{Code: "42501"}))
+	assert.False(t, isUndefinedTable(errors.New("connection refused")))
+	assert.False(t, isUndefinedTable(nil))
+}
+
+func setupTestStore(t *testing.T, options ...Option) *Store {
+	dbURL := os.Getenv("POSTGRES_URL")
+	if dbURL == "" {
+		t.Skip("POSTGRES_URL environment variable not set")
+	}
+
+	store, err := NewFromConnectionString(dbURL, &interfaces.VectorStoreConfig{ClassPrefix: "Test"}, options...)
+	if err != nil {
+		t.Fatalf("Failed to create pgvector store: %v", err)
+	}
+	return store
+}
+
+func TestStoreAndSearch(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+
+	docs := []interfaces.Document{
+		{ID: "a", Content: "Go has goroutines", Vector: []float32{1, 0}, Metadata: map[string]interface{}{"topic": "go"}},
+		{ID: "b", Content: "Tea is brewed", Vector: []float32{0, 1}, Metadata: map[string]interface{}{"topic": "tea"}},
+	}
+	require.NoError(t, store.Store(ctx, docs, interfaces.WithTenant("pgvector-test")))
+	defer func() {
+		_ = store.DeleteTenant(ctx, "pgvector-test")
+	}()
+
+	results, err := store.SearchByVector(ctx, []float32{1, 0}, 1, interfaces.WithTenantSearch("pgvector-test"))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "a", results[0].Document.ID)
+
+	results, err = store.Search(ctx, "tea", 5, interfaces.WithTenantSearch("pgvector-test"), interfaces.WithBM25(true))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "b", results[0].Document.ID)
+
+	tenants, err := store.ListTenants(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, tenants, "pgvector-test")
+}
+
+func TestSearchWithMismatchedValueTypes(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+
+	docs := []interfaces.Document{
+		{ID: "a", Content: "Typed values", Vector: []float32{1, 0}, Metadata: map[string]interface{}{"words": 12, "published": true}},
+		{ID: "b", Content: "Text values", Vector: []float32{1, 0}, Metadata: map[string]interface{}{"words": "many", "published": "yes"}},
+	}
+	require.NoError(t, store.Store(ctx, docs, interfaces.WithTenant("pgvector-types")))
+	defer func() {
+		_ = store.DeleteTenant(ctx, "pgvector-types")
+	}()
+
+	search := func(filter embedding.MetadataFilter) []string {
+		group := embedding.NewMetadataFilterGroup("and", filter)
+		results, err := store.SearchByVector(ctx, []float32{1, 0}, 5,
+			interfaces.WithTenantSearch("pgvector-types"), interfaces.WithFilters(embedding.FilterToMap(group)))
+		require.NoError(t, err)
+		var ids []string
+		for _, result := range results {
+			ids = append(ids, result.Document.ID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []string{"a"}, search(embedding.NewMetadataFilter("words", ">", 10)))
+	assert.Equal(t, []string{"a"}, search(embedding.NewMetadataFilter("words", "=", 12)))
+	assert.Equal(t, []string{"a"}, search(embedding.NewMetadataFilter("published", "=", true)))
+	assert.Equal(t, []string{"b"}, search(embedding.NewMetadataFilter("published", "!=", true)))
+}
+
+func TestReadsDoNotCreateTables(t *testing.T) {
+	store := setupTestStore(t, WithTenantMode(TenantModeSchema))
+	ctx := context.Background()
+	tenant := "pgvector-unknown-tenant"
+
+	_, err := store.Get(ctx, "a", interfaces.WithTenant(tenant))
+	assert.ErrorIs(t, err, ErrDocumentNotFound)
+
+	results, err := store.SearchByVector(ctx, []float32{1, 0}, 1, interfaces.WithTenantSearch(tenant))
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	require.NoError(t, store.Delete(ctx, []string{"a"}, interfaces.WithTenantDelete(tenant)))
+
+	var exists bool
+	require.NoError(t, store.db.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM information_schema.schemata WHERE schema_name = $1)", store.tenantSchema(tenant),
+	).Scan(&exists))
+	assert.False(t, exists, "reads must not create the tenant schema")
+}